@@ -17,7 +17,10 @@ import (
 	"go-transaction-service/internal/delivery/http/middleware"
 	"go-transaction-service/internal/infrastructure/database"
 	"go-transaction-service/internal/infrastructure/external"
+	"go-transaction-service/internal/infrastructure/keyring"
+	"go-transaction-service/internal/infrastructure/storage"
 	"go-transaction-service/internal/usecase"
+	"go-transaction-service/internal/worker"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 
@@ -76,6 +79,20 @@ func main() {
 	// Initialize repositories
 	userRepo := database.NewPostgresUserRepository(db.DB)
 	transactionRepo := database.NewPostgresTransactionRepository(db.DB)
+	ledgerRepo := database.NewPostgresLedgerRepository(db.DB)
+	txManager := database.NewPostgresTxManager(db.DB)
+	idempotencyRepo := database.NewPostgresIdempotencyRepository(db.DB)
+	paymentCallbackRepo := database.NewPostgresPaymentCallbackRepository(db.DB)
+	refreshTokenRepo := database.NewPostgresRefreshTokenRepository(db.DB)
+	revokedTokenRepo := database.NewPostgresRevokedTokenRepository(db.DB)
+	mfaRepo := database.NewPostgresMFARepository(db.DB)
+	accountTokenRepo := database.NewPostgresAccountTokenRepository(db.DB)
+	securityEventRepo := database.NewPostgresSecurityEventRepository(db.DB)
+	sessionRepo := database.NewPostgresSessionRepository(db.DB)
+	apiKeyRepo := database.NewPostgresAPIKeyRepository(db.DB)
+	feeRuleRepo := database.NewPostgresFeeRuleRepository(db.DB)
+	userLimitRepo := database.NewPostgresUserLimitRepository(db.DB)
+	kycSubmissionRepo := database.NewPostgresKYCSubmissionRepository(db.DB)
 
 	// Initialize external services
 	var paymentGateway usecase.PaymentGateway
@@ -88,22 +105,57 @@ func main() {
 		logger.Info("Using mock payment gateway for development")
 	}
 
+	mailer := external.NewMailer(cfg.Mail, logger)
+	logger.Info("Using mail driver", zap.String("driver", cfg.Mail.Driver))
+
+	kycBlobStore := storage.NewLocalBlobStore(cfg.KYC.StorageDir)
+	logger.Info("Storing KYC documents on the local filesystem", zap.String("dir", cfg.KYC.StorageDir))
+
+	// Load the JWT signing keys
+	tokenKeyring, err := keyring.NewFileKeyring(cfg.JWT, logger)
+	if err != nil {
+		logger.Fatal("Failed to load JWT signing keys", zap.Error(err))
+	}
+
 	// Initialize use cases
-	authUseCase := usecase.NewAuthUseCase(userRepo, cfg)
-	transactionUseCase := usecase.NewTransactionUseCase(transactionRepo, userRepo, paymentGateway)
+	authUseCase := usecase.NewAuthUseCase(userRepo, refreshTokenRepo, revokedTokenRepo, mfaRepo, securityEventRepo, sessionRepo, txManager, tokenKeyring, cfg)
+	feeUseCase := usecase.NewFeeUseCase(feeRuleRepo, cfg)
+	limitUseCase := usecase.NewLimitUseCase(userLimitRepo, userRepo, transactionRepo, cfg)
+	transactionUseCase := usecase.NewTransactionUseCase(transactionRepo, userRepo, ledgerRepo, txManager, paymentGateway, feeUseCase, limitUseCase, cfg)
+	adminUseCase := usecase.NewAdminUseCase(userRepo, refreshTokenRepo, mfaRepo, securityEventRepo, txManager)
+	mfaUseCase := usecase.NewMFAUseCase(userRepo, mfaRepo, txManager, cfg)
+	pinUseCase := usecase.NewPINUseCase(userRepo, mfaRepo, txManager, cfg)
+	accountUseCase := usecase.NewAccountUseCase(userRepo, accountTokenRepo, refreshTokenRepo, txManager, mailer, cfg, logger)
+	profileUseCase := usecase.NewProfileUseCase(userRepo, transactionRepo, refreshTokenRepo, txManager)
+	sessionUseCase := usecase.NewSessionUseCase(sessionRepo, refreshTokenRepo, txManager)
+	apiKeyUseCase := usecase.NewAPIKeyUseCase(apiKeyRepo, userRepo, cfg)
+	idempotencyUseCase := usecase.NewIdempotencyUseCase(idempotencyRepo)
+	kycUseCase := usecase.NewKYCUseCase(kycSubmissionRepo, userRepo, txManager, kycBlobStore, cfg)
+	paymentCallbackUseCase := usecase.NewPaymentCallbackUseCase(paymentCallbackRepo, transactionRepo, transactionUseCase, cfg.Midtrans.ServerKey)
 
 	// Initialize validator with custom validation rules
 	validator := initValidator()
 
 	// Initialize handlers
-	authHandler := handlers.NewAuthHandler(authUseCase, validator, logger)
-	transactionHandler := handlers.NewTransactionHandler(transactionUseCase, validator, logger)
+	authHandler := handlers.NewAuthHandler(authUseCase, accountUseCase, validator, logger)
+	accountHandler := handlers.NewAccountHandler(accountUseCase, validator, logger)
+	transactionHandler := handlers.NewTransactionHandler(transactionUseCase, paymentCallbackUseCase, validator, logger)
+	adminHandler := handlers.NewAdminHandler(adminUseCase, transactionUseCase, validator, logger)
+	mfaHandler := handlers.NewMFAHandler(mfaUseCase, validator, logger)
+	pinHandler := handlers.NewPINHandler(pinUseCase, validator, logger)
+	profileHandler := handlers.NewProfileHandler(profileUseCase, accountUseCase, validator, logger)
+	sessionHandler := handlers.NewSessionHandler(sessionUseCase, logger)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyUseCase, validator, logger)
+	feeHandler := handlers.NewFeeHandler(feeUseCase, validator, logger)
+	limitHandler := handlers.NewLimitHandler(limitUseCase, logger)
+	kycHandler := handlers.NewKYCHandler(kycUseCase, cfg.KYC.MaxDocumentSize, validator, logger)
 
 	// Initialize middleware
-	authMiddleware := middleware.NewAuthMiddleware(authUseCase, logger)
+	authMiddleware := middleware.NewAuthMiddleware(authUseCase, apiKeyUseCase, logger)
+	idempotencyMiddleware := middleware.NewIdempotencyMiddleware(idempotencyUseCase, logger)
 
 	// Initialize router
-	router := httpdelivery.NewRouter(authHandler, transactionHandler, authMiddleware)
+	router := httpdelivery.NewRouter(authHandler, accountHandler, transactionHandler, adminHandler, mfaHandler, pinHandler, profileHandler, sessionHandler, apiKeyHandler, feeHandler, limitHandler, kycHandler, authMiddleware, idempotencyMiddleware)
 	router.SetupRoutes()
 
 	// Configure HTTP server
@@ -114,6 +166,21 @@ func main() {
 		IdleTimeout:  120 * time.Second,
 	}
 
+	// Start background workers
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	if cfg.Reconciler.Enabled {
+		reconciler := worker.NewReconciler(transactionUseCase, cfg.Reconciler, logger)
+		go reconciler.Start(workerCtx)
+	}
+
+	expirer := worker.NewExpirer(transactionUseCase, cfg.Expiry, logger)
+	go expirer.Start(workerCtx)
+
+	keyRotator := worker.NewKeyRotator(tokenKeyring, cfg.JWT, logger)
+	go keyRotator.Start(workerCtx)
+
 	// Start server in goroutine
 	go func() {
 		logger.Info("Starting HTTP server", 
@@ -134,6 +201,9 @@ func main() {
 
 	logger.Info("Shutting down server...")
 
+	// Stop background workers before the server
+	stopWorkers()
+
 	// Graceful shutdown with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
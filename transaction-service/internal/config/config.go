@@ -3,16 +3,31 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/shopspring/decimal"
 )
 
 type Config struct {
-	App      AppConfig
-	Database DatabaseConfig
-	JWT      JWTConfig
-	Midtrans MidtransConfig
+	App        AppConfig
+	Database   DatabaseConfig
+	JWT        JWTConfig
+	MFA        MFAConfig
+	PIN        PINConfig
+	Login      LoginConfig
+	APIKey     APIKeyConfig
+	Account    AccountConfig
+	Mail       MailConfig
+	Midtrans   MidtransConfig
+	Reconciler ReconcilerConfig
+	Expiry     ExpiryConfig
+	Topup      TransactionPolicy
+	Payment    TransactionPolicy
+	Transfer   TransactionPolicy
+	Wallet     WalletConfig
+	KYC        KYCConfig
 }
 
 type AppConfig struct {
@@ -34,14 +49,175 @@ type DatabaseConfig struct {
 }
 
 type JWTConfig struct {
-	SecretKey      string
-	ExpireDuration time.Duration
+	ExpireDuration        time.Duration // Access token lifetime
+	RefreshExpireDuration time.Duration // Refresh token lifetime
+	KeysDir               string        // Directory holding one PEM private key per signing key, named <kid>.pem
+	Algorithm             string        // Algorithm of newly generated keys, RS256 or EdDSA
+	RotationInterval      time.Duration // Age after which the signing key is replaced, 0 disables rotation
+	GraceWindow           time.Duration // How long a replaced key still verifies tokens
+	KeyCheckInterval      time.Duration // How often the key directory is reloaded and rotation is checked
+}
+
+type MFAConfig struct {
+	Issuer            string        // Issuer shown by authenticator apps
+	ChallengeTTL      time.Duration // Time allowed between the password check and the code
+	MaxAttempts       int           // Wrong codes accepted per login challenge
+	RecoveryCodeCount int           // Recovery codes generated at enrollment
+}
+
+type PINConfig struct {
+	MaxAttempts  int           // Wrong transaction PINs accepted before the PIN is locked
+	LockDuration time.Duration // How long outgoing transactions are refused once locked
+}
+
+type LoginConfig struct {
+	MaxAttempts   int           // Wrong passwords accepted before the account is locked
+	LockDuration  time.Duration // How long logins are refused once locked
+	DelayAfter    int           // Wrong passwords before each further attempt has to wait
+	BaseDelay     time.Duration // Wait after the first delayed failure, doubled on each further one
+	MaxDelay      time.Duration // Upper bound of the wait between attempts
+	IPMaxAttempts int           // Failed logins accepted from one IP address within IPWindow
+	IPWindow      time.Duration // Sliding window of the per IP limit
+}
+
+type APIKeyConfig struct {
+	DefaultRateLimit int // Requests per minute of keys created without a rate limit
+}
+
+type AccountConfig struct {
+	PasswordResetTTL     time.Duration // Lifetime of a password reset link
+	EmailVerificationTTL time.Duration // Lifetime of an email verification link
+	RequireVerifiedEmail bool          // Refuse transactions until the email address is verified
+	LinkBaseURL          string        // Base URL of the pages the emailed links open
+}
+
+type MailConfig struct {
+	Driver       string // smtp, or log to write messages to the log and OutboxDir
+	From         string
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	OutboxDir    string // Directory the log driver writes .eml files to, empty to only log
 }
 
 type MidtransConfig struct {
 	ServerKey   string
 	Environment string
 	ClientKey   string
+	APIURL      string
+}
+
+// ExpiryConfig is the expiry policy of unfinished transactions, per transaction type.
+// A zero duration means transactions of that type never expire.
+type ExpiryConfig struct {
+	Topup            time.Duration
+	Payment          time.Duration
+	Transfer         time.Duration
+	Authorization    time.Duration // Lifetime of a payment authorization created without one
+	MaxAuthorization time.Duration // Longest lifetime a payment authorization may ask for
+	CheckInterval    time.Duration
+	BatchSize        int
+}
+
+// For returns the expiry duration configured for the given transaction type
+func (c ExpiryConfig) For(transactionType string) time.Duration {
+	switch transactionType {
+	case "topup":
+		return c.Topup
+	case "payment":
+		return c.Payment
+	case "transfer":
+		return c.Transfer
+	default:
+		return 0
+	}
+}
+
+// TransactionPolicy holds the global limits and the default fee of one transaction type. A zero
+// limit is not enforced; administrators can override the limits per user.
+type TransactionPolicy struct {
+	MinAmount       decimal.Decimal
+	MaxAmount       decimal.Decimal
+	DailyLimit      decimal.Decimal // Highest total amount per calendar day
+	DailyCount      int             // Most transactions per calendar day
+	MonthlyLimit    decimal.Decimal // Highest total amount per calendar month
+	MonthlyCount    int             // Most transactions per calendar month
+	FeeFlat         decimal.Decimal
+	FeePercent      decimal.Decimal
+	ReviewThreshold decimal.Decimal // Amounts at or above this wait for manual review
+}
+
+// Fee returns the fee charged on top of the given amount
+func (p TransactionPolicy) Fee(amount decimal.Decimal) decimal.Decimal {
+	return p.FeeFlat.Add(amount.Mul(p.FeePercent).Div(decimal.NewFromInt(100))).Round(2)
+}
+
+// PolicyFor returns the policy of the given transaction type; other types have no limits or fee
+func (c *Config) PolicyFor(transactionType string) TransactionPolicy {
+	switch transactionType {
+	case "topup":
+		return c.Topup
+	case "payment":
+		return c.Payment
+	case "transfer":
+		return c.Transfer
+	default:
+		return TransactionPolicy{}
+	}
+}
+
+// WalletConfig holds the global limits of a wallet, whatever moves money into it
+type WalletConfig struct {
+	MaxBalance decimal.Decimal // Highest balance a wallet may reach, zero for no cap
+}
+
+// KYCConfig holds the document storage of identity verification and what each KYC tier allows
+type KYCConfig struct {
+	StorageDir      string // Directory of the local blob store that keeps the uploaded documents
+	MaxDocumentSize int64  // Largest document accepted, in bytes
+	Unverified      KYCTierPolicy
+	Basic           KYCTierPolicy
+	Full            KYCTierPolicy
+}
+
+// KYCTierPolicy holds the features a KYC tier unlocks and the caps it puts on top of the global
+// limits of every transaction type. A zero cap is not enforced.
+type KYCTierPolicy struct {
+	Features     []string        // Features the tier unlocks: topup, payment, transfer, authorization
+	MaxBalance   decimal.Decimal // Highest wallet balance
+	MaxAmount    decimal.Decimal // Largest amount of a transaction
+	DailyLimit   decimal.Decimal // Highest total amount per calendar day and transaction type
+	MonthlyLimit decimal.Decimal // Highest total amount per calendar month and transaction type
+}
+
+// Allows checks if the tier unlocks the given feature
+func (p KYCTierPolicy) Allows(feature string) bool {
+	for _, allowed := range p.Features {
+		if allowed == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// PolicyFor returns the policy of the given tier; users without a known tier are unverified
+func (c KYCConfig) PolicyFor(tier string) KYCTierPolicy {
+	switch tier {
+	case "basic":
+		return c.Basic
+	case "full":
+		return c.Full
+	default:
+		return c.Unverified
+	}
+}
+
+type ReconcilerConfig struct {
+	Enabled    bool
+	Interval   time.Duration
+	StaleAfter time.Duration
+	BatchSize  int
 }
 
 func LoadConfig() (*Config, error) {
@@ -51,8 +227,65 @@ func LoadConfig() (*Config, error) {
 	// Parse debug mode
 	debug, _ := strconv.ParseBool(getEnv("DEBUG", "false"))
 
-	// Parse JWT expiry duration
-	jwtExpiry, _ := strconv.Atoi(getEnv("JWT_EXPIRE_HOURS", "24"))
+	// Parse access and refresh token lifetimes
+	jwtExpiry, _ := strconv.Atoi(getEnv("JWT_ACCESS_EXPIRE_MINUTES", "15"))
+	jwtRefreshExpiry, _ := strconv.Atoi(getEnv("JWT_REFRESH_EXPIRE_HOURS", "720"))
+
+	// Parse signing key rotation settings
+	jwtRotation, _ := strconv.Atoi(getEnv("JWT_KEY_ROTATION_HOURS", "720"))
+	jwtGrace, _ := strconv.Atoi(getEnv("JWT_KEY_GRACE_HOURS", "24"))
+	jwtKeyCheck, _ := strconv.Atoi(getEnv("JWT_KEY_CHECK_INTERVAL_SECONDS", "300"))
+
+	// Parse two-factor authentication settings
+	mfaChallengeTTL, _ := strconv.Atoi(getEnv("MFA_CHALLENGE_TTL_MINUTES", "5"))
+	mfaMaxAttempts, _ := strconv.Atoi(getEnv("MFA_MAX_ATTEMPTS", "5"))
+	mfaRecoveryCodes, _ := strconv.Atoi(getEnv("MFA_RECOVERY_CODE_COUNT", "10"))
+
+	// Parse transaction PIN lockout
+	pinMaxAttempts, _ := strconv.Atoi(getEnv("PIN_MAX_ATTEMPTS", "3"))
+	pinLock, _ := strconv.Atoi(getEnv("PIN_LOCK_MINUTES", "30"))
+
+	// Parse login brute-force protection
+	loginMaxAttempts, _ := strconv.Atoi(getEnv("LOGIN_MAX_ATTEMPTS", "5"))
+	loginLock, _ := strconv.Atoi(getEnv("LOGIN_LOCK_MINUTES", "15"))
+	loginDelayAfter, _ := strconv.Atoi(getEnv("LOGIN_DELAY_AFTER", "3"))
+	loginDelayBase, _ := strconv.Atoi(getEnv("LOGIN_DELAY_BASE_SECONDS", "1"))
+	loginDelayMax, _ := strconv.Atoi(getEnv("LOGIN_DELAY_MAX_SECONDS", "30"))
+	loginIPMaxAttempts, _ := strconv.Atoi(getEnv("LOGIN_IP_MAX_ATTEMPTS", "20"))
+	loginIPWindow, _ := strconv.Atoi(getEnv("LOGIN_IP_WINDOW_MINUTES", "15"))
+
+	// Parse API key defaults
+	apiKeyRateLimit, _ := strconv.Atoi(getEnv("API_KEY_DEFAULT_RATE_LIMIT", "60"))
+
+	// Parse account token lifetimes
+	passwordResetTTL, _ := strconv.Atoi(getEnv("PASSWORD_RESET_TTL_MINUTES", "60"))
+	emailVerificationTTL, _ := strconv.Atoi(getEnv("EMAIL_VERIFICATION_TTL_HOURS", "48"))
+	requireVerifiedEmail, _ := strconv.ParseBool(getEnv("REQUIRE_VERIFIED_EMAIL", "false"))
+
+	// Midtrans Core API base URL follows the environment unless overridden
+	midtransEnv := getEnv("MIDTRANS_ENV", "sandbox")
+	midtransAPIURL := "https://api.sandbox.midtrans.com"
+	if midtransEnv == "production" {
+		midtransAPIURL = "https://api.midtrans.com"
+	}
+
+	// Parse KYC document size limit
+	kycMaxDocumentSize, _ := strconv.Atoi(getEnv("KYC_MAX_DOCUMENT_SIZE_MB", "5"))
+
+	// Parse reconciler settings
+	reconcilerEnabled, _ := strconv.ParseBool(getEnv("RECONCILER_ENABLED", "true"))
+	reconcilerInterval, _ := strconv.Atoi(getEnv("RECONCILER_INTERVAL_SECONDS", "60"))
+	reconcilerStaleAfter, _ := strconv.Atoi(getEnv("RECONCILER_STALE_AFTER_MINUTES", "15"))
+	reconcilerBatchSize, _ := strconv.Atoi(getEnv("RECONCILER_BATCH_SIZE", "50"))
+
+	// Parse transaction expiry policy
+	topupExpiry, _ := strconv.Atoi(getEnv("TOPUP_EXPIRY_MINUTES", "60"))
+	paymentExpiry, _ := strconv.Atoi(getEnv("PAYMENT_EXPIRY_MINUTES", "0"))
+	transferExpiry, _ := strconv.Atoi(getEnv("TRANSFER_EXPIRY_MINUTES", "0"))
+	authorizationExpiry, _ := strconv.Atoi(getEnv("AUTHORIZATION_EXPIRY_MINUTES", "10080"))
+	authorizationMaxExpiry, _ := strconv.Atoi(getEnv("AUTHORIZATION_MAX_EXPIRY_MINUTES", "43200"))
+	expiryInterval, _ := strconv.Atoi(getEnv("EXPIRY_CHECK_INTERVAL_SECONDS", "60"))
+	expiryBatchSize, _ := strconv.Atoi(getEnv("EXPIRY_BATCH_SIZE", "100"))
 
 	config := &Config{
 		App: AppConfig{
@@ -72,19 +305,136 @@ func LoadConfig() (*Config, error) {
 			SSLMode:  getEnv("DB_SSL_MODE", "disable"),
 		},
 		JWT: JWTConfig{
-			SecretKey:      getEnv("JWT_SECRET_KEY", "8c100781e252cc0a9c588ea6bcbd60d750b13b42957276415895b028d24427e3"),
-			ExpireDuration: time.Duration(jwtExpiry) * time.Hour,
+			ExpireDuration:        time.Duration(jwtExpiry) * time.Minute,
+			RefreshExpireDuration: time.Duration(jwtRefreshExpiry) * time.Hour,
+			KeysDir:               getEnv("JWT_KEYS_DIR", "./keys"),
+			Algorithm:             getEnv("JWT_ALGORITHM", "RS256"),
+			RotationInterval:      time.Duration(jwtRotation) * time.Hour,
+			GraceWindow:           time.Duration(jwtGrace) * time.Hour,
+			KeyCheckInterval:      time.Duration(jwtKeyCheck) * time.Second,
+		},
+		MFA: MFAConfig{
+			Issuer:            getEnv("MFA_ISSUER", getEnv("APP_NAME", "Go Transaction Service")),
+			ChallengeTTL:      time.Duration(mfaChallengeTTL) * time.Minute,
+			MaxAttempts:       mfaMaxAttempts,
+			RecoveryCodeCount: mfaRecoveryCodes,
+		},
+		PIN: PINConfig{
+			MaxAttempts:  pinMaxAttempts,
+			LockDuration: time.Duration(pinLock) * time.Minute,
+		},
+		Login: LoginConfig{
+			MaxAttempts:   loginMaxAttempts,
+			LockDuration:  time.Duration(loginLock) * time.Minute,
+			DelayAfter:    loginDelayAfter,
+			BaseDelay:     time.Duration(loginDelayBase) * time.Second,
+			MaxDelay:      time.Duration(loginDelayMax) * time.Second,
+			IPMaxAttempts: loginIPMaxAttempts,
+			IPWindow:      time.Duration(loginIPWindow) * time.Minute,
+		},
+		APIKey: APIKeyConfig{
+			DefaultRateLimit: apiKeyRateLimit,
+		},
+		Account: AccountConfig{
+			PasswordResetTTL:     time.Duration(passwordResetTTL) * time.Minute,
+			EmailVerificationTTL: time.Duration(emailVerificationTTL) * time.Hour,
+			RequireVerifiedEmail: requireVerifiedEmail,
+			LinkBaseURL:          getEnv("ACCOUNT_LINK_BASE_URL", "http://localhost:8000"),
+		},
+		Mail: MailConfig{
+			Driver:       getEnv("MAIL_DRIVER", "log"),
+			From:         getEnv("MAIL_FROM", "no-reply@pintro.local"),
+			SMTPHost:     getEnv("SMTP_HOST", "localhost"),
+			SMTPPort:     getEnv("SMTP_PORT", "587"),
+			SMTPUsername: getEnv("SMTP_USERNAME", ""),
+			SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+			OutboxDir:    getEnv("MAIL_OUTBOX_DIR", "./mail"),
 		},
 		Midtrans: MidtransConfig{
 			ServerKey:   getEnv("MIDTRANS_SERVER_KEY", ""),
-			Environment: getEnv("MIDTRANS_ENV", "sandbox"),
+			Environment: midtransEnv,
 			ClientKey:   getEnv("MIDTRANS_CLIENT_KEY", ""),
+			APIURL:      getEnv("MIDTRANS_API_URL", midtransAPIURL),
+		},
+		Reconciler: ReconcilerConfig{
+			Enabled:    reconcilerEnabled,
+			Interval:   time.Duration(reconcilerInterval) * time.Second,
+			StaleAfter: time.Duration(reconcilerStaleAfter) * time.Minute,
+			BatchSize:  reconcilerBatchSize,
+		},
+		Expiry: ExpiryConfig{
+			Topup:            time.Duration(topupExpiry) * time.Minute,
+			Payment:          time.Duration(paymentExpiry) * time.Minute,
+			Transfer:         time.Duration(transferExpiry) * time.Minute,
+			Authorization:    time.Duration(authorizationExpiry) * time.Minute,
+			MaxAuthorization: time.Duration(authorizationMaxExpiry) * time.Minute,
+			CheckInterval:    time.Duration(expiryInterval) * time.Second,
+			BatchSize:        expiryBatchSize,
+		},
+		Topup:    loadTransactionPolicy("TOPUP", "0", "0"),
+		Payment:  loadTransactionPolicy("PAYMENT", "0", "0"),
+		Transfer: loadTransactionPolicy("TRANSFER", "25000000", "50000000"),
+		Wallet: WalletConfig{
+			MaxBalance: getEnvDecimal("WALLET_MAX_BALANCE", "0"),
+		},
+		KYC: KYCConfig{
+			StorageDir:      getEnv("KYC_STORAGE_DIR", "./storage/kyc"),
+			MaxDocumentSize: int64(kycMaxDocumentSize) << 20,
+			Unverified:      loadKYCTierPolicy("KYC_UNVERIFIED", "topup,payment", "2000000", "1000000"),
+			Basic:           loadKYCTierPolicy("KYC_BASIC", "topup,payment,transfer,authorization", "20000000", "0"),
+			Full:            loadKYCTierPolicy("KYC_FULL", "topup,payment,transfer,authorization", "0", "0"),
 		},
 	}
 
 	return config, nil
 }
 
+// loadTransactionPolicy reads the <PREFIX>_MIN_AMOUNT, _MAX_AMOUNT, _DAILY_LIMIT, _DAILY_COUNT,
+// _MONTHLY_LIMIT, _MONTHLY_COUNT, _FEE_FLAT, _FEE_PERCENT and _REVIEW_THRESHOLD variables
+func loadTransactionPolicy(prefix, maxAmount, dailyLimit string) TransactionPolicy {
+	dailyCount, _ := strconv.Atoi(getEnv(prefix+"_DAILY_COUNT", "0"))
+	monthlyCount, _ := strconv.Atoi(getEnv(prefix+"_MONTHLY_COUNT", "0"))
+
+	return TransactionPolicy{
+		MinAmount:       getEnvDecimal(prefix+"_MIN_AMOUNT", "0"),
+		MaxAmount:       getEnvDecimal(prefix+"_MAX_AMOUNT", maxAmount),
+		DailyLimit:      getEnvDecimal(prefix+"_DAILY_LIMIT", dailyLimit),
+		DailyCount:      dailyCount,
+		MonthlyLimit:    getEnvDecimal(prefix+"_MONTHLY_LIMIT", "0"),
+		MonthlyCount:    monthlyCount,
+		FeeFlat:         getEnvDecimal(prefix+"_FEE_FLAT", "0"),
+		FeePercent:      getEnvDecimal(prefix+"_FEE_PERCENT", "0"),
+		ReviewThreshold: getEnvDecimal(prefix+"_REVIEW_THRESHOLD", "0"),
+	}
+}
+
+// loadKYCTierPolicy reads the <PREFIX>_FEATURES, _MAX_BALANCE, _MAX_AMOUNT, _DAILY_LIMIT and
+// _MONTHLY_LIMIT variables; features are comma separated, "none" unlocks nothing
+func loadKYCTierPolicy(prefix, features, maxBalance, maxAmount string) KYCTierPolicy {
+	var list []string
+	for _, feature := range strings.Split(getEnv(prefix+"_FEATURES", features), ",") {
+		if feature = strings.TrimSpace(feature); feature != "" {
+			list = append(list, feature)
+		}
+	}
+
+	return KYCTierPolicy{
+		Features:     list,
+		MaxBalance:   getEnvDecimal(prefix+"_MAX_BALANCE", maxBalance),
+		MaxAmount:    getEnvDecimal(prefix+"_MAX_AMOUNT", maxAmount),
+		DailyLimit:   getEnvDecimal(prefix+"_DAILY_LIMIT", "0"),
+		MonthlyLimit: getEnvDecimal(prefix+"_MONTHLY_LIMIT", "0"),
+	}
+}
+
+func getEnvDecimal(key, defaultValue string) decimal.Decimal {
+	value, err := decimal.NewFromString(getEnv(key, defaultValue))
+	if err != nil {
+		value, _ = decimal.NewFromString(defaultValue)
+	}
+	return value
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
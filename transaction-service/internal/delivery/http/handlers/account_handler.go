@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"go-transaction-service/internal/domain/entities"
+	"go-transaction-service/internal/usecase"
+	"go-transaction-service/pkg/utils"
+	"go.uber.org/zap"
+)
+
+// AccountHandler handles password recovery and email verification
+type AccountHandler struct {
+	accountUseCase usecase.AccountUseCase
+	validator      *validator.Validate
+	logger         *zap.Logger
+}
+
+// NewAccountHandler creates a new account recovery handler
+func NewAccountHandler(accountUseCase usecase.AccountUseCase, validator *validator.Validate, logger *zap.Logger) *AccountHandler {
+	return &AccountHandler{
+		accountUseCase: accountUseCase,
+		validator:      validator,
+		logger:         logger,
+	}
+}
+
+// ForgotPassword emails a password reset link
+// @Summary Request password reset
+// @Description Email a single-use password reset link to the address. The response is the same whether or not the address has an account.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body entities.ForgotPasswordRequest true "Account email"
+// @Success 200 {object} entities.APIResponse "Reset link sent if the account exists"
+// @Failure 400 {object} entities.APIResponse{error=entities.ErrorInfo} "Bad request - invalid input format"
+// @Failure 422 {object} entities.APIResponse{data=[]entities.ValidationError} "Validation failed"
+// @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
+// @Router /auth/password/forgot [post]
+func (h *AccountHandler) ForgotPassword(c echo.Context) error {
+	var req entities.ForgotPasswordRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request format")
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+
+	if err := h.accountUseCase.RequestPasswordReset(c.Request().Context(), req); err != nil {
+		h.logger.Error("Failed to send password reset link",
+			zap.Error(err),
+			zap.String("remote_addr", c.RealIP()))
+		return utils.HandleError(c, err)
+	}
+
+	return utils.SuccessResponse(c, http.StatusOK, "If the account exists, a password reset link has been sent", nil)
+}
+
+// ResetPassword sets a new password with a reset token
+// @Summary Reset password
+// @Description Set a new password with the token from the reset email. All sessions of the account are ended.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body entities.ResetPasswordRequest true "Reset token and new password"
+// @Success 200 {object} entities.APIResponse "Password reset successfully"
+// @Failure 400 {object} entities.APIResponse{error=entities.ErrorInfo} "Bad request - invalid or expired token"
+// @Failure 422 {object} entities.APIResponse{data=[]entities.ValidationError} "Validation failed"
+// @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
+// @Router /auth/password/reset [post]
+func (h *AccountHandler) ResetPassword(c echo.Context) error {
+	var req entities.ResetPasswordRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request format")
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+
+	if err := h.accountUseCase.ResetPassword(c.Request().Context(), req); err != nil {
+		h.logger.Warn("Password reset failed",
+			zap.Error(err),
+			zap.String("remote_addr", c.RealIP()))
+		return utils.HandleError(c, err)
+	}
+
+	h.logger.Info("Password reset completed",
+		zap.String("remote_addr", c.RealIP()))
+
+	return utils.SuccessResponse(c, http.StatusOK, "Password reset successfully", nil)
+}
+
+// VerifyEmail confirms the email address with a verification token
+// @Summary Verify email address
+// @Description Confirm the email address with the token from the verification email
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body entities.VerifyEmailRequest true "Verification token"
+// @Success 200 {object} entities.APIResponse "Email address verified"
+// @Failure 400 {object} entities.APIResponse{error=entities.ErrorInfo} "Bad request - invalid or expired token"
+// @Failure 422 {object} entities.APIResponse{data=[]entities.ValidationError} "Validation failed"
+// @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
+// @Router /auth/email/verify [post]
+func (h *AccountHandler) VerifyEmail(c echo.Context) error {
+	var req entities.VerifyEmailRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request format")
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+
+	if err := h.accountUseCase.VerifyEmail(c.Request().Context(), req); err != nil {
+		h.logger.Warn("Email verification failed",
+			zap.Error(err),
+			zap.String("remote_addr", c.RealIP()))
+		return utils.HandleError(c, err)
+	}
+
+	return utils.SuccessResponse(c, http.StatusOK, "Email address verified", nil)
+}
+
+// ResendVerification emails a new verification link
+// @Summary Resend verification email
+// @Description Email a new verification link to the current address. Earlier links stop working.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} entities.APIResponse "Verification email sent"
+// @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid or missing token"
+// @Failure 409 {object} entities.APIResponse{error=entities.ErrorInfo} "Email address already verified"
+// @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
+// @Router /user/email/verification [post]
+func (h *AccountHandler) ResendVerification(c echo.Context) error {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+	}
+
+	if err := h.accountUseCase.SendEmailVerification(c.Request().Context(), userID); err != nil {
+		h.logger.Warn("Failed to send verification email",
+			zap.Error(err),
+			zap.String("user_id", userID.String()))
+		return utils.HandleError(c, err)
+	}
+
+	return utils.SuccessResponse(c, http.StatusOK, "Verification email sent", nil)
+}
@@ -0,0 +1,635 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"go-transaction-service/internal/domain/entities"
+	"go-transaction-service/internal/usecase"
+	"go-transaction-service/pkg/utils"
+	"go.uber.org/zap"
+)
+
+// AdminHandler handles back-office HTTP requests for support staff and administrators
+type AdminHandler struct {
+	adminUseCase       usecase.AdminUseCase
+	transactionUseCase usecase.TransactionUseCase
+	validator          *validator.Validate
+	logger             *zap.Logger
+}
+
+// NewAdminHandler creates a new admin handler
+func NewAdminHandler(adminUseCase usecase.AdminUseCase, transactionUseCase usecase.TransactionUseCase, validator *validator.Validate, logger *zap.Logger) *AdminHandler {
+	return &AdminHandler{
+		adminUseCase:       adminUseCase,
+		transactionUseCase: transactionUseCase,
+		validator:          validator,
+		logger:             logger,
+	}
+}
+
+// ListUsers lists and searches users
+// @Summary List users
+// @Description List users with optional search on email, name or phone and filters on status and role. Requires the users:read permission.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Param q query string false "Search on email, name or phone"
+// @Param status query string false "Filter by status" Enums(active, inactive, blocked, closed)
+// @Param role query string false "Filter by role" Enums(user, support, admin)
+// @Param limit query int false "Number of users per page (default: 20, max: 100)"
+// @Param offset query int false "Number of users to skip"
+// @Success 200 {object} entities.APIResponse{data=entities.UserListResponse} "Users retrieved successfully"
+// @Failure 400 {object} entities.APIResponse{error=entities.ErrorInfo} "Bad request - invalid parameters"
+// @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid or missing token"
+// @Failure 403 {object} entities.APIResponse{error=entities.ErrorInfo} "Forbidden - insufficient permissions"
+// @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
+// @Router /admin/users [get]
+func (h *AdminHandler) ListUsers(c echo.Context) error {
+	limit, offset, err := parseOffsetPage(c, usecase.DefaultUserPageSize, usecase.MaxUserPageSize)
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid pagination parameters")
+	}
+
+	filter := entities.UserFilter{
+		Search: c.QueryParam("q"),
+		Status: entities.UserStatus(c.QueryParam("status")),
+		Role:   entities.Role(c.QueryParam("role")),
+	}
+	if err := filter.Validate(); err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid filter parameters")
+	}
+
+	response, err := h.adminUseCase.ListUsers(c.Request().Context(), filter, limit, offset)
+	if err != nil {
+		h.logger.Error("Failed to list users", zap.Error(err))
+		return utils.HandleError(c, err)
+	}
+
+	return utils.SuccessResponse(c, http.StatusOK, "Users retrieved successfully", response)
+}
+
+// GetUser retrieves any user profile
+// @Summary Get user
+// @Description Retrieve the profile of any user. Requires the users:read permission.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Param id path string true "User ID" format(uuid)
+// @Success 200 {object} entities.APIResponse{data=entities.UserProfile} "User retrieved successfully"
+// @Failure 400 {object} entities.APIResponse{error=entities.ErrorInfo} "Bad request - invalid user ID"
+// @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid or missing token"
+// @Failure 403 {object} entities.APIResponse{error=entities.ErrorInfo} "Forbidden - insufficient permissions"
+// @Failure 404 {object} entities.APIResponse{error=entities.ErrorInfo} "User not found"
+// @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
+// @Router /admin/users/{id} [get]
+func (h *AdminHandler) GetUser(c echo.Context) error {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID")
+	}
+
+	profile, err := h.adminUseCase.GetUser(c.Request().Context(), userID)
+	if err != nil {
+		return utils.HandleError(c, err)
+	}
+
+	return utils.SuccessResponse(c, http.StatusOK, "User retrieved successfully", profile)
+}
+
+// UpdateUserStatus activates, deactivates or blocks a user
+// @Summary Change user status
+// @Description Change the status of a user. Deactivating or blocking a user ends all of their sessions. Requires the users:write permission.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Param id path string true "User ID" format(uuid)
+// @Param request body entities.UpdateUserStatusRequest true "New status"
+// @Success 200 {object} entities.APIResponse{data=entities.UserProfile} "User status updated successfully"
+// @Failure 400 {object} entities.APIResponse{error=entities.ErrorInfo} "Bad request - invalid input format"
+// @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid or missing token"
+// @Failure 403 {object} entities.APIResponse{error=entities.ErrorInfo} "Forbidden - insufficient permissions"
+// @Failure 404 {object} entities.APIResponse{error=entities.ErrorInfo} "User not found"
+// @Failure 422 {object} entities.APIResponse{data=[]entities.ValidationError} "Validation failed"
+// @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
+// @Router /admin/users/{id}/status [patch]
+func (h *AdminHandler) UpdateUserStatus(c echo.Context) error {
+	adminID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+	}
+
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID")
+	}
+
+	var req entities.UpdateUserStatusRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request format")
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+
+	profile, err := h.adminUseCase.UpdateUserStatus(c.Request().Context(), adminID, userID, req)
+	if err != nil {
+		h.logger.Error("Failed to update user status",
+			zap.Error(err),
+			zap.String("admin_id", adminID.String()),
+			zap.String("user_id", userID.String()))
+		return utils.HandleError(c, err)
+	}
+
+	h.logger.Info("User status updated",
+		zap.String("admin_id", adminID.String()),
+		zap.String("user_id", userID.String()),
+		zap.String("status", string(req.Status)),
+		zap.String("reason", req.Reason))
+
+	return utils.SuccessResponse(c, http.StatusOK, "User status updated successfully", profile)
+}
+
+// UpdateUserRole assigns a role to a user
+// @Summary Change user role
+// @Description Assign the user, support or admin role to a user. All sessions of the user end, so the new role applies from their next login. Requires the roles:manage permission.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Param id path string true "User ID" format(uuid)
+// @Param request body entities.UpdateUserRoleRequest true "New role"
+// @Success 200 {object} entities.APIResponse{data=entities.UserProfile} "User role updated successfully"
+// @Failure 400 {object} entities.APIResponse{error=entities.ErrorInfo} "Bad request - invalid input format or own account"
+// @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid or missing token"
+// @Failure 403 {object} entities.APIResponse{error=entities.ErrorInfo} "Forbidden - insufficient permissions"
+// @Failure 404 {object} entities.APIResponse{error=entities.ErrorInfo} "User not found"
+// @Failure 422 {object} entities.APIResponse{data=[]entities.ValidationError} "Validation failed"
+// @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
+// @Router /admin/users/{id}/role [patch]
+func (h *AdminHandler) UpdateUserRole(c echo.Context) error {
+	adminID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+	}
+
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID")
+	}
+
+	var req entities.UpdateUserRoleRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request format")
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+
+	profile, err := h.adminUseCase.UpdateUserRole(c.Request().Context(), adminID, userID, req)
+	if err != nil {
+		h.logger.Error("Failed to update user role",
+			zap.Error(err),
+			zap.String("admin_id", adminID.String()),
+			zap.String("user_id", userID.String()))
+		return utils.HandleError(c, err)
+	}
+
+	h.logger.Info("User role updated",
+		zap.String("admin_id", adminID.String()),
+		zap.String("user_id", userID.String()),
+		zap.String("role", string(req.Role)),
+		zap.String("reason", req.Reason))
+
+	return utils.SuccessResponse(c, http.StatusOK, "User role updated successfully", profile)
+}
+
+// ResetMFA turns off two-factor authentication for a user
+// @Summary Reset user two-factor authentication
+// @Description Turn off two-factor authentication for a user who lost their authenticator and recovery codes. Their TOTP secret and recovery codes are removed and their refresh tokens revoked. Requires the users:write permission.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Param id path string true "User ID" format(uuid)
+// @Success 200 {object} entities.APIResponse{data=entities.UserProfile} "Two-factor authentication reset successfully"
+// @Failure 400 {object} entities.APIResponse{error=entities.ErrorInfo} "Bad request - invalid user ID or own account"
+// @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid or missing token"
+// @Failure 403 {object} entities.APIResponse{error=entities.ErrorInfo} "Forbidden - insufficient permissions"
+// @Failure 404 {object} entities.APIResponse{error=entities.ErrorInfo} "User not found"
+// @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
+// @Router /admin/users/{id}/mfa/reset [post]
+func (h *AdminHandler) ResetMFA(c echo.Context) error {
+	adminID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+	}
+
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID")
+	}
+
+	profile, err := h.adminUseCase.ResetMFA(c.Request().Context(), adminID, userID)
+	if err != nil {
+		h.logger.Error("Failed to reset two-factor authentication",
+			zap.Error(err),
+			zap.String("admin_id", adminID.String()),
+			zap.String("user_id", userID.String()))
+		return utils.HandleError(c, err)
+	}
+
+	h.logger.Info("Two-factor authentication reset",
+		zap.String("admin_id", adminID.String()),
+		zap.String("user_id", userID.String()))
+
+	return utils.SuccessResponse(c, http.StatusOK, "Two-factor authentication reset successfully", profile)
+}
+
+// UnlockUser lifts a login lockout
+// @Summary Unlock user login
+// @Description Lift the lockout of a user who entered too many wrong passwords and clear their failed login attempts. Requires the users:write permission.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Param id path string true "User ID" format(uuid)
+// @Success 200 {object} entities.APIResponse{data=entities.UserProfile} "User unlocked successfully"
+// @Failure 400 {object} entities.APIResponse{error=entities.ErrorInfo} "Bad request - invalid user ID"
+// @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid or missing token"
+// @Failure 403 {object} entities.APIResponse{error=entities.ErrorInfo} "Forbidden - insufficient permissions"
+// @Failure 404 {object} entities.APIResponse{error=entities.ErrorInfo} "User not found"
+// @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
+// @Router /admin/users/{id}/unlock [post]
+func (h *AdminHandler) UnlockUser(c echo.Context) error {
+	adminID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+	}
+
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID")
+	}
+
+	profile, err := h.adminUseCase.UnlockUser(c.Request().Context(), adminID, userID)
+	if err != nil {
+		h.logger.Error("Failed to unlock user",
+			zap.Error(err),
+			zap.String("admin_id", adminID.String()),
+			zap.String("user_id", userID.String()))
+		return utils.HandleError(c, err)
+	}
+
+	h.logger.Info("User unlocked",
+		zap.String("admin_id", adminID.String()),
+		zap.String("user_id", userID.String()))
+
+	return utils.SuccessResponse(c, http.StatusOK, "User unlocked successfully", profile)
+}
+
+// ListSecurityEvents lists the login security events of a user
+// @Summary List user security events
+// @Description List successful, failed and throttled logins, lockouts and unlocks of a user, newest first. Requires the users:read permission.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Param id path string true "User ID" format(uuid)
+// @Param limit query int false "Number of events per page (default: 20, max: 100)"
+// @Param offset query int false "Number of events to skip"
+// @Success 200 {object} entities.APIResponse{data=entities.SecurityEventListResponse} "Security events retrieved successfully"
+// @Failure 400 {object} entities.APIResponse{error=entities.ErrorInfo} "Bad request - invalid parameters"
+// @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid or missing token"
+// @Failure 403 {object} entities.APIResponse{error=entities.ErrorInfo} "Forbidden - insufficient permissions"
+// @Failure 404 {object} entities.APIResponse{error=entities.ErrorInfo} "User not found"
+// @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
+// @Router /admin/users/{id}/security-events [get]
+func (h *AdminHandler) ListSecurityEvents(c echo.Context) error {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID")
+	}
+
+	limit, offset, err := parseOffsetPage(c, usecase.DefaultUserPageSize, usecase.MaxUserPageSize)
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid pagination parameters")
+	}
+
+	response, err := h.adminUseCase.ListSecurityEvents(c.Request().Context(), userID, limit, offset)
+	if err != nil {
+		h.logger.Error("Failed to list security events",
+			zap.Error(err),
+			zap.String("user_id", userID.String()))
+		return utils.HandleError(c, err)
+	}
+
+	return utils.SuccessResponse(c, http.StatusOK, "Security events retrieved successfully", response)
+}
+
+// ListTransactionsForReview lists transactions waiting for manual review
+// @Summary List transactions under review
+// @Description List transactions held for manual review, oldest first. Requires the transactions:read permission.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Param limit query int false "Number of transactions per page (default: 10, max: 100)"
+// @Param offset query int false "Number of transactions to skip"
+// @Success 200 {object} entities.APIResponse{data=entities.TransactionHistoryListResponse} "Transactions retrieved successfully"
+// @Failure 400 {object} entities.APIResponse{error=entities.ErrorInfo} "Bad request - invalid parameters"
+// @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid or missing token"
+// @Failure 403 {object} entities.APIResponse{error=entities.ErrorInfo} "Forbidden - insufficient permissions"
+// @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
+// @Router /admin/transactions/review [get]
+func (h *AdminHandler) ListTransactionsForReview(c echo.Context) error {
+	limit, offset, err := parseOffsetPage(c, entities.DefaultTransactionPageSize, entities.MaxTransactionPageSize)
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid pagination parameters")
+	}
+
+	response, err := h.transactionUseCase.ListTransactionsForReview(c.Request().Context(), limit, offset)
+	if err != nil {
+		h.logger.Error("Failed to list transactions under review", zap.Error(err))
+		return utils.HandleError(c, err)
+	}
+
+	return utils.SuccessResponse(c, http.StatusOK, "Transactions retrieved successfully", response)
+}
+
+// GetTransaction retrieves any transaction
+// @Summary Get any transaction
+// @Description Retrieve the details of any transaction as seen by the user who initiated it. Requires the transactions:read permission.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Param id path string true "Transaction ID" format(uuid)
+// @Success 200 {object} entities.APIResponse{data=entities.TransactionDetailResponse} "Transaction retrieved successfully"
+// @Failure 400 {object} entities.APIResponse{error=entities.ErrorInfo} "Bad request - invalid transaction ID"
+// @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid or missing token"
+// @Failure 403 {object} entities.APIResponse{error=entities.ErrorInfo} "Forbidden - insufficient permissions"
+// @Failure 404 {object} entities.APIResponse{error=entities.ErrorInfo} "Transaction not found"
+// @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
+// @Router /admin/transactions/{id} [get]
+func (h *AdminHandler) GetTransaction(c echo.Context) error {
+	transactionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid transaction ID")
+	}
+
+	detail, err := h.transactionUseCase.GetTransactionDetailForAdmin(c.Request().Context(), transactionID)
+	if err != nil {
+		return utils.HandleError(c, err)
+	}
+
+	return utils.SuccessResponse(c, http.StatusOK, "Transaction retrieved successfully", detail)
+}
+
+// ApproveTransaction approves a transaction under manual review
+// @Summary Approve transaction
+// @Description Move the funds of a transaction held for manual review and complete it. Requires the transactions:review permission.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Param id path string true "Transaction ID" format(uuid)
+// @Param request body entities.ApproveTransactionRequest false "Approval note"
+// @Success 200 {object} entities.APIResponse{data=entities.TransactionResponse} "Transaction approved successfully"
+// @Failure 400 {object} entities.APIResponse{error=entities.ErrorInfo} "Bad request - invalid input or insufficient balance"
+// @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid or missing token"
+// @Failure 403 {object} entities.APIResponse{error=entities.ErrorInfo} "Forbidden - insufficient permissions"
+// @Failure 404 {object} entities.APIResponse{error=entities.ErrorInfo} "Transaction not found"
+// @Failure 409 {object} entities.APIResponse{error=entities.ErrorInfo} "Transaction is not under review"
+// @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
+// @Router /admin/transactions/{id}/approve [post]
+func (h *AdminHandler) ApproveTransaction(c echo.Context) error {
+	reviewerID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+	}
+
+	transactionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid transaction ID")
+	}
+
+	var req entities.ApproveTransactionRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request format")
+	}
+
+	response, err := h.transactionUseCase.ApproveTransaction(c.Request().Context(), reviewerID, transactionID, req.Note)
+	if err != nil {
+		h.logger.Warn("Failed to approve transaction",
+			zap.Error(err),
+			zap.String("reviewer_id", reviewerID.String()),
+			zap.String("transaction_id", transactionID.String()))
+		return utils.HandleError(c, err)
+	}
+
+	h.logger.Info("Transaction approved",
+		zap.String("reviewer_id", reviewerID.String()),
+		zap.String("transaction_id", transactionID.String()))
+
+	return utils.SuccessResponse(c, http.StatusOK, "Transaction approved successfully", response)
+}
+
+// RejectTransaction rejects a transaction under manual review
+// @Summary Reject transaction
+// @Description Cancel a transaction held for manual review without moving any funds. Requires the transactions:review permission.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Param id path string true "Transaction ID" format(uuid)
+// @Param request body entities.RejectTransactionRequest true "Rejection reason"
+// @Success 200 {object} entities.APIResponse{data=entities.TransactionResponse} "Transaction rejected successfully"
+// @Failure 400 {object} entities.APIResponse{error=entities.ErrorInfo} "Bad request - invalid input format"
+// @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid or missing token"
+// @Failure 403 {object} entities.APIResponse{error=entities.ErrorInfo} "Forbidden - insufficient permissions"
+// @Failure 404 {object} entities.APIResponse{error=entities.ErrorInfo} "Transaction not found"
+// @Failure 409 {object} entities.APIResponse{error=entities.ErrorInfo} "Transaction is not under review"
+// @Failure 422 {object} entities.APIResponse{data=[]entities.ValidationError} "Validation failed"
+// @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
+// @Router /admin/transactions/{id}/reject [post]
+func (h *AdminHandler) RejectTransaction(c echo.Context) error {
+	reviewerID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+	}
+
+	transactionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid transaction ID")
+	}
+
+	var req entities.RejectTransactionRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request format")
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+
+	response, err := h.transactionUseCase.RejectTransaction(c.Request().Context(), reviewerID, transactionID, req.Reason)
+	if err != nil {
+		h.logger.Warn("Failed to reject transaction",
+			zap.Error(err),
+			zap.String("reviewer_id", reviewerID.String()),
+			zap.String("transaction_id", transactionID.String()))
+		return utils.HandleError(c, err)
+	}
+
+	h.logger.Info("Transaction rejected",
+		zap.String("reviewer_id", reviewerID.String()),
+		zap.String("transaction_id", transactionID.String()),
+		zap.String("reason", req.Reason))
+
+	return utils.SuccessResponse(c, http.StatusOK, "Transaction rejected successfully", response)
+}
+
+// RefundTransaction refunds a completed payment or top-up
+// @Summary Refund transaction
+// @Description Reverse all or part of a completed payment or top-up with a linked refund transaction. A payment is paid back from the recipient's wallet, a top-up is taken out of the user's wallet and refunded through the payment gateway. Without an amount the whole remaining amount is refunded. Requires the transactions:refund permission.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Param id path string true "Transaction ID" format(uuid)
+// @Param request body entities.RefundTransactionRequest true "Refund amount and reason"
+// @Success 200 {object} entities.APIResponse{data=entities.TransactionResponse} "Transaction refunded successfully"
+// @Failure 400 {object} entities.APIResponse{error=entities.ErrorInfo} "Bad request - invalid amount or insufficient balance"
+// @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid or missing token"
+// @Failure 403 {object} entities.APIResponse{error=entities.ErrorInfo} "Forbidden - insufficient permissions"
+// @Failure 404 {object} entities.APIResponse{error=entities.ErrorInfo} "Transaction not found"
+// @Failure 409 {object} entities.APIResponse{error=entities.ErrorInfo} "Transaction cannot be refunded or is already fully refunded"
+// @Failure 422 {object} entities.APIResponse{data=[]entities.ValidationError} "Validation failed"
+// @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
+// @Router /admin/transactions/{id}/refund [post]
+func (h *AdminHandler) RefundTransaction(c echo.Context) error {
+	adminID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+	}
+
+	transactionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid transaction ID")
+	}
+
+	var req entities.RefundTransactionRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request format")
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+
+	response, err := h.transactionUseCase.RefundTransaction(c.Request().Context(), adminID, transactionID, req)
+	if err != nil {
+		h.logger.Warn("Failed to refund transaction",
+			zap.Error(err),
+			zap.String("admin_id", adminID.String()),
+			zap.String("transaction_id", transactionID.String()))
+		return utils.HandleError(c, err)
+	}
+
+	h.logger.Info("Transaction refunded",
+		zap.String("admin_id", adminID.String()),
+		zap.String("transaction_id", transactionID.String()),
+		zap.String("refund_id", response.ID.String()),
+		zap.String("amount", response.Amount.String()),
+		zap.String("reason", req.Reason))
+
+	return utils.SuccessResponse(c, http.StatusOK, "Transaction refunded successfully", response)
+}
+
+// RebuildWalletBalance recomputes a user's balance from the ledger
+// @Summary Rebuild wallet balance
+// @Description Reset the stored balance of a user to the sum of their ledger wallet postings, correcting a balance that drifted from the ledger. Requires the users:write permission.
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Param id path string true "User ID" format(uuid)
+// @Success 200 {object} entities.APIResponse{data=entities.BalanceResponse} "Wallet balance rebuilt successfully"
+// @Failure 400 {object} entities.APIResponse{error=entities.ErrorInfo} "Bad request - invalid user ID"
+// @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid or missing token"
+// @Failure 403 {object} entities.APIResponse{error=entities.ErrorInfo} "Forbidden - insufficient permissions"
+// @Failure 404 {object} entities.APIResponse{error=entities.ErrorInfo} "User not found"
+// @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
+// @Router /admin/users/{id}/balance/rebuild [post]
+func (h *AdminHandler) RebuildWalletBalance(c echo.Context) error {
+	adminID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+	}
+
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID")
+	}
+
+	response, err := h.transactionUseCase.RebuildWalletBalance(c.Request().Context(), userID)
+	if err != nil {
+		h.logger.Warn("Failed to rebuild wallet balance",
+			zap.Error(err),
+			zap.String("admin_id", adminID.String()),
+			zap.String("user_id", userID.String()))
+		return utils.HandleError(c, err)
+	}
+
+	h.logger.Info("Wallet balance rebuilt",
+		zap.String("admin_id", adminID.String()),
+		zap.String("user_id", userID.String()),
+		zap.String("balance", response.Balance.String()))
+
+	return utils.SuccessResponse(c, http.StatusOK, "Wallet balance rebuilt successfully", response)
+}
+
+// parseOffsetPage extracts limit and offset pagination parameters from query
+func parseOffsetPage(c echo.Context, defaultLimit, maxLimit int) (limit, offset int, err error) {
+	limit = defaultLimit
+	if limitStr := c.QueryParam("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			return 0, 0, fmt.Errorf("invalid limit %q", limitStr)
+		}
+	}
+	if limit > maxLimit {
+		limit = maxLimit
+	}
+
+	if offsetStr := c.QueryParam("offset"); offsetStr != "" {
+		offset, err = strconv.Atoi(offsetStr)
+		if err != nil || offset < 0 {
+			return 0, 0, fmt.Errorf("invalid offset %q", offsetStr)
+		}
+	}
+
+	return limit, offset, nil
+}
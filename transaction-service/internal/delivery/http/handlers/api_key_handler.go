@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"go-transaction-service/internal/domain/entities"
+	"go-transaction-service/internal/usecase"
+	"go-transaction-service/pkg/utils"
+	"go.uber.org/zap"
+)
+
+// APIKeyHandler handles the API keys of service-to-service callers
+type APIKeyHandler struct {
+	apiKeyUseCase usecase.APIKeyUseCase
+	validator     *validator.Validate
+	logger        *zap.Logger
+}
+
+// NewAPIKeyHandler creates a new API key handler
+func NewAPIKeyHandler(apiKeyUseCase usecase.APIKeyUseCase, validator *validator.Validate, logger *zap.Logger) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyUseCase: apiKeyUseCase,
+		validator:     validator,
+		logger:        logger,
+	}
+}
+
+// CreateAPIKey creates an API key
+// @Summary Create API key
+// @Description Create an API key acting as the given user on the route groups of its scopes, sent in the X-API-Key header. The key is returned only once. Requires the api_keys:manage permission and a login, API keys cannot manage API keys.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body entities.CreateAPIKeyRequest true "API key details"
+// @Success 201 {object} entities.APIResponse{data=entities.CreateAPIKeyResponse} "API key created successfully"
+// @Failure 400 {object} entities.APIResponse{error=entities.ErrorInfo} "Bad request - invalid input format or owner"
+// @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid or missing token"
+// @Failure 403 {object} entities.APIResponse{error=entities.ErrorInfo} "Forbidden - insufficient permissions"
+// @Failure 404 {object} entities.APIResponse{error=entities.ErrorInfo} "User not found"
+// @Failure 422 {object} entities.APIResponse{data=[]entities.ValidationError} "Validation failed"
+// @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
+// @Router /admin/api-keys [post]
+func (h *APIKeyHandler) CreateAPIKey(c echo.Context) error {
+	adminID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req entities.CreateAPIKeyRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request format")
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+
+	response, err := h.apiKeyUseCase.CreateAPIKey(c.Request().Context(), adminID, req)
+	if err != nil {
+		h.logger.Error("Failed to create API key",
+			zap.Error(err),
+			zap.String("admin_id", adminID.String()),
+			zap.String("user_id", req.UserID.String()))
+		return utils.HandleError(c, err)
+	}
+
+	h.logger.Info("API key created",
+		zap.String("admin_id", adminID.String()),
+		zap.String("api_key_id", response.APIKey.ID.String()),
+		zap.String("user_id", response.APIKey.UserID.String()),
+		zap.String("name", response.APIKey.Name))
+
+	return utils.SuccessResponse(c, http.StatusCreated, "API key created successfully", response)
+}
+
+// ListAPIKeys lists API keys
+// @Summary List API keys
+// @Description List API keys, newest first, including revoked and expired ones. Keys are shown by their prefix only. Requires the api_keys:manage permission.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Number of keys per page (default: 20, max: 100)"
+// @Param offset query int false "Number of keys to skip"
+// @Success 200 {object} entities.APIResponse{data=entities.APIKeyListResponse} "API keys retrieved successfully"
+// @Failure 400 {object} entities.APIResponse{error=entities.ErrorInfo} "Bad request - invalid parameters"
+// @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid or missing token"
+// @Failure 403 {object} entities.APIResponse{error=entities.ErrorInfo} "Forbidden - insufficient permissions"
+// @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
+// @Router /admin/api-keys [get]
+func (h *APIKeyHandler) ListAPIKeys(c echo.Context) error {
+	limit, offset, err := parseOffsetPage(c, usecase.DefaultUserPageSize, usecase.MaxUserPageSize)
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid pagination parameters")
+	}
+
+	response, err := h.apiKeyUseCase.ListAPIKeys(c.Request().Context(), limit, offset)
+	if err != nil {
+		h.logger.Error("Failed to list API keys", zap.Error(err))
+		return utils.HandleError(c, err)
+	}
+
+	return utils.SuccessResponse(c, http.StatusOK, "API keys retrieved successfully", response)
+}
+
+// RevokeAPIKey revokes an API key
+// @Summary Revoke API key
+// @Description Revoke an API key; requests with it are refused from then on. Requires the api_keys:manage permission.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "API key ID" format(uuid)
+// @Success 200 {object} entities.APIResponse{data=entities.APIKey} "API key revoked successfully"
+// @Failure 400 {object} entities.APIResponse{error=entities.ErrorInfo} "Bad request - invalid API key ID"
+// @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid or missing token"
+// @Failure 403 {object} entities.APIResponse{error=entities.ErrorInfo} "Forbidden - insufficient permissions"
+// @Failure 404 {object} entities.APIResponse{error=entities.ErrorInfo} "API key not found"
+// @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
+// @Router /admin/api-keys/{id} [delete]
+func (h *APIKeyHandler) RevokeAPIKey(c echo.Context) error {
+	adminID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+	}
+
+	keyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid API key ID")
+	}
+
+	apiKey, err := h.apiKeyUseCase.RevokeAPIKey(c.Request().Context(), keyID)
+	if err != nil {
+		h.logger.Error("Failed to revoke API key",
+			zap.Error(err),
+			zap.String("admin_id", adminID.String()),
+			zap.String("api_key_id", keyID.String()))
+		return utils.HandleError(c, err)
+	}
+
+	h.logger.Info("API key revoked",
+		zap.String("admin_id", adminID.String()),
+		zap.String("api_key_id", keyID.String()))
+
+	return utils.SuccessResponse(c, http.StatusOK, "API key revoked successfully", apiKey)
+}
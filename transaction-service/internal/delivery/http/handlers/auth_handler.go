@@ -13,23 +13,25 @@ import (
 
 // AuthHandler handles authentication-related HTTP requests
 type AuthHandler struct {
-	authUseCase usecase.AuthUseCase
-	validator   *validator.Validate
-	logger      *zap.Logger
+	authUseCase    usecase.AuthUseCase
+	accountUseCase usecase.AccountUseCase
+	validator      *validator.Validate
+	logger         *zap.Logger
 }
 
 // NewAuthHandler creates a new authentication handler
-func NewAuthHandler(authUseCase usecase.AuthUseCase, validator *validator.Validate, logger *zap.Logger) *AuthHandler {
+func NewAuthHandler(authUseCase usecase.AuthUseCase, accountUseCase usecase.AccountUseCase, validator *validator.Validate, logger *zap.Logger) *AuthHandler {
 	return &AuthHandler{
-		authUseCase: authUseCase,
-		validator:   validator,
-		logger:      logger,
+		authUseCase:    authUseCase,
+		accountUseCase: accountUseCase,
+		validator:      validator,
+		logger:         logger,
 	}
 }
 
 // Register handles user registration
 // @Summary Register new user
-// @Description Register a new user account with email, password, and personal information
+// @Description Register a new user account with email, password, and personal information. A verification link is emailed to the address.
 // @Tags Authentication
 // @Accept json
 // @Produce json
@@ -68,20 +70,29 @@ func (h *AuthHandler) Register(c echo.Context) error {
 		zap.String("user_id", user.ID.String()),
 		zap.String("email", user.Email))
 
+	// The account exists either way; the user can ask for another link
+	if err := h.accountUseCase.SendEmailVerification(c.Request().Context(), user.ID); err != nil {
+		h.logger.Warn("Failed to send verification email",
+			zap.Error(err),
+			zap.String("user_id", user.ID.String()))
+	}
+
 	return utils.SuccessResponse(c, http.StatusCreated, "User registered successfully", user.ToProfile())
 }
 
 // Login handles user authentication
 // @Summary User login
-// @Description Authenticate user with email and password, returns JWT token
+// @Description Authenticate user with email and password, returns JWT token. When the account has two-factor authentication enabled, an MFA challenge is returned instead; complete it at /auth/mfa/verify. Repeated wrong passwords delay and then temporarily lock the account, and clients with too many failures are refused.
 // @Tags Authentication
 // @Accept json
 // @Produce json
 // @Param request body entities.LoginRequest true "User login credentials"
 // @Success 200 {object} entities.APIResponse{data=entities.LoginResponse} "Login successful"
+// @Success 202 {object} entities.APIResponse{data=entities.MFAChallengeResponse} "Two-factor authentication required"
 // @Failure 400 {object} entities.APIResponse{error=entities.ErrorInfo} "Bad request - invalid input format"
 // @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid credentials"
 // @Failure 422 {object} entities.APIResponse{data=[]entities.ValidationError} "Validation failed"
+// @Failure 429 {object} entities.APIResponse{error=entities.ErrorInfo} "Too many failed attempts - account locked or client throttled"
 // @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
 // @Router /auth/login [post]
 func (h *AuthHandler) Login(c echo.Context) error {
@@ -100,7 +111,7 @@ func (h *AuthHandler) Login(c echo.Context) error {
 		return utils.ValidationErrorResponse(c, err)
 	}
 
-	response, err := h.authUseCase.Login(c.Request().Context(), req)
+	response, challenge, err := h.authUseCase.Login(c.Request().Context(), req, clientInfo(c))
 	if err != nil {
 		h.logger.Error("Login failed", 
 			zap.Error(err),
@@ -109,6 +120,54 @@ func (h *AuthHandler) Login(c echo.Context) error {
 		return utils.HandleError(c, err)
 	}
 
+	if challenge != nil {
+		h.logger.Info("Login waiting for second factor", 
+			zap.String("email", req.Email))
+
+		return utils.SuccessResponse(c, http.StatusAccepted, "Two-factor authentication required", challenge)
+	}
+
+	h.logger.Info("User logged in successfully", 
+		zap.String("user_id", response.User.ID.String()),
+		zap.String("email", response.User.Email))
+
+	return utils.SuccessResponse(c, http.StatusOK, "Login successful", response)
+}
+
+// VerifyMFA completes a two-factor login
+// @Summary Verify login second factor
+// @Description Exchange the MFA challenge returned by login and a TOTP code, or an unused recovery code, for tokens. A challenge accepts a limited number of wrong codes.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body entities.MFAVerifyRequest true "Challenge token and code"
+// @Success 200 {object} entities.APIResponse{data=entities.LoginResponse} "Login successful"
+// @Failure 400 {object} entities.APIResponse{error=entities.ErrorInfo} "Bad request - invalid input format"
+// @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid code or expired challenge"
+// @Failure 422 {object} entities.APIResponse{data=[]entities.ValidationError} "Validation failed"
+// @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
+// @Router /auth/mfa/verify [post]
+func (h *AuthHandler) VerifyMFA(c echo.Context) error {
+	var req entities.MFAVerifyRequest
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("Failed to bind MFA verification request", 
+			zap.Error(err),
+			zap.String("remote_addr", c.RealIP()))
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request format")
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+
+	response, err := h.authUseCase.VerifyMFA(c.Request().Context(), req, clientInfo(c))
+	if err != nil {
+		h.logger.Warn("MFA verification failed", 
+			zap.Error(err),
+			zap.String("remote_addr", c.RealIP()))
+		return utils.HandleError(c, err)
+	}
+
 	h.logger.Info("User logged in successfully", 
 		zap.String("user_id", response.User.ID.String()),
 		zap.String("email", response.User.Email))
@@ -123,6 +182,7 @@ func (h *AuthHandler) Login(c echo.Context) error {
 // @Accept json
 // @Produce json
 // @Security BearerAuth
+// @Security APIKeyAuth
 // @Success 200 {object} entities.APIResponse{data=entities.UserProfile} "Profile retrieved successfully"
 // @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid or missing token"
 // @Failure 404 {object} entities.APIResponse{error=entities.ErrorInfo} "User not found"
@@ -149,43 +209,121 @@ func (h *AuthHandler) GetProfile(c echo.Context) error {
 	return utils.SuccessResponse(c, http.StatusOK, "Profile retrieved successfully", user.ToProfile())
 }
 
-// RefreshToken refreshes the JWT token for authenticated user
-// @Summary Refresh JWT token
-// @Description Refresh the JWT token for the authenticated user
+// RefreshToken exchanges a refresh token for a new access token
+// @Summary Refresh access token
+// @Description Exchange a refresh token for a new access token and a new refresh token. Each refresh token can be used once; reusing one ends the session.
 // @Tags Authentication
 // @Accept json
 // @Produce json
-// @Security BearerAuth
+// @Param request body entities.RefreshTokenRequest true "Refresh token"
 // @Success 200 {object} entities.APIResponse{data=entities.LoginResponse} "Token refreshed successfully"
-// @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid or expired token"
+// @Failure 400 {object} entities.APIResponse{error=entities.ErrorInfo} "Bad request - invalid input format"
+// @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid, expired or reused refresh token"
+// @Failure 422 {object} entities.APIResponse{data=[]entities.ValidationError} "Validation failed"
 // @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
 // @Router /auth/refresh [post]
 func (h *AuthHandler) RefreshToken(c echo.Context) error {
-	userID, err := utils.GetUserIDFromContext(c)
-	if err != nil {
-		h.logger.Error("Failed to get user ID from context for token refresh", zap.Error(err))
-		return utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+	var req entities.RefreshTokenRequest
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("Failed to bind refresh token request", 
+			zap.Error(err),
+			zap.String("remote_addr", c.RealIP()))
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request format")
 	}
 
-	user, err := h.authUseCase.GetUserByID(c.Request().Context(), userID)
-	if err != nil {
-		h.logger.Error("Failed to get user for token refresh", 
-			zap.Error(err),
-			zap.String("user_id", userID.String()))
-		return utils.HandleError(c, err)
+	if err := h.validator.Struct(req); err != nil {
+		return utils.ValidationErrorResponse(c, err)
 	}
 
-	// Generate new token
-	response, err := h.authUseCase.GenerateTokenResponse(user)
+	response, err := h.authUseCase.RefreshToken(c.Request().Context(), req.RefreshToken, clientInfo(c))
 	if err != nil {
-		h.logger.Error("Failed to generate refresh token", 
+		h.logger.Warn("Token refresh failed", 
 			zap.Error(err),
-			zap.String("user_id", userID.String()))
+			zap.String("remote_addr", c.RealIP()))
 		return utils.HandleError(c, err)
 	}
 
 	h.logger.Info("Token refreshed successfully", 
-		zap.String("user_id", userID.String()))
+		zap.String("user_id", response.User.ID.String()))
 
 	return utils.SuccessResponse(c, http.StatusOK, "Token refreshed successfully", response)
 }
+
+// Logout ends the current session
+// @Summary Logout
+// @Description Revoke the access token used for this request and the refresh tokens of its session
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} entities.APIResponse "Logged out successfully"
+// @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid or missing token"
+// @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
+// @Router /logout [post]
+func (h *AuthHandler) Logout(c echo.Context) error {
+	claims, ok := c.Get("claims").(*entities.JWTClaims)
+	if !ok {
+		h.logger.Error("Failed to get token claims from context for logout")
+		return utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+	}
+
+	if err := h.authUseCase.Logout(c.Request().Context(), claims); err != nil {
+		h.logger.Error("Logout failed", 
+			zap.Error(err),
+			zap.String("user_id", claims.UserID.String()))
+		return utils.HandleError(c, err)
+	}
+
+	h.logger.Info("User logged out successfully", 
+		zap.String("user_id", claims.UserID.String()),
+		zap.String("session_id", claims.SessionID.String()))
+
+	return utils.SuccessResponse(c, http.StatusOK, "Logged out successfully", nil)
+}
+
+// VerifyToken reports whether the access token used for this request is valid
+// @Summary Verify access token
+// @Description Check that the bearer token is valid and not revoked, and return who it belongs to
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} entities.APIResponse{data=entities.TokenVerificationResponse} "Token is valid"
+// @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid, expired or revoked token"
+// @Router /verify-token [get]
+func (h *AuthHandler) VerifyToken(c echo.Context) error {
+	claims, ok := c.Get("claims").(*entities.JWTClaims)
+	if !ok {
+		return utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+	}
+
+	return utils.SuccessResponse(c, http.StatusOK, "Token is valid", entities.TokenVerificationResponse{
+		Valid:       true,
+		UserID:      claims.UserID,
+		Email:       claims.Email,
+		Role:        claims.Role,
+		Permissions: claims.Role.Permissions(),
+		ExpiresAt:   claims.ExpiresAt(),
+	})
+}
+
+// JWKS publishes the public keys that verify access tokens
+// @Summary JSON Web Key Set
+// @Description Public keys for verifying access tokens locally. Tokens name their key in the kid header; refetch the set when an unknown kid appears.
+// @Tags Authentication
+// @Produce json
+// @Success 200 {object} entities.JSONWebKeySet "Current signing keys"
+// @Router /.well-known/jwks.json [get]
+func (h *AuthHandler) JWKS(c echo.Context) error {
+	// Verifiers may cache the set briefly; a new key is always fetched on an unknown kid
+	c.Response().Header().Set("Cache-Control", "public, max-age=300")
+	return c.JSON(http.StatusOK, h.authUseCase.GetJWKS(c.Request().Context()))
+}
+
+// clientInfo returns where the request came from, for login sessions and security events
+func clientInfo(c echo.Context) entities.ClientInfo {
+	return entities.ClientInfo{
+		IPAddress: c.RealIP(),
+		UserAgent: c.Request().UserAgent(),
+	}
+}
@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
+	"go-transaction-service/internal/domain/entities"
+	"go-transaction-service/internal/usecase"
+	"go-transaction-service/pkg/utils"
+	"go.uber.org/zap"
+)
+
+// FeeHandler handles fee quotes and the fee rules behind them
+type FeeHandler struct {
+	feeUseCase usecase.FeeUseCase
+	validator  *validator.Validate
+	logger     *zap.Logger
+}
+
+// NewFeeHandler creates a new fee handler
+func NewFeeHandler(feeUseCase usecase.FeeUseCase, validator *validator.Validate, logger *zap.Logger) *FeeHandler {
+	return &FeeHandler{
+		feeUseCase: feeUseCase,
+		validator:  validator,
+		logger:     logger,
+	}
+}
+
+// Quote returns the fee of a transaction before it is made
+// @Summary Quote transaction fee
+// @Description Get the fee a top-up, payment or transfer of the given amount would be charged on top of the amount. Top-up fees depend on the payment method.
+// @Tags Transactions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Param type query string true "Transaction type" Enums(topup, payment, transfer)
+// @Param amount query string true "Amount to move"
+// @Param payment_method query string false "Payment method of a top-up"
+// @Success 200 {object} entities.APIResponse{data=entities.FeeQuote} "Fee quoted successfully"
+// @Failure 400 {object} entities.APIResponse{error=entities.ErrorInfo} "Bad request - invalid amount"
+// @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid or missing token"
+// @Failure 422 {object} entities.APIResponse{data=[]entities.ValidationError} "Validation failed"
+// @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
+// @Router /transactions/fees/quote [get]
+func (h *FeeHandler) Quote(c echo.Context) error {
+	amount, err := decimal.NewFromString(c.QueryParam("amount"))
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid amount")
+	}
+
+	req := entities.FeeQuoteRequest{
+		Type:          entities.TransactionType(c.QueryParam("type")),
+		Amount:        amount,
+		PaymentMethod: c.QueryParam("payment_method"),
+	}
+	if err := h.validator.Struct(req); err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+
+	quote, err := h.feeUseCase.Quote(c.Request().Context(), req.Type, req.PaymentMethod, req.Amount)
+	if err != nil {
+		h.logger.Error("Failed to quote fee",
+			zap.Error(err),
+			zap.String("type", string(req.Type)),
+			zap.String("payment_method", req.PaymentMethod))
+		return utils.HandleError(c, err)
+	}
+
+	return utils.SuccessResponse(c, http.StatusOK, "Fee quoted successfully", quote)
+}
+
+// ListFeeRules lists fee rules
+// @Summary List fee rules
+// @Description List all fee rules by transaction type and payment method. Requires the fees:manage permission.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Success 200 {object} entities.APIResponse{data=[]entities.FeeRule} "Fee rules retrieved successfully"
+// @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid or missing token"
+// @Failure 403 {object} entities.APIResponse{error=entities.ErrorInfo} "Forbidden - insufficient permissions"
+// @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
+// @Router /admin/fee-rules [get]
+func (h *FeeHandler) ListFeeRules(c echo.Context) error {
+	rules, err := h.feeUseCase.ListFeeRules(c.Request().Context())
+	if err != nil {
+		h.logger.Error("Failed to list fee rules", zap.Error(err))
+		return utils.HandleError(c, err)
+	}
+
+	return utils.SuccessResponse(c, http.StatusOK, "Fee rules retrieved successfully", rules)
+}
+
+// CreateFeeRule creates a fee rule
+// @Summary Create fee rule
+// @Description Create the fee rule of a transaction type, for one payment method or for any. There can be one rule per transaction type and payment method. Requires the fees:manage permission.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Param request body entities.FeeRuleRequest true "Fee rule details"
+// @Success 201 {object} entities.APIResponse{data=entities.FeeRule} "Fee rule created successfully"
+// @Failure 400 {object} entities.APIResponse{error=entities.ErrorInfo} "Bad request - invalid input format or fee settings"
+// @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid or missing token"
+// @Failure 403 {object} entities.APIResponse{error=entities.ErrorInfo} "Forbidden - insufficient permissions"
+// @Failure 409 {object} entities.APIResponse{error=entities.ErrorInfo} "A rule for the transaction type and payment method already exists"
+// @Failure 422 {object} entities.APIResponse{data=[]entities.ValidationError} "Validation failed"
+// @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
+// @Router /admin/fee-rules [post]
+func (h *FeeHandler) CreateFeeRule(c echo.Context) error {
+	adminID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req entities.FeeRuleRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request format")
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+
+	rule, err := h.feeUseCase.CreateFeeRule(c.Request().Context(), adminID, req)
+	if err != nil {
+		h.logger.Error("Failed to create fee rule",
+			zap.Error(err),
+			zap.String("admin_id", adminID.String()),
+			zap.String("transaction_type", string(req.TransactionType)),
+			zap.String("payment_method", req.PaymentMethod))
+		return utils.HandleError(c, err)
+	}
+
+	h.logger.Info("Fee rule created",
+		zap.String("admin_id", adminID.String()),
+		zap.String("fee_rule_id", rule.ID.String()),
+		zap.String("transaction_type", string(rule.TransactionType)),
+		zap.String("payment_method", rule.PaymentMethod))
+
+	return utils.SuccessResponse(c, http.StatusCreated, "Fee rule created successfully", rule)
+}
+
+// UpdateFeeRule replaces a fee rule
+// @Summary Update fee rule
+// @Description Replace all settings of a fee rule. New transactions are charged by the new settings at once. Requires the fees:manage permission.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Param id path string true "Fee rule ID" format(uuid)
+// @Param request body entities.FeeRuleRequest true "Fee rule details"
+// @Success 200 {object} entities.APIResponse{data=entities.FeeRule} "Fee rule updated successfully"
+// @Failure 400 {object} entities.APIResponse{error=entities.ErrorInfo} "Bad request - invalid fee rule ID, input format or fee settings"
+// @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid or missing token"
+// @Failure 403 {object} entities.APIResponse{error=entities.ErrorInfo} "Forbidden - insufficient permissions"
+// @Failure 404 {object} entities.APIResponse{error=entities.ErrorInfo} "Fee rule not found"
+// @Failure 409 {object} entities.APIResponse{error=entities.ErrorInfo} "A rule for the transaction type and payment method already exists"
+// @Failure 422 {object} entities.APIResponse{data=[]entities.ValidationError} "Validation failed"
+// @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
+// @Router /admin/fee-rules/{id} [put]
+func (h *FeeHandler) UpdateFeeRule(c echo.Context) error {
+	adminID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+	}
+
+	ruleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid fee rule ID")
+	}
+
+	var req entities.FeeRuleRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request format")
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+
+	rule, err := h.feeUseCase.UpdateFeeRule(c.Request().Context(), ruleID, req)
+	if err != nil {
+		h.logger.Error("Failed to update fee rule",
+			zap.Error(err),
+			zap.String("admin_id", adminID.String()),
+			zap.String("fee_rule_id", ruleID.String()))
+		return utils.HandleError(c, err)
+	}
+
+	h.logger.Info("Fee rule updated",
+		zap.String("admin_id", adminID.String()),
+		zap.String("fee_rule_id", ruleID.String()))
+
+	return utils.SuccessResponse(c, http.StatusOK, "Fee rule updated successfully", rule)
+}
+
+// DeleteFeeRule deletes a fee rule
+// @Summary Delete fee rule
+// @Description Delete a fee rule; its transactions fall back to the rule for any payment method or the default fee. Requires the fees:manage permission.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Param id path string true "Fee rule ID" format(uuid)
+// @Success 200 {object} entities.APIResponse "Fee rule deleted successfully"
+// @Failure 400 {object} entities.APIResponse{error=entities.ErrorInfo} "Bad request - invalid fee rule ID"
+// @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid or missing token"
+// @Failure 403 {object} entities.APIResponse{error=entities.ErrorInfo} "Forbidden - insufficient permissions"
+// @Failure 404 {object} entities.APIResponse{error=entities.ErrorInfo} "Fee rule not found"
+// @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
+// @Router /admin/fee-rules/{id} [delete]
+func (h *FeeHandler) DeleteFeeRule(c echo.Context) error {
+	adminID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+	}
+
+	ruleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid fee rule ID")
+	}
+
+	if err := h.feeUseCase.DeleteFeeRule(c.Request().Context(), ruleID); err != nil {
+		h.logger.Error("Failed to delete fee rule",
+			zap.Error(err),
+			zap.String("admin_id", adminID.String()),
+			zap.String("fee_rule_id", ruleID.String()))
+		return utils.HandleError(c, err)
+	}
+
+	h.logger.Info("Fee rule deleted",
+		zap.String("admin_id", adminID.String()),
+		zap.String("fee_rule_id", ruleID.String()))
+
+	return utils.SuccessResponse(c, http.StatusOK, "Fee rule deleted successfully", nil)
+}
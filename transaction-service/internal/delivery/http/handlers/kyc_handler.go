@@ -0,0 +1,379 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"go-transaction-service/internal/domain/entities"
+	"go-transaction-service/internal/usecase"
+	"go-transaction-service/pkg/utils"
+	"go.uber.org/zap"
+)
+
+// kycFormOverhead is the room left in a submission body for the form fields and multipart framing
+const kycFormOverhead = 1 << 20
+
+// KYCHandler handles identity verification of users and its review by administrators
+type KYCHandler struct {
+	kycUseCase      usecase.KYCUseCase
+	maxDocumentSize int64
+	validator       *validator.Validate
+	logger          *zap.Logger
+}
+
+// NewKYCHandler creates a new KYC handler; maxDocumentSize bounds each uploaded file
+func NewKYCHandler(kycUseCase usecase.KYCUseCase, maxDocumentSize int64, validator *validator.Validate, logger *zap.Logger) *KYCHandler {
+	return &KYCHandler{
+		kycUseCase:      kycUseCase,
+		maxDocumentSize: maxDocumentSize,
+		validator:       validator,
+		logger:          logger,
+	}
+}
+
+// GetStatus returns the KYC tier of the current user
+// @Summary Get KYC status
+// @Description Get the KYC tier of the current user, the features it unlocks and the latest submission with its review outcome
+// @Tags KYC
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} entities.APIResponse{data=entities.KYCStatusResponse} "KYC status retrieved successfully"
+// @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid or missing token"
+// @Failure 404 {object} entities.APIResponse{error=entities.ErrorInfo} "User not found"
+// @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
+// @Router /user/kyc [get]
+func (h *KYCHandler) GetStatus(c echo.Context) error {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+	}
+
+	status, err := h.kycUseCase.GetStatus(c.Request().Context(), userID)
+	if err != nil {
+		return utils.HandleError(c, err)
+	}
+
+	return utils.SuccessResponse(c, http.StatusOK, "KYC status retrieved successfully", status)
+}
+
+// Submit submits identity data and documents for review
+// @Summary Submit KYC verification
+// @Description Submit identity data and documents to reach a higher KYC tier. The basic tier needs an identity document; the full tier also needs a selfie and a proof of address. Files must be JPEG, PNG or PDF. A user has at most one submission waiting for review.
+// @Tags KYC
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param tier formData string true "Tier to reach" Enums(basic, full)
+// @Param full_name formData string true "Legal name as on the identity document"
+// @Param date_of_birth formData string true "Date of birth (YYYY-MM-DD)"
+// @Param nationality formData string true "ISO 3166-1 alpha-2 country code"
+// @Param id_type formData string true "Kind of identity document" Enums(national_id, passport, driver_license)
+// @Param id_number formData string true "Number of the identity document"
+// @Param address formData string true "Residential address"
+// @Param id_document formData file true "Scan or photo of the identity document"
+// @Param selfie formData file false "Photo of the user holding the identity document"
+// @Param proof_of_address formData file false "Recent utility bill or bank statement"
+// @Success 201 {object} entities.APIResponse{data=entities.KYCSubmission} "KYC submission received successfully"
+// @Failure 400 {object} entities.APIResponse{error=entities.ErrorInfo} "Bad request - invalid form, tier or documents"
+// @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid or missing token"
+// @Failure 409 {object} entities.APIResponse{error=entities.ErrorInfo} "A submission is already waiting for review"
+// @Failure 413 {object} entities.APIResponse{error=entities.ErrorInfo} "Documents too large"
+// @Failure 422 {object} entities.APIResponse{data=[]entities.ValidationError} "Validation failed"
+// @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
+// @Router /user/kyc [post]
+func (h *KYCHandler) Submit(c echo.Context) error {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+	}
+
+	maxBody := int64(len(entities.KYCDocumentKinds))*h.maxDocumentSize + kycFormOverhead
+	c.Request().Body = http.MaxBytesReader(c.Response(), c.Request().Body, maxBody)
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return utils.ErrorResponse(c, http.StatusRequestEntityTooLarge, "Documents too large")
+		}
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request format")
+	}
+	defer form.RemoveAll()
+
+	var req entities.KYCSubmissionRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request format")
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+
+	var documents []entities.KYCDocumentUpload
+	for _, kind := range entities.KYCDocumentKinds {
+		files := form.File[string(kind)]
+		if len(files) == 0 {
+			continue
+		}
+		if len(files) > 1 {
+			return utils.ErrorResponse(c, http.StatusBadRequest, "Only one file may be uploaded per document")
+		}
+		if h.maxDocumentSize > 0 && files[0].Size > h.maxDocumentSize {
+			return utils.ErrorResponse(c, http.StatusRequestEntityTooLarge, "Documents too large")
+		}
+
+		file, err := files[0].Open()
+		if err != nil {
+			return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request format")
+		}
+		content, err := io.ReadAll(file)
+		file.Close()
+		if err != nil {
+			return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request format")
+		}
+
+		documents = append(documents, entities.KYCDocumentUpload{
+			Kind:     kind,
+			FileName: files[0].Filename,
+			Content:  content,
+		})
+	}
+
+	submission, err := h.kycUseCase.Submit(c.Request().Context(), userID, req, documents)
+	if err != nil {
+		h.logger.Error("Failed to submit KYC verification",
+			zap.Error(err),
+			zap.String("user_id", userID.String()),
+			zap.String("tier", string(req.Tier)))
+		return utils.HandleError(c, err)
+	}
+
+	h.logger.Info("KYC verification submitted",
+		zap.String("user_id", userID.String()),
+		zap.String("submission_id", submission.ID.String()),
+		zap.String("tier", string(submission.RequestedTier)))
+
+	return utils.SuccessResponse(c, http.StatusCreated, "KYC submission received successfully", submission)
+}
+
+// ListSubmissions lists KYC submissions
+// @Summary List KYC submissions
+// @Description List KYC submissions, oldest first, optionally by review status. Requires the kyc:review permission.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Param status query string false "Filter by status" Enums(pending, approved, rejected)
+// @Param limit query int false "Number of submissions per page (default: 20, max: 100)"
+// @Param offset query int false "Number of submissions to skip"
+// @Success 200 {object} entities.APIResponse{data=entities.KYCSubmissionListResponse} "KYC submissions retrieved successfully"
+// @Failure 400 {object} entities.APIResponse{error=entities.ErrorInfo} "Bad request - invalid parameters"
+// @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid or missing token"
+// @Failure 403 {object} entities.APIResponse{error=entities.ErrorInfo} "Forbidden - insufficient permissions"
+// @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
+// @Router /admin/kyc/submissions [get]
+func (h *KYCHandler) ListSubmissions(c echo.Context) error {
+	limit, offset, err := parseOffsetPage(c, usecase.DefaultUserPageSize, usecase.MaxUserPageSize)
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid pagination parameters")
+	}
+
+	status := entities.KYCSubmissionStatus(c.QueryParam("status"))
+	if status != "" && !status.IsValid() {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid filter parameters")
+	}
+
+	response, err := h.kycUseCase.ListSubmissions(c.Request().Context(), status, limit, offset)
+	if err != nil {
+		h.logger.Error("Failed to list KYC submissions", zap.Error(err))
+		return utils.HandleError(c, err)
+	}
+
+	return utils.SuccessResponse(c, http.StatusOK, "KYC submissions retrieved successfully", response)
+}
+
+// GetSubmission retrieves a KYC submission
+// @Summary Get KYC submission
+// @Description Retrieve the identity data, documents and review outcome of a KYC submission. Requires the kyc:review permission.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Param id path string true "Submission ID" format(uuid)
+// @Success 200 {object} entities.APIResponse{data=entities.KYCSubmission} "KYC submission retrieved successfully"
+// @Failure 400 {object} entities.APIResponse{error=entities.ErrorInfo} "Bad request - invalid submission ID"
+// @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid or missing token"
+// @Failure 403 {object} entities.APIResponse{error=entities.ErrorInfo} "Forbidden - insufficient permissions"
+// @Failure 404 {object} entities.APIResponse{error=entities.ErrorInfo} "KYC submission not found"
+// @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
+// @Router /admin/kyc/submissions/{id} [get]
+func (h *KYCHandler) GetSubmission(c echo.Context) error {
+	submissionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid submission ID")
+	}
+
+	submission, err := h.kycUseCase.GetSubmission(c.Request().Context(), submissionID)
+	if err != nil {
+		return utils.HandleError(c, err)
+	}
+
+	return utils.SuccessResponse(c, http.StatusOK, "KYC submission retrieved successfully", submission)
+}
+
+// GetDocument streams a document of a KYC submission
+// @Summary Get KYC document
+// @Description Download a document uploaded with a KYC submission. Requires the kyc:review permission.
+// @Tags Admin
+// @Produce image/jpeg,image/png,application/pdf
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Param id path string true "Submission ID" format(uuid)
+// @Param kind path string true "Document kind" Enums(id_document, selfie, proof_of_address)
+// @Success 200 {file} file "Document content"
+// @Failure 400 {object} entities.APIResponse{error=entities.ErrorInfo} "Bad request - invalid submission ID"
+// @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid or missing token"
+// @Failure 403 {object} entities.APIResponse{error=entities.ErrorInfo} "Forbidden - insufficient permissions"
+// @Failure 404 {object} entities.APIResponse{error=entities.ErrorInfo} "KYC submission or document not found"
+// @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
+// @Router /admin/kyc/submissions/{id}/documents/{kind} [get]
+func (h *KYCHandler) GetDocument(c echo.Context) error {
+	submissionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid submission ID")
+	}
+
+	document, content, err := h.kycUseCase.OpenDocument(c.Request().Context(), submissionID, entities.KYCDocumentKind(c.Param("kind")))
+	if err != nil {
+		h.logger.Error("Failed to open KYC document",
+			zap.Error(err),
+			zap.String("submission_id", submissionID.String()),
+			zap.String("kind", c.Param("kind")))
+		return utils.HandleError(c, err)
+	}
+	defer content.Close()
+
+	// Identity documents must not linger in shared caches
+	c.Response().Header().Set(echo.HeaderCacheControl, "no-store")
+	return c.Stream(http.StatusOK, document.ContentType, content)
+}
+
+// ApproveSubmission approves a KYC submission
+// @Summary Approve KYC submission
+// @Description Approve a pending KYC submission; the user is raised to the requested tier at once. Requires the kyc:review permission.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Param id path string true "Submission ID" format(uuid)
+// @Param request body entities.ApproveKYCRequest false "Approval note"
+// @Success 200 {object} entities.APIResponse{data=entities.KYCSubmission} "KYC submission approved successfully"
+// @Failure 400 {object} entities.APIResponse{error=entities.ErrorInfo} "Bad request - invalid submission ID or input format"
+// @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid or missing token"
+// @Failure 403 {object} entities.APIResponse{error=entities.ErrorInfo} "Forbidden - insufficient permissions"
+// @Failure 404 {object} entities.APIResponse{error=entities.ErrorInfo} "KYC submission not found"
+// @Failure 409 {object} entities.APIResponse{error=entities.ErrorInfo} "KYC submission has already been reviewed"
+// @Failure 422 {object} entities.APIResponse{data=[]entities.ValidationError} "Validation failed"
+// @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
+// @Router /admin/kyc/submissions/{id}/approve [post]
+func (h *KYCHandler) ApproveSubmission(c echo.Context) error {
+	reviewerID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+	}
+
+	submissionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid submission ID")
+	}
+
+	var req entities.ApproveKYCRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request format")
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+
+	submission, err := h.kycUseCase.ApproveSubmission(c.Request().Context(), reviewerID, submissionID, req.Reason)
+	if err != nil {
+		h.logger.Error("Failed to approve KYC submission",
+			zap.Error(err),
+			zap.String("reviewer_id", reviewerID.String()),
+			zap.String("submission_id", submissionID.String()))
+		return utils.HandleError(c, err)
+	}
+
+	h.logger.Info("KYC submission approved",
+		zap.String("reviewer_id", reviewerID.String()),
+		zap.String("submission_id", submissionID.String()),
+		zap.String("user_id", submission.UserID.String()),
+		zap.String("tier", string(submission.RequestedTier)))
+
+	return utils.SuccessResponse(c, http.StatusOK, "KYC submission approved successfully", submission)
+}
+
+// RejectSubmission rejects a KYC submission
+// @Summary Reject KYC submission
+// @Description Reject a pending KYC submission with a reason shown to the user, who may submit again. Requires the kyc:review permission.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Param id path string true "Submission ID" format(uuid)
+// @Param request body entities.RejectKYCRequest true "Rejection reason"
+// @Success 200 {object} entities.APIResponse{data=entities.KYCSubmission} "KYC submission rejected successfully"
+// @Failure 400 {object} entities.APIResponse{error=entities.ErrorInfo} "Bad request - invalid submission ID or input format"
+// @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid or missing token"
+// @Failure 403 {object} entities.APIResponse{error=entities.ErrorInfo} "Forbidden - insufficient permissions"
+// @Failure 404 {object} entities.APIResponse{error=entities.ErrorInfo} "KYC submission not found"
+// @Failure 409 {object} entities.APIResponse{error=entities.ErrorInfo} "KYC submission has already been reviewed"
+// @Failure 422 {object} entities.APIResponse{data=[]entities.ValidationError} "Validation failed"
+// @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
+// @Router /admin/kyc/submissions/{id}/reject [post]
+func (h *KYCHandler) RejectSubmission(c echo.Context) error {
+	reviewerID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+	}
+
+	submissionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid submission ID")
+	}
+
+	var req entities.RejectKYCRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request format")
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+
+	submission, err := h.kycUseCase.RejectSubmission(c.Request().Context(), reviewerID, submissionID, req.Reason)
+	if err != nil {
+		h.logger.Error("Failed to reject KYC submission",
+			zap.Error(err),
+			zap.String("reviewer_id", reviewerID.String()),
+			zap.String("submission_id", submissionID.String()))
+		return utils.HandleError(c, err)
+	}
+
+	h.logger.Info("KYC submission rejected",
+		zap.String("reviewer_id", reviewerID.String()),
+		zap.String("submission_id", submissionID.String()),
+		zap.String("user_id", submission.UserID.String()))
+
+	return utils.SuccessResponse(c, http.StatusOK, "KYC submission rejected successfully", submission)
+}
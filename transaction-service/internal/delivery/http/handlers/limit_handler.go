@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"go-transaction-service/internal/domain/entities"
+	"go-transaction-service/internal/usecase"
+	"go-transaction-service/pkg/utils"
+	"go.uber.org/zap"
+)
+
+// LimitHandler handles the per-user overrides of the transaction limits
+type LimitHandler struct {
+	limitUseCase usecase.LimitUseCase
+	logger       *zap.Logger
+}
+
+// NewLimitHandler creates a new limit handler
+func NewLimitHandler(limitUseCase usecase.LimitUseCase, logger *zap.Logger) *LimitHandler {
+	return &LimitHandler{
+		limitUseCase: limitUseCase,
+		logger:       logger,
+	}
+}
+
+// GetUserLimits returns the limits that apply to a user
+// @Summary Get user limits
+// @Description Get the transaction limits and maximum wallet balance that apply to a user, with the overrides an administrator set in place of the global limits. A zero limit is not enforced. Requires the users:read permission.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Param id path string true "User ID" format(uuid)
+// @Success 200 {object} entities.APIResponse{data=entities.UserLimitsResponse} "User limits retrieved successfully"
+// @Failure 400 {object} entities.APIResponse{error=entities.ErrorInfo} "Bad request - invalid user ID"
+// @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid or missing token"
+// @Failure 403 {object} entities.APIResponse{error=entities.ErrorInfo} "Forbidden - insufficient permissions"
+// @Failure 404 {object} entities.APIResponse{error=entities.ErrorInfo} "User not found"
+// @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
+// @Router /admin/users/{id}/limits [get]
+func (h *LimitHandler) GetUserLimits(c echo.Context) error {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID")
+	}
+
+	limits, err := h.limitUseCase.GetUserLimits(c.Request().Context(), userID)
+	if err != nil {
+		return utils.HandleError(c, err)
+	}
+
+	return utils.SuccessResponse(c, http.StatusOK, "User limits retrieved successfully", limits)
+}
+
+// UpdateUserLimits overrides the limits of a user
+// @Summary Override user limits
+// @Description Replace the limit overrides of a user. Limits omitted from a transaction type keep their global value and zero turns a limit off. Requires the users:write permission.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Param id path string true "User ID" format(uuid)
+// @Param request body entities.UpdateUserLimitsRequest true "Limit overrides"
+// @Success 200 {object} entities.APIResponse{data=entities.UserLimitsResponse} "User limits updated successfully"
+// @Failure 400 {object} entities.APIResponse{error=entities.ErrorInfo} "Bad request - invalid user ID, input format or limits"
+// @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid or missing token"
+// @Failure 403 {object} entities.APIResponse{error=entities.ErrorInfo} "Forbidden - insufficient permissions"
+// @Failure 404 {object} entities.APIResponse{error=entities.ErrorInfo} "User not found"
+// @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
+// @Router /admin/users/{id}/limits [put]
+func (h *LimitHandler) UpdateUserLimits(c echo.Context) error {
+	adminID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+	}
+
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID")
+	}
+
+	var req entities.UpdateUserLimitsRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request format")
+	}
+
+	limits, err := h.limitUseCase.UpdateUserLimits(c.Request().Context(), adminID, userID, req)
+	if err != nil {
+		h.logger.Error("Failed to update user limits",
+			zap.Error(err),
+			zap.String("admin_id", adminID.String()),
+			zap.String("user_id", userID.String()))
+		return utils.HandleError(c, err)
+	}
+
+	h.logger.Info("User limits updated",
+		zap.String("admin_id", adminID.String()),
+		zap.String("user_id", userID.String()))
+
+	return utils.SuccessResponse(c, http.StatusOK, "User limits updated successfully", limits)
+}
+
+// ResetUserLimits removes the limit overrides of a user
+// @Summary Reset user limits
+// @Description Remove the limit overrides of a user, who falls back to the global limits. Requires the users:write permission.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Param id path string true "User ID" format(uuid)
+// @Success 200 {object} entities.APIResponse "User limits reset successfully"
+// @Failure 400 {object} entities.APIResponse{error=entities.ErrorInfo} "Bad request - invalid user ID"
+// @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid or missing token"
+// @Failure 403 {object} entities.APIResponse{error=entities.ErrorInfo} "Forbidden - insufficient permissions"
+// @Failure 404 {object} entities.APIResponse{error=entities.ErrorInfo} "User not found"
+// @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
+// @Router /admin/users/{id}/limits [delete]
+func (h *LimitHandler) ResetUserLimits(c echo.Context) error {
+	adminID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+	}
+
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID")
+	}
+
+	if err := h.limitUseCase.ResetUserLimits(c.Request().Context(), userID); err != nil {
+		h.logger.Error("Failed to reset user limits",
+			zap.Error(err),
+			zap.String("admin_id", adminID.String()),
+			zap.String("user_id", userID.String()))
+		return utils.HandleError(c, err)
+	}
+
+	h.logger.Info("User limits reset",
+		zap.String("admin_id", adminID.String()),
+		zap.String("user_id", userID.String()))
+
+	return utils.SuccessResponse(c, http.StatusOK, "User limits reset successfully", nil)
+}
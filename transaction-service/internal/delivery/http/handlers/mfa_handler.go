@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"go-transaction-service/internal/domain/entities"
+	"go-transaction-service/internal/usecase"
+	"go-transaction-service/pkg/utils"
+	"go.uber.org/zap"
+)
+
+// MFAHandler handles two-factor authentication settings of the authenticated user
+type MFAHandler struct {
+	mfaUseCase usecase.MFAUseCase
+	validator  *validator.Validate
+	logger     *zap.Logger
+}
+
+// NewMFAHandler creates a new two-factor authentication handler
+func NewMFAHandler(mfaUseCase usecase.MFAUseCase, validator *validator.Validate, logger *zap.Logger) *MFAHandler {
+	return &MFAHandler{
+		mfaUseCase: mfaUseCase,
+		validator:  validator,
+		logger:     logger,
+	}
+}
+
+// Enroll starts a TOTP enrollment
+// @Summary Start two-factor enrollment
+// @Description Generate a TOTP secret and its otpauth URI for an authenticator app. Two-factor authentication is enabled once a code is confirmed at /user/mfa/confirm; starting again replaces an unconfirmed secret.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} entities.APIResponse{data=entities.MFAEnrollmentResponse} "Enrollment started"
+// @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid or missing token"
+// @Failure 409 {object} entities.APIResponse{error=entities.ErrorInfo} "Two-factor authentication already enabled"
+// @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
+// @Router /user/mfa/enroll [post]
+func (h *MFAHandler) Enroll(c echo.Context) error {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+	}
+
+	response, err := h.mfaUseCase.BeginEnrollment(c.Request().Context(), userID)
+	if err != nil {
+		h.logger.Error("Failed to start two-factor enrollment",
+			zap.Error(err),
+			zap.String("user_id", userID.String()))
+		return utils.HandleError(c, err)
+	}
+
+	return utils.SuccessResponse(c, http.StatusOK, "Two-factor enrollment started", response)
+}
+
+// Confirm enables two-factor authentication
+// @Summary Confirm two-factor enrollment
+// @Description Confirm the enrollment with a code from the authenticator app. Returns the recovery codes, which are shown only once.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body entities.MFACodeRequest true "Current TOTP code"
+// @Success 200 {object} entities.APIResponse{data=entities.MFARecoveryCodesResponse} "Two-factor authentication enabled"
+// @Failure 400 {object} entities.APIResponse{error=entities.ErrorInfo} "Bad request - invalid code or enrollment not started"
+// @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid or missing token"
+// @Failure 409 {object} entities.APIResponse{error=entities.ErrorInfo} "Two-factor authentication already enabled"
+// @Failure 422 {object} entities.APIResponse{data=[]entities.ValidationError} "Validation failed"
+// @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
+// @Router /user/mfa/confirm [post]
+func (h *MFAHandler) Confirm(c echo.Context) error {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req entities.MFACodeRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request format")
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+
+	response, err := h.mfaUseCase.ConfirmEnrollment(c.Request().Context(), userID, req)
+	if err != nil {
+		h.logger.Warn("Failed to confirm two-factor enrollment",
+			zap.Error(err),
+			zap.String("user_id", userID.String()))
+		return utils.HandleError(c, err)
+	}
+
+	h.logger.Info("Two-factor authentication enabled",
+		zap.String("user_id", userID.String()))
+
+	return utils.SuccessResponse(c, http.StatusOK, "Two-factor authentication enabled", response)
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes
+// @Summary Regenerate recovery codes
+// @Description Invalidate the remaining recovery codes and issue a new set. Requires a TOTP code; the new codes are shown only once.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body entities.MFACodeRequest true "Current TOTP code"
+// @Success 200 {object} entities.APIResponse{data=entities.MFARecoveryCodesResponse} "Recovery codes regenerated"
+// @Failure 400 {object} entities.APIResponse{error=entities.ErrorInfo} "Bad request - invalid code or two-factor authentication not enabled"
+// @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid or missing token"
+// @Failure 422 {object} entities.APIResponse{data=[]entities.ValidationError} "Validation failed"
+// @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
+// @Router /user/mfa/recovery-codes [post]
+func (h *MFAHandler) RegenerateRecoveryCodes(c echo.Context) error {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req entities.MFACodeRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request format")
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+
+	response, err := h.mfaUseCase.RegenerateRecoveryCodes(c.Request().Context(), userID, req)
+	if err != nil {
+		h.logger.Warn("Failed to regenerate recovery codes",
+			zap.Error(err),
+			zap.String("user_id", userID.String()))
+		return utils.HandleError(c, err)
+	}
+
+	h.logger.Info("Recovery codes regenerated",
+		zap.String("user_id", userID.String()))
+
+	return utils.SuccessResponse(c, http.StatusOK, "Recovery codes regenerated", response)
+}
+
+// Disable turns two-factor authentication off
+// @Summary Disable two-factor authentication
+// @Description Turn two-factor authentication off. Requires the password and a TOTP code or an unused recovery code.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body entities.MFADisableRequest true "Password and code"
+// @Success 200 {object} entities.APIResponse "Two-factor authentication disabled"
+// @Failure 400 {object} entities.APIResponse{error=entities.ErrorInfo} "Bad request - invalid password or code"
+// @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid or missing token"
+// @Failure 422 {object} entities.APIResponse{data=[]entities.ValidationError} "Validation failed"
+// @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
+// @Router /user/mfa/disable [post]
+func (h *MFAHandler) Disable(c echo.Context) error {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req entities.MFADisableRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request format")
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+
+	if err := h.mfaUseCase.Disable(c.Request().Context(), userID, req); err != nil {
+		h.logger.Warn("Failed to disable two-factor authentication",
+			zap.Error(err),
+			zap.String("user_id", userID.String()))
+		return utils.HandleError(c, err)
+	}
+
+	h.logger.Info("Two-factor authentication disabled",
+		zap.String("user_id", userID.String()))
+
+	return utils.SuccessResponse(c, http.StatusOK, "Two-factor authentication disabled", nil)
+}
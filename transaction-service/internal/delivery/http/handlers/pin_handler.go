@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"go-transaction-service/internal/domain/entities"
+	"go-transaction-service/internal/usecase"
+	"go-transaction-service/pkg/utils"
+	"go.uber.org/zap"
+)
+
+// PINHandler handles the transaction PIN of the authenticated user
+type PINHandler struct {
+	pinUseCase usecase.PINUseCase
+	validator  *validator.Validate
+	logger     *zap.Logger
+}
+
+// NewPINHandler creates a new transaction PIN handler
+func NewPINHandler(pinUseCase usecase.PINUseCase, validator *validator.Validate, logger *zap.Logger) *PINHandler {
+	return &PINHandler{
+		pinUseCase: pinUseCase,
+		validator:  validator,
+		logger:     logger,
+	}
+}
+
+// SetPIN sets the first transaction PIN
+// @Summary Set transaction PIN
+// @Description Set the 6-digit PIN required for payments and transfers. Requires the password.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body entities.SetPINRequest true "New PIN and password"
+// @Success 200 {object} entities.APIResponse "Transaction PIN set successfully"
+// @Failure 400 {object} entities.APIResponse{error=entities.ErrorInfo} "Bad request - invalid password"
+// @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid or missing token"
+// @Failure 409 {object} entities.APIResponse{error=entities.ErrorInfo} "Transaction PIN already set"
+// @Failure 422 {object} entities.APIResponse{data=[]entities.ValidationError} "Validation failed"
+// @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
+// @Router /user/pin [post]
+func (h *PINHandler) SetPIN(c echo.Context) error {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req entities.SetPINRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request format")
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+
+	if err := h.pinUseCase.SetPIN(c.Request().Context(), userID, req); err != nil {
+		h.logger.Warn("Failed to set transaction PIN",
+			zap.Error(err),
+			zap.String("user_id", userID.String()))
+		return utils.HandleError(c, err)
+	}
+
+	h.logger.Info("Transaction PIN set",
+		zap.String("user_id", userID.String()))
+
+	return utils.SuccessResponse(c, http.StatusOK, "Transaction PIN set successfully", nil)
+}
+
+// ChangePIN replaces the transaction PIN
+// @Summary Change transaction PIN
+// @Description Replace the transaction PIN. A wrong current PIN counts towards the lockout.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body entities.ChangePINRequest true "Current and new PIN"
+// @Success 200 {object} entities.APIResponse "Transaction PIN changed successfully"
+// @Failure 400 {object} entities.APIResponse{error=entities.ErrorInfo} "Bad request - wrong current PIN or no PIN set"
+// @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid or missing token"
+// @Failure 403 {object} entities.APIResponse{error=entities.ErrorInfo} "Transaction PIN locked"
+// @Failure 422 {object} entities.APIResponse{data=[]entities.ValidationError} "Validation failed"
+// @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
+// @Router /user/pin [put]
+func (h *PINHandler) ChangePIN(c echo.Context) error {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req entities.ChangePINRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request format")
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+
+	if err := h.pinUseCase.ChangePIN(c.Request().Context(), userID, req); err != nil {
+		h.logger.Warn("Failed to change transaction PIN",
+			zap.Error(err),
+			zap.String("user_id", userID.String()))
+		return utils.HandleError(c, err)
+	}
+
+	h.logger.Info("Transaction PIN changed",
+		zap.String("user_id", userID.String()))
+
+	return utils.SuccessResponse(c, http.StatusOK, "Transaction PIN changed successfully", nil)
+}
+
+// ResetPIN replaces a forgotten transaction PIN
+// @Summary Reset transaction PIN
+// @Description Replace a forgotten transaction PIN and lift a lockout. Requires the password and, when two-factor authentication is enabled, a TOTP or recovery code.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body entities.ResetPINRequest true "Password, code and new PIN"
+// @Success 200 {object} entities.APIResponse "Transaction PIN reset successfully"
+// @Failure 400 {object} entities.APIResponse{error=entities.ErrorInfo} "Bad request - invalid password or code"
+// @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid or missing token"
+// @Failure 422 {object} entities.APIResponse{data=[]entities.ValidationError} "Validation failed"
+// @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
+// @Router /user/pin/reset [post]
+func (h *PINHandler) ResetPIN(c echo.Context) error {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req entities.ResetPINRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request format")
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+
+	if err := h.pinUseCase.ResetPIN(c.Request().Context(), userID, req); err != nil {
+		h.logger.Warn("Failed to reset transaction PIN",
+			zap.Error(err),
+			zap.String("user_id", userID.String()))
+		return utils.HandleError(c, err)
+	}
+
+	h.logger.Info("Transaction PIN reset",
+		zap.String("user_id", userID.String()))
+
+	return utils.SuccessResponse(c, http.StatusOK, "Transaction PIN reset successfully", nil)
+}
@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"go-transaction-service/internal/domain/entities"
+	"go-transaction-service/internal/usecase"
+	"go-transaction-service/pkg/utils"
+	"go.uber.org/zap"
+)
+
+// ProfileHandler handles the personal details and credentials of the authenticated user
+type ProfileHandler struct {
+	profileUseCase usecase.ProfileUseCase
+	accountUseCase usecase.AccountUseCase
+	validator      *validator.Validate
+	logger         *zap.Logger
+}
+
+// NewProfileHandler creates a new profile handler
+func NewProfileHandler(profileUseCase usecase.ProfileUseCase, accountUseCase usecase.AccountUseCase, validator *validator.Validate, logger *zap.Logger) *ProfileHandler {
+	return &ProfileHandler{
+		profileUseCase: profileUseCase,
+		accountUseCase: accountUseCase,
+		validator:      validator,
+		logger:         logger,
+	}
+}
+
+// UpdateProfile changes the name and phone number
+// @Summary Update user profile
+// @Description Change the first name, last name or phone number of the authenticated user. Omitted fields are left unchanged.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Param request body entities.UpdateProfileRequest true "Profile fields to change"
+// @Success 200 {object} entities.APIResponse{data=entities.UserProfile} "Profile updated successfully"
+// @Failure 400 {object} entities.APIResponse{error=entities.ErrorInfo} "Bad request - invalid input format"
+// @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid or missing token"
+// @Failure 403 {object} entities.APIResponse{error=entities.ErrorInfo} "User account is inactive"
+// @Failure 422 {object} entities.APIResponse{data=[]entities.ValidationError} "Validation failed"
+// @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
+// @Router /user/profile [put]
+// @Router /profile [put]
+func (h *ProfileHandler) UpdateProfile(c echo.Context) error {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req entities.UpdateProfileRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request format")
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+
+	profile, err := h.profileUseCase.UpdateProfile(c.Request().Context(), userID, req)
+	if err != nil {
+		h.logger.Error("Failed to update profile",
+			zap.Error(err),
+			zap.String("user_id", userID.String()))
+		return utils.HandleError(c, err)
+	}
+
+	return utils.SuccessResponse(c, http.StatusOK, "Profile updated successfully", profile)
+}
+
+// ChangePassword replaces the password
+// @Summary Change password
+// @Description Replace the password after checking the current one. Every other session of the user is ended.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body entities.ChangePasswordRequest true "Current and new password"
+// @Success 200 {object} entities.APIResponse "Password changed successfully"
+// @Failure 400 {object} entities.APIResponse{error=entities.ErrorInfo} "Bad request - invalid current password"
+// @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid or missing token"
+// @Failure 403 {object} entities.APIResponse{error=entities.ErrorInfo} "User account is inactive"
+// @Failure 422 {object} entities.APIResponse{data=[]entities.ValidationError} "Validation failed"
+// @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
+// @Router /user/password [put]
+func (h *ProfileHandler) ChangePassword(c echo.Context) error {
+	claims, ok := c.Get("claims").(*entities.JWTClaims)
+	if !ok {
+		return utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req entities.ChangePasswordRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request format")
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+
+	if err := h.profileUseCase.ChangePassword(c.Request().Context(), claims, req); err != nil {
+		h.logger.Warn("Failed to change password",
+			zap.Error(err),
+			zap.String("user_id", claims.UserID.String()))
+		return utils.HandleError(c, err)
+	}
+
+	h.logger.Info("Password changed",
+		zap.String("user_id", claims.UserID.String()),
+		zap.String("session_id", claims.SessionID.String()))
+
+	return utils.SuccessResponse(c, http.StatusOK, "Password changed successfully", nil)
+}
+
+// ChangeEmail replaces the email address
+// @Summary Change email address
+// @Description Replace the email address after checking the password. The new address has to be verified again with the link sent to it, and the old address is notified.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body entities.ChangeEmailRequest true "New email address and password"
+// @Success 200 {object} entities.APIResponse{data=entities.UserProfile} "Email address changed, verification email sent"
+// @Failure 400 {object} entities.APIResponse{error=entities.ErrorInfo} "Bad request - invalid password or unchanged address"
+// @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid or missing token"
+// @Failure 403 {object} entities.APIResponse{error=entities.ErrorInfo} "User account is inactive"
+// @Failure 409 {object} entities.APIResponse{error=entities.ErrorInfo} "Email already exists"
+// @Failure 422 {object} entities.APIResponse{data=[]entities.ValidationError} "Validation failed"
+// @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
+// @Router /user/email [put]
+func (h *ProfileHandler) ChangeEmail(c echo.Context) error {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req entities.ChangeEmailRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request format")
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+
+	profile, err := h.accountUseCase.ChangeEmail(c.Request().Context(), userID, req)
+	if err != nil {
+		h.logger.Warn("Failed to change email address",
+			zap.Error(err),
+			zap.String("user_id", userID.String()))
+		return utils.HandleError(c, err)
+	}
+
+	h.logger.Info("Email address changed",
+		zap.String("user_id", userID.String()))
+
+	return utils.SuccessResponse(c, http.StatusOK, "Email address changed, please verify the new address", profile)
+}
+
+// CloseAccount closes the account of the authenticated user
+// @Summary Close account
+// @Description Close the account after checking the password. Only allowed with a zero balance and no transactions in progress. All sessions are ended and the account can no longer log in.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body entities.CloseAccountRequest true "Password"
+// @Success 200 {object} entities.APIResponse "Account closed successfully"
+// @Failure 400 {object} entities.APIResponse{error=entities.ErrorInfo} "Bad request - invalid password"
+// @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid or missing token"
+// @Failure 403 {object} entities.APIResponse{error=entities.ErrorInfo} "User account is inactive"
+// @Failure 409 {object} entities.APIResponse{error=entities.ErrorInfo} "Balance not zero or transactions in progress"
+// @Failure 422 {object} entities.APIResponse{data=[]entities.ValidationError} "Validation failed"
+// @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
+// @Router /user/close [post]
+func (h *ProfileHandler) CloseAccount(c echo.Context) error {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req entities.CloseAccountRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request format")
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return utils.ValidationErrorResponse(c, err)
+	}
+
+	if err := h.profileUseCase.CloseAccount(c.Request().Context(), userID, req); err != nil {
+		h.logger.Warn("Failed to close account",
+			zap.Error(err),
+			zap.String("user_id", userID.String()))
+		return utils.HandleError(c, err)
+	}
+
+	h.logger.Info("Account closed",
+		zap.String("user_id", userID.String()))
+
+	return utils.SuccessResponse(c, http.StatusOK, "Account closed successfully", nil)
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"go-transaction-service/internal/domain/entities"
+	"go-transaction-service/internal/usecase"
+	"go-transaction-service/pkg/utils"
+	"go.uber.org/zap"
+)
+
+// SessionHandler handles the login sessions of the authenticated user
+type SessionHandler struct {
+	sessionUseCase usecase.SessionUseCase
+	logger         *zap.Logger
+}
+
+// NewSessionHandler creates a new session handler
+func NewSessionHandler(sessionUseCase usecase.SessionUseCase, logger *zap.Logger) *SessionHandler {
+	return &SessionHandler{
+		sessionUseCase: sessionUseCase,
+		logger:         logger,
+	}
+}
+
+// ListSessions lists where the user is logged in
+// @Summary List active sessions
+// @Description List the devices the authenticated user is logged in on, with the user agent, IP address and last use of each. The session of the current token is marked.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} entities.APIResponse{data=entities.SessionListResponse} "Sessions retrieved successfully"
+// @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid or missing token"
+// @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
+// @Router /user/sessions [get]
+func (h *SessionHandler) ListSessions(c echo.Context) error {
+	claims, ok := c.Get("claims").(*entities.JWTClaims)
+	if !ok {
+		return utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+	}
+
+	response, err := h.sessionUseCase.ListSessions(c.Request().Context(), claims)
+	if err != nil {
+		h.logger.Error("Failed to list sessions",
+			zap.Error(err),
+			zap.String("user_id", claims.UserID.String()))
+		return utils.HandleError(c, err)
+	}
+
+	return utils.SuccessResponse(c, http.StatusOK, "Sessions retrieved successfully", response)
+}
+
+// RevokeSession signs a session out remotely
+// @Summary Sign out a session
+// @Description End a session of the authenticated user. Its refresh token and access tokens stop working immediately.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Session ID" format(uuid)
+// @Success 200 {object} entities.APIResponse "Session signed out successfully"
+// @Failure 400 {object} entities.APIResponse{error=entities.ErrorInfo} "Bad request - invalid session ID"
+// @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid or missing token"
+// @Failure 404 {object} entities.APIResponse{error=entities.ErrorInfo} "Session not found"
+// @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
+// @Router /user/sessions/{id} [delete]
+func (h *SessionHandler) RevokeSession(c echo.Context) error {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+	}
+
+	sessionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid session ID")
+	}
+
+	if err := h.sessionUseCase.RevokeSession(c.Request().Context(), userID, sessionID); err != nil {
+		h.logger.Warn("Failed to sign out session",
+			zap.Error(err),
+			zap.String("user_id", userID.String()),
+			zap.String("session_id", sessionID.String()))
+		return utils.HandleError(c, err)
+	}
+
+	h.logger.Info("Session signed out",
+		zap.String("user_id", userID.String()),
+		zap.String("session_id", sessionID.String()))
+
+	return utils.SuccessResponse(c, http.StatusOK, "Session signed out successfully", nil)
+}
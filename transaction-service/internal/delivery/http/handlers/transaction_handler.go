@@ -1,11 +1,17 @@
 package handlers
 
 import (
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
 	"go-transaction-service/internal/domain/entities"
 	"go-transaction-service/internal/usecase"
 	"go-transaction-service/pkg/utils"
@@ -14,17 +20,19 @@ import (
 
 // TransactionHandler handles transaction-related HTTP requests
 type TransactionHandler struct {
-	transactionUseCase usecase.TransactionUseCase
-	validator          *validator.Validate
-	logger             *zap.Logger
+	transactionUseCase     usecase.TransactionUseCase
+	paymentCallbackUseCase usecase.PaymentCallbackUseCase
+	validator              *validator.Validate
+	logger                 *zap.Logger
 }
 
 // NewTransactionHandler creates a new transaction handler
-func NewTransactionHandler(transactionUseCase usecase.TransactionUseCase, validator *validator.Validate, logger *zap.Logger) *TransactionHandler {
+func NewTransactionHandler(transactionUseCase usecase.TransactionUseCase, paymentCallbackUseCase usecase.PaymentCallbackUseCase, validator *validator.Validate, logger *zap.Logger) *TransactionHandler {
 	return &TransactionHandler{
-		transactionUseCase: transactionUseCase,
-		validator:          validator,
-		logger:             logger,
+		transactionUseCase:     transactionUseCase,
+		paymentCallbackUseCase: paymentCallbackUseCase,
+		validator:              validator,
+		logger:                 logger,
 	}
 }
 
@@ -35,11 +43,15 @@ func NewTransactionHandler(transactionUseCase usecase.TransactionUseCase, valida
 // @Accept json
 // @Produce json
 // @Security BearerAuth
+// @Security APIKeyAuth
 // @Param request body entities.TopupRequest true "Top-up request details"
+// @Param Idempotency-Key header string false "Unique key that makes retries of this request safe"
 // @Success 201 {object} entities.APIResponse{data=entities.TransactionResponse} "Topup transaction created successfully"
 // @Failure 400 {object} entities.APIResponse{error=entities.ErrorInfo} "Bad request - invalid input format"
 // @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid or missing token"
+// @Failure 403 {object} entities.APIResponse{error=entities.ErrorInfo} "Email address not verified"
 // @Failure 422 {object} entities.APIResponse{data=[]entities.ValidationError} "Validation failed"
+// @Failure 409 {object} entities.APIResponse{error=entities.ErrorInfo} "Idempotency-Key reused with a different request or still in progress"
 // @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
 // @Router /transactions/topup [post]
 func (h *TransactionHandler) Topup(c echo.Context) error {
@@ -83,18 +95,22 @@ func (h *TransactionHandler) Topup(c echo.Context) error {
 
 // Pay handles payment requests
 // @Summary Process payment
-// @Description Process payment from user's wallet balance to another user
+// @Description Process payment from user's wallet balance to another user. Requires the transaction PIN; repeated wrong PINs lock outgoing transactions for a while.
 // @Tags Transactions
 // @Accept json
 // @Produce json
 // @Security BearerAuth
+// @Security APIKeyAuth
 // @Param request body entities.PaymentRequest true "Payment request details"
+// @Param Idempotency-Key header string false "Unique key that makes retries of this request safe"
 // @Success 201 {object} entities.APIResponse{data=entities.TransactionResponse} "Payment processed successfully"
 // @Failure 400 {object} entities.APIResponse{error=entities.ErrorInfo} "Bad request - invalid input format"
 // @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid or missing token"
 // @Failure 422 {object} entities.APIResponse{data=[]entities.ValidationError} "Validation failed"
 // @Failure 402 {object} entities.APIResponse{error=entities.ErrorInfo} "Insufficient balance"
+// @Failure 403 {object} entities.APIResponse{error=entities.ErrorInfo} "Transaction PIN locked or email address not verified"
 // @Failure 404 {object} entities.APIResponse{error=entities.ErrorInfo} "Recipient user not found"
+// @Failure 409 {object} entities.APIResponse{error=entities.ErrorInfo} "Idempotency-Key reused with a different request or still in progress"
 // @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
 // @Router /transactions/pay [post]
 func (h *TransactionHandler) Pay(c echo.Context) error {
@@ -138,20 +154,181 @@ func (h *TransactionHandler) Pay(c echo.Context) error {
 	return utils.SuccessResponse(c, http.StatusCreated, "Payment processed successfully", response)
 }
 
+// Authorize handles payment authorization requests
+// @Summary Authorize payment
+// @Description Hold a payment amount and its fee on the user's balance for another user, who can then capture all or part of it, or void it. Held funds cannot be spent; the hold is released when the authorization expires. Requires the transaction PIN.
+// @Tags Transactions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Param request body entities.AuthorizePaymentRequest true "Authorization request details"
+// @Param Idempotency-Key header string false "Unique key that makes retries of this request safe"
+// @Success 201 {object} entities.APIResponse{data=entities.TransactionResponse} "Payment authorized successfully"
+// @Failure 400 {object} entities.APIResponse{error=entities.ErrorInfo} "Bad request - invalid input format"
+// @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid or missing token"
+// @Failure 422 {object} entities.APIResponse{data=[]entities.ValidationError} "Validation failed"
+// @Failure 402 {object} entities.APIResponse{error=entities.ErrorInfo} "Insufficient available balance"
+// @Failure 403 {object} entities.APIResponse{error=entities.ErrorInfo} "Transaction PIN locked or email address not verified"
+// @Failure 404 {object} entities.APIResponse{error=entities.ErrorInfo} "Recipient user not found"
+// @Failure 409 {object} entities.APIResponse{error=entities.ErrorInfo} "Idempotency-Key reused with a different request or still in progress"
+// @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
+// @Router /transactions/authorize [post]
+func (h *TransactionHandler) Authorize(c echo.Context) error {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.logger.Error("Failed to get user ID from context for payment authorization", zap.Error(err))
+		return utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req entities.AuthorizePaymentRequest
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("Failed to bind payment authorization request",
+			zap.Error(err),
+			zap.String("user_id", userID.String()))
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request format")
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		h.logger.Error("Payment authorization validation failed",
+			zap.Error(err),
+			zap.String("user_id", userID.String()))
+		return utils.ValidationErrorResponse(c, err)
+	}
+
+	response, err := h.transactionUseCase.AuthorizePayment(c.Request().Context(), userID, req)
+	if err != nil {
+		h.logger.Error("Payment authorization failed",
+			zap.Error(err),
+			zap.String("user_id", userID.String()),
+			zap.String("to_user_id", req.ToUserID.String()),
+			zap.String("amount", req.Amount.String()))
+		return utils.HandleError(c, err)
+	}
+
+	h.logger.Info("Payment authorized successfully",
+		zap.String("user_id", userID.String()),
+		zap.String("transaction_id", response.ID.String()),
+		zap.String("to_user_id", req.ToUserID.String()),
+		zap.String("amount", response.Amount.String()))
+
+	return utils.SuccessResponse(c, http.StatusCreated, "Payment authorized successfully", response)
+}
+
+// Capture handles capture requests of authorized payments
+// @Summary Capture authorized payment
+// @Description Charge all or part of a payment authorized to the user. Without an amount the full authorized amount is captured; the rest of the hold is released. Only the recipient of the payment can capture it.
+// @Tags Transactions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Param id path string true "Transaction ID" format(uuid)
+// @Param request body entities.CapturePaymentRequest false "Capture request details"
+// @Param Idempotency-Key header string false "Unique key that makes retries of this request safe"
+// @Success 200 {object} entities.APIResponse{data=entities.TransactionResponse} "Payment captured successfully"
+// @Failure 400 {object} entities.APIResponse{error=entities.ErrorInfo} "Bad request - invalid transaction ID or amount"
+// @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid or missing token"
+// @Failure 404 {object} entities.APIResponse{error=entities.ErrorInfo} "Transaction not found"
+// @Failure 409 {object} entities.APIResponse{error=entities.ErrorInfo} "Transaction is not authorized or the authorization has expired"
+// @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
+// @Router /transactions/{id}/capture [post]
+func (h *TransactionHandler) Capture(c echo.Context) error {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.logger.Error("Failed to get user ID from context for payment capture", zap.Error(err))
+		return utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+	}
+
+	transactionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid transaction ID")
+	}
+
+	var req entities.CapturePaymentRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request format")
+	}
+
+	response, err := h.transactionUseCase.CapturePayment(c.Request().Context(), userID, transactionID, req)
+	if err != nil {
+		h.logger.Warn("Payment capture failed",
+			zap.Error(err),
+			zap.String("user_id", userID.String()),
+			zap.String("transaction_id", transactionID.String()))
+		return utils.HandleError(c, err)
+	}
+
+	h.logger.Info("Payment captured successfully",
+		zap.String("user_id", userID.String()),
+		zap.String("transaction_id", transactionID.String()),
+		zap.String("amount", response.Amount.String()))
+
+	return utils.SuccessResponse(c, http.StatusOK, "Payment captured successfully", response)
+}
+
+// Void handles void requests of authorized payments
+// @Summary Void authorized payment
+// @Description Cancel a payment authorized to the user and release the hold on the sender's balance. Only the recipient of the payment can void it.
+// @Tags Transactions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Param id path string true "Transaction ID" format(uuid)
+// @Success 200 {object} entities.APIResponse{data=entities.TransactionResponse} "Payment voided successfully"
+// @Failure 400 {object} entities.APIResponse{error=entities.ErrorInfo} "Bad request - invalid transaction ID"
+// @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid or missing token"
+// @Failure 404 {object} entities.APIResponse{error=entities.ErrorInfo} "Transaction not found"
+// @Failure 409 {object} entities.APIResponse{error=entities.ErrorInfo} "Transaction is not authorized"
+// @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
+// @Router /transactions/{id}/void [post]
+func (h *TransactionHandler) Void(c echo.Context) error {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.logger.Error("Failed to get user ID from context for payment void", zap.Error(err))
+		return utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+	}
+
+	transactionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid transaction ID")
+	}
+
+	response, err := h.transactionUseCase.VoidPayment(c.Request().Context(), userID, transactionID)
+	if err != nil {
+		h.logger.Warn("Payment void failed",
+			zap.Error(err),
+			zap.String("user_id", userID.String()),
+			zap.String("transaction_id", transactionID.String()))
+		return utils.HandleError(c, err)
+	}
+
+	h.logger.Info("Payment voided successfully",
+		zap.String("user_id", userID.String()),
+		zap.String("transaction_id", transactionID.String()))
+
+	return utils.SuccessResponse(c, http.StatusOK, "Payment voided successfully", response)
+}
+
 // Transfer handles money transfer requests
 // @Summary Transfer money
-// @Description Transfer money from user's wallet to another user's wallet
+// @Description Transfer money from user's wallet to another user's wallet, found by email or phone number. Transfers have their own limits and fee. Requires the transaction PIN; repeated wrong PINs lock outgoing transactions for a while.
 // @Tags Transactions
 // @Accept json
 // @Produce json
 // @Security BearerAuth
+// @Security APIKeyAuth
 // @Param request body entities.TransferRequest true "Transfer request details"
-// @Success 201 {object} entities.APIResponse{data=entities.TransactionResponse} "Transfer processed successfully"
+// @Param Idempotency-Key header string false "Unique key that makes retries of this request safe"
+// @Success 201 {object} entities.APIResponse{data=entities.TransferReceipt} "Transfer processed successfully"
 // @Failure 400 {object} entities.APIResponse{error=entities.ErrorInfo} "Bad request - invalid input format"
 // @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid or missing token"
 // @Failure 422 {object} entities.APIResponse{data=[]entities.ValidationError} "Validation failed"
 // @Failure 402 {object} entities.APIResponse{error=entities.ErrorInfo} "Insufficient balance"
+// @Failure 403 {object} entities.APIResponse{error=entities.ErrorInfo} "Transaction PIN locked or email address not verified"
 // @Failure 404 {object} entities.APIResponse{error=entities.ErrorInfo} "Recipient user not found"
+// @Failure 409 {object} entities.APIResponse{error=entities.ErrorInfo} "Idempotency-Key reused with a different request or still in progress"
 // @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
 // @Router /transactions/transfer [post]
 func (h *TransactionHandler) Transfer(c echo.Context) error {
@@ -176,43 +353,44 @@ func (h *TransactionHandler) Transfer(c echo.Context) error {
 		return utils.ValidationErrorResponse(c, err)
 	}
 
-	// Convert to PaymentRequest for processing
-	paymentReq := entities.PaymentRequest{
-		Amount:      req.Amount,
-		Description: req.Description,
-		ToUserID:    req.ToUserID,
-	}
-
-	response, err := h.transactionUseCase.ProcessPayment(c.Request().Context(), userID, paymentReq)
+	receipt, err := h.transactionUseCase.TransferFunds(c.Request().Context(), userID, req)
 	if err != nil {
 		h.logger.Error("Transfer failed", 
 			zap.Error(err),
 			zap.String("user_id", userID.String()),
-			zap.String("to_user_id", req.ToUserID.String()),
 			zap.String("amount", req.Amount.String()))
 		return utils.HandleError(c, err)
 	}
 
 	h.logger.Info("Transfer processed successfully", 
 		zap.String("user_id", userID.String()),
-		zap.String("transaction_id", response.ID.String()),
-		zap.String("to_user_id", req.ToUserID.String()),
-		zap.String("amount", response.Amount.String()))
+		zap.String("transaction_id", receipt.TransactionID.String()),
+		zap.String("to_user_id", receipt.Recipient.ID.String()),
+		zap.String("amount", receipt.Amount.String()),
+		zap.String("fee", receipt.Fee.String()))
 
-	return utils.SuccessResponse(c, http.StatusCreated, "Transfer processed successfully", response)
+	return utils.SuccessResponse(c, http.StatusCreated, "Transfer processed successfully", receipt)
 }
 
 // GetTransactions retrieves user transaction history
 // @Summary Get transaction history
-// @Description Retrieve paginated list of user's transaction history
+// @Description Retrieve a filtered, cursor-paginated list of the user's transaction history
 // @Tags Transactions
 // @Accept json
 // @Produce json
 // @Security BearerAuth
+// @Security APIKeyAuth
 // @Param limit query int false "Number of transactions per page (max 100)" default(10)
-// @Param offset query int false "Number of transactions to skip" default(0)
+// @Param cursor query string false "Cursor returned as pagination.next_cursor by the previous page"
+// @Param order query string false "Sort order on creation time (desc, asc)" default(desc)
 // @Param type query string false "Filter by transaction type (topup, payment, transfer)"
-// @Param status query string false "Filter by transaction status (pending, completed, failed, cancelled)"
+// @Param status query string false "Filter by transaction status (pending, processing, completed, failed, cancelled)"
+// @Param direction query string false "Filter by direction from the user's point of view (incoming, outgoing)"
+// @Param min_amount query string false "Minimum amount (inclusive)"
+// @Param max_amount query string false "Maximum amount (inclusive)"
+// @Param from query string false "Created at or after (RFC3339 or YYYY-MM-DD)"
+// @Param to query string false "Created before (RFC3339, or YYYY-MM-DD to include that whole day)"
+// @Param search query string false "Search in reference and description"
 // @Success 200 {object} entities.APIResponse{data=entities.TransactionHistoryListResponse} "Transactions retrieved successfully"
 // @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid or missing token"
 // @Failure 400 {object} entities.APIResponse{error=entities.ErrorInfo} "Bad request - invalid query parameters"
@@ -226,42 +404,150 @@ func (h *TransactionHandler) GetTransactions(c echo.Context) error {
 	}
 
 	// Parse pagination parameters
-	limit, offset, err := h.parsePaginationParams(c)
+	page, err := h.parsePageRequest(c)
 	if err != nil {
-		h.logger.Error("Invalid pagination parameters", 
+		h.logger.Warn("Invalid pagination parameters",
 			zap.Error(err),
 			zap.String("user_id", userID.String()))
 		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid pagination parameters")
 	}
 
-	transactions, err := h.transactionUseCase.GetTransactionHistory(c.Request().Context(), userID, limit, offset)
+	// Parse filter parameters
+	filter, err := h.parseTransactionFilter(c)
+	if err != nil {
+		h.logger.Warn("Invalid transaction filter",
+			zap.Error(err),
+			zap.String("user_id", userID.String()))
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid filter parameters")
+	}
+
+	response, err := h.transactionUseCase.GetTransactionHistory(c.Request().Context(), userID, filter, page)
 	if err != nil {
-		h.logger.Error("Failed to get transactions", 
+		h.logger.Error("Failed to get transactions",
 			zap.Error(err),
 			zap.String("user_id", userID.String()))
 		return utils.HandleError(c, err)
 	}
 
-	// Convert to history response format
-	var historyResponses []entities.TransactionHistoryResponse
-	for _, tx := range transactions {
-		historyResponses = append(historyResponses, *tx)
+	h.logger.Debug("Transaction history retrieved",
+		zap.String("user_id", userID.String()),
+		zap.Int("count", response.Pagination.Count),
+		zap.Int("total", response.Pagination.Total))
+
+	return utils.SuccessResponse(c, http.StatusOK, "Transactions retrieved successfully", response)
+}
+
+// GetTransaction retrieves a single transaction
+// @Summary Get transaction details
+// @Description Retrieve full details of a transaction, including counterparty and status timeline. Only the sender or the recipient can read it.
+// @Tags Transactions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Param id path string true "Transaction ID" format(uuid)
+// @Success 200 {object} entities.APIResponse{data=entities.TransactionDetailResponse} "Transaction retrieved successfully"
+// @Failure 400 {object} entities.APIResponse{error=entities.ErrorInfo} "Bad request - invalid transaction ID"
+// @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid or missing token"
+// @Failure 404 {object} entities.APIResponse{error=entities.ErrorInfo} "Transaction not found"
+// @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
+// @Router /transactions/{id} [get]
+func (h *TransactionHandler) GetTransaction(c echo.Context) error {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.logger.Error("Failed to get user ID from context for transaction details", zap.Error(err))
+		return utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
 	}
 
-	response := entities.TransactionHistoryListResponse{
-		Transactions: historyResponses,
-		Pagination: entities.PaginationResponse{
-			Limit:  limit,
-			Offset: offset,
-			Count:  len(historyResponses),
-		},
+	transactionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid transaction ID")
 	}
 
-	h.logger.Debug("Transaction history retrieved", 
-		zap.String("user_id", userID.String()),
-		zap.Int("count", len(historyResponses)))
+	detail, err := h.transactionUseCase.GetTransactionDetail(c.Request().Context(), userID, transactionID)
+	if err != nil {
+		h.logger.Warn("Failed to get transaction details",
+			zap.Error(err),
+			zap.String("user_id", userID.String()),
+			zap.String("transaction_id", transactionID.String()))
+		return utils.HandleError(c, err)
+	}
 
-	return utils.SuccessResponse(c, http.StatusOK, "Transactions retrieved successfully", response)
+	return utils.SuccessResponse(c, http.StatusOK, "Transaction retrieved successfully", detail)
+}
+
+// GetTransferReceipt retrieves the receipt of a transfer
+// @Summary Get transfer receipt
+// @Description Retrieve the receipt of a transfer. Only the sender or the recipient can read it.
+// @Tags Transactions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Param id path string true "Transaction ID" format(uuid)
+// @Success 200 {object} entities.APIResponse{data=entities.TransferReceipt} "Receipt retrieved successfully"
+// @Failure 400 {object} entities.APIResponse{error=entities.ErrorInfo} "Bad request - invalid transaction ID"
+// @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid or missing token"
+// @Failure 404 {object} entities.APIResponse{error=entities.ErrorInfo} "Transfer not found"
+// @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
+// @Router /transactions/{id}/receipt [get]
+func (h *TransactionHandler) GetTransferReceipt(c echo.Context) error {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.logger.Error("Failed to get user ID from context for transfer receipt", zap.Error(err))
+		return utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+	}
+
+	transactionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid transaction ID")
+	}
+
+	receipt, err := h.transactionUseCase.GetTransferReceipt(c.Request().Context(), userID, transactionID)
+	if err != nil {
+		h.logger.Warn("Failed to get transfer receipt",
+			zap.Error(err),
+			zap.String("user_id", userID.String()),
+			zap.String("transaction_id", transactionID.String()))
+		return utils.HandleError(c, err)
+	}
+
+	return utils.SuccessResponse(c, http.StatusOK, "Receipt retrieved successfully", receipt)
+}
+
+// GetTransactionByReference retrieves a single transaction by its reference
+// @Summary Get transaction details by reference
+// @Description Retrieve full details of a transaction by its reference. Only the sender or the recipient can read it.
+// @Tags Transactions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Param reference path string true "Transaction reference" example(TXN-12345678)
+// @Success 200 {object} entities.APIResponse{data=entities.TransactionDetailResponse} "Transaction retrieved successfully"
+// @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid or missing token"
+// @Failure 404 {object} entities.APIResponse{error=entities.ErrorInfo} "Transaction not found"
+// @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
+// @Router /transactions/reference/{reference} [get]
+func (h *TransactionHandler) GetTransactionByReference(c echo.Context) error {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.logger.Error("Failed to get user ID from context for transaction details", zap.Error(err))
+		return utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+	}
+
+	reference := c.Param("reference")
+
+	detail, err := h.transactionUseCase.GetTransactionDetailByReference(c.Request().Context(), userID, reference)
+	if err != nil {
+		h.logger.Warn("Failed to get transaction details",
+			zap.Error(err),
+			zap.String("user_id", userID.String()),
+			zap.String("reference", reference))
+		return utils.HandleError(c, err)
+	}
+
+	return utils.SuccessResponse(c, http.StatusOK, "Transaction retrieved successfully", detail)
 }
 
 // GetBalance retrieves user's current balance
@@ -271,6 +557,7 @@ func (h *TransactionHandler) GetTransactions(c echo.Context) error {
 // @Accept json
 // @Produce json
 // @Security BearerAuth
+// @Security APIKeyAuth
 // @Success 200 {object} entities.APIResponse{data=entities.BalanceResponse} "Balance retrieved successfully"
 // @Failure 401 {object} entities.APIResponse{error=entities.ErrorInfo} "Unauthorized - invalid or missing token"
 // @Failure 404 {object} entities.APIResponse{error=entities.ErrorInfo} "User not found"
@@ -300,112 +587,132 @@ func (h *TransactionHandler) GetBalance(c echo.Context) error {
 
 // HandleCallback handles payment gateway callbacks
 // @Summary Handle payment callback
-// @Description Process payment gateway callbacks for transaction status updates
+// @Description Verify the signature of a payment gateway callback, store the raw payload for audit and update the transaction status
 // @Tags Webhooks
 // @Accept json
 // @Produce json
 // @Param request body entities.CallbackRequest true "Payment gateway callback payload"
 // @Success 200 {object} entities.APIResponse "Callback processed successfully"
-// @Failure 400 {object} entities.APIResponse{error=entities.ErrorInfo} "Bad request - invalid payload format"
+// @Failure 400 {object} entities.APIResponse{error=entities.ErrorInfo} "Bad request - invalid payload format or amount mismatch"
+// @Failure 403 {object} entities.APIResponse{error=entities.ErrorInfo} "Invalid callback signature"
 // @Failure 404 {object} entities.APIResponse{error=entities.ErrorInfo} "Transaction not found"
 // @Failure 500 {object} entities.APIResponse{error=entities.ErrorInfo} "Internal server error"
 // @Router /webhook/payment/callback [post]
 func (h *TransactionHandler) HandleCallback(c echo.Context) error {
-	var payload map[string]interface{}
-	if err := c.Bind(&payload); err != nil {
-		h.logger.Error("Failed to bind callback payload", 
+	// The raw body is kept as-is for signature auditing
+	payload, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		h.logger.Error("Failed to read callback payload",
 			zap.Error(err),
 			zap.String("remote_addr", c.RealIP()))
 		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid payload format")
 	}
 
-	// Extract order_id (reference) from payload
-	orderID, ok := payload["order_id"].(string)
-	if !ok {
-		h.logger.Error("Missing order_id in callback payload", 
-			zap.Any("payload", payload))
-		return utils.ErrorResponse(c, http.StatusBadRequest, "Missing order_id")
+	callback, err := h.paymentCallbackUseCase.HandleCallback(c.Request().Context(), payload)
+	if err != nil {
+		h.logger.Warn("Payment callback rejected",
+			zap.Error(err),
+			zap.String("order_id", callback.OrderID),
+			zap.String("gateway_status", callback.GatewayStatus),
+			zap.Bool("verified", callback.Verified),
+			zap.String("remote_addr", c.RealIP()))
+		return utils.HandleError(c, err)
 	}
 
-	// Extract transaction status from payload
-	transactionStatus, ok := payload["transaction_status"].(string)
-	if !ok {
-		h.logger.Error("Missing transaction_status in callback payload", 
-			zap.String("order_id", orderID))
-		return utils.ErrorResponse(c, http.StatusBadRequest, "Missing transaction_status")
+	h.logger.Info("Callback processed successfully",
+		zap.String("order_id", callback.OrderID),
+		zap.String("gateway_status", callback.GatewayStatus))
+
+	return utils.SuccessResponse(c, http.StatusOK, "Callback processed successfully", nil)
+}
+
+// parsePageRequest extracts and validates cursor pagination parameters from query
+func (h *TransactionHandler) parsePageRequest(c echo.Context) (entities.TransactionPageRequest, error) {
+	page := entities.TransactionPageRequest{Limit: entities.DefaultTransactionPageSize}
+
+	if limitStr := c.QueryParam("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			return page, fmt.Errorf("invalid limit %q", limitStr)
+		}
+		page.Limit = limit
 	}
 
-	// Map Midtrans status to our transaction status
-	status := h.mapPaymentGatewayStatus(transactionStatus)
-	if status == "" {
-		h.logger.Warn("Unknown transaction status in callback", 
-			zap.String("status", transactionStatus),
-			zap.String("order_id", orderID))
-		return utils.ErrorResponse(c, http.StatusBadRequest, "Unknown transaction status")
+	// Limit maximum results per page
+	if page.Limit > entities.MaxTransactionPageSize {
+		page.Limit = entities.MaxTransactionPageSize
 	}
 
-	// Process the callback
-	err := h.transactionUseCase.ProcessCallback(c.Request().Context(), orderID, status)
-	if err != nil {
-		h.logger.Error("Failed to process callback", 
-			zap.Error(err),
-			zap.String("order_id", orderID),
-			zap.String("status", string(status)))
-		return utils.HandleError(c, err)
+	if cursorStr := c.QueryParam("cursor"); cursorStr != "" {
+		cursor, err := entities.DecodeTransactionCursor(cursorStr)
+		if err != nil {
+			return page, err
+		}
+		page.Cursor = cursor
 	}
 
-	h.logger.Info("Callback processed successfully", 
-		zap.String("order_id", orderID),
-		zap.String("status", string(status)))
+	switch c.QueryParam("order") {
+	case "", "desc":
+	case "asc":
+		page.Ascending = true
+	default:
+		return page, fmt.Errorf("invalid order %q", c.QueryParam("order"))
+	}
 
-	return utils.SuccessResponse(c, http.StatusOK, "Callback processed successfully", nil)
+	return page, nil
 }
 
-// parsePaginationParams extracts and validates pagination parameters from query
-func (h *TransactionHandler) parsePaginationParams(c echo.Context) (limit, offset int, err error) {
-	// Parse pagination parameters
-	limitStr := c.QueryParam("limit")
-	offsetStr := c.QueryParam("offset")
-
-	limit = 10 // default limit
-	offset = 0 // default offset
+// parseTransactionFilter extracts transaction history filters from query
+func (h *TransactionHandler) parseTransactionFilter(c echo.Context) (entities.TransactionFilter, error) {
+	filter := entities.TransactionFilter{
+		Type:      entities.TransactionType(c.QueryParam("type")),
+		Status:    entities.TransactionStatus(c.QueryParam("status")),
+		Direction: entities.TransactionDirection(c.QueryParam("direction")),
+		Search:    strings.TrimSpace(c.QueryParam("search")),
+	}
 
-	if limitStr != "" {
-		if parsedLimit, parseErr := strconv.Atoi(limitStr); parseErr == nil && parsedLimit > 0 {
-			limit = parsedLimit
-		} else if parseErr != nil {
-			return 0, 0, parseErr
+	for param, target := range map[string]**decimal.Decimal{
+		"min_amount": &filter.MinAmount,
+		"max_amount": &filter.MaxAmount,
+	} {
+		if value := c.QueryParam(param); value != "" {
+			amount, err := decimal.NewFromString(value)
+			if err != nil {
+				return filter, fmt.Errorf("invalid %s %q", param, value)
+			}
+			*target = &amount
 		}
 	}
 
-	if offsetStr != "" {
-		if parsedOffset, parseErr := strconv.Atoi(offsetStr); parseErr == nil && parsedOffset >= 0 {
-			offset = parsedOffset
-		} else if parseErr != nil {
-			return 0, 0, parseErr
+	if value := c.QueryParam("from"); value != "" {
+		from, _, err := parseTimeParam(value)
+		if err != nil {
+			return filter, fmt.Errorf("invalid from %q", value)
 		}
+		filter.From = &from
 	}
 
-	// Limit maximum results per page
-	if limit > 100 {
-		limit = 100
+	if value := c.QueryParam("to"); value != "" {
+		to, dateOnly, err := parseTimeParam(value)
+		if err != nil {
+			return filter, fmt.Errorf("invalid to %q", value)
+		}
+		// A plain date includes the whole day
+		if dateOnly {
+			to = to.AddDate(0, 0, 1)
+		}
+		filter.To = &to
 	}
 
-	return limit, offset, nil
+	return filter, filter.Validate()
 }
 
-// mapPaymentGatewayStatus maps payment gateway status to internal transaction status
-func (h *TransactionHandler) mapPaymentGatewayStatus(gatewayStatus string) entities.TransactionStatus {
-	switch gatewayStatus {
-	case "settlement", "capture":
-		return entities.TransactionStatusCompleted
-	case "cancel", "expire":
-		return entities.TransactionStatusCancelled
-	case "deny", "failure":
-		return entities.TransactionStatusFailed
-	case "pending":
-		return entities.TransactionStatusPending
-	default:
-		return ""
+// parseTimeParam parses an RFC3339 timestamp or a YYYY-MM-DD date
+func parseTimeParam(value string) (t time.Time, dateOnly bool, err error) {
+	if t, err = time.Parse(time.RFC3339, value); err == nil {
+		return t, false, nil
 	}
+
+	t, err = time.Parse("2006-01-02", value)
+	return t, true, err
 }
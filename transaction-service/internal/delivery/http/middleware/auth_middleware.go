@@ -5,20 +5,23 @@ import (
 	"strings"
 
 	"github.com/labstack/echo/v4"
+	"go-transaction-service/internal/domain/entities"
 	"go-transaction-service/internal/usecase"
 	"go-transaction-service/pkg/utils"
 	"go.uber.org/zap"
 )
 
 type AuthMiddleware struct {
-	authUseCase usecase.AuthUseCase
-	logger      *zap.Logger
+	authUseCase   usecase.AuthUseCase
+	apiKeyUseCase usecase.APIKeyUseCase
+	logger        *zap.Logger
 }
 
-func NewAuthMiddleware(authUseCase usecase.AuthUseCase, logger *zap.Logger) *AuthMiddleware {
+func NewAuthMiddleware(authUseCase usecase.AuthUseCase, apiKeyUseCase usecase.APIKeyUseCase, logger *zap.Logger) *AuthMiddleware {
 	return &AuthMiddleware{
-		authUseCase: authUseCase,
-		logger:      logger,
+		authUseCase:   authUseCase,
+		apiKeyUseCase: apiKeyUseCase,
+		logger:        logger,
 	}
 }
 
@@ -53,6 +56,34 @@ func (m *AuthMiddleware) Authenticate(next echo.HandlerFunc) echo.HandlerFunc {
 	}
 }
 
+// AuthenticateAPIKey accepts an API key granting the scope in the X-API-Key header as well as a
+// bearer token. Requests without the header go through Authenticate.
+func (m *AuthMiddleware) AuthenticateAPIKey(scope entities.APIKeyScope) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		authenticate := m.Authenticate(next)
+
+		return func(c echo.Context) error {
+			key := c.Request().Header.Get(entities.APIKeyHeader)
+			if key == "" {
+				return authenticate(c)
+			}
+
+			claims, err := m.apiKeyUseCase.Authenticate(c.Request().Context(), key, scope)
+			if err != nil {
+				m.logger.Warn("API key authentication failed", zap.Error(err), zap.String("scope", string(scope)), zap.String("path", c.Path()))
+				return utils.HandleError(c, err)
+			}
+
+			// Set key owner info in context
+			c.Set("user_id", claims.UserID)
+			c.Set("user_email", claims.Email)
+			c.Set("claims", claims)
+
+			return next(c)
+		}
+	}
+}
+
 func (m *AuthMiddleware) OptionalAuthenticate(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		authHeader := c.Request().Header.Get("Authorization")
@@ -76,3 +107,47 @@ func (m *AuthMiddleware) OptionalAuthenticate(next echo.HandlerFunc) echo.Handle
 		return next(c)
 	}
 }
+
+// RequireRole only lets through users authenticated with one of the given roles.
+// It must run after Authenticate.
+func (m *AuthMiddleware) RequireRole(roles ...entities.Role) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			claims, ok := c.Get("claims").(*entities.JWTClaims)
+			if !ok {
+				return utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+			}
+
+			for _, role := range roles {
+				if claims.Role == role {
+					return next(c)
+				}
+			}
+
+			m.logger.Warn("Role not allowed", zap.String("user_id", claims.UserID.String()), zap.String("role", string(claims.Role)), zap.String("path", c.Path()))
+			return utils.ErrorResponse(c, http.StatusForbidden, "Insufficient role")
+		}
+	}
+}
+
+// RequirePermission only lets through users whose role grants all of the given permissions.
+// It must run after Authenticate.
+func (m *AuthMiddleware) RequirePermission(permissions ...entities.Permission) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			claims, ok := c.Get("claims").(*entities.JWTClaims)
+			if !ok {
+				return utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+			}
+
+			for _, permission := range permissions {
+				if !claims.HasPermission(permission) {
+					m.logger.Warn("Permission denied", zap.String("user_id", claims.UserID.String()), zap.String("permission", string(permission)), zap.String("path", c.Path()))
+					return utils.ErrorResponse(c, http.StatusForbidden, "Insufficient permissions")
+				}
+			}
+
+			return next(c)
+		}
+	}
+}
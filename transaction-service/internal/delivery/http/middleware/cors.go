@@ -5,6 +5,7 @@ import (
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"go-transaction-service/internal/domain/entities"
 )
 
 func CORSConfig() echo.MiddlewareFunc {
@@ -26,10 +27,13 @@ func CORSConfig() echo.MiddlewareFunc {
 			echo.HeaderAuthorization,
 			"X-Requested-With",
 			"X-CSRF-Token",
+			entities.IdempotencyKeyHeader,
+			entities.APIKeyHeader,
 		},
 		ExposeHeaders: []string{
 			echo.HeaderContentLength,
 			echo.HeaderContentType,
+			entities.IdempotencyReplayedHeader,
 		},
 		AllowCredentials: true,
 		MaxAge:           3600,
@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"go-transaction-service/internal/domain/entities"
+	"go-transaction-service/internal/usecase"
+	"go-transaction-service/pkg/utils"
+	"go.uber.org/zap"
+)
+
+// maxIdempotencyKeyLength bounds the size of client supplied keys
+const maxIdempotencyKeyLength = 255
+
+type IdempotencyMiddleware struct {
+	idempotencyUseCase usecase.IdempotencyUseCase
+	logger             *zap.Logger
+}
+
+func NewIdempotencyMiddleware(idempotencyUseCase usecase.IdempotencyUseCase, logger *zap.Logger) *IdempotencyMiddleware {
+	return &IdempotencyMiddleware{
+		idempotencyUseCase: idempotencyUseCase,
+		logger:             logger,
+	}
+}
+
+// Handle replays the stored response for a repeated Idempotency-Key and records the
+// response of the first request that got past validation. It must run after Authenticate.
+func (m *IdempotencyMiddleware) Handle(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		key := c.Request().Header.Get(entities.IdempotencyKeyHeader)
+		if key == "" {
+			return next(c)
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			return utils.ErrorResponse(c, http.StatusBadRequest, "Idempotency-Key is too long")
+		}
+
+		userID, err := utils.GetUserIDFromContext(c)
+		if err != nil {
+			return utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		}
+
+		// Read the body so it can be hashed, then restore it for the handler
+		body, err := io.ReadAll(c.Request().Body)
+		if err != nil {
+			return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+		}
+		c.Request().Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := c.Request().Context()
+		record, replay, err := m.idempotencyUseCase.Begin(ctx, userID, key, hashRequest(c.Request(), body))
+		if err != nil {
+			m.logger.Warn("Idempotency key rejected",
+				zap.Error(err),
+				zap.String("user_id", userID.String()),
+				zap.String("idempotency_key", key))
+			return utils.HandleError(c, err)
+		}
+
+		if replay {
+			m.logger.Info("Replaying idempotent response",
+				zap.String("user_id", userID.String()),
+				zap.String("idempotency_key", key))
+			c.Response().Header().Set(entities.IdempotencyReplayedHeader, "true")
+			return c.JSONBlob(record.ResponseCode, record.ResponseBody)
+		}
+
+		// Capture the response written by the handler
+		recorder := &responseRecorder{ResponseWriter: c.Response().Writer}
+		c.Response().Writer = recorder
+
+		// Write errors returned by the handler here so that their response is captured as well
+		if err := next(c); err != nil {
+			c.Error(err)
+		}
+
+		// A client error is raised before anything is committed, so the key is released for a
+		// corrected retry. Any other response is remembered: a server error may come after the
+		// money moved, and running the request again could move it twice.
+		status := c.Response().Status
+		if status >= 400 && status < 500 {
+			if err := m.idempotencyUseCase.Release(ctx, record); err != nil {
+				m.logger.Error("Failed to release idempotency key",
+					zap.Error(err),
+					zap.String("user_id", userID.String()),
+					zap.String("idempotency_key", key))
+			}
+			return nil
+		}
+
+		if err := m.idempotencyUseCase.Complete(ctx, record, status, recorder.body.Bytes()); err != nil {
+			m.logger.Error("Failed to store idempotent response",
+				zap.Error(err),
+				zap.String("user_id", userID.String()),
+				zap.String("idempotency_key", key))
+		}
+
+		return nil
+	}
+}
+
+// hashRequest fingerprints the method, path and body of a request
+func hashRequest(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// responseRecorder copies everything written to the client into a buffer
+type responseRecorder struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
@@ -17,23 +17,56 @@ import (
 type Router struct {
 	echo               *echo.Echo
 	authHandler        *handlers.AuthHandler
+	accountHandler     *handlers.AccountHandler
 	transactionHandler *handlers.TransactionHandler
+	adminHandler       *handlers.AdminHandler
+	mfaHandler         *handlers.MFAHandler
+	pinHandler         *handlers.PINHandler
+	profileHandler     *handlers.ProfileHandler
+	sessionHandler     *handlers.SessionHandler
+	apiKeyHandler      *handlers.APIKeyHandler
+	feeHandler         *handlers.FeeHandler
+	limitHandler       *handlers.LimitHandler
+	kycHandler         *handlers.KYCHandler
 	authMiddleware     *custommiddleware.AuthMiddleware
+	idempotency        *custommiddleware.IdempotencyMiddleware
 }
 
 // NewRouter creates a new HTTP router instance
 func NewRouter(
 	authHandler *handlers.AuthHandler,
+	accountHandler *handlers.AccountHandler,
 	transactionHandler *handlers.TransactionHandler,
+	adminHandler *handlers.AdminHandler,
+	mfaHandler *handlers.MFAHandler,
+	pinHandler *handlers.PINHandler,
+	profileHandler *handlers.ProfileHandler,
+	sessionHandler *handlers.SessionHandler,
+	apiKeyHandler *handlers.APIKeyHandler,
+	feeHandler *handlers.FeeHandler,
+	limitHandler *handlers.LimitHandler,
+	kycHandler *handlers.KYCHandler,
 	authMiddleware *custommiddleware.AuthMiddleware,
+	idempotency *custommiddleware.IdempotencyMiddleware,
 ) *Router {
 	e := echo.New()
 
 	return &Router{
 		echo:               e,
 		authHandler:        authHandler,
+		accountHandler:     accountHandler,
 		transactionHandler: transactionHandler,
+		adminHandler:       adminHandler,
+		mfaHandler:         mfaHandler,
+		pinHandler:         pinHandler,
+		profileHandler:     profileHandler,
+		sessionHandler:     sessionHandler,
+		apiKeyHandler:      apiKeyHandler,
+		feeHandler:         feeHandler,
+		limitHandler:       limitHandler,
+		kycHandler:         kycHandler,
 		authMiddleware:     authMiddleware,
+		idempotency:        idempotency,
 	}
 }
 
@@ -52,6 +85,10 @@ func NewRouter(
 // @in header
 // @name Authorization
 // @description Type "Bearer" followed by a space and JWT token.
+// @securityDefinitions.apikey APIKeyAuth
+// @in header
+// @name X-API-Key
+// @description API key of a service-to-service caller, accepted on the route groups of its scopes.
 func (r *Router) SetupRoutes() {
 	// Global middleware
 	r.echo.Use(middleware.Logger())
@@ -83,21 +120,31 @@ func (r *Router) SetupRoutes() {
 	r.echo.GET("/health", r.healthCheck)
 	r.echo.GET("/", r.rootHandler)
 
+	// Public keys for verifying access tokens
+	r.echo.GET("/.well-known/jwks.json", r.authHandler.JWKS)
+
 	// API routes
 	api := r.echo.Group("/api/v1")
 
 	// Public authentication routes
 	r.setupAuthRoutes(api)
 
-	// Protected routes
+	// Protected routes, which need a user login
 	protected := api.Group("")
 	protected.Use(r.authMiddleware.Authenticate)
 
+	// Session routes
+	protected.POST("/logout", r.authHandler.Logout)
+	protected.GET("/verify-token", r.authHandler.VerifyToken)
+
 	// User management routes
-	r.setupUserRoutes(protected)
+	r.setupUserRoutes(api, protected)
 
 	// Transaction routes
-	r.setupTransactionRoutes(protected)
+	r.setupTransactionRoutes(api)
+
+	// Back-office routes
+	r.setupAdminRoutes(api, protected)
 
 	// Webhook routes (public - for payment gateway callbacks)
 	r.setupWebhookRoutes(api)
@@ -114,29 +161,120 @@ func (r *Router) setupAuthRoutes(api *echo.Group) {
 	
 	auth.POST("/register", r.authHandler.Register)
 	auth.POST("/login", r.authHandler.Login)
-	
-	// Protected auth routes
-	authProtected := auth.Group("")
-	authProtected.Use(r.authMiddleware.Authenticate)
-	authProtected.POST("/refresh", r.authHandler.RefreshToken)
+	auth.POST("/refresh", r.authHandler.RefreshToken)
+	auth.POST("/mfa/verify", r.authHandler.VerifyMFA)
+
+	// Account recovery and email verification
+	auth.POST("/password/forgot", r.accountHandler.ForgotPassword)
+	auth.POST("/password/reset", r.accountHandler.ResetPassword)
+	auth.POST("/email/verify", r.accountHandler.VerifyEmail)
 }
 
 // setupUserRoutes configures user-related routes
-func (r *Router) setupUserRoutes(protected *echo.Group) {
+func (r *Router) setupUserRoutes(api, protected *echo.Group) {
+	// Profile and balance are also open to API keys with the user scope
+	profile := api.Group("/user", r.authMiddleware.AuthenticateAPIKey(entities.APIKeyScopeUser))
+
+	profile.GET("/profile", r.authHandler.GetProfile)
+	profile.GET("/balance", r.transactionHandler.GetBalance)
+	profile.PUT("/profile", r.profileHandler.UpdateProfile)
+
+	// Credentials and security settings need a user login
 	user := protected.Group("/user")
-	
-	user.GET("/profile", r.authHandler.GetProfile)
-	user.GET("/balance", r.transactionHandler.GetBalance)
+
+	user.POST("/email/verification", r.accountHandler.ResendVerification)
+	user.PUT("/password", r.profileHandler.ChangePassword)
+	user.PUT("/email", r.profileHandler.ChangeEmail)
+	user.POST("/close", r.profileHandler.CloseAccount)
+
+	// Login sessions
+	user.GET("/sessions", r.sessionHandler.ListSessions)
+	user.DELETE("/sessions/:id", r.sessionHandler.RevokeSession)
+
+	// The web client updates the profile at /profile
+	api.PUT("/profile", r.profileHandler.UpdateProfile, r.authMiddleware.AuthenticateAPIKey(entities.APIKeyScopeUser))
+
+	// Two-factor authentication settings
+	user.POST("/mfa/enroll", r.mfaHandler.Enroll)
+	user.POST("/mfa/confirm", r.mfaHandler.Confirm)
+	user.POST("/mfa/recovery-codes", r.mfaHandler.RegenerateRecoveryCodes)
+	user.POST("/mfa/disable", r.mfaHandler.Disable)
+
+	// Transaction PIN
+	user.POST("/pin", r.pinHandler.SetPIN)
+	user.PUT("/pin", r.pinHandler.ChangePIN)
+	user.POST("/pin/reset", r.pinHandler.ResetPIN)
+
+	// Identity verification
+	user.GET("/kyc", r.kycHandler.GetStatus)
+	user.POST("/kyc", r.kycHandler.Submit)
 }
 
-// setupTransactionRoutes configures transaction-related routes
-func (r *Router) setupTransactionRoutes(protected *echo.Group) {
-	transactions := protected.Group("/transactions")
+// setupTransactionRoutes configures transaction-related routes, also open to API keys with the transactions scope
+func (r *Router) setupTransactionRoutes(api *echo.Group) {
+	transactions := api.Group("/transactions", r.authMiddleware.AuthenticateAPIKey(entities.APIKeyScopeTransactions))
 	
-	transactions.POST("/topup", r.transactionHandler.Topup)
-	transactions.POST("/pay", r.transactionHandler.Pay)
-	transactions.POST("/transfer", r.transactionHandler.Transfer)
+	// Money-moving routes honour the Idempotency-Key header
+	transactions.POST("/topup", r.transactionHandler.Topup, r.idempotency.Handle)
+	transactions.POST("/pay", r.transactionHandler.Pay, r.idempotency.Handle)
+	transactions.POST("/transfer", r.transactionHandler.Transfer, r.idempotency.Handle)
+	transactions.POST("/authorize", r.transactionHandler.Authorize, r.idempotency.Handle)
+	transactions.POST("/:id/capture", r.transactionHandler.Capture, r.idempotency.Handle)
+	transactions.POST("/:id/void", r.transactionHandler.Void)
 	transactions.GET("", r.transactionHandler.GetTransactions)
+	transactions.GET("/fees/quote", r.feeHandler.Quote)
+	transactions.GET("/:id", r.transactionHandler.GetTransaction)
+	transactions.GET("/:id/receipt", r.transactionHandler.GetTransferReceipt)
+	transactions.GET("/reference/:reference", r.transactionHandler.GetTransactionByReference)
+}
+
+// setupAdminRoutes configures back-office routes for support staff and administrators, also open
+// to API keys with the admin scope whose owner has one of these roles
+func (r *Router) setupAdminRoutes(api, protected *echo.Group) {
+	staff := r.authMiddleware.RequireRole(entities.RoleSupport, entities.RoleAdmin)
+	admin := api.Group("/admin", r.authMiddleware.AuthenticateAPIKey(entities.APIKeyScopeAdmin), staff)
+
+	usersRead := r.authMiddleware.RequirePermission(entities.PermissionUsersRead)
+	usersWrite := r.authMiddleware.RequirePermission(entities.PermissionUsersWrite)
+	transactionsRead := r.authMiddleware.RequirePermission(entities.PermissionTransactionsRead)
+	transactionsReview := r.authMiddleware.RequirePermission(entities.PermissionTransactionsReview)
+	transactionsRefund := r.authMiddleware.RequirePermission(entities.PermissionTransactionsRefund)
+	feesManage := r.authMiddleware.RequirePermission(entities.PermissionFeesManage)
+	kycReview := r.authMiddleware.RequirePermission(entities.PermissionKYCReview)
+	rolesManage := r.authMiddleware.RequirePermission(entities.PermissionRolesManage)
+
+	admin.GET("/users", r.adminHandler.ListUsers, usersRead)
+	admin.GET("/users/:id", r.adminHandler.GetUser, usersRead)
+	admin.PATCH("/users/:id/status", r.adminHandler.UpdateUserStatus, usersWrite)
+	admin.PATCH("/users/:id/role", r.adminHandler.UpdateUserRole, rolesManage)
+	admin.POST("/users/:id/mfa/reset", r.adminHandler.ResetMFA, usersWrite)
+	admin.POST("/users/:id/unlock", r.adminHandler.UnlockUser, usersWrite)
+	admin.POST("/users/:id/balance/rebuild", r.adminHandler.RebuildWalletBalance, usersWrite)
+	admin.GET("/users/:id/security-events", r.adminHandler.ListSecurityEvents, usersRead)
+	admin.GET("/users/:id/limits", r.limitHandler.GetUserLimits, usersRead)
+	admin.PUT("/users/:id/limits", r.limitHandler.UpdateUserLimits, usersWrite)
+	admin.DELETE("/users/:id/limits", r.limitHandler.ResetUserLimits, usersWrite)
+	admin.GET("/transactions/review", r.adminHandler.ListTransactionsForReview, transactionsRead)
+	admin.GET("/transactions/:id", r.adminHandler.GetTransaction, transactionsRead)
+	admin.POST("/transactions/:id/approve", r.adminHandler.ApproveTransaction, transactionsReview)
+	admin.POST("/transactions/:id/reject", r.adminHandler.RejectTransaction, transactionsReview)
+	admin.POST("/transactions/:id/refund", r.adminHandler.RefundTransaction, transactionsRefund)
+	admin.GET("/fee-rules", r.feeHandler.ListFeeRules, feesManage)
+	admin.POST("/fee-rules", r.feeHandler.CreateFeeRule, feesManage)
+	admin.PUT("/fee-rules/:id", r.feeHandler.UpdateFeeRule, feesManage)
+	admin.DELETE("/fee-rules/:id", r.feeHandler.DeleteFeeRule, feesManage)
+	admin.GET("/kyc/submissions", r.kycHandler.ListSubmissions, kycReview)
+	admin.GET("/kyc/submissions/:id", r.kycHandler.GetSubmission, kycReview)
+	admin.GET("/kyc/submissions/:id/documents/:kind", r.kycHandler.GetDocument, kycReview)
+	admin.POST("/kyc/submissions/:id/approve", r.kycHandler.ApproveSubmission, kycReview)
+	admin.POST("/kyc/submissions/:id/reject", r.kycHandler.RejectSubmission, kycReview)
+
+	// API keys are managed by logged-in administrators, never with an API key
+	apiKeys := protected.Group("/admin/api-keys", staff, r.authMiddleware.RequirePermission(entities.PermissionAPIKeysManage))
+
+	apiKeys.POST("", r.apiKeyHandler.CreateAPIKey)
+	apiKeys.GET("", r.apiKeyHandler.ListAPIKeys)
+	apiKeys.DELETE("/:id", r.apiKeyHandler.RevokeAPIKey)
 }
 
 // setupWebhookRoutes configures webhook routes for external services
@@ -189,11 +327,13 @@ func (r *Router) rootHandler(c echo.Context) error {
 		"description": "RESTful API for transaction service with balance management, payments, and authentication",
 		"endpoints": map[string]string{
 			"health":       "/health",
+			"jwks":         "/.well-known/jwks.json",
 			"swagger":      "/swagger/index.html",
 			"api_base":     "/api/v1",
 			"auth":         "/api/v1/auth",
 			"transactions": "/api/v1/transactions",
 			"user":         "/api/v1/user",
+			"admin":        "/api/v1/admin",
 			"webhooks":     "/api/v1/webhook",
 		},
 		"documentation": map[string]string{
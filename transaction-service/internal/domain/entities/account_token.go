@@ -0,0 +1,78 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AccountTokenPurpose tells what an account token may be used for
+// @Description Account token purpose enumeration
+type AccountTokenPurpose string
+
+const (
+	AccountTokenPasswordReset     AccountTokenPurpose = "password_reset"     // Sets a new password without the old one
+	AccountTokenEmailVerification AccountTokenPurpose = "email_verification" // Confirms ownership of an email address
+)
+
+// AccountToken represents an opaque, expiring, single-use token sent by email. Only its hash
+// is stored, so a token can neither be guessed nor recovered from the database.
+// @Description Account token record
+type AccountToken struct {
+	ID        uuid.UUID           `json:"id" db:"id" example:"550e8400-e29b-41d4-a716-446655440000"`           // Token identifier
+	UserID    uuid.UUID           `json:"user_id" db:"user_id" example:"550e8400-e29b-41d4-a716-446655440000"` // Token owner
+	Purpose   AccountTokenPurpose `json:"purpose" db:"purpose" example:"password_reset"`                       // What the token may be used for
+	Email     string              `json:"email" db:"email" example:"john.doe@example.com"`                     // Address the token was sent to
+	TokenHash string              `json:"-" db:"token_hash"`                                                   // SHA-256 of the token
+	ExpiresAt time.Time           `json:"expires_at" db:"expires_at" example:"2024-01-01T01:00:00Z"`           // Token expiration time
+	UsedAt    *time.Time          `json:"used_at" db:"used_at" example:"2024-01-01T00:10:00Z"`                 // When the token was used or superseded
+	CreatedAt time.Time           `json:"created_at" db:"created_at" example:"2024-01-01T00:00:00Z"`           // Token creation timestamp
+}
+
+// NewAccountToken creates a token for the user's current email and returns it with its plain value
+func NewAccountToken(user *User, purpose AccountTokenPurpose, ttl time.Duration) (*AccountToken, string, error) {
+	token, err := newOpaqueToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now()
+	return &AccountToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		Purpose:   purpose,
+		Email:     user.Email,
+		TokenHash: HashAccountToken(token),
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	}, token, nil
+}
+
+// HashAccountToken returns the value stored for a plain account token
+func HashAccountToken(token string) string {
+	return hashOpaqueToken(token)
+}
+
+// IsUsable checks if the token can still be used for the purpose at the given time
+func (t *AccountToken) IsUsable(purpose AccountTokenPurpose, now time.Time) bool {
+	return t.Purpose == purpose && t.UsedAt == nil && now.Before(t.ExpiresAt)
+}
+
+// ForgotPasswordRequest represents a password reset request
+// @Description Password reset link request
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email" example:"john.doe@example.com"` // Email address of the account
+}
+
+// ResetPasswordRequest represents a new password set with a reset token
+// @Description Password reset request
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required" example:"m1J3c2VjcmV0LXJlc2V0LXRva2Vu"` // Token from the password reset email
+	Password string `json:"password" validate:"required,min=8" example:"newpassword123"`     // New password (minimum 8 characters)
+}
+
+// VerifyEmailRequest represents an email verification
+// @Description Email verification request
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required" example:"m1J3c2VjcmV0LXZlcmlmeS10b2tlbg"` // Token from the verification email
+}
@@ -0,0 +1,124 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIKeyHeader carries an API key in place of a bearer token
+const APIKeyHeader = "X-API-Key"
+
+// apiKeyPrefix marks API keys so they are recognisable in logs and by secret scanners
+const apiKeyPrefix = "pk_"
+
+// apiKeyDisplayLength is how much of a key is kept in clear to tell keys apart
+const apiKeyDisplayLength = 11
+
+// APIKeyScope names a route group an API key may call
+// @Description API key scope enumeration
+type APIKeyScope string
+
+const (
+	APIKeyScopeUser         APIKeyScope = "user"         // Profile and balance of the key owner under /user
+	APIKeyScopeTransactions APIKeyScope = "transactions" // Transactions of the key owner under /transactions
+	APIKeyScopeAdmin        APIKeyScope = "admin"        // Back-office routes under /admin, limited by the permissions of the owner's role
+)
+
+// IsValid checks if the scope is known
+func (s APIKeyScope) IsValid() bool {
+	switch s {
+	case APIKeyScopeUser, APIKeyScopeTransactions, APIKeyScopeAdmin:
+		return true
+	}
+	return false
+}
+
+// APIKey represents a credential for service-to-service callers. The key acts as its owner on
+// the route groups of its scopes. Only the hash of the key is stored.
+// @Description API key record
+type APIKey struct {
+	ID         uuid.UUID     `json:"id" db:"id" example:"550e8400-e29b-41d4-a716-446655440000"`                 // API key identifier
+	UserID     uuid.UUID     `json:"user_id" db:"user_id" example:"550e8400-e29b-41d4-a716-446655440000"`       // User the key acts as
+	Name       string        `json:"name" db:"name" example:"Back office"`                                      // Name of the caller using the key
+	Prefix     string        `json:"prefix" db:"prefix" example:"pk_Xb3kq9Zr"`                                  // Start of the key, to recognise it
+	KeyHash    string        `json:"-" db:"key_hash"`                                                           // SHA-256 of the key
+	Scopes     []APIKeyScope `json:"scopes" db:"scopes"`                                                        // Route groups the key may call
+	RateLimit  int           `json:"rate_limit" db:"rate_limit" example:"60"`                                   // Requests allowed per minute
+	ExpiresAt  *time.Time    `json:"expires_at" db:"expires_at" example:"2025-01-01T00:00:00Z"`                 // Key expiration time, empty for no expiry
+	LastUsedAt *time.Time    `json:"last_used_at" db:"last_used_at" example:"2024-01-01T01:00:00Z"`             // Last time the key authenticated a request
+	RevokedAt  *time.Time    `json:"revoked_at" db:"revoked_at" example:"2024-01-02T00:00:00Z"`                 // When the key was revoked
+	CreatedBy  *uuid.UUID    `json:"created_by" db:"created_by" example:"550e8400-e29b-41d4-a716-446655440000"` // Administrator who created the key
+	CreatedAt  time.Time     `json:"created_at" db:"created_at" example:"2024-01-01T00:00:00Z"`                 // Key creation timestamp
+}
+
+// NewAPIKey creates an API key and returns it with its plain value
+func NewAPIKey(userID, createdBy uuid.UUID, name string, scopes []APIKeyScope, rateLimit int, expiresAt *time.Time) (*APIKey, string, error) {
+	token, err := newOpaqueToken()
+	if err != nil {
+		return nil, "", err
+	}
+	key := apiKeyPrefix + token
+
+	return &APIKey{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Name:      name,
+		Prefix:    key[:apiKeyDisplayLength],
+		KeyHash:   HashAPIKey(key),
+		Scopes:    scopes,
+		RateLimit: rateLimit,
+		ExpiresAt: expiresAt,
+		CreatedBy: &createdBy,
+		CreatedAt: time.Now(),
+	}, key, nil
+}
+
+// HashAPIKey returns the value stored for a plain API key
+func HashAPIKey(key string) string {
+	return hashOpaqueToken(key)
+}
+
+// HasScope checks if the key may call the route group
+func (k *APIKey) HasScope(scope APIKeyScope) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// IsRevoked checks if the key was revoked
+func (k *APIKey) IsRevoked() bool {
+	return k.RevokedAt != nil
+}
+
+// IsExpired checks if the key expired at the given time
+func (k *APIKey) IsExpired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
+}
+
+// CreateAPIKeyRequest represents the API key creation payload
+// @Description API key creation request
+type CreateAPIKeyRequest struct {
+	Name      string        `json:"name" validate:"required,max=100" example:"Back office"`                                     // Name of the caller using the key
+	UserID    uuid.UUID     `json:"user_id" validate:"required" example:"550e8400-e29b-41d4-a716-446655440000"`                 // User the key acts as
+	Scopes    []APIKeyScope `json:"scopes" validate:"required,min=1,dive,oneof=user transactions admin" example:"transactions"` // Route groups the key may call
+	RateLimit int           `json:"rate_limit" validate:"omitempty,min=1,max=6000" example:"60"`                                // Requests allowed per minute, the configured default when omitted
+	ExpiresAt *time.Time    `json:"expires_at,omitempty" example:"2025-01-01T00:00:00Z"`                                        // Key expiration time, no expiry when omitted
+}
+
+// CreateAPIKeyResponse represents a newly created API key
+// @Description API key creation response, the only time the key is shown
+type CreateAPIKeyResponse struct {
+	APIKey *APIKey `json:"api_key"`                                   // Stored key details
+	Key    string  `json:"key" example:"pk_Xb3kq9ZrT0v1w2x3y4z5A6B7"` // Plain API key, send it in the X-API-Key header
+}
+
+// APIKeyListResponse represents a page of API keys
+// @Description Paginated API key list response
+type APIKeyListResponse struct {
+	APIKeys    []*APIKey          `json:"api_keys"`   // API keys, newest first
+	Pagination PaginationResponse `json:"pagination"` // Pagination metadata
+}
@@ -12,8 +12,9 @@ type APIResponse struct {
 // ErrorInfo represents error information in API response
 // @Description Error details in API response
 type ErrorInfo struct {
-	Code    int    `json:"code" example:"400"`                     // HTTP status code
-	Message string `json:"message" example:"Validation failed"`   // Error message
+	Code    int    `json:"code" example:"400"`                            // HTTP status code
+	Message string `json:"message" example:"Validation failed"`           // Error message
+	Reason  string `json:"reason,omitempty" example:"LIMIT_DAILY_AMOUNT"` // Machine-readable cause, such as the limit that refused a transaction
 	Details string `json:"details,omitempty" example:"Email is required"` // Additional error details
 }
 
@@ -0,0 +1,147 @@
+package entities
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// ErrInvalidFeeTiers is returned when the tiers of a fee rule are not in ascending order
+var ErrInvalidFeeTiers = errors.New("fee tiers must have ascending limits and only the last may be unbounded")
+
+// FeeRule sets the fee charged on one transaction type, optionally only for one payment method.
+// A rule without a payment method applies to every method that has no rule of its own.
+// @Description Fee rule
+type FeeRule struct {
+	ID              uuid.UUID       `json:"id" db:"id" example:"550e8400-e29b-41d4-a716-446655440000"`                 // Fee rule identifier
+	TransactionType TransactionType `json:"transaction_type" db:"transaction_type" example:"topup"`                    // Transaction type the rule applies to
+	PaymentMethod   string          `json:"payment_method" db:"payment_method" example:"credit_card"`                  // Payment method the rule applies to, empty for any
+	FlatFee         decimal.Decimal `json:"flat_fee" db:"flat_fee" example:"1000" swaggertype:"string"`                // Fixed part of the fee
+	Percent         decimal.Decimal `json:"percent" db:"percent" example:"2.5" swaggertype:"string"`                   // Percentage of the amount added to the fee
+	Tiers           []FeeTier       `json:"tiers" db:"tiers"`                                                          // Amount brackets replacing the flat fee and percentage
+	MinFee          decimal.Decimal `json:"min_fee" db:"min_fee" example:"0" swaggertype:"string"`                     // Lowest fee charged when a fee is due
+	MaxFee          decimal.Decimal `json:"max_fee" db:"max_fee" example:"0" swaggertype:"string"`                     // Highest fee charged, zero for no cap
+	CreatedBy       *uuid.UUID      `json:"created_by" db:"created_by" example:"550e8400-e29b-41d4-a716-446655440000"` // Administrator who created the rule
+	CreatedAt       time.Time       `json:"created_at" db:"created_at" example:"2024-01-01T00:00:00Z"`                 // Rule creation timestamp
+	UpdatedAt       time.Time       `json:"updated_at" db:"updated_at" example:"2024-01-01T00:00:00Z"`                 // Last update timestamp
+}
+
+// FeeTier is the fee of the amounts up to a limit. The first tier whose limit is not below the
+// amount applies; a zero limit makes the tier unbounded. Amounts above every limit are charged
+// the flat fee and percentage of the rule.
+// @Description Fee tier
+type FeeTier struct {
+	UpTo    decimal.Decimal `json:"up_to" example:"1000000" swaggertype:"string"` // Highest amount of the tier, zero for no limit
+	FlatFee decimal.Decimal `json:"flat_fee" example:"0" swaggertype:"string"`    // Fixed part of the fee
+	Percent decimal.Decimal `json:"percent" example:"0" swaggertype:"string"`     // Percentage of the amount added to the fee
+}
+
+// FeeRuleRequest represents the payload to create or replace a fee rule
+// @Description Fee rule request
+type FeeRuleRequest struct {
+	TransactionType TransactionType `json:"transaction_type" validate:"required,oneof=topup payment transfer" example:"topup"` // Transaction type the rule applies to
+	PaymentMethod   string          `json:"payment_method" validate:"omitempty,max=50" example:"credit_card"`                  // Payment method the rule applies to, empty for any
+	FlatFee         decimal.Decimal `json:"flat_fee" example:"1000" swaggertype:"string"`                                      // Fixed part of the fee
+	Percent         decimal.Decimal `json:"percent" example:"2.5" swaggertype:"string"`                                        // Percentage of the amount added to the fee
+	Tiers           []FeeTier       `json:"tiers"`                                                                             // Amount brackets replacing the flat fee and percentage
+	MinFee          decimal.Decimal `json:"min_fee" example:"0" swaggertype:"string"`                                          // Lowest fee charged when a fee is due
+	MaxFee          decimal.Decimal `json:"max_fee" example:"5000" swaggertype:"string"`                                       // Highest fee charged, zero for no cap
+}
+
+// FeeQuoteRequest represents the query of a fee quote
+// @Description Fee quote request
+type FeeQuoteRequest struct {
+	Type          TransactionType `query:"type" validate:"required,oneof=topup payment transfer" example:"transfer"` // Transaction type
+	Amount        decimal.Decimal `query:"amount" validate:"required,gt=0" example:"150000" swaggertype:"string"`    // Amount to move
+	PaymentMethod string          `query:"payment_method" example:"bank_transfer"`                                   // Payment method of a top-up
+}
+
+// FeeQuote is the fee that a transaction would be charged
+// @Description Fee quote
+type FeeQuote struct {
+	Type          TransactionType `json:"type" example:"transfer"`                                              // Transaction type
+	PaymentMethod string          `json:"payment_method,omitempty" example:"bank_transfer"`                     // Payment method of a top-up
+	Amount        decimal.Decimal `json:"amount" example:"150000" swaggertype:"string"`                         // Amount to move
+	Fee           decimal.Decimal `json:"fee" example:"2500" swaggertype:"string"`                              // Fee charged on top of the amount
+	Total         decimal.Decimal `json:"total" example:"152500" swaggertype:"string"`                          // Amount plus fee paid by the user
+	FeeRuleID     *uuid.UUID      `json:"fee_rule_id,omitempty" example:"550e8400-e29b-41d4-a716-446655440000"` // Rule that set the fee, empty for the default fee
+}
+
+// NewFeeRule creates a fee rule from a request
+func NewFeeRule(createdBy uuid.UUID, req FeeRuleRequest) *FeeRule {
+	rule := &FeeRule{
+		ID:        uuid.New(),
+		CreatedBy: &createdBy,
+		CreatedAt: time.Now(),
+	}
+	rule.Apply(req)
+	return rule
+}
+
+// Apply replaces the settings of the rule with those of the request
+func (r *FeeRule) Apply(req FeeRuleRequest) {
+	r.TransactionType = req.TransactionType
+	r.PaymentMethod = req.PaymentMethod
+	r.FlatFee = req.FlatFee
+	r.Percent = req.Percent
+	r.Tiers = req.Tiers
+	if r.Tiers == nil {
+		r.Tiers = []FeeTier{}
+	}
+	r.MinFee = req.MinFee
+	r.MaxFee = req.MaxFee
+	r.UpdatedAt = time.Now()
+}
+
+// Validate checks that no value is negative, the caps are consistent and the tiers are ordered
+func (r *FeeRule) Validate() error {
+	for _, value := range []decimal.Decimal{r.FlatFee, r.Percent, r.MinFee, r.MaxFee} {
+		if value.IsNegative() {
+			return errors.New("fees cannot be negative")
+		}
+	}
+	if r.MaxFee.IsPositive() && r.MinFee.GreaterThan(r.MaxFee) {
+		return errors.New("minimum fee cannot be higher than the maximum fee")
+	}
+
+	for i, tier := range r.Tiers {
+		if tier.FlatFee.IsNegative() || tier.Percent.IsNegative() || tier.UpTo.IsNegative() {
+			return errors.New("fees cannot be negative")
+		}
+		if tier.UpTo.IsZero() && i != len(r.Tiers)-1 {
+			return ErrInvalidFeeTiers
+		}
+		if i > 0 && tier.UpTo.IsPositive() && !r.Tiers[i-1].UpTo.LessThan(tier.UpTo) {
+			return ErrInvalidFeeTiers
+		}
+	}
+
+	return nil
+}
+
+// Calculate returns the fee charged on the given amount. The minimum only applies when the
+// amount is charged at all, so a free tier stays free.
+func (r *FeeRule) Calculate(amount decimal.Decimal) decimal.Decimal {
+	flat, percent := r.FlatFee, r.Percent
+	for _, tier := range r.Tiers {
+		if tier.UpTo.IsZero() || !amount.GreaterThan(tier.UpTo) {
+			flat, percent = tier.FlatFee, tier.Percent
+			break
+		}
+	}
+
+	fee := flat.Add(amount.Mul(percent).Div(decimal.NewFromInt(100)))
+	if !fee.IsPositive() {
+		return decimal.Zero
+	}
+	if fee.LessThan(r.MinFee) {
+		fee = r.MinFee
+	}
+	if r.MaxFee.IsPositive() && fee.GreaterThan(r.MaxFee) {
+		fee = r.MaxFee
+	}
+
+	return fee.Round(2)
+}
@@ -0,0 +1,54 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IdempotencyKeyHeader is the request header carrying the client supplied idempotency key
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotencyReplayedHeader is set on responses that were replayed from the idempotency store
+const IdempotencyReplayedHeader = "Idempotent-Replayed"
+
+// IdempotencyKeyTTL is how long a key is remembered before it can be reused
+const IdempotencyKeyTTL = 24 * time.Hour
+
+// IdempotencyRecord represents a stored money-moving request and its original response
+// @Description Idempotency store record
+type IdempotencyRecord struct {
+	Key          string     `json:"key" db:"key" example:"3f2b1c9e-topup-001"`                                 // Client supplied idempotency key
+	UserID       uuid.UUID  `json:"user_id" db:"user_id" example:"550e8400-e29b-41d4-a716-446655440000"`       // User who sent the request
+	RequestHash  string     `json:"request_hash" db:"request_hash" example:"9f86d081884c7d659a2feaa0c55ad015"` // SHA-256 of method, path and body
+	ResponseCode int        `json:"response_code" db:"response_code" example:"201"`                            // HTTP status of the original response
+	ResponseBody []byte     `json:"-" db:"response_body"`                                                      // Serialized original response
+	CompletedAt  *time.Time `json:"completed_at" db:"completed_at" example:"2024-01-01T00:00:00Z"`             // When the original response was stored
+	ExpiresAt    time.Time  `json:"expires_at" db:"expires_at" example:"2024-01-02T00:00:00Z"`                 // When the key may be reused
+	CreatedAt    time.Time  `json:"created_at" db:"created_at" example:"2024-01-01T00:00:00Z"`                 // Record creation timestamp
+}
+
+// NewIdempotencyRecord creates an in-flight record for the given key
+func NewIdempotencyRecord(userID uuid.UUID, key, requestHash string) *IdempotencyRecord {
+	now := time.Now()
+	return &IdempotencyRecord{
+		Key:         key,
+		UserID:      userID,
+		RequestHash: requestHash,
+		ExpiresAt:   now.Add(IdempotencyKeyTTL),
+		CreatedAt:   now,
+	}
+}
+
+// IsCompleted checks if the original request finished and its response was stored
+func (r *IdempotencyRecord) IsCompleted() bool {
+	return r.CompletedAt != nil
+}
+
+// Complete stores the response produced by the original request
+func (r *IdempotencyRecord) Complete(code int, body []byte) {
+	now := time.Now()
+	r.ResponseCode = code
+	r.ResponseBody = body
+	r.CompletedAt = &now
+}
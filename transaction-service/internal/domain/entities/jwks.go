@@ -0,0 +1,20 @@
+package entities
+
+// JSONWebKey represents the public part of a token signing key (RFC 7517)
+// @Description Public token signing key
+type JSONWebKey struct {
+	Kty string `json:"kty" example:"RSA"`                   // Key type, RSA or OKP
+	Use string `json:"use" example:"sig"`                   // Key usage, always sig
+	Alg string `json:"alg" example:"RS256"`                 // Signing algorithm, RS256 or EdDSA
+	Kid string `json:"kid" example:"20240101T000000Z-1a2b"` // Key ID carried in the token header
+	N   string `json:"n,omitempty"`                         // RSA modulus, base64url encoded
+	E   string `json:"e,omitempty" example:"AQAB"`          // RSA public exponent, base64url encoded
+	Crv string `json:"crv,omitempty" example:"Ed25519"`     // Curve of an OKP key
+	X   string `json:"x,omitempty"`                         // OKP public key, base64url encoded
+}
+
+// JSONWebKeySet represents the keys that currently verify access tokens
+// @Description Set of public token signing keys
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"` // Active key first, followed by keys within their grace window
+}
@@ -0,0 +1,251 @@
+package entities
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// KYCTier represents how far the identity of a user has been verified
+// @Description KYC tier enumeration
+type KYCTier string
+
+const (
+	KYCTierUnverified KYCTier = "unverified" // No identity data verified, the default of new users
+	KYCTierBasic      KYCTier = "basic"      // Identity data and an identity document verified
+	KYCTierFull       KYCTier = "full"       // Also a selfie and a proof of address verified
+)
+
+// kycTierRanks orders the tiers from least to most verified
+var kycTierRanks = map[KYCTier]int{
+	KYCTierUnverified: 0,
+	KYCTierBasic:      1,
+	KYCTierFull:       2,
+}
+
+// IsValid checks if the tier is known
+func (t KYCTier) IsValid() bool {
+	_, ok := kycTierRanks[t]
+	return ok
+}
+
+// AtLeast checks if the tier is the given tier or a higher one
+func (t KYCTier) AtLeast(other KYCTier) bool {
+	return kycTierRanks[t] >= kycTierRanks[other]
+}
+
+// Features that a KYC tier may unlock, refused with KYCReasonFeatureLocked otherwise
+const (
+	KYCFeatureTopup         = "topup"         // Top up the wallet
+	KYCFeaturePayment       = "payment"       // Send payments
+	KYCFeatureTransfer      = "transfer"      // Send transfers
+	KYCFeatureAuthorization = "authorization" // Authorize payments that hold funds
+)
+
+// KYCReasonFeatureLocked is the reason given with the errors of features the KYC tier of a user does not unlock
+const KYCReasonFeatureLocked = "KYC_TIER_REQUIRED"
+
+// KYCSubmissionStatus represents the review state of a KYC submission
+// @Description KYC submission status enumeration
+type KYCSubmissionStatus string
+
+const (
+	KYCSubmissionStatusPending  KYCSubmissionStatus = "pending"  // Waiting for review
+	KYCSubmissionStatusApproved KYCSubmissionStatus = "approved" // Accepted, the user was raised to the requested tier
+	KYCSubmissionStatusRejected KYCSubmissionStatus = "rejected" // Refused, the user may submit again
+)
+
+// IsValid checks if the status is known
+func (s KYCSubmissionStatus) IsValid() bool {
+	switch s {
+	case KYCSubmissionStatusPending, KYCSubmissionStatusApproved, KYCSubmissionStatusRejected:
+		return true
+	}
+	return false
+}
+
+// KYCIDType represents the kind of identity document of a submission
+// @Description Identity document type enumeration
+type KYCIDType string
+
+const (
+	KYCIDTypeNationalID    KYCIDType = "national_id"    // National identity card
+	KYCIDTypePassport      KYCIDType = "passport"       // Passport
+	KYCIDTypeDriverLicense KYCIDType = "driver_license" // Driver's license
+)
+
+// KYCDocumentKind names an uploaded document of a submission
+// @Description KYC document kind enumeration
+type KYCDocumentKind string
+
+const (
+	KYCDocumentIDDocument     KYCDocumentKind = "id_document"      // Scan or photo of the identity document
+	KYCDocumentSelfie         KYCDocumentKind = "selfie"           // Photo of the user holding the identity document
+	KYCDocumentProofOfAddress KYCDocumentKind = "proof_of_address" // Recent utility bill or bank statement
+)
+
+// KYCDocumentKinds lists every document kind, in the order they are shown
+var KYCDocumentKinds = []KYCDocumentKind{KYCDocumentIDDocument, KYCDocumentSelfie, KYCDocumentProofOfAddress}
+
+// KYCDocumentContentTypes are the content types accepted for documents
+var KYCDocumentContentTypes = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"application/pdf": ".pdf",
+}
+
+// RequiredKYCDocuments returns the documents a submission for the given tier must include
+func RequiredKYCDocuments(tier KYCTier) []KYCDocumentKind {
+	if tier == KYCTierFull {
+		return KYCDocumentKinds
+	}
+	return []KYCDocumentKind{KYCDocumentIDDocument}
+}
+
+// KYCDocument is a document uploaded with a submission. The file itself is kept in the blob store.
+// @Description KYC document
+type KYCDocument struct {
+	Kind        KYCDocumentKind `json:"kind" example:"id_document"`                 // What the document shows
+	FileName    string          `json:"file_name" example:"ktp.jpg"`                // Name of the uploaded file
+	ContentType string          `json:"content_type" example:"image/jpeg"`          // MIME type of the file
+	Size        int64           `json:"size" example:"245760"`                      // File size in bytes
+	StorageKey  string          `json:"-"`                                          // Key of the file in the blob store
+	UploadedAt  time.Time       `json:"uploaded_at" example:"2024-01-01T00:00:00Z"` // Upload timestamp
+}
+
+// KYCDocumentUpload is a document received with a submission, before it is stored. Its content
+// type is detected from the content rather than trusted from the client.
+type KYCDocumentUpload struct {
+	Kind     KYCDocumentKind
+	FileName string
+	Content  []byte
+}
+
+// KYCSubmission represents the identity data and documents a user submitted to reach a KYC tier
+// @Description KYC submission
+type KYCSubmission struct {
+	ID            uuid.UUID           `json:"id" db:"id" example:"550e8400-e29b-41d4-a716-446655440000"`                   // Submission identifier
+	UserID        uuid.UUID           `json:"user_id" db:"user_id" example:"550e8400-e29b-41d4-a716-446655440000"`         // User who submitted
+	RequestedTier KYCTier             `json:"requested_tier" db:"requested_tier" example:"basic"`                          // Tier the user asked for
+	Status        KYCSubmissionStatus `json:"status" db:"status" example:"pending"`                                        // Review state
+	FullName      string              `json:"full_name" db:"full_name" example:"John Doe"`                                 // Legal name as on the identity document
+	DateOfBirth   time.Time           `json:"date_of_birth" db:"date_of_birth" example:"1990-01-31T00:00:00Z"`             // Date of birth
+	Nationality   string              `json:"nationality" db:"nationality" example:"ID"`                                   // ISO 3166-1 alpha-2 country code
+	IDType        KYCIDType           `json:"id_type" db:"id_type" example:"national_id"`                                  // Kind of identity document
+	IDNumber      string              `json:"id_number" db:"id_number" example:"3174012345678901"`                         // Number of the identity document
+	Address       string              `json:"address" db:"address" example:"Jl. Sudirman 1, Jakarta"`                      // Residential address
+	Documents     []KYCDocument       `json:"documents" db:"documents"`                                                    // Uploaded documents
+	ReviewedBy    *uuid.UUID          `json:"reviewed_by" db:"reviewed_by" example:"550e8400-e29b-41d4-a716-446655440000"` // Administrator who approved or rejected the submission
+	ReviewReason  string              `json:"review_reason" db:"review_reason" example:"Document expired"`                 // Why the submission was approved or rejected
+	ReviewedAt    *time.Time          `json:"reviewed_at" db:"reviewed_at" example:"2024-01-02T00:00:00Z"`                 // When the submission was reviewed
+	CreatedAt     time.Time           `json:"created_at" db:"created_at" example:"2024-01-01T00:00:00Z"`                   // Submission timestamp
+	UpdatedAt     time.Time           `json:"updated_at" db:"updated_at" example:"2024-01-01T00:00:00Z"`                   // Last update timestamp
+}
+
+// KYCSubmissionRequest represents the identity data of a submission, sent as multipart form
+// fields next to the document files
+// @Description KYC submission request
+type KYCSubmissionRequest struct {
+	Tier        KYCTier   `form:"tier" validate:"required,oneof=basic full" example:"basic"`                                   // Tier to reach
+	FullName    string    `form:"full_name" validate:"required,min=2,max=100" example:"John Doe"`                              // Legal name as on the identity document
+	DateOfBirth string    `form:"date_of_birth" validate:"required,datetime=2006-01-02" example:"1990-01-31"`                  // Date of birth
+	Nationality string    `form:"nationality" validate:"required,len=2,alpha" example:"ID"`                                    // ISO 3166-1 alpha-2 country code
+	IDType      KYCIDType `form:"id_type" validate:"required,oneof=national_id passport driver_license" example:"national_id"` // Kind of identity document
+	IDNumber    string    `form:"id_number" validate:"required,min=4,max=50" example:"3174012345678901"`                       // Number of the identity document
+	Address     string    `form:"address" validate:"required,min=5,max=255" example:"Jl. Sudirman 1, Jakarta"`                 // Residential address
+}
+
+// ApproveKYCRequest represents admin approval of a KYC submission
+// @Description KYC approval request
+type ApproveKYCRequest struct {
+	Reason string `json:"reason" validate:"max=500" example:"Document matches the selfie"` // Optional note stored with the submission
+}
+
+// RejectKYCRequest represents admin rejection of a KYC submission
+// @Description KYC rejection request
+type RejectKYCRequest struct {
+	Reason string `json:"reason" validate:"required,max=500" example:"Identity document is expired"` // Why the submission was rejected, shown to the user
+}
+
+// KYCStatusResponse represents the verification state of a user
+// @Description KYC status of a user
+type KYCStatusResponse struct {
+	Tier       KYCTier        `json:"tier" example:"basic"`                                    // Current tier
+	Features   []string       `json:"features" example:"topup,payment,transfer,authorization"` // Features the tier unlocks
+	Submission *KYCSubmission `json:"submission,omitempty"`                                    // Latest submission, empty when the user never submitted
+}
+
+// KYCSubmissionListResponse represents a page of KYC submissions
+// @Description Paginated KYC submission list response
+type KYCSubmissionListResponse struct {
+	Submissions []*KYCSubmission   `json:"submissions"` // Submissions, oldest first
+	Pagination  PaginationResponse `json:"pagination"`  // Pagination metadata
+}
+
+// ErrKYCTierNotHigher is returned when a submission asks for a tier the user already has
+var ErrKYCTierNotHigher = errors.New("the requested tier must be above the current tier")
+
+// ErrKYCSubmissionReviewed is returned when a submission that is no longer pending is reviewed again
+var ErrKYCSubmissionReviewed = errors.New("submission has already been reviewed")
+
+// NewKYCSubmission creates a pending submission of a user from a request; the date of birth must
+// already be parsed
+func NewKYCSubmission(userID uuid.UUID, req KYCSubmissionRequest, dateOfBirth time.Time) *KYCSubmission {
+	now := time.Now()
+	return &KYCSubmission{
+		ID:            uuid.New(),
+		UserID:        userID,
+		RequestedTier: req.Tier,
+		Status:        KYCSubmissionStatusPending,
+		FullName:      strings.TrimSpace(req.FullName),
+		DateOfBirth:   dateOfBirth,
+		Nationality:   strings.ToUpper(req.Nationality),
+		IDType:        req.IDType,
+		IDNumber:      strings.TrimSpace(req.IDNumber),
+		Address:       strings.TrimSpace(req.Address),
+		Documents:     []KYCDocument{},
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+}
+
+// IsPending checks if the submission still waits for review
+func (s *KYCSubmission) IsPending() bool {
+	return s.Status == KYCSubmissionStatusPending
+}
+
+// Document returns the uploaded document of the given kind
+func (s *KYCSubmission) Document(kind KYCDocumentKind) (*KYCDocument, bool) {
+	for i := range s.Documents {
+		if s.Documents[i].Kind == kind {
+			return &s.Documents[i], true
+		}
+	}
+	return nil, false
+}
+
+// Approve accepts a pending submission
+func (s *KYCSubmission) Approve(reviewerID uuid.UUID, reason string) error {
+	return s.review(KYCSubmissionStatusApproved, reviewerID, reason)
+}
+
+// Reject refuses a pending submission
+func (s *KYCSubmission) Reject(reviewerID uuid.UUID, reason string) error {
+	return s.review(KYCSubmissionStatusRejected, reviewerID, reason)
+}
+
+func (s *KYCSubmission) review(status KYCSubmissionStatus, reviewerID uuid.UUID, reason string) error {
+	if !s.IsPending() {
+		return ErrKYCSubmissionReviewed
+	}
+
+	now := time.Now()
+	s.Status = status
+	s.ReviewedBy = &reviewerID
+	s.ReviewReason = strings.TrimSpace(reason)
+	s.ReviewedAt = &now
+	s.UpdatedAt = now
+	return nil
+}
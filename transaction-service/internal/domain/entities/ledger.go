@@ -0,0 +1,173 @@
+package entities
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// LedgerAccount identifies the account a posting is booked against
+// @Description Ledger account code
+type LedgerAccount string
+
+const (
+	LedgerAccountUserWallet      LedgerAccount = "user_wallet"      // Customer wallet balance (liability, owned by a user)
+	LedgerAccountGatewayClearing LedgerAccount = "gateway_clearing" // Funds received through the payment gateway
+	LedgerAccountOpeningBalance  LedgerAccount = "opening_balance"  // Balances that existed before the ledger was introduced
+	LedgerAccountFeeIncome       LedgerAccount = "fee_income"       // House account of the fees charged on top-ups, payments and transfers
+)
+
+// PostingDirection represents the side of a ledger posting
+// @Description Posting direction enumeration
+type PostingDirection string
+
+const (
+	PostingDirectionDebit  PostingDirection = "debit"  // Debit side of the entry
+	PostingDirectionCredit PostingDirection = "credit" // Credit side of the entry
+)
+
+// JournalEntry represents a balanced set of postings produced by a single business event
+// @Description Double-entry journal entry
+type JournalEntry struct {
+	ID            uuid.UUID  `json:"id" db:"id" example:"550e8400-e29b-41d4-a716-446655440000"`                         // Journal entry identifier
+	TransactionID *uuid.UUID `json:"transaction_id" db:"transaction_id" example:"550e8400-e29b-41d4-a716-446655440000"` // Transaction that produced the entry
+	Description   string     `json:"description" db:"description" example:"Balance top-up"`                             // Entry description
+	Postings      []Posting  `json:"postings"`                                                                          // Debit and credit lines
+	CreatedAt     time.Time  `json:"created_at" db:"created_at" example:"2024-01-01T00:00:00Z"`                         // Entry creation timestamp
+}
+
+// Posting represents a single debit or credit line of a journal entry
+// @Description Ledger posting line
+type Posting struct {
+	ID        uuid.UUID        `json:"id" db:"id" example:"550e8400-e29b-41d4-a716-446655440000"`                 // Posting identifier
+	JournalID uuid.UUID        `json:"journal_id" db:"journal_id" example:"550e8400-e29b-41d4-a716-446655440000"` // Parent journal entry
+	Account   LedgerAccount    `json:"account" db:"account" example:"user_wallet"`                                // Account code
+	UserID    *uuid.UUID       `json:"user_id" db:"user_id" example:"550e8400-e29b-41d4-a716-446655440000"`       // Wallet owner (user accounts only)
+	Direction PostingDirection `json:"direction" db:"direction" example:"credit"`                                 // Debit or credit
+	Amount    decimal.Decimal  `json:"amount" db:"amount" example:"100.50" swaggertype:"string"`                  // Posting amount (always positive)
+	CreatedAt time.Time        `json:"created_at" db:"created_at" example:"2024-01-01T00:00:00Z"`                 // Posting timestamp
+}
+
+// ErrUnbalancedEntry is returned when the debits of an entry do not equal its credits
+var ErrUnbalancedEntry = errors.New("journal entry is not balanced")
+
+// ErrInvalidPosting is returned when a posting has a non-positive amount or is missing its owner
+var ErrInvalidPosting = errors.New("journal entry contains an invalid posting")
+
+// NewJournalEntry creates an empty journal entry for the given transaction
+func NewJournalEntry(transactionID *uuid.UUID, description string) *JournalEntry {
+	return &JournalEntry{
+		ID:            uuid.New(),
+		TransactionID: transactionID,
+		Description:   description,
+		Postings:      make([]Posting, 0, 2),
+		CreatedAt:     time.Now(),
+	}
+}
+
+// Debit appends a debit posting to the entry
+func (j *JournalEntry) Debit(account LedgerAccount, userID *uuid.UUID, amount decimal.Decimal) *JournalEntry {
+	return j.addPosting(account, userID, PostingDirectionDebit, amount)
+}
+
+// Credit appends a credit posting to the entry
+func (j *JournalEntry) Credit(account LedgerAccount, userID *uuid.UUID, amount decimal.Decimal) *JournalEntry {
+	return j.addPosting(account, userID, PostingDirectionCredit, amount)
+}
+
+// Validate checks that the entry has postings, every posting is well formed and debits equal credits
+func (j *JournalEntry) Validate() error {
+	if len(j.Postings) < 2 {
+		return ErrUnbalancedEntry
+	}
+
+	debits, credits := decimal.Zero, decimal.Zero
+	for _, p := range j.Postings {
+		if !p.Amount.IsPositive() {
+			return ErrInvalidPosting
+		}
+		if p.Account == LedgerAccountUserWallet && p.UserID == nil {
+			return ErrInvalidPosting
+		}
+
+		switch p.Direction {
+		case PostingDirectionDebit:
+			debits = debits.Add(p.Amount)
+		case PostingDirectionCredit:
+			credits = credits.Add(p.Amount)
+		default:
+			return ErrInvalidPosting
+		}
+	}
+
+	if !debits.Equal(credits) {
+		return ErrUnbalancedEntry
+	}
+
+	return nil
+}
+
+// NewTopupEntry builds the journal entry for a settled top-up. The gateway collected the amount
+// plus the fee; only the amount goes to the wallet and the fee is booked as fee income.
+func NewTopupEntry(transaction *Transaction) *JournalEntry {
+	userID := transaction.UserID
+	entry := NewJournalEntry(&transaction.ID, transaction.Description).
+		Debit(LedgerAccountGatewayClearing, nil, transaction.TotalDebit()).
+		Credit(LedgerAccountUserWallet, &userID, transaction.Amount)
+	if transaction.Fee.IsPositive() {
+		entry.Credit(LedgerAccountFeeIncome, nil, transaction.Fee)
+	}
+	return entry
+}
+
+// NewWalletTransferEntry builds the journal entry for moving funds between two user wallets.
+// The sender also pays the transaction fee, which is booked as fee income.
+func NewWalletTransferEntry(transaction *Transaction, fromUserID, toUserID uuid.UUID) *JournalEntry {
+	entry := NewJournalEntry(&transaction.ID, transaction.Description).
+		Debit(LedgerAccountUserWallet, &fromUserID, transaction.TotalDebit()).
+		Credit(LedgerAccountUserWallet, &toUserID, transaction.Amount)
+	if transaction.Fee.IsPositive() {
+		entry.Credit(LedgerAccountFeeIncome, nil, transaction.Fee)
+	}
+	return entry
+}
+
+// NewRefundEntry builds the journal entry for a settled refund. A payment refund moves the amount
+// back from the counterparty's wallet, a top-up reversal returns it to the payment gateway.
+// Fees of the refunded transaction are kept.
+func NewRefundEntry(refund *Transaction) *JournalEntry {
+	userID := refund.UserID
+	entry := NewJournalEntry(&refund.ID, refund.Description)
+	if refund.CounterpartyUserID != nil {
+		return entry.
+			Debit(LedgerAccountUserWallet, refund.CounterpartyUserID, refund.Amount).
+			Credit(LedgerAccountUserWallet, &userID, refund.Amount)
+	}
+	return entry.
+		Debit(LedgerAccountUserWallet, &userID, refund.Amount).
+		Credit(LedgerAccountGatewayClearing, nil, refund.Amount)
+}
+
+// NewRefundReturnEntry builds the journal entry that gives a top-up reversal refused by the
+// payment gateway back to the user's wallet
+func NewRefundReturnEntry(refund *Transaction) *JournalEntry {
+	userID := refund.UserID
+	return NewJournalEntry(&refund.ID, refund.Description).
+		Debit(LedgerAccountGatewayClearing, nil, refund.Amount).
+		Credit(LedgerAccountUserWallet, &userID, refund.Amount)
+}
+
+func (j *JournalEntry) addPosting(account LedgerAccount, userID *uuid.UUID, direction PostingDirection, amount decimal.Decimal) *JournalEntry {
+	j.Postings = append(j.Postings, Posting{
+		ID:        uuid.New(),
+		JournalID: j.ID,
+		Account:   account,
+		UserID:    userID,
+		Direction: direction,
+		Amount:    amount,
+		CreatedAt: j.CreatedAt,
+	})
+	return j
+}
@@ -0,0 +1,179 @@
+package entities
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Reasons given with the errors of transactions refused by a limit
+const (
+	LimitReasonMinAmount     = "LIMIT_MIN_AMOUNT"     // Amount below the per-transaction minimum
+	LimitReasonMaxAmount     = "LIMIT_MAX_AMOUNT"     // Amount above the per-transaction maximum
+	LimitReasonDailyAmount   = "LIMIT_DAILY_AMOUNT"   // Total amount of the day would exceed its cap
+	LimitReasonDailyCount    = "LIMIT_DAILY_COUNT"    // Too many transactions of the type today
+	LimitReasonMonthlyAmount = "LIMIT_MONTHLY_AMOUNT" // Total amount of the month would exceed its cap
+	LimitReasonMonthlyCount  = "LIMIT_MONTHLY_COUNT"  // Too many transactions of the type this month
+	LimitReasonMaxBalance    = "LIMIT_MAX_BALANCE"    // Wallet balance would exceed its maximum
+)
+
+// TransactionLimits are the limits of one transaction type that apply to a user. A zero limit is
+// not enforced.
+// @Description Limits of a transaction type
+type TransactionLimits struct {
+	MinAmount     decimal.Decimal `json:"min_amount" example:"10000" swaggertype:"string"`      // Smallest amount of a transaction
+	MaxAmount     decimal.Decimal `json:"max_amount" example:"25000000" swaggertype:"string"`   // Largest amount of a transaction
+	DailyAmount   decimal.Decimal `json:"daily_amount" example:"50000000" swaggertype:"string"` // Highest total amount per calendar day
+	DailyCount    int             `json:"daily_count" example:"20"`                             // Most transactions per calendar day
+	MonthlyAmount decimal.Decimal `json:"monthly_amount" example:"0" swaggertype:"string"`      // Highest total amount per calendar month
+	MonthlyCount  int             `json:"monthly_count" example:"0"`                            // Most transactions per calendar month
+}
+
+// LimitOverride replaces some limits of one transaction type for a user. Omitted limits keep
+// their global value and zero turns a limit off.
+// @Description Per-user override of the limits of a transaction type
+type LimitOverride struct {
+	MinAmount     *decimal.Decimal `json:"min_amount,omitempty" example:"10000" swaggertype:"string"`     // Smallest amount of a transaction
+	MaxAmount     *decimal.Decimal `json:"max_amount,omitempty" example:"5000000" swaggertype:"string"`   // Largest amount of a transaction
+	DailyAmount   *decimal.Decimal `json:"daily_amount,omitempty" example:"5000000" swaggertype:"string"` // Highest total amount per calendar day
+	DailyCount    *int             `json:"daily_count,omitempty" example:"5"`                             // Most transactions per calendar day
+	MonthlyAmount *decimal.Decimal `json:"monthly_amount,omitempty" example:"0" swaggertype:"string"`     // Highest total amount per calendar month
+	MonthlyCount  *int             `json:"monthly_count,omitempty" example:"0"`                           // Most transactions per calendar month
+}
+
+// UserLimits holds the limits an administrator set for one user in place of the global ones
+// @Description Per-user limit overrides
+type UserLimits struct {
+	UserID       uuid.UUID                         `json:"user_id" db:"user_id" example:"550e8400-e29b-41d4-a716-446655440000"`       // User the limits apply to
+	MaxBalance   *decimal.Decimal                  `json:"max_balance" db:"max_balance" example:"10000000" swaggertype:"string"`      // Highest wallet balance, empty for the global maximum
+	Transactions map[TransactionType]LimitOverride `json:"transactions" db:"transactions"`                                            // Overrides per transaction type
+	UpdatedBy    *uuid.UUID                        `json:"updated_by" db:"updated_by" example:"550e8400-e29b-41d4-a716-446655440000"` // Administrator who last changed the limits
+	UpdatedAt    time.Time                         `json:"updated_at" db:"updated_at" example:"2024-01-01T00:00:00Z"`                 // Last update timestamp
+}
+
+// LimitUsage is what a user already spent of the daily and monthly caps of a transaction type
+type LimitUsage struct {
+	DailyCount    int
+	DailyAmount   decimal.Decimal
+	MonthlyCount  int
+	MonthlyAmount decimal.Decimal
+}
+
+// UpdateUserLimitsRequest represents the per-user limit overrides set by an administrator
+// @Description Per-user limit overrides request, replacing all earlier overrides of the user
+type UpdateUserLimitsRequest struct {
+	MaxBalance   *decimal.Decimal                  `json:"max_balance,omitempty" example:"10000000" swaggertype:"string"` // Highest wallet balance, omitted for the global maximum
+	Transactions map[TransactionType]LimitOverride `json:"transactions,omitempty"`                                        // Overrides per transaction type (topup, payment, transfer)
+}
+
+// UserLimitsResponse represents the limits that apply to a user
+// @Description Effective limits of a user
+type UserLimitsResponse struct {
+	UserID       uuid.UUID                             `json:"user_id" example:"550e8400-e29b-41d4-a716-446655440000"` // User the limits apply to
+	KYCTier      KYCTier                               `json:"kyc_tier" example:"basic"`                               // KYC tier whose caps apply on top of the global limits
+	MaxBalance   decimal.Decimal                       `json:"max_balance" example:"0" swaggertype:"string"`           // Highest wallet balance, zero for no cap
+	Transactions map[TransactionType]TransactionLimits `json:"transactions"`                                           // Limits per transaction type
+	Overrides    *UserLimits                           `json:"overrides,omitempty"`                                    // Overrides set by an administrator, empty when the global limits apply
+}
+
+// ErrInvalidLimits is returned when limit overrides are negative, inconsistent or for an unknown type
+var ErrInvalidLimits = errors.New("limits must be zero or positive, for topup, payment or transfer, with a minimum not above the maximum")
+
+// NewUserLimits creates the limit overrides of a user from a request
+func NewUserLimits(userID, updatedBy uuid.UUID, req UpdateUserLimitsRequest) *UserLimits {
+	transactions := req.Transactions
+	if transactions == nil {
+		transactions = map[TransactionType]LimitOverride{}
+	}
+
+	return &UserLimits{
+		UserID:       userID,
+		MaxBalance:   req.MaxBalance,
+		Transactions: transactions,
+		UpdatedBy:    &updatedBy,
+		UpdatedAt:    time.Now(),
+	}
+}
+
+// Validate checks that every override is for a limited transaction type and no limit is negative
+func (l *UserLimits) Validate() error {
+	if l.MaxBalance != nil && l.MaxBalance.IsNegative() {
+		return ErrInvalidLimits
+	}
+
+	for transactionType, override := range l.Transactions {
+		switch transactionType {
+		case TransactionTypeTopup, TransactionTypePayment, TransactionTypeTransfer:
+		default:
+			return ErrInvalidLimits
+		}
+		if err := override.validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Apply returns the given limits with the overridden ones replaced
+func (o LimitOverride) Apply(limits TransactionLimits) TransactionLimits {
+	if o.MinAmount != nil {
+		limits.MinAmount = *o.MinAmount
+	}
+	if o.MaxAmount != nil {
+		limits.MaxAmount = *o.MaxAmount
+	}
+	if o.DailyAmount != nil {
+		limits.DailyAmount = *o.DailyAmount
+	}
+	if o.DailyCount != nil {
+		limits.DailyCount = *o.DailyCount
+	}
+	if o.MonthlyAmount != nil {
+		limits.MonthlyAmount = *o.MonthlyAmount
+	}
+	if o.MonthlyCount != nil {
+		limits.MonthlyCount = *o.MonthlyCount
+	}
+	return limits
+}
+
+func (o LimitOverride) validate() error {
+	for _, value := range []*decimal.Decimal{o.MinAmount, o.MaxAmount, o.DailyAmount, o.MonthlyAmount} {
+		if value != nil && value.IsNegative() {
+			return ErrInvalidLimits
+		}
+	}
+	for _, value := range []*int{o.DailyCount, o.MonthlyCount} {
+		if value != nil && *value < 0 {
+			return ErrInvalidLimits
+		}
+	}
+	if o.MinAmount != nil && o.MaxAmount != nil && o.MaxAmount.IsPositive() && o.MinAmount.GreaterThan(*o.MaxAmount) {
+		return ErrInvalidLimits
+	}
+	return nil
+}
+
+// Exceeded reports whether a new transaction of the given amount would exceed a daily or monthly cap,
+// and if so the reason of the first one
+func (l TransactionLimits) Exceeded(usage LimitUsage, amount decimal.Decimal) string {
+	switch {
+	case l.DailyCount > 0 && usage.DailyCount+1 > l.DailyCount:
+		return LimitReasonDailyCount
+	case l.DailyAmount.IsPositive() && usage.DailyAmount.Add(amount).GreaterThan(l.DailyAmount):
+		return LimitReasonDailyAmount
+	case l.MonthlyCount > 0 && usage.MonthlyCount+1 > l.MonthlyCount:
+		return LimitReasonMonthlyCount
+	case l.MonthlyAmount.IsPositive() && usage.MonthlyAmount.Add(amount).GreaterThan(l.MonthlyAmount):
+		return LimitReasonMonthlyAmount
+	}
+	return ""
+}
+
+// HasCaps checks if any daily or monthly cap is enforced, which needs the usage of the user
+func (l TransactionLimits) HasCaps() bool {
+	return l.DailyCount > 0 || l.DailyAmount.IsPositive() || l.MonthlyCount > 0 || l.MonthlyAmount.IsPositive()
+}
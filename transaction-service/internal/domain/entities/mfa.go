@@ -0,0 +1,167 @@
+package entities
+
+import (
+	"crypto/rand"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// recoveryCodeAlphabet avoids characters that are easily confused when written down
+const recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+// recoveryCodeLength is the number of characters of a recovery code, without the separator
+const recoveryCodeLength = 10
+
+// MFAChallenge represents the pending second step of a login. The opaque challenge token is
+// returned after the password check and exchanged, together with a code, for the real tokens.
+// @Description MFA login challenge record
+type MFAChallenge struct {
+	ID        uuid.UUID  `json:"id" db:"id" example:"550e8400-e29b-41d4-a716-446655440000"`           // Challenge identifier
+	UserID    uuid.UUID  `json:"user_id" db:"user_id" example:"550e8400-e29b-41d4-a716-446655440000"` // User who passed the password check
+	TokenHash string     `json:"-" db:"token_hash"`                                                   // SHA-256 of the challenge token
+	Attempts  int        `json:"attempts" db:"attempts" example:"0"`                                  // Wrong codes entered so far
+	ExpiresAt time.Time  `json:"expires_at" db:"expires_at" example:"2024-01-01T00:05:00Z"`           // Challenge expiration time
+	UsedAt    *time.Time `json:"used_at" db:"used_at" example:"2024-01-01T00:01:00Z"`                 // When the challenge was completed
+	CreatedAt time.Time  `json:"created_at" db:"created_at" example:"2024-01-01T00:00:00Z"`           // Challenge creation timestamp
+}
+
+// NewMFAChallenge creates a challenge for the user and returns it with its plain token
+func NewMFAChallenge(userID uuid.UUID, ttl time.Duration) (*MFAChallenge, string, error) {
+	token, err := newOpaqueToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now()
+	return &MFAChallenge{
+		ID:        uuid.New(),
+		UserID:    userID,
+		TokenHash: HashMFAChallengeToken(token),
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	}, token, nil
+}
+
+// HashMFAChallengeToken returns the value stored for a plain challenge token
+func HashMFAChallengeToken(token string) string {
+	return hashOpaqueToken(token)
+}
+
+// IsUsable checks if the challenge can still be completed at the given time
+func (c *MFAChallenge) IsUsable(now time.Time, maxAttempts int) bool {
+	return c.UsedAt == nil && now.Before(c.ExpiresAt) && c.Attempts < maxAttempts
+}
+
+// RecoveryCode represents a one-time code that replaces a TOTP code when the device is lost
+// @Description MFA recovery code record
+type RecoveryCode struct {
+	ID        uuid.UUID  `json:"id" db:"id" example:"550e8400-e29b-41d4-a716-446655440000"`           // Recovery code identifier
+	UserID    uuid.UUID  `json:"user_id" db:"user_id" example:"550e8400-e29b-41d4-a716-446655440000"` // Code owner
+	CodeHash  string     `json:"-" db:"code_hash"`                                                    // Bcrypt hash of the code
+	UsedAt    *time.Time `json:"used_at" db:"used_at" example:"2024-01-01T00:00:00Z"`                 // When the code was used
+	CreatedAt time.Time  `json:"created_at" db:"created_at" example:"2024-01-01T00:00:00Z"`           // Code creation timestamp
+}
+
+// NewRecoveryCodes creates a set of recovery codes and returns them with their plain values
+func NewRecoveryCodes(userID uuid.UUID, count int) ([]*RecoveryCode, []string, error) {
+	codes := make([]*RecoveryCode, 0, count)
+	plain := make([]string, 0, count)
+	now := time.Now()
+
+	for i := 0; i < count; i++ {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		hash, err := bcrypt.GenerateFromPassword([]byte(normalizeRecoveryCode(code)), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		codes = append(codes, &RecoveryCode{
+			ID:        uuid.New(),
+			UserID:    userID,
+			CodeHash:  string(hash),
+			CreatedAt: now,
+		})
+		plain = append(plain, code)
+	}
+
+	return codes, plain, nil
+}
+
+// Matches checks a plain code against the stored hash, ignoring case and separators
+func (r *RecoveryCode) Matches(code string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(r.CodeHash), []byte(normalizeRecoveryCode(code))) == nil
+}
+
+// IsRecoveryCode tells a recovery code apart from a TOTP code
+func IsRecoveryCode(code string) bool {
+	return len(normalizeRecoveryCode(code)) == recoveryCodeLength
+}
+
+// newRecoveryCode returns a code formatted as xxxxx-xxxxx
+func newRecoveryCode() (string, error) {
+	raw := make([]byte, recoveryCodeLength)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for i, v := range raw {
+		if i == recoveryCodeLength/2 {
+			b.WriteByte('-')
+		}
+		b.WriteByte(recoveryCodeAlphabet[int(v)%len(recoveryCodeAlphabet)])
+	}
+	return b.String(), nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(code)))
+}
+
+// MFAChallengeResponse is returned by login instead of tokens when the account has MFA enabled
+// @Description MFA login challenge
+type MFAChallengeResponse struct {
+	MFARequired    bool      `json:"mfa_required" example:"true"`                              // Always true, the login needs a second step
+	ChallengeToken string    `json:"challenge_token" example:"Zm9vYmFyLWNoYWxsZW5nZS10b2tlbg"` // Token to send with the code to /auth/mfa/verify
+	ExpiresAt      time.Time `json:"expires_at" example:"2024-01-01T00:05:00Z"`                // Challenge expiration time
+}
+
+// MFAVerifyRequest represents the second step of a login
+// @Description MFA login verification request
+type MFAVerifyRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required" example:"Zm9vYmFyLWNoYWxsZW5nZS10b2tlbg"` // Token returned by login
+	Code           string `json:"code" validate:"required" example:"123456"`                                    // TOTP code or an unused recovery code
+}
+
+// MFAEnrollmentResponse represents a started TOTP enrollment
+// @Description TOTP enrollment details
+type MFAEnrollmentResponse struct {
+	Secret     string `json:"secret" example:"JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"`                                          // Base32 secret for manual entry
+	OTPAuthURI string `json:"otpauth_uri" example:"otpauth://totp/Pintro:john.doe%40example.com?secret=JBSWY3DPEHPK3PXP"` // URI to render as a QR code
+}
+
+// MFACodeRequest represents a request confirmed with a TOTP code
+// @Description TOTP code confirmation request
+type MFACodeRequest struct {
+	Code string `json:"code" validate:"required,len=6,numeric" example:"123456"` // Current TOTP code
+}
+
+// MFADisableRequest represents a request to turn MFA off
+// @Description MFA disable request
+type MFADisableRequest struct {
+	Password string `json:"password" validate:"required" example:"password123"` // Current password
+	Code     string `json:"code" validate:"required" example:"123456"`          // TOTP code or an unused recovery code
+}
+
+// MFARecoveryCodesResponse returns freshly generated recovery codes. They are shown only once.
+// @Description MFA recovery codes
+type MFARecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes" example:"abcde-fghjk"` // One-time codes, each replaces a TOTP code once
+}
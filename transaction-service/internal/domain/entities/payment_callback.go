@@ -0,0 +1,109 @@
+package entities
+
+import (
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/hex"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PaymentCallback represents a raw payment gateway notification kept for audit
+// @Description Payment gateway callback audit record
+type PaymentCallback struct {
+	ID                   uuid.UUID  `json:"id" db:"id" example:"550e8400-e29b-41d4-a716-446655440000"`                                         // Callback record identifier
+	TransactionID        *uuid.UUID `json:"transaction_id" db:"transaction_id" example:"550e8400-e29b-41d4-a716-446655440000"`                 // Matched transaction (if any)
+	OrderID              string     `json:"order_id" db:"order_id" example:"TXN-12345678"`                                                     // Order ID sent by the gateway
+	GatewayTransactionID string     `json:"gateway_transaction_id" db:"gateway_transaction_id" example:"9aed5972-5b6a-401e-894b-a32c91ed1a3a"` // Gateway transaction ID
+	GatewayStatus        string     `json:"gateway_status" db:"gateway_status" example:"settlement"`                                           // Raw gateway transaction status
+	StatusCode           string     `json:"status_code" db:"status_code" example:"200"`                                                        // Raw gateway status code
+	GrossAmount          string     `json:"gross_amount" db:"gross_amount" example:"100000.00"`                                                // Raw gross amount
+	RawPayload           []byte     `json:"raw_payload" db:"raw_payload" swaggertype:"object"`                                                 // Payload exactly as received
+	Signature            string     `json:"signature" db:"signature" example:"abc123..."`                                                      // Signature sent by the gateway
+	Verified             bool       `json:"verified" db:"verified" example:"true"`                                                             // Whether the signature was valid
+	RejectionReason      string     `json:"rejection_reason,omitempty" db:"rejection_reason" example:"invalid signature"`                      // Why the callback was rejected
+	ReceivedAt           time.Time  `json:"received_at" db:"received_at" example:"2024-01-01T00:00:00Z"`                                       // When the callback arrived
+	ProcessedAt          *time.Time `json:"processed_at" db:"processed_at" example:"2024-01-01T00:00:00Z"`                                     // When the callback was applied
+	CreatedAt            time.Time  `json:"created_at" db:"created_at" example:"2024-01-01T00:00:00Z"`                                         // Record creation timestamp
+	UpdatedAt            time.Time  `json:"updated_at" db:"updated_at" example:"2024-01-01T00:00:00Z"`                                         // Last update timestamp
+}
+
+// NewPaymentCallback creates an audit record for a received callback payload
+func NewPaymentCallback(req CallbackRequest, rawPayload []byte) *PaymentCallback {
+	now := time.Now()
+	return &PaymentCallback{
+		ID:                   uuid.New(),
+		OrderID:              req.OrderID,
+		GatewayTransactionID: req.TransactionID,
+		GatewayStatus:        req.TransactionStatus,
+		StatusCode:           req.StatusCode,
+		GrossAmount:          req.GrossAmount,
+		RawPayload:           rawPayload,
+		Signature:            req.SignatureKey,
+		ReceivedAt:           now,
+		CreatedAt:            now,
+		UpdatedAt:            now,
+	}
+}
+
+// Reject marks the callback as rejected with the given reason
+func (p *PaymentCallback) Reject(reason string) {
+	p.RejectionReason = reason
+	p.UpdatedAt = time.Now()
+}
+
+// MarkAsProcessed marks the callback as applied to its transaction
+func (p *PaymentCallback) MarkAsProcessed() {
+	now := time.Now()
+	p.ProcessedAt = &now
+	p.UpdatedAt = now
+}
+
+// VerifySignature checks the Midtrans signature: SHA512(order_id + status_code + gross_amount + server_key)
+func (r CallbackRequest) VerifySignature(serverKey string) bool {
+	if serverKey == "" || r.SignatureKey == "" {
+		return false
+	}
+
+	expected := MidtransSignature(r.OrderID, r.StatusCode, r.GrossAmount, serverKey)
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(r.SignatureKey)) == 1
+}
+
+// MidtransSignature computes the hex encoded Midtrans notification signature
+func MidtransSignature(orderID, statusCode, grossAmount, serverKey string) string {
+	sum := sha512.Sum512([]byte(orderID + statusCode + grossAmount + serverKey))
+	return hex.EncodeToString(sum[:])
+}
+
+// MapGatewayStatus maps a payment gateway transaction status to the internal transaction status.
+// A card capture only completes once fraud detection accepted it; a challenged capture stays
+// pending until the merchant decides, and a denied one has failed.
+func MapGatewayStatus(gatewayStatus, fraudStatus string) TransactionStatus {
+	switch gatewayStatus {
+	case "settlement":
+		return TransactionStatusCompleted
+	case "capture":
+		switch fraudStatus {
+		case "accept":
+			return TransactionStatusCompleted
+		case "deny":
+			return TransactionStatusFailed
+		default:
+			return TransactionStatusPending
+		}
+	case "cancel", "expire":
+		return TransactionStatusCancelled
+	case "deny", "failure":
+		return TransactionStatusFailed
+	case "pending":
+		return TransactionStatusPending
+	default:
+		return ""
+	}
+}
+
+// IsGatewayRefundStatus checks if a payment gateway status reports a refund of a settled payment
+func IsGatewayRefundStatus(gatewayStatus string) bool {
+	return gatewayStatus == "refund" || gatewayStatus == "partial_refund"
+}
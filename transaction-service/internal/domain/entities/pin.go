@@ -0,0 +1,79 @@
+package entities
+
+import (
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// HasPIN checks if the user has set a transaction PIN
+func (u *User) HasPIN() bool {
+	return u.PINHash != ""
+}
+
+// SetPIN hashes and stores a new transaction PIN and clears any lockout
+func (u *User) SetPIN(pin string) error {
+	hashedPIN, err := bcrypt.GenerateFromPassword([]byte(pin), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	u.PINHash = string(hashedPIN)
+	u.ResetPINAttempts()
+	return nil
+}
+
+// CheckPIN verifies if the provided PIN matches the stored hash
+func (u *User) CheckPIN(pin string) bool {
+	if !u.HasPIN() {
+		return false
+	}
+	err := bcrypt.CompareHashAndPassword([]byte(u.PINHash), []byte(pin))
+	return err == nil
+}
+
+// IsPINLocked checks if outgoing transactions are refused after too many wrong PINs
+func (u *User) IsPINLocked(now time.Time) bool {
+	return u.PINLockedUntil != nil && now.Before(*u.PINLockedUntil)
+}
+
+// RecordPINFailure counts a wrong PIN and locks the PIN for lockDuration once maxAttempts is reached.
+// It returns the attempts left before the lock, 0 when the PIN is now locked.
+func (u *User) RecordPINFailure(now time.Time, maxAttempts int, lockDuration time.Duration) int {
+	u.PINFailedAttempts++
+	if u.PINFailedAttempts < maxAttempts {
+		return maxAttempts - u.PINFailedAttempts
+	}
+
+	lockedUntil := now.Add(lockDuration)
+	u.PINLockedUntil = &lockedUntil
+	u.PINFailedAttempts = 0
+	return 0
+}
+
+// ResetPINAttempts clears the failed attempt counter and the lockout
+func (u *User) ResetPINAttempts() {
+	u.PINFailedAttempts = 0
+	u.PINLockedUntil = nil
+}
+
+// SetPINRequest represents the first transaction PIN of a user
+// @Description Transaction PIN setup request
+type SetPINRequest struct {
+	PIN      string `json:"pin" validate:"required,len=6,numeric" example:"123456"` // New 6-digit PIN
+	Password string `json:"password" validate:"required" example:"password123"`     // Current password
+}
+
+// ChangePINRequest represents a transaction PIN change
+// @Description Transaction PIN change request
+type ChangePINRequest struct {
+	CurrentPIN string `json:"current_pin" validate:"required,len=6,numeric" example:"123456"` // Current PIN
+	NewPIN     string `json:"new_pin" validate:"required,len=6,numeric" example:"654321"`     // New 6-digit PIN
+}
+
+// ResetPINRequest represents a forgotten transaction PIN being replaced
+// @Description Transaction PIN reset request
+type ResetPINRequest struct {
+	Password string `json:"password" validate:"required" example:"password123"`         // Current password
+	Code     string `json:"code,omitempty" example:"123456"`                            // TOTP or recovery code, required when two-factor authentication is enabled
+	NewPIN   string `json:"new_pin" validate:"required,len=6,numeric" example:"654321"` // New 6-digit PIN
+}
@@ -0,0 +1,48 @@
+package entities
+
+// UpdateProfileRequest represents a change of the personal details of the authenticated user.
+// Omitted fields are left unchanged.
+// @Description Profile update request
+type UpdateProfileRequest struct {
+	FirstName *string `json:"first_name,omitempty" validate:"omitempty,min=2,max=100" example:"John"`   // New first name (minimum 2 characters)
+	LastName  *string `json:"last_name,omitempty" validate:"omitempty,min=2,max=100" example:"Doe"`     // New last name (minimum 2 characters)
+	Phone     *string `json:"phone,omitempty" validate:"omitempty,min=10,max=20" example:"+1234567890"` // New phone number (minimum 10 characters)
+}
+
+// ChangePasswordRequest represents a password change by the authenticated user
+// @Description Password change request
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required" example:"password123"`    // Current password
+	NewPassword     string `json:"new_password" validate:"required,min=8" example:"newpassword1"` // New password (minimum 8 characters)
+}
+
+// ChangeEmailRequest represents an email address change by the authenticated user
+// @Description Email change request
+type ChangeEmailRequest struct {
+	Email    string `json:"email" validate:"required,email" example:"john.new@example.com"` // New email address
+	Password string `json:"password" validate:"required" example:"password123"`             // Current password
+}
+
+// CloseAccountRequest represents the authenticated user closing their account
+// @Description Account closure request
+type CloseAccountRequest struct {
+	Password string `json:"password" validate:"required" example:"password123"` // Current password
+}
+
+// ApplyProfileUpdate copies the fields set in the request and reports whether anything changed
+func (u *User) ApplyProfileUpdate(req UpdateProfileRequest) bool {
+	changed := false
+	if req.FirstName != nil && *req.FirstName != u.FirstName {
+		u.FirstName = *req.FirstName
+		changed = true
+	}
+	if req.LastName != nil && *req.LastName != u.LastName {
+		u.LastName = *req.LastName
+		changed = true
+	}
+	if req.Phone != nil && *req.Phone != u.Phone {
+		u.Phone = *req.Phone
+		changed = true
+	}
+	return changed
+}
@@ -0,0 +1,55 @@
+package entities
+
+// Role represents the access level of a user
+// @Description User role enumeration
+type Role string
+
+const (
+	RoleUser    Role = "user"    // Wallet owner, can only act on their own account
+	RoleSupport Role = "support" // Back-office staff with read access to users and transactions
+	RoleAdmin   Role = "admin"   // Back-office administrator
+)
+
+// Permission represents a single action guarded by RequirePermission
+// @Description Permission enumeration
+type Permission string
+
+const (
+	PermissionUsersRead          Permission = "users:read"          // List, search and view any user
+	PermissionUsersWrite         Permission = "users:write"         // Change the status of any user
+	PermissionTransactionsRead   Permission = "transactions:read"   // View any transaction
+	PermissionTransactionsReview Permission = "transactions:review" // Approve or reject transactions under manual review
+	PermissionTransactionsRefund Permission = "transactions:refund" // Refund completed payments and top-ups
+	PermissionAPIKeysManage      Permission = "api_keys:manage"     // Create, list and revoke API keys
+	PermissionFeesManage         Permission = "fees:manage"         // Create, list, change and delete fee rules
+	PermissionKYCReview          Permission = "kyc:review"          // View KYC submissions and their documents, approve or reject them
+	PermissionRolesManage        Permission = "roles:manage"        // Assign and revoke the roles of users
+)
+
+// rolePermissions lists what each role is allowed to do
+var rolePermissions = map[Role][]Permission{
+	RoleUser:    {},
+	RoleSupport: {PermissionUsersRead, PermissionTransactionsRead},
+	RoleAdmin:   {PermissionUsersRead, PermissionUsersWrite, PermissionTransactionsRead, PermissionTransactionsReview, PermissionTransactionsRefund, PermissionAPIKeysManage, PermissionFeesManage, PermissionKYCReview, PermissionRolesManage},
+}
+
+// IsValid checks if the role is known
+func (r Role) IsValid() bool {
+	_, ok := rolePermissions[r]
+	return ok
+}
+
+// Permissions returns the permissions granted to the role
+func (r Role) Permissions() []Permission {
+	return append([]Permission{}, rolePermissions[r]...)
+}
+
+// HasPermission checks if the role grants the given permission
+func (r Role) HasPermission(permission Permission) bool {
+	for _, p := range rolePermissions[r] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ClientInfo identifies where a request came from
+type ClientInfo struct {
+	IPAddress string // Client IP as seen by the server
+	UserAgent string // User-Agent header of the request
+}
+
+// SecurityEventType represents the kind of a security event
+// @Description Security event type enumeration
+type SecurityEventType string
+
+const (
+	SecurityEventLoginSucceeded  SecurityEventType = "login_succeeded"  // Password and second factor accepted
+	SecurityEventLoginFailed     SecurityEventType = "login_failed"     // Unknown email, wrong password or wrong verification code
+	SecurityEventLoginThrottled  SecurityEventType = "login_throttled"  // Attempt refused while the account was locked or delayed
+	SecurityEventAccountLocked   SecurityEventType = "account_locked"   // Too many failed logins locked the account
+	SecurityEventAccountUnlocked SecurityEventType = "account_unlocked" // An administrator lifted the lock
+	SecurityEventRoleChanged     SecurityEventType = "role_changed"     // An administrator assigned another role
+)
+
+// SecurityEvent represents an audit record of an authentication related event
+// @Description Security event record
+type SecurityEvent struct {
+	ID        uuid.UUID         `json:"id" db:"id" example:"550e8400-e29b-41d4-a716-446655440000"`           // Event identifier
+	UserID    *uuid.UUID        `json:"user_id" db:"user_id" example:"550e8400-e29b-41d4-a716-446655440000"` // Account concerned, empty for unknown emails
+	Type      SecurityEventType `json:"type" db:"type" example:"login_failed"`                               // Event type
+	Email     string            `json:"email" db:"email" example:"john.doe@example.com"`                     // Email given by the client
+	IPAddress string            `json:"ip_address" db:"ip_address" example:"203.0.113.7"`                    // Client IP address
+	UserAgent string            `json:"user_agent" db:"user_agent" example:"Mozilla/5.0"`                    // Client user agent
+	Details   string            `json:"details" db:"details" example:"wrong password"`                       // Free-form details
+	CreatedAt time.Time         `json:"created_at" db:"created_at" example:"2024-01-01T00:00:00Z"`           // Event timestamp
+}
+
+// NewSecurityEvent creates a security event for the client
+func NewSecurityEvent(eventType SecurityEventType, userID *uuid.UUID, email string, client ClientInfo, details string) *SecurityEvent {
+	return &SecurityEvent{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Type:      eventType,
+		Email:     email,
+		IPAddress: client.IPAddress,
+		UserAgent: client.UserAgent,
+		Details:   details,
+		CreatedAt: time.Now(),
+	}
+}
+
+// SecurityEventListResponse represents a page of security events
+// @Description Paginated security event list response
+type SecurityEventListResponse struct {
+	Events     []*SecurityEvent   `json:"events"`     // Events, newest first
+	Pagination PaginationResponse `json:"pagination"` // Pagination metadata
+}
+
+// IsLoginLocked checks if logins are refused after too many failed attempts
+func (u *User) IsLoginLocked(now time.Time) bool {
+	return u.LockedUntil != nil && now.Before(*u.LockedUntil)
+}
+
+// LoginRetryAt returns when the next login attempt is accepted. From the delayAfter-th failure on,
+// each failure doubles the wait starting at baseDelay, up to maxDelay. It is zero without delay.
+func (u *User) LoginRetryAt(delayAfter int, baseDelay, maxDelay time.Duration) time.Time {
+	if u.LastFailedLoginAt == nil || delayAfter <= 0 || u.FailedLoginAttempts < delayAfter {
+		return time.Time{}
+	}
+
+	delay := baseDelay
+	for i := delayAfter; i < u.FailedLoginAttempts && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+
+	return u.LastFailedLoginAt.Add(delay)
+}
+
+// RecordLoginFailure counts a wrong password and locks logins for lockDuration once maxAttempts
+// is reached. It reports whether the account is now locked.
+func (u *User) RecordLoginFailure(now time.Time, maxAttempts int, lockDuration time.Duration) bool {
+	u.FailedLoginAttempts++
+	u.LastFailedLoginAt = &now
+	if maxAttempts <= 0 || u.FailedLoginAttempts < maxAttempts {
+		return false
+	}
+
+	lockedUntil := now.Add(lockDuration)
+	u.LockedUntil = &lockedUntil
+	u.FailedLoginAttempts = 0
+	return true
+}
+
+// ResetLoginFailures clears the failed login counter and the lockout
+func (u *User) ResetLoginFailures() {
+	u.FailedLoginAttempts = 0
+	u.LastFailedLoginAt = nil
+	u.LockedUntil = nil
+}
+
+// HasLoginFailures checks if there is anything for ResetLoginFailures to clear
+func (u *User) HasLoginFailures() bool {
+	return u.FailedLoginAttempts > 0 || u.LastFailedLoginAt != nil || u.LockedUntil != nil
+}
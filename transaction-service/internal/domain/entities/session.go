@@ -0,0 +1,101 @@
+package entities
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session represents a login of a user on a device. Its ID is the family of the refresh tokens
+// issued for the login and the sid claim of its access tokens. A session is active while its
+// family has a refresh token that is neither revoked nor expired.
+// @Description Login session
+type Session struct {
+	ID         uuid.UUID `json:"id" db:"id" example:"550e8400-e29b-41d4-a716-446655440000"`     // Session identifier
+	UserID     uuid.UUID `json:"-" db:"user_id"`                                                // Session owner
+	Device     string    `json:"device" db:"device" example:"Chrome on Windows"`                // Device described from the user agent
+	UserAgent  string    `json:"user_agent" db:"user_agent" example:"Mozilla/5.0"`              // User agent of the last login or refresh
+	IPAddress  string    `json:"ip_address" db:"ip_address" example:"203.0.113.7"`              // Client IP of the last login or refresh
+	CreatedAt  time.Time `json:"created_at" db:"created_at" example:"2024-01-01T00:00:00Z"`     // Login time
+	LastSeenAt time.Time `json:"last_seen_at" db:"last_seen_at" example:"2024-01-01T01:00:00Z"` // Last time the session was used
+	Current    bool      `json:"current" db:"-" example:"true"`                                 // Whether the listing request was made in this session
+}
+
+// NewSession creates a session for a login from the client
+func NewSession(userID uuid.UUID, client ClientInfo) *Session {
+	now := time.Now()
+	return &Session{
+		ID:         uuid.New(),
+		UserID:     userID,
+		Device:     DescribeDevice(client.UserAgent),
+		UserAgent:  client.UserAgent,
+		IPAddress:  client.IPAddress,
+		CreatedAt:  now,
+		LastSeenAt: now,
+	}
+}
+
+// SessionListResponse represents the active sessions of a user
+// @Description Active session list response
+type SessionListResponse struct {
+	Sessions []*Session `json:"sessions"` // Active sessions, most recently used first
+}
+
+// userAgentBrowsers and userAgentSystems are matched in order, as user agents name the engines they imitate too
+var (
+	userAgentBrowsers = []struct{ token, name string }{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+		{"okhttp/", "Android app"},
+		{"Dart/", "Mobile app"},
+		{"curl/", "curl"},
+		{"PostmanRuntime/", "Postman"},
+	}
+	userAgentSystems = []struct{ token, name string }{
+		{"iPhone", "iPhone"},
+		{"iPad", "iPad"},
+		{"Android", "Android"},
+		{"Windows", "Windows"},
+		{"Mac OS X", "macOS"},
+		{"CrOS", "ChromeOS"},
+		{"Linux", "Linux"},
+	}
+)
+
+// DescribeDevice returns a short description such as "Chrome on Windows" for a user agent
+func DescribeDevice(userAgent string) string {
+	if userAgent == "" {
+		return "Unknown device"
+	}
+
+	browser := ""
+	for _, b := range userAgentBrowsers {
+		if strings.Contains(userAgent, b.token) {
+			browser = b.name
+			break
+		}
+	}
+
+	system := ""
+	for _, s := range userAgentSystems {
+		if strings.Contains(userAgent, s.token) {
+			system = s.name
+			break
+		}
+	}
+
+	switch {
+	case browser != "" && system != "":
+		return browser + " on " + system
+	case browser != "":
+		return browser
+	case system != "":
+		return system
+	default:
+		return "Unknown device"
+	}
+}
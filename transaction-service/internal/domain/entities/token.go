@@ -0,0 +1,90 @@
+package entities
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RefreshToken represents an opaque, single-use refresh token. Only its hash is stored.
+// Every rotation issues a new token in the same family, so a whole login session can be revoked at once.
+// @Description Refresh token record
+type RefreshToken struct {
+	ID           uuid.UUID  `json:"id" db:"id" example:"550e8400-e29b-41d4-a716-446655440000"`                         // Refresh token identifier
+	UserID       uuid.UUID  `json:"user_id" db:"user_id" example:"550e8400-e29b-41d4-a716-446655440000"`               // Token owner
+	FamilyID     uuid.UUID  `json:"family_id" db:"family_id" example:"550e8400-e29b-41d4-a716-446655440000"`           // Login session the token belongs to
+	TokenHash    string     `json:"-" db:"token_hash"`                                                                 // SHA-256 of the token
+	ExpiresAt    time.Time  `json:"expires_at" db:"expires_at" example:"2024-01-31T00:00:00Z"`                         // Token expiration time
+	RevokedAt    *time.Time `json:"revoked_at" db:"revoked_at" example:"2024-01-01T00:00:00Z"`                         // When the token was used or revoked
+	ReplacedByID *uuid.UUID `json:"replaced_by_id" db:"replaced_by_id" example:"550e8400-e29b-41d4-a716-446655440000"` // Token issued when this one was rotated
+	CreatedAt    time.Time  `json:"created_at" db:"created_at" example:"2024-01-01T00:00:00Z"`                         // Token creation timestamp
+}
+
+// NewRefreshToken creates a refresh token in the given family and returns it with its plain value
+func NewRefreshToken(userID, familyID uuid.UUID, ttl time.Duration) (*RefreshToken, string, error) {
+	token, err := newOpaqueToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now()
+	return &RefreshToken{
+		ID:        uuid.New(),
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: HashRefreshToken(token),
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	}, token, nil
+}
+
+// HashRefreshToken returns the value stored for a plain refresh token
+func HashRefreshToken(token string) string {
+	return hashOpaqueToken(token)
+}
+
+// newOpaqueToken returns 32 random bytes, base64url encoded
+func newOpaqueToken() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// hashOpaqueToken returns the SHA-256 of a random token. A fast hash is enough for 256-bit tokens.
+func hashOpaqueToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsRevoked checks if the token was already rotated or revoked
+func (t *RefreshToken) IsRevoked() bool {
+	return t.RevokedAt != nil
+}
+
+// IsExpired checks if the token expired at the given time
+func (t *RefreshToken) IsExpired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
+
+// RefreshTokenRequest represents token refresh request payload
+// @Description Token refresh request
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required" example:"m1J3c2VjcmV0LXJlZnJlc2gtdG9rZW4"` // Refresh token returned by login or the previous refresh
+}
+
+// TokenVerificationResponse represents the result of verifying an access token
+// @Description Access token verification result
+type TokenVerificationResponse struct {
+	Valid       bool         `json:"valid" example:"true"`                                   // Whether the token is valid
+	UserID      uuid.UUID    `json:"user_id" example:"550e8400-e29b-41d4-a716-446655440000"` // User ID from token
+	Email       string       `json:"email" example:"john.doe@example.com"`                   // User email from token
+	Role        Role         `json:"role" example:"user"`                                    // User role from token
+	Permissions []Permission `json:"permissions"`                                            // Permissions granted by the role
+	ExpiresAt   time.Time    `json:"expires_at" example:"2024-01-01T00:15:00Z"`              // Token expiration time
+}
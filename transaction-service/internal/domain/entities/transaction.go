@@ -10,18 +10,23 @@ import (
 // Transaction represents a financial transaction in the system
 // @Description Financial transaction details
 type Transaction struct {
-	ID               uuid.UUID         `json:"id" db:"id" example:"550e8400-e29b-41d4-a716-446655440000"`                              // Transaction unique identifier
-	UserID           uuid.UUID         `json:"user_id" db:"user_id" example:"550e8400-e29b-41d4-a716-446655440000"`                   // User ID who initiated the transaction
-	Type             TransactionType   `json:"type" db:"type" example:"topup"`                                                          // Transaction type (topup, payment, transfer)
-	Amount           decimal.Decimal   `json:"amount" db:"amount" example:"100.50" swaggertype:"string"`                               // Transaction amount
-	Status           TransactionStatus `json:"status" db:"status" example:"pending"`                                                   // Transaction status
-	Reference        string            `json:"reference" db:"reference" example:"TXN-12345678"`                                        // Unique transaction reference
-	PaymentGatewayID string            `json:"payment_gateway_id" db:"payment_gateway_id" example:"midtrans-12345"`                   // Payment gateway transaction ID
-	Description      string            `json:"description" db:"description" example:"Top up wallet balance"`                          // Transaction description
-	Metadata         map[string]string `json:"metadata" db:"metadata" swaggertype:"object"`                                            // Additional transaction metadata
-	ProcessedAt      *time.Time        `json:"processed_at" db:"processed_at" example:"2024-01-01T00:00:00Z"`                        // Transaction processing timestamp
-	CreatedAt        time.Time         `json:"created_at" db:"created_at" example:"2024-01-01T00:00:00Z"`                            // Transaction creation timestamp
-	UpdatedAt        time.Time         `json:"updated_at" db:"updated_at" example:"2024-01-01T00:00:00Z"`                            // Last update timestamp
+	ID                  uuid.UUID            `json:"id" db:"id" example:"550e8400-e29b-41d4-a716-446655440000"`                                       // Transaction unique identifier
+	UserID              uuid.UUID            `json:"user_id" db:"user_id" example:"550e8400-e29b-41d4-a716-446655440000"`                             // User ID who initiated the transaction
+	Type                TransactionType      `json:"type" db:"type" example:"topup"`                                                                  // Transaction type (topup, payment, transfer, refund)
+	Direction           TransactionDirection `json:"direction" db:"direction" example:"outgoing"`                                                     // Direction from the initiating user's point of view
+	CounterpartyUserID  *uuid.UUID           `json:"counterparty_user_id" db:"counterparty_user_id" example:"550e8400-e29b-41d4-a716-446655440000"`   // Other user involved (recipient of a payment or transfer)
+	ParentTransactionID *uuid.UUID           `json:"parent_transaction_id" db:"parent_transaction_id" example:"550e8400-e29b-41d4-a716-446655440000"` // Transaction reversed by a refund
+	Amount              decimal.Decimal      `json:"amount" db:"amount" example:"100.50" swaggertype:"string"`                                        // Transaction amount
+	Fee                 decimal.Decimal      `json:"fee" db:"fee" example:"2500.00" swaggertype:"string"`                                             // Fee charged to the initiating user on top of the amount
+	Status              TransactionStatus    `json:"status" db:"status" example:"pending"`                                                            // Transaction status
+	Reference           string               `json:"reference" db:"reference" example:"TXN-12345678"`                                                 // Unique transaction reference
+	PaymentGatewayID    string               `json:"payment_gateway_id" db:"payment_gateway_id" example:"midtrans-12345"`                             // Payment gateway transaction ID
+	Description         string               `json:"description" db:"description" example:"Top up wallet balance"`                                    // Transaction description
+	Metadata            map[string]string    `json:"metadata" db:"metadata" swaggertype:"object"`                                                     // Additional transaction metadata
+	ProcessedAt         *time.Time           `json:"processed_at" db:"processed_at" example:"2024-01-01T00:00:00Z"`                                   // Transaction processing timestamp
+	ExpiresAt           *time.Time           `json:"expires_at" db:"expires_at" example:"2024-01-01T01:00:00Z"`                                       // When an unfinished transaction is cancelled
+	CreatedAt           time.Time            `json:"created_at" db:"created_at" example:"2024-01-01T00:00:00Z"`                                       // Transaction creation timestamp
+	UpdatedAt           time.Time            `json:"updated_at" db:"updated_at" example:"2024-01-01T00:00:00Z"`                                       // Last update timestamp
 }
 
 // TransactionType represents the type of transaction
@@ -32,6 +37,7 @@ const (
 	TransactionTypeTopup    TransactionType = "topup"    // Balance top-up transaction
 	TransactionTypePayment  TransactionType = "payment"  // Payment transaction
 	TransactionTypeTransfer TransactionType = "transfer" // Transfer transaction
+	TransactionTypeRefund   TransactionType = "refund"   // Full or partial reversal of a completed transaction
 )
 
 // TransactionStatus represents the status of a transaction
@@ -40,17 +46,67 @@ type TransactionStatus string
 
 const (
 	TransactionStatusPending    TransactionStatus = "pending"    // Transaction is pending
+	TransactionStatusReview     TransactionStatus = "review"     // Transaction is waiting for manual review, no funds moved yet
+	TransactionStatusAuthorized TransactionStatus = "authorized" // Funds are held for the counterparty until captured or voided
 	TransactionStatusProcessing TransactionStatus = "processing" // Transaction is being processed
 	TransactionStatusCompleted  TransactionStatus = "completed"  // Transaction completed successfully
 	TransactionStatusFailed     TransactionStatus = "failed"     // Transaction failed
 	TransactionStatusCancelled  TransactionStatus = "cancelled"  // Transaction was cancelled
 )
 
+// TransactionDirection tells whether money moved into or out of a user's wallet
+// @Description Transaction direction enumeration
+type TransactionDirection string
+
+const (
+	TransactionDirectionIncoming TransactionDirection = "incoming" // Money received by the user
+	TransactionDirectionOutgoing TransactionDirection = "outgoing" // Money sent by the user
+)
+
+// Metadata keys and values recorded when a transaction is cancelled
+const (
+	MetadataCancelReason = "cancel_reason"
+	CancelReasonExpired  = "expired"
+	CancelReasonRejected = "rejected"
+	CancelReasonVoided   = "voided"
+)
+
+// Metadata keys recorded when a transaction under manual review is approved or rejected
+const (
+	MetadataReviewedBy = "reviewed_by"
+	MetadataReviewNote = "review_note"
+)
+
+// MetadataReviewReason records why a paid top-up was held for review instead of being credited:
+// a limit reason such as LimitReasonMaxBalance, or ReviewReasonPaidAfterExpiry
+const (
+	MetadataReviewReason        = "review_reason"
+	ReviewReasonPaidAfterExpiry = "paid_after_expiry"
+)
+
+// Metadata keys recording the amount and fee an authorized payment held when it was captured for less
+const (
+	MetadataAuthorizedAmount = "authorized_amount"
+	MetadataAuthorizedFee    = "authorized_fee"
+)
+
+// Metadata keys recording how the fee of a transaction was set
+const (
+	MetadataPaymentMethod = "payment_method"
+	MetadataFeeRuleID     = "fee_rule_id"
+)
+
+// Metadata keys recorded when a completed transaction is refunded
+const (
+	MetadataRefundedBy   = "refunded_by"
+	MetadataRefundReason = "refund_reason"
+)
+
 // TopupRequest represents balance top-up request payload
 // @Description Balance top-up request
 type TopupRequest struct {
-	Amount        decimal.Decimal `json:"amount" validate:"required,gt=0" example:"100.50" swaggertype:"string"`    // Top-up amount (must be greater than 0)
-	PaymentMethod string          `json:"payment_method" validate:"required" example:"credit_card"`                 // Payment method for top-up
+	Amount        decimal.Decimal `json:"amount" validate:"required,gt=0" example:"100000" swaggertype:"string"`    // Top-up amount in whole rupiah (must be greater than 0)
+	PaymentMethod string          `json:"payment_method" validate:"required" example:"credit_card"`                 // Payment method for top-up: credit_card, bank_transfer, va_bca, va_bni, va_bri, va_permata, gopay, shopeepay, indomaret or alfamart
 }
 
 // PaymentRequest represents payment request payload
@@ -59,14 +115,33 @@ type PaymentRequest struct {
 	Amount      decimal.Decimal `json:"amount" validate:"required,gt=0" example:"50.25" swaggertype:"string"`                // Payment amount (must be greater than 0)
 	Description string          `json:"description" validate:"required" example:"Payment for services"`                      // Payment description
 	ToUserID    uuid.UUID       `json:"to_user_id" validate:"required" example:"550e8400-e29b-41d4-a716-446655440000"`      // Recipient user ID
+	PIN         string          `json:"pin" validate:"required,len=6,numeric" example:"123456"`                              // Transaction PIN of the sender
+}
+
+// AuthorizePaymentRequest represents a request to hold funds for a later payment
+// @Description Payment authorization request, the amount is held until captured or voided by the recipient
+type AuthorizePaymentRequest struct {
+	Amount           decimal.Decimal `json:"amount" validate:"required,gt=0" example:"50.25" swaggertype:"string"`           // Amount to hold (must be greater than 0)
+	Description      string          `json:"description" validate:"required" example:"Order #1042"`                          // Payment description
+	ToUserID         uuid.UUID       `json:"to_user_id" validate:"required" example:"550e8400-e29b-41d4-a716-446655440000"` // Recipient user ID, the only one allowed to capture or void
+	PIN              string          `json:"pin" validate:"required,len=6,numeric" example:"123456"`                         // Transaction PIN of the sender
+	ExpiresInMinutes int             `json:"expires_in_minutes,omitempty" validate:"omitempty,min=1" example:"1440"`         // How long the hold lasts, the configured default when omitted
+}
+
+// CapturePaymentRequest represents the capture of an authorized payment
+// @Description Payment capture request
+type CapturePaymentRequest struct {
+	Amount decimal.Decimal `json:"amount" example:"40.00" swaggertype:"string"` // Amount to charge, the whole authorized amount when omitted; the rest of the hold is released
 }
 
 // TransferRequest represents money transfer request payload
-// @Description Money transfer request
+// @Description Money transfer request, the recipient is looked up by email or phone number
 type TransferRequest struct {
-	Amount      decimal.Decimal `json:"amount" validate:"required,gt=0" example:"75.00" swaggertype:"string"`                // Transfer amount (must be greater than 0)
-	ToUserID    uuid.UUID       `json:"to_user_id" validate:"required" example:"550e8400-e29b-41d4-a716-446655440000"`      // Recipient user ID
-	Description string          `json:"description" validate:"required" example:"Transfer to friend"`                        // Transfer description
+	Amount      decimal.Decimal `json:"amount" validate:"required,gt=0" example:"75.00" swaggertype:"string"`     // Transfer amount (must be greater than 0)
+	ToEmail     string          `json:"to_email,omitempty" validate:"omitempty,email" example:"jane@example.com"` // Recipient email address (either this or to_phone)
+	ToPhone     string          `json:"to_phone,omitempty" validate:"omitempty,min=10" example:"+6281234567890"`  // Recipient phone number (either this or to_email)
+	Description string          `json:"description" validate:"required" example:"Transfer to friend"`             // Transfer description
+	PIN         string          `json:"pin" validate:"required,len=6,numeric" example:"123456"`                   // Transaction PIN of the sender
 }
 
 // TransactionResponse represents transaction operation response
@@ -75,37 +150,46 @@ type TransactionResponse struct {
 	ID         uuid.UUID         `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`              // Transaction ID
 	Status     TransactionStatus `json:"status" example:"pending"`                                       // Transaction status
 	Amount     decimal.Decimal   `json:"amount" example:"100.50" swaggertype:"string"`                   // Transaction amount
-	PaymentURL string            `json:"payment_url,omitempty" example:"https://app.midtrans.com/snap/"`  // Payment URL (for topup transactions)
+	Fee        decimal.Decimal   `json:"fee" example:"2500.00" swaggertype:"string"`                     // Fee charged on top of the amount
+	PaymentURL string            `json:"payment_url,omitempty" example:"https://app.midtrans.com/snap/"` // Payment URL (for topup transactions)
 	Reference  string            `json:"reference" example:"TXN-12345678"`                               // Transaction reference
-	CreatedAt  time.Time         `json:"created_at" example:"2024-01-01T00:00:00Z"`                     // Creation timestamp
+	ExpiresAt  *time.Time        `json:"expires_at,omitempty" example:"2024-01-01T01:00:00Z"`            // Payment deadline (for topup transactions)
+	CreatedAt  time.Time         `json:"created_at" example:"2024-01-01T00:00:00Z"`                      // Creation timestamp
 }
 
 // TransactionHistoryResponse represents transaction in history list
 // @Description Transaction history item
 type TransactionHistoryResponse struct {
-	ID          uuid.UUID         `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`      // Transaction ID
-	Type        TransactionType   `json:"type" example:"topup"`                                   // Transaction type
-	Amount      decimal.Decimal   `json:"amount" example:"100.50" swaggertype:"string"`           // Transaction amount
-	Status      TransactionStatus `json:"status" example:"completed"`                             // Transaction status
-	Description string            `json:"description" example:"Top up wallet balance"`            // Transaction description
-	Reference   string            `json:"reference" example:"TXN-12345678"`                       // Transaction reference
-	ProcessedAt *time.Time        `json:"processed_at" example:"2024-01-01T00:00:00Z"`           // Processing timestamp
-	CreatedAt   time.Time         `json:"created_at" example:"2024-01-01T00:00:00Z"`             // Creation timestamp
+	ID                 uuid.UUID            `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`                             // Transaction ID
+	Type               TransactionType      `json:"type" example:"topup"`                                                          // Transaction type
+	Direction          TransactionDirection `json:"direction" example:"incoming"`                                                  // Direction from the viewer's point of view
+	Amount             decimal.Decimal      `json:"amount" example:"100.50" swaggertype:"string"`                                  // Transaction amount
+	SignedAmount       decimal.Decimal      `json:"signed_amount" example:"-100.50" swaggertype:"string"`                          // Amount signed from the viewer's point of view (negative when outgoing)
+	CounterpartyUserID *uuid.UUID           `json:"counterparty_user_id,omitempty" example:"550e8400-e29b-41d4-a716-446655440000"` // Other user involved, seen from the viewer
+	Status             TransactionStatus    `json:"status" example:"completed"`                                                    // Transaction status
+	Description        string               `json:"description" example:"Top up wallet balance"`                                   // Transaction description
+	Reference          string               `json:"reference" example:"TXN-12345678"`                                              // Transaction reference
+	ProcessedAt        *time.Time           `json:"processed_at" example:"2024-01-01T00:00:00Z"`                                   // Processing timestamp
+	CreatedAt          time.Time            `json:"created_at" example:"2024-01-01T00:00:00Z"`                                     // Creation timestamp
 }
 
 // BalanceResponse represents user balance response
 // @Description User wallet balance
 type BalanceResponse struct {
-	Balance decimal.Decimal `json:"balance" example:"1000.50" swaggertype:"string"` // Current wallet balance
+	Balance          decimal.Decimal `json:"balance" example:"1000.50" swaggertype:"string"`           // Current wallet balance
+	AvailableBalance decimal.Decimal `json:"available_balance" example:"750.50" swaggertype:"string"` // Balance that can be spent
+	HeldBalance      decimal.Decimal `json:"held_balance" example:"250.00" swaggertype:"string"`      // Balance held for authorized payments
 }
 
 // PaginationResponse represents pagination metadata
 // @Description Pagination information
 type PaginationResponse struct {
-	Limit  int `json:"limit" example:"10"`  // Number of items per page
-	Offset int `json:"offset" example:"0"`  // Number of items to skip
-	Count  int `json:"count" example:"5"`   // Number of items in current response
-	Total  int `json:"total" example:"100"` // Total number of items available
+	Limit      int    `json:"limit" example:"10"`                                               // Number of items per page
+	Offset     int    `json:"offset,omitempty" example:"0"`                                     // Number of items skipped (offset-paginated lists only)
+	Count      int    `json:"count" example:"5"`                                                // Number of items in current response
+	Total      int    `json:"total" example:"100"`                                              // Total number of items matching the filters
+	HasMore    bool   `json:"has_more" example:"true"`                                          // Whether another page is available
+	NextCursor string `json:"next_cursor,omitempty" example:"MjAyNC0wMS0wMVQwMDowMDowMFp8NTUw"` // Cursor to request the next page
 }
 
 // TransactionHistoryListResponse represents paginated transaction history
@@ -115,14 +199,56 @@ type TransactionHistoryListResponse struct {
 	Pagination   PaginationResponse           `json:"pagination"`   // Pagination metadata
 }
 
+// ApproveTransactionRequest represents admin approval of a transaction under review
+// @Description Transaction approval request
+type ApproveTransactionRequest struct {
+	Note string `json:"note" example:"Verified with the customer by phone"` // Optional note stored with the transaction
+}
+
+// RejectTransactionRequest represents admin rejection of a transaction under review
+// @Description Transaction rejection request
+type RejectTransactionRequest struct {
+	Reason string `json:"reason" validate:"required" example:"Recipient account reported as fraudulent"` // Why the transaction was rejected
+}
+
+// RefundTransactionRequest represents a refund of a completed payment or top-up
+// @Description Transaction refund request
+type RefundTransactionRequest struct {
+	Amount decimal.Decimal `json:"amount" example:"25.00" swaggertype:"string"`                          // Amount to refund, the whole remaining amount when omitted
+	Reason string          `json:"reason" validate:"required" example:"Order cancelled by the merchant"` // Why the transaction is refunded
+}
+
 // CallbackRequest represents payment gateway callback payload
 // @Description Payment gateway callback request
 type CallbackRequest struct {
-	OrderID           string `json:"order_id" example:"TXN-12345678"`           // Transaction reference/order ID
-	TransactionStatus string `json:"transaction_status" example:"settlement"`   // Payment gateway transaction status
-	PaymentType       string `json:"payment_type" example:"credit_card"`        // Payment method used
-	GrossAmount       string `json:"gross_amount" example:"100000.00"`          // Transaction amount in string format
-	SignatureKey      string `json:"signature_key" example:"abc123..."`         // Security signature from payment gateway
+	OrderID           string `json:"order_id" example:"TXN-12345678"`                               // Transaction reference/order ID
+	TransactionID     string `json:"transaction_id" example:"9aed5972-5b6a-401e-894b-a32c91ed1a3a"` // Payment gateway transaction ID
+	TransactionStatus string `json:"transaction_status" example:"settlement"`                       // Payment gateway transaction status
+	StatusCode        string `json:"status_code" example:"200"`                                     // Payment gateway status code
+	PaymentType       string `json:"payment_type" example:"credit_card"`                            // Payment method used
+	GrossAmount       string `json:"gross_amount" example:"100000.00"`                              // Transaction amount in string format
+	FraudStatus       string `json:"fraud_status,omitempty" example:"accept"`                       // Fraud detection result
+	SignatureKey      string `json:"signature_key" example:"abc123..."`                             // Security signature from payment gateway
+}
+
+// TopupPaymentMethod is a way to pay a top-up through the payment gateway
+type TopupPaymentMethod struct {
+	Channel     string // Payment channel enabled on the gateway payment page
+	PaymentType string // payment_type the gateway reports in notifications for the channel
+}
+
+// TopupPaymentMethods lists the payment methods a top-up can be paid with
+var TopupPaymentMethods = map[string]TopupPaymentMethod{
+	"credit_card":   {Channel: "credit_card", PaymentType: "credit_card"},
+	"bank_transfer": {Channel: "bank_transfer", PaymentType: "bank_transfer"},
+	"va_bca":        {Channel: "bca_va", PaymentType: "bank_transfer"},
+	"va_bni":        {Channel: "bni_va", PaymentType: "bank_transfer"},
+	"va_bri":        {Channel: "bri_va", PaymentType: "bank_transfer"},
+	"va_permata":    {Channel: "permata_va", PaymentType: "bank_transfer"},
+	"gopay":         {Channel: "gopay", PaymentType: "gopay"},
+	"shopeepay":     {Channel: "shopeepay", PaymentType: "shopeepay"},
+	"indomaret":     {Channel: "indomaret", PaymentType: "cstore"},
+	"alfamart":      {Channel: "alfamart", PaymentType: "cstore"},
 }
 
 // NewTransaction creates a new transaction entity
@@ -131,6 +257,7 @@ func NewTransaction(userID uuid.UUID, transactionType TransactionType, amount de
 		ID:          uuid.New(),
 		UserID:      userID,
 		Type:        transactionType,
+		Direction:   directionOf(transactionType),
 		Amount:      amount,
 		Status:      TransactionStatusPending,
 		Reference:   generateReference(),
@@ -141,6 +268,64 @@ func NewTransaction(userID uuid.UUID, transactionType TransactionType, amount de
 	}
 }
 
+// NewRefundTransaction creates a refund of the given amount reversing a completed transaction.
+// The refund belongs to the same user and counterparty, with the money moving the other way.
+func NewRefundTransaction(parent *Transaction, amount decimal.Decimal, description string) *Transaction {
+	refund := NewTransaction(parent.UserID, TransactionTypeRefund, amount, description)
+	refund.ParentTransactionID = &parent.ID
+	refund.CounterpartyUserID = parent.CounterpartyUserID
+	refund.Direction = TransactionDirectionIncoming
+	if parent.Direction == TransactionDirectionIncoming {
+		refund.Direction = TransactionDirectionOutgoing
+	}
+	return refund
+}
+
+// directionOf returns the direction a transaction of the given type has for its initiator
+func directionOf(transactionType TransactionType) TransactionDirection {
+	if transactionType == TransactionTypeTopup {
+		return TransactionDirectionIncoming
+	}
+	return TransactionDirectionOutgoing
+}
+
+// TotalDebit returns what the initiating user pays: the amount plus the fee
+func (t *Transaction) TotalDebit() decimal.Decimal {
+	if t.Fee.IsZero() {
+		return t.Amount
+	}
+	return t.Amount.Add(t.Fee)
+}
+
+// IsCancelledByExpiry checks if the transaction was cancelled because its deadline passed
+func (t *Transaction) IsCancelledByExpiry() bool {
+	return t.Status == TransactionStatusCancelled && t.Metadata[MetadataCancelReason] == CancelReasonExpired
+}
+
+// MarkForReview holds the transaction for manual review
+func (t *Transaction) MarkForReview() {
+	t.Status = TransactionStatusReview
+	t.UpdatedAt = time.Now()
+}
+
+// IsUnderReview checks if the transaction is waiting for manual review
+func (t *Transaction) IsUnderReview() bool {
+	return t.Status == TransactionStatusReview
+}
+
+// MarkAsAuthorized holds the transaction amount for the counterparty for the given time;
+// zero keeps the hold until it is captured or voided
+func (t *Transaction) MarkAsAuthorized(ttl time.Duration) {
+	t.Status = TransactionStatusAuthorized
+	t.SetExpiry(ttl)
+	t.UpdatedAt = time.Now()
+}
+
+// IsAuthorized checks if the transaction holds funds waiting to be captured or voided
+func (t *Transaction) IsAuthorized() bool {
+	return t.Status == TransactionStatusAuthorized
+}
+
 // MarkAsProcessing updates transaction status to processing
 func (t *Transaction) MarkAsProcessing() {
 	t.Status = TransactionStatusProcessing
@@ -167,6 +352,61 @@ func (t *Transaction) MarkAsCancelled() {
 	t.UpdatedAt = time.Now()
 }
 
+// ApplyFee charges the quoted fee and records the payment method and fee rule behind it
+func (t *Transaction) ApplyFee(quote *FeeQuote) {
+	t.Fee = quote.Fee
+	if t.Metadata == nil {
+		t.Metadata = make(map[string]string)
+	}
+	if quote.PaymentMethod != "" {
+		t.Metadata[MetadataPaymentMethod] = quote.PaymentMethod
+	}
+	if quote.FeeRuleID != nil {
+		t.Metadata[MetadataFeeRuleID] = quote.FeeRuleID.String()
+	} else {
+		delete(t.Metadata, MetadataFeeRuleID)
+	}
+}
+
+// CapturePartially lowers the amount of an authorized payment captured for less than it held. The fee
+// held at authorization is pro-rated, never re-quoted, so the charge stays within the hold.
+func (t *Transaction) CapturePartially(amount decimal.Decimal) {
+	if t.Metadata == nil {
+		t.Metadata = make(map[string]string)
+	}
+	t.Metadata[MetadataAuthorizedAmount] = t.Amount.String()
+	if t.Fee.IsPositive() {
+		t.Metadata[MetadataAuthorizedFee] = t.Fee.String()
+		t.Fee = t.Fee.Mul(amount).Div(t.Amount).RoundDown(2)
+	}
+	t.Amount = amount
+}
+
+// PaidWith checks that a gateway payment_type matches the payment method the top-up was priced
+// for. Transactions without a recorded method accept any payment type.
+func (t *Transaction) PaidWith(paymentType string) bool {
+	method, ok := t.Metadata[MetadataPaymentMethod]
+	if !ok {
+		return true
+	}
+	return TopupPaymentMethods[method].PaymentType == paymentType
+}
+
+// SetExpiry sets the deadline of the transaction relative to its creation; zero means it never expires
+func (t *Transaction) SetExpiry(ttl time.Duration) {
+	if ttl <= 0 {
+		t.ExpiresAt = nil
+		return
+	}
+	expiresAt := t.CreatedAt.Add(ttl)
+	t.ExpiresAt = &expiresAt
+}
+
+// IsExpired checks if an unfinished transaction or an authorization has passed its deadline
+func (t *Transaction) IsExpired(now time.Time) bool {
+	return t.ExpiresAt != nil && (t.CanBeProcessed() || t.IsAuthorized()) && now.After(*t.ExpiresAt)
+}
+
 // IsCompleted checks if transaction is completed
 func (t *Transaction) IsCompleted() bool {
 	return t.Status == TransactionStatusCompleted
@@ -182,6 +422,11 @@ func (t *Transaction) IsProcessing() bool {
 	return t.Status == TransactionStatusProcessing
 }
 
+// IsRefundable checks if the transaction is a completed payment or top-up that may be refunded
+func (t *Transaction) IsRefundable() bool {
+	return t.IsCompleted() && (t.Type == TransactionTypePayment || t.Type == TransactionTypeTopup)
+}
+
 // CanBeProcessed checks if transaction can be processed
 func (t *Transaction) CanBeProcessed() bool {
 	return t.Status == TransactionStatusPending || t.Status == TransactionStatusProcessing
@@ -193,22 +438,26 @@ func (t *Transaction) ToResponse() TransactionResponse {
 		ID:        t.ID,
 		Status:    t.Status,
 		Amount:    t.Amount,
+		Fee:       t.Fee,
 		Reference: t.Reference,
 		CreatedAt: t.CreatedAt,
 	}
 }
 
-// ToHistoryResponse converts Transaction to TransactionHistoryResponse
-func (t *Transaction) ToHistoryResponse() TransactionHistoryResponse {
+// ToHistoryResponse converts Transaction to TransactionHistoryResponse seen by the given user
+func (t *Transaction) ToHistoryResponse(viewerID uuid.UUID) TransactionHistoryResponse {
 	return TransactionHistoryResponse{
-		ID:          t.ID,
-		Type:        t.Type,
-		Amount:      t.Amount,
-		Status:      t.Status,
-		Description: t.Description,
-		Reference:   t.Reference,
-		ProcessedAt: t.ProcessedAt,
-		CreatedAt:   t.CreatedAt,
+		ID:                 t.ID,
+		Type:               t.Type,
+		Direction:          t.DirectionFor(viewerID),
+		Amount:             t.Amount,
+		SignedAmount:       t.SignedAmountFor(viewerID),
+		CounterpartyUserID: t.CounterpartyFor(viewerID),
+		Status:             t.Status,
+		Description:        t.Description,
+		Reference:          t.Reference,
+		ProcessedAt:        t.ProcessedAt,
+		CreatedAt:          t.CreatedAt,
 	}
 }
 
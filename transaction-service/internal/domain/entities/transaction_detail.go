@@ -0,0 +1,124 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// TransactionStatusChange represents a single entry of a transaction status timeline
+// @Description Transaction status change
+type TransactionStatusChange struct {
+	FromStatus TransactionStatus `json:"from_status,omitempty" example:"processing"` // Previous status (empty when the transaction was created)
+	ToStatus   TransactionStatus `json:"to_status" example:"completed"`              // New status
+	ChangedAt  time.Time         `json:"changed_at" example:"2024-01-01T00:00:00Z"`  // When the status changed
+}
+
+// CounterpartySummary represents the public profile of the other party of a transaction
+// @Description Counterparty profile summary
+type CounterpartySummary struct {
+	ID        uuid.UUID `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"` // Counterparty user ID
+	FirstName string    `json:"first_name" example:"Jane"`                         // Counterparty first name
+	LastName  string    `json:"last_name" example:"Doe"`                           // Counterparty last name
+}
+
+// TransactionDetailResponse represents the full details of a single transaction
+// @Description Transaction details
+type TransactionDetailResponse struct {
+	ID                  uuid.UUID                 `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`                              // Transaction ID
+	UserID              uuid.UUID                 `json:"user_id" example:"550e8400-e29b-41d4-a716-446655440000"`                         // User ID who initiated the transaction
+	Type                TransactionType           `json:"type" example:"payment"`                                                         // Transaction type
+	Direction           TransactionDirection      `json:"direction" example:"outgoing"`                                                   // Direction from the viewer's point of view
+	Amount              decimal.Decimal           `json:"amount" example:"100.50" swaggertype:"string"`                                   // Transaction amount
+	Fee                 decimal.Decimal           `json:"fee" example:"0" swaggertype:"string"`                                           // Fee paid by the initiator
+	SignedAmount        decimal.Decimal           `json:"signed_amount" example:"-100.50" swaggertype:"string"`                           // Amount signed from the viewer's point of view (negative when outgoing)
+	Status              TransactionStatus         `json:"status" example:"completed"`                                                     // Transaction status
+	Reference           string                    `json:"reference" example:"TXN-12345678"`                                               // Transaction reference
+	PaymentGatewayID    string                    `json:"payment_gateway_id,omitempty" example:"midtrans-12345"`                          // Payment gateway transaction ID
+	Description         string                    `json:"description" example:"Payment for services"`                                     // Transaction description
+	Metadata            map[string]string         `json:"metadata" swaggertype:"object"`                                                  // Additional transaction metadata
+	Counterparty        *CounterpartySummary      `json:"counterparty,omitempty"`                                                         // Other party of the transaction, if any
+	ParentTransactionID *uuid.UUID                `json:"parent_transaction_id,omitempty" example:"550e8400-e29b-41d4-a716-446655440000"` // Transaction reversed by this refund
+	Timeline            []TransactionStatusChange `json:"timeline"`                                                                       // Status changes, oldest first
+	ProcessedAt         *time.Time                `json:"processed_at" example:"2024-01-01T00:00:00Z"`                                    // Processing timestamp
+	ExpiresAt           *time.Time                `json:"expires_at,omitempty" example:"2024-01-01T01:00:00Z"`                            // Payment deadline
+	CreatedAt           time.Time                 `json:"created_at" example:"2024-01-01T00:00:00Z"`                                      // Creation timestamp
+	UpdatedAt           time.Time                 `json:"updated_at" example:"2024-01-01T00:00:00Z"`                                      // Last update timestamp
+}
+
+// IsVisibleTo checks if the user is the initiator or the counterparty of the transaction
+func (t *Transaction) IsVisibleTo(userID uuid.UUID) bool {
+	return t.UserID == userID || (t.CounterpartyUserID != nil && *t.CounterpartyUserID == userID)
+}
+
+// DirectionFor returns the direction of the transaction from the given user's point of view
+func (t *Transaction) DirectionFor(userID uuid.UUID) TransactionDirection {
+	if t.UserID == userID {
+		return t.Direction
+	}
+	if t.Direction == TransactionDirectionOutgoing {
+		return TransactionDirectionIncoming
+	}
+	return TransactionDirectionOutgoing
+}
+
+// SignedAmountFor returns the wallet change seen from the given user's point of view, negative
+// when outgoing. The fee is only paid by the initiator.
+func (t *Transaction) SignedAmountFor(userID uuid.UUID) decimal.Decimal {
+	amount := t.Amount
+	if t.UserID == userID {
+		amount = t.TotalDebit()
+	}
+	if t.DirectionFor(userID) == TransactionDirectionOutgoing {
+		return amount.Neg()
+	}
+	return amount
+}
+
+// CounterpartyFor returns the other user of the transaction from the given user's point of view
+func (t *Transaction) CounterpartyFor(userID uuid.UUID) *uuid.UUID {
+	if t.UserID == userID {
+		return t.CounterpartyUserID
+	}
+	initiator := t.UserID
+	return &initiator
+}
+
+// ToDetailResponse converts Transaction to TransactionDetailResponse
+func (t *Transaction) ToDetailResponse(viewerID uuid.UUID, counterparty *CounterpartySummary, timeline []TransactionStatusChange) *TransactionDetailResponse {
+	if timeline == nil {
+		timeline = []TransactionStatusChange{}
+	}
+
+	return &TransactionDetailResponse{
+		ID:                  t.ID,
+		UserID:              t.UserID,
+		Type:                t.Type,
+		Direction:           t.DirectionFor(viewerID),
+		Amount:              t.Amount,
+		Fee:                 t.Fee,
+		SignedAmount:        t.SignedAmountFor(viewerID),
+		Status:              t.Status,
+		Reference:           t.Reference,
+		PaymentGatewayID:    t.PaymentGatewayID,
+		Description:         t.Description,
+		Metadata:            t.Metadata,
+		Counterparty:        counterparty,
+		ParentTransactionID: t.ParentTransactionID,
+		Timeline:            timeline,
+		ProcessedAt:         t.ProcessedAt,
+		ExpiresAt:           t.ExpiresAt,
+		CreatedAt:           t.CreatedAt,
+		UpdatedAt:           t.UpdatedAt,
+	}
+}
+
+// ToCounterpartySummary converts User to CounterpartySummary
+func (u *User) ToCounterpartySummary() *CounterpartySummary {
+	return &CounterpartySummary{
+		ID:        u.ID,
+		FirstName: u.FirstName,
+		LastName:  u.LastName,
+	}
+}
@@ -0,0 +1,115 @@
+package entities
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Default and maximum number of transactions per history page
+const (
+	DefaultTransactionPageSize = 10
+	MaxTransactionPageSize     = 100
+)
+
+var (
+	ErrInvalidTransactionFilter = errors.New("invalid transaction filter")
+	ErrInvalidCursor            = errors.New("invalid cursor")
+)
+
+// TransactionFilter narrows down a transaction history query. Zero values are ignored.
+type TransactionFilter struct {
+	Type      TransactionType
+	Status    TransactionStatus
+	MinAmount *decimal.Decimal
+	MaxAmount *decimal.Decimal
+	From      *time.Time // Inclusive lower bound on created_at
+	To        *time.Time // Exclusive upper bound on created_at
+	Search    string     // Case-insensitive match on reference or description
+	Direction TransactionDirection
+}
+
+// Validate checks the filter values
+func (f TransactionFilter) Validate() error {
+	switch f.Type {
+	case "", TransactionTypeTopup, TransactionTypePayment, TransactionTypeTransfer, TransactionTypeRefund:
+	default:
+		return ErrInvalidTransactionFilter
+	}
+
+	switch f.Status {
+	case "", TransactionStatusPending, TransactionStatusReview, TransactionStatusAuthorized, TransactionStatusProcessing, TransactionStatusCompleted,
+		TransactionStatusFailed, TransactionStatusCancelled:
+	default:
+		return ErrInvalidTransactionFilter
+	}
+
+	switch f.Direction {
+	case "", TransactionDirectionIncoming, TransactionDirectionOutgoing:
+	default:
+		return ErrInvalidTransactionFilter
+	}
+
+	if f.MinAmount != nil && f.MaxAmount != nil && f.MinAmount.GreaterThan(*f.MaxAmount) {
+		return ErrInvalidTransactionFilter
+	}
+
+	if f.From != nil && f.To != nil && !f.From.Before(*f.To) {
+		return ErrInvalidTransactionFilter
+	}
+
+	return nil
+}
+
+// TransactionCursor points at the last transaction of a history page, in (created_at, id) order
+type TransactionCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// NewTransactionCursor creates a cursor positioned after the given transaction
+func NewTransactionCursor(t *Transaction) *TransactionCursor {
+	return &TransactionCursor{CreatedAt: t.CreatedAt, ID: t.ID}
+}
+
+// Encode returns the opaque string handed out to clients
+func (c TransactionCursor) Encode() string {
+	raw := c.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeTransactionCursor parses a cursor produced by Encode
+func DecodeTransactionCursor(encoded string) (*TransactionCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	parts := strings.SplitN(string(raw), "|", 2)
+	if len(parts) != 2 {
+		return nil, ErrInvalidCursor
+	}
+
+	createdAt, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	id, err := uuid.Parse(parts[1])
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &TransactionCursor{CreatedAt: createdAt, ID: id}, nil
+}
+
+// TransactionPageRequest describes which page of a transaction history to load
+type TransactionPageRequest struct {
+	Limit     int
+	Cursor    *TransactionCursor // Continue after this transaction; nil for the first page
+	Ascending bool               // Oldest first instead of newest first
+}
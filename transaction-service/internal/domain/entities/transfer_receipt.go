@@ -0,0 +1,41 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// TransferReceipt represents the receipt of a wallet-to-wallet transfer
+// @Description Transfer receipt
+type TransferReceipt struct {
+	TransactionID uuid.UUID           `json:"transaction_id" example:"550e8400-e29b-41d4-a716-446655440000"` // Transaction ID
+	Reference     string              `json:"reference" example:"TXN-12345678"`                              // Transaction reference
+	Status        TransactionStatus   `json:"status" example:"completed"`                                    // Transaction status
+	Amount        decimal.Decimal     `json:"amount" example:"75.00" swaggertype:"string"`                   // Amount received by the recipient
+	Fee           decimal.Decimal     `json:"fee" example:"2500.00" swaggertype:"string"`                    // Fee paid by the sender
+	TotalDebited  decimal.Decimal     `json:"total_debited" example:"2575.00" swaggertype:"string"`          // Amount plus fee taken from the sender's wallet
+	Description   string              `json:"description" example:"Transfer to friend"`                      // Transfer description
+	Sender        CounterpartySummary `json:"sender"`                                                        // Sending user
+	Recipient     CounterpartySummary `json:"recipient"`                                                     // Receiving user
+	ProcessedAt   *time.Time          `json:"processed_at" example:"2024-01-01T00:00:00Z"`                   // Completion timestamp
+	CreatedAt     time.Time           `json:"created_at" example:"2024-01-01T00:00:00Z"`                     // Creation timestamp
+}
+
+// NewTransferReceipt builds the receipt of a transfer between the given users
+func NewTransferReceipt(transaction *Transaction, sender, recipient *User) *TransferReceipt {
+	return &TransferReceipt{
+		TransactionID: transaction.ID,
+		Reference:     transaction.Reference,
+		Status:        transaction.Status,
+		Amount:        transaction.Amount,
+		Fee:           transaction.Fee,
+		TotalDebited:  transaction.TotalDebit(),
+		Description:   transaction.Description,
+		Sender:        *sender.ToCounterpartySummary(),
+		Recipient:     *recipient.ToCounterpartySummary(),
+		ProcessedAt:   transaction.ProcessedAt,
+		CreatedAt:     transaction.CreatedAt,
+	}
+}
@@ -1,6 +1,7 @@
 package entities
 
 import (
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
@@ -11,16 +12,29 @@ import (
 // User represents user entity in the system
 // @Description User account information
 type User struct {
-	ID        uuid.UUID       `json:"id" db:"id" example:"550e8400-e29b-41d4-a716-446655440000"`                      // User unique identifier
-	Email     string          `json:"email" db:"email" example:"john.doe@example.com"`                                // User email address
-	Password  string          `json:"-" db:"password"`                                                                // User password (not exposed in JSON)
-	FirstName string          `json:"first_name" db:"first_name" example:"John"`                                      // User first name
-	LastName  string          `json:"last_name" db:"last_name" example:"Doe"`                                         // User last name
-	Phone     string          `json:"phone" db:"phone" example:"+1234567890"`                                         // User phone number
-	Balance   decimal.Decimal `json:"balance" db:"balance" example:"1000.50" swaggertype:"string"`                   // User wallet balance
-	Status    UserStatus      `json:"status" db:"status" example:"active"`                                            // User account status
-	CreatedAt time.Time       `json:"created_at" db:"created_at" example:"2024-01-01T00:00:00Z"`                     // Account creation timestamp
-	UpdatedAt time.Time       `json:"updated_at" db:"updated_at" example:"2024-01-01T00:00:00Z"`                     // Last update timestamp
+	ID                  uuid.UUID       `json:"id" db:"id" example:"550e8400-e29b-41d4-a716-446655440000"`               // User unique identifier
+	Email               string          `json:"email" db:"email" example:"john.doe@example.com"`                         // User email address
+	Password            string          `json:"-" db:"password"`                                                         // User password (not exposed in JSON)
+	FirstName           string          `json:"first_name" db:"first_name" example:"John"`                               // User first name
+	LastName            string          `json:"last_name" db:"last_name" example:"Doe"`                                  // User last name
+	Phone               string          `json:"phone" db:"phone" example:"+1234567890"`                                  // User phone number
+	Balance             decimal.Decimal `json:"balance" db:"balance" example:"1000.50" swaggertype:"string"`             // User wallet balance
+	HeldBalance         decimal.Decimal `json:"held_balance" db:"held_balance" example:"250.00" swaggertype:"string"`    // Part of the balance reserved by authorized payments
+	Status              UserStatus      `json:"status" db:"status" example:"active"`                                     // User account status
+	Role                Role            `json:"role" db:"role" example:"user"`                                           // User role
+	KYCTier             KYCTier         `json:"kyc_tier" db:"kyc_tier" example:"basic"`                                  // How far the identity of the user has been verified
+	MFAEnabled          bool            `json:"mfa_enabled" db:"mfa_enabled" example:"false"`                            // Whether login requires a TOTP code
+	MFASecret           string          `json:"-" db:"mfa_secret"`                                                       // TOTP secret, pending until MFA is enabled
+	MFALastUsedStep     int64           `json:"-" db:"mfa_last_used_step"`                                               // Last accepted TOTP time step, refuses replays
+	PINHash             string          `json:"-" db:"pin_hash"`                                                         // Bcrypt hash of the transaction PIN, empty until set
+	PINFailedAttempts   int             `json:"-" db:"pin_failed_attempts"`                                              // Wrong PINs entered since the last correct one
+	PINLockedUntil      *time.Time      `json:"-" db:"pin_locked_until"`                                                 // Outgoing transactions are refused until then
+	EmailVerifiedAt     *time.Time      `json:"email_verified_at" db:"email_verified_at" example:"2024-01-01T00:10:00Z"` // When the current email address was confirmed
+	FailedLoginAttempts int             `json:"-" db:"failed_login_attempts"`                                            // Wrong passwords since the last successful login or lock
+	LastFailedLoginAt   *time.Time      `json:"-" db:"last_failed_login_at"`                                             // Time of the last wrong password, for progressive delays
+	LockedUntil         *time.Time      `json:"-" db:"locked_until"`                                                     // Logins are refused until then
+	CreatedAt           time.Time       `json:"created_at" db:"created_at" example:"2024-01-01T00:00:00Z"`               // Account creation timestamp
+	UpdatedAt           time.Time       `json:"updated_at" db:"updated_at" example:"2024-01-01T00:00:00Z"`               // Last update timestamp
 }
 
 // UserStatus represents possible user account statuses
@@ -31,6 +45,7 @@ const (
 	UserStatusActive   UserStatus = "active"   // User account is active
 	UserStatusInactive UserStatus = "inactive" // User account is inactive
 	UserStatusBlocked  UserStatus = "blocked"  // User account is blocked
+	UserStatusClosed   UserStatus = "closed"   // User closed the account
 )
 
 // RegisterRequest represents user registration request payload
@@ -53,32 +68,61 @@ type LoginRequest struct {
 // LoginResponse represents successful login response
 // @Description Successful login response with JWT token
 type LoginResponse struct {
-	Token     string    `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`         // JWT authentication token
-	User      User      `json:"user"`                                                          // User information
-	ExpiresAt time.Time `json:"expires_at" example:"2024-01-02T00:00:00Z"`                    // Token expiration time
+	Token                 string    `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."` // JWT authentication token
+	User                  User      `json:"user"`                                                    // User information
+	ExpiresAt             time.Time `json:"expires_at" example:"2024-01-02T00:00:00Z"`               // Token expiration time
+	RefreshToken          string    `json:"refresh_token" example:"m1J3c2VjcmV0LXJlZnJlc2gtdG9rZW4"` // Opaque single-use token to obtain a new access token
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at" example:"2024-01-31T00:00:00Z"` // Refresh token expiration time
 }
 
 // JWTClaims represents JWT token claims
 // @Description JWT token claims structure
 type JWTClaims struct {
-	UserID uuid.UUID `json:"user_id" example:"550e8400-e29b-41d4-a716-446655440000"` // User ID from token
-	Email  string    `json:"email" example:"john.doe@example.com"`                    // User email from token
-	Exp    int64     `json:"exp" example:"1640995200"`                                // Token expiration timestamp
-	Iat    int64     `json:"iat" example:"1640908800"`                                // Token issued at timestamp
+	UserID    uuid.UUID `json:"user_id" example:"550e8400-e29b-41d4-a716-446655440000"` // User ID from token
+	Email     string    `json:"email" example:"john.doe@example.com"`                   // User email from token
+	Exp       int64     `json:"exp" example:"1640995200"`                               // Token expiration timestamp
+	Iat       int64     `json:"iat" example:"1640908800"`                               // Token issued at timestamp
+	Role      Role      `json:"role" example:"user"`                                    // User role at the time the token was issued
+	ID        string    `json:"jti" example:"6f1c2d3e-4b5a-4c6d-8e7f-901234567890"`     // Unique token ID, used for revocation
+	SessionID uuid.UUID `json:"sid" example:"550e8400-e29b-41d4-a716-446655440000"`     // Refresh token family the token was issued for
+	APIKeyID  uuid.UUID `json:"-"`                                                      // API key the request was authenticated with, empty for access tokens
+}
+
+// IsAPIKey checks if the claims come from an API key rather than an access token
+func (c *JWTClaims) IsAPIKey() bool {
+	return c.APIKeyID != uuid.Nil
+}
+
+// HasPermission checks if the token's role grants the given permission
+func (c *JWTClaims) HasPermission(permission Permission) bool {
+	return c.Role.HasPermission(permission)
+}
+
+// ExpiresAt returns the token expiration time
+func (c *JWTClaims) ExpiresAt() time.Time {
+	return time.Unix(c.Exp, 0)
 }
 
 // UserProfile represents user profile response
 // @Description User profile information
 type UserProfile struct {
-	ID        uuid.UUID       `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`      // User unique identifier
-	Email     string          `json:"email" example:"john.doe@example.com"`                   // User email address
-	FirstName string          `json:"first_name" example:"John"`                              // User first name
-	LastName  string          `json:"last_name" example:"Doe"`                                // User last name
-	Phone     string          `json:"phone" example:"+1234567890"`                            // User phone number
-	Balance   decimal.Decimal `json:"balance" example:"1000.50" swaggertype:"string"`        // User wallet balance
-	Status    UserStatus      `json:"status" example:"active"`                                // User account status
-	CreatedAt time.Time       `json:"created_at" example:"2024-01-01T00:00:00Z"`             // Account creation timestamp
-	UpdatedAt time.Time       `json:"updated_at" example:"2024-01-01T00:00:00Z"`             // Last update timestamp
+	ID             uuid.UUID       `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`         // User unique identifier
+	Email          string          `json:"email" example:"john.doe@example.com"`                      // User email address
+	FirstName      string          `json:"first_name" example:"John"`                                 // User first name
+	LastName       string          `json:"last_name" example:"Doe"`                                   // User last name
+	Phone          string          `json:"phone" example:"+1234567890"`                               // User phone number
+	Balance        decimal.Decimal `json:"balance" example:"1000.50" swaggertype:"string"`            // User wallet balance
+	HeldBalance    decimal.Decimal `json:"held_balance" example:"250.00" swaggertype:"string"`        // Part of the balance reserved by authorized payments
+	Status         UserStatus      `json:"status" example:"active"`                                   // User account status
+	Role           Role            `json:"role" example:"user"`                                       // User role
+	KYCTier        KYCTier         `json:"kyc_tier" example:"basic"`                                  // How far the identity of the user has been verified
+	MFAEnabled     bool            `json:"mfa_enabled" example:"false"`                               // Whether login requires a TOTP code
+	PINSet         bool            `json:"pin_set" example:"true"`                                    // Whether a transaction PIN has been set
+	PINLockedUntil *time.Time      `json:"pin_locked_until,omitempty" example:"2024-01-01T00:30:00Z"` // Outgoing transactions are refused until then
+	EmailVerified  bool            `json:"email_verified" example:"true"`                             // Whether the email address has been confirmed
+	LockedUntil    *time.Time      `json:"locked_until,omitempty" example:"2024-01-01T00:15:00Z"`     // Logins are refused until then after too many failed attempts
+	CreatedAt      time.Time       `json:"created_at" example:"2024-01-01T00:00:00Z"`                 // Account creation timestamp
+	UpdatedAt      time.Time       `json:"updated_at" example:"2024-01-01T00:00:00Z"`                 // Last update timestamp
 }
 
 // HashPassword hashes the user password using bcrypt
@@ -102,18 +146,36 @@ func (u *User) IsActive() bool {
 	return u.Status == UserStatusActive
 }
 
+// AvailableBalance returns the part of the balance that is not held for authorized payments
+func (u *User) AvailableBalance() decimal.Decimal {
+	return u.Balance.Sub(u.HeldBalance)
+}
+
+// IsEmailVerified checks if the user confirmed the current email address
+func (u *User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
 // ToProfile converts User to UserProfile for safe exposure
 func (u *User) ToProfile() UserProfile {
 	return UserProfile{
-		ID:        u.ID,
-		Email:     u.Email,
-		FirstName: u.FirstName,
-		LastName:  u.LastName,
-		Phone:     u.Phone,
-		Balance:   u.Balance,
-		Status:    u.Status,
-		CreatedAt: u.CreatedAt,
-		UpdatedAt: u.UpdatedAt,
+		ID:             u.ID,
+		Email:          u.Email,
+		FirstName:      u.FirstName,
+		LastName:       u.LastName,
+		Phone:          u.Phone,
+		Balance:        u.Balance,
+		HeldBalance:    u.HeldBalance,
+		Status:         u.Status,
+		Role:           u.Role,
+		KYCTier:        u.KYCTier,
+		MFAEnabled:     u.MFAEnabled,
+		PINSet:         u.HasPIN(),
+		PINLockedUntil: u.PINLockedUntil,
+		EmailVerified:  u.IsEmailVerified(),
+		LockedUntil:    u.LockedUntil,
+		CreatedAt:      u.CreatedAt,
+		UpdatedAt:      u.UpdatedAt,
 	}
 }
 
@@ -128,6 +190,8 @@ func NewUser(req RegisterRequest) (*User, error) {
 		Phone:     req.Phone,
 		Balance:   decimal.Zero,
 		Status:    UserStatusActive,
+		Role:      RoleUser,
+		KYCTier:   KYCTierUnverified,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
@@ -138,3 +202,55 @@ func NewUser(req RegisterRequest) (*User, error) {
 
 	return user, nil
 }
+
+// IsValid checks if the status is known
+func (s UserStatus) IsValid() bool {
+	switch s {
+	case UserStatusActive, UserStatusInactive, UserStatusBlocked, UserStatusClosed:
+		return true
+	default:
+		return false
+	}
+}
+
+// UserFilter narrows down an admin user search. Zero values are ignored.
+type UserFilter struct {
+	Search string // Case-insensitive match on email, name or phone
+	Status UserStatus
+	Role   Role
+}
+
+// ErrInvalidUserFilter is returned for an unknown status or role in a UserFilter
+var ErrInvalidUserFilter = errors.New("invalid user filter")
+
+// Validate checks the status and role of the filter
+func (f UserFilter) Validate() error {
+	if f.Status != "" && !f.Status.IsValid() {
+		return ErrInvalidUserFilter
+	}
+	if f.Role != "" && !f.Role.IsValid() {
+		return ErrInvalidUserFilter
+	}
+	return nil
+}
+
+// UpdateUserStatusRequest represents admin user status change payload
+// @Description User status change request
+type UpdateUserStatusRequest struct {
+	Status UserStatus `json:"status" validate:"required,oneof=active inactive blocked" example:"blocked"` // New account status
+	Reason string     `json:"reason" example:"Suspicious activity"`                                       // Why the status was changed
+}
+
+// UpdateUserRoleRequest represents admin role assignment payload
+// @Description User role change request
+type UpdateUserRoleRequest struct {
+	Role   Role   `json:"role" validate:"required,oneof=user support admin" example:"support"` // New role
+	Reason string `json:"reason" validate:"max=500" example:"Joined the support team"`         // Why the role was changed
+}
+
+// UserListResponse represents a page of users
+// @Description Paginated user list response
+type UserListResponse struct {
+	Users      []UserProfile      `json:"users"`      // List of users
+	Pagination PaginationResponse `json:"pagination"` // Pagination metadata
+}
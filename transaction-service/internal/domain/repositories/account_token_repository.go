@@ -0,0 +1,16 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"go-transaction-service/internal/domain/entities"
+)
+
+type AccountTokenRepository interface {
+	Create(ctx context.Context, token *entities.AccountToken) error
+	GetByHashForUpdate(ctx context.Context, tokenHash string) (*entities.AccountToken, error)
+	MarkUsed(ctx context.Context, id uuid.UUID) error
+	// InvalidateForUser marks every unused token of the user with the given purpose as used
+	InvalidateForUser(ctx context.Context, userID uuid.UUID, purpose entities.AccountTokenPurpose) error
+}
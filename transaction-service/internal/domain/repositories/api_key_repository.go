@@ -0,0 +1,20 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"go-transaction-service/internal/domain/entities"
+)
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *entities.APIKey) error
+	GetByID(ctx context.Context, id uuid.UUID) (*entities.APIKey, error)
+	GetByHash(ctx context.Context, keyHash string) (*entities.APIKey, error)
+	List(ctx context.Context, limit, offset int) ([]*entities.APIKey, error)
+	Count(ctx context.Context) (int, error)
+	Revoke(ctx context.Context, id uuid.UUID, revokedAt time.Time) error
+	// Touch records that the key authenticated a request
+	Touch(ctx context.Context, id uuid.UUID, usedAt time.Time) error
+}
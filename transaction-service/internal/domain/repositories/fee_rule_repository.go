@@ -0,0 +1,19 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"go-transaction-service/internal/domain/entities"
+)
+
+type FeeRuleRepository interface {
+	Create(ctx context.Context, rule *entities.FeeRule) error
+	GetByID(ctx context.Context, id uuid.UUID) (*entities.FeeRule, error)
+	List(ctx context.Context) ([]*entities.FeeRule, error)
+	Update(ctx context.Context, rule *entities.FeeRule) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	// FindApplicable returns the rule of the payment method, or else the one for any method of the
+	// transaction type. A not found error means no rule applies.
+	FindApplicable(ctx context.Context, transactionType entities.TransactionType, paymentMethod string) (*entities.FeeRule, error)
+}
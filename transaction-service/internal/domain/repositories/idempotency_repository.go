@@ -0,0 +1,18 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"go-transaction-service/internal/domain/entities"
+)
+
+type IdempotencyRepository interface {
+	// Reserve stores a new in-flight record and reports whether it was inserted. Only expired
+	// records for the same key are replaced; an unfinished request keeps its key until it expires,
+	// since its money may already have moved.
+	Reserve(ctx context.Context, record *entities.IdempotencyRecord) (bool, error)
+	Get(ctx context.Context, userID uuid.UUID, key string) (*entities.IdempotencyRecord, error)
+	SaveResponse(ctx context.Context, record *entities.IdempotencyRecord) error
+	Delete(ctx context.Context, userID uuid.UUID, key string) error
+}
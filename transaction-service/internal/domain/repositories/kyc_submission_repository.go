@@ -0,0 +1,22 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"go-transaction-service/internal/domain/entities"
+)
+
+type KYCSubmissionRepository interface {
+	// Create stores a submission; a conflict error when the user already has one waiting for review
+	Create(ctx context.Context, submission *entities.KYCSubmission) error
+	GetByID(ctx context.Context, id uuid.UUID) (*entities.KYCSubmission, error)
+	GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*entities.KYCSubmission, error)
+	// GetLatestByUserID returns the newest submission of a user, a not found error when there is none
+	GetLatestByUserID(ctx context.Context, userID uuid.UUID) (*entities.KYCSubmission, error)
+	// List returns submissions oldest first, only those of the given status unless it is empty
+	List(ctx context.Context, status entities.KYCSubmissionStatus, limit, offset int) ([]*entities.KYCSubmission, error)
+	Count(ctx context.Context, status entities.KYCSubmissionStatus) (int, error)
+	// UpdateReview stores the status and review of a submission
+	UpdateReview(ctx context.Context, submission *entities.KYCSubmission) error
+}
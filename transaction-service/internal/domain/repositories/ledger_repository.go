@@ -0,0 +1,18 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"go-transaction-service/internal/domain/entities"
+)
+
+type LedgerRepository interface {
+	CreateEntry(ctx context.Context, entry *entities.JournalEntry) error
+	GetEntriesByTransactionID(ctx context.Context, transactionID uuid.UUID) ([]*entities.JournalEntry, error)
+	GetWalletBalance(ctx context.Context, userID uuid.UUID) (decimal.Decimal, error)
+	// RebuildWalletBalance resets the balance of a user to the sum of their wallet postings. The
+	// caller must hold the lock of the user row so that no concurrent balance change is overwritten.
+	RebuildWalletBalance(ctx context.Context, userID uuid.UUID) (decimal.Decimal, error)
+}
@@ -0,0 +1,20 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"go-transaction-service/internal/domain/entities"
+)
+
+type MFARepository interface {
+	CreateChallenge(ctx context.Context, challenge *entities.MFAChallenge) error
+	GetChallengeByHashForUpdate(ctx context.Context, tokenHash string) (*entities.MFAChallenge, error)
+	// UpdateChallenge stores the attempt counter and completion time of a challenge
+	UpdateChallenge(ctx context.Context, challenge *entities.MFAChallenge) error
+	// ReplaceRecoveryCodes deletes the existing recovery codes of the user and stores the new ones
+	ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, codes []*entities.RecoveryCode) error
+	GetUnusedRecoveryCodes(ctx context.Context, userID uuid.UUID) ([]*entities.RecoveryCode, error)
+	MarkRecoveryCodeUsed(ctx context.Context, id uuid.UUID) error
+	DeleteRecoveryCodes(ctx context.Context, userID uuid.UUID) error
+}
@@ -0,0 +1,11 @@
+package repositories
+
+import (
+	"context"
+
+	"go-transaction-service/internal/domain/entities"
+)
+
+type PaymentCallbackRepository interface {
+	Create(ctx context.Context, callback *entities.PaymentCallback) error
+}
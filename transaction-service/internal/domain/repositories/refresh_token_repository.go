@@ -0,0 +1,19 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"go-transaction-service/internal/domain/entities"
+)
+
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, token *entities.RefreshToken) error
+	GetByHashForUpdate(ctx context.Context, tokenHash string) (*entities.RefreshToken, error)
+	// MarkReplaced revokes a rotated token and links it to the token that replaced it
+	MarkReplaced(ctx context.Context, id, replacedByID uuid.UUID) error
+	RevokeFamily(ctx context.Context, familyID uuid.UUID) error
+	RevokeAllForUser(ctx context.Context, userID uuid.UUID) error
+	// RevokeAllForUserExcept revokes every session of the user but the given one
+	RevokeAllForUserExcept(ctx context.Context, userID, familyID uuid.UUID) error
+}
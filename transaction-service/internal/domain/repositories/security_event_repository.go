@@ -0,0 +1,18 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"go-transaction-service/internal/domain/entities"
+)
+
+type SecurityEventRepository interface {
+	Create(ctx context.Context, event *entities.SecurityEvent) error
+	// CountByIPSince counts events of the given type from an IP address since the given time
+	CountByIPSince(ctx context.Context, eventType entities.SecurityEventType, ipAddress string, since time.Time) (int, error)
+	// ListByUser returns the events of a user, newest first
+	ListByUser(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*entities.SecurityEvent, error)
+	CountByUser(ctx context.Context, userID uuid.UUID) (int, error)
+}
@@ -0,0 +1,21 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"go-transaction-service/internal/domain/entities"
+)
+
+type SessionRepository interface {
+	Create(ctx context.Context, session *entities.Session) error
+	// GetActive returns a session while it has a usable refresh token, or a not found error
+	GetActive(ctx context.Context, id uuid.UUID) (*entities.Session, error)
+	// ListActiveByUser returns the active sessions of a user, most recently used first
+	ListActiveByUser(ctx context.Context, userID uuid.UUID) ([]*entities.Session, error)
+	// Touch records that the session was used at seenAt
+	Touch(ctx context.Context, id uuid.UUID, seenAt time.Time) error
+	// UpdateClient records the client details and use of a session at its refresh
+	UpdateClient(ctx context.Context, session *entities.Session) error
+}
@@ -2,18 +2,34 @@ package repositories
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 	"go-transaction-service/internal/domain/entities"
 )
 
 type TransactionRepository interface {
 	Create(ctx context.Context, transaction *entities.Transaction) error
 	GetByID(ctx context.Context, id uuid.UUID) (*entities.Transaction, error)
+	GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*entities.Transaction, error)
 	GetByReference(ctx context.Context, reference string) (*entities.Transaction, error)
-	GetByUserID(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*entities.Transaction, error)
+	GetByReferenceForUpdate(ctx context.Context, reference string) (*entities.Transaction, error)
+	GetByUserID(ctx context.Context, userID uuid.UUID, filter entities.TransactionFilter, page entities.TransactionPageRequest) ([]*entities.Transaction, error)
 	UpdateStatus(ctx context.Context, id uuid.UUID, status entities.TransactionStatus) error
 	Update(ctx context.Context, transaction *entities.Transaction) error
-	GetPendingTransactions(ctx context.Context) ([]*entities.Transaction, error)
-	CountByUserID(ctx context.Context, userID uuid.UUID) (int, error)
+	GetPendingTransactions(ctx context.Context, transactionType entities.TransactionType, olderThan time.Time, limit int) ([]*entities.Transaction, error)
+	GetExpiredTransactions(ctx context.Context, now time.Time, limit int) ([]*entities.Transaction, error)
+	GetByStatus(ctx context.Context, status entities.TransactionStatus, limit, offset int) ([]*entities.Transaction, error)
+	CountByStatus(ctx context.Context, status entities.TransactionStatus) (int, error)
+	GetStatusHistory(ctx context.Context, transactionID uuid.UUID) ([]entities.TransactionStatusChange, error)
+	CountByUserID(ctx context.Context, userID uuid.UUID, filter entities.TransactionFilter) (int, error)
+	// GetUsage counts and adds up the transactions of a type the user started since the start of
+	// the day and of the month that are not failed or cancelled
+	GetUsage(ctx context.Context, userID uuid.UUID, transactionType entities.TransactionType, dayStart, monthStart time.Time) (*entities.LimitUsage, error)
+	SumRefundedAmount(ctx context.Context, parentID uuid.UUID) (decimal.Decimal, error)
+	// SumPendingIncoming adds up the funds still on their way into the wallet of the user: its
+	// top-ups waiting for the payment gateway and payments and transfers to it that are held for
+	// review or authorized but not captured yet
+	SumPendingIncoming(ctx context.Context, userID uuid.UUID) (decimal.Decimal, error)
 }
@@ -0,0 +1,12 @@
+package repositories
+
+import (
+	"context"
+)
+
+// TxManager runs a unit of work inside a single database transaction.
+// Repositories called with the context passed to fn take part in that transaction;
+// nested calls join the outer transaction instead of opening a new one.
+type TxManager interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
@@ -0,0 +1,16 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"go-transaction-service/internal/domain/entities"
+)
+
+type UserLimitRepository interface {
+	// GetByUserID returns the limit overrides of a user, a not found error when the global limits apply
+	GetByUserID(ctx context.Context, userID uuid.UUID) (*entities.UserLimits, error)
+	// Save creates or replaces the limit overrides of a user
+	Save(ctx context.Context, limits *entities.UserLimits) error
+	Delete(ctx context.Context, userID uuid.UUID) error
+}
@@ -11,11 +11,29 @@ import (
 type UserRepository interface {
 	Create(ctx context.Context, user *entities.User) error
 	GetByID(ctx context.Context, id uuid.UUID) (*entities.User, error)
+	GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*entities.User, error)
 	GetByEmail(ctx context.Context, email string) (*entities.User, error)
+	GetByPhone(ctx context.Context, phone string) (*entities.User, error)
 	Update(ctx context.Context, user *entities.User) error
+	UpdateStatus(ctx context.Context, userID uuid.UUID, status entities.UserStatus) error
+	UpdateRole(ctx context.Context, userID uuid.UUID, role entities.Role) error
+	UpdateKYCTier(ctx context.Context, userID uuid.UUID, tier entities.KYCTier) error
+	// UpdateMFA stores the MFA settings of the user (enabled flag, secret and last used step)
+	UpdateMFA(ctx context.Context, user *entities.User) error
+	// UpdatePIN stores the transaction PIN hash, failed attempt counter and lockout of the user
+	UpdatePIN(ctx context.Context, user *entities.User) error
+	// UpdateLoginFailures stores the failed login counter, last failure time and lockout of the user
+	UpdateLoginFailures(ctx context.Context, user *entities.User) error
+	Search(ctx context.Context, filter entities.UserFilter, limit, offset int) ([]*entities.User, error)
+	Count(ctx context.Context, filter entities.UserFilter) (int, error)
 	UpdateBalance(ctx context.Context, userID uuid.UUID, balance decimal.Decimal) error
 	AddBalance(ctx context.Context, userID uuid.UUID, amount decimal.Decimal) error
+	// SubtractBalance takes the amount out of the balance; held funds are never touched
 	SubtractBalance(ctx context.Context, userID uuid.UUID, amount decimal.Decimal) error
+	// HoldBalance reserves the amount out of the available balance
+	HoldBalance(ctx context.Context, userID uuid.UUID, amount decimal.Decimal) error
+	// ReleaseHold makes a held amount available again
+	ReleaseHold(ctx context.Context, userID uuid.UUID, amount decimal.Decimal) error
 	GetBalance(ctx context.Context, userID uuid.UUID) (decimal.Decimal, error)
 	CheckEmailExists(ctx context.Context, email string) (bool, error)
 }
@@ -0,0 +1,99 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"go-transaction-service/internal/domain/entities"
+	"go-transaction-service/internal/domain/repositories"
+	"go-transaction-service/pkg/errors"
+)
+
+type postgresAccountTokenRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresAccountTokenRepository(db *sql.DB) repositories.AccountTokenRepository {
+	return &postgresAccountTokenRepository{db: db}
+}
+
+func (r *postgresAccountTokenRepository) Create(ctx context.Context, token *entities.AccountToken) error {
+	query := `
+		INSERT INTO account_tokens (id, user_id, purpose, email, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query,
+		token.ID,
+		token.UserID,
+		token.Purpose,
+		token.Email,
+		token.TokenHash,
+		token.ExpiresAt,
+		token.CreatedAt,
+	)
+	if err != nil {
+		return customerrors.NewInternalError("Failed to create account token", err)
+	}
+
+	return nil
+}
+
+func (r *postgresAccountTokenRepository) GetByHashForUpdate(ctx context.Context, tokenHash string) (*entities.AccountToken, error) {
+	token := &entities.AccountToken{}
+
+	query := `
+		SELECT id, user_id, purpose, email, token_hash, expires_at, used_at, created_at
+		FROM account_tokens
+		WHERE token_hash = $1
+		FOR UPDATE
+	`
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, tokenHash).Scan(
+		&token.ID,
+		&token.UserID,
+		&token.Purpose,
+		&token.Email,
+		&token.TokenHash,
+		&token.ExpiresAt,
+		&token.UsedAt,
+		&token.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, customerrors.NewNotFoundError("Account token not found")
+		}
+		return nil, customerrors.NewInternalError("Failed to get account token", err)
+	}
+
+	return token, nil
+}
+
+func (r *postgresAccountTokenRepository) MarkUsed(ctx context.Context, id uuid.UUID) error {
+	query := `
+		UPDATE account_tokens
+		SET used_at = NOW()
+		WHERE id = $1 AND used_at IS NULL
+	`
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, id); err != nil {
+		return customerrors.NewInternalError("Failed to use account token", err)
+	}
+
+	return nil
+}
+
+func (r *postgresAccountTokenRepository) InvalidateForUser(ctx context.Context, userID uuid.UUID, purpose entities.AccountTokenPurpose) error {
+	query := `
+		UPDATE account_tokens
+		SET used_at = NOW()
+		WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL
+	`
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, userID, purpose); err != nil {
+		return customerrors.NewInternalError("Failed to invalidate account tokens", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,173 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"go-transaction-service/internal/domain/entities"
+	"go-transaction-service/internal/domain/repositories"
+	"go-transaction-service/pkg/errors"
+)
+
+type postgresAPIKeyRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresAPIKeyRepository(db *sql.DB) repositories.APIKeyRepository {
+	return &postgresAPIKeyRepository{db: db}
+}
+
+const apiKeyColumns = `id, user_id, name, prefix, key_hash, scopes, rate_limit, expires_at, last_used_at, revoked_at, created_by, created_at`
+
+func (r *postgresAPIKeyRepository) Create(ctx context.Context, key *entities.APIKey) error {
+	query := `
+		INSERT INTO api_keys (` + apiKeyColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`
+
+	scopes := make([]string, 0, len(key.Scopes))
+	for _, scope := range key.Scopes {
+		scopes = append(scopes, string(scope))
+	}
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query,
+		key.ID,
+		key.UserID,
+		key.Name,
+		key.Prefix,
+		key.KeyHash,
+		pq.Array(scopes),
+		key.RateLimit,
+		key.ExpiresAt,
+		key.LastUsedAt,
+		key.RevokedAt,
+		key.CreatedBy,
+		key.CreatedAt,
+	)
+	if err != nil {
+		return customerrors.NewInternalError("Failed to create API key", err)
+	}
+
+	return nil
+}
+
+func (r *postgresAPIKeyRepository) GetByID(ctx context.Context, id uuid.UUID) (*entities.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE id = $1`
+
+	key, err := scanAPIKey(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, customerrors.NewNotFoundError("API key not found")
+		}
+		return nil, customerrors.NewInternalError("Failed to get API key", err)
+	}
+
+	return key, nil
+}
+
+func (r *postgresAPIKeyRepository) GetByHash(ctx context.Context, keyHash string) (*entities.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE key_hash = $1`
+
+	key, err := scanAPIKey(conn(ctx, r.db).QueryRowContext(ctx, query, keyHash))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, customerrors.NewNotFoundError("API key not found")
+		}
+		return nil, customerrors.NewInternalError("Failed to get API key", err)
+	}
+
+	return key, nil
+}
+
+func (r *postgresAPIKeyRepository) List(ctx context.Context, limit, offset int) ([]*entities.APIKey, error) {
+	query := `
+		SELECT ` + apiKeyColumns + `
+		FROM api_keys
+		ORDER BY created_at DESC, id
+		LIMIT $1 OFFSET $2
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, limit, offset)
+	if err != nil {
+		return nil, customerrors.NewInternalError("Failed to list API keys", err)
+	}
+	defer rows.Close()
+
+	keys := []*entities.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, customerrors.NewInternalError("Failed to scan API key", err)
+		}
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, customerrors.NewInternalError("Failed to iterate API keys", err)
+	}
+
+	return keys, nil
+}
+
+func (r *postgresAPIKeyRepository) Count(ctx context.Context) (int, error) {
+	query := `SELECT COUNT(*) FROM api_keys`
+
+	var count int
+	if err := conn(ctx, r.db).QueryRowContext(ctx, query).Scan(&count); err != nil {
+		return 0, customerrors.NewInternalError("Failed to count API keys", err)
+	}
+
+	return count, nil
+}
+
+func (r *postgresAPIKeyRepository) Revoke(ctx context.Context, id uuid.UUID, revokedAt time.Time) error {
+	query := `UPDATE api_keys SET revoked_at = $2 WHERE id = $1 AND revoked_at IS NULL`
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, id, revokedAt); err != nil {
+		return customerrors.NewInternalError("Failed to revoke API key", err)
+	}
+
+	return nil
+}
+
+func (r *postgresAPIKeyRepository) Touch(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	query := `UPDATE api_keys SET last_used_at = $2 WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < $2)`
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, id, usedAt); err != nil {
+		return customerrors.NewInternalError("Failed to update API key", err)
+	}
+
+	return nil
+}
+
+func scanAPIKey(row rowScanner) (*entities.APIKey, error) {
+	key := &entities.APIKey{}
+	var scopes pq.StringArray
+	err := row.Scan(
+		&key.ID,
+		&key.UserID,
+		&key.Name,
+		&key.Prefix,
+		&key.KeyHash,
+		&scopes,
+		&key.RateLimit,
+		&key.ExpiresAt,
+		&key.LastUsedAt,
+		&key.RevokedAt,
+		&key.CreatedBy,
+		&key.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	key.Scopes = make([]entities.APIKeyScope, 0, len(scopes))
+	for _, scope := range scopes {
+		key.Scopes = append(key.Scopes, entities.APIKeyScope(scope))
+	}
+
+	return key, nil
+}
@@ -0,0 +1,207 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"go-transaction-service/internal/domain/entities"
+	"go-transaction-service/internal/domain/repositories"
+	"go-transaction-service/pkg/errors"
+)
+
+type postgresFeeRuleRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresFeeRuleRepository(db *sql.DB) repositories.FeeRuleRepository {
+	return &postgresFeeRuleRepository{db: db}
+}
+
+const feeRuleColumns = `id, transaction_type, payment_method, flat_fee, percent, tiers, min_fee, max_fee, created_by, created_at, updated_at`
+
+func (r *postgresFeeRuleRepository) Create(ctx context.Context, rule *entities.FeeRule) error {
+	tiersJSON, err := json.Marshal(rule.Tiers)
+	if err != nil {
+		return customerrors.NewInternalError("Failed to marshal fee tiers", err)
+	}
+
+	query := `
+		INSERT INTO fee_rules (` + feeRuleColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`
+
+	_, err = conn(ctx, r.db).ExecContext(ctx, query,
+		rule.ID,
+		rule.TransactionType,
+		rule.PaymentMethod,
+		rule.FlatFee,
+		rule.Percent,
+		tiersJSON,
+		rule.MinFee,
+		rule.MaxFee,
+		rule.CreatedBy,
+		rule.CreatedAt,
+		rule.UpdatedAt,
+	)
+	if err != nil {
+		return feeRuleWriteError("Failed to create fee rule", err)
+	}
+
+	return nil
+}
+
+func (r *postgresFeeRuleRepository) GetByID(ctx context.Context, id uuid.UUID) (*entities.FeeRule, error) {
+	query := `SELECT ` + feeRuleColumns + ` FROM fee_rules WHERE id = $1`
+
+	rule, err := scanFeeRule(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, customerrors.NewNotFoundError("Fee rule not found")
+		}
+		return nil, customerrors.NewInternalError("Failed to get fee rule", err)
+	}
+
+	return rule, nil
+}
+
+func (r *postgresFeeRuleRepository) List(ctx context.Context) ([]*entities.FeeRule, error) {
+	query := `SELECT ` + feeRuleColumns + ` FROM fee_rules ORDER BY transaction_type, payment_method`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query)
+	if err != nil {
+		return nil, customerrors.NewInternalError("Failed to list fee rules", err)
+	}
+	defer rows.Close()
+
+	rules := []*entities.FeeRule{}
+	for rows.Next() {
+		rule, err := scanFeeRule(rows)
+		if err != nil {
+			return nil, customerrors.NewInternalError("Failed to scan fee rule", err)
+		}
+		rules = append(rules, rule)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, customerrors.NewInternalError("Failed to iterate fee rules", err)
+	}
+
+	return rules, nil
+}
+
+func (r *postgresFeeRuleRepository) Update(ctx context.Context, rule *entities.FeeRule) error {
+	tiersJSON, err := json.Marshal(rule.Tiers)
+	if err != nil {
+		return customerrors.NewInternalError("Failed to marshal fee tiers", err)
+	}
+
+	query := `
+		UPDATE fee_rules
+		SET transaction_type = $2, payment_method = $3, flat_fee = $4, percent = $5, tiers = $6,
+			min_fee = $7, max_fee = $8, updated_at = $9
+		WHERE id = $1
+	`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query,
+		rule.ID,
+		rule.TransactionType,
+		rule.PaymentMethod,
+		rule.FlatFee,
+		rule.Percent,
+		tiersJSON,
+		rule.MinFee,
+		rule.MaxFee,
+		rule.UpdatedAt,
+	)
+	if err != nil {
+		return feeRuleWriteError("Failed to update fee rule", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return customerrors.NewInternalError("Failed to update fee rule", err)
+	}
+	if rows == 0 {
+		return customerrors.NewNotFoundError("Fee rule not found")
+	}
+
+	return nil
+}
+
+func (r *postgresFeeRuleRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	query := `DELETE FROM fee_rules WHERE id = $1`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, id)
+	if err != nil {
+		return customerrors.NewInternalError("Failed to delete fee rule", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return customerrors.NewInternalError("Failed to delete fee rule", err)
+	}
+	if rows == 0 {
+		return customerrors.NewNotFoundError("Fee rule not found")
+	}
+
+	return nil
+}
+
+func (r *postgresFeeRuleRepository) FindApplicable(ctx context.Context, transactionType entities.TransactionType, paymentMethod string) (*entities.FeeRule, error) {
+	// The rule of the payment method sorts before the one for any method
+	query := `
+		SELECT ` + feeRuleColumns + `
+		FROM fee_rules
+		WHERE transaction_type = $1 AND payment_method IN ($2, '')
+		ORDER BY payment_method = '' ASC
+		LIMIT 1
+	`
+
+	rule, err := scanFeeRule(conn(ctx, r.db).QueryRowContext(ctx, query, transactionType, paymentMethod))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, customerrors.NewNotFoundError("No fee rule applies")
+		}
+		return nil, customerrors.NewInternalError("Failed to find fee rule", err)
+	}
+
+	return rule, nil
+}
+
+// feeRuleWriteError reports a second rule for the same transaction type and payment method as a conflict
+func feeRuleWriteError(message string, err error) error {
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" { // unique_violation
+		return customerrors.NewConflictError("A fee rule for this transaction type and payment method already exists")
+	}
+	return customerrors.NewInternalError(message, err)
+}
+
+func scanFeeRule(row rowScanner) (*entities.FeeRule, error) {
+	rule := &entities.FeeRule{}
+	var tiersJSON []byte
+	err := row.Scan(
+		&rule.ID,
+		&rule.TransactionType,
+		&rule.PaymentMethod,
+		&rule.FlatFee,
+		&rule.Percent,
+		&tiersJSON,
+		&rule.MinFee,
+		&rule.MaxFee,
+		&rule.CreatedBy,
+		&rule.CreatedAt,
+		&rule.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(tiersJSON, &rule.Tiers); err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
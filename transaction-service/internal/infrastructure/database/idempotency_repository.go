@@ -0,0 +1,124 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"go-transaction-service/internal/domain/entities"
+	"go-transaction-service/internal/domain/repositories"
+	"go-transaction-service/pkg/errors"
+)
+
+type postgresIdempotencyRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresIdempotencyRepository(db *sql.DB) repositories.IdempotencyRepository {
+	return &postgresIdempotencyRepository{db: db}
+}
+
+func (r *postgresIdempotencyRepository) Reserve(ctx context.Context, record *entities.IdempotencyRecord) (bool, error) {
+	query := `
+		INSERT INTO idempotency_keys (key, user_id, request_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, key) DO UPDATE
+		SET request_hash = EXCLUDED.request_hash, response_code = NULL, response_body = NULL, completed_at = NULL,
+		    expires_at = EXCLUDED.expires_at, created_at = EXCLUDED.created_at
+		WHERE idempotency_keys.expires_at < NOW()
+	`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query,
+		record.Key,
+		record.UserID,
+		record.RequestHash,
+		record.ExpiresAt,
+		record.CreatedAt,
+	)
+
+	if err != nil {
+		return false, customerrors.NewInternalError("Failed to reserve idempotency key", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, customerrors.NewInternalError("Failed to get rows affected", err)
+	}
+
+	return rowsAffected == 1, nil
+}
+
+func (r *postgresIdempotencyRepository) Get(ctx context.Context, userID uuid.UUID, key string) (*entities.IdempotencyRecord, error) {
+	record := &entities.IdempotencyRecord{}
+	var responseCode sql.NullInt64
+
+	query := `
+		SELECT key, user_id, request_hash, response_code, response_body, completed_at, expires_at, created_at
+		FROM idempotency_keys
+		WHERE user_id = $1 AND key = $2
+	`
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, userID, key).Scan(
+		&record.Key,
+		&record.UserID,
+		&record.RequestHash,
+		&responseCode,
+		&record.ResponseBody,
+		&record.CompletedAt,
+		&record.ExpiresAt,
+		&record.CreatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, customerrors.NewNotFoundError("Idempotency key not found")
+		}
+		return nil, customerrors.NewInternalError("Failed to get idempotency key", err)
+	}
+
+	record.ResponseCode = int(responseCode.Int64)
+
+	return record, nil
+}
+
+func (r *postgresIdempotencyRepository) SaveResponse(ctx context.Context, record *entities.IdempotencyRecord) error {
+	query := `
+		UPDATE idempotency_keys
+		SET response_code = $3, response_body = $4, completed_at = $5
+		WHERE user_id = $1 AND key = $2
+	`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query,
+		record.UserID,
+		record.Key,
+		record.ResponseCode,
+		record.ResponseBody,
+		record.CompletedAt,
+	)
+
+	if err != nil {
+		return customerrors.NewInternalError("Failed to save idempotent response", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return customerrors.NewInternalError("Failed to get rows affected", err)
+	}
+
+	if rowsAffected == 0 {
+		return customerrors.NewNotFoundError("Idempotency key not found")
+	}
+
+	return nil
+}
+
+func (r *postgresIdempotencyRepository) Delete(ctx context.Context, userID uuid.UUID, key string) error {
+	query := `DELETE FROM idempotency_keys WHERE user_id = $1 AND key = $2 AND completed_at IS NULL`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, userID, key)
+	if err != nil {
+		return customerrors.NewInternalError("Failed to release idempotency key", err)
+	}
+
+	return nil
+}
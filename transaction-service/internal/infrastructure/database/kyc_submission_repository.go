@@ -0,0 +1,216 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"go-transaction-service/internal/domain/entities"
+	"go-transaction-service/internal/domain/repositories"
+	"go-transaction-service/pkg/errors"
+)
+
+type postgresKYCSubmissionRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresKYCSubmissionRepository(db *sql.DB) repositories.KYCSubmissionRepository {
+	return &postgresKYCSubmissionRepository{db: db}
+}
+
+const kycSubmissionColumns = `id, user_id, requested_tier, status, full_name, date_of_birth, nationality, id_type, id_number, address, documents, reviewed_by, review_reason, reviewed_at, created_at, updated_at`
+
+// kycDocumentRecord is how a document is kept in the documents column; unlike the API it includes the storage key
+type kycDocumentRecord struct {
+	Kind        entities.KYCDocumentKind `json:"kind"`
+	FileName    string                   `json:"file_name"`
+	ContentType string                   `json:"content_type"`
+	Size        int64                    `json:"size"`
+	StorageKey  string                   `json:"storage_key"`
+	UploadedAt  time.Time                `json:"uploaded_at"`
+}
+
+func (r *postgresKYCSubmissionRepository) Create(ctx context.Context, submission *entities.KYCSubmission) error {
+	records := make([]kycDocumentRecord, len(submission.Documents))
+	for i, document := range submission.Documents {
+		records[i] = kycDocumentRecord(document)
+	}
+	documentsJSON, err := json.Marshal(records)
+	if err != nil {
+		return customerrors.NewInternalError("Failed to marshal KYC documents", err)
+	}
+
+	query := `
+		INSERT INTO kyc_submissions (` + kycSubmissionColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+	`
+
+	_, err = conn(ctx, r.db).ExecContext(ctx, query,
+		submission.ID,
+		submission.UserID,
+		submission.RequestedTier,
+		submission.Status,
+		submission.FullName,
+		submission.DateOfBirth,
+		submission.Nationality,
+		submission.IDType,
+		submission.IDNumber,
+		submission.Address,
+		documentsJSON,
+		submission.ReviewedBy,
+		submission.ReviewReason,
+		submission.ReviewedAt,
+		submission.CreatedAt,
+		submission.UpdatedAt,
+	)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" { // unique_violation
+			return customerrors.NewConflictError("A KYC submission is already waiting for review")
+		}
+		return customerrors.NewInternalError("Failed to create KYC submission", err)
+	}
+
+	return nil
+}
+
+func (r *postgresKYCSubmissionRepository) GetByID(ctx context.Context, id uuid.UUID) (*entities.KYCSubmission, error) {
+	query := `SELECT ` + kycSubmissionColumns + ` FROM kyc_submissions WHERE id = $1`
+
+	return r.getOne(ctx, query, id)
+}
+
+func (r *postgresKYCSubmissionRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*entities.KYCSubmission, error) {
+	query := `SELECT ` + kycSubmissionColumns + ` FROM kyc_submissions WHERE id = $1 FOR UPDATE`
+
+	return r.getOne(ctx, query, id)
+}
+
+func (r *postgresKYCSubmissionRepository) GetLatestByUserID(ctx context.Context, userID uuid.UUID) (*entities.KYCSubmission, error) {
+	query := `SELECT ` + kycSubmissionColumns + ` FROM kyc_submissions WHERE user_id = $1 ORDER BY created_at DESC LIMIT 1`
+
+	return r.getOne(ctx, query, userID)
+}
+
+func (r *postgresKYCSubmissionRepository) getOne(ctx context.Context, query string, arg interface{}) (*entities.KYCSubmission, error) {
+	submission, err := scanKYCSubmission(conn(ctx, r.db).QueryRowContext(ctx, query, arg))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, customerrors.NewNotFoundError("KYC submission not found")
+		}
+		return nil, customerrors.NewInternalError("Failed to get KYC submission", err)
+	}
+
+	return submission, nil
+}
+
+func (r *postgresKYCSubmissionRepository) List(ctx context.Context, status entities.KYCSubmissionStatus, limit, offset int) ([]*entities.KYCSubmission, error) {
+	query := `
+		SELECT ` + kycSubmissionColumns + `
+		FROM kyc_submissions
+		WHERE $1 = '' OR status = $1
+		ORDER BY created_at ASC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, status, limit, offset)
+	if err != nil {
+		return nil, customerrors.NewInternalError("Failed to list KYC submissions", err)
+	}
+	defer rows.Close()
+
+	submissions := []*entities.KYCSubmission{}
+	for rows.Next() {
+		submission, err := scanKYCSubmission(rows)
+		if err != nil {
+			return nil, customerrors.NewInternalError("Failed to scan KYC submission", err)
+		}
+		submissions = append(submissions, submission)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, customerrors.NewInternalError("Failed to iterate KYC submissions", err)
+	}
+
+	return submissions, nil
+}
+
+func (r *postgresKYCSubmissionRepository) Count(ctx context.Context, status entities.KYCSubmissionStatus) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM kyc_submissions WHERE $1 = '' OR status = $1`
+
+	if err := conn(ctx, r.db).QueryRowContext(ctx, query, status).Scan(&count); err != nil {
+		return 0, customerrors.NewInternalError("Failed to count KYC submissions", err)
+	}
+
+	return count, nil
+}
+
+func (r *postgresKYCSubmissionRepository) UpdateReview(ctx context.Context, submission *entities.KYCSubmission) error {
+	query := `
+		UPDATE kyc_submissions
+		SET status = $2, reviewed_by = $3, review_reason = $4, reviewed_at = $5, updated_at = $6
+		WHERE id = $1
+	`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query,
+		submission.ID,
+		submission.Status,
+		submission.ReviewedBy,
+		submission.ReviewReason,
+		submission.ReviewedAt,
+		submission.UpdatedAt,
+	)
+	if err != nil {
+		return customerrors.NewInternalError("Failed to update KYC submission", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return customerrors.NewInternalError("Failed to update KYC submission", err)
+	}
+	if rows == 0 {
+		return customerrors.NewNotFoundError("KYC submission not found")
+	}
+
+	return nil
+}
+
+func scanKYCSubmission(row rowScanner) (*entities.KYCSubmission, error) {
+	submission := &entities.KYCSubmission{}
+	var documentsJSON []byte
+	err := row.Scan(
+		&submission.ID,
+		&submission.UserID,
+		&submission.RequestedTier,
+		&submission.Status,
+		&submission.FullName,
+		&submission.DateOfBirth,
+		&submission.Nationality,
+		&submission.IDType,
+		&submission.IDNumber,
+		&submission.Address,
+		&documentsJSON,
+		&submission.ReviewedBy,
+		&submission.ReviewReason,
+		&submission.ReviewedAt,
+		&submission.CreatedAt,
+		&submission.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []kycDocumentRecord
+	if err := json.Unmarshal(documentsJSON, &records); err != nil {
+		return nil, err
+	}
+	submission.Documents = make([]entities.KYCDocument, len(records))
+	for i, record := range records {
+		submission.Documents[i] = entities.KYCDocument(record)
+	}
+
+	return submission, nil
+}
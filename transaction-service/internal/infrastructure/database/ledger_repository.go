@@ -0,0 +1,161 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"go-transaction-service/internal/domain/entities"
+	"go-transaction-service/internal/domain/repositories"
+	"go-transaction-service/pkg/errors"
+)
+
+type postgresLedgerRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresLedgerRepository(db *sql.DB) repositories.LedgerRepository {
+	return &postgresLedgerRepository{db: db}
+}
+
+// walletBalanceQuery sums the wallet postings of a user; credits increase and debits decrease the balance
+const walletBalanceQuery = `
+	SELECT COALESCE(SUM(CASE WHEN direction = 'credit' THEN amount ELSE -amount END), 0)
+	FROM ledger_postings
+	WHERE account = 'user_wallet' AND user_id = $1
+`
+
+func (r *postgresLedgerRepository) CreateEntry(ctx context.Context, entry *entities.JournalEntry) error {
+	if err := entry.Validate(); err != nil {
+		return customerrors.NewInternalError("Invalid journal entry", err)
+	}
+
+	// Journal and postings are written atomically, joining the caller's transaction if any
+	return withinTransaction(ctx, r.db, func(ctx context.Context) error {
+		query := `
+			INSERT INTO ledger_journals (id, transaction_id, description, created_at)
+			VALUES ($1, $2, $3, $4)
+		`
+
+		_, err := conn(ctx, r.db).ExecContext(ctx, query,
+			entry.ID,
+			entry.TransactionID,
+			entry.Description,
+			entry.CreatedAt,
+		)
+
+		if err != nil {
+			return customerrors.NewInternalError("Failed to create journal entry", err)
+		}
+
+		postingQuery := `
+			INSERT INTO ledger_postings (id, journal_id, account, user_id, direction, amount, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+		`
+
+		for _, posting := range entry.Postings {
+			_, err = conn(ctx, r.db).ExecContext(ctx, postingQuery,
+				posting.ID,
+				entry.ID,
+				posting.Account,
+				posting.UserID,
+				posting.Direction,
+				posting.Amount,
+				posting.CreatedAt,
+			)
+
+			if err != nil {
+				return customerrors.NewInternalError("Failed to create ledger posting", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+func (r *postgresLedgerRepository) GetEntriesByTransactionID(ctx context.Context, transactionID uuid.UUID) ([]*entities.JournalEntry, error) {
+	query := `
+		SELECT j.id, j.transaction_id, j.description, j.created_at,
+		       p.id, p.account, p.user_id, p.direction, p.amount, p.created_at
+		FROM ledger_journals j
+		JOIN ledger_postings p ON p.journal_id = j.id
+		WHERE j.transaction_id = $1
+		ORDER BY j.created_at ASC, j.id, p.direction DESC
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, transactionID)
+	if err != nil {
+		return nil, customerrors.NewInternalError("Failed to get journal entries", err)
+	}
+	defer rows.Close()
+
+	var entries []*entities.JournalEntry
+	var current *entities.JournalEntry
+	for rows.Next() {
+		entry := &entities.JournalEntry{}
+		posting := entities.Posting{}
+
+		err := rows.Scan(
+			&entry.ID,
+			&entry.TransactionID,
+			&entry.Description,
+			&entry.CreatedAt,
+			&posting.ID,
+			&posting.Account,
+			&posting.UserID,
+			&posting.Direction,
+			&posting.Amount,
+			&posting.CreatedAt,
+		)
+
+		if err != nil {
+			return nil, customerrors.NewInternalError("Failed to scan journal entry", err)
+		}
+
+		if current == nil || current.ID != entry.ID {
+			current = entry
+			entries = append(entries, current)
+		}
+
+		posting.JournalID = current.ID
+		current.Postings = append(current.Postings, posting)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, customerrors.NewInternalError("Failed to iterate journal entries", err)
+	}
+
+	return entries, nil
+}
+
+func (r *postgresLedgerRepository) GetWalletBalance(ctx context.Context, userID uuid.UUID) (decimal.Decimal, error) {
+	var balance decimal.Decimal
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, walletBalanceQuery, userID).Scan(&balance)
+	if err != nil {
+		return decimal.Zero, customerrors.NewInternalError("Failed to compute wallet balance", err)
+	}
+
+	return balance, nil
+}
+
+func (r *postgresLedgerRepository) RebuildWalletBalance(ctx context.Context, userID uuid.UUID) (decimal.Decimal, error) {
+	query := `
+		UPDATE users
+		SET balance = (` + walletBalanceQuery + `), updated_at = NOW()
+		WHERE id = $1
+		RETURNING balance
+	`
+
+	var balance decimal.Decimal
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, userID).Scan(&balance)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return decimal.Zero, customerrors.NewNotFoundError("User not found")
+		}
+		return decimal.Zero, customerrors.NewInternalError("Failed to rebuild wallet balance", err)
+	}
+
+	return balance, nil
+}
@@ -0,0 +1,167 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"go-transaction-service/internal/domain/entities"
+	"go-transaction-service/internal/domain/repositories"
+	"go-transaction-service/pkg/errors"
+)
+
+type postgresMFARepository struct {
+	db *sql.DB
+}
+
+func NewPostgresMFARepository(db *sql.DB) repositories.MFARepository {
+	return &postgresMFARepository{db: db}
+}
+
+func (r *postgresMFARepository) CreateChallenge(ctx context.Context, challenge *entities.MFAChallenge) error {
+	query := `
+		INSERT INTO mfa_challenges (id, user_id, token_hash, attempts, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query,
+		challenge.ID,
+		challenge.UserID,
+		challenge.TokenHash,
+		challenge.Attempts,
+		challenge.ExpiresAt,
+		challenge.CreatedAt,
+	)
+	if err != nil {
+		return customerrors.NewInternalError("Failed to create MFA challenge", err)
+	}
+
+	return nil
+}
+
+func (r *postgresMFARepository) GetChallengeByHashForUpdate(ctx context.Context, tokenHash string) (*entities.MFAChallenge, error) {
+	challenge := &entities.MFAChallenge{}
+
+	query := `
+		SELECT id, user_id, token_hash, attempts, expires_at, used_at, created_at
+		FROM mfa_challenges
+		WHERE token_hash = $1
+		FOR UPDATE
+	`
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, tokenHash).Scan(
+		&challenge.ID,
+		&challenge.UserID,
+		&challenge.TokenHash,
+		&challenge.Attempts,
+		&challenge.ExpiresAt,
+		&challenge.UsedAt,
+		&challenge.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, customerrors.NewNotFoundError("MFA challenge not found")
+		}
+		return nil, customerrors.NewInternalError("Failed to get MFA challenge", err)
+	}
+
+	return challenge, nil
+}
+
+func (r *postgresMFARepository) UpdateChallenge(ctx context.Context, challenge *entities.MFAChallenge) error {
+	query := `
+		UPDATE mfa_challenges
+		SET attempts = $2, used_at = $3
+		WHERE id = $1
+	`
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, challenge.ID, challenge.Attempts, challenge.UsedAt); err != nil {
+		return customerrors.NewInternalError("Failed to update MFA challenge", err)
+	}
+
+	return nil
+}
+
+func (r *postgresMFARepository) ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, codes []*entities.RecoveryCode) error {
+	if err := r.DeleteRecoveryCodes(ctx, userID); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO mfa_recovery_codes (id, user_id, code_hash, created_at)
+		VALUES ($1, $2, $3, $4)
+	`
+
+	for _, code := range codes {
+		if _, err := conn(ctx, r.db).ExecContext(ctx, query, code.ID, userID, code.CodeHash, code.CreatedAt); err != nil {
+			return customerrors.NewInternalError("Failed to store recovery codes", err)
+		}
+	}
+
+	return nil
+}
+
+func (r *postgresMFARepository) GetUnusedRecoveryCodes(ctx context.Context, userID uuid.UUID) ([]*entities.RecoveryCode, error) {
+	query := `
+		SELECT id, user_id, code_hash, used_at, created_at
+		FROM mfa_recovery_codes
+		WHERE user_id = $1 AND used_at IS NULL
+		ORDER BY created_at, id
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, customerrors.NewInternalError("Failed to get recovery codes", err)
+	}
+	defer rows.Close()
+
+	var codes []*entities.RecoveryCode
+	for rows.Next() {
+		code := &entities.RecoveryCode{}
+		if err := rows.Scan(&code.ID, &code.UserID, &code.CodeHash, &code.UsedAt, &code.CreatedAt); err != nil {
+			return nil, customerrors.NewInternalError("Failed to scan recovery code", err)
+		}
+		codes = append(codes, code)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, customerrors.NewInternalError("Failed to iterate recovery codes", err)
+	}
+
+	return codes, nil
+}
+
+func (r *postgresMFARepository) MarkRecoveryCodeUsed(ctx context.Context, id uuid.UUID) error {
+	query := `
+		UPDATE mfa_recovery_codes
+		SET used_at = NOW()
+		WHERE id = $1 AND used_at IS NULL
+	`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, id)
+	if err != nil {
+		return customerrors.NewInternalError("Failed to use recovery code", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return customerrors.NewInternalError("Failed to get rows affected", err)
+	}
+
+	// A concurrent request used the same code first
+	if rowsAffected == 0 {
+		return customerrors.NewUnauthorizedError("Invalid verification code")
+	}
+
+	return nil
+}
+
+func (r *postgresMFARepository) DeleteRecoveryCodes(ctx context.Context, userID uuid.UUID) error {
+	query := `DELETE FROM mfa_recovery_codes WHERE user_id = $1`
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, userID); err != nil {
+		return customerrors.NewInternalError("Failed to delete recovery codes", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"go-transaction-service/internal/domain/entities"
+	"go-transaction-service/internal/domain/repositories"
+	"go-transaction-service/pkg/errors"
+)
+
+type postgresPaymentCallbackRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresPaymentCallbackRepository(db *sql.DB) repositories.PaymentCallbackRepository {
+	return &postgresPaymentCallbackRepository{db: db}
+}
+
+func (r *postgresPaymentCallbackRepository) Create(ctx context.Context, callback *entities.PaymentCallback) error {
+	query := `
+		INSERT INTO payment_callbacks (id, transaction_id, order_id, gateway_transaction_id, gateway_status, status_code,
+		                               gross_amount, raw_payload, signature, verified, rejection_reason, received_at,
+		                               processed_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NULLIF($11, ''), $12, $13, $14, $15)
+	`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query,
+		callback.ID,
+		callback.TransactionID,
+		callback.OrderID,
+		callback.GatewayTransactionID,
+		callback.GatewayStatus,
+		callback.StatusCode,
+		callback.GrossAmount,
+		callback.RawPayload,
+		callback.Signature,
+		callback.Verified,
+		callback.RejectionReason,
+		callback.ReceivedAt,
+		callback.ProcessedAt,
+		callback.CreatedAt,
+		callback.UpdatedAt,
+	)
+
+	if err != nil {
+		return customerrors.NewInternalError("Failed to store payment callback", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,126 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"go-transaction-service/internal/domain/entities"
+	"go-transaction-service/internal/domain/repositories"
+	"go-transaction-service/pkg/errors"
+)
+
+type postgresRefreshTokenRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresRefreshTokenRepository(db *sql.DB) repositories.RefreshTokenRepository {
+	return &postgresRefreshTokenRepository{db: db}
+}
+
+func (r *postgresRefreshTokenRepository) Create(ctx context.Context, token *entities.RefreshToken) error {
+	query := `
+		INSERT INTO refresh_tokens (id, user_id, family_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query,
+		token.ID,
+		token.UserID,
+		token.FamilyID,
+		token.TokenHash,
+		token.ExpiresAt,
+		token.CreatedAt,
+	)
+	if err != nil {
+		return customerrors.NewInternalError("Failed to create refresh token", err)
+	}
+
+	return nil
+}
+
+func (r *postgresRefreshTokenRepository) GetByHashForUpdate(ctx context.Context, tokenHash string) (*entities.RefreshToken, error) {
+	token := &entities.RefreshToken{}
+
+	query := `
+		SELECT id, user_id, family_id, token_hash, expires_at, revoked_at, replaced_by_id, created_at
+		FROM refresh_tokens
+		WHERE token_hash = $1
+		FOR UPDATE
+	`
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, tokenHash).Scan(
+		&token.ID,
+		&token.UserID,
+		&token.FamilyID,
+		&token.TokenHash,
+		&token.ExpiresAt,
+		&token.RevokedAt,
+		&token.ReplacedByID,
+		&token.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, customerrors.NewNotFoundError("Refresh token not found")
+		}
+		return nil, customerrors.NewInternalError("Failed to get refresh token", err)
+	}
+
+	return token, nil
+}
+
+func (r *postgresRefreshTokenRepository) MarkReplaced(ctx context.Context, id, replacedByID uuid.UUID) error {
+	query := `
+		UPDATE refresh_tokens
+		SET revoked_at = NOW(), replaced_by_id = $2
+		WHERE id = $1
+	`
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, id, replacedByID); err != nil {
+		return customerrors.NewInternalError("Failed to rotate refresh token", err)
+	}
+
+	return nil
+}
+
+func (r *postgresRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID uuid.UUID) error {
+	query := `
+		UPDATE refresh_tokens
+		SET revoked_at = NOW()
+		WHERE family_id = $1 AND revoked_at IS NULL
+	`
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, familyID); err != nil {
+		return customerrors.NewInternalError("Failed to revoke refresh tokens", err)
+	}
+
+	return nil
+}
+
+func (r *postgresRefreshTokenRepository) RevokeAllForUser(ctx context.Context, userID uuid.UUID) error {
+	query := `
+		UPDATE refresh_tokens
+		SET revoked_at = NOW()
+		WHERE user_id = $1 AND revoked_at IS NULL
+	`
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, userID); err != nil {
+		return customerrors.NewInternalError("Failed to revoke refresh tokens", err)
+	}
+
+	return nil
+}
+
+func (r *postgresRefreshTokenRepository) RevokeAllForUserExcept(ctx context.Context, userID, familyID uuid.UUID) error {
+	query := `
+		UPDATE refresh_tokens
+		SET revoked_at = NOW()
+		WHERE user_id = $1 AND family_id <> $2 AND revoked_at IS NULL
+	`
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, userID, familyID); err != nil {
+		return customerrors.NewInternalError("Failed to revoke refresh tokens", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,44 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"go-transaction-service/internal/domain/repositories"
+	"go-transaction-service/pkg/errors"
+)
+
+type postgresRevokedTokenRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresRevokedTokenRepository(db *sql.DB) repositories.RevokedTokenRepository {
+	return &postgresRevokedTokenRepository{db: db}
+}
+
+func (r *postgresRevokedTokenRepository) Revoke(ctx context.Context, jti string, userID uuid.UUID, expiresAt time.Time) error {
+	query := `
+		INSERT INTO revoked_tokens (jti, user_id, expires_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (jti) DO NOTHING
+	`
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, jti, userID, expiresAt); err != nil {
+		return customerrors.NewInternalError("Failed to revoke token", err)
+	}
+
+	return nil
+}
+
+func (r *postgresRevokedTokenRepository) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	var revoked bool
+	query := `SELECT EXISTS(SELECT 1 FROM revoked_tokens WHERE jti = $1)`
+
+	if err := conn(ctx, r.db).QueryRowContext(ctx, query, jti).Scan(&revoked); err != nil {
+		return false, customerrors.NewInternalError("Failed to check token revocation", err)
+	}
+
+	return revoked, nil
+}
@@ -0,0 +1,110 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"go-transaction-service/internal/domain/entities"
+	"go-transaction-service/internal/domain/repositories"
+	"go-transaction-service/pkg/errors"
+)
+
+type postgresSecurityEventRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresSecurityEventRepository(db *sql.DB) repositories.SecurityEventRepository {
+	return &postgresSecurityEventRepository{db: db}
+}
+
+func (r *postgresSecurityEventRepository) Create(ctx context.Context, event *entities.SecurityEvent) error {
+	query := `
+		INSERT INTO security_events (id, user_id, type, email, ip_address, user_agent, details, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query,
+		event.ID,
+		event.UserID,
+		event.Type,
+		event.Email,
+		event.IPAddress,
+		event.UserAgent,
+		event.Details,
+		event.CreatedAt,
+	)
+	if err != nil {
+		return customerrors.NewInternalError("Failed to create security event", err)
+	}
+
+	return nil
+}
+
+func (r *postgresSecurityEventRepository) CountByIPSince(ctx context.Context, eventType entities.SecurityEventType, ipAddress string, since time.Time) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM security_events
+		WHERE ip_address = $1 AND type = $2 AND created_at >= $3
+	`
+
+	var count int
+	if err := conn(ctx, r.db).QueryRowContext(ctx, query, ipAddress, eventType, since).Scan(&count); err != nil {
+		return 0, customerrors.NewInternalError("Failed to count security events", err)
+	}
+
+	return count, nil
+}
+
+func (r *postgresSecurityEventRepository) ListByUser(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*entities.SecurityEvent, error) {
+	query := `
+		SELECT id, user_id, type, email, ip_address, user_agent, details, created_at
+		FROM security_events
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, userID, limit, offset)
+	if err != nil {
+		return nil, customerrors.NewInternalError("Failed to list security events", err)
+	}
+	defer rows.Close()
+
+	events := []*entities.SecurityEvent{}
+	for rows.Next() {
+		event := &entities.SecurityEvent{}
+		err := rows.Scan(
+			&event.ID,
+			&event.UserID,
+			&event.Type,
+			&event.Email,
+			&event.IPAddress,
+			&event.UserAgent,
+			&event.Details,
+			&event.CreatedAt,
+		)
+		if err != nil {
+			return nil, customerrors.NewInternalError("Failed to scan security event", err)
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, customerrors.NewInternalError("Failed to iterate security events", err)
+	}
+
+	return events, nil
+}
+
+func (r *postgresSecurityEventRepository) CountByUser(ctx context.Context, userID uuid.UUID) (int, error) {
+	query := `SELECT COUNT(*) FROM security_events WHERE user_id = $1`
+
+	var count int
+	if err := conn(ctx, r.db).QueryRowContext(ctx, query, userID).Scan(&count); err != nil {
+		return 0, customerrors.NewInternalError("Failed to count security events", err)
+	}
+
+	return count, nil
+}
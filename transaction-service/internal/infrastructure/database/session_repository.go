@@ -0,0 +1,145 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"go-transaction-service/internal/domain/entities"
+	"go-transaction-service/internal/domain/repositories"
+	"go-transaction-service/pkg/errors"
+)
+
+type postgresSessionRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresSessionRepository(db *sql.DB) repositories.SessionRepository {
+	return &postgresSessionRepository{db: db}
+}
+
+// activeSession matches sessions with a refresh token that can still be used, so revoking
+// the refresh tokens of a session in any way ends it
+const activeSession = `EXISTS (
+			SELECT 1 FROM refresh_tokens rt
+			WHERE rt.family_id = s.id AND rt.revoked_at IS NULL AND rt.expires_at > NOW()
+		)`
+
+func (r *postgresSessionRepository) Create(ctx context.Context, session *entities.Session) error {
+	query := `
+		INSERT INTO sessions (id, user_id, device, user_agent, ip_address, created_at, last_seen_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query,
+		session.ID,
+		session.UserID,
+		session.Device,
+		session.UserAgent,
+		session.IPAddress,
+		session.CreatedAt,
+		session.LastSeenAt,
+	)
+	if err != nil {
+		return customerrors.NewInternalError("Failed to create session", err)
+	}
+
+	return nil
+}
+
+func (r *postgresSessionRepository) GetActive(ctx context.Context, id uuid.UUID) (*entities.Session, error) {
+	query := `
+		SELECT s.id, s.user_id, s.device, s.user_agent, s.ip_address, s.created_at, s.last_seen_at
+		FROM sessions s
+		WHERE s.id = $1 AND ` + activeSession
+
+	session, err := scanSession(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, customerrors.NewNotFoundError("Session not found")
+		}
+		return nil, customerrors.NewInternalError("Failed to get session", err)
+	}
+
+	return session, nil
+}
+
+func (r *postgresSessionRepository) ListActiveByUser(ctx context.Context, userID uuid.UUID) ([]*entities.Session, error) {
+	query := `
+		SELECT s.id, s.user_id, s.device, s.user_agent, s.ip_address, s.created_at, s.last_seen_at
+		FROM sessions s
+		WHERE s.user_id = $1 AND ` + activeSession + `
+		ORDER BY s.last_seen_at DESC, s.id
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, customerrors.NewInternalError("Failed to list sessions", err)
+	}
+	defer rows.Close()
+
+	sessions := []*entities.Session{}
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, customerrors.NewInternalError("Failed to scan session", err)
+		}
+		sessions = append(sessions, session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, customerrors.NewInternalError("Failed to iterate sessions", err)
+	}
+
+	return sessions, nil
+}
+
+func (r *postgresSessionRepository) Touch(ctx context.Context, id uuid.UUID, seenAt time.Time) error {
+	query := `UPDATE sessions SET last_seen_at = $2 WHERE id = $1 AND last_seen_at < $2`
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, id, seenAt); err != nil {
+		return customerrors.NewInternalError("Failed to update session", err)
+	}
+
+	return nil
+}
+
+func (r *postgresSessionRepository) UpdateClient(ctx context.Context, session *entities.Session) error {
+	query := `
+		UPDATE sessions
+		SET device = $2, user_agent = $3, ip_address = $4, last_seen_at = $5
+		WHERE id = $1
+	`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query,
+		session.ID,
+		session.Device,
+		session.UserAgent,
+		session.IPAddress,
+		session.LastSeenAt,
+	)
+	if err != nil {
+		return customerrors.NewInternalError("Failed to update session", err)
+	}
+
+	return nil
+}
+
+func scanSession(row rowScanner) (*entities.Session, error) {
+	session := &entities.Session{}
+	err := row.Scan(
+		&session.ID,
+		&session.UserID,
+		&session.Device,
+		&session.UserAgent,
+		&session.IPAddress,
+		&session.CreatedAt,
+		&session.LastSeenAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
@@ -0,0 +1,81 @@
+package database
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"go-transaction-service/internal/domain/entities"
+)
+
+// filterQuery accumulates the WHERE conditions of a query and their positional arguments
+type filterQuery struct {
+	conditions []string
+	args       []interface{}
+}
+
+// arg registers a positional argument and returns its placeholder
+func (q *filterQuery) arg(value interface{}) string {
+	q.args = append(q.args, value)
+	return "$" + strconv.Itoa(len(q.args))
+}
+
+// add appends a condition, replacing each ? with the placeholder of the matching value
+func (q *filterQuery) add(condition string, values ...interface{}) {
+	for _, value := range values {
+		condition = strings.Replace(condition, "?", q.arg(value), 1)
+	}
+	q.conditions = append(q.conditions, condition)
+}
+
+// sql returns the conditions joined for a WHERE clause
+func (q *filterQuery) sql() string {
+	return strings.Join(q.conditions, " AND ")
+}
+
+// newTransactionFilterQuery translates a transaction filter into conditions on the transactions table
+func newTransactionFilterQuery(userID uuid.UUID, filter entities.TransactionFilter) *filterQuery {
+	q := &filterQuery{}
+	user := q.arg(userID)
+
+	// A transaction belongs to the history of whoever initiated it and of its counterparty,
+	// and its direction is reversed for the counterparty
+	switch filter.Direction {
+	case entities.TransactionDirectionIncoming:
+		q.add("((user_id = " + user + " AND direction = 'incoming') OR (counterparty_user_id = " + user + " AND direction = 'outgoing'))")
+	case entities.TransactionDirectionOutgoing:
+		q.add("((user_id = " + user + " AND direction = 'outgoing') OR (counterparty_user_id = " + user + " AND direction = 'incoming'))")
+	default:
+		q.add("(user_id = " + user + " OR counterparty_user_id = " + user + ")")
+	}
+
+	if filter.Type != "" {
+		q.add("type = ?", filter.Type)
+	}
+	if filter.Status != "" {
+		q.add("status = ?", filter.Status)
+	}
+	if filter.MinAmount != nil {
+		q.add("amount >= ?", *filter.MinAmount)
+	}
+	if filter.MaxAmount != nil {
+		q.add("amount <= ?", *filter.MaxAmount)
+	}
+	if filter.From != nil {
+		q.add("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		q.add("created_at < ?", *filter.To)
+	}
+	if filter.Search != "" {
+		pattern := q.arg("%" + escapeLike(filter.Search) + "%")
+		q.add("(reference ILIKE " + pattern + " OR description ILIKE " + pattern + ")")
+	}
+
+	return q
+}
+
+// escapeLike escapes the LIKE wildcards of user input
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
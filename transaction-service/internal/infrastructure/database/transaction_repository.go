@@ -4,8 +4,10 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 	"go-transaction-service/internal/domain/entities"
 	"go-transaction-service/internal/domain/repositories"
 	"go-transaction-service/pkg/errors"
@@ -26,21 +28,26 @@ func (r *postgresTransactionRepository) Create(ctx context.Context, transaction
 	}
 
 	query := `
-		INSERT INTO transactions (id, user_id, type, amount, status, reference, payment_gateway_id, description, metadata, processed_at, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		INSERT INTO transactions (id, user_id, counterparty_user_id, parent_transaction_id, type, direction, amount, fee, status, reference, payment_gateway_id, description, metadata, processed_at, expires_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
 	`
 	
-	_, err = r.db.ExecContext(ctx, query,
+	_, err = conn(ctx, r.db).ExecContext(ctx, query,
 		transaction.ID,
 		transaction.UserID,
+		transaction.CounterpartyUserID,
+		transaction.ParentTransactionID,
 		transaction.Type,
+		transaction.Direction,
 		transaction.Amount,
+		transaction.Fee,
 		transaction.Status,
 		transaction.Reference,
 		transaction.PaymentGatewayID,
 		transaction.Description,
 		metadataJSON,
 		transaction.ProcessedAt,
+		transaction.ExpiresAt,
 		transaction.CreatedAt,
 		transaction.UpdatedAt,
 	)
@@ -52,138 +59,118 @@ func (r *postgresTransactionRepository) Create(ctx context.Context, transaction
 	return nil
 }
 
-func (r *postgresTransactionRepository) GetByID(ctx context.Context, id uuid.UUID) (*entities.Transaction, error) {
+// transactionColumns lists the columns read by scanTransaction, in scan order
+const transactionColumns = `id, user_id, counterparty_user_id, parent_transaction_id, type, direction, amount, fee, status, reference, payment_gateway_id, description, metadata, processed_at, expires_at, created_at, updated_at`
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanTransaction scans a row selected with transactionColumns
+func scanTransaction(row rowScanner) (*entities.Transaction, error) {
 	transaction := &entities.Transaction{}
 	var metadataJSON []byte
-	
-	query := `
-		SELECT id, user_id, type, amount, status, reference, payment_gateway_id, description, metadata, processed_at, created_at, updated_at
-		FROM transactions
-		WHERE id = $1
-	`
-	
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+
+	err := row.Scan(
 		&transaction.ID,
 		&transaction.UserID,
+		&transaction.CounterpartyUserID,
+		&transaction.ParentTransactionID,
 		&transaction.Type,
+		&transaction.Direction,
 		&transaction.Amount,
+		&transaction.Fee,
 		&transaction.Status,
 		&transaction.Reference,
 		&transaction.PaymentGatewayID,
 		&transaction.Description,
 		&metadataJSON,
 		&transaction.ProcessedAt,
+		&transaction.ExpiresAt,
 		&transaction.CreatedAt,
 		&transaction.UpdatedAt,
 	)
-	
+
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, customerrors.NewNotFoundError("Transaction not found")
-		}
-		return nil, customerrors.NewInternalError("Failed to get transaction", err)
+		return nil, err
 	}
-	
+
 	// Unmarshal metadata
 	if err := json.Unmarshal(metadataJSON, &transaction.Metadata); err != nil {
 		return nil, customerrors.NewInternalError("Failed to unmarshal metadata", err)
 	}
-	
+
 	return transaction, nil
 }
 
-func (r *postgresTransactionRepository) GetByReference(ctx context.Context, reference string) (*entities.Transaction, error) {
-	transaction := &entities.Transaction{}
-	var metadataJSON []byte
-	
-	query := `
-		SELECT id, user_id, type, amount, status, reference, payment_gateway_id, description, metadata, processed_at, created_at, updated_at
-		FROM transactions
-		WHERE reference = $1
-	`
-	
-	err := r.db.QueryRowContext(ctx, query, reference).Scan(
-		&transaction.ID,
-		&transaction.UserID,
-		&transaction.Type,
-		&transaction.Amount,
-		&transaction.Status,
-		&transaction.Reference,
-		&transaction.PaymentGatewayID,
-		&transaction.Description,
-		&metadataJSON,
-		&transaction.ProcessedAt,
-		&transaction.CreatedAt,
-		&transaction.UpdatedAt,
-	)
-	
+// getOne loads a single transaction matching the given condition, optionally locking the row
+func (r *postgresTransactionRepository) getOne(ctx context.Context, condition string, arg interface{}, forUpdate bool) (*entities.Transaction, error) {
+	query := `SELECT ` + transactionColumns + ` FROM transactions WHERE ` + condition
+	if forUpdate {
+		query += ` FOR UPDATE`
+	}
+
+	transaction, err := scanTransaction(conn(ctx, r.db).QueryRowContext(ctx, query, arg))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, customerrors.NewNotFoundError("Transaction not found")
 		}
+		if _, ok := err.(*customerrors.CustomError); ok {
+			return nil, err
+		}
 		return nil, customerrors.NewInternalError("Failed to get transaction", err)
 	}
-	
-	// Unmarshal metadata
-	if err := json.Unmarshal(metadataJSON, &transaction.Metadata); err != nil {
-		return nil, customerrors.NewInternalError("Failed to unmarshal metadata", err)
-	}
-	
+
 	return transaction, nil
 }
 
-func (r *postgresTransactionRepository) GetByUserID(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*entities.Transaction, error) {
+func (r *postgresTransactionRepository) GetByID(ctx context.Context, id uuid.UUID) (*entities.Transaction, error) {
+	return r.getOne(ctx, "id = $1", id, false)
+}
+
+func (r *postgresTransactionRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*entities.Transaction, error) {
+	return r.getOne(ctx, "id = $1", id, true)
+}
+
+func (r *postgresTransactionRepository) GetByReference(ctx context.Context, reference string) (*entities.Transaction, error) {
+	return r.getOne(ctx, "reference = $1", reference, false)
+}
+
+func (r *postgresTransactionRepository) GetByReferenceForUpdate(ctx context.Context, reference string) (*entities.Transaction, error) {
+	return r.getOne(ctx, "reference = $1", reference, true)
+}
+
+func (r *postgresTransactionRepository) GetByUserID(ctx context.Context, userID uuid.UUID, filter entities.TransactionFilter, page entities.TransactionPageRequest) ([]*entities.Transaction, error) {
+	where := newTransactionFilterQuery(userID, filter)
+	
+	// Keyset pagination: continue strictly after the cursor in (created_at, id) order
+	order := "DESC"
+	if page.Ascending {
+		order = "ASC"
+	}
+	if page.Cursor != nil {
+		comparison := "<"
+		if page.Ascending {
+			comparison = ">"
+		}
+		where.add("(created_at, id) "+comparison+" (?, ?)", page.Cursor.CreatedAt, page.Cursor.ID)
+	}
+	
 	query := `
-		SELECT id, user_id, type, amount, status, reference, payment_gateway_id, description, metadata, processed_at, created_at, updated_at
+		SELECT ` + transactionColumns + `
 		FROM transactions
-		WHERE user_id = $1
-		ORDER BY created_at DESC
-		LIMIT $2 OFFSET $3
-	`
+		WHERE ` + where.sql() + `
+		ORDER BY created_at ` + order + `, id ` + order + `
+		LIMIT ` + where.arg(page.Limit)
 	
-	rows, err := r.db.QueryContext(ctx, query, userID, limit, offset)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, where.args...)
 	if err != nil {
 		return nil, customerrors.NewInternalError("Failed to get transactions", err)
 	}
 	defer rows.Close()
 	
-	var transactions []*entities.Transaction
-	for rows.Next() {
-		transaction := &entities.Transaction{}
-		var metadataJSON []byte
-		
-		err := rows.Scan(
-			&transaction.ID,
-			&transaction.UserID,
-			&transaction.Type,
-			&transaction.Amount,
-			&transaction.Status,
-			&transaction.Reference,
-			&transaction.PaymentGatewayID,
-			&transaction.Description,
-			&metadataJSON,
-			&transaction.ProcessedAt,
-			&transaction.CreatedAt,
-			&transaction.UpdatedAt,
-		)
-		
-		if err != nil {
-			return nil, customerrors.NewInternalError("Failed to scan transaction", err)
-		}
-		
-		// Unmarshal metadata
-		if err := json.Unmarshal(metadataJSON, &transaction.Metadata); err != nil {
-			return nil, customerrors.NewInternalError("Failed to unmarshal metadata", err)
-		}
-		
-		transactions = append(transactions, transaction)
-	}
-	
-	if err := rows.Err(); err != nil {
-		return nil, customerrors.NewInternalError("Failed to iterate transactions", err)
-	}
-	
-	return transactions, nil
+	return collectTransactions(rows)
 }
 
 func (r *postgresTransactionRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status entities.TransactionStatus) error {
@@ -193,7 +180,7 @@ func (r *postgresTransactionRepository) UpdateStatus(ctx context.Context, id uui
 		WHERE id = $1
 	`
 	
-	result, err := r.db.ExecContext(ctx, query, id, status)
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, id, status)
 	if err != nil {
 		return customerrors.NewInternalError("Failed to update transaction status", err)
 	}
@@ -218,21 +205,25 @@ func (r *postgresTransactionRepository) Update(ctx context.Context, transaction
 
 	query := `
 		UPDATE transactions
-		SET type = $2, amount = $3, status = $4, reference = $5, payment_gateway_id = $6, 
-		    description = $7, metadata = $8, processed_at = $9, updated_at = $10
+		SET counterparty_user_id = $2, type = $3, direction = $4, amount = $5, fee = $6, status = $7, reference = $8,
+		    payment_gateway_id = $9, description = $10, metadata = $11, processed_at = $12, expires_at = $13, updated_at = $14
 		WHERE id = $1
 	`
 	
-	result, err := r.db.ExecContext(ctx, query,
+	result, err := conn(ctx, r.db).ExecContext(ctx, query,
 		transaction.ID,
+		transaction.CounterpartyUserID,
 		transaction.Type,
+		transaction.Direction,
 		transaction.Amount,
+		transaction.Fee,
 		transaction.Status,
 		transaction.Reference,
 		transaction.PaymentGatewayID,
 		transaction.Description,
 		metadataJSON,
 		transaction.ProcessedAt,
+		transaction.ExpiresAt,
 		transaction.UpdatedAt,
 	)
 	
@@ -252,67 +243,230 @@ func (r *postgresTransactionRepository) Update(ctx context.Context, transaction
 	return nil
 }
 
-func (r *postgresTransactionRepository) GetPendingTransactions(ctx context.Context) ([]*entities.Transaction, error) {
+func (r *postgresTransactionRepository) GetPendingTransactions(ctx context.Context, transactionType entities.TransactionType, olderThan time.Time, limit int) ([]*entities.Transaction, error) {
 	query := `
-		SELECT id, user_id, type, amount, status, reference, payment_gateway_id, description, metadata, processed_at, created_at, updated_at
+		SELECT ` + transactionColumns + `
 		FROM transactions
-		WHERE status = $1
-		ORDER BY created_at ASC
+		WHERE type = $1 AND status IN ($2, $3) AND updated_at < $4
+		ORDER BY updated_at ASC
+		LIMIT $5
 	`
 	
-	rows, err := r.db.QueryContext(ctx, query, entities.TransactionStatusPending)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query,
+		transactionType,
+		entities.TransactionStatusPending,
+		entities.TransactionStatusProcessing,
+		olderThan,
+		limit,
+	)
 	if err != nil {
 		return nil, customerrors.NewInternalError("Failed to get pending transactions", err)
 	}
 	defer rows.Close()
 	
-	var transactions []*entities.Transaction
+	return collectTransactions(rows)
+}
+
+func (r *postgresTransactionRepository) GetExpiredTransactions(ctx context.Context, now time.Time, limit int) ([]*entities.Transaction, error) {
+	query := `
+		SELECT ` + transactionColumns + `
+		FROM transactions
+		WHERE status IN ($1, $2, $3) AND expires_at < $4
+		ORDER BY expires_at ASC
+		LIMIT $5
+	`
+	
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query,
+		entities.TransactionStatusPending,
+		entities.TransactionStatusProcessing,
+		entities.TransactionStatusAuthorized,
+		now,
+		limit,
+	)
+	if err != nil {
+		return nil, customerrors.NewInternalError("Failed to get expired transactions", err)
+	}
+	defer rows.Close()
+	
+	return collectTransactions(rows)
+}
+
+// GetByStatus lists transactions of every user in the given status, oldest first
+func (r *postgresTransactionRepository) GetByStatus(ctx context.Context, status entities.TransactionStatus, limit, offset int) ([]*entities.Transaction, error) {
+	query := `
+		SELECT ` + transactionColumns + `
+		FROM transactions
+		WHERE status = $1
+		ORDER BY created_at ASC, id ASC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, status, limit, offset)
+	if err != nil {
+		return nil, customerrors.NewInternalError("Failed to get transactions", err)
+	}
+	defer rows.Close()
+
+	return collectTransactions(rows)
+}
+
+func (r *postgresTransactionRepository) CountByStatus(ctx context.Context, status entities.TransactionStatus) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM transactions WHERE status = $1`
+
+	if err := conn(ctx, r.db).QueryRowContext(ctx, query, status).Scan(&count); err != nil {
+		return 0, customerrors.NewInternalError("Failed to count transactions", err)
+	}
+
+	return count, nil
+}
+
+func (r *postgresTransactionRepository) GetStatusHistory(ctx context.Context, transactionID uuid.UUID) ([]entities.TransactionStatusChange, error) {
+	query := `
+		SELECT from_status, to_status, changed_at
+		FROM transaction_status_history
+		WHERE transaction_id = $1
+		ORDER BY changed_at ASC, id
+	`
+	
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, transactionID)
+	if err != nil {
+		return nil, customerrors.NewInternalError("Failed to get transaction status history", err)
+	}
+	defer rows.Close()
+	
+	var history []entities.TransactionStatusChange
 	for rows.Next() {
-		transaction := &entities.Transaction{}
-		var metadataJSON []byte
-		
-		err := rows.Scan(
-			&transaction.ID,
-			&transaction.UserID,
-			&transaction.Type,
-			&transaction.Amount,
-			&transaction.Status,
-			&transaction.Reference,
-			&transaction.PaymentGatewayID,
-			&transaction.Description,
-			&metadataJSON,
-			&transaction.ProcessedAt,
-			&transaction.CreatedAt,
-			&transaction.UpdatedAt,
-		)
+		var change entities.TransactionStatusChange
+		var fromStatus sql.NullString
 		
-		if err != nil {
-			return nil, customerrors.NewInternalError("Failed to scan transaction", err)
+		if err := rows.Scan(&fromStatus, &change.ToStatus, &change.ChangedAt); err != nil {
+			return nil, customerrors.NewInternalError("Failed to scan transaction status history", err)
 		}
+		change.FromStatus = entities.TransactionStatus(fromStatus.String)
 		
-		// Unmarshal metadata
-		if err := json.Unmarshal(metadataJSON, &transaction.Metadata); err != nil {
-			return nil, customerrors.NewInternalError("Failed to unmarshal metadata", err)
-		}
-		
-		transactions = append(transactions, transaction)
+		history = append(history, change)
 	}
 	
 	if err := rows.Err(); err != nil {
-		return nil, customerrors.NewInternalError("Failed to iterate transactions", err)
+		return nil, customerrors.NewInternalError("Failed to iterate transaction status history", err)
 	}
 	
-	return transactions, nil
+	return history, nil
+}
+
+func (r *postgresTransactionRepository) GetUsage(ctx context.Context, userID uuid.UUID, transactionType entities.TransactionType, dayStart, monthStart time.Time) (*entities.LimitUsage, error) {
+	usage := &entities.LimitUsage{}
+	query := `
+		SELECT
+			COUNT(*) FILTER (WHERE created_at >= $8),
+			COALESCE(SUM(amount) FILTER (WHERE created_at >= $8), 0),
+			COUNT(*),
+			COALESCE(SUM(amount), 0)
+		FROM transactions
+		WHERE user_id = $1 AND type = $2 AND status IN ($3, $4, $5, $6, $7) AND created_at >= $9
+	`
+	
+	err := conn(ctx, r.db).QueryRowContext(ctx, query,
+		userID,
+		transactionType,
+		entities.TransactionStatusPending,
+		entities.TransactionStatusReview,
+		entities.TransactionStatusAuthorized,
+		entities.TransactionStatusProcessing,
+		entities.TransactionStatusCompleted,
+		dayStart,
+		monthStart,
+	).Scan(&usage.DailyCount, &usage.DailyAmount, &usage.MonthlyCount, &usage.MonthlyAmount)
+	if err != nil {
+		return nil, customerrors.NewInternalError("Failed to sum transactions", err)
+	}
+	
+	return usage, nil
 }
 
-func (r *postgresTransactionRepository) CountByUserID(ctx context.Context, userID uuid.UUID) (int, error) {
+// SumRefundedAmount adds up the refunds of a transaction that are completed or still in progress
+func (r *postgresTransactionRepository) SumRefundedAmount(ctx context.Context, parentID uuid.UUID) (decimal.Decimal, error) {
+	var total decimal.Decimal
+	query := `
+		SELECT COALESCE(SUM(amount), 0)
+		FROM transactions
+		WHERE parent_transaction_id = $1 AND type = $2 AND status IN ($3, $4, $5)
+	`
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, query,
+		parentID,
+		entities.TransactionTypeRefund,
+		entities.TransactionStatusPending,
+		entities.TransactionStatusProcessing,
+		entities.TransactionStatusCompleted,
+	).Scan(&total)
+	if err != nil {
+		return decimal.Zero, customerrors.NewInternalError("Failed to sum refunds", err)
+	}
+
+	return total, nil
+}
+
+// SumPendingIncoming adds up the top-ups of a user the payment gateway has not settled yet and the
+// payments and transfers to the user that are held for review or authorized
+func (r *postgresTransactionRepository) SumPendingIncoming(ctx context.Context, userID uuid.UUID) (decimal.Decimal, error) {
+	var total decimal.Decimal
+	query := `
+		SELECT COALESCE(SUM(amount), 0)
+		FROM transactions
+		WHERE (user_id = $1 AND type = $2 AND status IN ($3, $4))
+			OR (counterparty_user_id = $1 AND type IN ($5, $6) AND status IN ($7, $8))
+	`
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, query,
+		userID,
+		entities.TransactionTypeTopup,
+		entities.TransactionStatusPending,
+		entities.TransactionStatusProcessing,
+		entities.TransactionTypePayment,
+		entities.TransactionTypeTransfer,
+		entities.TransactionStatusReview,
+		entities.TransactionStatusAuthorized,
+	).Scan(&total)
+	if err != nil {
+		return decimal.Zero, customerrors.NewInternalError("Failed to sum pending incoming funds", err)
+	}
+
+	return total, nil
+}
+
+func (r *postgresTransactionRepository) CountByUserID(ctx context.Context, userID uuid.UUID, filter entities.TransactionFilter) (int, error) {
 	var count int
-	query := `SELECT COUNT(*) FROM transactions WHERE user_id = $1`
+	where := newTransactionFilterQuery(userID, filter)
+	query := `SELECT COUNT(*) FROM transactions WHERE ` + where.sql()
 	
-	err := r.db.QueryRowContext(ctx, query, userID).Scan(&count)
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, where.args...).Scan(&count)
 	if err != nil {
 		return 0, customerrors.NewInternalError("Failed to count transactions", err)
 	}
 	
 	return count, nil
 }
+
+// collectTransactions scans every row selected with transactionColumns
+func collectTransactions(rows *sql.Rows) ([]*entities.Transaction, error) {
+	var transactions []*entities.Transaction
+	for rows.Next() {
+		transaction, err := scanTransaction(rows)
+		if err != nil {
+			if _, ok := err.(*customerrors.CustomError); ok {
+				return nil, err
+			}
+			return nil, customerrors.NewInternalError("Failed to scan transaction", err)
+		}
+
+		transactions = append(transactions, transaction)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, customerrors.NewInternalError("Failed to iterate transactions", err)
+	}
+
+	return transactions, nil
+}
@@ -0,0 +1,99 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"go-transaction-service/internal/domain/entities"
+	"go-transaction-service/internal/domain/repositories"
+	"go-transaction-service/pkg/errors"
+)
+
+type postgresUserLimitRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresUserLimitRepository(db *sql.DB) repositories.UserLimitRepository {
+	return &postgresUserLimitRepository{db: db}
+}
+
+func (r *postgresUserLimitRepository) GetByUserID(ctx context.Context, userID uuid.UUID) (*entities.UserLimits, error) {
+	query := `SELECT user_id, max_balance, transactions, updated_by, updated_at FROM user_limits WHERE user_id = $1`
+
+	limits := &entities.UserLimits{}
+	var maxBalance sql.NullString
+	var transactionsJSON []byte
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, userID).Scan(
+		&limits.UserID,
+		&maxBalance,
+		&transactionsJSON,
+		&limits.UpdatedBy,
+		&limits.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, customerrors.NewNotFoundError("User limits not found")
+		}
+		return nil, customerrors.NewInternalError("Failed to get user limits", err)
+	}
+
+	if maxBalance.Valid {
+		value, err := decimal.NewFromString(maxBalance.String)
+		if err != nil {
+			return nil, customerrors.NewInternalError("Failed to parse maximum balance", err)
+		}
+		limits.MaxBalance = &value
+	}
+
+	if err := json.Unmarshal(transactionsJSON, &limits.Transactions); err != nil {
+		return nil, customerrors.NewInternalError("Failed to unmarshal user limits", err)
+	}
+
+	return limits, nil
+}
+
+func (r *postgresUserLimitRepository) Save(ctx context.Context, limits *entities.UserLimits) error {
+	transactionsJSON, err := json.Marshal(limits.Transactions)
+	if err != nil {
+		return customerrors.NewInternalError("Failed to marshal user limits", err)
+	}
+
+	query := `
+		INSERT INTO user_limits (user_id, max_balance, transactions, updated_by, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id) DO UPDATE
+		SET max_balance = EXCLUDED.max_balance, transactions = EXCLUDED.transactions,
+			updated_by = EXCLUDED.updated_by, updated_at = EXCLUDED.updated_at
+	`
+
+	var maxBalance interface{}
+	if limits.MaxBalance != nil {
+		maxBalance = *limits.MaxBalance
+	}
+
+	_, err = conn(ctx, r.db).ExecContext(ctx, query,
+		limits.UserID,
+		maxBalance,
+		transactionsJSON,
+		limits.UpdatedBy,
+		limits.UpdatedAt,
+	)
+	if err != nil {
+		return customerrors.NewInternalError("Failed to save user limits", err)
+	}
+
+	return nil
+}
+
+func (r *postgresUserLimitRepository) Delete(ctx context.Context, userID uuid.UUID) error {
+	query := `DELETE FROM user_limits WHERE user_id = $1`
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, userID); err != nil {
+		return customerrors.NewInternalError("Failed to delete user limits", err)
+	}
+
+	return nil
+}
@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 	"database/sql"
+	"fmt"
 
 	"github.com/google/uuid"
 	"github.com/lib/pq"
@@ -22,11 +23,14 @@ func NewPostgresUserRepository(db *sql.DB) repositories.UserRepository {
 
 func (r *postgresUserRepository) Create(ctx context.Context, user *entities.User) error {
 	query := `
-		INSERT INTO users (id, email, password, first_name, last_name, phone, balance, status, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		INSERT INTO users (id, email, password, first_name, last_name, phone, balance, status, role, kyc_tier,
+		                   mfa_enabled, mfa_secret, mfa_last_used_step, pin_hash, pin_failed_attempts, pin_locked_until,
+		                   email_verified_at, failed_login_attempts, last_failed_login_at, locked_until,
+		                   created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22)
 	`
 	
-	_, err := r.db.ExecContext(ctx, query,
+	_, err := conn(ctx, r.db).ExecContext(ctx, query,
 		user.ID,
 		user.Email,
 		user.Password,
@@ -35,6 +39,18 @@ func (r *postgresUserRepository) Create(ctx context.Context, user *entities.User
 		user.Phone,
 		user.Balance,
 		user.Status,
+		user.Role,
+		user.KYCTier,
+		user.MFAEnabled,
+		user.MFASecret,
+		user.MFALastUsedStep,
+		user.PINHash,
+		user.PINFailedAttempts,
+		user.PINLockedUntil,
+		user.EmailVerifiedAt,
+		user.FailedLoginAttempts,
+		user.LastFailedLoginAt,
+		user.LockedUntil,
 		user.CreatedAt,
 		user.UpdatedAt,
 	)
@@ -51,15 +67,14 @@ func (r *postgresUserRepository) Create(ctx context.Context, user *entities.User
 	return nil
 }
 
-func (r *postgresUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*entities.User, error) {
+// userColumns lists the columns read by scanUser, in scan order
+const userColumns = `id, email, password, first_name, last_name, phone, balance, held_balance, status, role, kyc_tier, mfa_enabled, mfa_secret, mfa_last_used_step, pin_hash, pin_failed_attempts, pin_locked_until, email_verified_at, failed_login_attempts, last_failed_login_at, locked_until, created_at, updated_at`
+
+// scanUser scans a row selected with userColumns
+func scanUser(row rowScanner) (*entities.User, error) {
 	user := &entities.User{}
-	query := `
-		SELECT id, email, password, first_name, last_name, phone, balance, status, created_at, updated_at
-		FROM users
-		WHERE id = $1
-	`
-	
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+
+	err := row.Scan(
 		&user.ID,
 		&user.Email,
 		&user.Password,
@@ -67,61 +82,68 @@ func (r *postgresUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*en
 		&user.LastName,
 		&user.Phone,
 		&user.Balance,
+		&user.HeldBalance,
 		&user.Status,
+		&user.Role,
+		&user.KYCTier,
+		&user.MFAEnabled,
+		&user.MFASecret,
+		&user.MFALastUsedStep,
+		&user.PINHash,
+		&user.PINFailedAttempts,
+		&user.PINLockedUntil,
+		&user.EmailVerifiedAt,
+		&user.FailedLoginAttempts,
+		&user.LastFailedLoginAt,
+		&user.LockedUntil,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
-	
+
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, customerrors.NewNotFoundError("User not found")
 		}
 		return nil, customerrors.NewInternalError("Failed to get user", err)
 	}
-	
+
 	return user, nil
 }
 
+func (r *postgresUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*entities.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = $1`
+
+	return scanUser(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+}
+
+func (r *postgresUserRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*entities.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = $1 FOR UPDATE`
+
+	return scanUser(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+}
+
 func (r *postgresUserRepository) GetByEmail(ctx context.Context, email string) (*entities.User, error) {
-	user := &entities.User{}
-	query := `
-		SELECT id, email, password, first_name, last_name, phone, balance, status, created_at, updated_at
-		FROM users
-		WHERE email = $1
-	`
-	
-	err := r.db.QueryRowContext(ctx, query, email).Scan(
-		&user.ID,
-		&user.Email,
-		&user.Password,
-		&user.FirstName,
-		&user.LastName,
-		&user.Phone,
-		&user.Balance,
-		&user.Status,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
-	
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, customerrors.NewNotFoundError("User not found")
-		}
-		return nil, customerrors.NewInternalError("Failed to get user", err)
-	}
-	
-	return user, nil
+	query := `SELECT ` + userColumns + ` FROM users WHERE email = $1`
+
+	return scanUser(conn(ctx, r.db).QueryRowContext(ctx, query, email))
+}
+
+func (r *postgresUserRepository) GetByPhone(ctx context.Context, phone string) (*entities.User, error) {
+	// Phone numbers are not unique, so a number shared by several accounts matches none of them
+	query := `SELECT ` + userColumns + ` FROM users WHERE phone = $1 AND (SELECT COUNT(*) FROM users WHERE phone = $1) = 1`
+
+	return scanUser(conn(ctx, r.db).QueryRowContext(ctx, query, phone))
 }
 
 func (r *postgresUserRepository) Update(ctx context.Context, user *entities.User) error {
 	query := `
 		UPDATE users
 		SET email = $2, password = $3, first_name = $4, last_name = $5, phone = $6, 
-		    balance = $7, status = $8, updated_at = $9
+		    balance = $7, status = $8, role = $9, email_verified_at = $10, updated_at = $11
 		WHERE id = $1
 	`
 	
-	result, err := r.db.ExecContext(ctx, query,
+	result, err := conn(ctx, r.db).ExecContext(ctx, query,
 		user.ID,
 		user.Email,
 		user.Password,
@@ -130,10 +152,15 @@ func (r *postgresUserRepository) Update(ctx context.Context, user *entities.User
 		user.Phone,
 		user.Balance,
 		user.Status,
+		user.Role,
+		user.EmailVerifiedAt,
 		user.UpdatedAt,
 	)
 	
 	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" { // unique_violation
+			return customerrors.NewConflictError("Email already exists")
+		}
 		return customerrors.NewInternalError("Failed to update user", err)
 	}
 	
@@ -149,6 +176,212 @@ func (r *postgresUserRepository) Update(ctx context.Context, user *entities.User
 	return nil
 }
 
+func (r *postgresUserRepository) UpdateStatus(ctx context.Context, userID uuid.UUID, status entities.UserStatus) error {
+	query := `
+		UPDATE users
+		SET status = $2, updated_at = NOW()
+		WHERE id = $1
+	`
+	
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, userID, status)
+	if err != nil {
+		return customerrors.NewInternalError("Failed to update user status", err)
+	}
+	
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return customerrors.NewInternalError("Failed to get rows affected", err)
+	}
+	
+	if rowsAffected == 0 {
+		return customerrors.NewNotFoundError("User not found")
+	}
+	
+	return nil
+}
+
+func (r *postgresUserRepository) UpdateRole(ctx context.Context, userID uuid.UUID, role entities.Role) error {
+	query := `
+		UPDATE users
+		SET role = $2, updated_at = NOW()
+		WHERE id = $1
+	`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, userID, role)
+	if err != nil {
+		return customerrors.NewInternalError("Failed to update user role", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return customerrors.NewInternalError("Failed to get rows affected", err)
+	}
+
+	if rowsAffected == 0 {
+		return customerrors.NewNotFoundError("User not found")
+	}
+
+	return nil
+}
+
+func (r *postgresUserRepository) UpdateKYCTier(ctx context.Context, userID uuid.UUID, tier entities.KYCTier) error {
+	query := `
+		UPDATE users
+		SET kyc_tier = $2, updated_at = NOW()
+		WHERE id = $1
+	`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, userID, tier)
+	if err != nil {
+		return customerrors.NewInternalError("Failed to update KYC tier", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return customerrors.NewInternalError("Failed to get rows affected", err)
+	}
+
+	if rowsAffected == 0 {
+		return customerrors.NewNotFoundError("User not found")
+	}
+
+	return nil
+}
+
+func (r *postgresUserRepository) UpdateMFA(ctx context.Context, user *entities.User) error {
+	query := `
+		UPDATE users
+		SET mfa_enabled = $2, mfa_secret = $3, mfa_last_used_step = $4, updated_at = NOW()
+		WHERE id = $1
+	`
+	
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, user.ID, user.MFAEnabled, user.MFASecret, user.MFALastUsedStep)
+	if err != nil {
+		return customerrors.NewInternalError("Failed to update MFA settings", err)
+	}
+	
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return customerrors.NewInternalError("Failed to get rows affected", err)
+	}
+	
+	if rowsAffected == 0 {
+		return customerrors.NewNotFoundError("User not found")
+	}
+	
+	return nil
+}
+
+func (r *postgresUserRepository) UpdatePIN(ctx context.Context, user *entities.User) error {
+	query := `
+		UPDATE users
+		SET pin_hash = $2, pin_failed_attempts = $3, pin_locked_until = $4, updated_at = NOW()
+		WHERE id = $1
+	`
+	
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, user.ID, user.PINHash, user.PINFailedAttempts, user.PINLockedUntil)
+	if err != nil {
+		return customerrors.NewInternalError("Failed to update transaction PIN", err)
+	}
+	
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return customerrors.NewInternalError("Failed to get rows affected", err)
+	}
+	
+	if rowsAffected == 0 {
+		return customerrors.NewNotFoundError("User not found")
+	}
+	
+	return nil
+}
+
+func (r *postgresUserRepository) UpdateLoginFailures(ctx context.Context, user *entities.User) error {
+	query := `
+		UPDATE users
+		SET failed_login_attempts = $2, last_failed_login_at = $3, locked_until = $4, updated_at = NOW()
+		WHERE id = $1
+	`
+	
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, user.ID, user.FailedLoginAttempts, user.LastFailedLoginAt, user.LockedUntil)
+	if err != nil {
+		return customerrors.NewInternalError("Failed to update login failures", err)
+	}
+	
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return customerrors.NewInternalError("Failed to get rows affected", err)
+	}
+	
+	if rowsAffected == 0 {
+		return customerrors.NewNotFoundError("User not found")
+	}
+	
+	return nil
+}
+
+func (r *postgresUserRepository) Search(ctx context.Context, filter entities.UserFilter, limit, offset int) ([]*entities.User, error) {
+	where := newUserFilterQuery(filter)
+	query := `
+		SELECT ` + userColumns + `
+		FROM users
+		WHERE ` + where.sql() + `
+		ORDER BY created_at DESC, id DESC
+		LIMIT ` + where.arg(limit) + ` OFFSET ` + where.arg(offset)
+	
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, where.args...)
+	if err != nil {
+		return nil, customerrors.NewInternalError("Failed to search users", err)
+	}
+	defer rows.Close()
+	
+	var users []*entities.User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	
+	if err := rows.Err(); err != nil {
+		return nil, customerrors.NewInternalError("Failed to iterate users", err)
+	}
+	
+	return users, nil
+}
+
+func (r *postgresUserRepository) Count(ctx context.Context, filter entities.UserFilter) (int, error) {
+	var count int
+	where := newUserFilterQuery(filter)
+	query := `SELECT COUNT(*) FROM users WHERE ` + where.sql()
+	
+	if err := conn(ctx, r.db).QueryRowContext(ctx, query, where.args...).Scan(&count); err != nil {
+		return 0, customerrors.NewInternalError("Failed to count users", err)
+	}
+	
+	return count, nil
+}
+
+// newUserFilterQuery translates a user filter into conditions on the users table
+func newUserFilterQuery(filter entities.UserFilter) *filterQuery {
+	q := &filterQuery{}
+	q.add("TRUE")
+	
+	if filter.Status != "" {
+		q.add("status = ?", filter.Status)
+	}
+	if filter.Role != "" {
+		q.add("role = ?", filter.Role)
+	}
+	if filter.Search != "" {
+		pattern := q.arg("%" + escapeLike(filter.Search) + "%")
+		q.add("(email ILIKE " + pattern + " OR first_name || ' ' || last_name ILIKE " + pattern + " OR phone ILIKE " + pattern + ")")
+	}
+	
+	return q
+}
+
 func (r *postgresUserRepository) UpdateBalance(ctx context.Context, userID uuid.UUID, balance decimal.Decimal) error {
 	query := `
 		UPDATE users
@@ -156,7 +389,7 @@ func (r *postgresUserRepository) UpdateBalance(ctx context.Context, userID uuid.
 		WHERE id = $1
 	`
 	
-	result, err := r.db.ExecContext(ctx, query, userID, balance)
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, userID, balance)
 	if err != nil {
 		return customerrors.NewInternalError("Failed to update balance", err)
 	}
@@ -180,7 +413,7 @@ func (r *postgresUserRepository) AddBalance(ctx context.Context, userID uuid.UUI
 		WHERE id = $1
 	`
 	
-	result, err := r.db.ExecContext(ctx, query, userID, amount)
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, userID, amount)
 	if err != nil {
 		return customerrors.NewInternalError("Failed to add balance", err)
 	}
@@ -201,10 +434,10 @@ func (r *postgresUserRepository) SubtractBalance(ctx context.Context, userID uui
 	query := `
 		UPDATE users
 		SET balance = balance - $2, updated_at = NOW()
-		WHERE id = $1 AND balance >= $2
+		WHERE id = $1 AND balance - held_balance >= $2
 	`
 	
-	result, err := r.db.ExecContext(ctx, query, userID, amount)
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, userID, amount)
 	if err != nil {
 		return customerrors.NewInternalError("Failed to subtract balance", err)
 	}
@@ -221,11 +454,61 @@ func (r *postgresUserRepository) SubtractBalance(ctx context.Context, userID uui
 	return nil
 }
 
+// HoldBalance reserves part of the available balance so it can no longer be spent
+func (r *postgresUserRepository) HoldBalance(ctx context.Context, userID uuid.UUID, amount decimal.Decimal) error {
+	query := `
+		UPDATE users
+		SET held_balance = held_balance + $2, updated_at = NOW()
+		WHERE id = $1 AND balance - held_balance >= $2
+	`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, userID, amount)
+	if err != nil {
+		return customerrors.NewInternalError("Failed to hold balance", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return customerrors.NewInternalError("Failed to get rows affected", err)
+	}
+
+	if rowsAffected == 0 {
+		return customerrors.NewValidationError("Insufficient balance")
+	}
+
+	return nil
+}
+
+// ReleaseHold makes a previously held amount available again
+func (r *postgresUserRepository) ReleaseHold(ctx context.Context, userID uuid.UUID, amount decimal.Decimal) error {
+	query := `
+		UPDATE users
+		SET held_balance = held_balance - $2, updated_at = NOW()
+		WHERE id = $1 AND held_balance >= $2
+	`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, userID, amount)
+	if err != nil {
+		return customerrors.NewInternalError("Failed to release held balance", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return customerrors.NewInternalError("Failed to get rows affected", err)
+	}
+
+	if rowsAffected == 0 {
+		return customerrors.NewInternalError("Failed to release held balance", fmt.Errorf("user %s holds less than %s", userID, amount))
+	}
+
+	return nil
+}
+
 func (r *postgresUserRepository) GetBalance(ctx context.Context, userID uuid.UUID) (decimal.Decimal, error) {
 	var balance decimal.Decimal
 	query := `SELECT balance FROM users WHERE id = $1`
 	
-	err := r.db.QueryRowContext(ctx, query, userID).Scan(&balance)
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, userID).Scan(&balance)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return decimal.Zero, customerrors.NewNotFoundError("User not found")
@@ -240,7 +523,7 @@ func (r *postgresUserRepository) CheckEmailExists(ctx context.Context, email str
 	var exists bool
 	query := `SELECT EXISTS(SELECT 1 FROM users WHERE email = $1)`
 	
-	err := r.db.QueryRowContext(ctx, query, email).Scan(&exists)
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, email).Scan(&exists)
 	if err != nil {
 		return false, customerrors.NewInternalError("Failed to check email existence", err)
 	}
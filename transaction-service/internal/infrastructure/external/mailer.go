@@ -0,0 +1,97 @@
+package external
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go-transaction-service/internal/config"
+	"go-transaction-service/internal/usecase"
+	"go.uber.org/zap"
+)
+
+// NewMailer returns the mailer selected by MAIL_DRIVER
+func NewMailer(cfg config.MailConfig, logger *zap.Logger) usecase.Mailer {
+	if cfg.Driver == "smtp" {
+		return NewSMTPMailer(cfg)
+	}
+	return NewLogMailer(cfg, logger)
+}
+
+type smtpMailer struct {
+	config config.MailConfig
+}
+
+// NewSMTPMailer sends email through an SMTP server, with PLAIN authentication when a username is set
+func NewSMTPMailer(cfg config.MailConfig) usecase.Mailer {
+	return &smtpMailer{config: cfg}
+}
+
+func (m *smtpMailer) Send(ctx context.Context, message usecase.MailMessage) error {
+	var auth smtp.Auth
+	if m.config.SMTPUsername != "" {
+		auth = smtp.PlainAuth("", m.config.SMTPUsername, m.config.SMTPPassword, m.config.SMTPHost)
+	}
+
+	addr := net.JoinHostPort(m.config.SMTPHost, m.config.SMTPPort)
+	if err := smtp.SendMail(addr, auth, m.config.From, []string{message.To}, buildMessage(m.config.From, message)); err != nil {
+		return fmt.Errorf("send email to %s: %w", message.To, err)
+	}
+
+	return nil
+}
+
+// Development implementation that never leaves the machine
+type logMailer struct {
+	config config.MailConfig
+	logger *zap.Logger
+}
+
+// NewLogMailer logs every email and, when an outbox directory is configured, writes it there as
+// an .eml file that any mail client can open
+func NewLogMailer(cfg config.MailConfig, logger *zap.Logger) usecase.Mailer {
+	return &logMailer{config: cfg, logger: logger}
+}
+
+func (m *logMailer) Send(ctx context.Context, message usecase.MailMessage) error {
+	m.logger.Info("Mail: Sending email",
+		zap.String("to", message.To),
+		zap.String("subject", message.Subject),
+		zap.String("body", message.Body))
+
+	if m.config.OutboxDir == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(m.config.OutboxDir, 0o755); err != nil {
+		return fmt.Errorf("create mail outbox: %w", err)
+	}
+
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405"), uuid.NewString())
+	if err := os.WriteFile(filepath.Join(m.config.OutboxDir, name), buildMessage(m.config.From, message), 0o600); err != nil {
+		return fmt.Errorf("write email to outbox: %w", err)
+	}
+
+	return nil
+}
+
+// buildMessage renders a plain text RFC 5322 message
+func buildMessage(from string, message usecase.MailMessage) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", message.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", message.Subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(message.Body, "\n", "\r\n"))
+	return b.Bytes()
+}
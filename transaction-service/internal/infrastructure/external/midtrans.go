@@ -1,24 +1,65 @@
 package external
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
-	"strconv"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/midtrans/midtrans-go"
 	"github.com/midtrans/midtrans-go/snap"
+	"github.com/shopspring/decimal"
 	"go-transaction-service/internal/config"
+	"go-transaction-service/internal/domain/entities"
 	"go-transaction-service/internal/usecase"
 	"go.uber.org/zap"
 )
 
+// midtransStatusTimeout bounds a single call to the Midtrans Core API
+const midtransStatusTimeout = 15 * time.Second
+
+// midtransTimeLayout is the timestamp format expected by the Midtrans API
+const midtransTimeLayout = "2006-01-02 15:04:05 -0700"
+
 type midtransPaymentGateway struct {
 	snapClient *snap.Client
+	httpClient *http.Client
 	config     *config.Config
 	logger     *zap.Logger
 }
 
+// midtransStatusResponse is the subset of the Core API status response used by the service
+type midtransStatusResponse struct {
+	StatusCode        string `json:"status_code"`
+	StatusMessage     string `json:"status_message"`
+	TransactionID     string `json:"transaction_id"`
+	OrderID           string `json:"order_id"`
+	GrossAmount       string `json:"gross_amount"`
+	TransactionStatus string `json:"transaction_status"`
+	PaymentType       string `json:"payment_type"`
+	FraudStatus       string `json:"fraud_status"`
+}
+
+// midtransRefundRequest is the body of a Core API refund request
+type midtransRefundRequest struct {
+	RefundKey string `json:"refund_key"`
+	Amount    int64  `json:"amount"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// midtransRefundResponse is the subset of the Core API refund response used by the service
+type midtransRefundResponse struct {
+	midtransStatusResponse
+	RefundAmount string `json:"refund_amount"`
+	RefundKey    string `json:"refund_key"`
+}
+
 func NewMidtransPaymentGateway(config *config.Config, logger *zap.Logger) usecase.PaymentGateway {
 	var env midtrans.EnvironmentType
 	if config.Midtrans.Environment == "production" {
@@ -32,21 +73,25 @@ func NewMidtransPaymentGateway(config *config.Config, logger *zap.Logger) usecas
 
 	return &midtransPaymentGateway{
 		snapClient: &snapClient,
+		httpClient: &http.Client{Timeout: midtransStatusTimeout},
 		config:     config,
 		logger:     logger,
 	}
 }
 
-func (m *midtransPaymentGateway) CreateTopupTransaction(ctx context.Context, userID uuid.UUID, amount string, orderId string) (*usecase.PaymentGatewayResponse, error) {
-	// Convert amount to integer (in cents/rupiah)
-	amountFloat, err := strconv.ParseFloat(amount, 64)
+func (m *midtransPaymentGateway) CreateTopupTransaction(ctx context.Context, userID uuid.UUID, amount string, paymentMethod string, orderId string, expiresAt *time.Time) (*usecase.PaymentGatewayResponse, error) {
+	// Midtrans expects an integer amount in rupiah
+	grossAmount, err := wholeAmount(amount)
 	if err != nil {
 		m.logger.Error("Failed to parse amount", zap.Error(err))
-		return nil, fmt.Errorf("invalid amount format: %w", err)
+		return nil, err
 	}
 
-	// Convert to integer (Midtrans expects integer amount)
-	grossAmount := int64(amountFloat)
+	// Only the method the fee was quoted for is offered on the payment page
+	method, ok := entities.TopupPaymentMethods[paymentMethod]
+	if !ok {
+		return nil, fmt.Errorf("unsupported payment method %q", paymentMethod)
+	}
 
 	// Create Snap transaction request
 	snapReq := &snap.Request{
@@ -58,12 +103,27 @@ func (m *midtransPaymentGateway) CreateTopupTransaction(ctx context.Context, use
 			FName: "User",
 			LName: userID.String(),
 		},
-		EnabledPayments: snap.AllSnapPaymentType,
+		EnabledPayments: []snap.SnapPaymentType{snap.SnapPaymentType(method.Channel)},
 		CreditCard: &snap.CreditCardDetails{
 			Secure: true,
 		},
 	}
 
+	// Snap's counterpart of the Core API custom_expiry. Rounded down so the payment page
+	// never outlives the transaction deadline enforced by the expiry job.
+	if expiresAt != nil {
+		now := time.Now()
+		minutes := int64(expiresAt.Sub(now) / time.Minute)
+		if minutes < 1 {
+			minutes = 1
+		}
+		snapReq.Expiry = &snap.ExpiryDetails{
+			StartTime: now.Format(midtransTimeLayout),
+			Unit:      "minute",
+			Duration:  minutes,
+		}
+	}
+
 	// Create transaction with Midtrans
 	snapResp, err := m.snapClient.CreateTransaction(snapReq)
 	if err != nil {
@@ -88,39 +148,169 @@ func (m *midtransPaymentGateway) CreateTopupTransaction(ctx context.Context, use
 }
 
 func (m *midtransPaymentGateway) GetTransactionStatus(ctx context.Context, orderId string) (*usecase.PaymentGatewayResponse, error) {
-	// In a real implementation, you would call Midtrans API to get transaction status
-	// For now, we'll return a mock response
-	m.logger.Info("Getting transaction status from Midtrans", 
+	m.logger.Info("Getting transaction status from Midtrans",
 		zap.String("order_id", orderId))
 
-	// This would be replaced with actual Midtrans API call
-	// transactionStatusResp, err := m.coreClient.CheckTransaction(orderId)
-	
+	endpoint := strings.TrimRight(m.config.Midtrans.APIURL, "/") + "/v2/" + url.PathEscape(orderId) + "/status"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build status request: %w", err)
+	}
+	req.SetBasicAuth(m.config.Midtrans.ServerKey, "")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		m.logger.Error("Failed to call Midtrans status API",
+			zap.Error(err),
+			zap.String("order_id", orderId))
+		return nil, fmt.Errorf("failed to get payment status: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status from payment gateway: %s", resp.Status)
+	}
+
+	var statusResp midtransStatusResponse
+	if err := json.NewDecoder(resp.Body).Decode(&statusResp); err != nil {
+		return nil, fmt.Errorf("invalid payment status response: %w", err)
+	}
+
+	// Midtrans reports API level errors (e.g. unknown order) in the body with HTTP 200
+	if statusResp.StatusCode == "404" {
+		return nil, fmt.Errorf("%w: %s", usecase.ErrPaymentNotFound, orderId)
+	}
+	if !strings.HasPrefix(statusResp.StatusCode, "2") {
+		m.logger.Warn("Midtrans status lookup rejected",
+			zap.String("order_id", orderId),
+			zap.String("status_code", statusResp.StatusCode),
+			zap.String("status_message", statusResp.StatusMessage))
+		return nil, fmt.Errorf("payment gateway returned %s: %s", statusResp.StatusCode, statusResp.StatusMessage)
+	}
+
 	return &usecase.PaymentGatewayResponse{
-		OrderID:    orderId,
-		Status:     "pending",
-		PaymentURL: "",
-		GatewayID:  "",
+		OrderID:     statusResp.OrderID,
+		Status:      statusResp.TransactionStatus,
+		PaymentURL:  "",
+		GatewayID:   statusResp.TransactionID,
+		GrossAmount: statusResp.GrossAmount,
+		PaymentType: statusResp.PaymentType,
+		FraudStatus: statusResp.FraudStatus,
+	}, nil
+}
+
+func (m *midtransPaymentGateway) Refund(ctx context.Context, orderId string, refundKey string, amount string, reason string) (*usecase.PaymentGatewayResponse, error) {
+	m.logger.Info("Requesting refund from Midtrans",
+		zap.String("order_id", orderId),
+		zap.String("refund_key", refundKey),
+		zap.String("amount", amount))
+
+	// Midtrans expects integer amounts, as for the payment itself
+	refundAmount, err := wholeAmount(amount)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(midtransRefundRequest{
+		RefundKey: refundKey,
+		Amount:    refundAmount,
+		Reason:    reason,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode refund request: %w", err)
+	}
+
+	endpoint := strings.TrimRight(m.config.Midtrans.APIURL, "/") + "/v2/" + url.PathEscape(orderId) + "/refund"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build refund request: %w", err)
+	}
+	req.SetBasicAuth(m.config.Midtrans.ServerKey, "")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		m.logger.Error("Failed to call Midtrans refund API",
+			zap.Error(err),
+			zap.String("order_id", orderId))
+		return nil, fmt.Errorf("failed to request refund: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status from payment gateway: %s", resp.Status)
+	}
+
+	var refundResp midtransRefundResponse
+	if err := json.NewDecoder(resp.Body).Decode(&refundResp); err != nil {
+		return nil, fmt.Errorf("invalid refund response: %w", err)
+	}
+
+	// As with status lookups, API level errors are reported in the body
+	if refundResp.StatusCode == "404" {
+		return nil, fmt.Errorf("%w: %s", usecase.ErrPaymentNotFound, orderId)
+	}
+	if !strings.HasPrefix(refundResp.StatusCode, "2") {
+		m.logger.Warn("Midtrans refund rejected",
+			zap.String("order_id", orderId),
+			zap.String("refund_key", refundKey),
+			zap.String("status_code", refundResp.StatusCode),
+			zap.String("status_message", refundResp.StatusMessage))
+		return nil, fmt.Errorf("payment gateway returned %s: %s", refundResp.StatusCode, refundResp.StatusMessage)
+	}
+
+	return &usecase.PaymentGatewayResponse{
+		OrderID:     refundResp.OrderID,
+		Status:      refundResp.TransactionStatus,
+		PaymentURL:  "",
+		GatewayID:   refundResp.TransactionID,
+		GrossAmount: refundResp.GrossAmount,
 	}, nil
 }
 
-// Mock implementation for testing
+// wholeAmount converts an amount to the integer rupiah Midtrans works with. A fraction is refused
+// rather than dropped, as the gateway would then charge less than the transaction is booked for.
+func wholeAmount(amount string) (int64, error) {
+	value, err := decimal.NewFromString(amount)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount format: %w", err)
+	}
+	if !value.Equal(value.Truncate(0)) {
+		return 0, fmt.Errorf("amount %s is not a whole number of rupiah", amount)
+	}
+	return value.IntPart(), nil
+}
+
+// Mock implementation for testing. Like the real gateway it reports a top-up as pending until
+// its expiry, as expired afterwards, and an order it never created as not found; a payment is
+// only settled by posting a notification to the webhook.
 type mockPaymentGateway struct {
 	logger *zap.Logger
+
+	mu       sync.Mutex
+	expiries map[string]*time.Time // Expiry of every created top-up by order ID, nil when it never expires
 }
 
 func NewMockPaymentGateway(logger *zap.Logger) usecase.PaymentGateway {
 	return &mockPaymentGateway{
-		logger: logger,
+		logger:   logger,
+		expiries: make(map[string]*time.Time),
 	}
 }
 
-func (m *mockPaymentGateway) CreateTopupTransaction(ctx context.Context, userID uuid.UUID, amount string, orderId string) (*usecase.PaymentGatewayResponse, error) {
+func (m *mockPaymentGateway) CreateTopupTransaction(ctx context.Context, userID uuid.UUID, amount string, paymentMethod string, orderId string, expiresAt *time.Time) (*usecase.PaymentGatewayResponse, error) {
 	m.logger.Info("Mock: Creating top-up transaction", 
 		zap.String("user_id", userID.String()),
 		zap.String("amount", amount),
+		zap.String("payment_method", paymentMethod),
 		zap.String("order_id", orderId))
 
+	m.mu.Lock()
+	m.expiries[orderId] = expiresAt
+	m.mu.Unlock()
+
 	// Mock payment URL
 	paymentURL := fmt.Sprintf("https://app.sandbox.midtrans.com/snap/v2/vtweb/%s", orderId)
 
@@ -136,9 +326,35 @@ func (m *mockPaymentGateway) GetTransactionStatus(ctx context.Context, orderId s
 	m.logger.Info("Mock: Getting transaction status", 
 		zap.String("order_id", orderId))
 
+	m.mu.Lock()
+	expiresAt, ok := m.expiries[orderId]
+	m.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", usecase.ErrPaymentNotFound, orderId)
+	}
+
+	status := "pending"
+	if expiresAt != nil && time.Now().After(*expiresAt) {
+		status = "expire"
+	}
+
+	return &usecase.PaymentGatewayResponse{
+		OrderID:    orderId,
+		Status:     status,
+		PaymentURL: "",
+		GatewayID:  "mock-gateway-id-" + orderId,
+	}, nil
+}
+
+func (m *mockPaymentGateway) Refund(ctx context.Context, orderId string, refundKey string, amount string, reason string) (*usecase.PaymentGatewayResponse, error) {
+	m.logger.Info("Mock: Refunding transaction",
+		zap.String("order_id", orderId),
+		zap.String("refund_key", refundKey),
+		zap.String("amount", amount))
+
 	return &usecase.PaymentGatewayResponse{
 		OrderID:    orderId,
-		Status:     "settlement",
+		Status:     "refund",
 		PaymentURL: "",
 		GatewayID:  "mock-gateway-id-" + orderId,
 	}, nil
@@ -0,0 +1,394 @@
+package keyring
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go-transaction-service/internal/config"
+	"go-transaction-service/internal/domain/entities"
+	"go-transaction-service/internal/usecase"
+	"go.uber.org/zap"
+)
+
+// Supported signing algorithms
+const (
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+// rsaKeyBits is the size of generated RSA keys
+const rsaKeyBits = 2048
+
+// kidTimeLayout prefixes generated key IDs so the creation time survives copying the key files
+const kidTimeLayout = "20060102T150405Z"
+
+// reloadCooldown limits how often tokens with an unknown kid can trigger a reload from disk
+const reloadCooldown = 10 * time.Second
+
+var (
+	ErrNoSigningKey = errors.New("no signing key available")
+	ErrUnknownKey   = errors.New("unknown signing key")
+)
+
+// signingKey is one private key of the ring
+type signingKey struct {
+	id        string
+	method    jwt.SigningMethod
+	private   crypto.Signer
+	createdAt time.Time
+}
+
+// fileKeyring keeps the signing keys as PEM files in a directory shared by all instances.
+// The newest key signs; older keys keep verifying tokens until the grace window after they
+// were replaced has passed, then the rotation job removes their files.
+type fileKeyring struct {
+	config config.JWTConfig
+	logger *zap.Logger
+
+	mu   sync.RWMutex
+	keys []*signingKey // Newest first
+
+	reloadMu   sync.Mutex // Serializes reloads triggered by unknown kids
+	lastReload time.Time
+}
+
+// NewFileKeyring loads the keys from the configured directory, creating the directory and a
+// first key when there are none
+func NewFileKeyring(cfg config.JWTConfig, logger *zap.Logger) (usecase.TokenKeyring, error) {
+	switch cfg.Algorithm {
+	case AlgorithmRS256, AlgorithmEdDSA:
+	default:
+		return nil, fmt.Errorf("unsupported JWT algorithm %q", cfg.Algorithm)
+	}
+
+	// A replaced key must outlive every token it signed
+	if cfg.GraceWindow < cfg.ExpireDuration {
+		cfg.GraceWindow = cfg.ExpireDuration
+	}
+
+	if err := os.MkdirAll(cfg.KeysDir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create keys directory: %w", err)
+	}
+
+	k := &fileKeyring{config: cfg, logger: logger}
+	if err := k.load(time.Now()); err != nil {
+		return nil, err
+	}
+
+	if len(k.keys) == 0 {
+		logger.Warn("No JWT signing key found, generating one", zap.String("dir", cfg.KeysDir))
+		if _, err := k.generate(time.Now()); err != nil {
+			return nil, err
+		}
+	}
+
+	return k, nil
+}
+
+func (k *fileKeyring) Sign(claims jwt.Claims) (string, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	if len(k.keys) == 0 {
+		return "", ErrNoSigningKey
+	}
+	key := k.keys[0]
+
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.id
+	return token.SignedString(key.private)
+}
+
+func (k *fileKeyring) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, ErrUnknownKey
+	}
+
+	key := k.find(kid)
+	if key == nil {
+		// Another instance may have rotated; pick up its key without waiting for the next check
+		if err := k.reloadThrottled(time.Now()); err != nil {
+			return nil, err
+		}
+		if key = k.find(kid); key == nil {
+			return nil, ErrUnknownKey
+		}
+	}
+
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %s for key %s", token.Method.Alg(), kid)
+	}
+
+	return key.private.Public(), nil
+}
+
+func (k *fileKeyring) JWKS() entities.JSONWebKeySet {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	set := entities.JSONWebKeySet{Keys: make([]entities.JSONWebKey, 0, len(k.keys))}
+	for _, key := range k.keys {
+		set.Keys = append(set.Keys, key.jwk())
+	}
+	return set
+}
+
+func (k *fileKeyring) Rotate(now time.Time) (bool, error) {
+	if err := k.load(now); err != nil {
+		return false, err
+	}
+	k.prune(now)
+
+	if k.config.RotationInterval <= 0 {
+		return false, nil
+	}
+
+	k.mu.RLock()
+	due := len(k.keys) == 0 || !now.Before(k.keys[0].createdAt.Add(k.config.RotationInterval))
+	k.mu.RUnlock()
+	if !due {
+		return false, nil
+	}
+
+	key, err := k.generate(now)
+	if err != nil {
+		return false, err
+	}
+
+	k.logger.Info("JWT signing key rotated", zap.String("kid", key.id))
+	return true, nil
+}
+
+func (k *fileKeyring) find(kid string) *signingKey {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	for _, key := range k.keys {
+		if key.id == kid {
+			return key
+		}
+	}
+	return nil
+}
+
+// reloadThrottled reloads the keys at most once per cooldown. Concurrent callers wait for a
+// reload in progress instead of starting their own, so a burst of unknown kids reads the
+// directory once.
+func (k *fileKeyring) reloadThrottled(now time.Time) error {
+	k.reloadMu.Lock()
+	defer k.reloadMu.Unlock()
+
+	if now.Sub(k.lastReload) < reloadCooldown {
+		return nil
+	}
+	k.lastReload = now
+	return k.load(now)
+}
+
+// load reads every key file of the directory, leaving out keys whose grace window is over
+func (k *fileKeyring) load(now time.Time) error {
+	keys, err := k.readKeys()
+	if err != nil {
+		return err
+	}
+
+	active, _ := k.split(keys, now)
+
+	k.mu.Lock()
+	k.keys = active
+	k.mu.Unlock()
+
+	return nil
+}
+
+// prune removes the files of keys whose grace window is over
+func (k *fileKeyring) prune(now time.Time) {
+	keys, err := k.readKeys()
+	if err != nil {
+		k.logger.Warn("Failed to read signing keys for cleanup", zap.Error(err))
+		return
+	}
+
+	_, expired := k.split(keys, now)
+	for _, key := range expired {
+		if err := os.Remove(k.path(key.id)); err != nil && !os.IsNotExist(err) {
+			k.logger.Warn("Failed to remove expired signing key", zap.String("kid", key.id), zap.Error(err))
+		} else {
+			k.logger.Info("Expired JWT signing key removed", zap.String("kid", key.id))
+		}
+	}
+}
+
+// readKeys loads the key files of the directory, newest first
+func (k *fileKeyring) readKeys() ([]*signingKey, error) {
+	paths, err := filepath.Glob(filepath.Join(k.config.KeysDir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]*signingKey, 0, len(paths))
+	for _, path := range paths {
+		key, err := loadKey(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load signing key %s: %w", path, err)
+		}
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].createdAt.Equal(keys[j].createdAt) {
+			return keys[i].id > keys[j].id
+		}
+		return keys[i].createdAt.After(keys[j].createdAt)
+	})
+
+	return keys, nil
+}
+
+// split separates keys that still verify from keys whose grace window is over. A key is replaced
+// when its successor is created and verifies until the grace window after that ends.
+func (k *fileKeyring) split(keys []*signingKey, now time.Time) (active, expired []*signingKey) {
+	for i := 1; i < len(keys); i++ {
+		if !now.Before(keys[i-1].createdAt.Add(k.config.GraceWindow)) {
+			return keys[:i], keys[i:]
+		}
+	}
+	return keys, nil
+}
+
+// generate creates a key with the configured algorithm, stores it and makes it the signing key
+func (k *fileKeyring) generate(now time.Time) (*signingKey, error) {
+	var private crypto.Signer
+	var err error
+	switch k.config.Algorithm {
+	case AlgorithmEdDSA:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		private, err = rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate signing key: %w", err)
+	}
+
+	suffix := make([]byte, 2)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, err
+	}
+	id := now.UTC().Format(kidTimeLayout) + "-" + hex.EncodeToString(suffix)
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+
+	// Write then rename so other instances never read a partial file
+	tmp := k.path(id) + ".tmp"
+	if err := os.WriteFile(tmp, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		return nil, fmt.Errorf("failed to write signing key: %w", err)
+	}
+	if err := os.Rename(tmp, k.path(id)); err != nil {
+		os.Remove(tmp)
+		return nil, fmt.Errorf("failed to write signing key: %w", err)
+	}
+
+	key := &signingKey{id: id, method: methodFor(private), private: private, createdAt: now.UTC().Truncate(time.Second)}
+
+	k.mu.Lock()
+	k.keys = append([]*signingKey{key}, k.keys...)
+	k.mu.Unlock()
+
+	return key, nil
+}
+
+func (k *fileKeyring) path(kid string) string {
+	return filepath.Join(k.config.KeysDir, kid+".pem")
+}
+
+// loadKey reads a PKCS#8 or PKCS#1 private key. The key ID is the file name; the creation time
+// comes from a generated key ID or, for keys added by hand, from the file modification time.
+func loadKey(path string) (*signingKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var parsed interface{}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var private crypto.Signer
+	switch key := parsed.(type) {
+	case *rsa.PrivateKey:
+		private = key
+	case ed25519.PrivateKey:
+		private = key
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+
+	id := strings.TrimSuffix(filepath.Base(path), ".pem")
+	createdAt, err := time.Parse(kidTimeLayout, strings.SplitN(id, "-", 2)[0])
+	if err != nil {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		createdAt = info.ModTime()
+	}
+
+	return &signingKey{id: id, method: methodFor(private), private: private, createdAt: createdAt}, nil
+}
+
+func methodFor(private crypto.Signer) jwt.SigningMethod {
+	if _, ok := private.(ed25519.PrivateKey); ok {
+		return jwt.SigningMethodEdDSA
+	}
+	return jwt.SigningMethodRS256
+}
+
+// jwk returns the public part of the key in JWK form
+func (s *signingKey) jwk() entities.JSONWebKey {
+	jwk := entities.JSONWebKey{Use: "sig", Alg: s.method.Alg(), Kid: s.id}
+
+	switch public := s.private.Public().(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(public)
+	}
+
+	return jwk
+}
@@ -0,0 +1,322 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go-transaction-service/internal/config"
+	"go-transaction-service/internal/domain/entities"
+	"go-transaction-service/internal/domain/repositories"
+	"go-transaction-service/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// Mailer delivers transactional email
+type Mailer interface {
+	Send(ctx context.Context, message MailMessage) error
+}
+
+// MailMessage is a plain text email
+type MailMessage struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type AccountUseCase interface {
+	// RequestPasswordReset emails a reset link. Unknown or inactive addresses are ignored silently,
+	// so the response does not reveal which emails have an account.
+	RequestPasswordReset(ctx context.Context, req entities.ForgotPasswordRequest) error
+	// ResetPassword sets a new password with a reset token and ends all sessions of the user
+	ResetPassword(ctx context.Context, req entities.ResetPasswordRequest) error
+	SendEmailVerification(ctx context.Context, userID uuid.UUID) error
+	VerifyEmail(ctx context.Context, req entities.VerifyEmailRequest) error
+	// ChangeEmail replaces the email address after checking the password. The new address is unverified
+	// until its link is opened, and the old address is told about the change. The change is kept when the
+	// emails cannot be sent; the verification link can be requested again.
+	ChangeEmail(ctx context.Context, userID uuid.UUID, req entities.ChangeEmailRequest) (*entities.UserProfile, error)
+}
+
+type accountUseCase struct {
+	userRepo         repositories.UserRepository
+	accountTokenRepo repositories.AccountTokenRepository
+	refreshTokenRepo repositories.RefreshTokenRepository
+	txManager        repositories.TxManager
+	mailer           Mailer
+	config           *config.Config
+	logger           *zap.Logger
+}
+
+func NewAccountUseCase(
+	userRepo repositories.UserRepository,
+	accountTokenRepo repositories.AccountTokenRepository,
+	refreshTokenRepo repositories.RefreshTokenRepository,
+	txManager repositories.TxManager,
+	mailer Mailer,
+	config *config.Config,
+	logger *zap.Logger,
+) AccountUseCase {
+	return &accountUseCase{
+		userRepo:         userRepo,
+		accountTokenRepo: accountTokenRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		txManager:        txManager,
+		mailer:           mailer,
+		config:           config,
+		logger:           logger,
+	}
+}
+
+func (a *accountUseCase) RequestPasswordReset(ctx context.Context, req entities.ForgotPasswordRequest) error {
+	user, err := a.userRepo.GetByEmail(ctx, req.Email)
+	if err != nil {
+		if customerrors.IsNotFoundError(err) {
+			return nil
+		}
+		return err
+	}
+
+	if !user.IsActive() {
+		return nil
+	}
+
+	token, err := a.issueToken(ctx, user, entities.AccountTokenPasswordReset, a.config.Account.PasswordResetTTL)
+	if err != nil {
+		return err
+	}
+
+	return a.send(ctx, MailMessage{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nWe received a request to reset the password of your account. "+
+			"Open the link below to choose a new one:\n\n%s\n\nThe link expires in %s and works once. "+
+			"If you did not ask for this, you can ignore this email.\n",
+			user.FirstName, a.link("reset-password", token), formatTTL(a.config.Account.PasswordResetTTL)),
+	})
+}
+
+func (a *accountUseCase) ResetPassword(ctx context.Context, req entities.ResetPasswordRequest) error {
+	return a.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		token, user, err := a.consumeToken(ctx, req.Token, entities.AccountTokenPasswordReset)
+		if err != nil {
+			return err
+		}
+
+		if !user.IsActive() {
+			return customerrors.NewValidationError("User account is inactive")
+		}
+
+		user.Password = req.Password
+		if err := user.HashPassword(); err != nil {
+			return customerrors.NewInternalError("Failed to hash password", err)
+		}
+		user.UpdatedAt = time.Now()
+		if err := a.userRepo.Update(ctx, user); err != nil {
+			return err
+		}
+
+		// Other links sent before this one must not work any more
+		if err := a.accountTokenRepo.InvalidateForUser(ctx, token.UserID, entities.AccountTokenPasswordReset); err != nil {
+			return err
+		}
+
+		// Whoever knew the old password is logged out
+		return a.refreshTokenRepo.RevokeAllForUser(ctx, user.ID)
+	})
+}
+
+func (a *accountUseCase) SendEmailVerification(ctx context.Context, userID uuid.UUID) error {
+	user, err := a.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if customerrors.IsNotFoundError(err) {
+			return customerrors.NewNotFoundError("User not found")
+		}
+		return customerrors.NewInternalError("Failed to get user", err)
+	}
+
+	if user.IsEmailVerified() {
+		return customerrors.NewConflictError("Email address is already verified")
+	}
+
+	token, err := a.issueToken(ctx, user, entities.AccountTokenEmailVerification, a.config.Account.EmailVerificationTTL)
+	if err != nil {
+		return err
+	}
+
+	return a.send(ctx, MailMessage{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nPlease confirm that %s is your email address by opening the link below:\n\n%s\n\n"+
+			"The link expires in %s.\n",
+			user.FirstName, user.Email, a.link("verify-email", token), formatTTL(a.config.Account.EmailVerificationTTL)),
+	})
+}
+
+func (a *accountUseCase) VerifyEmail(ctx context.Context, req entities.VerifyEmailRequest) error {
+	return a.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		token, user, err := a.consumeToken(ctx, req.Token, entities.AccountTokenEmailVerification)
+		if err != nil {
+			return err
+		}
+
+		// The link confirms the address it was sent to, not one set since
+		if !strings.EqualFold(token.Email, user.Email) {
+			return customerrors.NewValidationError("Invalid or expired token")
+		}
+
+		if user.IsEmailVerified() {
+			return nil
+		}
+
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+		user.UpdatedAt = now
+		if err := a.userRepo.Update(ctx, user); err != nil {
+			return err
+		}
+
+		return a.accountTokenRepo.InvalidateForUser(ctx, user.ID, entities.AccountTokenEmailVerification)
+	})
+}
+
+func (a *accountUseCase) ChangeEmail(ctx context.Context, userID uuid.UUID, req entities.ChangeEmailRequest) (*entities.UserProfile, error) {
+	var user *entities.User
+	var oldEmail string
+	err := a.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		user, err = a.userRepo.GetByIDForUpdate(ctx, userID)
+		if err != nil {
+			if customerrors.IsNotFoundError(err) {
+				return customerrors.NewNotFoundError("User not found")
+			}
+			return customerrors.NewInternalError("Failed to get user", err)
+		}
+
+		if !user.IsActive() {
+			return customerrors.NewForbiddenError("User account is inactive")
+		}
+
+		if !user.CheckPassword(req.Password) {
+			return customerrors.NewValidationError("Invalid password")
+		}
+
+		if strings.EqualFold(req.Email, user.Email) {
+			return customerrors.NewValidationError("New email address is the same as the current one")
+		}
+
+		exists, err := a.userRepo.CheckEmailExists(ctx, req.Email)
+		if err != nil {
+			return customerrors.NewInternalError("Failed to check email existence", err)
+		}
+		if exists {
+			return customerrors.NewConflictError("Email already exists")
+		}
+
+		oldEmail = user.Email
+		user.Email = req.Email
+		user.EmailVerifiedAt = nil
+		user.UpdatedAt = time.Now()
+		return a.userRepo.Update(ctx, user)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	profile := user.ToProfile()
+
+	// The change is committed, so failed emails are only logged. Links sent to the old address
+	// stop working as their email no longer matches.
+	if err := a.SendEmailVerification(ctx, user.ID); err != nil {
+		a.logger.Warn("Failed to send email verification after email change",
+			zap.String("user_id", user.ID.String()), zap.Error(err))
+	}
+
+	err = a.send(ctx, MailMessage{
+		To:      oldEmail,
+		Subject: "Your email address was changed",
+		Body: fmt.Sprintf("Hi %s,\n\nThe email address of your account was changed to %s. "+
+			"If you did not make this change, contact support right away.\n",
+			user.FirstName, user.Email),
+	})
+	if err != nil {
+		a.logger.Warn("Failed to notify the previous address of an email change",
+			zap.String("user_id", user.ID.String()), zap.Error(err))
+	}
+
+	return &profile, nil
+}
+
+// issueToken stores a new token for the user, superseding the unused ones with the same purpose
+func (a *accountUseCase) issueToken(ctx context.Context, user *entities.User, purpose entities.AccountTokenPurpose, ttl time.Duration) (string, error) {
+	token, plainToken, err := entities.NewAccountToken(user, purpose, ttl)
+	if err != nil {
+		return "", customerrors.NewInternalError("Failed to generate account token", err)
+	}
+
+	err = a.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := a.accountTokenRepo.InvalidateForUser(ctx, user.ID, purpose); err != nil {
+			return err
+		}
+
+		return a.accountTokenRepo.Create(ctx, token)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return plainToken, nil
+}
+
+// consumeToken checks a plain token, marks it used and returns it with its owner locked for update
+func (a *accountUseCase) consumeToken(ctx context.Context, plainToken string, purpose entities.AccountTokenPurpose) (*entities.AccountToken, *entities.User, error) {
+	token, err := a.accountTokenRepo.GetByHashForUpdate(ctx, entities.HashAccountToken(plainToken))
+	if err != nil {
+		if customerrors.IsNotFoundError(err) {
+			return nil, nil, customerrors.NewValidationError("Invalid or expired token")
+		}
+		return nil, nil, err
+	}
+
+	if !token.IsUsable(purpose, time.Now()) {
+		return nil, nil, customerrors.NewValidationError("Invalid or expired token")
+	}
+
+	user, err := a.userRepo.GetByIDForUpdate(ctx, token.UserID)
+	if err != nil {
+		if customerrors.IsNotFoundError(err) {
+			return nil, nil, customerrors.NewValidationError("Invalid or expired token")
+		}
+		return nil, nil, customerrors.NewInternalError("Failed to get user", err)
+	}
+
+	if err := a.accountTokenRepo.MarkUsed(ctx, token.ID); err != nil {
+		return nil, nil, err
+	}
+
+	return token, user, nil
+}
+
+func (a *accountUseCase) send(ctx context.Context, message MailMessage) error {
+	if err := a.mailer.Send(ctx, message); err != nil {
+		return customerrors.NewInternalError("Failed to send email", err)
+	}
+
+	return nil
+}
+
+// link returns the URL of a page of the web app that receives the token
+func (a *accountUseCase) link(page, token string) string {
+	return fmt.Sprintf("%s/%s?token=%s", strings.TrimRight(a.config.Account.LinkBaseURL, "/"), page, url.QueryEscape(token))
+}
+
+// formatTTL writes a link lifetime in whole hours or minutes for an email
+func formatTTL(ttl time.Duration) string {
+	if ttl >= time.Hour && ttl%time.Hour == 0 {
+		return fmt.Sprintf("%d hours", int(ttl/time.Hour))
+	}
+	return fmt.Sprintf("%d minutes", int(ttl/time.Minute))
+}
@@ -0,0 +1,315 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"go-transaction-service/internal/domain/entities"
+	"go-transaction-service/internal/domain/repositories"
+	"go-transaction-service/pkg/errors"
+)
+
+// Default and maximum number of users per admin list page
+const (
+	DefaultUserPageSize = 20
+	MaxUserPageSize     = 100
+)
+
+type AdminUseCase interface {
+	ListUsers(ctx context.Context, filter entities.UserFilter, limit, offset int) (*entities.UserListResponse, error)
+	GetUser(ctx context.Context, userID uuid.UUID) (*entities.UserProfile, error)
+	UpdateUserStatus(ctx context.Context, adminID, userID uuid.UUID, req entities.UpdateUserStatusRequest) (*entities.UserProfile, error)
+	UpdateUserRole(ctx context.Context, adminID, userID uuid.UUID, req entities.UpdateUserRoleRequest) (*entities.UserProfile, error)
+	ResetMFA(ctx context.Context, adminID, userID uuid.UUID) (*entities.UserProfile, error)
+	UnlockUser(ctx context.Context, adminID, userID uuid.UUID) (*entities.UserProfile, error)
+	ListSecurityEvents(ctx context.Context, userID uuid.UUID, limit, offset int) (*entities.SecurityEventListResponse, error)
+}
+
+type adminUseCase struct {
+	userRepo          repositories.UserRepository
+	refreshTokenRepo  repositories.RefreshTokenRepository
+	mfaRepo           repositories.MFARepository
+	securityEventRepo repositories.SecurityEventRepository
+	txManager         repositories.TxManager
+}
+
+func NewAdminUseCase(
+	userRepo repositories.UserRepository,
+	refreshTokenRepo repositories.RefreshTokenRepository,
+	mfaRepo repositories.MFARepository,
+	securityEventRepo repositories.SecurityEventRepository,
+	txManager repositories.TxManager,
+) AdminUseCase {
+	return &adminUseCase{
+		userRepo:          userRepo,
+		refreshTokenRepo:  refreshTokenRepo,
+		mfaRepo:           mfaRepo,
+		securityEventRepo: securityEventRepo,
+		txManager:         txManager,
+	}
+}
+
+func (a *adminUseCase) ListUsers(ctx context.Context, filter entities.UserFilter, limit, offset int) (*entities.UserListResponse, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, customerrors.NewValidationError("Invalid user filter")
+	}
+
+	if limit <= 0 {
+		limit = DefaultUserPageSize
+	}
+	if limit > MaxUserPageSize {
+		limit = MaxUserPageSize
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	users, err := a.userRepo.Search(ctx, filter, limit, offset)
+	if err != nil {
+		return nil, customerrors.NewInternalError("Failed to search users", err)
+	}
+
+	total, err := a.userRepo.Count(ctx, filter)
+	if err != nil {
+		return nil, customerrors.NewInternalError("Failed to count users", err)
+	}
+
+	profiles := make([]entities.UserProfile, 0, len(users))
+	for _, user := range users {
+		profiles = append(profiles, user.ToProfile())
+	}
+
+	return &entities.UserListResponse{
+		Users: profiles,
+		Pagination: entities.PaginationResponse{
+			Limit:   limit,
+			Offset:  offset,
+			Count:   len(profiles),
+			Total:   total,
+			HasMore: offset+len(profiles) < total,
+		},
+	}, nil
+}
+
+func (a *adminUseCase) GetUser(ctx context.Context, userID uuid.UUID) (*entities.UserProfile, error) {
+	user, err := a.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if customerrors.IsNotFoundError(err) {
+			return nil, customerrors.NewNotFoundError("User not found")
+		}
+		return nil, customerrors.NewInternalError("Failed to get user", err)
+	}
+
+	profile := user.ToProfile()
+	return &profile, nil
+}
+
+// UpdateUserStatus changes the status of a user. Deactivating or blocking a user also revokes
+// all of their refresh tokens, which ends their sessions: access tokens of an ended session are
+// refused at once.
+func (a *adminUseCase) UpdateUserStatus(ctx context.Context, adminID, userID uuid.UUID, req entities.UpdateUserStatusRequest) (*entities.UserProfile, error) {
+	if !req.Status.IsValid() {
+		return nil, customerrors.NewValidationError("Invalid user status")
+	}
+
+	if adminID == userID {
+		return nil, customerrors.NewValidationError("Cannot change the status of your own account")
+	}
+
+	var user *entities.User
+	err := a.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		user, err = a.userRepo.GetByIDForUpdate(ctx, userID)
+		if err != nil {
+			if customerrors.IsNotFoundError(err) {
+				return customerrors.NewNotFoundError("User not found")
+			}
+			return customerrors.NewInternalError("Failed to get user", err)
+		}
+
+		if user.Status == req.Status {
+			return nil
+		}
+
+		if err := a.userRepo.UpdateStatus(ctx, userID, req.Status); err != nil {
+			return err
+		}
+		user.Status = req.Status
+
+		if !user.IsActive() {
+			if err := a.refreshTokenRepo.RevokeAllForUser(ctx, userID); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	profile := user.ToProfile()
+	return &profile, nil
+}
+
+// UpdateUserRole assigns another role to a user. The role is carried in access tokens, so all
+// sessions of the user are ended and the new role applies from their next login.
+func (a *adminUseCase) UpdateUserRole(ctx context.Context, adminID, userID uuid.UUID, req entities.UpdateUserRoleRequest) (*entities.UserProfile, error) {
+	if !req.Role.IsValid() {
+		return nil, customerrors.NewValidationError("Invalid user role")
+	}
+
+	if adminID == userID {
+		return nil, customerrors.NewValidationError("Cannot change the role of your own account")
+	}
+
+	var user *entities.User
+	err := a.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		user, err = a.userRepo.GetByIDForUpdate(ctx, userID)
+		if err != nil {
+			if customerrors.IsNotFoundError(err) {
+				return customerrors.NewNotFoundError("User not found")
+			}
+			return customerrors.NewInternalError("Failed to get user", err)
+		}
+
+		if user.Role == req.Role {
+			return nil
+		}
+
+		previous := user.Role
+		if err := a.userRepo.UpdateRole(ctx, userID, req.Role); err != nil {
+			return err
+		}
+		user.Role = req.Role
+
+		if err := a.refreshTokenRepo.RevokeAllForUser(ctx, userID); err != nil {
+			return err
+		}
+
+		details := fmt.Sprintf("%s to %s by %s", previous, req.Role, adminID)
+		if req.Reason != "" {
+			details += ": " + req.Reason
+		}
+		event := entities.NewSecurityEvent(entities.SecurityEventRoleChanged, &user.ID, user.Email, entities.ClientInfo{}, details)
+		return a.securityEventRepo.Create(ctx, event)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	profile := user.ToProfile()
+	return &profile, nil
+}
+
+// ResetMFA turns off two-factor authentication for a user who lost their authenticator and
+// recovery codes. Their sessions are revoked, so they have to log in again with the password.
+func (a *adminUseCase) ResetMFA(ctx context.Context, adminID, userID uuid.UUID) (*entities.UserProfile, error) {
+	if adminID == userID {
+		return nil, customerrors.NewValidationError("Cannot reset two-factor authentication of your own account")
+	}
+
+	var user *entities.User
+	err := a.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		user, err = a.userRepo.GetByIDForUpdate(ctx, userID)
+		if err != nil {
+			if customerrors.IsNotFoundError(err) {
+				return customerrors.NewNotFoundError("User not found")
+			}
+			return customerrors.NewInternalError("Failed to get user", err)
+		}
+
+		if !user.MFAEnabled && user.MFASecret == "" {
+			return nil
+		}
+
+		if err := clearMFA(ctx, a.userRepo, a.mfaRepo, user); err != nil {
+			return err
+		}
+
+		return a.refreshTokenRepo.RevokeAllForUser(ctx, userID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	profile := user.ToProfile()
+	return &profile, nil
+}
+
+// UnlockUser lifts a login lockout and clears the failed login attempts of a user before the lock expires
+func (a *adminUseCase) UnlockUser(ctx context.Context, adminID, userID uuid.UUID) (*entities.UserProfile, error) {
+	var user *entities.User
+	err := a.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		user, err = a.userRepo.GetByIDForUpdate(ctx, userID)
+		if err != nil {
+			if customerrors.IsNotFoundError(err) {
+				return customerrors.NewNotFoundError("User not found")
+			}
+			return customerrors.NewInternalError("Failed to get user", err)
+		}
+
+		if !user.HasLoginFailures() {
+			return nil
+		}
+
+		user.ResetLoginFailures()
+		if err := a.userRepo.UpdateLoginFailures(ctx, user); err != nil {
+			return err
+		}
+
+		event := entities.NewSecurityEvent(entities.SecurityEventAccountUnlocked, &user.ID, user.Email, entities.ClientInfo{},
+			fmt.Sprintf("unlocked by %s", adminID))
+		return a.securityEventRepo.Create(ctx, event)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	profile := user.ToProfile()
+	return &profile, nil
+}
+
+func (a *adminUseCase) ListSecurityEvents(ctx context.Context, userID uuid.UUID, limit, offset int) (*entities.SecurityEventListResponse, error) {
+	if limit <= 0 {
+		limit = DefaultUserPageSize
+	}
+	if limit > MaxUserPageSize {
+		limit = MaxUserPageSize
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	if _, err := a.userRepo.GetByID(ctx, userID); err != nil {
+		if customerrors.IsNotFoundError(err) {
+			return nil, customerrors.NewNotFoundError("User not found")
+		}
+		return nil, customerrors.NewInternalError("Failed to get user", err)
+	}
+
+	events, err := a.securityEventRepo.ListByUser(ctx, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := a.securityEventRepo.CountByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entities.SecurityEventListResponse{
+		Events: events,
+		Pagination: entities.PaginationResponse{
+			Limit:   limit,
+			Offset:  offset,
+			Count:   len(events),
+			Total:   total,
+			HasMore: offset+len(events) < total,
+		},
+	}, nil
+}
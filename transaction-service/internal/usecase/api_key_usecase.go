@@ -0,0 +1,218 @@
+package usecase
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go-transaction-service/internal/config"
+	"go-transaction-service/internal/domain/entities"
+	"go-transaction-service/internal/domain/repositories"
+	"go-transaction-service/pkg/errors"
+	"golang.org/x/time/rate"
+)
+
+// apiKeyTouchInterval is how often the last use of an API key is written while it is being used
+const apiKeyTouchInterval = time.Minute
+
+type APIKeyUseCase interface {
+	// CreateAPIKey creates a key acting as the given user and returns its plain value, which is not stored
+	CreateAPIKey(ctx context.Context, adminID uuid.UUID, req entities.CreateAPIKeyRequest) (*entities.CreateAPIKeyResponse, error)
+	ListAPIKeys(ctx context.Context, limit, offset int) (*entities.APIKeyListResponse, error)
+	RevokeAPIKey(ctx context.Context, keyID uuid.UUID) (*entities.APIKey, error)
+	// Authenticate checks a plain API key for a route group and its rate limit, and returns claims
+	// for the key owner as if they had logged in
+	Authenticate(ctx context.Context, key string, scope entities.APIKeyScope) (*entities.JWTClaims, error)
+}
+
+type apiKeyUseCase struct {
+	apiKeyRepo repositories.APIKeyRepository
+	userRepo   repositories.UserRepository
+	config     *config.Config
+
+	// Request rate limiters by API key ID, kept in memory per instance
+	mu       sync.Mutex
+	limiters map[uuid.UUID]*rate.Limiter
+}
+
+func NewAPIKeyUseCase(
+	apiKeyRepo repositories.APIKeyRepository,
+	userRepo repositories.UserRepository,
+	config *config.Config,
+) APIKeyUseCase {
+	return &apiKeyUseCase{
+		apiKeyRepo: apiKeyRepo,
+		userRepo:   userRepo,
+		config:     config,
+		limiters:   make(map[uuid.UUID]*rate.Limiter),
+	}
+}
+
+func (a *apiKeyUseCase) CreateAPIKey(ctx context.Context, adminID uuid.UUID, req entities.CreateAPIKeyRequest) (*entities.CreateAPIKeyResponse, error) {
+	adminScope := false
+	for _, scope := range req.Scopes {
+		if !scope.IsValid() {
+			return nil, customerrors.NewValidationError("Invalid API key scope")
+		}
+		adminScope = adminScope || scope == entities.APIKeyScopeAdmin
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, customerrors.NewValidationError("Expiry must be in the future")
+	}
+
+	owner, err := a.userRepo.GetByID(ctx, req.UserID)
+	if err != nil {
+		if customerrors.IsNotFoundError(err) {
+			return nil, customerrors.NewNotFoundError("User not found")
+		}
+		return nil, customerrors.NewInternalError("Failed to get user", err)
+	}
+
+	if !owner.IsActive() {
+		return nil, customerrors.NewValidationError("API keys can only act as an active user")
+	}
+
+	// Back-office routes check the role of the owner, a wallet user would be refused anyway
+	if adminScope && owner.Role == entities.RoleUser {
+		return nil, customerrors.NewValidationError("The admin scope needs a user with a support or admin role")
+	}
+
+	rateLimit := req.RateLimit
+	if rateLimit <= 0 {
+		rateLimit = a.config.APIKey.DefaultRateLimit
+	}
+
+	apiKey, plain, err := entities.NewAPIKey(owner.ID, adminID, req.Name, req.Scopes, rateLimit, req.ExpiresAt)
+	if err != nil {
+		return nil, customerrors.NewInternalError("Failed to generate API key", err)
+	}
+
+	if err := a.apiKeyRepo.Create(ctx, apiKey); err != nil {
+		return nil, err
+	}
+
+	return &entities.CreateAPIKeyResponse{APIKey: apiKey, Key: plain}, nil
+}
+
+func (a *apiKeyUseCase) ListAPIKeys(ctx context.Context, limit, offset int) (*entities.APIKeyListResponse, error) {
+	if limit <= 0 {
+		limit = DefaultUserPageSize
+	}
+	if limit > MaxUserPageSize {
+		limit = MaxUserPageSize
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	keys, err := a.apiKeyRepo.List(ctx, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := a.apiKeyRepo.Count(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entities.APIKeyListResponse{
+		APIKeys: keys,
+		Pagination: entities.PaginationResponse{
+			Limit:   limit,
+			Offset:  offset,
+			Count:   len(keys),
+			Total:   total,
+			HasMore: offset+len(keys) < total,
+		},
+	}, nil
+}
+
+func (a *apiKeyUseCase) RevokeAPIKey(ctx context.Context, keyID uuid.UUID) (*entities.APIKey, error) {
+	apiKey, err := a.apiKeyRepo.GetByID(ctx, keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if apiKey.IsRevoked() {
+		return apiKey, nil
+	}
+
+	now := time.Now()
+	if err := a.apiKeyRepo.Revoke(ctx, apiKey.ID, now); err != nil {
+		return nil, err
+	}
+	apiKey.RevokedAt = &now
+
+	a.mu.Lock()
+	delete(a.limiters, apiKey.ID)
+	a.mu.Unlock()
+
+	return apiKey, nil
+}
+
+func (a *apiKeyUseCase) Authenticate(ctx context.Context, key string, scope entities.APIKeyScope) (*entities.JWTClaims, error) {
+	apiKey, err := a.apiKeyRepo.GetByHash(ctx, entities.HashAPIKey(key))
+	if err != nil {
+		if customerrors.IsNotFoundError(err) {
+			return nil, customerrors.NewUnauthorizedError("Invalid API key")
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	if apiKey.IsRevoked() {
+		return nil, customerrors.NewUnauthorizedError("API key has been revoked")
+	}
+	if apiKey.IsExpired(now) {
+		return nil, customerrors.NewUnauthorizedError("API key has expired")
+	}
+
+	if !apiKey.HasScope(scope) {
+		return nil, customerrors.NewForbiddenError("API key is not allowed on this route")
+	}
+
+	if !a.limiter(apiKey).AllowN(now, 1) {
+		return nil, customerrors.NewTooManyRequestsError("API key rate limit exceeded")
+	}
+
+	// The key acts with the current role of its owner and stops working with their account
+	owner, err := a.userRepo.GetByID(ctx, apiKey.UserID)
+	if err != nil {
+		if customerrors.IsNotFoundError(err) {
+			return nil, customerrors.NewUnauthorizedError("Invalid API key")
+		}
+		return nil, customerrors.NewInternalError("Failed to get user", err)
+	}
+	if !owner.IsActive() {
+		return nil, customerrors.NewUnauthorizedError("User account is inactive")
+	}
+
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) >= apiKeyTouchInterval {
+		if err := a.apiKeyRepo.Touch(ctx, apiKey.ID, now); err != nil {
+			return nil, err
+		}
+	}
+
+	return &entities.JWTClaims{
+		UserID:   owner.ID,
+		Email:    owner.Email,
+		Role:     owner.Role,
+		APIKeyID: apiKey.ID,
+	}, nil
+}
+
+// limiter returns the request rate limiter of the key, allowing RateLimit requests per minute in bursts of up to RateLimit
+func (a *apiKeyUseCase) limiter(apiKey *entities.APIKey) *rate.Limiter {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	limiter, ok := a.limiters[apiKey.ID]
+	if !ok {
+		limiter = rate.NewLimiter(rate.Limit(float64(apiKey.RateLimit)/60), apiKey.RateLimit)
+		a.limiters[apiKey.ID] = limiter
+	}
+
+	return limiter
+}
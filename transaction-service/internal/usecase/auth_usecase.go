@@ -2,7 +2,7 @@ package usecase
 
 import (
 	"context"
-	"errors"
+	"fmt"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -15,21 +15,66 @@ import (
 
 type AuthUseCase interface {
 	Register(ctx context.Context, req entities.RegisterRequest) (*entities.User, error)
-	Login(ctx context.Context, req entities.LoginRequest) (*entities.LoginResponse, error)
+	// Login returns the tokens, or only an MFA challenge when the account has two-factor authentication enabled.
+	// Repeated wrong passwords slow down and then lock the account, and too many failures from one client IP are refused.
+	Login(ctx context.Context, req entities.LoginRequest, client entities.ClientInfo) (*entities.LoginResponse, *entities.MFAChallengeResponse, error)
+	VerifyMFA(ctx context.Context, req entities.MFAVerifyRequest, client entities.ClientInfo) (*entities.LoginResponse, error)
+	RefreshToken(ctx context.Context, refreshToken string, client entities.ClientInfo) (*entities.LoginResponse, error)
+	Logout(ctx context.Context, claims *entities.JWTClaims) error
+	// ValidateToken checks an access token and that its session has not ended, and records the session as seen
 	ValidateToken(ctx context.Context, tokenString string) (*entities.JWTClaims, error)
+	GetJWKS(ctx context.Context) entities.JSONWebKeySet
 	GetUserByID(ctx context.Context, userID uuid.UUID) (*entities.User, error)
-	GenerateTokenResponse(user *entities.User) (*entities.LoginResponse, error)
+}
+
+// sessionTouchInterval is how often the last use of a session is written while it is being used
+const sessionTouchInterval = time.Minute
+
+// TokenKeyring holds the asymmetric keys that sign and verify access tokens
+type TokenKeyring interface {
+	// Sign signs the claims with the current key and names it in the kid header
+	Sign(claims jwt.Claims) (string, error)
+	// Keyfunc returns the public key named by the kid header of a token, for jwt.Parse
+	Keyfunc(token *jwt.Token) (interface{}, error)
+	// JWKS returns the public keys that currently verify tokens
+	JWKS() entities.JSONWebKeySet
+	// Rotate reloads the keys, removes expired key files and replaces the signing key when it is due
+	Rotate(now time.Time) (bool, error)
 }
 
 type authUseCase struct {
-	userRepo repositories.UserRepository
-	config   *config.Config
+	userRepo          repositories.UserRepository
+	refreshTokenRepo  repositories.RefreshTokenRepository
+	revokedTokenRepo  repositories.RevokedTokenRepository
+	mfaRepo           repositories.MFARepository
+	securityEventRepo repositories.SecurityEventRepository
+	sessionRepo       repositories.SessionRepository
+	txManager         repositories.TxManager
+	keyring           TokenKeyring
+	config            *config.Config
 }
 
-func NewAuthUseCase(userRepo repositories.UserRepository, config *config.Config) AuthUseCase {
+func NewAuthUseCase(
+	userRepo repositories.UserRepository,
+	refreshTokenRepo repositories.RefreshTokenRepository,
+	revokedTokenRepo repositories.RevokedTokenRepository,
+	mfaRepo repositories.MFARepository,
+	securityEventRepo repositories.SecurityEventRepository,
+	sessionRepo repositories.SessionRepository,
+	txManager repositories.TxManager,
+	keyring TokenKeyring,
+	config *config.Config,
+) AuthUseCase {
 	return &authUseCase{
-		userRepo: userRepo,
-		config:   config,
+		userRepo:          userRepo,
+		refreshTokenRepo:  refreshTokenRepo,
+		revokedTokenRepo:  revokedTokenRepo,
+		mfaRepo:           mfaRepo,
+		securityEventRepo: securityEventRepo,
+		sessionRepo:       sessionRepo,
+		txManager:         txManager,
+		keyring:           keyring,
+		config:            config,
 	}
 }
 
@@ -57,43 +102,248 @@ func (a *authUseCase) Register(ctx context.Context, req entities.RegisterRequest
 	return user, nil
 }
 
-func (a *authUseCase) Login(ctx context.Context, req entities.LoginRequest) (*entities.LoginResponse, error) {
+func (a *authUseCase) Login(ctx context.Context, req entities.LoginRequest, client entities.ClientInfo) (*entities.LoginResponse, *entities.MFAChallengeResponse, error) {
+	// Refuse clients that keep failing, whichever accounts they try
+	if err := a.checkClientThrottle(ctx, req.Email, client); err != nil {
+		return nil, nil, err
+	}
+
 	// Get user by email
 	user, err := a.userRepo.GetByEmail(ctx, req.Email)
 	if err != nil {
-		return nil, customerrors.NewUnauthorizedError("Invalid credentials")
+		if customerrors.IsNotFoundError(err) {
+			// Counted against the client IP like a wrong password
+			event := entities.NewSecurityEvent(entities.SecurityEventLoginFailed, nil, req.Email, client, "unknown email")
+			if err := a.securityEventRepo.Create(ctx, event); err != nil {
+				return nil, nil, err
+			}
+		}
+		return nil, nil, customerrors.NewUnauthorizedError("Invalid credentials")
 	}
 
 	// Check if user is active
 	if !user.IsActive() {
-		return nil, customerrors.NewUnauthorizedError("User account is inactive")
+		return nil, nil, customerrors.NewUnauthorizedError("User account is inactive")
+	}
+
+	// A locked or delayed account is refused before the password is even checked
+	if message := a.loginRefusal(user, time.Now()); message != "" {
+		return nil, nil, a.throttle(ctx, user, req.Email, client, message)
 	}
 
 	// Verify password
 	if !user.CheckPassword(req.Password) {
-		return nil, customerrors.NewUnauthorizedError("Invalid credentials")
+		return nil, nil, a.recordLoginFailure(ctx, user.ID, req.Email, client)
 	}
 
-	// Generate JWT token
-	token, expiresAt, err := a.generateJWT(user)
+	// The password alone is not enough: hand out a challenge to complete with a code. Failed
+	// attempts are only cleared once the second factor is verified.
+	if user.MFAEnabled {
+		challenge, plainChallenge, err := entities.NewMFAChallenge(user.ID, a.config.MFA.ChallengeTTL)
+		if err != nil {
+			return nil, nil, customerrors.NewInternalError("Failed to generate MFA challenge", err)
+		}
+
+		if err := a.mfaRepo.CreateChallenge(ctx, challenge); err != nil {
+			return nil, nil, err
+		}
+
+		return nil, &entities.MFAChallengeResponse{
+			MFARequired:    true,
+			ChallengeToken: plainChallenge,
+			ExpiresAt:      challenge.ExpiresAt,
+		}, nil
+	}
+
+	if err := a.recordLoginSuccess(ctx, user, client); err != nil {
+		return nil, nil, err
+	}
+
+	// Start a new session
+	var response *entities.LoginResponse
+	err = a.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		response, err = a.startSession(ctx, user, client)
+		return err
+	})
 	if err != nil {
-		return nil, customerrors.NewInternalError("Failed to generate token", err)
+		return nil, nil, err
 	}
 
-	return &entities.LoginResponse{
-		Token:     token,
-		User:      *user,
-		ExpiresAt: expiresAt,
-	}, nil
+	return response, nil, nil
 }
 
-func (a *authUseCase) ValidateToken(ctx context.Context, tokenString string) (*entities.JWTClaims, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("invalid signing method")
+func (a *authUseCase) VerifyMFA(ctx context.Context, req entities.MFAVerifyRequest, client entities.ClientInfo) (*entities.LoginResponse, error) {
+	var response *entities.LoginResponse
+	// A refused or wrong code is recorded, so the error is only returned after the transaction
+	var refused error
+	err := a.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		challenge, err := a.mfaRepo.GetChallengeByHashForUpdate(ctx, entities.HashMFAChallengeToken(req.ChallengeToken))
+		if err != nil {
+			if customerrors.IsNotFoundError(err) {
+				return customerrors.NewUnauthorizedError("Invalid MFA challenge")
+			}
+			return err
+		}
+
+		if !challenge.IsUsable(time.Now(), a.config.MFA.MaxAttempts) {
+			return customerrors.NewUnauthorizedError("MFA challenge expired, please log in again")
+		}
+
+		user, err := a.userRepo.GetByIDForUpdate(ctx, challenge.UserID)
+		if err != nil {
+			if customerrors.IsNotFoundError(err) {
+				return customerrors.NewUnauthorizedError("Invalid MFA challenge")
+			}
+			return customerrors.NewInternalError("Failed to get user", err)
+		}
+
+		if !user.IsActive() {
+			return customerrors.NewUnauthorizedError("User account is inactive")
+		}
+
+		// Codes are guessed under the same limits as passwords; a refusal is committed with its event
+		refused = a.checkClientThrottle(ctx, user.Email, client)
+		if refused == nil {
+			if message := a.loginRefusal(user, time.Now()); message != "" {
+				refused = a.throttle(ctx, user, user.Email, client, message)
+			}
+		}
+		if refused != nil {
+			if customerrors.IsTooManyRequestsError(refused) {
+				return nil
+			}
+			return refused
+		}
+
+		ok, err := verifySecondFactor(ctx, a.userRepo, a.mfaRepo, user, req.Code)
+		if err != nil {
+			return err
+		}
+
+		// Count the failed attempt against both the challenge and the account
+		if !ok {
+			challenge.Attempts++
+			if err := a.mfaRepo.UpdateChallenge(ctx, challenge); err != nil {
+				return err
+			}
+
+			lockedUntil, err := a.countLoginFailure(ctx, user, user.Email, client, "wrong verification code")
+			if err != nil {
+				return err
+			}
+			refused = loginFailureError(lockedUntil, "Invalid verification code")
+			return nil
+		}
+
+		usedAt := time.Now()
+		challenge.UsedAt = &usedAt
+		if err := a.mfaRepo.UpdateChallenge(ctx, challenge); err != nil {
+			return err
 		}
-		return []byte(a.config.JWT.SecretKey), nil
+
+		if err := a.recordLoginSuccess(ctx, user, client); err != nil {
+			return err
+		}
+
+		// Start a new session
+		response, err = a.startSession(ctx, user, client)
+		return err
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	if refused != nil {
+		return nil, refused
+	}
+
+	return response, nil
+}
+
+func (a *authUseCase) RefreshToken(ctx context.Context, refreshToken string, client entities.ClientInfo) (*entities.LoginResponse, error) {
+	var response *entities.LoginResponse
+	reused := false
+	err := a.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		stored, err := a.refreshTokenRepo.GetByHashForUpdate(ctx, entities.HashRefreshToken(refreshToken))
+		if err != nil {
+			if customerrors.IsNotFoundError(err) {
+				return customerrors.NewUnauthorizedError("Invalid refresh token")
+			}
+			return err
+		}
+
+		// A refresh token is single use: presenting it again means it leaked, so end the whole session.
+		// The revocation must be committed, hence the error is only returned after the transaction.
+		if stored.IsRevoked() {
+			reused = true
+			return a.refreshTokenRepo.RevokeFamily(ctx, stored.FamilyID)
+		}
+
+		if stored.IsExpired(time.Now()) {
+			return customerrors.NewUnauthorizedError("Refresh token expired")
+		}
+
+		user, err := a.userRepo.GetByID(ctx, stored.UserID)
+		if err != nil {
+			if customerrors.IsNotFoundError(err) {
+				return customerrors.NewUnauthorizedError("Invalid refresh token")
+			}
+			return customerrors.NewInternalError("Failed to get user", err)
+		}
+
+		if !user.IsActive() {
+			return customerrors.NewUnauthorizedError("User account is inactive")
+		}
+
+		// Rotate: issue the next token of the session and retire the presented one
+		var next *entities.RefreshToken
+		response, next, err = a.issueTokens(ctx, user, stored.FamilyID)
+		if err != nil {
+			return err
+		}
+
+		if err := a.refreshTokenRepo.MarkReplaced(ctx, stored.ID, next.ID); err != nil {
+			return err
+		}
+
+		// The session follows the client, which may have moved to another network
+		session, err := a.sessionRepo.GetActive(ctx, stored.FamilyID)
+		if err != nil {
+			return err
+		}
+		session.Device = entities.DescribeDevice(client.UserAgent)
+		session.UserAgent = client.UserAgent
+		session.IPAddress = client.IPAddress
+		session.LastSeenAt = time.Now()
+		return a.sessionRepo.UpdateClient(ctx, session)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if reused {
+		return nil, customerrors.NewUnauthorizedError("Refresh token has already been used")
+	}
+
+	return response, nil
+}
+
+func (a *authUseCase) Logout(ctx context.Context, claims *entities.JWTClaims) error {
+	return a.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		// Reject the access token until it expires on its own
+		if err := a.revokedTokenRepo.Revoke(ctx, claims.ID, claims.UserID, claims.ExpiresAt()); err != nil {
+			return err
+		}
+
+		// End the session so it cannot be refreshed either
+		return a.refreshTokenRepo.RevokeFamily(ctx, claims.SessionID)
+	})
+}
+
+func (a *authUseCase) ValidateToken(ctx context.Context, tokenString string) (*entities.JWTClaims, error) {
+	token, err := jwt.Parse(tokenString, a.keyring.Keyfunc,
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}))
 
 	if err != nil {
 		return nil, customerrors.NewUnauthorizedError("Invalid token")
@@ -125,11 +375,58 @@ func (a *authUseCase) ValidateToken(ctx context.Context, tokenString string) (*e
 			return nil, customerrors.NewUnauthorizedError("Invalid token claims")
 		}
 
+		jti, ok := claims["jti"].(string)
+		if !ok || jti == "" {
+			return nil, customerrors.NewUnauthorizedError("Invalid token claims")
+		}
+
+		sid, _ := claims["sid"].(string)
+		sessionID, err := uuid.Parse(sid)
+		if err != nil {
+			return nil, customerrors.NewUnauthorizedError("Invalid token claims")
+		}
+
+		roleStr, _ := claims["role"].(string)
+		role := entities.Role(roleStr)
+		if !role.IsValid() {
+			return nil, customerrors.NewUnauthorizedError("Invalid token claims")
+		}
+
+		// Tokens of a logged out session stay on the revocation list until they expire
+		revoked, err := a.revokedTokenRepo.IsRevoked(ctx, jti)
+		if err != nil {
+			return nil, err
+		}
+		if revoked {
+			return nil, customerrors.NewUnauthorizedError("Token has been revoked")
+		}
+
+		// Tokens of an ended session are refused before they expire
+		session, err := a.sessionRepo.GetActive(ctx, sessionID)
+		if err != nil {
+			if customerrors.IsNotFoundError(err) {
+				return nil, customerrors.NewUnauthorizedError("Session has ended")
+			}
+			return nil, err
+		}
+		if session.UserID != userID {
+			return nil, customerrors.NewUnauthorizedError("Invalid token claims")
+		}
+
+		if now := time.Now(); now.Sub(session.LastSeenAt) >= sessionTouchInterval {
+			if err := a.sessionRepo.Touch(ctx, session.ID, now); err != nil {
+				return nil, err
+			}
+		}
+
 		return &entities.JWTClaims{
-			UserID: userID,
-			Email:  email,
-			Exp:    int64(exp),
-			Iat:    int64(iat),
+			UserID:    userID,
+			Email:     email,
+			Exp:       int64(exp),
+			Iat:       int64(iat),
+			Role:      role,
+			ID:        jti,
+			SessionID: sessionID,
 		}, nil
 	}
 
@@ -145,21 +442,49 @@ func (a *authUseCase) GetUserByID(ctx context.Context, userID uuid.UUID) (*entit
 	return user, nil
 }
 
-func (a *authUseCase) GenerateTokenResponse(user *entities.User) (*entities.LoginResponse, error) {
+// startSession records a login from the client and issues the first tokens of the session
+func (a *authUseCase) startSession(ctx context.Context, user *entities.User, client entities.ClientInfo) (*entities.LoginResponse, error) {
+	session := entities.NewSession(user.ID, client)
+	if err := a.sessionRepo.Create(ctx, session); err != nil {
+		return nil, err
+	}
+
+	response, _, err := a.issueTokens(ctx, user, session.ID)
+	return response, err
+}
+
+// issueTokens stores a new refresh token in the given session and signs a matching access token
+func (a *authUseCase) issueTokens(ctx context.Context, user *entities.User, sessionID uuid.UUID) (*entities.LoginResponse, *entities.RefreshToken, error) {
+	refreshToken, plainRefreshToken, err := entities.NewRefreshToken(user.ID, sessionID, a.config.JWT.RefreshExpireDuration)
+	if err != nil {
+		return nil, nil, customerrors.NewInternalError("Failed to generate refresh token", err)
+	}
+
+	if err := a.refreshTokenRepo.Create(ctx, refreshToken); err != nil {
+		return nil, nil, err
+	}
+
 	// Generate JWT token
-	token, expiresAt, err := a.generateJWT(user)
+	token, expiresAt, err := a.generateJWT(user, sessionID)
 	if err != nil {
-		return nil, customerrors.NewInternalError("Failed to generate token", err)
+		return nil, nil, customerrors.NewInternalError("Failed to generate token", err)
 	}
 
 	return &entities.LoginResponse{
-		Token:     token,
-		User:      *user,
-		ExpiresAt: expiresAt,
-	}, nil
+		Token:                 token,
+		User:                  *user,
+		ExpiresAt:             expiresAt,
+		RefreshToken:          plainRefreshToken,
+		RefreshTokenExpiresAt: refreshToken.ExpiresAt,
+	}, refreshToken, nil
+}
+
+// GetJWKS returns the public keys that verify access tokens
+func (a *authUseCase) GetJWKS(ctx context.Context) entities.JSONWebKeySet {
+	return a.keyring.JWKS()
 }
 
-func (a *authUseCase) generateJWT(user *entities.User) (string, time.Time, error) {
+func (a *authUseCase) generateJWT(user *entities.User, sessionID uuid.UUID) (string, time.Time, error) {
 	expiresAt := time.Now().Add(a.config.JWT.ExpireDuration)
 	
 	claims := jwt.MapClaims{
@@ -167,13 +492,136 @@ func (a *authUseCase) generateJWT(user *entities.User) (string, time.Time, error
 		"email":   user.Email,
 		"exp":     expiresAt.Unix(),
 		"iat":     time.Now().Unix(),
+		"jti":     uuid.NewString(),
+		"sid":     sessionID.String(),
+		// Permissions are informational for clients; the server derives them from the role
+		"role":        user.Role,
+		"permissions": user.Role.Permissions(),
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(a.config.JWT.SecretKey))
+	tokenString, err := a.keyring.Sign(claims)
 	if err != nil {
 		return "", time.Time{}, err
 	}
 
 	return tokenString, expiresAt, nil
 }
+
+// checkClientThrottle refuses the login when the client IP has failed too often within the configured window
+func (a *authUseCase) checkClientThrottle(ctx context.Context, email string, client entities.ClientInfo) error {
+	if client.IPAddress == "" || a.config.Login.IPMaxAttempts <= 0 {
+		return nil
+	}
+
+	failures, err := a.securityEventRepo.CountByIPSince(ctx, entities.SecurityEventLoginFailed, client.IPAddress, time.Now().Add(-a.config.Login.IPWindow))
+	if err != nil {
+		return err
+	}
+
+	if failures < a.config.Login.IPMaxAttempts {
+		return nil
+	}
+
+	return a.throttle(ctx, nil, email, client, "Too many failed login attempts from this address, try again later")
+}
+
+// throttle records the refused attempt and returns the error for the client
+func (a *authUseCase) throttle(ctx context.Context, user *entities.User, email string, client entities.ClientInfo, message string) error {
+	var userID *uuid.UUID
+	if user != nil {
+		userID = &user.ID
+	}
+
+	event := entities.NewSecurityEvent(entities.SecurityEventLoginThrottled, userID, email, client, message)
+	if err := a.securityEventRepo.Create(ctx, event); err != nil {
+		return err
+	}
+
+	return customerrors.NewTooManyRequestsError(message)
+}
+
+// loginRefusal explains why a locked or delayed account cannot attempt a login now, or returns
+// an empty string when it can
+func (a *authUseCase) loginRefusal(user *entities.User, now time.Time) string {
+	if user.IsLoginLocked(now) {
+		return fmt.Sprintf("Too many failed login attempts, account locked until %s", user.LockedUntil.UTC().Format(time.RFC3339))
+	}
+	if retryAt := user.LoginRetryAt(a.config.Login.DelayAfter, a.config.Login.BaseDelay, a.config.Login.MaxDelay); now.Before(retryAt) {
+		return fmt.Sprintf("Too many failed login attempts, try again in %d seconds", int(retryAt.Sub(now).Seconds())+1)
+	}
+	return ""
+}
+
+// recordLoginFailure counts a wrong password, locking the account once the limit is reached
+func (a *authUseCase) recordLoginFailure(ctx context.Context, userID uuid.UUID, email string, client entities.ClientInfo) error {
+	var lockedUntil *time.Time
+	err := a.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		// Lock the row so concurrent failures are all counted
+		user, err := a.userRepo.GetByIDForUpdate(ctx, userID)
+		if err != nil {
+			return customerrors.NewInternalError("Failed to get user", err)
+		}
+
+		lockedUntil, err = a.countLoginFailure(ctx, user, email, client, "wrong password")
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return loginFailureError(lockedUntil, "Invalid credentials")
+}
+
+// countLoginFailure counts a failed attempt of a user locked by the caller and records it as a
+// security event. It returns the end of the lockout when the attempt locked the account.
+func (a *authUseCase) countLoginFailure(ctx context.Context, user *entities.User, email string, client entities.ClientInfo, reason string) (*time.Time, error) {
+	locked := user.RecordLoginFailure(time.Now(), a.config.Login.MaxAttempts, a.config.Login.LockDuration)
+	if err := a.userRepo.UpdateLoginFailures(ctx, user); err != nil {
+		return nil, err
+	}
+
+	event := entities.NewSecurityEvent(entities.SecurityEventLoginFailed, &user.ID, email, client, reason)
+	if err := a.securityEventRepo.Create(ctx, event); err != nil {
+		return nil, err
+	}
+
+	if !locked {
+		return nil, nil
+	}
+
+	event = entities.NewSecurityEvent(entities.SecurityEventAccountLocked, &user.ID, email, client,
+		fmt.Sprintf("locked until %s", user.LockedUntil.UTC().Format(time.RFC3339)))
+	if err := a.securityEventRepo.Create(ctx, event); err != nil {
+		return nil, err
+	}
+
+	return user.LockedUntil, nil
+}
+
+// loginFailureError returns the error for a failed attempt, telling the client when it locked the account
+func loginFailureError(lockedUntil *time.Time, message string) error {
+	if lockedUntil != nil {
+		return customerrors.NewTooManyRequestsError(
+			fmt.Sprintf("Too many failed login attempts, account locked until %s", lockedUntil.UTC().Format(time.RFC3339)))
+	}
+	return customerrors.NewUnauthorizedError(message)
+}
+
+// recordLoginSuccess clears the failed attempts once the login is complete, that is after the
+// password and, when enabled, the second factor were accepted
+func (a *authUseCase) recordLoginSuccess(ctx context.Context, user *entities.User, client entities.ClientInfo) error {
+	if user.HasLoginFailures() {
+		user.ResetLoginFailures()
+		if err := a.userRepo.UpdateLoginFailures(ctx, user); err != nil {
+			return err
+		}
+	}
+
+	details := ""
+	if user.MFAEnabled {
+		details = "second factor verified"
+	}
+
+	event := entities.NewSecurityEvent(entities.SecurityEventLoginSucceeded, &user.ID, user.Email, client, details)
+	return a.securityEventRepo.Create(ctx, event)
+}
@@ -0,0 +1,107 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"go-transaction-service/internal/config"
+	"go-transaction-service/internal/domain/entities"
+	"go-transaction-service/internal/domain/repositories"
+	"go-transaction-service/pkg/errors"
+)
+
+type FeeUseCase interface {
+	// Quote returns the fee charged on a transaction: the one of the matching fee rule, or else the
+	// fee of the configured policy of the transaction type
+	Quote(ctx context.Context, transactionType entities.TransactionType, paymentMethod string, amount decimal.Decimal) (*entities.FeeQuote, error)
+	ListFeeRules(ctx context.Context) ([]*entities.FeeRule, error)
+	CreateFeeRule(ctx context.Context, adminID uuid.UUID, req entities.FeeRuleRequest) (*entities.FeeRule, error)
+	// UpdateFeeRule replaces all settings of a fee rule
+	UpdateFeeRule(ctx context.Context, ruleID uuid.UUID, req entities.FeeRuleRequest) (*entities.FeeRule, error)
+	DeleteFeeRule(ctx context.Context, ruleID uuid.UUID) error
+}
+
+type feeUseCase struct {
+	feeRuleRepo repositories.FeeRuleRepository
+	config      *config.Config
+}
+
+func NewFeeUseCase(feeRuleRepo repositories.FeeRuleRepository, config *config.Config) FeeUseCase {
+	return &feeUseCase{
+		feeRuleRepo: feeRuleRepo,
+		config:      config,
+	}
+}
+
+func (f *feeUseCase) Quote(ctx context.Context, transactionType entities.TransactionType, paymentMethod string, amount decimal.Decimal) (*entities.FeeQuote, error) {
+	if !amount.IsPositive() {
+		return nil, customerrors.NewValidationError("Amount must be greater than 0")
+	}
+
+	quote := &entities.FeeQuote{
+		Type:          transactionType,
+		PaymentMethod: paymentMethod,
+		Amount:        amount,
+		Fee:           decimal.Zero,
+	}
+
+	rule, err := f.feeRuleRepo.FindApplicable(ctx, transactionType, paymentMethod)
+	switch {
+	case err == nil:
+		quote.Fee = rule.Calculate(amount)
+		quote.FeeRuleID = &rule.ID
+	case customerrors.IsNotFoundError(err):
+		quote.Fee = f.config.PolicyFor(string(transactionType)).Fee(amount)
+	default:
+		return nil, customerrors.NewInternalError("Failed to find fee rule", err)
+	}
+
+	// The payment gateway collects top-ups in whole rupiah; rounding the fee up keeps the gross
+	// amount it reports equal to the amount and fee of the transaction
+	if transactionType == entities.TransactionTypeTopup {
+		quote.Fee = quote.Fee.Ceil()
+	}
+
+	quote.Total = amount.Add(quote.Fee)
+	return quote, nil
+}
+
+func (f *feeUseCase) ListFeeRules(ctx context.Context) ([]*entities.FeeRule, error) {
+	return f.feeRuleRepo.List(ctx)
+}
+
+func (f *feeUseCase) CreateFeeRule(ctx context.Context, adminID uuid.UUID, req entities.FeeRuleRequest) (*entities.FeeRule, error) {
+	rule := entities.NewFeeRule(adminID, req)
+	if err := rule.Validate(); err != nil {
+		return nil, customerrors.NewValidationError("Invalid fee rule: " + err.Error())
+	}
+
+	if err := f.feeRuleRepo.Create(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+func (f *feeUseCase) UpdateFeeRule(ctx context.Context, ruleID uuid.UUID, req entities.FeeRuleRequest) (*entities.FeeRule, error) {
+	rule, err := f.feeRuleRepo.GetByID(ctx, ruleID)
+	if err != nil {
+		return nil, err
+	}
+
+	rule.Apply(req)
+	if err := rule.Validate(); err != nil {
+		return nil, customerrors.NewValidationError("Invalid fee rule: " + err.Error())
+	}
+
+	if err := f.feeRuleRepo.Update(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+func (f *feeUseCase) DeleteFeeRule(ctx context.Context, ruleID uuid.UUID) error {
+	return f.feeRuleRepo.Delete(ctx, ruleID)
+}
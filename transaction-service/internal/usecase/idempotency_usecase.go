@@ -0,0 +1,74 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"go-transaction-service/internal/domain/entities"
+	"go-transaction-service/internal/domain/repositories"
+	"go-transaction-service/pkg/errors"
+)
+
+type IdempotencyUseCase interface {
+	// Begin reserves the key for a new request. When the key was already used for the
+	// same request and completed, the stored record is returned with replay set to true.
+	Begin(ctx context.Context, userID uuid.UUID, key, requestHash string) (record *entities.IdempotencyRecord, replay bool, err error)
+	Complete(ctx context.Context, record *entities.IdempotencyRecord, code int, body []byte) error
+	Release(ctx context.Context, record *entities.IdempotencyRecord) error
+}
+
+type idempotencyUseCase struct {
+	idempotencyRepo repositories.IdempotencyRepository
+}
+
+func NewIdempotencyUseCase(idempotencyRepo repositories.IdempotencyRepository) IdempotencyUseCase {
+	return &idempotencyUseCase{
+		idempotencyRepo: idempotencyRepo,
+	}
+}
+
+func (i *idempotencyUseCase) Begin(ctx context.Context, userID uuid.UUID, key, requestHash string) (*entities.IdempotencyRecord, bool, error) {
+	record := entities.NewIdempotencyRecord(userID, key, requestHash)
+
+	reserved, err := i.idempotencyRepo.Reserve(ctx, record)
+	if err != nil {
+		return nil, false, customerrors.NewInternalError("Failed to reserve idempotency key", err)
+	}
+	if reserved {
+		return record, false, nil
+	}
+
+	// Key already known: replay, or reject if it does not describe the same request
+	existing, err := i.idempotencyRepo.Get(ctx, userID, key)
+	if err != nil {
+		return nil, false, customerrors.NewInternalError("Failed to get idempotency key", err)
+	}
+
+	if existing.RequestHash != requestHash {
+		return nil, false, customerrors.NewConflictError("Idempotency-Key has already been used with a different request")
+	}
+
+	if !existing.IsCompleted() {
+		return nil, false, customerrors.NewConflictError("A request with this Idempotency-Key is still being processed")
+	}
+
+	return existing, true, nil
+}
+
+func (i *idempotencyUseCase) Complete(ctx context.Context, record *entities.IdempotencyRecord, code int, body []byte) error {
+	record.Complete(code, body)
+
+	if err := i.idempotencyRepo.SaveResponse(ctx, record); err != nil {
+		return customerrors.NewInternalError("Failed to save idempotent response", err)
+	}
+
+	return nil
+}
+
+func (i *idempotencyUseCase) Release(ctx context.Context, record *entities.IdempotencyRecord) error {
+	if err := i.idempotencyRepo.Delete(ctx, record.UserID, record.Key); err != nil {
+		return customerrors.NewInternalError("Failed to release idempotency key", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,330 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go-transaction-service/internal/config"
+	"go-transaction-service/internal/domain/entities"
+	"go-transaction-service/internal/domain/repositories"
+	"go-transaction-service/pkg/errors"
+)
+
+// ErrBlobNotFound is returned by a BlobStore for a key it holds no blob for
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore keeps uploaded files under slash separated keys
+type BlobStore interface {
+	Put(ctx context.Context, key string, content io.Reader) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+type KYCUseCase interface {
+	// Submit stores the documents and a pending submission for a tier above the current one. A user
+	// has at most one submission waiting for review.
+	Submit(ctx context.Context, userID uuid.UUID, req entities.KYCSubmissionRequest, documents []entities.KYCDocumentUpload) (*entities.KYCSubmission, error)
+	GetStatus(ctx context.Context, userID uuid.UUID) (*entities.KYCStatusResponse, error)
+	ListSubmissions(ctx context.Context, status entities.KYCSubmissionStatus, limit, offset int) (*entities.KYCSubmissionListResponse, error)
+	GetSubmission(ctx context.Context, submissionID uuid.UUID) (*entities.KYCSubmission, error)
+	// OpenDocument returns a document of a submission with its content, which the caller must close
+	OpenDocument(ctx context.Context, submissionID uuid.UUID, kind entities.KYCDocumentKind) (*entities.KYCDocument, io.ReadCloser, error)
+	// ApproveSubmission accepts a pending submission and raises the user to the requested tier
+	ApproveSubmission(ctx context.Context, reviewerID, submissionID uuid.UUID, reason string) (*entities.KYCSubmission, error)
+	// RejectSubmission refuses a pending submission; the reason is shown to the user
+	RejectSubmission(ctx context.Context, reviewerID, submissionID uuid.UUID, reason string) (*entities.KYCSubmission, error)
+}
+
+type kycUseCase struct {
+	kycRepo   repositories.KYCSubmissionRepository
+	userRepo  repositories.UserRepository
+	txManager repositories.TxManager
+	blobStore BlobStore
+	config    *config.Config
+}
+
+func NewKYCUseCase(
+	kycRepo repositories.KYCSubmissionRepository,
+	userRepo repositories.UserRepository,
+	txManager repositories.TxManager,
+	blobStore BlobStore,
+	config *config.Config,
+) KYCUseCase {
+	return &kycUseCase{
+		kycRepo:   kycRepo,
+		userRepo:  userRepo,
+		txManager: txManager,
+		blobStore: blobStore,
+		config:    config,
+	}
+}
+
+func (k *kycUseCase) Submit(ctx context.Context, userID uuid.UUID, req entities.KYCSubmissionRequest, documents []entities.KYCDocumentUpload) (*entities.KYCSubmission, error) {
+	user, err := k.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, customerrors.NewNotFoundError("User not found")
+	}
+
+	if !user.IsActive() {
+		return nil, customerrors.NewValidationError("User account is inactive")
+	}
+
+	if user.KYCTier.AtLeast(req.Tier) {
+		return nil, customerrors.NewValidationError("Invalid KYC submission: " + entities.ErrKYCTierNotHigher.Error())
+	}
+
+	dateOfBirth, err := time.Parse("2006-01-02", req.DateOfBirth)
+	if err != nil || !dateOfBirth.Before(time.Now()) {
+		return nil, customerrors.NewValidationError("Invalid date of birth")
+	}
+
+	latest, err := k.kycRepo.GetLatestByUserID(ctx, userID)
+	if err != nil && !customerrors.IsNotFoundError(err) {
+		return nil, err
+	}
+	if latest != nil && latest.IsPending() {
+		return nil, customerrors.NewConflictError("A KYC submission is already waiting for review")
+	}
+
+	submission := entities.NewKYCSubmission(userID, req, dateOfBirth)
+	if err := k.addDocuments(submission, documents); err != nil {
+		return nil, err
+	}
+
+	// Store the files first; they are removed again when the submission cannot be saved
+	if err := k.storeDocuments(ctx, submission, documents); err != nil {
+		return nil, err
+	}
+
+	if err := k.kycRepo.Create(ctx, submission); err != nil {
+		k.deleteDocuments(ctx, submission.Documents)
+		return nil, err
+	}
+
+	return submission, nil
+}
+
+// addDocuments checks the uploads against the documents required for the requested tier and
+// describes them on the submission
+func (k *kycUseCase) addDocuments(submission *entities.KYCSubmission, documents []entities.KYCDocumentUpload) error {
+	uploaded := make(map[entities.KYCDocumentKind]bool, len(documents))
+	for _, document := range documents {
+		if uploaded[document.Kind] {
+			return customerrors.NewValidationError(fmt.Sprintf("Only one %s document may be uploaded", document.Kind))
+		}
+		uploaded[document.Kind] = true
+
+		if !isKYCDocumentKind(document.Kind) {
+			return customerrors.NewValidationError(fmt.Sprintf("Unknown document %s", document.Kind))
+		}
+		if len(document.Content) == 0 {
+			return customerrors.NewValidationError(fmt.Sprintf("The %s document is empty", document.Kind))
+		}
+		if max := k.config.KYC.MaxDocumentSize; max > 0 && int64(len(document.Content)) > max {
+			return customerrors.NewValidationError(fmt.Sprintf("Documents cannot be larger than %d MB", max>>20))
+		}
+
+		contentType := http.DetectContentType(document.Content)
+		extension, ok := entities.KYCDocumentContentTypes[contentType]
+		if !ok {
+			return customerrors.NewValidationError(fmt.Sprintf("The %s document must be a JPEG, PNG or PDF file", document.Kind))
+		}
+
+		submission.Documents = append(submission.Documents, entities.KYCDocument{
+			Kind:        document.Kind,
+			FileName:    filepath.Base(document.FileName),
+			ContentType: contentType,
+			Size:        int64(len(document.Content)),
+			StorageKey:  fmt.Sprintf("kyc/%s/%s/%s%s", submission.UserID, submission.ID, document.Kind, extension),
+			UploadedAt:  submission.CreatedAt,
+		})
+	}
+
+	for _, kind := range entities.RequiredKYCDocuments(submission.RequestedTier) {
+		if !uploaded[kind] {
+			return customerrors.NewValidationError(fmt.Sprintf("A %s document is required for the %s tier", kind, submission.RequestedTier))
+		}
+	}
+	return nil
+}
+
+func (k *kycUseCase) storeDocuments(ctx context.Context, submission *entities.KYCSubmission, documents []entities.KYCDocumentUpload) error {
+	for i, document := range documents {
+		if err := k.blobStore.Put(ctx, submission.Documents[i].StorageKey, bytes.NewReader(document.Content)); err != nil {
+			k.deleteDocuments(ctx, submission.Documents[:i])
+			return customerrors.NewInternalError("Failed to store KYC document", err)
+		}
+	}
+	return nil
+}
+
+// deleteDocuments removes stored files on a best-effort basis; a leftover file is only unreferenced
+func (k *kycUseCase) deleteDocuments(ctx context.Context, documents []entities.KYCDocument) {
+	for _, document := range documents {
+		_ = k.blobStore.Delete(ctx, document.StorageKey)
+	}
+}
+
+func isKYCDocumentKind(kind entities.KYCDocumentKind) bool {
+	for _, known := range entities.KYCDocumentKinds {
+		if kind == known {
+			return true
+		}
+	}
+	return false
+}
+
+func (k *kycUseCase) GetStatus(ctx context.Context, userID uuid.UUID) (*entities.KYCStatusResponse, error) {
+	user, err := k.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, customerrors.NewNotFoundError("User not found")
+	}
+
+	latest, err := k.kycRepo.GetLatestByUserID(ctx, userID)
+	if err != nil && !customerrors.IsNotFoundError(err) {
+		return nil, err
+	}
+
+	features := k.config.KYC.PolicyFor(string(user.KYCTier)).Features
+	if features == nil {
+		features = []string{}
+	}
+
+	return &entities.KYCStatusResponse{
+		Tier:       user.KYCTier,
+		Features:   features,
+		Submission: latest,
+	}, nil
+}
+
+func (k *kycUseCase) ListSubmissions(ctx context.Context, status entities.KYCSubmissionStatus, limit, offset int) (*entities.KYCSubmissionListResponse, error) {
+	if status != "" && !status.IsValid() {
+		return nil, customerrors.NewValidationError("Invalid submission status")
+	}
+	if limit <= 0 {
+		limit = DefaultUserPageSize
+	}
+	if limit > MaxUserPageSize {
+		limit = MaxUserPageSize
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	submissions, err := k.kycRepo.List(ctx, status, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := k.kycRepo.Count(ctx, status)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entities.KYCSubmissionListResponse{
+		Submissions: submissions,
+		Pagination: entities.PaginationResponse{
+			Limit:   limit,
+			Offset:  offset,
+			Count:   len(submissions),
+			Total:   total,
+			HasMore: offset+len(submissions) < total,
+		},
+	}, nil
+}
+
+func (k *kycUseCase) GetSubmission(ctx context.Context, submissionID uuid.UUID) (*entities.KYCSubmission, error) {
+	return k.kycRepo.GetByID(ctx, submissionID)
+}
+
+func (k *kycUseCase) OpenDocument(ctx context.Context, submissionID uuid.UUID, kind entities.KYCDocumentKind) (*entities.KYCDocument, io.ReadCloser, error) {
+	submission, err := k.kycRepo.GetByID(ctx, submissionID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	document, ok := submission.Document(kind)
+	if !ok {
+		return nil, nil, customerrors.NewNotFoundError("KYC document not found")
+	}
+
+	content, err := k.blobStore.Open(ctx, document.StorageKey)
+	if err != nil {
+		if errors.Is(err, ErrBlobNotFound) {
+			return nil, nil, customerrors.NewNotFoundError("KYC document not found")
+		}
+		return nil, nil, customerrors.NewInternalError("Failed to open KYC document", err)
+	}
+
+	return document, content, nil
+}
+
+func (k *kycUseCase) ApproveSubmission(ctx context.Context, reviewerID, submissionID uuid.UUID, reason string) (*entities.KYCSubmission, error) {
+	var submission *entities.KYCSubmission
+	err := k.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		submission, err = k.kycRepo.GetByIDForUpdate(ctx, submissionID)
+		if err != nil {
+			return err
+		}
+
+		user, err := k.userRepo.GetByIDForUpdate(ctx, submission.UserID)
+		if err != nil {
+			return customerrors.NewNotFoundError("User not found")
+		}
+
+		if err := submission.Approve(reviewerID, reason); err != nil {
+			return customerrors.NewConflictError("KYC submission has already been reviewed")
+		}
+
+		if err := k.kycRepo.UpdateReview(ctx, submission); err != nil {
+			return err
+		}
+
+		// An approval never lowers a tier reached in the meantime
+		if user.KYCTier.AtLeast(submission.RequestedTier) {
+			return nil
+		}
+
+		return k.userRepo.UpdateKYCTier(ctx, user.ID, submission.RequestedTier)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return submission, nil
+}
+
+func (k *kycUseCase) RejectSubmission(ctx context.Context, reviewerID, submissionID uuid.UUID, reason string) (*entities.KYCSubmission, error) {
+	if strings.TrimSpace(reason) == "" {
+		return nil, customerrors.NewValidationError("A reason is required to reject a KYC submission")
+	}
+
+	var submission *entities.KYCSubmission
+	err := k.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		submission, err = k.kycRepo.GetByIDForUpdate(ctx, submissionID)
+		if err != nil {
+			return err
+		}
+
+		if err := submission.Reject(reviewerID, reason); err != nil {
+			return customerrors.NewConflictError("KYC submission has already been reviewed")
+		}
+
+		return k.kycRepo.UpdateReview(ctx, submission)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return submission, nil
+}
@@ -0,0 +1,251 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"go-transaction-service/internal/config"
+	"go-transaction-service/internal/domain/entities"
+	"go-transaction-service/internal/domain/repositories"
+	"go-transaction-service/pkg/errors"
+)
+
+// limitedTransactionTypes are the transaction types that limits apply to
+var limitedTransactionTypes = []entities.TransactionType{
+	entities.TransactionTypeTopup,
+	entities.TransactionTypePayment,
+	entities.TransactionTypeTransfer,
+}
+
+type LimitUseCase interface {
+	// CheckTransaction refuses a transaction of the user that is outside the per-transaction
+	// minimum and maximum or would exceed a daily or monthly cap. The caller must hold the lock on
+	// the user row so that concurrent requests cannot both slip under a cap.
+	CheckTransaction(ctx context.Context, user *entities.User, transactionType entities.TransactionType, amount decimal.Decimal) error
+	// CheckBalance refuses incoming funds that would raise the balance of the user above its
+	// maximum once they and the funds still on their way in, such as unsettled top-ups, arrive
+	CheckBalance(ctx context.Context, user *entities.User, incoming decimal.Decimal) error
+	// CheckCredit refuses crediting funds that were counted when they were started but would still
+	// raise the balance above the maximum, e.g. because the maximum was lowered in the meantime.
+	// The caller must hold the lock on the user row.
+	CheckCredit(ctx context.Context, user *entities.User, amount decimal.Decimal) error
+	GetUserLimits(ctx context.Context, userID uuid.UUID) (*entities.UserLimitsResponse, error)
+	// UpdateUserLimits replaces the limit overrides of a user
+	UpdateUserLimits(ctx context.Context, adminID, userID uuid.UUID, req entities.UpdateUserLimitsRequest) (*entities.UserLimitsResponse, error)
+	// ResetUserLimits removes the limit overrides of a user, who falls back to the global limits
+	ResetUserLimits(ctx context.Context, userID uuid.UUID) error
+}
+
+type limitUseCase struct {
+	userLimitRepo   repositories.UserLimitRepository
+	userRepo        repositories.UserRepository
+	transactionRepo repositories.TransactionRepository
+	config          *config.Config
+}
+
+func NewLimitUseCase(
+	userLimitRepo repositories.UserLimitRepository,
+	userRepo repositories.UserRepository,
+	transactionRepo repositories.TransactionRepository,
+	config *config.Config,
+) LimitUseCase {
+	return &limitUseCase{
+		userLimitRepo:   userLimitRepo,
+		userRepo:        userRepo,
+		transactionRepo: transactionRepo,
+		config:          config,
+	}
+}
+
+func (l *limitUseCase) CheckTransaction(ctx context.Context, user *entities.User, transactionType entities.TransactionType, amount decimal.Decimal) error {
+	overrides, err := l.findOverrides(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	limits := l.transactionLimits(user.KYCTier, transactionType, overrides)
+	if limits.MinAmount.IsPositive() && amount.LessThan(limits.MinAmount) {
+		return customerrors.NewLimitExceededError(entities.LimitReasonMinAmount,
+			fmt.Sprintf("Minimum %s amount is %s", transactionType, limits.MinAmount))
+	}
+	if limits.MaxAmount.IsPositive() && amount.GreaterThan(limits.MaxAmount) {
+		return customerrors.NewLimitExceededError(entities.LimitReasonMaxAmount,
+			fmt.Sprintf("Maximum %s amount is %s", transactionType, limits.MaxAmount))
+	}
+
+	if !limits.HasCaps() {
+		return nil
+	}
+
+	now := time.Now()
+	usage, err := l.transactionRepo.GetUsage(ctx, user.ID, transactionType, startOfDay(now), startOfMonth(now))
+	if err != nil {
+		return err
+	}
+
+	switch reason := limits.Exceeded(*usage, amount); reason {
+	case entities.LimitReasonDailyCount:
+		return customerrors.NewLimitExceededError(reason, fmt.Sprintf("Daily limit of %d %s transactions reached", limits.DailyCount, transactionType))
+	case entities.LimitReasonDailyAmount:
+		return customerrors.NewLimitExceededError(reason, fmt.Sprintf("Daily %s limit of %s exceeded", transactionType, limits.DailyAmount))
+	case entities.LimitReasonMonthlyCount:
+		return customerrors.NewLimitExceededError(reason, fmt.Sprintf("Monthly limit of %d %s transactions reached", limits.MonthlyCount, transactionType))
+	case entities.LimitReasonMonthlyAmount:
+		return customerrors.NewLimitExceededError(reason, fmt.Sprintf("Monthly %s limit of %s exceeded", transactionType, limits.MonthlyAmount))
+	}
+
+	return nil
+}
+
+func (l *limitUseCase) CheckBalance(ctx context.Context, user *entities.User, incoming decimal.Decimal) error {
+	return l.checkMaxBalance(ctx, user, incoming, true)
+}
+
+func (l *limitUseCase) CheckCredit(ctx context.Context, user *entities.User, amount decimal.Decimal) error {
+	return l.checkMaxBalance(ctx, user, amount, false)
+}
+
+// checkMaxBalance compares the balance the user would reach with its maximum; with withPending the
+// funds still on their way into the wallet are added as well
+func (l *limitUseCase) checkMaxBalance(ctx context.Context, user *entities.User, incoming decimal.Decimal, withPending bool) error {
+	overrides, err := l.findOverrides(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	maxBalance := l.maxBalance(user.KYCTier, overrides)
+	if !maxBalance.IsPositive() {
+		return nil
+	}
+
+	balance := user.Balance.Add(incoming)
+	if withPending {
+		pending, err := l.transactionRepo.SumPendingIncoming(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+		balance = balance.Add(pending)
+	}
+
+	if balance.GreaterThan(maxBalance) {
+		return customerrors.NewLimitExceededError(entities.LimitReasonMaxBalance,
+			fmt.Sprintf("Wallet balance cannot exceed %s", maxBalance))
+	}
+
+	return nil
+}
+
+func (l *limitUseCase) GetUserLimits(ctx context.Context, userID uuid.UUID) (*entities.UserLimitsResponse, error) {
+	user, err := l.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, customerrors.NewNotFoundError("User not found")
+	}
+
+	overrides, err := l.findOverrides(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return l.buildResponse(user, overrides), nil
+}
+
+func (l *limitUseCase) UpdateUserLimits(ctx context.Context, adminID, userID uuid.UUID, req entities.UpdateUserLimitsRequest) (*entities.UserLimitsResponse, error) {
+	user, err := l.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, customerrors.NewNotFoundError("User not found")
+	}
+
+	overrides := entities.NewUserLimits(userID, adminID, req)
+	if err := overrides.Validate(); err != nil {
+		return nil, customerrors.NewValidationError("Invalid limits: " + err.Error())
+	}
+
+	if err := l.userLimitRepo.Save(ctx, overrides); err != nil {
+		return nil, err
+	}
+
+	return l.buildResponse(user, overrides), nil
+}
+
+func (l *limitUseCase) ResetUserLimits(ctx context.Context, userID uuid.UUID) error {
+	if _, err := l.userRepo.GetByID(ctx, userID); err != nil {
+		return customerrors.NewNotFoundError("User not found")
+	}
+
+	return l.userLimitRepo.Delete(ctx, userID)
+}
+
+// findOverrides returns the limit overrides of a user, nil when the global limits apply
+func (l *limitUseCase) findOverrides(ctx context.Context, userID uuid.UUID) (*entities.UserLimits, error) {
+	overrides, err := l.userLimitRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		if customerrors.IsNotFoundError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return overrides, nil
+}
+
+// transactionLimits returns the global limits of a transaction type, tightened by the caps of the
+// user's KYC tier, with the user's overrides applied
+func (l *limitUseCase) transactionLimits(tier entities.KYCTier, transactionType entities.TransactionType, overrides *entities.UserLimits) entities.TransactionLimits {
+	policy := l.config.PolicyFor(string(transactionType))
+	tierPolicy := l.config.KYC.PolicyFor(string(tier))
+	limits := entities.TransactionLimits{
+		MinAmount:     policy.MinAmount,
+		MaxAmount:     tighterLimit(policy.MaxAmount, tierPolicy.MaxAmount),
+		DailyAmount:   tighterLimit(policy.DailyLimit, tierPolicy.DailyLimit),
+		DailyCount:    policy.DailyCount,
+		MonthlyAmount: tighterLimit(policy.MonthlyLimit, tierPolicy.MonthlyLimit),
+		MonthlyCount:  policy.MonthlyCount,
+	}
+
+	if overrides != nil {
+		if override, ok := overrides.Transactions[transactionType]; ok {
+			limits = override.Apply(limits)
+		}
+	}
+
+	return limits
+}
+
+func (l *limitUseCase) maxBalance(tier entities.KYCTier, overrides *entities.UserLimits) decimal.Decimal {
+	if overrides != nil && overrides.MaxBalance != nil {
+		return *overrides.MaxBalance
+	}
+	return tighterLimit(l.config.Wallet.MaxBalance, l.config.KYC.PolicyFor(string(tier)).MaxBalance)
+}
+
+func (l *limitUseCase) buildResponse(user *entities.User, overrides *entities.UserLimits) *entities.UserLimitsResponse {
+	response := &entities.UserLimitsResponse{
+		UserID:       user.ID,
+		KYCTier:      user.KYCTier,
+		MaxBalance:   l.maxBalance(user.KYCTier, overrides),
+		Transactions: make(map[entities.TransactionType]entities.TransactionLimits, len(limitedTransactionTypes)),
+		Overrides:    overrides,
+	}
+
+	for _, transactionType := range limitedTransactionTypes {
+		response.Transactions[transactionType] = l.transactionLimits(user.KYCTier, transactionType, overrides)
+	}
+
+	return response
+}
+
+// tighterLimit returns the lower of two limits where zero means no limit
+func tighterLimit(a, b decimal.Decimal) decimal.Decimal {
+	if !a.IsPositive() || (b.IsPositive() && b.LessThan(a)) {
+		return b
+	}
+	return a
+}
+
+// startOfMonth returns midnight of the first day of the given month in its location
+func startOfMonth(now time.Time) time.Time {
+	year, month, _ := now.Date()
+	return time.Date(year, month, 1, 0, 0, 0, 0, now.Location())
+}
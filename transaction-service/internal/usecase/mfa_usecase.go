@@ -0,0 +1,239 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"go-transaction-service/internal/config"
+	"go-transaction-service/internal/domain/entities"
+	"go-transaction-service/internal/domain/repositories"
+	"go-transaction-service/pkg/errors"
+	"go-transaction-service/pkg/totp"
+)
+
+type MFAUseCase interface {
+	// BeginEnrollment stores a new pending TOTP secret; MFA is enabled once a code from it is confirmed
+	BeginEnrollment(ctx context.Context, userID uuid.UUID) (*entities.MFAEnrollmentResponse, error)
+	ConfirmEnrollment(ctx context.Context, userID uuid.UUID, req entities.MFACodeRequest) (*entities.MFARecoveryCodesResponse, error)
+	RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, req entities.MFACodeRequest) (*entities.MFARecoveryCodesResponse, error)
+	Disable(ctx context.Context, userID uuid.UUID, req entities.MFADisableRequest) error
+}
+
+type mfaUseCase struct {
+	userRepo  repositories.UserRepository
+	mfaRepo   repositories.MFARepository
+	txManager repositories.TxManager
+	config    *config.Config
+}
+
+func NewMFAUseCase(
+	userRepo repositories.UserRepository,
+	mfaRepo repositories.MFARepository,
+	txManager repositories.TxManager,
+	config *config.Config,
+) MFAUseCase {
+	return &mfaUseCase{
+		userRepo:  userRepo,
+		mfaRepo:   mfaRepo,
+		txManager: txManager,
+		config:    config,
+	}
+}
+
+func (m *mfaUseCase) BeginEnrollment(ctx context.Context, userID uuid.UUID) (*entities.MFAEnrollmentResponse, error) {
+	var response *entities.MFAEnrollmentResponse
+	err := m.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		user, err := m.lockUser(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		if user.MFAEnabled {
+			return customerrors.NewConflictError("Two-factor authentication is already enabled")
+		}
+
+		secret, err := totp.GenerateSecret()
+		if err != nil {
+			return customerrors.NewInternalError("Failed to generate TOTP secret", err)
+		}
+
+		// Starting again replaces a secret that was never confirmed
+		user.MFASecret = secret
+		user.MFALastUsedStep = 0
+		if err := m.userRepo.UpdateMFA(ctx, user); err != nil {
+			return err
+		}
+
+		response = &entities.MFAEnrollmentResponse{
+			Secret:     secret,
+			OTPAuthURI: totp.URI(m.config.MFA.Issuer, user.Email, secret),
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func (m *mfaUseCase) ConfirmEnrollment(ctx context.Context, userID uuid.UUID, req entities.MFACodeRequest) (*entities.MFARecoveryCodesResponse, error) {
+	var response *entities.MFARecoveryCodesResponse
+	err := m.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		user, err := m.lockUser(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		if user.MFAEnabled {
+			return customerrors.NewConflictError("Two-factor authentication is already enabled")
+		}
+		if user.MFASecret == "" {
+			return customerrors.NewValidationError("Two-factor enrollment has not been started")
+		}
+
+		step, ok := totp.Validate(user.MFASecret, req.Code, time.Now(), user.MFALastUsedStep)
+		if !ok {
+			return customerrors.NewValidationError("Invalid verification code")
+		}
+
+		user.MFAEnabled = true
+		user.MFALastUsedStep = step
+		if err := m.userRepo.UpdateMFA(ctx, user); err != nil {
+			return err
+		}
+
+		response, err = m.replaceRecoveryCodes(ctx, user.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// RegenerateRecoveryCodes invalidates the remaining recovery codes and issues a new set.
+// Only a TOTP code is accepted, so a leaked recovery code cannot be used to mint new ones.
+func (m *mfaUseCase) RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, req entities.MFACodeRequest) (*entities.MFARecoveryCodesResponse, error) {
+	var response *entities.MFARecoveryCodesResponse
+	err := m.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		user, err := m.lockUser(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		if !user.MFAEnabled {
+			return customerrors.NewValidationError("Two-factor authentication is not enabled")
+		}
+
+		step, ok := totp.Validate(user.MFASecret, req.Code, time.Now(), user.MFALastUsedStep)
+		if !ok {
+			return customerrors.NewValidationError("Invalid verification code")
+		}
+
+		user.MFALastUsedStep = step
+		if err := m.userRepo.UpdateMFA(ctx, user); err != nil {
+			return err
+		}
+
+		response, err = m.replaceRecoveryCodes(ctx, user.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func (m *mfaUseCase) Disable(ctx context.Context, userID uuid.UUID, req entities.MFADisableRequest) error {
+	return m.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		user, err := m.lockUser(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		if !user.MFAEnabled {
+			return customerrors.NewValidationError("Two-factor authentication is not enabled")
+		}
+
+		if !user.CheckPassword(req.Password) {
+			return customerrors.NewValidationError("Invalid password")
+		}
+
+		ok, err := verifySecondFactor(ctx, m.userRepo, m.mfaRepo, user, req.Code)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return customerrors.NewValidationError("Invalid verification code")
+		}
+
+		return clearMFA(ctx, m.userRepo, m.mfaRepo, user)
+	})
+}
+
+func (m *mfaUseCase) lockUser(ctx context.Context, userID uuid.UUID) (*entities.User, error) {
+	user, err := m.userRepo.GetByIDForUpdate(ctx, userID)
+	if err != nil {
+		if customerrors.IsNotFoundError(err) {
+			return nil, customerrors.NewNotFoundError("User not found")
+		}
+		return nil, customerrors.NewInternalError("Failed to get user", err)
+	}
+
+	return user, nil
+}
+
+func (m *mfaUseCase) replaceRecoveryCodes(ctx context.Context, userID uuid.UUID) (*entities.MFARecoveryCodesResponse, error) {
+	codes, plain, err := entities.NewRecoveryCodes(userID, m.config.MFA.RecoveryCodeCount)
+	if err != nil {
+		return nil, customerrors.NewInternalError("Failed to generate recovery codes", err)
+	}
+
+	if err := m.mfaRepo.ReplaceRecoveryCodes(ctx, userID, codes); err != nil {
+		return nil, err
+	}
+
+	return &entities.MFARecoveryCodesResponse{RecoveryCodes: plain}, nil
+}
+
+// verifySecondFactor checks a TOTP code or an unused recovery code of a user locked for update.
+// A matching TOTP step is stored so the code cannot be replayed, a matching recovery code is used up.
+func verifySecondFactor(ctx context.Context, userRepo repositories.UserRepository, mfaRepo repositories.MFARepository, user *entities.User, code string) (bool, error) {
+	if entities.IsRecoveryCode(code) {
+		codes, err := mfaRepo.GetUnusedRecoveryCodes(ctx, user.ID)
+		if err != nil {
+			return false, err
+		}
+
+		for _, recoveryCode := range codes {
+			if recoveryCode.Matches(code) {
+				return true, mfaRepo.MarkRecoveryCodeUsed(ctx, recoveryCode.ID)
+			}
+		}
+		return false, nil
+	}
+
+	step, ok := totp.Validate(user.MFASecret, code, time.Now(), user.MFALastUsedStep)
+	if !ok {
+		return false, nil
+	}
+
+	user.MFALastUsedStep = step
+	return true, userRepo.UpdateMFA(ctx, user)
+}
+
+// clearMFA turns two-factor authentication off and removes the secret and recovery codes
+func clearMFA(ctx context.Context, userRepo repositories.UserRepository, mfaRepo repositories.MFARepository, user *entities.User) error {
+	user.MFAEnabled = false
+	user.MFASecret = ""
+	user.MFALastUsedStep = 0
+	if err := userRepo.UpdateMFA(ctx, user); err != nil {
+		return err
+	}
+
+	return mfaRepo.DeleteRecoveryCodes(ctx, user.ID)
+}
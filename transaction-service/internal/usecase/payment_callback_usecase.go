@@ -0,0 +1,115 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/shopspring/decimal"
+	"go-transaction-service/internal/domain/entities"
+	"go-transaction-service/internal/domain/repositories"
+	"go-transaction-service/pkg/errors"
+)
+
+type PaymentCallbackUseCase interface {
+	// HandleCallback verifies, audits and applies a raw payment gateway notification
+	HandleCallback(ctx context.Context, rawPayload []byte) (*entities.PaymentCallback, error)
+}
+
+type paymentCallbackUseCase struct {
+	callbackRepo       repositories.PaymentCallbackRepository
+	transactionRepo    repositories.TransactionRepository
+	transactionUseCase TransactionUseCase
+	serverKey          string
+}
+
+func NewPaymentCallbackUseCase(
+	callbackRepo repositories.PaymentCallbackRepository,
+	transactionRepo repositories.TransactionRepository,
+	transactionUseCase TransactionUseCase,
+	serverKey string,
+) PaymentCallbackUseCase {
+	return &paymentCallbackUseCase{
+		callbackRepo:       callbackRepo,
+		transactionRepo:    transactionRepo,
+		transactionUseCase: transactionUseCase,
+		serverKey:          serverKey,
+	}
+}
+
+func (p *paymentCallbackUseCase) HandleCallback(ctx context.Context, rawPayload []byte) (*entities.PaymentCallback, error) {
+	var req entities.CallbackRequest
+	if err := json.Unmarshal(rawPayload, &req); err != nil {
+		// Keep malformed payloads as a JSON string so they can still be audited
+		quoted, _ := json.Marshal(string(rawPayload))
+		callback := entities.NewPaymentCallback(req, quoted)
+		return callback, p.reject(ctx, callback, customerrors.NewBadRequestError("Invalid payload format"))
+	}
+
+	callback := entities.NewPaymentCallback(req, rawPayload)
+
+	if req.OrderID == "" || req.StatusCode == "" || req.GrossAmount == "" || req.TransactionStatus == "" {
+		return callback, p.reject(ctx, callback, customerrors.NewBadRequestError("Missing required callback fields"))
+	}
+
+	// Verify the gateway signature before trusting anything in the payload
+	if !req.VerifySignature(p.serverKey) {
+		return callback, p.reject(ctx, callback, customerrors.NewForbiddenError("Invalid callback signature"))
+	}
+	callback.Verified = true
+
+	transaction, err := p.transactionRepo.GetByReference(ctx, req.OrderID)
+	if err != nil {
+		return callback, p.reject(ctx, callback, customerrors.NewNotFoundError("Transaction not found"))
+	}
+	callback.TransactionID = &transaction.ID
+
+	grossAmount, err := decimal.NewFromString(req.GrossAmount)
+	if err != nil || !grossAmount.Equal(transaction.TotalDebit()) {
+		return callback, p.reject(ctx, callback, customerrors.NewValidationError("Gross amount does not match transaction amount"))
+	}
+
+	// The fee was quoted for the chosen method; a payment made another way is not applied
+	if !transaction.PaidWith(req.PaymentType) {
+		return callback, p.reject(ctx, callback, customerrors.NewValidationError("Payment type does not match the payment method of the transaction"))
+	}
+
+	status := entities.MapGatewayStatus(req.TransactionStatus, req.FraudStatus)
+	switch {
+	case entities.IsGatewayRefundStatus(req.TransactionStatus):
+		// Refunds are booked by RefundTransaction before the gateway is asked to pay them out
+	case status == "":
+		return callback, p.reject(ctx, callback, customerrors.NewValidationError("Unknown transaction status"))
+	case status == entities.TransactionStatusPending:
+		// Nothing to apply yet; acknowledge so the gateway stops retrying
+	case status == transaction.Status:
+		// Duplicate notification for an already applied status
+	case status == entities.TransactionStatusCompleted && transaction.IsUnderReview():
+		// Duplicate notification for a paid top-up that is held for review
+	default:
+		if err := p.transactionUseCase.ProcessCallback(ctx, req.OrderID, status); err != nil {
+			return callback, p.reject(ctx, callback, err)
+		}
+	}
+
+	callback.MarkAsProcessed()
+	if err := p.callbackRepo.Create(ctx, callback); err != nil {
+		return callback, customerrors.NewInternalError("Failed to store payment callback", err)
+	}
+
+	return callback, nil
+}
+
+// reject records the callback with the reason it was refused and returns that reason as error
+func (p *paymentCallbackUseCase) reject(ctx context.Context, callback *entities.PaymentCallback, reason error) error {
+	if customErr, ok := reason.(*customerrors.CustomError); ok {
+		callback.Reject(customErr.Message)
+	} else {
+		callback.Reject(reason.Error())
+	}
+
+	if err := p.callbackRepo.Create(ctx, callback); err != nil {
+		return customerrors.NewInternalError("Failed to store payment callback", err)
+	}
+
+	return reason
+}
@@ -0,0 +1,167 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go-transaction-service/internal/config"
+	"go-transaction-service/internal/domain/entities"
+	"go-transaction-service/internal/domain/repositories"
+	"go-transaction-service/pkg/errors"
+)
+
+type PINUseCase interface {
+	SetPIN(ctx context.Context, userID uuid.UUID, req entities.SetPINRequest) error
+	ChangePIN(ctx context.Context, userID uuid.UUID, req entities.ChangePINRequest) error
+	// ResetPIN replaces a forgotten PIN after checking the password and, with MFA enabled, a second factor
+	ResetPIN(ctx context.Context, userID uuid.UUID, req entities.ResetPINRequest) error
+}
+
+type pinUseCase struct {
+	userRepo  repositories.UserRepository
+	mfaRepo   repositories.MFARepository
+	txManager repositories.TxManager
+	config    *config.Config
+}
+
+func NewPINUseCase(
+	userRepo repositories.UserRepository,
+	mfaRepo repositories.MFARepository,
+	txManager repositories.TxManager,
+	config *config.Config,
+) PINUseCase {
+	return &pinUseCase{
+		userRepo:  userRepo,
+		mfaRepo:   mfaRepo,
+		txManager: txManager,
+		config:    config,
+	}
+}
+
+func (p *pinUseCase) SetPIN(ctx context.Context, userID uuid.UUID, req entities.SetPINRequest) error {
+	return p.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		user, err := p.lockUser(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		if user.HasPIN() {
+			return customerrors.NewConflictError("Transaction PIN is already set")
+		}
+
+		if !user.CheckPassword(req.Password) {
+			return customerrors.NewValidationError("Invalid password")
+		}
+
+		return p.storePIN(ctx, user, req.PIN)
+	})
+}
+
+// ChangePIN replaces the PIN after checking the current one. A wrong current PIN counts
+// towards the lockout like a wrong PIN on a transaction.
+func (p *pinUseCase) ChangePIN(ctx context.Context, userID uuid.UUID, req entities.ChangePINRequest) error {
+	var pinErr error
+	err := p.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		user, err := p.lockUser(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		// The failed attempt must be committed, hence the error is only returned after the transaction
+		dirty, err := verifyPIN(user, req.CurrentPIN, p.config.PIN, time.Now())
+		if err != nil {
+			pinErr = err
+			if dirty {
+				return p.userRepo.UpdatePIN(ctx, user)
+			}
+			return nil
+		}
+
+		return p.storePIN(ctx, user, req.NewPIN)
+	})
+	if err != nil {
+		return err
+	}
+
+	return pinErr
+}
+
+func (p *pinUseCase) ResetPIN(ctx context.Context, userID uuid.UUID, req entities.ResetPINRequest) error {
+	return p.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		user, err := p.lockUser(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		if !user.CheckPassword(req.Password) {
+			return customerrors.NewValidationError("Invalid password")
+		}
+
+		if user.MFAEnabled {
+			if req.Code == "" {
+				return customerrors.NewValidationError("Verification code is required")
+			}
+
+			ok, err := verifySecondFactor(ctx, p.userRepo, p.mfaRepo, user, req.Code)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return customerrors.NewValidationError("Invalid verification code")
+			}
+		}
+
+		// Resetting also lifts a lockout
+		return p.storePIN(ctx, user, req.NewPIN)
+	})
+}
+
+func (p *pinUseCase) lockUser(ctx context.Context, userID uuid.UUID) (*entities.User, error) {
+	user, err := p.userRepo.GetByIDForUpdate(ctx, userID)
+	if err != nil {
+		if customerrors.IsNotFoundError(err) {
+			return nil, customerrors.NewNotFoundError("User not found")
+		}
+		return nil, customerrors.NewInternalError("Failed to get user", err)
+	}
+
+	return user, nil
+}
+
+func (p *pinUseCase) storePIN(ctx context.Context, user *entities.User, pin string) error {
+	if err := user.SetPIN(pin); err != nil {
+		return customerrors.NewInternalError("Failed to hash transaction PIN", err)
+	}
+
+	return p.userRepo.UpdatePIN(ctx, user)
+}
+
+// verifyPIN checks the transaction PIN of a user locked for update. A wrong PIN is counted and
+// locks the PIN after cfg.MaxAttempts; a correct one clears earlier failures. dirty reports that
+// the user must be stored with UpdatePIN, also when an error is returned.
+func verifyPIN(user *entities.User, pin string, cfg config.PINConfig, now time.Time) (dirty bool, err error) {
+	if !user.HasPIN() {
+		return false, customerrors.NewValidationError("Set a transaction PIN before sending money")
+	}
+
+	if user.IsPINLocked(now) {
+		return false, customerrors.NewForbiddenError(fmt.Sprintf("Transaction PIN is locked until %s", user.PINLockedUntil.UTC().Format(time.RFC3339)))
+	}
+
+	if !user.CheckPIN(pin) {
+		left := user.RecordPINFailure(now, cfg.MaxAttempts, cfg.LockDuration)
+		if left == 0 {
+			return true, customerrors.NewForbiddenError(fmt.Sprintf("Too many wrong PINs, transaction PIN is locked until %s", user.PINLockedUntil.UTC().Format(time.RFC3339)))
+		}
+		return true, customerrors.NewValidationError(fmt.Sprintf("Invalid transaction PIN, %d attempts left", left))
+	}
+
+	if user.PINFailedAttempts > 0 || user.PINLockedUntil != nil {
+		user.ResetPINAttempts()
+		return true, nil
+	}
+
+	return false, nil
+}
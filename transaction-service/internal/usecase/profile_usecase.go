@@ -0,0 +1,151 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"go-transaction-service/internal/domain/entities"
+	"go-transaction-service/internal/domain/repositories"
+	"go-transaction-service/pkg/errors"
+)
+
+type ProfileUseCase interface {
+	UpdateProfile(ctx context.Context, userID uuid.UUID, req entities.UpdateProfileRequest) (*entities.UserProfile, error)
+	// ChangePassword replaces the password after checking the current one and ends every other session of the user
+	ChangePassword(ctx context.Context, claims *entities.JWTClaims, req entities.ChangePasswordRequest) error
+	// CloseAccount closes the account of a user with a zero balance and no transactions in progress, and ends all their sessions
+	CloseAccount(ctx context.Context, userID uuid.UUID, req entities.CloseAccountRequest) error
+}
+
+type profileUseCase struct {
+	userRepo         repositories.UserRepository
+	transactionRepo  repositories.TransactionRepository
+	refreshTokenRepo repositories.RefreshTokenRepository
+	txManager        repositories.TxManager
+}
+
+func NewProfileUseCase(
+	userRepo repositories.UserRepository,
+	transactionRepo repositories.TransactionRepository,
+	refreshTokenRepo repositories.RefreshTokenRepository,
+	txManager repositories.TxManager,
+) ProfileUseCase {
+	return &profileUseCase{
+		userRepo:         userRepo,
+		transactionRepo:  transactionRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		txManager:        txManager,
+	}
+}
+
+func (p *profileUseCase) UpdateProfile(ctx context.Context, userID uuid.UUID, req entities.UpdateProfileRequest) (*entities.UserProfile, error) {
+	var user *entities.User
+	err := p.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		user, err = p.lockUser(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		if !user.ApplyProfileUpdate(req) {
+			return nil
+		}
+
+		user.UpdatedAt = time.Now()
+		return p.userRepo.Update(ctx, user)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	profile := user.ToProfile()
+	return &profile, nil
+}
+
+func (p *profileUseCase) ChangePassword(ctx context.Context, claims *entities.JWTClaims, req entities.ChangePasswordRequest) error {
+	return p.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		user, err := p.lockUser(ctx, claims.UserID)
+		if err != nil {
+			return err
+		}
+
+		if !user.CheckPassword(req.CurrentPassword) {
+			return customerrors.NewValidationError("Invalid current password")
+		}
+
+		if user.CheckPassword(req.NewPassword) {
+			return customerrors.NewValidationError("New password must differ from the current one")
+		}
+
+		user.Password = req.NewPassword
+		if err := user.HashPassword(); err != nil {
+			return customerrors.NewInternalError("Failed to hash password", err)
+		}
+		user.UpdatedAt = time.Now()
+		if err := p.userRepo.Update(ctx, user); err != nil {
+			return err
+		}
+
+		// Whoever knew the old password is logged out, the session making the change stays
+		return p.refreshTokenRepo.RevokeAllForUserExcept(ctx, user.ID, claims.SessionID)
+	})
+}
+
+func (p *profileUseCase) CloseAccount(ctx context.Context, userID uuid.UUID, req entities.CloseAccountRequest) error {
+	return p.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		user, err := p.lockUser(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		if !user.CheckPassword(req.Password) {
+			return customerrors.NewValidationError("Invalid password")
+		}
+
+		if !user.Balance.IsZero() {
+			return customerrors.NewConflictError("Withdraw or spend the remaining balance before closing the account")
+		}
+
+		// A pending top-up, a transaction under review or an uncaptured authorization could still
+		// move money into the account
+		for _, status := range []entities.TransactionStatus{
+			entities.TransactionStatusPending,
+			entities.TransactionStatusReview,
+			entities.TransactionStatusProcessing,
+			entities.TransactionStatusAuthorized,
+		} {
+			count, err := p.transactionRepo.CountByUserID(ctx, user.ID, entities.TransactionFilter{Status: status})
+			if err != nil {
+				return customerrors.NewInternalError("Failed to count transactions", err)
+			}
+			if count > 0 {
+				return customerrors.NewConflictError("Account has transactions in progress and cannot be closed yet")
+			}
+		}
+
+		user.Status = entities.UserStatusClosed
+		user.UpdatedAt = time.Now()
+		if err := p.userRepo.Update(ctx, user); err != nil {
+			return err
+		}
+
+		return p.refreshTokenRepo.RevokeAllForUser(ctx, user.ID)
+	})
+}
+
+func (p *profileUseCase) lockUser(ctx context.Context, userID uuid.UUID) (*entities.User, error) {
+	user, err := p.userRepo.GetByIDForUpdate(ctx, userID)
+	if err != nil {
+		if customerrors.IsNotFoundError(err) {
+			return nil, customerrors.NewNotFoundError("User not found")
+		}
+		return nil, customerrors.NewInternalError("Failed to get user", err)
+	}
+
+	if !user.IsActive() {
+		return nil, customerrors.NewForbiddenError("User account is inactive")
+	}
+
+	return user, nil
+}
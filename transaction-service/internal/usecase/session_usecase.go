@@ -0,0 +1,65 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"go-transaction-service/internal/domain/entities"
+	"go-transaction-service/internal/domain/repositories"
+	"go-transaction-service/pkg/errors"
+)
+
+type SessionUseCase interface {
+	// ListSessions returns the active sessions of the user, marking the one the claims belong to
+	ListSessions(ctx context.Context, claims *entities.JWTClaims) (*entities.SessionListResponse, error)
+	// RevokeSession ends a session of the user. Its refresh token stops working at once and so do
+	// its access tokens, which are checked against the session on every request.
+	RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error
+}
+
+type sessionUseCase struct {
+	sessionRepo      repositories.SessionRepository
+	refreshTokenRepo repositories.RefreshTokenRepository
+	txManager        repositories.TxManager
+}
+
+func NewSessionUseCase(
+	sessionRepo repositories.SessionRepository,
+	refreshTokenRepo repositories.RefreshTokenRepository,
+	txManager repositories.TxManager,
+) SessionUseCase {
+	return &sessionUseCase{
+		sessionRepo:      sessionRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		txManager:        txManager,
+	}
+}
+
+func (s *sessionUseCase) ListSessions(ctx context.Context, claims *entities.JWTClaims) (*entities.SessionListResponse, error) {
+	sessions, err := s.sessionRepo.ListActiveByUser(ctx, claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, session := range sessions {
+		session.Current = session.ID == claims.SessionID
+	}
+
+	return &entities.SessionListResponse{Sessions: sessions}, nil
+}
+
+func (s *sessionUseCase) RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error {
+	return s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		session, err := s.sessionRepo.GetActive(ctx, sessionID)
+		if err != nil {
+			return err
+		}
+
+		// Sessions of other users are reported as missing rather than forbidden
+		if session.UserID != userID {
+			return customerrors.NewNotFoundError("Session not found")
+		}
+
+		return s.refreshTokenRepo.RevokeFamily(ctx, session.ID)
+	})
+}
@@ -1,10 +1,17 @@
 package usecase
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"go-transaction-service/internal/config"
 	"go-transaction-service/internal/domain/entities"
 	"go-transaction-service/internal/domain/repositories"
 	"go-transaction-service/pkg/errors"
@@ -13,21 +20,52 @@ import (
 type TransactionUseCase interface {
 	TopupBalance(ctx context.Context, userID uuid.UUID, req entities.TopupRequest) (*entities.TransactionResponse, error)
 	ProcessPayment(ctx context.Context, userID uuid.UUID, req entities.PaymentRequest) (*entities.TransactionResponse, error)
-	GetTransactionHistory(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*entities.TransactionHistoryResponse, error)
+	// AuthorizePayment holds a payment amount on the sender's balance until the recipient captures or voids it
+	AuthorizePayment(ctx context.Context, userID uuid.UUID, req entities.AuthorizePaymentRequest) (*entities.TransactionResponse, error)
+	// CapturePayment charges all or part of an authorized payment; only its recipient may capture it
+	CapturePayment(ctx context.Context, recipientID, transactionID uuid.UUID, req entities.CapturePaymentRequest) (*entities.TransactionResponse, error)
+	// VoidPayment releases the hold of an authorized payment; only its recipient may void it
+	VoidPayment(ctx context.Context, recipientID, transactionID uuid.UUID) (*entities.TransactionResponse, error)
+	TransferFunds(ctx context.Context, userID uuid.UUID, req entities.TransferRequest) (*entities.TransferReceipt, error)
+	GetTransferReceipt(ctx context.Context, viewerID, transactionID uuid.UUID) (*entities.TransferReceipt, error)
+	GetTransactionHistory(ctx context.Context, userID uuid.UUID, filter entities.TransactionFilter, page entities.TransactionPageRequest) (*entities.TransactionHistoryListResponse, error)
 	GetBalance(ctx context.Context, userID uuid.UUID) (*entities.BalanceResponse, error)
+	// RebuildWalletBalance resets the balance of a user to the sum of their ledger wallet postings
+	RebuildWalletBalance(ctx context.Context, userID uuid.UUID) (*entities.BalanceResponse, error)
 	ProcessCallback(ctx context.Context, reference string, status entities.TransactionStatus) error
 	GetTransactionByReference(ctx context.Context, reference string) (*entities.Transaction, error)
+	GetTransactionDetail(ctx context.Context, viewerID, transactionID uuid.UUID) (*entities.TransactionDetailResponse, error)
+	GetTransactionDetailByReference(ctx context.Context, viewerID uuid.UUID, reference string) (*entities.TransactionDetailResponse, error)
+	ReconcilePendingTransactions(ctx context.Context, olderThan time.Time, limit int) ([]*ReconcileResult, error)
+	ExpireTransactions(ctx context.Context, now time.Time, limit int) ([]*ReconcileResult, error)
+	ListTransactionsForReview(ctx context.Context, limit, offset int) (*entities.TransactionHistoryListResponse, error)
+	GetTransactionDetailForAdmin(ctx context.Context, transactionID uuid.UUID) (*entities.TransactionDetailResponse, error)
+	ApproveTransaction(ctx context.Context, reviewerID, transactionID uuid.UUID, note string) (*entities.TransactionResponse, error)
+	RejectTransaction(ctx context.Context, reviewerID, transactionID uuid.UUID, reason string) (*entities.TransactionResponse, error)
+	RefundTransaction(ctx context.Context, refundedBy, transactionID uuid.UUID, req entities.RefundTransactionRequest) (*entities.TransactionResponse, error)
 }
 
 type transactionUseCase struct {
 	transactionRepo repositories.TransactionRepository
 	userRepo        repositories.UserRepository
+	ledgerRepo      repositories.LedgerRepository
+	txManager       repositories.TxManager
 	paymentGateway  PaymentGateway
+	fees            FeeUseCase
+	limits          LimitUseCase
+	config          *config.Config
 }
 
+// ErrPaymentNotFound is returned by a PaymentGateway when it has no record of an order
+var ErrPaymentNotFound = errors.New("payment not found at payment gateway")
+
 type PaymentGateway interface {
-	CreateTopupTransaction(ctx context.Context, userID uuid.UUID, amount string, orderId string) (*PaymentGatewayResponse, error)
+	// CreateTopupTransaction opens a payment page that only offers the given payment method
+	CreateTopupTransaction(ctx context.Context, userID uuid.UUID, amount string, paymentMethod string, orderId string, expiresAt *time.Time) (*PaymentGatewayResponse, error)
 	GetTransactionStatus(ctx context.Context, orderId string) (*PaymentGatewayResponse, error)
+	// Refund pays part or all of a settled order back to the customer. The refund key identifies
+	// the refund so that a retried request is not paid out twice.
+	Refund(ctx context.Context, orderId string, refundKey string, amount string, reason string) (*PaymentGatewayResponse, error)
 }
 
 type PaymentGatewayResponse struct {
@@ -35,45 +73,108 @@ type PaymentGatewayResponse struct {
 	Status      string
 	PaymentURL  string
 	GatewayID   string
+	GrossAmount string
+	PaymentType string // payment_type of a status lookup, empty until the customer has paid
+	FraudStatus string // fraud detection result of a card capture
+}
+
+// ReconcileResult describes what the reconciler did with a single transaction
+type ReconcileResult struct {
+	TransactionID uuid.UUID
+	Reference     string
+	GatewayStatus string
+	Status        entities.TransactionStatus
+	Updated       bool
+	Err           error
 }
 
 func NewTransactionUseCase(
 	transactionRepo repositories.TransactionRepository,
 	userRepo repositories.UserRepository,
+	ledgerRepo repositories.LedgerRepository,
+	txManager repositories.TxManager,
 	paymentGateway PaymentGateway,
+	fees FeeUseCase,
+	limits LimitUseCase,
+	config *config.Config,
 ) TransactionUseCase {
 	return &transactionUseCase{
 		transactionRepo: transactionRepo,
 		userRepo:        userRepo,
+		ledgerRepo:      ledgerRepo,
+		txManager:       txManager,
 		paymentGateway:  paymentGateway,
+		fees:            fees,
+		limits:          limits,
+		config:          config,
 	}
 }
 
 func (t *transactionUseCase) TopupBalance(ctx context.Context, userID uuid.UUID, req entities.TopupRequest) (*entities.TransactionResponse, error) {
-	// Validate user exists
-	user, err := t.userRepo.GetByID(ctx, userID)
-	if err != nil {
-		return nil, customerrors.NewNotFoundError("User not found")
+	if !isWholeRupiah(req.Amount) {
+		return nil, customerrors.NewValidationError("Top-up amount must be a whole number of rupiah")
 	}
-
-	if !user.IsActive() {
-		return nil, customerrors.NewValidationError("User account is inactive")
+	if _, ok := entities.TopupPaymentMethods[req.PaymentMethod]; !ok {
+		return nil, customerrors.NewValidationError(fmt.Sprintf("Unsupported payment method %s", req.PaymentMethod))
 	}
 
-	// Create transaction
-	transaction := entities.NewTransaction(userID, entities.TransactionTypeTopup, req.Amount, "Balance top-up")
-	
-	// Save transaction to database
-	if err := t.transactionRepo.Create(ctx, transaction); err != nil {
-		return nil, customerrors.NewInternalError("Failed to create transaction", err)
+	// Create the pending transaction; the gateway call stays outside of any database transaction
+	var transaction *entities.Transaction
+	err := t.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		// Validate user exists; the row stays locked so concurrent top-ups cannot both slip under a limit
+		user, err := t.userRepo.GetByIDForUpdate(ctx, userID)
+		if err != nil {
+			return customerrors.NewNotFoundError("User not found")
+		}
+
+		if !user.IsActive() {
+			return customerrors.NewValidationError("User account is inactive")
+		}
+
+		if err := t.checkEmailVerified(user); err != nil {
+			return err
+		}
+
+		if err := t.checkKYCFeature(user, entities.KYCFeatureTopup); err != nil {
+			return err
+		}
+
+		if err := t.limits.CheckTransaction(ctx, user, entities.TransactionTypeTopup, req.Amount); err != nil {
+			return err
+		}
+		if err := t.limits.CheckBalance(ctx, user, req.Amount); err != nil {
+			return err
+		}
+
+		quote, err := t.fees.Quote(ctx, entities.TransactionTypeTopup, req.PaymentMethod, req.Amount)
+		if err != nil {
+			return err
+		}
+
+		// Create transaction; the fee is collected by the gateway together with the amount
+		transaction = entities.NewTransaction(userID, entities.TransactionTypeTopup, req.Amount, "Balance top-up")
+		transaction.ApplyFee(quote)
+		transaction.SetExpiry(t.config.Expiry.For(string(entities.TransactionTypeTopup)))
+
+		// Save transaction to database
+		if err := t.transactionRepo.Create(ctx, transaction); err != nil {
+			return customerrors.NewInternalError("Failed to create transaction", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// Create payment with payment gateway
-	paymentResp, err := t.paymentGateway.CreateTopupTransaction(ctx, userID, req.Amount.String(), transaction.Reference)
+	paymentResp, err := t.paymentGateway.CreateTopupTransaction(ctx, userID, transaction.TotalDebit().String(), req.PaymentMethod, transaction.Reference, transaction.ExpiresAt)
 	if err != nil {
 		// Mark transaction as failed
 		transaction.MarkAsFailed()
-		t.transactionRepo.Update(ctx, transaction)
+		if updateErr := t.transactionRepo.Update(ctx, transaction); updateErr != nil {
+			return nil, customerrors.NewInternalError("Failed to create payment and mark transaction as failed", updateErr)
+		}
 		return nil, customerrors.NewInternalError("Failed to create payment", err)
 	}
 
@@ -88,151 +189,957 @@ func (t *transactionUseCase) TopupBalance(ctx context.Context, userID uuid.UUID,
 		ID:         transaction.ID,
 		Status:     transaction.Status,
 		Amount:     transaction.Amount,
+		Fee:        transaction.Fee,
 		PaymentURL: paymentResp.PaymentURL,
 		Reference:  transaction.Reference,
+		ExpiresAt:  transaction.ExpiresAt,
 		CreatedAt:  transaction.CreatedAt,
 	}, nil
 }
 
 func (t *transactionUseCase) ProcessPayment(ctx context.Context, userID uuid.UUID, req entities.PaymentRequest) (*entities.TransactionResponse, error) {
-	// Validate user exists
-	user, err := t.userRepo.GetByID(ctx, userID)
-	if err != nil {
-		return nil, customerrors.NewNotFoundError("User not found")
+	if userID == req.ToUserID {
+		return nil, customerrors.NewValidationError("Cannot send payment to yourself")
 	}
 
-	if !user.IsActive() {
-		return nil, customerrors.NewValidationError("User account is inactive")
+	transaction, _, _, err := t.moveFunds(ctx, entities.TransactionTypePayment, t.config.Payment, userID, req.ToUserID, req.Amount, req.Description, req.PIN, nil)
+	if err != nil {
+		return nil, err
 	}
 
-	// Validate recipient exists
-	recipient, err := t.userRepo.GetByID(ctx, req.ToUserID)
+	return &entities.TransactionResponse{
+		ID:        transaction.ID,
+		Status:    transaction.Status,
+		Amount:    transaction.Amount,
+		Fee:       transaction.Fee,
+		Reference: transaction.Reference,
+		CreatedAt: transaction.CreatedAt,
+	}, nil
+}
+
+func (t *transactionUseCase) TransferFunds(ctx context.Context, userID uuid.UUID, req entities.TransferRequest) (*entities.TransferReceipt, error) {
+	recipient, err := t.findTransferRecipient(ctx, req)
 	if err != nil {
-		return nil, customerrors.NewNotFoundError("Recipient not found")
+		return nil, err
 	}
 
-	if !recipient.IsActive() {
-		return nil, customerrors.NewValidationError("Recipient account is inactive")
+	if userID == recipient.ID {
+		return nil, customerrors.NewValidationError("Cannot transfer to yourself")
 	}
 
-	// Check if user has sufficient balance
-	balance, err := t.userRepo.GetBalance(ctx, userID)
+	transaction, sender, recipient, err := t.moveFunds(ctx, entities.TransactionTypeTransfer, t.config.Transfer, userID, recipient.ID, req.Amount, req.Description, req.PIN, nil)
 	if err != nil {
-		return nil, customerrors.NewInternalError("Failed to get user balance", err)
+		return nil, err
 	}
 
-	if balance.LessThan(req.Amount) {
-		return nil, customerrors.NewValidationError("Insufficient balance")
-	}
+	return entities.NewTransferReceipt(transaction, sender, recipient), nil
+}
 
-	// Create transaction
-	transaction := entities.NewTransaction(userID, entities.TransactionTypePayment, req.Amount, req.Description)
-	transaction.Metadata["to_user_id"] = req.ToUserID.String()
-	
-	// Save transaction to database
-	if err := t.transactionRepo.Create(ctx, transaction); err != nil {
-		return nil, customerrors.NewInternalError("Failed to create transaction", err)
+func (t *transactionUseCase) AuthorizePayment(ctx context.Context, userID uuid.UUID, req entities.AuthorizePaymentRequest) (*entities.TransactionResponse, error) {
+	if userID == req.ToUserID {
+		return nil, customerrors.NewValidationError("Cannot send payment to yourself")
 	}
 
-	// Process payment (deduct from sender, add to recipient)
-	if err := t.userRepo.SubtractBalance(ctx, userID, req.Amount); err != nil {
-		transaction.MarkAsFailed()
-		t.transactionRepo.Update(ctx, transaction)
-		return nil, customerrors.NewInternalError("Failed to deduct balance", err)
+	holdFor := t.config.Expiry.Authorization
+	if req.ExpiresInMinutes > 0 {
+		holdFor = time.Duration(req.ExpiresInMinutes) * time.Minute
 	}
-
-	if err := t.userRepo.AddBalance(ctx, req.ToUserID, req.Amount); err != nil {
-		// Rollback: add balance back to sender
-		t.userRepo.AddBalance(ctx, userID, req.Amount)
-		transaction.MarkAsFailed()
-		t.transactionRepo.Update(ctx, transaction)
-		return nil, customerrors.NewInternalError("Failed to add balance to recipient", err)
+	if max := t.config.Expiry.MaxAuthorization; max > 0 && holdFor > max {
+		return nil, customerrors.NewValidationError(fmt.Sprintf("Authorizations cannot last longer than %d minutes", int(max/time.Minute)))
 	}
 
-	// Mark transaction as completed
-	transaction.MarkAsCompleted()
-	if err := t.transactionRepo.Update(ctx, transaction); err != nil {
-		return nil, customerrors.NewInternalError("Failed to update transaction", err)
+	transaction, _, _, err := t.moveFunds(ctx, entities.TransactionTypePayment, t.config.Payment, userID, req.ToUserID, req.Amount, req.Description, req.PIN, &holdFor)
+	if err != nil {
+		return nil, err
 	}
 
 	return &entities.TransactionResponse{
 		ID:        transaction.ID,
 		Status:    transaction.Status,
 		Amount:    transaction.Amount,
+		Fee:       transaction.Fee,
 		Reference: transaction.Reference,
+		ExpiresAt: transaction.ExpiresAt,
 		CreatedAt: transaction.CreatedAt,
 	}, nil
 }
 
-func (t *transactionUseCase) GetTransactionHistory(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*entities.TransactionHistoryResponse, error) {
-	transactions, err := t.transactionRepo.GetByUserID(ctx, userID, limit, offset)
+func (t *transactionUseCase) CapturePayment(ctx context.Context, recipientID, transactionID uuid.UUID, req entities.CapturePaymentRequest) (*entities.TransactionResponse, error) {
+	if req.Amount.IsNegative() {
+		return nil, customerrors.NewValidationError("Capture amount must be greater than 0")
+	}
+
+	var transaction *entities.Transaction
+	err := t.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		transaction, err = t.lockAuthorization(ctx, recipientID, transactionID)
+		if err != nil {
+			return err
+		}
+
+		// The expiry job may not have released the hold yet
+		if transaction.IsExpired(time.Now()) {
+			return customerrors.NewConflictError("Authorization has expired")
+		}
+
+		amount := req.Amount
+		if amount.IsZero() {
+			amount = transaction.Amount
+		}
+		if amount.GreaterThan(transaction.Amount) {
+			return customerrors.NewValidationError(fmt.Sprintf("Capture amount exceeds the authorized amount of %s", transaction.Amount))
+		}
+
+		users, err := t.lockUsers(ctx, transaction.UserID, recipientID)
+		if err != nil {
+			return err
+		}
+
+		if _, ok := users[transaction.UserID]; !ok {
+			return customerrors.NewNotFoundError("User not found")
+		}
+
+		recipient, ok := users[recipientID]
+		if !ok || !recipient.IsActive() {
+			return customerrors.NewValidationError("Recipient account is inactive")
+		}
+
+		// The whole hold is released; only the captured amount and its share of the held fee are charged
+		if err := t.userRepo.ReleaseHold(ctx, transaction.UserID, transaction.TotalDebit()); err != nil {
+			return err
+		}
+
+		if amount.LessThan(transaction.Amount) {
+			transaction.CapturePartially(amount)
+		}
+
+		return t.settleTransfer(ctx, transaction)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response := transaction.ToResponse()
+	return &response, nil
+}
+
+func (t *transactionUseCase) VoidPayment(ctx context.Context, recipientID, transactionID uuid.UUID) (*entities.TransactionResponse, error) {
+	var transaction *entities.Transaction
+	err := t.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		transaction, err = t.lockAuthorization(ctx, recipientID, transactionID)
+		if err != nil {
+			return err
+		}
+
+		return t.releaseAuthorization(ctx, transaction, entities.CancelReasonVoided)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response := transaction.ToResponse()
+	return &response, nil
+}
+
+// lockAuthorization locks an authorized payment of the given recipient. Other users get the same
+// answer as for a missing transaction.
+func (t *transactionUseCase) lockAuthorization(ctx context.Context, recipientID, transactionID uuid.UUID) (*entities.Transaction, error) {
+	transaction, err := t.transactionRepo.GetByIDForUpdate(ctx, transactionID)
+	if err != nil {
+		if customerrors.IsNotFoundError(err) {
+			return nil, customerrors.NewNotFoundError("Transaction not found")
+		}
+		return nil, customerrors.NewInternalError("Failed to get transaction", err)
+	}
+
+	if transaction.CounterpartyUserID == nil || *transaction.CounterpartyUserID != recipientID {
+		return nil, customerrors.NewNotFoundError("Transaction not found")
+	}
+
+	if !transaction.IsAuthorized() {
+		return nil, customerrors.NewConflictError(fmt.Sprintf("Transaction is %s, not authorized", transaction.Status))
+	}
+
+	return transaction, nil
+}
+
+// releaseAuthorization gives the held amount of a locked authorization back to the sender and
+// cancels it for the given reason
+func (t *transactionUseCase) releaseAuthorization(ctx context.Context, transaction *entities.Transaction, reason string) error {
+	if err := t.userRepo.ReleaseHold(ctx, transaction.UserID, transaction.TotalDebit()); err != nil {
+		return err
+	}
+
+	if transaction.Metadata == nil {
+		transaction.Metadata = make(map[string]string)
+	}
+	transaction.Metadata[entities.MetadataCancelReason] = reason
+	transaction.MarkAsCancelled()
+	if err := t.transactionRepo.Update(ctx, transaction); err != nil {
+		return customerrors.NewInternalError("Failed to update transaction", err)
+	}
+
+	return nil
+}
+
+// findTransferRecipient looks up the recipient of a transfer by email or phone number
+func (t *transactionUseCase) findTransferRecipient(ctx context.Context, req entities.TransferRequest) (*entities.User, error) {
+	email := strings.TrimSpace(req.ToEmail)
+	phone := strings.TrimSpace(req.ToPhone)
+
+	var recipient *entities.User
+	var err error
+	switch {
+	case email != "" && phone != "":
+		return nil, customerrors.NewValidationError("Provide either the recipient email or phone number, not both")
+	case email != "":
+		recipient, err = t.userRepo.GetByEmail(ctx, email)
+	case phone != "":
+		recipient, err = t.userRepo.GetByPhone(ctx, phone)
+	default:
+		return nil, customerrors.NewValidationError("Recipient email or phone number is required")
+	}
+
+	if err != nil {
+		if customerrors.IsNotFoundError(err) {
+			return nil, customerrors.NewNotFoundError("Recipient not found")
+		}
+		return nil, customerrors.NewInternalError("Failed to find recipient", err)
+	}
+
+	return recipient, nil
+}
+
+// checkEmailVerified refuses transactions of users with an unverified email address when
+// REQUIRE_VERIFIED_EMAIL is set
+func (t *transactionUseCase) checkEmailVerified(user *entities.User) error {
+	if t.config.Account.RequireVerifiedEmail && !user.IsEmailVerified() {
+		return customerrors.NewForbiddenError("Verify your email address before making transactions")
+	}
+
+	return nil
+}
+
+// checkKYCFeature refuses features that the KYC tier of the user does not unlock
+func (t *transactionUseCase) checkKYCFeature(user *entities.User, feature string) error {
+	if !t.config.KYC.PolicyFor(string(user.KYCTier)).Allows(feature) {
+		return customerrors.NewFeatureLockedError(entities.KYCReasonFeatureLocked,
+			fmt.Sprintf("Verify your identity to a higher KYC tier to use %s", feature))
+	}
+	return nil
+}
+
+// moveFunds moves an amount from one wallet to another as a completed transaction of the given
+// type, enforcing the limits of the sender and recipient and charging the quoted fee to the sender. The sender's
+// transaction PIN is verified first. Amounts at or above the review threshold of the policy are
+// only recorded and wait for ApproveTransaction. With authorizeFor set, the amount and fee are
+// only held on the sender's balance for that long, until CapturePayment or VoidPayment.
+func (t *transactionUseCase) moveFunds(ctx context.Context, transactionType entities.TransactionType, policy config.TransactionPolicy, userID, toUserID uuid.UUID, amount decimal.Decimal, description, pin string, authorizeFor *time.Duration) (*entities.Transaction, *entities.User, *entities.User, error) {
+	var transaction *entities.Transaction
+	var user, recipient *entities.User
+	var pinErr error
+	err := t.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		// Lock sender and recipient rows for the rest of the unit of work
+		users, err := t.lockUsers(ctx, userID, toUserID)
+		if err != nil {
+			return err
+		}
+
+		// Validate user exists
+		var ok bool
+		user, ok = users[userID]
+		if !ok {
+			return customerrors.NewNotFoundError("User not found")
+		}
+
+		if !user.IsActive() {
+			return customerrors.NewValidationError("User account is inactive")
+		}
+
+		if err := t.checkEmailVerified(user); err != nil {
+			return err
+		}
+
+		// KYC features are named after the transaction types; holding funds is a feature of its own
+		feature := string(transactionType)
+		if authorizeFor != nil {
+			feature = entities.KYCFeatureAuthorization
+		}
+		if err := t.checkKYCFeature(user, feature); err != nil {
+			return err
+		}
+
+		// Validate recipient exists
+		recipient, ok = users[toUserID]
+		if !ok {
+			return customerrors.NewNotFoundError("Recipient not found")
+		}
+
+		if !recipient.IsActive() {
+			return customerrors.NewValidationError("Recipient account is inactive")
+		}
+
+		// Verify the PIN before any balance is touched. A failed attempt must be committed,
+		// hence the error is only returned after the transaction.
+		dirty, err := verifyPIN(user, pin, t.config.PIN, time.Now())
+		if dirty {
+			if err := t.userRepo.UpdatePIN(ctx, user); err != nil {
+				return err
+			}
+		}
+		if err != nil {
+			pinErr = err
+			return nil
+		}
+
+		// Both rows are locked, so concurrent requests cannot both slip under a limit
+		if err := t.limits.CheckTransaction(ctx, user, transactionType, amount); err != nil {
+			return err
+		}
+		if err := t.limits.CheckBalance(ctx, recipient, amount); err != nil {
+			return err
+		}
+
+		// Create transaction
+		transaction = entities.NewTransaction(userID, transactionType, amount, description)
+		transaction.CounterpartyUserID = &toUserID
+
+		quote, err := t.fees.Quote(ctx, transactionType, "", amount)
+		if err != nil {
+			return err
+		}
+		transaction.ApplyFee(quote)
+
+		// Check if user has sufficient balance; held funds cannot be spent
+		if user.AvailableBalance().LessThan(transaction.TotalDebit()) {
+			return customerrors.NewValidationError("Insufficient balance")
+		}
+
+		// Large amounts are held for manual review before any funds move
+		if policy.ReviewThreshold.IsPositive() && !amount.LessThan(policy.ReviewThreshold) {
+			if authorizeFor != nil {
+				return customerrors.NewValidationError(fmt.Sprintf("A %s of %s or more cannot be authorized in advance", transactionType, policy.ReviewThreshold))
+			}
+			transaction.MarkForReview()
+		}
+
+		if authorizeFor != nil {
+			transaction.MarkAsAuthorized(*authorizeFor)
+		}
+
+		// Save transaction to database
+		if err := t.transactionRepo.Create(ctx, transaction); err != nil {
+			return customerrors.NewInternalError("Failed to create transaction", err)
+		}
+
+		if transaction.IsUnderReview() {
+			return nil
+		}
+
+		if transaction.IsAuthorized() {
+			return t.userRepo.HoldBalance(ctx, userID, transaction.TotalDebit())
+		}
+
+		return t.settleTransfer(ctx, transaction)
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if pinErr != nil {
+		return nil, nil, nil, pinErr
+	}
+
+	return transaction, user, recipient, nil
+}
+
+// settleTransfer debits the sender, credits the counterparty, records the ledger entry and
+// completes the transaction. Both user rows must already be locked by the caller.
+func (t *transactionUseCase) settleTransfer(ctx context.Context, transaction *entities.Transaction) error {
+	toUserID := *transaction.CounterpartyUserID
+
+	// Deduct amount and fee from sender, add amount to recipient
+	if err := t.userRepo.SubtractBalance(ctx, transaction.UserID, transaction.TotalDebit()); err != nil {
+		return err
+	}
+
+	if err := t.userRepo.AddBalance(ctx, toUserID, transaction.Amount); err != nil {
+		return customerrors.NewInternalError("Failed to add balance to recipient", err)
+	}
+
+	// Record the movement in the ledger
+	if err := t.ledgerRepo.CreateEntry(ctx, entities.NewWalletTransferEntry(transaction, transaction.UserID, toUserID)); err != nil {
+		return customerrors.NewInternalError("Failed to record ledger entry", err)
+	}
+
+	// Mark transaction as completed
+	transaction.MarkAsCompleted()
+	if err := t.transactionRepo.Update(ctx, transaction); err != nil {
+		return customerrors.NewInternalError("Failed to update transaction", err)
+	}
+
+	return nil
+}
+
+func (t *transactionUseCase) ListTransactionsForReview(ctx context.Context, limit, offset int) (*entities.TransactionHistoryListResponse, error) {
+	transactions, err := t.transactionRepo.GetByStatus(ctx, entities.TransactionStatusReview, limit, offset)
+	if err != nil {
+		return nil, customerrors.NewInternalError("Failed to get transactions under review", err)
+	}
+
+	total, err := t.transactionRepo.CountByStatus(ctx, entities.TransactionStatusReview)
+	if err != nil {
+		return nil, customerrors.NewInternalError("Failed to count transactions under review", err)
+	}
+
+	// Each transaction is shown from the side of the user who initiated it
+	responses := make([]entities.TransactionHistoryResponse, 0, len(transactions))
+	for _, transaction := range transactions {
+		responses = append(responses, transaction.ToHistoryResponse(transaction.UserID))
+	}
+
+	return &entities.TransactionHistoryListResponse{
+		Transactions: responses,
+		Pagination: entities.PaginationResponse{
+			Limit:   limit,
+			Offset:  offset,
+			Count:   len(responses),
+			Total:   total,
+			HasMore: offset+len(responses) < total,
+		},
+	}, nil
+}
+
+// GetTransactionDetailForAdmin returns any transaction as seen by the user who initiated it
+func (t *transactionUseCase) GetTransactionDetailForAdmin(ctx context.Context, transactionID uuid.UUID) (*entities.TransactionDetailResponse, error) {
+	transaction, err := t.transactionRepo.GetByID(ctx, transactionID)
+	if err != nil {
+		if customerrors.IsNotFoundError(err) {
+			return nil, customerrors.NewNotFoundError("Transaction not found")
+		}
+		return nil, customerrors.NewInternalError("Failed to get transaction", err)
+	}
+
+	return t.buildTransactionDetail(ctx, transaction.UserID, transaction)
+}
+
+// ApproveTransaction settles a transaction that was held for manual review. Both accounts and the
+// sender balance are checked again because they may have changed while the transaction waited.
+func (t *transactionUseCase) ApproveTransaction(ctx context.Context, reviewerID, transactionID uuid.UUID, note string) (*entities.TransactionResponse, error) {
+	var transaction *entities.Transaction
+	err := t.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		transaction, err = t.lockTransactionForReview(ctx, transactionID)
+		if err != nil {
+			return err
+		}
+
+		if transaction.Type == entities.TransactionTypeTopup {
+			return t.approveTopup(ctx, transaction, reviewerID, note)
+		}
+
+		users, err := t.lockUsers(ctx, transaction.UserID, *transaction.CounterpartyUserID)
+		if err != nil {
+			return err
+		}
+
+		user, ok := users[transaction.UserID]
+		if !ok || !user.IsActive() {
+			return customerrors.NewValidationError("Sender account is inactive")
+		}
+
+		recipient, ok := users[*transaction.CounterpartyUserID]
+		if !ok || !recipient.IsActive() {
+			return customerrors.NewValidationError("Recipient account is inactive")
+		}
+
+		if user.AvailableBalance().LessThan(transaction.TotalDebit()) {
+			return customerrors.NewValidationError("Insufficient balance")
+		}
+
+		recordReview(transaction, reviewerID, note)
+		return t.settleTransfer(ctx, transaction)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response := transaction.ToResponse()
+	return &response, nil
+}
+
+// approveTopup credits a paid top-up that was held for review because it would raise the wallet
+// above its maximum. The maximum is still enforced, so it has to be raised for the user first.
+func (t *transactionUseCase) approveTopup(ctx context.Context, transaction *entities.Transaction, reviewerID uuid.UUID, note string) error {
+	user, err := t.userRepo.GetByIDForUpdate(ctx, transaction.UserID)
+	if err != nil {
+		return customerrors.NewNotFoundError("User not found")
+	}
+
+	if err := t.limits.CheckCredit(ctx, user, transaction.Amount); err != nil {
+		return err
+	}
+
+	recordReview(transaction, reviewerID, note)
+	if err := t.bookTopup(ctx, transaction); err != nil {
+		return err
+	}
+
+	if err := t.transactionRepo.Update(ctx, transaction); err != nil {
+		return customerrors.NewInternalError("Failed to update transaction", err)
+	}
+
+	return nil
+}
+
+// RejectTransaction cancels a transaction that was held for manual review; no funds were moved.
+// A rejected top-up has already been paid, so the whole charge is refunded through the payment
+// gateway; if the gateway refuses, the top-up goes back under review.
+func (t *transactionUseCase) RejectTransaction(ctx context.Context, reviewerID, transactionID uuid.UUID, reason string) (*entities.TransactionResponse, error) {
+	var transaction *entities.Transaction
+	err := t.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		transaction, err = t.lockTransactionForReview(ctx, transactionID)
+		if err != nil {
+			return err
+		}
+
+		recordReview(transaction, reviewerID, reason)
+		transaction.Metadata[entities.MetadataCancelReason] = entities.CancelReasonRejected
+		transaction.MarkAsCancelled()
+		if err := t.transactionRepo.Update(ctx, transaction); err != nil {
+			return customerrors.NewInternalError("Failed to update transaction", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if transaction.Type == entities.TransactionTypeTopup {
+		// The gateway call stays outside of any database transaction, as for top-ups
+		if _, err := t.paymentGateway.Refund(ctx, transaction.Reference, transaction.Reference, transaction.TotalDebit().String(), reason); err != nil {
+			if reopenErr := t.reopenReview(ctx, transaction.ID); reopenErr != nil {
+				return nil, customerrors.NewInternalError("Failed to refund top-up and hold it for review again", reopenErr)
+			}
+			return nil, customerrors.NewInternalError("Failed to refund top-up", err)
+		}
+	}
+
+	response := transaction.ToResponse()
+	return &response, nil
+}
+
+// reopenReview puts a rejected top-up the payment gateway could not refund back under review
+func (t *transactionUseCase) reopenReview(ctx context.Context, transactionID uuid.UUID) error {
+	return t.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		transaction, err := t.transactionRepo.GetByIDForUpdate(ctx, transactionID)
+		if err != nil {
+			return err
+		}
+
+		if transaction.Status != entities.TransactionStatusCancelled {
+			return nil
+		}
+
+		delete(transaction.Metadata, entities.MetadataCancelReason)
+		delete(transaction.Metadata, entities.MetadataReviewedBy)
+		delete(transaction.Metadata, entities.MetadataReviewNote)
+		transaction.MarkForReview()
+		return t.transactionRepo.Update(ctx, transaction)
+	})
+}
+
+// RefundTransaction reverses all or part of a completed payment or top-up with a linked refund
+// transaction; without an amount the whole remaining amount is refunded. A payment is refunded
+// from the recipient's wallet at once. A top-up is taken out of the wallet first and then paid
+// back through the payment gateway; if the gateway refuses, the amount returns to the wallet and
+// the refund fails.
+func (t *transactionUseCase) RefundTransaction(ctx context.Context, refundedBy, transactionID uuid.UUID, req entities.RefundTransactionRequest) (*entities.TransactionResponse, error) {
+	if req.Amount.IsNegative() {
+		return nil, customerrors.NewValidationError("Refund amount must be greater than 0")
+	}
+
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return nil, customerrors.NewValidationError("Refund reason is required")
+	}
+
+	var parent, refund *entities.Transaction
+	err := t.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		// Lock the refunded transaction so concurrent refunds cannot exceed its amount together
+		var err error
+		parent, err = t.transactionRepo.GetByIDForUpdate(ctx, transactionID)
+		if err != nil {
+			if customerrors.IsNotFoundError(err) {
+				return customerrors.NewNotFoundError("Transaction not found")
+			}
+			return customerrors.NewInternalError("Failed to get transaction", err)
+		}
+
+		if !parent.IsRefundable() {
+			return customerrors.NewConflictError(fmt.Sprintf("Only completed payments and top-ups can be refunded, not a %s %s", parent.Status, parent.Type))
+		}
+
+		refunded, err := t.transactionRepo.SumRefundedAmount(ctx, parent.ID)
+		if err != nil {
+			return customerrors.NewInternalError("Failed to sum refunds", err)
+		}
+
+		remaining := parent.Amount.Sub(refunded)
+		if !remaining.IsPositive() {
+			return customerrors.NewConflictError("Transaction is already fully refunded")
+		}
+
+		amount := req.Amount
+		if amount.IsZero() {
+			amount = remaining
+		}
+		if amount.GreaterThan(remaining) {
+			return customerrors.NewValidationError(fmt.Sprintf("Refund amount exceeds the refundable amount of %s", remaining))
+		}
+		if parent.Type == entities.TransactionTypeTopup && !isWholeRupiah(amount) {
+			return customerrors.NewValidationError("Top-up refunds must be a whole number of rupiah")
+		}
+
+		// A payment is paid back by its recipient, a top-up by the user who made it
+		payerID := parent.UserID
+		if parent.Type == entities.TransactionTypePayment {
+			if parent.CounterpartyUserID == nil {
+				return customerrors.NewConflictError("Recipient of the payment no longer exists")
+			}
+			payerID = *parent.CounterpartyUserID
+		}
+
+		users, err := t.lockUsers(ctx, parent.UserID, payerID)
+		if err != nil {
+			return err
+		}
+
+		if _, ok := users[parent.UserID]; !ok {
+			return customerrors.NewNotFoundError("User not found")
+		}
+
+		payer, ok := users[payerID]
+		if !ok {
+			return customerrors.NewNotFoundError("Recipient not found")
+		}
+
+		if payer.AvailableBalance().LessThan(amount) {
+			return customerrors.NewValidationError("Insufficient balance to refund")
+		}
+
+		refund = entities.NewRefundTransaction(parent, amount, "Refund of "+parent.Reference)
+		refund.Metadata[entities.MetadataRefundedBy] = refundedBy.String()
+		refund.Metadata[entities.MetadataRefundReason] = reason
+
+		if err := t.transactionRepo.Create(ctx, refund); err != nil {
+			return customerrors.NewInternalError("Failed to create transaction", err)
+		}
+
+		// Take the amount out of the paying wallet and book the refund
+		if err := t.userRepo.SubtractBalance(ctx, payerID, amount); err != nil {
+			return err
+		}
+
+		if parent.Type == entities.TransactionTypePayment {
+			if err := t.userRepo.AddBalance(ctx, parent.UserID, amount); err != nil {
+				return customerrors.NewInternalError("Failed to add balance", err)
+			}
+		}
+
+		if err := t.ledgerRepo.CreateEntry(ctx, entities.NewRefundEntry(refund)); err != nil {
+			return customerrors.NewInternalError("Failed to record ledger entry", err)
+		}
+
+		// A top-up reversal is only complete once the payment gateway has paid it out
+		if parent.Type == entities.TransactionTypeTopup {
+			refund.MarkAsProcessing()
+		} else {
+			refund.MarkAsCompleted()
+		}
+		if err := t.transactionRepo.Update(ctx, refund); err != nil {
+			return customerrors.NewInternalError("Failed to update transaction", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if refund.IsProcessing() {
+		// The gateway call stays outside of any database transaction, as for top-ups
+		if _, err := t.paymentGateway.Refund(ctx, parent.Reference, refund.Reference, refund.Amount.String(), reason); err != nil {
+			if returnErr := t.returnRefund(ctx, refund.ID); returnErr != nil {
+				return nil, customerrors.NewInternalError("Failed to refund payment and return the amount", returnErr)
+			}
+			return nil, customerrors.NewInternalError("Failed to refund payment", err)
+		}
+
+		refund.MarkAsCompleted()
+		if err := t.transactionRepo.Update(ctx, refund); err != nil {
+			return nil, customerrors.NewInternalError("Failed to update transaction", err)
+		}
+	}
+
+	response := refund.ToResponse()
+	return &response, nil
+}
+
+// returnRefund gives a top-up reversal the payment gateway refused back to the user's wallet and
+// marks it as failed
+func (t *transactionUseCase) returnRefund(ctx context.Context, refundID uuid.UUID) error {
+	return t.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		refund, err := t.transactionRepo.GetByIDForUpdate(ctx, refundID)
+		if err != nil {
+			return err
+		}
+
+		if !refund.IsProcessing() {
+			return nil
+		}
+
+		if err := t.userRepo.AddBalance(ctx, refund.UserID, refund.Amount); err != nil {
+			return err
+		}
+
+		if err := t.ledgerRepo.CreateEntry(ctx, entities.NewRefundReturnEntry(refund)); err != nil {
+			return err
+		}
+
+		refund.MarkAsFailed()
+		return t.transactionRepo.Update(ctx, refund)
+	})
+}
+
+// lockTransactionForReview locks a transaction and checks that it is still waiting for review
+func (t *transactionUseCase) lockTransactionForReview(ctx context.Context, transactionID uuid.UUID) (*entities.Transaction, error) {
+	transaction, err := t.transactionRepo.GetByIDForUpdate(ctx, transactionID)
+	if err != nil {
+		if customerrors.IsNotFoundError(err) {
+			return nil, customerrors.NewNotFoundError("Transaction not found")
+		}
+		return nil, customerrors.NewInternalError("Failed to get transaction", err)
+	}
+
+	// Only transfers and paid top-ups are held for review; a top-up has no counterparty
+	if !transaction.IsUnderReview() || (transaction.CounterpartyUserID == nil && transaction.Type != entities.TransactionTypeTopup) {
+		return nil, customerrors.NewConflictError(fmt.Sprintf("Transaction is %s, not under review", transaction.Status))
+	}
+
+	return transaction, nil
+}
+
+// recordReview stores who reviewed the transaction and their note in its metadata
+func recordReview(transaction *entities.Transaction, reviewerID uuid.UUID, note string) {
+	if transaction.Metadata == nil {
+		transaction.Metadata = make(map[string]string)
+	}
+	transaction.Metadata[entities.MetadataReviewedBy] = reviewerID.String()
+	if note = strings.TrimSpace(note); note != "" {
+		transaction.Metadata[entities.MetadataReviewNote] = note
+	}
+}
+
+// startOfDay returns midnight of the given day in its location
+func startOfDay(now time.Time) time.Time {
+	year, month, day := now.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, now.Location())
+}
+
+func (t *transactionUseCase) GetTransactionHistory(ctx context.Context, userID uuid.UUID, filter entities.TransactionFilter, page entities.TransactionPageRequest) (*entities.TransactionHistoryListResponse, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, customerrors.NewValidationError("Invalid transaction filter")
+	}
+
+	if page.Limit <= 0 {
+		page.Limit = entities.DefaultTransactionPageSize
+	}
+	if page.Limit > entities.MaxTransactionPageSize {
+		page.Limit = entities.MaxTransactionPageSize
+	}
+
+	// Fetch one extra row to know whether another page follows
+	limit := page.Limit
+	page.Limit++
+	transactions, err := t.transactionRepo.GetByUserID(ctx, userID, filter, page)
 	if err != nil {
 		return nil, customerrors.NewInternalError("Failed to get transactions", err)
 	}
 
-	var response []*entities.TransactionHistoryResponse
+	hasMore := len(transactions) > limit
+	if hasMore {
+		transactions = transactions[:limit]
+	}
+
+	total, err := t.transactionRepo.CountByUserID(ctx, userID, filter)
+	if err != nil {
+		return nil, customerrors.NewInternalError("Failed to count transactions", err)
+	}
+
+	response := &entities.TransactionHistoryListResponse{
+		Transactions: make([]entities.TransactionHistoryResponse, 0, len(transactions)),
+		Pagination: entities.PaginationResponse{
+			Limit:   limit,
+			Count:   len(transactions),
+			Total:   total,
+			HasMore: hasMore,
+		},
+	}
+
 	for _, tx := range transactions {
-		response = append(response, &entities.TransactionHistoryResponse{
-			ID:          tx.ID,
-			Type:        tx.Type,
-			Amount:      tx.Amount,
-			Status:      tx.Status,
-			Description: tx.Description,
-			Reference:   tx.Reference,
-			ProcessedAt: tx.ProcessedAt,
-			CreatedAt:   tx.CreatedAt,
-		})
+		response.Transactions = append(response.Transactions, tx.ToHistoryResponse(userID))
+	}
+
+	if hasMore {
+		response.Pagination.NextCursor = entities.NewTransactionCursor(transactions[len(transactions)-1]).Encode()
 	}
 
 	return response, nil
 }
 
 func (t *transactionUseCase) GetBalance(ctx context.Context, userID uuid.UUID) (*entities.BalanceResponse, error) {
-	balance, err := t.userRepo.GetBalance(ctx, userID)
+	user, err := t.userRepo.GetByID(ctx, userID)
 	if err != nil {
+		if customerrors.IsNotFoundError(err) {
+			return nil, customerrors.NewNotFoundError("User not found")
+		}
 		return nil, customerrors.NewInternalError("Failed to get balance", err)
 	}
 
 	return &entities.BalanceResponse{
-		Balance: balance,
+		Balance:          user.Balance,
+		AvailableBalance: user.AvailableBalance(),
+		HeldBalance:      user.HeldBalance,
 	}, nil
 }
 
-func (t *transactionUseCase) ProcessCallback(ctx context.Context, reference string, status entities.TransactionStatus) error {
-	// Get transaction by reference
-	transaction, err := t.transactionRepo.GetByReference(ctx, reference)
+// RebuildWalletBalance recomputes the balance of a user from the ledger. The user row is locked
+// first so that a transfer or callback running at the same time cannot change the balance
+// between the ledger sum and the update.
+func (t *transactionUseCase) RebuildWalletBalance(ctx context.Context, userID uuid.UUID) (*entities.BalanceResponse, error) {
+	var response *entities.BalanceResponse
+
+	err := t.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		user, err := t.userRepo.GetByIDForUpdate(ctx, userID)
+		if err != nil {
+			if customerrors.IsNotFoundError(err) {
+				return customerrors.NewNotFoundError("User not found")
+			}
+			return customerrors.NewInternalError("Failed to get user", err)
+		}
+
+		balance, err := t.ledgerRepo.RebuildWalletBalance(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+		user.Balance = balance
+
+		response = &entities.BalanceResponse{
+			Balance:          user.Balance,
+			AvailableBalance: user.AvailableBalance(),
+			HeldBalance:      user.HeldBalance,
+		}
+		return nil
+	})
 	if err != nil {
-		return customerrors.NewNotFoundError("Transaction not found")
+		return nil, err
 	}
 
-	// Only process if transaction is in pending or processing state
-	if !transaction.CanBeProcessed() {
-		return customerrors.NewValidationError("Transaction cannot be processed")
-	}
+	return response, nil
+}
 
-	// Update transaction status based on callback
-	switch status {
-	case entities.TransactionStatusCompleted:
-		// For top-up transactions, add balance to user
-		if transaction.Type == entities.TransactionTypeTopup {
-			if err := t.userRepo.AddBalance(ctx, transaction.UserID, transaction.Amount); err != nil {
-				return customerrors.NewInternalError("Failed to add balance", err)
+func (t *transactionUseCase) ProcessCallback(ctx context.Context, reference string, status entities.TransactionStatus) error {
+	return t.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		// Get transaction by reference, locking it against concurrent callbacks
+		transaction, err := t.transactionRepo.GetByReferenceForUpdate(ctx, reference)
+		if err != nil {
+			return customerrors.NewNotFoundError("Transaction not found")
+		}
+
+		// The gateway may settle a top-up that was cancelled here after its deadline; it holds the
+		// money, so the top-up is held for review rather than refused
+		if status == entities.TransactionStatusCompleted && transaction.Type == entities.TransactionTypeTopup && transaction.IsCancelledByExpiry() {
+			delete(transaction.Metadata, entities.MetadataCancelReason)
+			transaction.Metadata[entities.MetadataReviewReason] = entities.ReviewReasonPaidAfterExpiry
+			transaction.MarkForReview()
+			if err := t.transactionRepo.Update(ctx, transaction); err != nil {
+				return customerrors.NewInternalError("Failed to update transaction", err)
 			}
+			return nil
 		}
-		transaction.MarkAsCompleted()
-	case entities.TransactionStatusFailed:
-		transaction.MarkAsFailed()
-	case entities.TransactionStatusCancelled:
-		transaction.MarkAsCancelled()
-	default:
-		return customerrors.NewValidationError(fmt.Sprintf("Invalid transaction status: %s", status))
+
+		// Only process if transaction is in pending or processing state
+		if !transaction.CanBeProcessed() {
+			return customerrors.NewValidationError("Transaction cannot be processed")
+		}
+
+		// Update transaction status based on callback
+		switch status {
+		case entities.TransactionStatusCompleted:
+			// For top-up transactions, add balance to user
+			if transaction.Type == entities.TransactionTypeTopup {
+				if err := t.creditTopup(ctx, transaction); err != nil {
+					return err
+				}
+			} else {
+				transaction.MarkAsCompleted()
+			}
+		case entities.TransactionStatusFailed:
+			transaction.MarkAsFailed()
+		case entities.TransactionStatusCancelled:
+			transaction.MarkAsCancelled()
+		default:
+			return customerrors.NewValidationError(fmt.Sprintf("Invalid transaction status: %s", status))
+		}
+
+		// Update transaction in database
+		if err := t.transactionRepo.Update(ctx, transaction); err != nil {
+			return customerrors.NewInternalError("Failed to update transaction", err)
+		}
+
+		return nil
+	})
+}
+
+// creditTopup adds a paid top-up to the wallet of its user and completes it. The maximum balance
+// is checked again on the locked user row because it may have been lowered since the top-up was
+// started; the gateway has already collected the money, so a top-up above it is held for review
+// instead of being refused.
+func (t *transactionUseCase) creditTopup(ctx context.Context, transaction *entities.Transaction) error {
+	user, err := t.userRepo.GetByIDForUpdate(ctx, transaction.UserID)
+	if err != nil {
+		return customerrors.NewNotFoundError("User not found")
 	}
 
-	// Update transaction in database
-	if err := t.transactionRepo.Update(ctx, transaction); err != nil {
-		return customerrors.NewInternalError("Failed to update transaction", err)
+	if err := t.limits.CheckCredit(ctx, user, transaction.Amount); err != nil {
+		if !customerrors.IsLimitExceededError(err) {
+			return err
+		}
+		if transaction.Metadata == nil {
+			transaction.Metadata = make(map[string]string)
+		}
+		transaction.Metadata[entities.MetadataReviewReason] = entities.LimitReasonMaxBalance
+		transaction.MarkForReview()
+		return nil
 	}
 
+	return t.bookTopup(ctx, transaction)
+}
+
+// bookTopup records the ledger entry of a paid top-up, credits its user and completes it
+func (t *transactionUseCase) bookTopup(ctx context.Context, transaction *entities.Transaction) error {
+	if err := t.ledgerRepo.CreateEntry(ctx, entities.NewTopupEntry(transaction)); err != nil {
+		return customerrors.NewInternalError("Failed to record ledger entry", err)
+	}
+	if err := t.userRepo.AddBalance(ctx, transaction.UserID, transaction.Amount); err != nil {
+		return customerrors.NewInternalError("Failed to add balance", err)
+	}
+
+	transaction.MarkAsCompleted()
 	return nil
 }
 
@@ -244,3 +1151,252 @@ func (t *transactionUseCase) GetTransactionByReference(ctx context.Context, refe
 
 	return transaction, nil
 }
+
+func (t *transactionUseCase) GetTransactionDetail(ctx context.Context, viewerID, transactionID uuid.UUID) (*entities.TransactionDetailResponse, error) {
+	transaction, err := t.transactionRepo.GetByID(ctx, transactionID)
+	if err != nil {
+		if customerrors.IsNotFoundError(err) {
+			return nil, customerrors.NewNotFoundError("Transaction not found")
+		}
+		return nil, customerrors.NewInternalError("Failed to get transaction", err)
+	}
+
+	return t.buildTransactionDetail(ctx, viewerID, transaction)
+}
+
+func (t *transactionUseCase) GetTransactionDetailByReference(ctx context.Context, viewerID uuid.UUID, reference string) (*entities.TransactionDetailResponse, error) {
+	transaction, err := t.transactionRepo.GetByReference(ctx, reference)
+	if err != nil {
+		if customerrors.IsNotFoundError(err) {
+			return nil, customerrors.NewNotFoundError("Transaction not found")
+		}
+		return nil, customerrors.NewInternalError("Failed to get transaction", err)
+	}
+
+	return t.buildTransactionDetail(ctx, viewerID, transaction)
+}
+
+// buildTransactionDetail checks that the viewer took part in the transaction and assembles
+// its details with the counterparty seen from the viewer's side and the status timeline
+func (t *transactionUseCase) buildTransactionDetail(ctx context.Context, viewerID uuid.UUID, transaction *entities.Transaction) (*entities.TransactionDetailResponse, error) {
+	// Other users get the same answer as for a missing transaction so references cannot be probed
+	if !transaction.IsVisibleTo(viewerID) {
+		return nil, customerrors.NewNotFoundError("Transaction not found")
+	}
+
+	var counterparty *entities.CounterpartySummary
+	if counterpartyID := transaction.CounterpartyFor(viewerID); counterpartyID != nil {
+		user, err := t.userRepo.GetByID(ctx, *counterpartyID)
+		if err != nil && !customerrors.IsNotFoundError(err) {
+			return nil, customerrors.NewInternalError("Failed to get counterparty", err)
+		}
+		if err == nil {
+			counterparty = user.ToCounterpartySummary()
+		}
+	}
+
+	timeline, err := t.transactionRepo.GetStatusHistory(ctx, transaction.ID)
+	if err != nil {
+		return nil, customerrors.NewInternalError("Failed to get transaction status history", err)
+	}
+
+	return transaction.ToDetailResponse(viewerID, counterparty, timeline), nil
+}
+
+func (t *transactionUseCase) GetTransferReceipt(ctx context.Context, viewerID, transactionID uuid.UUID) (*entities.TransferReceipt, error) {
+	transaction, err := t.transactionRepo.GetByID(ctx, transactionID)
+	if err != nil {
+		if customerrors.IsNotFoundError(err) {
+			return nil, err
+		}
+		return nil, customerrors.NewInternalError("Failed to get transaction", err)
+	}
+
+	if transaction.Type != entities.TransactionTypeTransfer || transaction.CounterpartyUserID == nil || !transaction.IsVisibleTo(viewerID) {
+		return nil, customerrors.NewNotFoundError("Transfer not found")
+	}
+
+	sender, err := t.userRepo.GetByID(ctx, transaction.UserID)
+	if err != nil {
+		return nil, customerrors.NewInternalError("Failed to get sender", err)
+	}
+
+	recipient, err := t.userRepo.GetByID(ctx, *transaction.CounterpartyUserID)
+	if err != nil {
+		return nil, customerrors.NewInternalError("Failed to get recipient", err)
+	}
+
+	return entities.NewTransferReceipt(transaction, sender, recipient), nil
+}
+
+func (t *transactionUseCase) ReconcilePendingTransactions(ctx context.Context, olderThan time.Time, limit int) ([]*ReconcileResult, error) {
+	transactions, err := t.transactionRepo.GetPendingTransactions(ctx, entities.TransactionTypeTopup, olderThan, limit)
+	if err != nil {
+		return nil, customerrors.NewInternalError("Failed to get pending transactions", err)
+	}
+
+	results := make([]*ReconcileResult, 0, len(transactions))
+	for _, transaction := range transactions {
+		results = append(results, t.reconcileTransaction(ctx, transaction))
+	}
+
+	return results, nil
+}
+
+func (t *transactionUseCase) ExpireTransactions(ctx context.Context, now time.Time, limit int) ([]*ReconcileResult, error) {
+	transactions, err := t.transactionRepo.GetExpiredTransactions(ctx, now, limit)
+	if err != nil {
+		return nil, customerrors.NewInternalError("Failed to get expired transactions", err)
+	}
+
+	results := make([]*ReconcileResult, 0, len(transactions))
+	for _, transaction := range transactions {
+		results = append(results, t.expireTransaction(ctx, transaction))
+	}
+
+	return results, nil
+}
+
+// expireTransaction cancels an overdue transaction. A top-up that reached the payment gateway
+// follows the gateway instead: it is settled or cancelled with the status found there and left
+// alone while the gateway still waits for the customer, so it can still be paid. It is only
+// cancelled here when the gateway does not know it. Authorized payments give their hold back to
+// the sender.
+func (t *transactionUseCase) expireTransaction(ctx context.Context, transaction *entities.Transaction) *ReconcileResult {
+	if transaction.Type == entities.TransactionTypeTopup && transaction.PaymentGatewayID != "" {
+		result := t.reconcileTransaction(ctx, transaction)
+		if result.Err == nil || !errors.Is(result.Err, ErrPaymentNotFound) {
+			return result
+		}
+	}
+
+	result := &ReconcileResult{
+		TransactionID: transaction.ID,
+		Reference:     transaction.Reference,
+		Status:        transaction.Status,
+	}
+
+	err := t.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		locked, err := t.transactionRepo.GetByReferenceForUpdate(ctx, transaction.Reference)
+		if err != nil {
+			return customerrors.NewNotFoundError("Transaction not found")
+		}
+
+		// A callback, capture or void may have finished the transaction in the meantime
+		if !locked.CanBeProcessed() && !locked.IsAuthorized() {
+			result.Status = locked.Status
+			return nil
+		}
+
+		if locked.IsAuthorized() {
+			if err := t.releaseAuthorization(ctx, locked, entities.CancelReasonExpired); err != nil {
+				return err
+			}
+			result.Status = locked.Status
+			result.Updated = true
+			return nil
+		}
+
+		if locked.Metadata == nil {
+			locked.Metadata = make(map[string]string)
+		}
+		locked.Metadata[entities.MetadataCancelReason] = entities.CancelReasonExpired
+		locked.MarkAsCancelled()
+		if err := t.transactionRepo.Update(ctx, locked); err != nil {
+			return customerrors.NewInternalError("Failed to update transaction", err)
+		}
+
+		result.Status = locked.Status
+		result.Updated = true
+		return nil
+	})
+	if err != nil {
+		result.Err = err
+	}
+
+	return result
+}
+
+// reconcileTransaction pulls the gateway status of a single transaction and applies it
+// through ProcessCallback, exactly as if the gateway notification had been received
+func (t *transactionUseCase) reconcileTransaction(ctx context.Context, transaction *entities.Transaction) *ReconcileResult {
+	result := &ReconcileResult{
+		TransactionID: transaction.ID,
+		Reference:     transaction.Reference,
+		Status:        transaction.Status,
+	}
+
+	gatewayResp, err := t.paymentGateway.GetTransactionStatus(ctx, transaction.Reference)
+	if err != nil {
+		result.Err = customerrors.NewInternalError("Failed to get payment status", err)
+		return result
+	}
+	result.GatewayStatus = gatewayResp.Status
+
+	status := entities.MapGatewayStatus(gatewayResp.Status, gatewayResp.FraudStatus)
+	if status == "" {
+		result.Err = customerrors.NewValidationError(fmt.Sprintf("Unknown transaction status: %s", gatewayResp.Status))
+		return result
+	}
+
+	// Still waiting for the customer; check again on the next run
+	if status == entities.TransactionStatusPending {
+		return result
+	}
+
+	if gatewayResp.GrossAmount != "" {
+		grossAmount, err := decimal.NewFromString(gatewayResp.GrossAmount)
+		if err != nil || !grossAmount.Equal(transaction.TotalDebit()) {
+			result.Err = customerrors.NewValidationError("Gross amount does not match transaction amount")
+			return result
+		}
+	}
+
+	// As for notifications, a payment made another way than the fee was quoted for is not applied
+	if !transaction.PaidWith(gatewayResp.PaymentType) {
+		result.Err = customerrors.NewValidationError("Payment type does not match the payment method of the transaction")
+		return result
+	}
+
+	if err := t.ProcessCallback(ctx, transaction.Reference, status); err != nil {
+		result.Err = err
+		return result
+	}
+
+	result.Status = status
+	result.Updated = true
+
+	return result
+}
+
+// lockUsers locks the given user rows in a deterministic order to avoid deadlocks
+// between concurrent transfers in opposite directions. Missing users are omitted.
+func (t *transactionUseCase) lockUsers(ctx context.Context, ids ...uuid.UUID) (map[uuid.UUID]*entities.User, error) {
+	sorted := append([]uuid.UUID(nil), ids...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i][:], sorted[j][:]) < 0
+	})
+
+	users := make(map[uuid.UUID]*entities.User, len(sorted))
+	for _, id := range sorted {
+		if _, ok := users[id]; ok {
+			continue
+		}
+
+		user, err := t.userRepo.GetByIDForUpdate(ctx, id)
+		if err != nil {
+			if customerrors.IsNotFoundError(err) {
+				continue
+			}
+			return nil, customerrors.NewInternalError("Failed to lock user", err)
+		}
+		users[id] = user
+	}
+
+	return users, nil
+}
+
+// isWholeRupiah checks that an amount has no fraction, as the payment gateway only moves whole rupiah
+func isWholeRupiah(amount decimal.Decimal) bool {
+	return amount.Equal(amount.Truncate(0))
+}
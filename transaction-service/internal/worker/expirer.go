@@ -0,0 +1,64 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"go-transaction-service/internal/config"
+	"go-transaction-service/internal/usecase"
+	"go.uber.org/zap"
+)
+
+// Expirer periodically cancels unfinished transactions that passed their expiry deadline
+type Expirer struct {
+	transactionUseCase usecase.TransactionUseCase
+	config             config.ExpiryConfig
+	logger             *zap.Logger
+}
+
+func NewExpirer(transactionUseCase usecase.TransactionUseCase, config config.ExpiryConfig, logger *zap.Logger) *Expirer {
+	return &Expirer{
+		transactionUseCase: transactionUseCase,
+		config:             config,
+		logger:             logger,
+	}
+}
+
+// Start runs the expirer on every interval until the context is cancelled
+func (e *Expirer) Start(ctx context.Context) {
+	e.logger.Info("Starting transaction expirer",
+		zap.Duration("interval", e.config.CheckInterval),
+		zap.Int("batch_size", e.config.BatchSize))
+
+	runEvery(ctx, e.config.CheckInterval, e.RunOnce)
+
+	e.logger.Info("Transaction expirer stopped")
+}
+
+// RunOnce expires a single batch of overdue transactions
+func (e *Expirer) RunOnce(ctx context.Context) {
+	results, err := e.transactionUseCase.ExpireTransactions(ctx, time.Now(), e.config.BatchSize)
+	if err != nil {
+		e.logger.Error("Failed to expire transactions", zap.Error(err))
+		return
+	}
+
+	for _, result := range results {
+		if result.Err != nil {
+			e.logger.Warn("Failed to expire transaction",
+				zap.Error(result.Err),
+				zap.String("transaction_id", result.TransactionID.String()),
+				zap.String("reference", result.Reference),
+				zap.String("gateway_status", result.GatewayStatus))
+			continue
+		}
+
+		if result.Updated {
+			e.logger.Info("Expired transaction finalized",
+				zap.String("transaction_id", result.TransactionID.String()),
+				zap.String("reference", result.Reference),
+				zap.String("gateway_status", result.GatewayStatus),
+				zap.String("status", string(result.Status)))
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"go-transaction-service/internal/config"
+	"go-transaction-service/internal/usecase"
+	"go.uber.org/zap"
+)
+
+// KeyRotator periodically reloads the JWT signing keys and replaces the signing key when it is due
+type KeyRotator struct {
+	keyring usecase.TokenKeyring
+	config  config.JWTConfig
+	logger  *zap.Logger
+}
+
+func NewKeyRotator(keyring usecase.TokenKeyring, config config.JWTConfig, logger *zap.Logger) *KeyRotator {
+	return &KeyRotator{
+		keyring: keyring,
+		config:  config,
+		logger:  logger,
+	}
+}
+
+// Start runs the key rotator on every interval until the context is cancelled
+func (r *KeyRotator) Start(ctx context.Context) {
+	r.logger.Info("Starting JWT key rotator",
+		zap.Duration("interval", r.config.KeyCheckInterval),
+		zap.Duration("rotation_interval", r.config.RotationInterval))
+
+	runEvery(ctx, r.config.KeyCheckInterval, r.RunOnce)
+
+	r.logger.Info("JWT key rotator stopped")
+}
+
+// RunOnce reloads the keys, removes expired ones and rotates the signing key if it is older than the rotation interval
+func (r *KeyRotator) RunOnce(ctx context.Context) {
+	if _, err := r.keyring.Rotate(time.Now()); err != nil {
+		r.logger.Error("Failed to rotate JWT signing keys", zap.Error(err))
+	}
+}
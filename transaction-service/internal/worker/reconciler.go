@@ -0,0 +1,76 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"go-transaction-service/internal/config"
+	"go-transaction-service/internal/usecase"
+	"go.uber.org/zap"
+)
+
+// Reconciler periodically asks the payment gateway about top-ups that never received
+// a callback and applies the final status it reports
+type Reconciler struct {
+	transactionUseCase usecase.TransactionUseCase
+	config             config.ReconcilerConfig
+	logger             *zap.Logger
+}
+
+func NewReconciler(transactionUseCase usecase.TransactionUseCase, config config.ReconcilerConfig, logger *zap.Logger) *Reconciler {
+	return &Reconciler{
+		transactionUseCase: transactionUseCase,
+		config:             config,
+		logger:             logger,
+	}
+}
+
+// Start runs the reconciler on every interval until the context is cancelled
+func (r *Reconciler) Start(ctx context.Context) {
+	r.logger.Info("Starting pending transaction reconciler",
+		zap.Duration("interval", r.config.Interval),
+		zap.Duration("stale_after", r.config.StaleAfter),
+		zap.Int("batch_size", r.config.BatchSize))
+
+	runEvery(ctx, r.config.Interval, r.RunOnce)
+
+	r.logger.Info("Pending transaction reconciler stopped")
+}
+
+// RunOnce reconciles a single batch of stale transactions
+func (r *Reconciler) RunOnce(ctx context.Context) {
+	olderThan := time.Now().Add(-r.config.StaleAfter)
+
+	results, err := r.transactionUseCase.ReconcilePendingTransactions(ctx, olderThan, r.config.BatchSize)
+	if err != nil {
+		r.logger.Error("Failed to reconcile pending transactions", zap.Error(err))
+		return
+	}
+
+	updated := 0
+	for _, result := range results {
+		if result.Err != nil {
+			r.logger.Warn("Failed to reconcile transaction",
+				zap.Error(result.Err),
+				zap.String("transaction_id", result.TransactionID.String()),
+				zap.String("reference", result.Reference),
+				zap.String("gateway_status", result.GatewayStatus))
+			continue
+		}
+
+		if result.Updated {
+			updated++
+			r.logger.Info("Transaction reconciled",
+				zap.String("transaction_id", result.TransactionID.String()),
+				zap.String("reference", result.Reference),
+				zap.String("gateway_status", result.GatewayStatus),
+				zap.String("status", string(result.Status)))
+		}
+	}
+
+	if len(results) > 0 {
+		r.logger.Info("Reconciliation run finished",
+			zap.Int("checked", len(results)),
+			zap.Int("updated", updated))
+	}
+}
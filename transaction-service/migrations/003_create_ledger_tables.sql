@@ -0,0 +1,40 @@
+-- Create ledger journal table (one row per balanced business event)
+CREATE TABLE ledger_journals (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    transaction_id UUID NULL REFERENCES transactions(id) ON DELETE RESTRICT,
+    description TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create ledger postings table (debit/credit lines of a journal entry)
+CREATE TABLE ledger_postings (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    journal_id UUID NOT NULL REFERENCES ledger_journals(id) ON DELETE RESTRICT,
+    account VARCHAR(50) NOT NULL,
+    user_id UUID NULL REFERENCES users(id) ON DELETE RESTRICT,
+    direction VARCHAR(10) NOT NULL CHECK (direction IN ('debit', 'credit')),
+    amount DECIMAL(15,2) NOT NULL CHECK (amount > 0),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (account <> 'user_wallet' OR user_id IS NOT NULL)
+);
+
+-- Create indexes for better performance
+CREATE INDEX idx_ledger_journals_transaction_id ON ledger_journals(transaction_id);
+CREATE INDEX idx_ledger_journals_created_at ON ledger_journals(created_at);
+CREATE INDEX idx_ledger_postings_journal_id ON ledger_postings(journal_id);
+CREATE INDEX idx_ledger_postings_account_user ON ledger_postings(account, user_id);
+
+-- Backfill opening balances so that users.balance can be rebuilt from the journal
+WITH opening AS (
+    SELECT id AS user_id, balance, uuid_generate_v4() AS journal_id
+    FROM users
+    WHERE balance > 0
+), journals AS (
+    INSERT INTO ledger_journals (id, transaction_id, description)
+    SELECT journal_id, NULL, 'Opening balance'
+    FROM opening
+)
+INSERT INTO ledger_postings (journal_id, account, user_id, direction, amount)
+SELECT journal_id, 'opening_balance', NULL, 'debit', balance FROM opening
+UNION ALL
+SELECT journal_id, 'user_wallet', user_id, 'credit', balance FROM opening;
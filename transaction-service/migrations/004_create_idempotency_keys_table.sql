@@ -0,0 +1,15 @@
+-- Create idempotency keys table (one row per client supplied Idempotency-Key)
+CREATE TABLE idempotency_keys (
+    key VARCHAR(255) NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    request_hash VARCHAR(64) NOT NULL,
+    response_code INTEGER NULL,
+    response_body BYTEA NULL,
+    completed_at TIMESTAMP NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, key)
+);
+
+-- Create indexes for better performance
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);
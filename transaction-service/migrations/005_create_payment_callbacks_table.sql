@@ -0,0 +1,24 @@
+-- Create payment callbacks table (every gateway notification, valid or rejected)
+CREATE TABLE payment_callbacks (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    transaction_id UUID NULL REFERENCES transactions(id) ON DELETE SET NULL,
+    order_id VARCHAR(100),
+    gateway_transaction_id VARCHAR(255),
+    gateway_status VARCHAR(50),
+    status_code VARCHAR(10),
+    gross_amount VARCHAR(50),
+    raw_payload JSONB NOT NULL DEFAULT '{}',
+    signature VARCHAR(255),
+    verified BOOLEAN NOT NULL DEFAULT FALSE,
+    rejection_reason TEXT,
+    received_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    processed_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create indexes for better performance
+CREATE INDEX idx_payment_callbacks_transaction_received ON payment_callbacks(transaction_id, received_at);
+CREATE INDEX idx_payment_callbacks_order_id ON payment_callbacks(order_id);
+CREATE INDEX idx_payment_callbacks_gateway_transaction_id ON payment_callbacks(gateway_transaction_id);
+CREATE INDEX idx_payment_callbacks_gateway_status ON payment_callbacks(gateway_status);
@@ -0,0 +1,3 @@
+-- Create index used by the reconciler to find stale pending and processing transactions
+CREATE INDEX idx_transactions_pending_updated_at ON transactions(type, updated_at)
+    WHERE status IN ('pending', 'processing');
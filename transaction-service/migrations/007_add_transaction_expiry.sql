@@ -0,0 +1,11 @@
+-- Add expiry deadline to transactions
+ALTER TABLE transactions ADD COLUMN expires_at TIMESTAMP NULL;
+
+-- Give unfinished top-ups created before expiry existed a deadline
+UPDATE transactions
+SET expires_at = created_at + INTERVAL '24 hours'
+WHERE type = 'topup' AND status IN ('pending', 'processing');
+
+-- Create indexes for better performance
+CREATE INDEX idx_transactions_unfinished_expires_at ON transactions(expires_at)
+    WHERE status IN ('pending', 'processing');
@@ -0,0 +1,34 @@
+-- Create transaction status history table (one row per status change)
+CREATE TABLE transaction_status_history (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    transaction_id UUID NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    from_status VARCHAR(20) NULL,
+    to_status VARCHAR(20) NOT NULL,
+    changed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create indexes for better performance
+CREATE INDEX idx_transaction_status_history_transaction_id ON transaction_status_history(transaction_id, changed_at);
+
+-- Record every status change, whichever code path writes it
+CREATE OR REPLACE FUNCTION record_transaction_status_change() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        INSERT INTO transaction_status_history (transaction_id, from_status, to_status, changed_at)
+        VALUES (NEW.id, NULL, NEW.status, COALESCE(NEW.created_at, CURRENT_TIMESTAMP));
+    ELSIF NEW.status IS DISTINCT FROM OLD.status THEN
+        INSERT INTO transaction_status_history (transaction_id, from_status, to_status, changed_at)
+        VALUES (NEW.id, OLD.status, NEW.status, COALESCE(NEW.updated_at, CURRENT_TIMESTAMP));
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_transactions_status_history
+    AFTER INSERT OR UPDATE OF status ON transactions
+    FOR EACH ROW EXECUTE FUNCTION record_transaction_status_change();
+
+-- Backfill the current status of existing transactions
+INSERT INTO transaction_status_history (transaction_id, from_status, to_status, changed_at)
+SELECT id, NULL, status, COALESCE(updated_at, created_at)
+FROM transactions;
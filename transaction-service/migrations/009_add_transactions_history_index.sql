@@ -0,0 +1,2 @@
+-- Create index backing keyset pagination of transaction history on (created_at, id)
+CREATE INDEX idx_transactions_user_created_at_id ON transactions(user_id, created_at DESC, id DESC);
@@ -0,0 +1,16 @@
+-- Record the other party of a transaction and which way the money moves for its initiator
+ALTER TABLE transactions
+    ADD COLUMN counterparty_user_id UUID NULL REFERENCES users(id) ON DELETE SET NULL,
+    ADD COLUMN direction VARCHAR(10) NOT NULL DEFAULT 'outgoing' CHECK (direction IN ('incoming', 'outgoing'));
+
+-- Top-ups credit the initiator, payments and transfers debit it
+UPDATE transactions SET direction = 'incoming' WHERE type = 'topup';
+
+-- Payments used to keep the recipient in metadata only
+UPDATE transactions t
+SET counterparty_user_id = u.id
+FROM users u
+WHERE u.id::text = t.metadata->>'to_user_id';
+
+-- Back the recipient side of the transaction history
+CREATE INDEX idx_transactions_counterparty_created_at_id ON transactions(counterparty_user_id, created_at DESC, id DESC);
@@ -0,0 +1,8 @@
+-- Fee charged to the initiating user on top of the transaction amount
+ALTER TABLE transactions ADD COLUMN fee DECIMAL(15,2) NOT NULL DEFAULT 0.00 CHECK (fee >= 0);
+
+-- Back recipient lookup by phone number for transfers
+CREATE INDEX idx_users_phone ON users(phone);
+
+-- Back the daily limit check on outgoing payments and transfers
+CREATE INDEX idx_transactions_user_type_created_at ON transactions(user_id, type, created_at);
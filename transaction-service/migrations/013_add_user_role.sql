@@ -0,0 +1,5 @@
+-- Add role used for access control of the admin API
+ALTER TABLE users ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'user' CHECK (role IN ('user', 'support', 'admin'));
+
+-- Create indexes for better performance
+CREATE INDEX idx_users_role ON users(role);
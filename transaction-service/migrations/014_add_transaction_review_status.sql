@@ -0,0 +1,4 @@
+-- Allow transactions to wait for manual review before funds move
+ALTER TABLE transactions DROP CONSTRAINT transactions_status_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_status_check
+    CHECK (status IN ('pending', 'review', 'processing', 'completed', 'failed', 'cancelled'));
@@ -0,0 +1,4 @@
+-- Add transaction PIN required for outgoing payments and transfers (bcrypt hash)
+ALTER TABLE users ADD COLUMN pin_hash VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN pin_failed_attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN pin_locked_until TIMESTAMP NULL;
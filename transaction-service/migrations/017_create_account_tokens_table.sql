@@ -0,0 +1,18 @@
+-- Track when the current email address was confirmed
+ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMP NULL;
+
+-- Create account tokens table (password reset and email verification links, only the SHA-256 of the token is stored)
+CREATE TABLE account_tokens (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    purpose VARCHAR(32) NOT NULL CHECK (purpose IN ('password_reset', 'email_verification')),
+    email VARCHAR(255) NOT NULL,
+    token_hash VARCHAR(64) UNIQUE NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create indexes for better performance
+CREATE INDEX idx_account_tokens_user_purpose ON account_tokens(user_id, purpose);
+CREATE INDEX idx_account_tokens_expires_at ON account_tokens(expires_at);
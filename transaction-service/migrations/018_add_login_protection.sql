@@ -0,0 +1,20 @@
+-- Track failed logins for progressive delays and temporary lockout
+ALTER TABLE users ADD COLUMN failed_login_attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN last_failed_login_at TIMESTAMP NULL;
+ALTER TABLE users ADD COLUMN locked_until TIMESTAMP NULL;
+
+-- Create security events table (login audit trail, also counts failed logins per IP)
+CREATE TABLE security_events (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NULL REFERENCES users(id) ON DELETE SET NULL,
+    type VARCHAR(32) NOT NULL,
+    email VARCHAR(255) NOT NULL DEFAULT '',
+    ip_address VARCHAR(45) NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    details TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create indexes for better performance
+CREATE INDEX idx_security_events_user_id ON security_events(user_id, created_at DESC);
+CREATE INDEX idx_security_events_ip_type ON security_events(ip_address, type, created_at);
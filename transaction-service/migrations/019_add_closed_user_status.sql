@@ -0,0 +1,4 @@
+-- Allow users to close their own account
+ALTER TABLE users DROP CONSTRAINT users_status_check;
+ALTER TABLE users ADD CONSTRAINT users_status_check
+    CHECK (status IN ('active', 'inactive', 'blocked', 'closed'));
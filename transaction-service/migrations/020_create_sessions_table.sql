@@ -0,0 +1,23 @@
+-- Create sessions table (one row per login, the refresh token family of the login)
+CREATE TABLE sessions (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    device VARCHAR(100) NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    ip_address VARCHAR(45) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_seen_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Sessions started before this migration have no client details
+INSERT INTO sessions (id, user_id, device, created_at, last_seen_at)
+SELECT family_id, user_id, 'Unknown device', MIN(created_at), MAX(created_at)
+FROM refresh_tokens
+GROUP BY family_id, user_id;
+
+-- Every refresh token belongs to a session
+ALTER TABLE refresh_tokens ADD CONSTRAINT fk_refresh_tokens_session
+    FOREIGN KEY (family_id) REFERENCES sessions(id) ON DELETE CASCADE;
+
+-- Create indexes for better performance
+CREATE INDEX idx_sessions_user_id ON sessions(user_id, last_seen_at DESC);
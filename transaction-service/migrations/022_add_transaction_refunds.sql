@@ -0,0 +1,11 @@
+-- Allow completed transactions to be reversed by linked refund transactions
+ALTER TABLE transactions DROP CONSTRAINT transactions_type_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_type_check
+    CHECK (type IN ('topup', 'payment', 'transfer', 'refund'));
+
+ALTER TABLE transactions
+    ADD COLUMN parent_transaction_id UUID NULL REFERENCES transactions(id) ON DELETE RESTRICT,
+    ADD CONSTRAINT transactions_refund_parent_check CHECK ((type = 'refund') = (parent_transaction_id IS NOT NULL));
+
+-- Back the sum of refunds already made against a transaction
+CREATE INDEX idx_transactions_parent_transaction_id ON transactions(parent_transaction_id);
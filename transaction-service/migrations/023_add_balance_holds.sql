@@ -0,0 +1,9 @@
+-- Reserve part of the balance for authorized payments; only balance - held_balance can be spent
+ALTER TABLE users
+    ADD COLUMN held_balance DECIMAL(15,2) NOT NULL DEFAULT 0.00,
+    ADD CONSTRAINT users_held_balance_check CHECK (held_balance >= 0 AND held_balance <= balance);
+
+-- Allow payments to hold funds until the recipient captures or voids them
+ALTER TABLE transactions DROP CONSTRAINT transactions_status_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_status_check
+    CHECK (status IN ('pending', 'review', 'authorized', 'processing', 'completed', 'failed', 'cancelled'));
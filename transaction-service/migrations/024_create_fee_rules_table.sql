@@ -0,0 +1,15 @@
+-- Create fee rules table (fee per transaction type, optionally per payment method; '' applies to any method)
+CREATE TABLE fee_rules (
+    id UUID PRIMARY KEY,
+    transaction_type VARCHAR(20) NOT NULL CHECK (transaction_type IN ('topup', 'payment', 'transfer')),
+    payment_method VARCHAR(50) NOT NULL DEFAULT '',
+    flat_fee DECIMAL(15,2) NOT NULL DEFAULT 0.00 CHECK (flat_fee >= 0),
+    percent DECIMAL(7,4) NOT NULL DEFAULT 0 CHECK (percent >= 0),
+    tiers JSONB NOT NULL DEFAULT '[]',
+    min_fee DECIMAL(15,2) NOT NULL DEFAULT 0.00 CHECK (min_fee >= 0),
+    max_fee DECIMAL(15,2) NOT NULL DEFAULT 0.00 CHECK (max_fee >= 0),
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (transaction_type, payment_method)
+);
@@ -0,0 +1,11 @@
+-- Create user limits table (per-user overrides of the global transaction limits, set by administrators)
+CREATE TABLE user_limits (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    max_balance DECIMAL(15,2) NULL CHECK (max_balance >= 0),
+    transactions JSONB NOT NULL DEFAULT '{}',
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Back the daily and monthly usage of a user per transaction type
+CREATE INDEX idx_transactions_user_type_created_at ON transactions(user_id, type, created_at);
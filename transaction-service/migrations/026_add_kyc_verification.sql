@@ -0,0 +1,30 @@
+-- Add the KYC tier of users; existing users start unverified
+ALTER TABLE users ADD COLUMN kyc_tier VARCHAR(20) NOT NULL DEFAULT 'unverified' CHECK (kyc_tier IN ('unverified', 'basic', 'full'));
+
+-- Create KYC submissions table (identity data and documents reviewed by administrators; the files are kept in the blob store)
+CREATE TABLE kyc_submissions (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    requested_tier VARCHAR(20) NOT NULL CHECK (requested_tier IN ('basic', 'full')),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected')),
+    full_name VARCHAR(100) NOT NULL,
+    date_of_birth DATE NOT NULL,
+    nationality CHAR(2) NOT NULL,
+    id_type VARCHAR(20) NOT NULL CHECK (id_type IN ('national_id', 'passport', 'driver_license')),
+    id_number VARCHAR(50) NOT NULL,
+    address VARCHAR(255) NOT NULL,
+    documents JSONB NOT NULL DEFAULT '[]',
+    reviewed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    review_reason TEXT NOT NULL DEFAULT '',
+    reviewed_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- A user has at most one submission waiting for review
+CREATE UNIQUE INDEX idx_kyc_submissions_pending_user ON kyc_submissions(user_id) WHERE status = 'pending';
+
+-- Create indexes for better performance
+CREATE INDEX idx_kyc_submissions_user_id ON kyc_submissions(user_id, created_at DESC);
+CREATE INDEX idx_kyc_submissions_status ON kyc_submissions(status, created_at);
+CREATE INDEX idx_users_kyc_tier ON users(kyc_tier);
@@ -0,0 +1,2 @@
+-- Add a lease to in-flight idempotency keys so a retry can take over a key whose request died with its process
+ALTER TABLE idempotency_keys ADD COLUMN locked_until TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
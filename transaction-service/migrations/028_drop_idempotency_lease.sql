@@ -0,0 +1,2 @@
+-- In-flight idempotency keys are no longer taken over by retries: the request may have moved the money already
+ALTER TABLE idempotency_keys DROP COLUMN locked_until;
@@ -9,6 +9,7 @@ import (
 type CustomError struct {
 	Code    int
 	Message string
+	Reason  string // Machine-readable cause for clients, empty when the code says enough
 	Err     error
 }
 
@@ -67,6 +68,31 @@ func NewInternalError(message string, err error) *CustomError {
 	}
 }
 
+func NewTooManyRequestsError(message string) *CustomError {
+	return &CustomError{
+		Code:    http.StatusTooManyRequests,
+		Message: message,
+	}
+}
+
+// NewLimitExceededError reports a transaction refused by a limit; the reason names the limit
+func NewLimitExceededError(reason, message string) *CustomError {
+	return &CustomError{
+		Code:    http.StatusBadRequest,
+		Message: message,
+		Reason:  reason,
+	}
+}
+
+// NewFeatureLockedError reports a feature the user has not unlocked yet; the reason names what is missing
+func NewFeatureLockedError(reason, message string) *CustomError {
+	return &CustomError{
+		Code:    http.StatusForbidden,
+		Message: message,
+		Reason:  reason,
+	}
+}
+
 func NewBadRequestError(message string) *CustomError {
 	return &CustomError{
 		Code:    http.StatusBadRequest,
@@ -96,6 +122,20 @@ func IsNotFoundError(err error) bool {
 	return false
 }
 
+func IsConflictError(err error) bool {
+	if customErr, ok := err.(*CustomError); ok {
+		return customErr.Code == http.StatusConflict
+	}
+	return false
+}
+
+func IsTooManyRequestsError(err error) bool {
+	if customErr, ok := err.(*CustomError); ok {
+		return customErr.Code == http.StatusTooManyRequests
+	}
+	return false
+}
+
 func IsInternalError(err error) bool {
 	if customErr, ok := err.(*CustomError); ok {
 		return customErr.Code == http.StatusInternalServerError
@@ -103,6 +143,14 @@ func IsInternalError(err error) bool {
 	return false
 }
 
+// IsLimitExceededError checks for a refusal created by NewLimitExceededError
+func IsLimitExceededError(err error) bool {
+	if customErr, ok := err.(*CustomError); ok {
+		return customErr.Code == http.StatusBadRequest && customErr.Reason != ""
+	}
+	return false
+}
+
 func GetErrorCode(err error) int {
 	if customErr, ok := err.(*CustomError); ok {
 		return customErr.Code
@@ -22,6 +22,7 @@ type Response struct {
 type ErrorInfo struct {
 	Code    int    `json:"code"`
 	Message string `json:"message"`
+	Reason  string `json:"reason,omitempty"`
 	Details string `json:"details,omitempty"`
 }
 
@@ -89,6 +90,7 @@ func HandleError(c echo.Context, err error) error {
 			Error: &ErrorInfo{
 				Code:    customErr.Code,
 				Message: customErr.Message,
+				Reason:  customErr.Reason,
 			},
 		})
 	}
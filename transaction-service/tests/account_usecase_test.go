@@ -0,0 +1,276 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-transaction-service/internal/config"
+	"go-transaction-service/internal/domain/entities"
+	"go-transaction-service/internal/mocks"
+	"go-transaction-service/internal/usecase"
+	"go-transaction-service/pkg/errors"
+	"go.uber.org/zap"
+)
+
+func newAccountTestConfig() *config.Config {
+	return &config.Config{
+		Account: config.AccountConfig{
+			PasswordResetTTL:     time.Hour,
+			EmailVerificationTTL: 48 * time.Hour,
+			LinkBaseURL:          "https://pintro.example/",
+		},
+	}
+}
+
+// tokenFromLink extracts the token query parameter of the link in an email body
+func tokenFromLink(t *testing.T, body string) string {
+	t.Helper()
+
+	link := regexp.MustCompile(`https://\S+`).FindString(body)
+	require.NotEmpty(t, link)
+	parsed, err := url.Parse(link)
+	require.NoError(t, err)
+	return parsed.Query().Get("token")
+}
+
+func TestAccountUseCase_PasswordReset(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Mock repositories
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockAccountTokenRepo := mocks.NewMockAccountTokenRepository(ctrl)
+	mockRefreshTokenRepo := mocks.NewMockRefreshTokenRepository(ctrl)
+	mockTxManager := newPassThroughTxManager(ctrl)
+	mockMailer := mocks.NewMockMailer(ctrl)
+
+	// Create use case
+	accountUseCase := usecase.NewAccountUseCase(mockUserRepo, mockAccountTokenRepo, mockRefreshTokenRepo, mockTxManager, mockMailer, newAccountTestConfig(), zap.NewNop())
+
+	t.Run("unknown email sends nothing", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetByEmail(gomock.Any(), "nobody@example.com").Return(nil, customerrors.NewNotFoundError("User not found"))
+
+		err := accountUseCase.RequestPasswordReset(context.Background(), entities.ForgotPasswordRequest{Email: "nobody@example.com"})
+
+		require.NoError(t, err)
+	})
+
+	t.Run("emailed token sets a new password once", func(t *testing.T) {
+		user := newMFAUser(t)
+
+		// Request: earlier links are superseded and the new one is emailed
+		var stored *entities.AccountToken
+		var message usecase.MailMessage
+		mockUserRepo.EXPECT().GetByEmail(gomock.Any(), user.Email).Return(user, nil)
+		mockAccountTokenRepo.EXPECT().InvalidateForUser(gomock.Any(), user.ID, entities.AccountTokenPasswordReset).Return(nil)
+		mockAccountTokenRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, token *entities.AccountToken) error {
+				stored = token
+				return nil
+			})
+		mockMailer.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, m usecase.MailMessage) error {
+				message = m
+				return nil
+			})
+
+		err := accountUseCase.RequestPasswordReset(context.Background(), entities.ForgotPasswordRequest{Email: user.Email})
+		require.NoError(t, err)
+		assert.Equal(t, user.Email, message.To)
+		assert.Contains(t, message.Body, "https://pintro.example/reset-password?token=")
+
+		plainToken := tokenFromLink(t, message.Body)
+		assert.Equal(t, stored.TokenHash, entities.HashAccountToken(plainToken))
+		assert.NotContains(t, stored.TokenHash, plainToken)
+
+		// Reset: the token is used up and every session ends
+		mockAccountTokenRepo.EXPECT().GetByHashForUpdate(gomock.Any(), stored.TokenHash).Return(stored, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil)
+		mockAccountTokenRepo.EXPECT().MarkUsed(gomock.Any(), stored.ID).DoAndReturn(
+			func(_ context.Context, _ uuid.UUID) error {
+				now := time.Now()
+				stored.UsedAt = &now
+				return nil
+			})
+		mockUserRepo.EXPECT().Update(gomock.Any(), user).Return(nil)
+		mockAccountTokenRepo.EXPECT().InvalidateForUser(gomock.Any(), user.ID, entities.AccountTokenPasswordReset).Return(nil)
+		mockRefreshTokenRepo.EXPECT().RevokeAllForUser(gomock.Any(), user.ID).Return(nil)
+
+		err = accountUseCase.ResetPassword(context.Background(), entities.ResetPasswordRequest{Token: plainToken, Password: "newpassword123"})
+		require.NoError(t, err)
+		assert.True(t, user.CheckPassword("newpassword123"))
+
+		// Reuse: refused
+		mockAccountTokenRepo.EXPECT().GetByHashForUpdate(gomock.Any(), stored.TokenHash).Return(stored, nil)
+
+		err = accountUseCase.ResetPassword(context.Background(), entities.ResetPasswordRequest{Token: plainToken, Password: "anotherpassword"})
+		require.Error(t, err)
+		assert.True(t, customerrors.IsValidationError(err))
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		user := newMFAUser(t)
+		token, plainToken, err := entities.NewAccountToken(user, entities.AccountTokenPasswordReset, -time.Minute)
+		require.NoError(t, err)
+
+		mockAccountTokenRepo.EXPECT().GetByHashForUpdate(gomock.Any(), token.TokenHash).Return(token, nil)
+
+		err = accountUseCase.ResetPassword(context.Background(), entities.ResetPasswordRequest{Token: plainToken, Password: "newpassword123"})
+
+		require.Error(t, err)
+		assert.True(t, customerrors.IsValidationError(err))
+		assert.True(t, user.CheckPassword("password"))
+	})
+
+	t.Run("verification token cannot reset the password", func(t *testing.T) {
+		user := newMFAUser(t)
+		token, plainToken, err := entities.NewAccountToken(user, entities.AccountTokenEmailVerification, time.Hour)
+		require.NoError(t, err)
+
+		mockAccountTokenRepo.EXPECT().GetByHashForUpdate(gomock.Any(), token.TokenHash).Return(token, nil)
+
+		err = accountUseCase.ResetPassword(context.Background(), entities.ResetPasswordRequest{Token: plainToken, Password: "newpassword123"})
+
+		require.Error(t, err)
+		assert.True(t, customerrors.IsValidationError(err))
+	})
+}
+
+func TestAccountUseCase_EmailVerification(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Mock repositories
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockAccountTokenRepo := mocks.NewMockAccountTokenRepository(ctrl)
+	mockRefreshTokenRepo := mocks.NewMockRefreshTokenRepository(ctrl)
+	mockTxManager := newPassThroughTxManager(ctrl)
+	mockMailer := mocks.NewMockMailer(ctrl)
+
+	// Create use case
+	accountUseCase := usecase.NewAccountUseCase(mockUserRepo, mockAccountTokenRepo, mockRefreshTokenRepo, mockTxManager, mockMailer, newAccountTestConfig(), zap.NewNop())
+
+	t.Run("send verification link", func(t *testing.T) {
+		user := newMFAUser(t)
+
+		mockUserRepo.EXPECT().GetByID(gomock.Any(), user.ID).Return(user, nil)
+		mockAccountTokenRepo.EXPECT().InvalidateForUser(gomock.Any(), user.ID, entities.AccountTokenEmailVerification).Return(nil)
+		mockAccountTokenRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, token *entities.AccountToken) error {
+				assert.Equal(t, user.Email, token.Email)
+				assert.Equal(t, entities.AccountTokenEmailVerification, token.Purpose)
+				return nil
+			})
+		mockMailer.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, m usecase.MailMessage) error {
+				assert.Contains(t, m.Body, "https://pintro.example/verify-email?token=")
+				assert.Contains(t, m.Body, "48 hours")
+				return nil
+			})
+
+		err := accountUseCase.SendEmailVerification(context.Background(), user.ID)
+
+		require.NoError(t, err)
+	})
+
+	t.Run("already verified", func(t *testing.T) {
+		user := newMFAUser(t)
+		verifiedAt := time.Now()
+		user.EmailVerifiedAt = &verifiedAt
+
+		mockUserRepo.EXPECT().GetByID(gomock.Any(), user.ID).Return(user, nil)
+
+		err := accountUseCase.SendEmailVerification(context.Background(), user.ID)
+
+		require.Error(t, err)
+		assert.True(t, customerrors.IsConflictError(err))
+	})
+
+	t.Run("verify email", func(t *testing.T) {
+		user := newMFAUser(t)
+		token, plainToken, err := entities.NewAccountToken(user, entities.AccountTokenEmailVerification, time.Hour)
+		require.NoError(t, err)
+
+		mockAccountTokenRepo.EXPECT().GetByHashForUpdate(gomock.Any(), token.TokenHash).Return(token, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil)
+		mockAccountTokenRepo.EXPECT().MarkUsed(gomock.Any(), token.ID).Return(nil)
+		mockUserRepo.EXPECT().Update(gomock.Any(), user).Return(nil)
+		mockAccountTokenRepo.EXPECT().InvalidateForUser(gomock.Any(), user.ID, entities.AccountTokenEmailVerification).Return(nil)
+
+		err = accountUseCase.VerifyEmail(context.Background(), entities.VerifyEmailRequest{Token: plainToken})
+
+		require.NoError(t, err)
+		assert.True(t, user.IsEmailVerified())
+	})
+
+	t.Run("link for a previous address", func(t *testing.T) {
+		user := newMFAUser(t)
+		token, plainToken, err := entities.NewAccountToken(user, entities.AccountTokenEmailVerification, time.Hour)
+		require.NoError(t, err)
+		user.Email = "changed@example.com"
+
+		mockAccountTokenRepo.EXPECT().GetByHashForUpdate(gomock.Any(), token.TokenHash).Return(token, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil)
+		mockAccountTokenRepo.EXPECT().MarkUsed(gomock.Any(), token.ID).Return(nil)
+
+		err = accountUseCase.VerifyEmail(context.Background(), entities.VerifyEmailRequest{Token: plainToken})
+
+		require.Error(t, err)
+		assert.True(t, customerrors.IsValidationError(err))
+		assert.False(t, user.IsEmailVerified())
+	})
+}
+
+func TestTransactionUseCase_RequireVerifiedEmail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Mock repositories
+	mockTransactionRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockLedgerRepo := mocks.NewMockLedgerRepository(ctrl)
+	mockTxManager := newPassThroughTxManager(ctrl)
+	mockPaymentGateway := mocks.NewMockPaymentGateway(ctrl)
+
+	cfg := newTransactionTestConfig()
+	cfg.Account.RequireVerifiedEmail = true
+
+	// Create use case
+	transactionUseCase := usecase.NewTransactionUseCase(mockTransactionRepo, mockUserRepo, mockLedgerRepo, mockTxManager, mockPaymentGateway, newDefaultFeeUseCase(ctrl, cfg), newDefaultLimitUseCase(ctrl, mockTransactionRepo, mockUserRepo, cfg), cfg)
+
+	t.Run("top-up refused", func(t *testing.T) {
+		user := &entities.User{ID: uuid.New(), Status: entities.UserStatusActive}
+
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil)
+
+		response, err := transactionUseCase.TopupBalance(context.Background(), user.ID, entities.TopupRequest{Amount: decimal.NewFromInt(100), PaymentMethod: "credit_card"})
+
+		require.Error(t, err)
+		assert.Nil(t, response)
+		assert.Equal(t, http.StatusForbidden, customerrors.GetErrorCode(err))
+	})
+
+	t.Run("payment refused before the PIN is checked", func(t *testing.T) {
+		sender := &entities.User{ID: uuid.New(), Balance: decimal.NewFromInt(500), Status: entities.UserStatusActive, PINHash: testPINHash}
+		recipient := &entities.User{ID: uuid.New(), Status: entities.UserStatusActive}
+
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), sender.ID).Return(sender, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), recipient.ID).Return(recipient, nil)
+
+		response, err := transactionUseCase.ProcessPayment(context.Background(), sender.ID, entities.PaymentRequest{Amount: decimal.NewFromInt(100), ToUserID: recipient.ID, PIN: "000000", Description: "Test payment"})
+
+		require.Error(t, err)
+		assert.Nil(t, response)
+		assert.Equal(t, http.StatusForbidden, customerrors.GetErrorCode(err))
+		assert.Equal(t, 0, sender.PINFailedAttempts)
+	})
+}
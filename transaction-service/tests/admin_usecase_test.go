@@ -0,0 +1,260 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-transaction-service/internal/domain/entities"
+	"go-transaction-service/internal/mocks"
+	"go-transaction-service/internal/usecase"
+	"go-transaction-service/pkg/errors"
+)
+
+func TestAdminUseCase_ListUsers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Mock repositories
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockRefreshTokenRepo := mocks.NewMockRefreshTokenRepository(ctrl)
+	mockMFARepo := mocks.NewMockMFARepository(ctrl)
+	mockSecurityEventRepo := mocks.NewMockSecurityEventRepository(ctrl)
+	mockTxManager := newPassThroughTxManager(ctrl)
+
+	// Create use case
+	adminUseCase := usecase.NewAdminUseCase(mockUserRepo, mockRefreshTokenRepo, mockMFARepo, mockSecurityEventRepo, mockTxManager)
+
+	t.Run("search with filters", func(t *testing.T) {
+		filter := entities.UserFilter{Search: "john", Status: entities.UserStatusActive, Role: entities.RoleUser}
+		users := []*entities.User{
+			{ID: uuid.New(), Email: "john@example.com", Status: entities.UserStatusActive, Role: entities.RoleUser},
+			{ID: uuid.New(), Email: "johnny@example.com", Status: entities.UserStatusActive, Role: entities.RoleUser},
+		}
+
+		// Mock expectations
+		mockUserRepo.EXPECT().Search(gomock.Any(), filter, 2, 0).Return(users, nil)
+		mockUserRepo.EXPECT().Count(gomock.Any(), filter).Return(5, nil)
+
+		// Execute
+		response, err := adminUseCase.ListUsers(context.Background(), filter, 2, 0)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Len(t, response.Users, 2)
+		assert.Equal(t, users[0].Email, response.Users[0].Email)
+		assert.Equal(t, 5, response.Pagination.Total)
+		assert.True(t, response.Pagination.HasMore)
+	})
+
+	t.Run("invalid role filter", func(t *testing.T) {
+		// Execute
+		response, err := adminUseCase.ListUsers(context.Background(), entities.UserFilter{Role: "root"}, 10, 0)
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, response)
+		assert.True(t, customerrors.IsValidationError(err))
+	})
+}
+
+func TestAdminUseCase_UpdateUserStatus(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Mock repositories
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockRefreshTokenRepo := mocks.NewMockRefreshTokenRepository(ctrl)
+	mockMFARepo := mocks.NewMockMFARepository(ctrl)
+	mockSecurityEventRepo := mocks.NewMockSecurityEventRepository(ctrl)
+	mockTxManager := newPassThroughTxManager(ctrl)
+
+	// Create use case
+	adminUseCase := usecase.NewAdminUseCase(mockUserRepo, mockRefreshTokenRepo, mockMFARepo, mockSecurityEventRepo, mockTxManager)
+
+	adminID := uuid.New()
+
+	t.Run("blocking revokes refresh tokens", func(t *testing.T) {
+		user := &entities.User{ID: uuid.New(), Status: entities.UserStatusActive, Role: entities.RoleUser}
+
+		// Mock expectations
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil)
+		mockUserRepo.EXPECT().UpdateStatus(gomock.Any(), user.ID, entities.UserStatusBlocked).Return(nil)
+		mockRefreshTokenRepo.EXPECT().RevokeAllForUser(gomock.Any(), user.ID).Return(nil)
+
+		// Execute
+		profile, err := adminUseCase.UpdateUserStatus(context.Background(), adminID, user.ID, entities.UpdateUserStatusRequest{Status: entities.UserStatusBlocked, Reason: "Fraud"})
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, entities.UserStatusBlocked, profile.Status)
+	})
+
+	t.Run("activating keeps refresh tokens", func(t *testing.T) {
+		user := &entities.User{ID: uuid.New(), Status: entities.UserStatusBlocked, Role: entities.RoleUser}
+
+		// Mock expectations
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil)
+		mockUserRepo.EXPECT().UpdateStatus(gomock.Any(), user.ID, entities.UserStatusActive).Return(nil)
+
+		// Execute
+		profile, err := adminUseCase.UpdateUserStatus(context.Background(), adminID, user.ID, entities.UpdateUserStatusRequest{Status: entities.UserStatusActive})
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, entities.UserStatusActive, profile.Status)
+	})
+
+	t.Run("own account", func(t *testing.T) {
+		// Execute
+		profile, err := adminUseCase.UpdateUserStatus(context.Background(), adminID, adminID, entities.UpdateUserStatusRequest{Status: entities.UserStatusBlocked})
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, profile)
+		assert.True(t, customerrors.IsValidationError(err))
+	})
+
+	t.Run("user not found", func(t *testing.T) {
+		userID := uuid.New()
+
+		// Mock expectations
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), userID).Return(nil, customerrors.NewNotFoundError("User not found"))
+
+		// Execute
+		profile, err := adminUseCase.UpdateUserStatus(context.Background(), adminID, userID, entities.UpdateUserStatusRequest{Status: entities.UserStatusInactive})
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, profile)
+		assert.True(t, customerrors.IsNotFoundError(err))
+	})
+}
+
+func TestAdminUseCase_UpdateUserRole(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Mock repositories
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockRefreshTokenRepo := mocks.NewMockRefreshTokenRepository(ctrl)
+	mockMFARepo := mocks.NewMockMFARepository(ctrl)
+	mockSecurityEventRepo := mocks.NewMockSecurityEventRepository(ctrl)
+	mockTxManager := newPassThroughTxManager(ctrl)
+
+	// Create use case
+	adminUseCase := usecase.NewAdminUseCase(mockUserRepo, mockRefreshTokenRepo, mockMFARepo, mockSecurityEventRepo, mockTxManager)
+
+	adminID := uuid.New()
+
+	t.Run("demotion ends the sessions", func(t *testing.T) {
+		user := &entities.User{ID: uuid.New(), Status: entities.UserStatusActive, Role: entities.RoleAdmin}
+
+		// Mock expectations
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil)
+		mockUserRepo.EXPECT().UpdateRole(gomock.Any(), user.ID, entities.RoleUser).Return(nil)
+		mockRefreshTokenRepo.EXPECT().RevokeAllForUser(gomock.Any(), user.ID).Return(nil)
+		mockSecurityEventRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, event *entities.SecurityEvent) error {
+				assert.Equal(t, entities.SecurityEventRoleChanged, event.Type)
+				assert.Equal(t, &user.ID, event.UserID)
+				return nil
+			})
+
+		// Execute
+		profile, err := adminUseCase.UpdateUserRole(context.Background(), adminID, user.ID, entities.UpdateUserRoleRequest{Role: entities.RoleUser, Reason: "Left the team"})
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, entities.RoleUser, profile.Role)
+	})
+
+	t.Run("same role changes nothing", func(t *testing.T) {
+		user := &entities.User{ID: uuid.New(), Status: entities.UserStatusActive, Role: entities.RoleSupport}
+
+		// Mock expectations
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil)
+
+		// Execute
+		profile, err := adminUseCase.UpdateUserRole(context.Background(), adminID, user.ID, entities.UpdateUserRoleRequest{Role: entities.RoleSupport})
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, entities.RoleSupport, profile.Role)
+	})
+
+	t.Run("own account", func(t *testing.T) {
+		// Execute
+		profile, err := adminUseCase.UpdateUserRole(context.Background(), adminID, adminID, entities.UpdateUserRoleRequest{Role: entities.RoleUser})
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, profile)
+		assert.True(t, customerrors.IsValidationError(err))
+	})
+
+	t.Run("unknown role", func(t *testing.T) {
+		// Execute
+		profile, err := adminUseCase.UpdateUserRole(context.Background(), adminID, uuid.New(), entities.UpdateUserRoleRequest{Role: "root"})
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, profile)
+		assert.True(t, customerrors.IsValidationError(err))
+	})
+}
+
+func TestAdminUseCase_ResetMFA(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Mock repositories
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockRefreshTokenRepo := mocks.NewMockRefreshTokenRepository(ctrl)
+	mockMFARepo := mocks.NewMockMFARepository(ctrl)
+	mockSecurityEventRepo := mocks.NewMockSecurityEventRepository(ctrl)
+	mockTxManager := newPassThroughTxManager(ctrl)
+
+	// Create use case
+	adminUseCase := usecase.NewAdminUseCase(mockUserRepo, mockRefreshTokenRepo, mockMFARepo, mockSecurityEventRepo, mockTxManager)
+	adminID := uuid.New()
+
+	t.Run("reset clears MFA and ends sessions", func(t *testing.T) {
+		user := &entities.User{
+			ID:              uuid.New(),
+			Status:          entities.UserStatusActive,
+			Role:            entities.RoleUser,
+			MFAEnabled:      true,
+			MFASecret:       "JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP",
+			MFALastUsedStep: 100,
+		}
+
+		// Mock expectations
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil)
+		mockUserRepo.EXPECT().UpdateMFA(gomock.Any(), user).Return(nil)
+		mockMFARepo.EXPECT().DeleteRecoveryCodes(gomock.Any(), user.ID).Return(nil)
+		mockRefreshTokenRepo.EXPECT().RevokeAllForUser(gomock.Any(), user.ID).Return(nil)
+
+		// Execute
+		profile, err := adminUseCase.ResetMFA(context.Background(), adminID, user.ID)
+
+		// Assert
+		require.NoError(t, err)
+		assert.False(t, profile.MFAEnabled)
+		assert.Empty(t, user.MFASecret)
+		assert.Zero(t, user.MFALastUsedStep)
+	})
+
+	t.Run("cannot reset own account", func(t *testing.T) {
+		// Execute
+		profile, err := adminUseCase.ResetMFA(context.Background(), adminID, adminID)
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, profile)
+		assert.True(t, customerrors.IsValidationError(err))
+	})
+}
@@ -0,0 +1,313 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-transaction-service/internal/config"
+	"go-transaction-service/internal/domain/entities"
+	"go-transaction-service/internal/mocks"
+	"go-transaction-service/internal/usecase"
+	"go-transaction-service/pkg/errors"
+)
+
+//go:generate mockgen -source=../internal/domain/repositories/api_key_repository.go -destination=../internal/mocks/api_key_repository_mock.go
+
+func newAPIKeyTestConfig() *config.Config {
+	return &config.Config{
+		APIKey: config.APIKeyConfig{
+			DefaultRateLimit: 60,
+		},
+	}
+}
+
+// newTestAPIKey returns an active key with the given scopes and its plain value
+func newTestAPIKey(t *testing.T, owner *entities.User, rateLimit int, scopes ...entities.APIKeyScope) (*entities.APIKey, string) {
+	t.Helper()
+
+	apiKey, plain, err := entities.NewAPIKey(owner.ID, uuid.New(), "Back office", scopes, rateLimit, nil)
+	require.NoError(t, err)
+	return apiKey, plain
+}
+
+func TestAPIKeyUseCase_CreateAPIKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Mock repositories
+	mockAPIKeyRepo := mocks.NewMockAPIKeyRepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+
+	// Create use case
+	apiKeyUseCase := usecase.NewAPIKeyUseCase(mockAPIKeyRepo, mockUserRepo, newAPIKeyTestConfig())
+
+	adminID := uuid.New()
+
+	t.Run("key is returned once and only its hash is stored", func(t *testing.T) {
+		owner := newLoginUser(t)
+		owner.Role = entities.RoleSupport
+		req := entities.CreateAPIKeyRequest{
+			Name:   "Back office",
+			UserID: owner.ID,
+			Scopes: []entities.APIKeyScope{entities.APIKeyScopeAdmin},
+		}
+
+		// Mock expectations
+		var stored *entities.APIKey
+		mockUserRepo.EXPECT().GetByID(gomock.Any(), owner.ID).Return(owner, nil)
+		mockAPIKeyRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, key *entities.APIKey) error {
+				stored = key
+				return nil
+			})
+
+		// Execute
+		response, err := apiKeyUseCase.CreateAPIKey(context.Background(), adminID, req)
+
+		// Assert
+		require.NoError(t, err)
+		require.NotNil(t, stored)
+		assert.True(t, strings.HasPrefix(response.Key, "pk_"))
+		assert.True(t, strings.HasPrefix(response.Key, stored.Prefix))
+		assert.Equal(t, entities.HashAPIKey(response.Key), stored.KeyHash)
+		assert.NotEqual(t, response.Key, stored.KeyHash)
+		assert.Equal(t, owner.ID, stored.UserID)
+		assert.Equal(t, adminID, *stored.CreatedBy)
+		assert.Equal(t, 60, stored.RateLimit)
+	})
+
+	t.Run("admin scope for a wallet user", func(t *testing.T) {
+		owner := newLoginUser(t)
+		req := entities.CreateAPIKeyRequest{
+			Name:   "Batch job",
+			UserID: owner.ID,
+			Scopes: []entities.APIKeyScope{entities.APIKeyScopeTransactions, entities.APIKeyScopeAdmin},
+		}
+
+		// Mock expectations
+		mockUserRepo.EXPECT().GetByID(gomock.Any(), owner.ID).Return(owner, nil)
+
+		// Execute
+		response, err := apiKeyUseCase.CreateAPIKey(context.Background(), adminID, req)
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, response)
+		assert.True(t, customerrors.IsValidationError(err))
+	})
+
+	t.Run("expiry in the past", func(t *testing.T) {
+		expiresAt := time.Now().Add(-time.Hour)
+		req := entities.CreateAPIKeyRequest{
+			Name:      "Batch job",
+			UserID:    uuid.New(),
+			Scopes:    []entities.APIKeyScope{entities.APIKeyScopeTransactions},
+			ExpiresAt: &expiresAt,
+		}
+
+		// Execute
+		response, err := apiKeyUseCase.CreateAPIKey(context.Background(), adminID, req)
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, response)
+		assert.True(t, customerrors.IsValidationError(err))
+	})
+}
+
+func TestAPIKeyUseCase_Authenticate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Mock repositories
+	mockAPIKeyRepo := mocks.NewMockAPIKeyRepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+
+	// Create use case
+	apiKeyUseCase := usecase.NewAPIKeyUseCase(mockAPIKeyRepo, mockUserRepo, newAPIKeyTestConfig())
+
+	t.Run("key acts as its owner and its use is recorded", func(t *testing.T) {
+		owner := newLoginUser(t)
+		owner.Role = entities.RoleAdmin
+		apiKey, plain := newTestAPIKey(t, owner, 60, entities.APIKeyScopeAdmin)
+
+		// Mock expectations
+		mockAPIKeyRepo.EXPECT().GetByHash(gomock.Any(), entities.HashAPIKey(plain)).Return(apiKey, nil)
+		mockUserRepo.EXPECT().GetByID(gomock.Any(), owner.ID).Return(owner, nil)
+		mockAPIKeyRepo.EXPECT().Touch(gomock.Any(), apiKey.ID, gomock.Any()).Return(nil)
+
+		// Execute
+		claims, err := apiKeyUseCase.Authenticate(context.Background(), plain, entities.APIKeyScopeAdmin)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, owner.ID, claims.UserID)
+		assert.Equal(t, entities.RoleAdmin, claims.Role)
+		assert.Equal(t, apiKey.ID, claims.APIKeyID)
+		assert.True(t, claims.IsAPIKey())
+		assert.True(t, claims.HasPermission(entities.PermissionUsersWrite))
+	})
+
+	t.Run("recently used key is not written again", func(t *testing.T) {
+		owner := newLoginUser(t)
+		apiKey, plain := newTestAPIKey(t, owner, 60, entities.APIKeyScopeTransactions)
+		usedAt := time.Now().Add(-time.Second)
+		apiKey.LastUsedAt = &usedAt
+
+		// Mock expectations
+		mockAPIKeyRepo.EXPECT().GetByHash(gomock.Any(), gomock.Any()).Return(apiKey, nil)
+		mockUserRepo.EXPECT().GetByID(gomock.Any(), owner.ID).Return(owner, nil)
+
+		// Execute
+		claims, err := apiKeyUseCase.Authenticate(context.Background(), plain, entities.APIKeyScopeTransactions)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, owner.ID, claims.UserID)
+	})
+
+	t.Run("route group outside the scopes", func(t *testing.T) {
+		owner := newLoginUser(t)
+		apiKey, plain := newTestAPIKey(t, owner, 60, entities.APIKeyScopeTransactions)
+
+		// Mock expectations
+		mockAPIKeyRepo.EXPECT().GetByHash(gomock.Any(), gomock.Any()).Return(apiKey, nil)
+
+		// Execute
+		claims, err := apiKeyUseCase.Authenticate(context.Background(), plain, entities.APIKeyScopeAdmin)
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, claims)
+		assert.Equal(t, http.StatusForbidden, customerrors.GetErrorCode(err))
+	})
+
+	t.Run("requests above the rate limit are refused", func(t *testing.T) {
+		owner := newLoginUser(t)
+		apiKey, plain := newTestAPIKey(t, owner, 2, entities.APIKeyScopeUser)
+		usedAt := time.Now()
+		apiKey.LastUsedAt = &usedAt
+
+		// Mock expectations
+		mockAPIKeyRepo.EXPECT().GetByHash(gomock.Any(), gomock.Any()).Return(apiKey, nil).Times(3)
+		mockUserRepo.EXPECT().GetByID(gomock.Any(), owner.ID).Return(owner, nil).Times(2)
+
+		// Execute
+		for i := 0; i < 2; i++ {
+			_, err := apiKeyUseCase.Authenticate(context.Background(), plain, entities.APIKeyScopeUser)
+			require.NoError(t, err)
+		}
+		claims, err := apiKeyUseCase.Authenticate(context.Background(), plain, entities.APIKeyScopeUser)
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, claims)
+		assert.True(t, customerrors.IsTooManyRequestsError(err))
+	})
+
+	t.Run("revoked key", func(t *testing.T) {
+		owner := newLoginUser(t)
+		apiKey, plain := newTestAPIKey(t, owner, 60, entities.APIKeyScopeUser)
+		revokedAt := time.Now().Add(-time.Minute)
+		apiKey.RevokedAt = &revokedAt
+
+		// Mock expectations
+		mockAPIKeyRepo.EXPECT().GetByHash(gomock.Any(), gomock.Any()).Return(apiKey, nil)
+
+		// Execute
+		claims, err := apiKeyUseCase.Authenticate(context.Background(), plain, entities.APIKeyScopeUser)
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, claims)
+		assert.True(t, customerrors.IsUnauthorizedError(err))
+	})
+
+	t.Run("owner no longer active", func(t *testing.T) {
+		owner := newLoginUser(t)
+		owner.Status = entities.UserStatusBlocked
+		apiKey, plain := newTestAPIKey(t, owner, 60, entities.APIKeyScopeUser)
+
+		// Mock expectations
+		mockAPIKeyRepo.EXPECT().GetByHash(gomock.Any(), gomock.Any()).Return(apiKey, nil)
+		mockUserRepo.EXPECT().GetByID(gomock.Any(), owner.ID).Return(owner, nil)
+
+		// Execute
+		claims, err := apiKeyUseCase.Authenticate(context.Background(), plain, entities.APIKeyScopeUser)
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, claims)
+		assert.True(t, customerrors.IsUnauthorizedError(err))
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		// Mock expectations
+		mockAPIKeyRepo.EXPECT().GetByHash(gomock.Any(), gomock.Any()).Return(nil, customerrors.NewNotFoundError("API key not found"))
+
+		// Execute
+		claims, err := apiKeyUseCase.Authenticate(context.Background(), "pk_unknown", entities.APIKeyScopeUser)
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, claims)
+		assert.True(t, customerrors.IsUnauthorizedError(err))
+	})
+}
+
+func TestAPIKeyUseCase_RevokeAPIKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Mock repositories
+	mockAPIKeyRepo := mocks.NewMockAPIKeyRepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+
+	// Create use case
+	apiKeyUseCase := usecase.NewAPIKeyUseCase(mockAPIKeyRepo, mockUserRepo, newAPIKeyTestConfig())
+
+	owner := newLoginUser(t)
+
+	t.Run("revoked key is refused afterwards", func(t *testing.T) {
+		apiKey, plain := newTestAPIKey(t, owner, 60, entities.APIKeyScopeUser)
+
+		// Mock expectations
+		mockAPIKeyRepo.EXPECT().GetByID(gomock.Any(), apiKey.ID).Return(apiKey, nil)
+		mockAPIKeyRepo.EXPECT().Revoke(gomock.Any(), apiKey.ID, gomock.Any()).Return(nil)
+		mockAPIKeyRepo.EXPECT().GetByHash(gomock.Any(), entities.HashAPIKey(plain)).Return(apiKey, nil)
+
+		// Execute
+		revoked, err := apiKeyUseCase.RevokeAPIKey(context.Background(), apiKey.ID)
+		require.NoError(t, err)
+		claims, authErr := apiKeyUseCase.Authenticate(context.Background(), plain, entities.APIKeyScopeUser)
+
+		// Assert
+		assert.NotNil(t, revoked.RevokedAt)
+		require.Error(t, authErr)
+		assert.Nil(t, claims)
+		assert.True(t, customerrors.IsUnauthorizedError(authErr))
+	})
+
+	t.Run("revoking twice keeps the first revocation", func(t *testing.T) {
+		apiKey, _ := newTestAPIKey(t, owner, 60, entities.APIKeyScopeUser)
+		revokedAt := time.Now().Add(-time.Hour)
+		apiKey.RevokedAt = &revokedAt
+
+		// Mock expectations
+		mockAPIKeyRepo.EXPECT().GetByID(gomock.Any(), apiKey.ID).Return(apiKey, nil)
+
+		// Execute
+		revoked, err := apiKeyUseCase.RevokeAPIKey(context.Background(), apiKey.ID)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, revokedAt, *revoked.RevokedAt)
+	})
+}
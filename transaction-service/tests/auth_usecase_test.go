@@ -18,24 +18,33 @@ import (
 )
 
 //go:generate mockgen -source=../internal/domain/repositories/user_repository.go -destination=../internal/mocks/user_repository_mock.go
+//go:generate mockgen -source=../internal/domain/repositories/refresh_token_repository.go -destination=../internal/mocks/refresh_token_repository_mock.go
+//go:generate mockgen -source=../internal/domain/repositories/revoked_token_repository.go -destination=../internal/mocks/revoked_token_repository_mock.go
+//go:generate mockgen -source=../internal/domain/repositories/mfa_repository.go -destination=../internal/mocks/mfa_repository_mock.go
 
 func TestAuthUseCase_Register(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	// Mock repository
+	// Mock repositories
 	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockRefreshTokenRepo := mocks.NewMockRefreshTokenRepository(ctrl)
+	mockRevokedTokenRepo := mocks.NewMockRevokedTokenRepository(ctrl)
+	mockMFARepo := mocks.NewMockMFARepository(ctrl)
+	mockSecurityEventRepo := mocks.NewMockSecurityEventRepository(ctrl)
+	mockSessionRepo := mocks.NewMockSessionRepository(ctrl)
+	mockTxManager := newPassThroughTxManager(ctrl)
 
 	// Create config
 	cfg := &config.Config{
 		JWT: config.JWTConfig{
-			SecretKey:      "test-secret-key",
-			ExpireDuration: 24 * time.Hour,
+			ExpireDuration:        15 * time.Minute,
+			RefreshExpireDuration: 24 * time.Hour,
 		},
 	}
 
 	// Create use case
-	authUseCase := usecase.NewAuthUseCase(mockUserRepo, cfg)
+	authUseCase := usecase.NewAuthUseCase(mockUserRepo, mockRefreshTokenRepo, mockRevokedTokenRepo, mockMFARepo, mockSecurityEventRepo, mockSessionRepo, mockTxManager, newTestKeyring(t), cfg)
 
 	t.Run("successful registration", func(t *testing.T) {
 		req := entities.RegisterRequest{
@@ -112,19 +121,25 @@ func TestAuthUseCase_Login(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	// Mock repository
+	// Mock repositories
 	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockRefreshTokenRepo := mocks.NewMockRefreshTokenRepository(ctrl)
+	mockRevokedTokenRepo := mocks.NewMockRevokedTokenRepository(ctrl)
+	mockMFARepo := mocks.NewMockMFARepository(ctrl)
+	mockSecurityEventRepo := mocks.NewMockSecurityEventRepository(ctrl)
+	mockSessionRepo := mocks.NewMockSessionRepository(ctrl)
+	mockTxManager := newPassThroughTxManager(ctrl)
 
 	// Create config
 	cfg := &config.Config{
 		JWT: config.JWTConfig{
-			SecretKey:      "test-secret-key",
-			ExpireDuration: 24 * time.Hour,
+			ExpireDuration:        15 * time.Minute,
+			RefreshExpireDuration: 24 * time.Hour,
 		},
 	}
 
 	// Create use case
-	authUseCase := usecase.NewAuthUseCase(mockUserRepo, cfg)
+	authUseCase := usecase.NewAuthUseCase(mockUserRepo, mockRefreshTokenRepo, mockRevokedTokenRepo, mockMFARepo, mockSecurityEventRepo, mockSessionRepo, mockTxManager, newTestKeyring(t), cfg)
 
 	t.Run("successful login", func(t *testing.T) {
 		// Create test user with hashed password
@@ -144,10 +159,27 @@ func TestAuthUseCase_Login(t *testing.T) {
 		}
 
 		// Mock expectations
+		var stored *entities.RefreshToken
+		var session *entities.Session
 		mockUserRepo.EXPECT().GetByEmail(gomock.Any(), req.Email).Return(user, nil)
+		mockSecurityEventRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+		mockSessionRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, s *entities.Session) error {
+				session = s
+				return nil
+			})
+		mockRefreshTokenRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, token *entities.RefreshToken) error {
+				stored = token
+				return nil
+			})
 
 		// Execute
-		response, err := authUseCase.Login(context.Background(), req)
+		client := entities.ClientInfo{
+			IPAddress: "203.0.113.7",
+			UserAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36",
+		}
+		response, _, err := authUseCase.Login(context.Background(), req, client)
 
 		// Assert
 		require.NoError(t, err)
@@ -156,6 +188,20 @@ func TestAuthUseCase_Login(t *testing.T) {
 		assert.Equal(t, user.ID, response.User.ID)
 		assert.Equal(t, user.Email, response.User.Email)
 		assert.True(t, response.ExpiresAt.After(time.Now()))
+
+		// Only the hash of the refresh token is stored
+		require.NotNil(t, stored)
+		assert.NotEmpty(t, response.RefreshToken)
+		assert.NotEqual(t, response.RefreshToken, stored.TokenHash)
+		assert.Equal(t, entities.HashRefreshToken(response.RefreshToken), stored.TokenHash)
+		assert.Equal(t, user.ID, stored.UserID)
+
+		// The refresh token belongs to the recorded session
+		require.NotNil(t, session)
+		assert.Equal(t, session.ID, stored.FamilyID)
+		assert.Equal(t, user.ID, session.UserID)
+		assert.Equal(t, "Chrome on Windows", session.Device)
+		assert.Equal(t, client.IPAddress, session.IPAddress)
 	})
 
 	t.Run("invalid credentials - user not found", func(t *testing.T) {
@@ -166,9 +212,15 @@ func TestAuthUseCase_Login(t *testing.T) {
 
 		// Mock expectations
 		mockUserRepo.EXPECT().GetByEmail(gomock.Any(), req.Email).Return(nil, customerrors.NewNotFoundError("User not found"))
+		mockSecurityEventRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, event *entities.SecurityEvent) error {
+				assert.Equal(t, entities.SecurityEventLoginFailed, event.Type)
+				assert.Nil(t, event.UserID)
+				return nil
+			})
 
 		// Execute
-		response, err := authUseCase.Login(context.Background(), req)
+		response, _, err := authUseCase.Login(context.Background(), req, entities.ClientInfo{})
 
 		// Assert
 		require.Error(t, err)
@@ -195,9 +247,12 @@ func TestAuthUseCase_Login(t *testing.T) {
 
 		// Mock expectations
 		mockUserRepo.EXPECT().GetByEmail(gomock.Any(), req.Email).Return(user, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil)
+		mockUserRepo.EXPECT().UpdateLoginFailures(gomock.Any(), user).Return(nil)
+		mockSecurityEventRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
 
 		// Execute
-		response, err := authUseCase.Login(context.Background(), req)
+		response, _, err := authUseCase.Login(context.Background(), req, entities.ClientInfo{})
 
 		// Assert
 		require.Error(t, err)
@@ -226,7 +281,7 @@ func TestAuthUseCase_Login(t *testing.T) {
 		mockUserRepo.EXPECT().GetByEmail(gomock.Any(), req.Email).Return(user, nil)
 
 		// Execute
-		response, err := authUseCase.Login(context.Background(), req)
+		response, _, err := authUseCase.Login(context.Background(), req, entities.ClientInfo{})
 
 		// Assert
 		require.Error(t, err)
@@ -239,28 +294,37 @@ func TestAuthUseCase_ValidateToken(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	// Mock repository
+	// Mock repositories
 	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockRefreshTokenRepo := mocks.NewMockRefreshTokenRepository(ctrl)
+	mockRevokedTokenRepo := mocks.NewMockRevokedTokenRepository(ctrl)
+	mockMFARepo := mocks.NewMockMFARepository(ctrl)
+	mockSecurityEventRepo := mocks.NewMockSecurityEventRepository(ctrl)
+	mockSessionRepo := mocks.NewMockSessionRepository(ctrl)
+	mockTxManager := newPassThroughTxManager(ctrl)
 
 	// Create config
 	cfg := &config.Config{
 		JWT: config.JWTConfig{
-			SecretKey:      "test-secret-key",
-			ExpireDuration: 24 * time.Hour,
+			ExpireDuration:        15 * time.Minute,
+			RefreshExpireDuration: 24 * time.Hour,
 		},
 	}
 
 	// Create use case
-	authUseCase := usecase.NewAuthUseCase(mockUserRepo, cfg)
+	authUseCase := usecase.NewAuthUseCase(mockUserRepo, mockRefreshTokenRepo, mockRevokedTokenRepo, mockMFARepo, mockSecurityEventRepo, mockSessionRepo, mockTxManager, newTestKeyring(t), cfg)
 
 	t.Run("valid token", func(t *testing.T) {
-		// Create test user
+		// Create test user with hashed password
+		hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.DefaultCost)
 		user := &entities.User{
 			ID:        uuid.New(),
 			Email:     "test@example.com",
+			Password:  string(hashedPassword),
 			FirstName: "John",
 			LastName:  "Doe",
 			Status:    entities.UserStatusActive,
+			Role:      entities.RoleUser,
 		}
 
 		// Generate token by logging in first
@@ -271,9 +335,19 @@ func TestAuthUseCase_ValidateToken(t *testing.T) {
 		
 		// Mock login to generate token
 		mockUserRepo.EXPECT().GetByEmail(gomock.Any(), user.Email).Return(user, nil)
-		response, err := authUseCase.Login(context.Background(), loginReq)
+		mockSecurityEventRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+		mockSessionRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+		mockRefreshTokenRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+		response, _, err := authUseCase.Login(context.Background(), loginReq, entities.ClientInfo{})
 		require.NoError(t, err)
 
+		// Mock expectations
+		mockRevokedTokenRepo.EXPECT().IsRevoked(gomock.Any(), gomock.Any()).Return(false, nil)
+		mockSessionRepo.EXPECT().GetActive(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, id uuid.UUID) (*entities.Session, error) {
+				return &entities.Session{ID: id, UserID: user.ID, LastSeenAt: time.Now()}, nil
+			})
+
 		// Execute
 		claims, err := authUseCase.ValidateToken(context.Background(), response.Token)
 
@@ -282,6 +356,105 @@ func TestAuthUseCase_ValidateToken(t *testing.T) {
 		assert.NotNil(t, claims)
 		assert.Equal(t, user.ID, claims.UserID)
 		assert.Equal(t, user.Email, claims.Email)
+		assert.Equal(t, entities.RoleUser, claims.Role)
+		assert.NotEmpty(t, claims.ID)
+		assert.NotEqual(t, uuid.Nil, claims.SessionID)
+	})
+
+	t.Run("token of a signed out session", func(t *testing.T) {
+		hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.DefaultCost)
+		user := &entities.User{
+			ID:       uuid.New(),
+			Email:    "test@example.com",
+			Password: string(hashedPassword),
+			Status:   entities.UserStatusActive,
+			Role:     entities.RoleUser,
+		}
+
+		// Mock login to generate token
+		mockUserRepo.EXPECT().GetByEmail(gomock.Any(), user.Email).Return(user, nil)
+		mockSecurityEventRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+		mockSessionRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+		mockRefreshTokenRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+		response, _, err := authUseCase.Login(context.Background(), entities.LoginRequest{Email: user.Email, Password: "password"}, entities.ClientInfo{})
+		require.NoError(t, err)
+
+		// Mock expectations
+		mockRevokedTokenRepo.EXPECT().IsRevoked(gomock.Any(), gomock.Any()).Return(false, nil)
+		mockSessionRepo.EXPECT().GetActive(gomock.Any(), gomock.Any()).Return(nil, customerrors.NewNotFoundError("Session not found"))
+
+		// Execute
+		claims, err := authUseCase.ValidateToken(context.Background(), response.Token)
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, claims)
+		assert.True(t, customerrors.IsUnauthorizedError(err))
+	})
+
+	t.Run("last use of an idle session is recorded", func(t *testing.T) {
+		hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.DefaultCost)
+		user := &entities.User{
+			ID:       uuid.New(),
+			Email:    "test@example.com",
+			Password: string(hashedPassword),
+			Status:   entities.UserStatusActive,
+			Role:     entities.RoleUser,
+		}
+
+		// Mock login to generate token
+		mockUserRepo.EXPECT().GetByEmail(gomock.Any(), user.Email).Return(user, nil)
+		mockSecurityEventRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+		mockSessionRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+		mockRefreshTokenRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+		response, _, err := authUseCase.Login(context.Background(), entities.LoginRequest{Email: user.Email, Password: "password"}, entities.ClientInfo{})
+		require.NoError(t, err)
+
+		// Mock expectations
+		mockRevokedTokenRepo.EXPECT().IsRevoked(gomock.Any(), gomock.Any()).Return(false, nil)
+		mockSessionRepo.EXPECT().GetActive(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, id uuid.UUID) (*entities.Session, error) {
+				return &entities.Session{ID: id, UserID: user.ID, LastSeenAt: time.Now().Add(-time.Hour)}, nil
+			})
+		mockSessionRepo.EXPECT().Touch(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+		// Execute
+		claims, err := authUseCase.ValidateToken(context.Background(), response.Token)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, claims.UserID)
+	})
+
+	t.Run("revoked token", func(t *testing.T) {
+		// Create test user with hashed password
+		hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.DefaultCost)
+		user := &entities.User{
+			ID:       uuid.New(),
+			Email:    "test@example.com",
+			Password: string(hashedPassword),
+			Status:   entities.UserStatusActive,
+			Role:     entities.RoleUser,
+		}
+
+		// Mock login to generate token
+		mockUserRepo.EXPECT().GetByEmail(gomock.Any(), user.Email).Return(user, nil)
+		mockSecurityEventRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+		mockSessionRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+		mockRefreshTokenRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+		response, _, err := authUseCase.Login(context.Background(), entities.LoginRequest{Email: user.Email, Password: "password"}, entities.ClientInfo{})
+		require.NoError(t, err)
+
+		// Mock expectations
+		mockRevokedTokenRepo.EXPECT().IsRevoked(gomock.Any(), gomock.Any()).Return(true, nil)
+
+		// Execute
+		claims, err := authUseCase.ValidateToken(context.Background(), response.Token)
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, claims)
+		assert.True(t, customerrors.IsUnauthorizedError(err))
 	})
 
 	t.Run("invalid token", func(t *testing.T) {
@@ -305,3 +478,150 @@ func TestAuthUseCase_ValidateToken(t *testing.T) {
 		assert.True(t, customerrors.IsUnauthorizedError(err))
 	})
 }
+
+func TestAuthUseCase_RefreshToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Mock repositories
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockRefreshTokenRepo := mocks.NewMockRefreshTokenRepository(ctrl)
+	mockRevokedTokenRepo := mocks.NewMockRevokedTokenRepository(ctrl)
+	mockMFARepo := mocks.NewMockMFARepository(ctrl)
+	mockSecurityEventRepo := mocks.NewMockSecurityEventRepository(ctrl)
+	mockSessionRepo := mocks.NewMockSessionRepository(ctrl)
+	mockTxManager := newPassThroughTxManager(ctrl)
+
+	// Create config
+	cfg := &config.Config{
+		JWT: config.JWTConfig{
+			ExpireDuration:        15 * time.Minute,
+			RefreshExpireDuration: 24 * time.Hour,
+		},
+	}
+
+	// Create use case
+	authUseCase := usecase.NewAuthUseCase(mockUserRepo, mockRefreshTokenRepo, mockRevokedTokenRepo, mockMFARepo, mockSecurityEventRepo, mockSessionRepo, mockTxManager, newTestKeyring(t), cfg)
+
+	user := &entities.User{
+		ID:     uuid.New(),
+		Email:  "test@example.com",
+		Status: entities.UserStatusActive,
+	}
+
+	t.Run("token is rotated within the session", func(t *testing.T) {
+		stored, plain, err := entities.NewRefreshToken(user.ID, uuid.New(), time.Hour)
+		require.NoError(t, err)
+
+		// Mock expectations
+		var next *entities.RefreshToken
+		mockRefreshTokenRepo.EXPECT().GetByHashForUpdate(gomock.Any(), entities.HashRefreshToken(plain)).Return(stored, nil)
+		mockUserRepo.EXPECT().GetByID(gomock.Any(), user.ID).Return(user, nil)
+		mockRefreshTokenRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, token *entities.RefreshToken) error {
+				next = token
+				return nil
+			})
+		mockRefreshTokenRepo.EXPECT().MarkReplaced(gomock.Any(), stored.ID, gomock.Any()).DoAndReturn(
+			func(_ context.Context, _ uuid.UUID, replacedByID uuid.UUID) error {
+				assert.Equal(t, next.ID, replacedByID)
+				return nil
+			})
+		session := &entities.Session{ID: stored.FamilyID, UserID: user.ID, IPAddress: "198.51.100.1"}
+		mockSessionRepo.EXPECT().GetActive(gomock.Any(), stored.FamilyID).Return(session, nil)
+		mockSessionRepo.EXPECT().UpdateClient(gomock.Any(), session).Return(nil)
+
+		// Execute
+		response, err := authUseCase.RefreshToken(context.Background(), plain, entities.ClientInfo{IPAddress: "203.0.113.7"})
+
+		// Assert
+		require.NoError(t, err)
+		assert.NotEmpty(t, response.Token)
+		assert.NotEqual(t, plain, response.RefreshToken)
+		assert.Equal(t, stored.FamilyID, next.FamilyID)
+		assert.Equal(t, "203.0.113.7", session.IPAddress)
+	})
+
+	t.Run("reused token revokes the session", func(t *testing.T) {
+		stored, plain, err := entities.NewRefreshToken(user.ID, uuid.New(), time.Hour)
+		require.NoError(t, err)
+		usedAt := time.Now().Add(-time.Minute)
+		stored.RevokedAt = &usedAt
+
+		// Mock expectations
+		mockRefreshTokenRepo.EXPECT().GetByHashForUpdate(gomock.Any(), entities.HashRefreshToken(plain)).Return(stored, nil)
+		mockRefreshTokenRepo.EXPECT().RevokeFamily(gomock.Any(), stored.FamilyID).Return(nil)
+
+		// Execute
+		response, err := authUseCase.RefreshToken(context.Background(), plain, entities.ClientInfo{})
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, response)
+		assert.True(t, customerrors.IsUnauthorizedError(err))
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		stored, plain, err := entities.NewRefreshToken(user.ID, uuid.New(), -time.Minute)
+		require.NoError(t, err)
+
+		// Mock expectations
+		mockRefreshTokenRepo.EXPECT().GetByHashForUpdate(gomock.Any(), entities.HashRefreshToken(plain)).Return(stored, nil)
+
+		// Execute
+		response, err := authUseCase.RefreshToken(context.Background(), plain, entities.ClientInfo{})
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, response)
+		assert.True(t, customerrors.IsUnauthorizedError(err))
+	})
+
+	t.Run("unknown token", func(t *testing.T) {
+		// Mock expectations
+		mockRefreshTokenRepo.EXPECT().GetByHashForUpdate(gomock.Any(), gomock.Any()).Return(nil, customerrors.NewNotFoundError("Refresh token not found"))
+
+		// Execute
+		response, err := authUseCase.RefreshToken(context.Background(), "unknown-token", entities.ClientInfo{})
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, response)
+		assert.True(t, customerrors.IsUnauthorizedError(err))
+	})
+}
+
+func TestAuthUseCase_Logout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Mock repositories
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockRefreshTokenRepo := mocks.NewMockRefreshTokenRepository(ctrl)
+	mockRevokedTokenRepo := mocks.NewMockRevokedTokenRepository(ctrl)
+	mockMFARepo := mocks.NewMockMFARepository(ctrl)
+	mockSecurityEventRepo := mocks.NewMockSecurityEventRepository(ctrl)
+	mockSessionRepo := mocks.NewMockSessionRepository(ctrl)
+	mockTxManager := newPassThroughTxManager(ctrl)
+
+	// Create use case
+	authUseCase := usecase.NewAuthUseCase(mockUserRepo, mockRefreshTokenRepo, mockRevokedTokenRepo, mockMFARepo, mockSecurityEventRepo, mockSessionRepo, mockTxManager, newTestKeyring(t), &config.Config{})
+
+	claims := &entities.JWTClaims{
+		UserID:    uuid.New(),
+		Email:     "test@example.com",
+		Exp:       time.Now().Add(15 * time.Minute).Unix(),
+		ID:        uuid.NewString(),
+		SessionID: uuid.New(),
+	}
+
+	// Mock expectations
+	mockRevokedTokenRepo.EXPECT().Revoke(gomock.Any(), claims.ID, claims.UserID, claims.ExpiresAt()).Return(nil)
+	mockRefreshTokenRepo.EXPECT().RevokeFamily(gomock.Any(), claims.SessionID).Return(nil)
+
+	// Execute
+	err := authUseCase.Logout(context.Background(), claims)
+
+	// Assert
+	require.NoError(t, err)
+}
@@ -0,0 +1,266 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-transaction-service/internal/domain/entities"
+	"go-transaction-service/internal/mocks"
+	"go-transaction-service/internal/usecase"
+	"go-transaction-service/pkg/errors"
+)
+
+func TestFeeUseCase(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Mock repositories
+	mockFeeRuleRepo := mocks.NewMockFeeRuleRepository(ctrl)
+
+	// Create use case
+	cfg := newTransactionTestConfig()
+	cfg.Transfer.FeeFlat = decimal.NewFromInt(2500)
+	feeUseCase := usecase.NewFeeUseCase(mockFeeRuleRepo, cfg)
+
+	t.Run("percentage fee is capped by the rule", func(t *testing.T) {
+		rule := entities.NewFeeRule(uuid.New(), entities.FeeRuleRequest{
+			TransactionType: entities.TransactionTypeTopup,
+			PaymentMethod:   "credit_card",
+			Percent:         decimal.NewFromInt(3),
+			MinFee:          decimal.NewFromInt(2000),
+			MaxFee:          decimal.NewFromInt(10000),
+		})
+
+		// Mock expectations
+		mockFeeRuleRepo.EXPECT().FindApplicable(gomock.Any(), entities.TransactionTypeTopup, "credit_card").Return(rule, nil).Times(3)
+
+		// Execute
+		low, err := feeUseCase.Quote(context.Background(), entities.TransactionTypeTopup, "credit_card", decimal.NewFromInt(50000))
+		require.NoError(t, err)
+		mid, err := feeUseCase.Quote(context.Background(), entities.TransactionTypeTopup, "credit_card", decimal.NewFromInt(200000))
+		require.NoError(t, err)
+		high, err := feeUseCase.Quote(context.Background(), entities.TransactionTypeTopup, "credit_card", decimal.NewFromInt(1000000))
+		require.NoError(t, err)
+
+		// Assert
+		assert.True(t, low.Fee.Equal(decimal.NewFromInt(2000)))
+		assert.True(t, mid.Fee.Equal(decimal.NewFromInt(6000)))
+		assert.True(t, mid.Total.Equal(decimal.NewFromInt(206000)))
+		assert.True(t, high.Fee.Equal(decimal.NewFromInt(10000)))
+		require.NotNil(t, mid.FeeRuleID)
+		assert.Equal(t, rule.ID, *mid.FeeRuleID)
+	})
+
+	t.Run("transfers within the free tier are free", func(t *testing.T) {
+		rule := entities.NewFeeRule(uuid.New(), entities.FeeRuleRequest{
+			TransactionType: entities.TransactionTypeTransfer,
+			MinFee:          decimal.NewFromInt(1000),
+			Tiers: []entities.FeeTier{
+				{UpTo: decimal.NewFromInt(1000000)},
+				{FlatFee: decimal.NewFromInt(2500)},
+			},
+		})
+
+		// Mock expectations
+		mockFeeRuleRepo.EXPECT().FindApplicable(gomock.Any(), entities.TransactionTypeTransfer, "").Return(rule, nil).Times(2)
+
+		// Execute
+		free, err := feeUseCase.Quote(context.Background(), entities.TransactionTypeTransfer, "", decimal.NewFromInt(1000000))
+		require.NoError(t, err)
+		charged, err := feeUseCase.Quote(context.Background(), entities.TransactionTypeTransfer, "", decimal.NewFromInt(1000001))
+		require.NoError(t, err)
+
+		// Assert
+		assert.True(t, free.Fee.IsZero())
+		assert.True(t, charged.Fee.Equal(decimal.NewFromInt(2500)))
+	})
+
+	t.Run("without a rule the policy fee applies", func(t *testing.T) {
+		// Mock expectations
+		mockFeeRuleRepo.EXPECT().FindApplicable(gomock.Any(), entities.TransactionTypeTransfer, "").
+			Return(nil, customerrors.NewNotFoundError("No fee rule applies"))
+
+		// Execute
+		quote, err := feeUseCase.Quote(context.Background(), entities.TransactionTypeTransfer, "", decimal.NewFromInt(50000))
+
+		// Assert
+		require.NoError(t, err)
+		assert.True(t, quote.Fee.Equal(decimal.NewFromInt(2500)))
+		assert.Nil(t, quote.FeeRuleID)
+	})
+
+	t.Run("tiers out of order are refused", func(t *testing.T) {
+		// Execute
+		_, err := feeUseCase.CreateFeeRule(context.Background(), uuid.New(), entities.FeeRuleRequest{
+			TransactionType: entities.TransactionTypeTransfer,
+			Tiers: []entities.FeeTier{
+				{UpTo: decimal.NewFromInt(500000), FlatFee: decimal.NewFromInt(1000)},
+				{UpTo: decimal.NewFromInt(100000)},
+			},
+		})
+
+		// Assert
+		require.Error(t, err)
+		assert.True(t, customerrors.IsValidationError(err))
+	})
+}
+
+func TestTransactionUseCase_TopupFee(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Mock repositories
+	mockTransactionRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockLedgerRepo := mocks.NewMockLedgerRepository(ctrl)
+	mockTxManager := newPassThroughTxManager(ctrl)
+	mockPaymentGateway := mocks.NewMockPaymentGateway(ctrl)
+	mockFeeRuleRepo := mocks.NewMockFeeRuleRepository(ctrl)
+
+	// Create use case
+	cfg := newTransactionTestConfig()
+	transactionUseCase := usecase.NewTransactionUseCase(mockTransactionRepo, mockUserRepo, mockLedgerRepo, mockTxManager, mockPaymentGateway, usecase.NewFeeUseCase(mockFeeRuleRepo, cfg), newDefaultLimitUseCase(ctrl, mockTransactionRepo, mockUserRepo, cfg), cfg)
+
+	rule := entities.NewFeeRule(uuid.New(), entities.FeeRuleRequest{
+		TransactionType: entities.TransactionTypeTopup,
+		PaymentMethod:   "bank_transfer",
+		FlatFee:         decimal.NewFromInt(4000),
+	})
+
+	t.Run("topup fee is collected by the gateway on top of the amount", func(t *testing.T) {
+		user := &entities.User{ID: uuid.New(), Status: entities.UserStatusActive}
+
+		// Mock expectations
+		var created *entities.Transaction
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil)
+		mockFeeRuleRepo.EXPECT().FindApplicable(gomock.Any(), entities.TransactionTypeTopup, "bank_transfer").Return(rule, nil)
+		mockTransactionRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, transaction *entities.Transaction) error {
+				created = transaction
+				return nil
+			})
+		mockPaymentGateway.EXPECT().CreateTopupTransaction(gomock.Any(), user.ID, "104000", "bank_transfer", gomock.Any(), gomock.Any()).
+			Return(&usecase.PaymentGatewayResponse{GatewayID: "snap-token", PaymentURL: "https://payment.gateway.com/pay/1"}, nil)
+		mockTransactionRepo.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil)
+
+		// Execute
+		response, err := transactionUseCase.TopupBalance(context.Background(), user.ID, entities.TopupRequest{
+			Amount:        decimal.NewFromInt(100000),
+			PaymentMethod: "bank_transfer",
+		})
+
+		// Assert
+		require.NoError(t, err)
+		assert.True(t, response.Amount.Equal(decimal.NewFromInt(100000)))
+		assert.True(t, response.Fee.Equal(decimal.NewFromInt(4000)))
+		assert.Equal(t, "bank_transfer", created.Metadata[entities.MetadataPaymentMethod])
+		assert.Equal(t, rule.ID.String(), created.Metadata[entities.MetadataFeeRuleID])
+	})
+
+	t.Run("fractional percentage fee is rounded up so the callback settles", func(t *testing.T) {
+		user := &entities.User{ID: uuid.New(), Status: entities.UserStatusActive}
+		percentRule := entities.NewFeeRule(uuid.New(), entities.FeeRuleRequest{
+			TransactionType: entities.TransactionTypeTopup,
+			PaymentMethod:   "credit_card",
+			Percent:         decimal.RequireFromString("2.5"),
+		})
+		mockCallbackRepo := mocks.NewMockPaymentCallbackRepository(ctrl)
+		callbackUseCase := usecase.NewPaymentCallbackUseCase(mockCallbackRepo, mockTransactionRepo, transactionUseCase, testServerKey)
+
+		// 2.5% of 10001 is 250.025, charged as 251
+		var created *entities.Transaction
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil)
+		mockFeeRuleRepo.EXPECT().FindApplicable(gomock.Any(), entities.TransactionTypeTopup, "credit_card").Return(percentRule, nil)
+		mockTransactionRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, transaction *entities.Transaction) error {
+				created = transaction
+				return nil
+			})
+		mockPaymentGateway.EXPECT().CreateTopupTransaction(gomock.Any(), user.ID, "10252", "credit_card", gomock.Any(), gomock.Any()).
+			Return(&usecase.PaymentGatewayResponse{GatewayID: "snap-token"}, nil)
+		mockTransactionRepo.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil)
+
+		response, err := transactionUseCase.TopupBalance(context.Background(), user.ID, entities.TopupRequest{
+			Amount:        decimal.NewFromInt(10001),
+			PaymentMethod: "credit_card",
+		})
+		require.NoError(t, err)
+		assert.True(t, response.Fee.Equal(decimal.NewFromInt(251)))
+
+		// The gateway reports the whole amount it collected
+		payload := signedCallbackPayload(t, created.Reference, "settlement", "10252.00", testServerKey)
+		mockTransactionRepo.EXPECT().GetByReference(gomock.Any(), created.Reference).Return(created, nil)
+		mockTransactionRepo.EXPECT().GetByReferenceForUpdate(gomock.Any(), created.Reference).Return(created, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil)
+		mockLedgerRepo.EXPECT().CreateEntry(gomock.Any(), gomock.Any()).Return(nil)
+		mockUserRepo.EXPECT().AddBalance(gomock.Any(), user.ID, decimalEq{decimal.NewFromInt(10001)}).Return(nil)
+		mockTransactionRepo.EXPECT().Update(gomock.Any(), created).Return(nil)
+		mockCallbackRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+
+		callback, err := callbackUseCase.HandleCallback(context.Background(), payload)
+		require.NoError(t, err)
+		assert.Empty(t, callback.RejectionReason)
+		assert.Equal(t, entities.TransactionStatusCompleted, created.Status)
+	})
+
+	t.Run("fractional topup amount is refused", func(t *testing.T) {
+		// Execute
+		_, err := transactionUseCase.TopupBalance(context.Background(), uuid.New(), entities.TopupRequest{
+			Amount:        decimal.RequireFromString("100.50"),
+			PaymentMethod: "credit_card",
+		})
+
+		// Assert
+		require.Error(t, err)
+		assert.True(t, customerrors.IsValidationError(err))
+	})
+
+	t.Run("unsupported payment method is refused", func(t *testing.T) {
+		// Execute
+		_, err := transactionUseCase.TopupBalance(context.Background(), uuid.New(), entities.TopupRequest{
+			Amount:        decimal.NewFromInt(100000),
+			PaymentMethod: "cash",
+		})
+
+		// Assert
+		require.Error(t, err)
+		assert.True(t, customerrors.IsValidationError(err))
+	})
+
+	t.Run("settled topup books the fee as fee income", func(t *testing.T) {
+		transaction := entities.NewTransaction(uuid.New(), entities.TransactionTypeTopup, decimal.NewFromInt(100000), "Balance top-up")
+		transaction.Fee = decimal.NewFromInt(4000)
+		transaction.MarkAsProcessing()
+
+		// Mock expectations
+		mockTransactionRepo.EXPECT().GetByReferenceForUpdate(gomock.Any(), transaction.Reference).Return(transaction, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), transaction.UserID).Return(&entities.User{ID: transaction.UserID}, nil)
+		mockLedgerRepo.EXPECT().CreateEntry(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, entry *entities.JournalEntry) error {
+				require.NoError(t, entry.Validate())
+				assert.True(t, walletDeltas(entry)[transaction.UserID].Equal(decimal.NewFromInt(100000)))
+				feeIncome := decimal.Zero
+				for _, posting := range entry.Postings {
+					if posting.Account == entities.LedgerAccountFeeIncome && posting.Direction == entities.PostingDirectionCredit {
+						feeIncome = feeIncome.Add(posting.Amount)
+					}
+				}
+				assert.True(t, feeIncome.Equal(decimal.NewFromInt(4000)))
+				return nil
+			})
+		mockUserRepo.EXPECT().AddBalance(gomock.Any(), transaction.UserID, decimalEq{decimal.NewFromInt(100000)}).Return(nil)
+		mockTransactionRepo.EXPECT().Update(gomock.Any(), transaction).Return(nil)
+
+		// Execute
+		err := transactionUseCase.ProcessCallback(context.Background(), transaction.Reference, entities.TransactionStatusCompleted)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, entities.TransactionStatusCompleted, transaction.Status)
+	})
+}
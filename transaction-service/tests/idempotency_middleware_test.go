@@ -0,0 +1,88 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go-transaction-service/internal/delivery/http/middleware"
+	"go-transaction-service/internal/domain/entities"
+	"go-transaction-service/internal/mocks"
+	"go.uber.org/zap"
+)
+
+func TestIdempotencyMiddleware_Handle(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Mock use case
+	mockIdempotencyUseCase := mocks.NewMockIdempotencyUseCase(ctrl)
+
+	// Create middleware
+	idempotency := middleware.NewIdempotencyMiddleware(mockIdempotencyUseCase, zap.NewNop())
+
+	serve := func(handler echo.HandlerFunc) *httptest.ResponseRecorder {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/transactions/pay", strings.NewReader(`{"amount":"100"}`))
+		req.Header.Set(entities.IdempotencyKeyHeader, "key-1")
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.Set("user_id", uuid.New())
+
+		_ = idempotency.Handle(handler)(c)
+		return rec
+	}
+
+	t.Run("client error releases the key", func(t *testing.T) {
+		record := &entities.IdempotencyRecord{Key: "key-1"}
+
+		// Mock expectations
+		mockIdempotencyUseCase.EXPECT().Begin(gomock.Any(), gomock.Any(), "key-1", gomock.Any()).Return(record, false, nil)
+		mockIdempotencyUseCase.EXPECT().Release(gomock.Any(), record).Return(nil)
+
+		// Execute
+		rec := serve(func(c echo.Context) error {
+			return c.JSON(http.StatusBadRequest, map[string]string{"message": "Insufficient balance"})
+		})
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("server error is stored", func(t *testing.T) {
+		record := &entities.IdempotencyRecord{Key: "key-1"}
+
+		// Mock expectations
+		mockIdempotencyUseCase.EXPECT().Begin(gomock.Any(), gomock.Any(), "key-1", gomock.Any()).Return(record, false, nil)
+		mockIdempotencyUseCase.EXPECT().Complete(gomock.Any(), record, http.StatusInternalServerError, gomock.Any()).Return(nil)
+
+		// Execute
+		rec := serve(func(c echo.Context) error {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"message": "Failed to update transaction"})
+		})
+
+		// Assert
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	})
+
+	t.Run("returned error is written and stored", func(t *testing.T) {
+		record := &entities.IdempotencyRecord{Key: "key-1"}
+
+		// Mock expectations
+		mockIdempotencyUseCase.EXPECT().Begin(gomock.Any(), gomock.Any(), "key-1", gomock.Any()).Return(record, false, nil)
+		mockIdempotencyUseCase.EXPECT().Complete(gomock.Any(), record, http.StatusInternalServerError, gomock.Not(gomock.Len(0))).Return(nil)
+
+		// Execute
+		rec := serve(func(c echo.Context) error {
+			return echo.NewHTTPError(http.StatusInternalServerError)
+		})
+
+		// Assert
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	})
+}
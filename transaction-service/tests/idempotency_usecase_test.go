@@ -0,0 +1,119 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-transaction-service/internal/domain/entities"
+	"go-transaction-service/internal/mocks"
+	"go-transaction-service/internal/usecase"
+	"go-transaction-service/pkg/errors"
+)
+
+//go:generate mockgen -source=../internal/domain/repositories/idempotency_repository.go -destination=../internal/mocks/idempotency_repository_mock.go
+
+func TestIdempotencyUseCase_Begin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Mock repository
+	mockIdempotencyRepo := mocks.NewMockIdempotencyRepository(ctrl)
+
+	// Create use case
+	idempotencyUseCase := usecase.NewIdempotencyUseCase(mockIdempotencyRepo)
+
+	t.Run("new key is reserved", func(t *testing.T) {
+		userID := uuid.New()
+
+		// Mock expectations
+		mockIdempotencyRepo.EXPECT().Reserve(gomock.Any(), gomock.Any()).Return(true, nil)
+
+		// Execute
+		record, replay, err := idempotencyUseCase.Begin(context.Background(), userID, "key-1", "hash-1")
+
+		// Assert
+		require.NoError(t, err)
+		assert.False(t, replay)
+		assert.Equal(t, userID, record.UserID)
+		assert.Equal(t, "key-1", record.Key)
+		assert.Equal(t, "hash-1", record.RequestHash)
+		assert.False(t, record.IsCompleted())
+	})
+
+	t.Run("completed key with same request is replayed", func(t *testing.T) {
+		userID := uuid.New()
+		completedAt := time.Now()
+		existing := &entities.IdempotencyRecord{
+			Key:          "key-1",
+			UserID:       userID,
+			RequestHash:  "hash-1",
+			ResponseCode: http.StatusCreated,
+			ResponseBody: []byte(`{"success":true}`),
+			CompletedAt:  &completedAt,
+		}
+
+		// Mock expectations
+		mockIdempotencyRepo.EXPECT().Reserve(gomock.Any(), gomock.Any()).Return(false, nil)
+		mockIdempotencyRepo.EXPECT().Get(gomock.Any(), userID, "key-1").Return(existing, nil)
+
+		// Execute
+		record, replay, err := idempotencyUseCase.Begin(context.Background(), userID, "key-1", "hash-1")
+
+		// Assert
+		require.NoError(t, err)
+		assert.True(t, replay)
+		assert.Equal(t, http.StatusCreated, record.ResponseCode)
+		assert.Equal(t, existing.ResponseBody, record.ResponseBody)
+	})
+
+	t.Run("key reused with different request", func(t *testing.T) {
+		userID := uuid.New()
+		completedAt := time.Now()
+		existing := &entities.IdempotencyRecord{
+			Key:         "key-1",
+			UserID:      userID,
+			RequestHash: "hash-1",
+			CompletedAt: &completedAt,
+		}
+
+		// Mock expectations
+		mockIdempotencyRepo.EXPECT().Reserve(gomock.Any(), gomock.Any()).Return(false, nil)
+		mockIdempotencyRepo.EXPECT().Get(gomock.Any(), userID, "key-1").Return(existing, nil)
+
+		// Execute
+		record, replay, err := idempotencyUseCase.Begin(context.Background(), userID, "key-1", "hash-2")
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, record)
+		assert.False(t, replay)
+		assert.Equal(t, http.StatusConflict, customerrors.GetErrorCode(err))
+	})
+
+	t.Run("key still in flight", func(t *testing.T) {
+		userID := uuid.New()
+		existing := &entities.IdempotencyRecord{
+			Key:         "key-1",
+			UserID:      userID,
+			RequestHash: "hash-1",
+		}
+
+		// Mock expectations
+		mockIdempotencyRepo.EXPECT().Reserve(gomock.Any(), gomock.Any()).Return(false, nil)
+		mockIdempotencyRepo.EXPECT().Get(gomock.Any(), userID, "key-1").Return(existing, nil)
+
+		// Execute
+		record, _, err := idempotencyUseCase.Begin(context.Background(), userID, "key-1", "hash-1")
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, record)
+		assert.Equal(t, http.StatusConflict, customerrors.GetErrorCode(err))
+	})
+}
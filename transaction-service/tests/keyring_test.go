@@ -0,0 +1,198 @@
+package tests
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-transaction-service/internal/config"
+	"go-transaction-service/internal/infrastructure/keyring"
+	"go-transaction-service/internal/usecase"
+	"go.uber.org/zap"
+)
+
+// newTestKeyring returns an Ed25519 keyring in a temporary directory
+func newTestKeyring(t *testing.T) usecase.TokenKeyring {
+	t.Helper()
+
+	tokenKeyring, err := keyring.NewFileKeyring(config.JWTConfig{
+		KeysDir:   t.TempDir(),
+		Algorithm: keyring.AlgorithmEdDSA,
+	}, zap.NewNop())
+	require.NoError(t, err)
+
+	return tokenKeyring
+}
+
+// writeTestKey stores a new Ed25519 key under the given kid
+func writeTestKey(t *testing.T, dir, kid string) {
+	t.Helper()
+
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, kid+".pem"), pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600))
+}
+
+// parseWith verifies a token with the keyring the way ValidateToken does
+func parseWith(tokenKeyring usecase.TokenKeyring, token string) (*jwt.Token, error) {
+	return jwt.Parse(token, tokenKeyring.Keyfunc,
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}))
+}
+
+func TestFileKeyring(t *testing.T) {
+	claims := jwt.MapClaims{"sub": "user", "exp": time.Now().Add(time.Hour).Unix()}
+
+	for _, algorithm := range []string{keyring.AlgorithmRS256, keyring.AlgorithmEdDSA} {
+		t.Run("sign and verify with "+algorithm, func(t *testing.T) {
+			dir := t.TempDir()
+			tokenKeyring, err := keyring.NewFileKeyring(config.JWTConfig{KeysDir: dir, Algorithm: algorithm}, zap.NewNop())
+			require.NoError(t, err)
+
+			// A first key is generated and published
+			files, _ := filepath.Glob(filepath.Join(dir, "*.pem"))
+			require.Len(t, files, 1)
+			jwks := tokenKeyring.JWKS()
+			require.Len(t, jwks.Keys, 1)
+			assert.Equal(t, algorithm, jwks.Keys[0].Alg)
+
+			token, err := tokenKeyring.Sign(claims)
+			require.NoError(t, err)
+
+			parsed, err := parseWith(tokenKeyring, token)
+			require.NoError(t, err)
+			assert.True(t, parsed.Valid)
+			assert.Equal(t, jwks.Keys[0].Kid, parsed.Header["kid"])
+		})
+	}
+
+	t.Run("rotation keeps the old key during the grace window", func(t *testing.T) {
+		dir := t.TempDir()
+		cfg := config.JWTConfig{
+			KeysDir:          dir,
+			Algorithm:        keyring.AlgorithmEdDSA,
+			ExpireDuration:   15 * time.Minute,
+			RotationInterval: 24 * time.Hour,
+			GraceWindow:      time.Hour,
+		}
+		tokenKeyring, err := keyring.NewFileKeyring(cfg, zap.NewNop())
+		require.NoError(t, err)
+
+		oldToken, err := tokenKeyring.Sign(claims)
+		require.NoError(t, err)
+
+		// Not due yet
+		rotated, err := tokenKeyring.Rotate(time.Now())
+		require.NoError(t, err)
+		assert.False(t, rotated)
+
+		// Due: a new key signs, the old one still verifies
+		rotatedAt := time.Now().Add(25 * time.Hour)
+		rotated, err = tokenKeyring.Rotate(rotatedAt)
+		require.NoError(t, err)
+		assert.True(t, rotated)
+		require.Len(t, tokenKeyring.JWKS().Keys, 2)
+
+		newToken, err := tokenKeyring.Sign(claims)
+		require.NoError(t, err)
+		newParsed, err := parseWith(tokenKeyring, newToken)
+		require.NoError(t, err)
+		assert.Equal(t, tokenKeyring.JWKS().Keys[0].Kid, newParsed.Header["kid"])
+
+		_, err = parseWith(tokenKeyring, oldToken)
+		require.NoError(t, err)
+
+		// After the grace window the old key is dropped and its file removed
+		_, err = tokenKeyring.Rotate(rotatedAt.Add(2 * time.Hour))
+		require.NoError(t, err)
+		require.Len(t, tokenKeyring.JWKS().Keys, 1)
+		files, _ := filepath.Glob(filepath.Join(dir, "*.pem"))
+		assert.Len(t, files, 1)
+
+		_, err = parseWith(tokenKeyring, oldToken)
+		assert.Error(t, err)
+	})
+
+	t.Run("keys added by another instance are picked up", func(t *testing.T) {
+		dir := t.TempDir()
+		cfg := config.JWTConfig{KeysDir: dir, Algorithm: keyring.AlgorithmEdDSA, GraceWindow: time.Hour}
+		first, err := keyring.NewFileKeyring(cfg, zap.NewNop())
+		require.NoError(t, err)
+		second, err := keyring.NewFileKeyring(cfg, zap.NewNop())
+		require.NoError(t, err)
+
+		token, err := second.Sign(claims)
+		require.NoError(t, err)
+
+		_, err = parseWith(first, token)
+		require.NoError(t, err)
+	})
+
+	t.Run("token without known kid is rejected", func(t *testing.T) {
+		tokenKeyring := newTestKeyring(t)
+		other := newTestKeyring(t)
+
+		token, err := other.Sign(claims)
+		require.NoError(t, err)
+
+		_, err = parseWith(tokenKeyring, token)
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown kid does not remove expired key files", func(t *testing.T) {
+		dir := t.TempDir()
+		writeTestKey(t, dir, "20200101T000000Z-aaaa")
+		writeTestKey(t, dir, "20200102T000000Z-bbbb")
+		cfg := config.JWTConfig{KeysDir: dir, Algorithm: keyring.AlgorithmEdDSA, GraceWindow: time.Hour}
+		tokenKeyring, err := keyring.NewFileKeyring(cfg, zap.NewNop())
+		require.NoError(t, err)
+
+		// The replaced key no longer verifies but its file stays until the rotation job runs
+		require.Len(t, tokenKeyring.JWKS().Keys, 1)
+		assert.Equal(t, "20200102T000000Z-bbbb", tokenKeyring.JWKS().Keys[0].Kid)
+
+		token, err := newTestKeyring(t).Sign(claims)
+		require.NoError(t, err)
+		for i := 0; i < 3; i++ {
+			_, err = parseWith(tokenKeyring, token)
+			assert.Error(t, err)
+		}
+		files, _ := filepath.Glob(filepath.Join(dir, "*.pem"))
+		assert.Len(t, files, 2)
+
+		_, err = tokenKeyring.Rotate(time.Now())
+		require.NoError(t, err)
+		files, _ = filepath.Glob(filepath.Join(dir, "*.pem"))
+		assert.Equal(t, []string{filepath.Join(dir, "20200102T000000Z-bbbb.pem")}, files)
+	})
+
+	t.Run("HMAC token is rejected", func(t *testing.T) {
+		tokenKeyring := newTestKeyring(t)
+		kid := tokenKeyring.JWKS().Keys[0].Kid
+
+		hmac := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		hmac.Header["kid"] = kid
+		token, err := hmac.SignedString([]byte("guessed-secret"))
+		require.NoError(t, err)
+
+		_, err = parseWith(tokenKeyring, token)
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid key file", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "broken.pem"), []byte("not a key"), 0o600))
+
+		_, err := keyring.NewFileKeyring(config.JWTConfig{KeysDir: dir, Algorithm: keyring.AlgorithmRS256}, zap.NewNop())
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,386 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-transaction-service/internal/config"
+	"go-transaction-service/internal/domain/entities"
+	"go-transaction-service/internal/mocks"
+	"go-transaction-service/internal/usecase"
+	"go-transaction-service/pkg/errors"
+)
+
+// pngContent is enough of a PNG file for its content type to be detected
+var pngContent = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01")
+
+func newKYCSubmissionRequest(tier entities.KYCTier) entities.KYCSubmissionRequest {
+	return entities.KYCSubmissionRequest{
+		Tier:        tier,
+		FullName:    "John Doe",
+		DateOfBirth: "1990-01-31",
+		Nationality: "id",
+		IDType:      entities.KYCIDTypeNationalID,
+		IDNumber:    "3174012345678901",
+		Address:     "Jl. Sudirman 1, Jakarta",
+	}
+}
+
+func TestKYCUseCase_Submit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Mock repositories
+	mockKYCRepo := mocks.NewMockKYCSubmissionRepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockBlobStore := mocks.NewMockBlobStore(ctrl)
+
+	// Create use case
+	kycUseCase := usecase.NewKYCUseCase(mockKYCRepo, mockUserRepo, newPassThroughTxManager(ctrl), mockBlobStore, newTransactionTestConfig())
+
+	noSubmission := customerrors.NewNotFoundError("KYC submission not found")
+
+	t.Run("successful basic submission", func(t *testing.T) {
+		user := &entities.User{ID: uuid.New(), Status: entities.UserStatusActive, KYCTier: entities.KYCTierUnverified}
+
+		// Mock expectations
+		mockUserRepo.EXPECT().GetByID(gomock.Any(), user.ID).Return(user, nil)
+		mockKYCRepo.EXPECT().GetLatestByUserID(gomock.Any(), user.ID).Return(nil, noSubmission)
+		mockBlobStore.EXPECT().Put(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, key string, content io.Reader) error {
+				assert.True(t, strings.HasPrefix(key, "kyc/"+user.ID.String()+"/"))
+				assert.True(t, strings.HasSuffix(key, "/id_document.png"))
+				stored, err := io.ReadAll(content)
+				require.NoError(t, err)
+				assert.Equal(t, pngContent, stored)
+				return nil
+			})
+		mockKYCRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+
+		// Execute
+		submission, err := kycUseCase.Submit(context.Background(), user.ID, newKYCSubmissionRequest(entities.KYCTierBasic), []entities.KYCDocumentUpload{
+			{Kind: entities.KYCDocumentIDDocument, FileName: "../ktp.png", Content: pngContent},
+		})
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, entities.KYCSubmissionStatusPending, submission.Status)
+		assert.Equal(t, entities.KYCTierBasic, submission.RequestedTier)
+		assert.Equal(t, "ID", submission.Nationality)
+		require.Len(t, submission.Documents, 1)
+		assert.Equal(t, "image/png", submission.Documents[0].ContentType)
+		assert.Equal(t, "ktp.png", submission.Documents[0].FileName)
+	})
+
+	t.Run("full tier needs every document", func(t *testing.T) {
+		user := &entities.User{ID: uuid.New(), Status: entities.UserStatusActive, KYCTier: entities.KYCTierBasic}
+
+		// Mock expectations
+		mockUserRepo.EXPECT().GetByID(gomock.Any(), user.ID).Return(user, nil)
+		mockKYCRepo.EXPECT().GetLatestByUserID(gomock.Any(), user.ID).Return(nil, noSubmission)
+
+		// Execute
+		submission, err := kycUseCase.Submit(context.Background(), user.ID, newKYCSubmissionRequest(entities.KYCTierFull), []entities.KYCDocumentUpload{
+			{Kind: entities.KYCDocumentIDDocument, FileName: "ktp.png", Content: pngContent},
+		})
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, submission)
+		assert.True(t, customerrors.IsValidationError(err))
+	})
+
+	t.Run("tier already reached", func(t *testing.T) {
+		user := &entities.User{ID: uuid.New(), Status: entities.UserStatusActive, KYCTier: entities.KYCTierBasic}
+
+		// Mock expectations
+		mockUserRepo.EXPECT().GetByID(gomock.Any(), user.ID).Return(user, nil)
+
+		// Execute
+		_, err := kycUseCase.Submit(context.Background(), user.ID, newKYCSubmissionRequest(entities.KYCTierBasic), []entities.KYCDocumentUpload{
+			{Kind: entities.KYCDocumentIDDocument, FileName: "ktp.png", Content: pngContent},
+		})
+
+		// Assert
+		require.Error(t, err)
+		assert.True(t, customerrors.IsValidationError(err))
+	})
+
+	t.Run("submission already pending", func(t *testing.T) {
+		user := &entities.User{ID: uuid.New(), Status: entities.UserStatusActive}
+		pending := &entities.KYCSubmission{ID: uuid.New(), UserID: user.ID, Status: entities.KYCSubmissionStatusPending}
+
+		// Mock expectations
+		mockUserRepo.EXPECT().GetByID(gomock.Any(), user.ID).Return(user, nil)
+		mockKYCRepo.EXPECT().GetLatestByUserID(gomock.Any(), user.ID).Return(pending, nil)
+
+		// Execute
+		_, err := kycUseCase.Submit(context.Background(), user.ID, newKYCSubmissionRequest(entities.KYCTierBasic), []entities.KYCDocumentUpload{
+			{Kind: entities.KYCDocumentIDDocument, FileName: "ktp.png", Content: pngContent},
+		})
+
+		// Assert
+		require.Error(t, err)
+		assert.True(t, customerrors.IsConflictError(err))
+	})
+
+	t.Run("unsupported document type", func(t *testing.T) {
+		user := &entities.User{ID: uuid.New(), Status: entities.UserStatusActive}
+
+		// Mock expectations
+		mockUserRepo.EXPECT().GetByID(gomock.Any(), user.ID).Return(user, nil)
+		mockKYCRepo.EXPECT().GetLatestByUserID(gomock.Any(), user.ID).Return(nil, noSubmission)
+
+		// Execute
+		_, err := kycUseCase.Submit(context.Background(), user.ID, newKYCSubmissionRequest(entities.KYCTierBasic), []entities.KYCDocumentUpload{
+			{Kind: entities.KYCDocumentIDDocument, FileName: "ktp.png", Content: []byte("<html><script>alert(1)</script></html>")},
+		})
+
+		// Assert
+		require.Error(t, err)
+		assert.True(t, customerrors.IsValidationError(err))
+	})
+
+	t.Run("stored documents are removed when the submission cannot be saved", func(t *testing.T) {
+		user := &entities.User{ID: uuid.New(), Status: entities.UserStatusActive}
+		var storedKey string
+
+		// Mock expectations
+		mockUserRepo.EXPECT().GetByID(gomock.Any(), user.ID).Return(user, nil)
+		mockKYCRepo.EXPECT().GetLatestByUserID(gomock.Any(), user.ID).Return(nil, noSubmission)
+		mockBlobStore.EXPECT().Put(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, key string, _ io.Reader) error {
+				storedKey = key
+				return nil
+			})
+		mockKYCRepo.EXPECT().Create(gomock.Any(), gomock.Any()).
+			Return(customerrors.NewConflictError("A KYC submission is already waiting for review"))
+		mockBlobStore.EXPECT().Delete(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, key string) error {
+				assert.Equal(t, storedKey, key)
+				return nil
+			})
+
+		// Execute
+		_, err := kycUseCase.Submit(context.Background(), user.ID, newKYCSubmissionRequest(entities.KYCTierBasic), []entities.KYCDocumentUpload{
+			{Kind: entities.KYCDocumentIDDocument, FileName: "ktp.png", Content: pngContent},
+		})
+
+		// Assert
+		require.Error(t, err)
+		assert.True(t, customerrors.IsConflictError(err))
+	})
+}
+
+func TestKYCUseCase_Review(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Mock repositories
+	mockKYCRepo := mocks.NewMockKYCSubmissionRepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockBlobStore := mocks.NewMockBlobStore(ctrl)
+
+	// Create use case
+	kycUseCase := usecase.NewKYCUseCase(mockKYCRepo, mockUserRepo, newPassThroughTxManager(ctrl), mockBlobStore, newTransactionTestConfig())
+
+	reviewerID := uuid.New()
+
+	t.Run("approval raises the tier", func(t *testing.T) {
+		user := &entities.User{ID: uuid.New(), Status: entities.UserStatusActive, KYCTier: entities.KYCTierUnverified}
+		submission := &entities.KYCSubmission{ID: uuid.New(), UserID: user.ID, RequestedTier: entities.KYCTierBasic, Status: entities.KYCSubmissionStatusPending}
+
+		// Mock expectations
+		mockKYCRepo.EXPECT().GetByIDForUpdate(gomock.Any(), submission.ID).Return(submission, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil)
+		mockKYCRepo.EXPECT().UpdateReview(gomock.Any(), submission).Return(nil)
+		mockUserRepo.EXPECT().UpdateKYCTier(gomock.Any(), user.ID, entities.KYCTierBasic).Return(nil)
+
+		// Execute
+		reviewed, err := kycUseCase.ApproveSubmission(context.Background(), reviewerID, submission.ID, "Document matches the selfie")
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, entities.KYCSubmissionStatusApproved, reviewed.Status)
+		assert.Equal(t, &reviewerID, reviewed.ReviewedBy)
+		assert.NotNil(t, reviewed.ReviewedAt)
+	})
+
+	t.Run("submission already reviewed", func(t *testing.T) {
+		user := &entities.User{ID: uuid.New(), Status: entities.UserStatusActive}
+		submission := &entities.KYCSubmission{ID: uuid.New(), UserID: user.ID, RequestedTier: entities.KYCTierBasic, Status: entities.KYCSubmissionStatusRejected}
+
+		// Mock expectations
+		mockKYCRepo.EXPECT().GetByIDForUpdate(gomock.Any(), submission.ID).Return(submission, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil)
+
+		// Execute
+		_, err := kycUseCase.ApproveSubmission(context.Background(), reviewerID, submission.ID, "")
+
+		// Assert
+		require.Error(t, err)
+		assert.True(t, customerrors.IsConflictError(err))
+	})
+
+	t.Run("rejection keeps the tier", func(t *testing.T) {
+		submission := &entities.KYCSubmission{ID: uuid.New(), UserID: uuid.New(), RequestedTier: entities.KYCTierFull, Status: entities.KYCSubmissionStatusPending}
+
+		// Mock expectations
+		mockKYCRepo.EXPECT().GetByIDForUpdate(gomock.Any(), submission.ID).Return(submission, nil)
+		mockKYCRepo.EXPECT().UpdateReview(gomock.Any(), submission).Return(nil)
+
+		// Execute
+		reviewed, err := kycUseCase.RejectSubmission(context.Background(), reviewerID, submission.ID, " Identity document is expired ")
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, entities.KYCSubmissionStatusRejected, reviewed.Status)
+		assert.Equal(t, "Identity document is expired", reviewed.ReviewReason)
+	})
+
+	t.Run("rejection needs a reason", func(t *testing.T) {
+		// Execute
+		_, err := kycUseCase.RejectSubmission(context.Background(), reviewerID, uuid.New(), "  ")
+
+		// Assert
+		require.Error(t, err)
+		assert.True(t, customerrors.IsValidationError(err))
+	})
+
+	t.Run("missing document file", func(t *testing.T) {
+		submission := &entities.KYCSubmission{
+			ID:     uuid.New(),
+			Status: entities.KYCSubmissionStatusPending,
+			Documents: []entities.KYCDocument{
+				{Kind: entities.KYCDocumentIDDocument, ContentType: "image/png", StorageKey: "kyc/user/submission/id_document.png"},
+			},
+		}
+
+		// Mock expectations
+		mockKYCRepo.EXPECT().GetByID(gomock.Any(), submission.ID).Return(submission, nil)
+		mockBlobStore.EXPECT().Open(gomock.Any(), "kyc/user/submission/id_document.png").Return(nil, usecase.ErrBlobNotFound)
+
+		// Execute
+		_, _, err := kycUseCase.OpenDocument(context.Background(), submission.ID, entities.KYCDocumentIDDocument)
+
+		// Assert
+		require.Error(t, err)
+		assert.True(t, customerrors.IsNotFoundError(err))
+	})
+
+	t.Run("document store failure", func(t *testing.T) {
+		submission := &entities.KYCSubmission{
+			ID: uuid.New(),
+			Documents: []entities.KYCDocument{
+				{Kind: entities.KYCDocumentSelfie, ContentType: "image/jpeg", StorageKey: "kyc/user/submission/selfie.jpg"},
+			},
+		}
+
+		// Mock expectations
+		mockKYCRepo.EXPECT().GetByID(gomock.Any(), submission.ID).Return(submission, nil)
+		mockBlobStore.EXPECT().Open(gomock.Any(), gomock.Any()).Return(nil, errors.New("disk unavailable"))
+
+		// Execute
+		_, _, err := kycUseCase.OpenDocument(context.Background(), submission.ID, entities.KYCDocumentSelfie)
+
+		// Assert
+		require.Error(t, err)
+		assert.True(t, customerrors.IsInternalError(err))
+	})
+}
+
+func TestTransactionUseCase_KYCTiers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Mock repositories
+	mockTransactionRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockLedgerRepo := mocks.NewMockLedgerRepository(ctrl)
+	mockTxManager := newPassThroughTxManager(ctrl)
+	mockPaymentGateway := mocks.NewMockPaymentGateway(ctrl)
+
+	// Create use case
+	cfg := newTransactionTestConfig()
+	cfg.KYC.Unverified = config.KYCTierPolicy{
+		Features:   []string{entities.KYCFeatureTopup, entities.KYCFeaturePayment},
+		MaxBalance: decimal.NewFromInt(1000),
+		MaxAmount:  decimal.NewFromInt(200),
+	}
+	transactionUseCase := usecase.NewTransactionUseCase(mockTransactionRepo, mockUserRepo, mockLedgerRepo, mockTxManager, mockPaymentGateway, newDefaultFeeUseCase(ctrl, cfg), newDefaultLimitUseCase(ctrl, mockTransactionRepo, mockUserRepo, cfg), cfg)
+
+	t.Run("transfer is locked for unverified users", func(t *testing.T) {
+		sender := &entities.User{ID: uuid.New(), Status: entities.UserStatusActive, Balance: decimal.NewFromInt(500), PINHash: testPINHash, KYCTier: entities.KYCTierUnverified}
+		recipient := &entities.User{ID: uuid.New(), Email: "recipient@example.com", Status: entities.UserStatusActive}
+
+		// Mock expectations
+		mockUserRepo.EXPECT().GetByEmail(gomock.Any(), recipient.Email).Return(recipient, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), sender.ID).Return(sender, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), recipient.ID).Return(recipient, nil)
+
+		// Execute
+		receipt, err := transactionUseCase.TransferFunds(context.Background(), sender.ID, entities.TransferRequest{
+			ToEmail: recipient.Email,
+			Amount:  decimal.NewFromInt(100),
+			PIN:     testPIN,
+		})
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, receipt)
+		assert.Equal(t, http.StatusForbidden, customerrors.GetErrorCode(err))
+		assert.Equal(t, entities.KYCReasonFeatureLocked, err.(*customerrors.CustomError).Reason)
+	})
+
+	t.Run("tier caps the amount of a payment", func(t *testing.T) {
+		sender := &entities.User{ID: uuid.New(), Status: entities.UserStatusActive, Balance: decimal.NewFromInt(500), PINHash: testPINHash, KYCTier: entities.KYCTierUnverified}
+		recipient := &entities.User{ID: uuid.New(), Status: entities.UserStatusActive}
+
+		// Mock expectations
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), sender.ID).Return(sender, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), recipient.ID).Return(recipient, nil)
+
+		// Execute
+		response, err := transactionUseCase.ProcessPayment(context.Background(), sender.ID, entities.PaymentRequest{
+			ToUserID: recipient.ID,
+			Amount:   decimal.NewFromInt(300),
+			PIN:      testPIN,
+		})
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, response)
+		assert.Equal(t, entities.LimitReasonMaxAmount, err.(*customerrors.CustomError).Reason)
+	})
+
+	t.Run("higher tier lifts the balance cap", func(t *testing.T) {
+		user := &entities.User{ID: uuid.New(), Status: entities.UserStatusActive, Balance: decimal.NewFromInt(950), KYCTier: entities.KYCTierBasic}
+		amount := decimal.NewFromInt(100)
+
+		// Mock expectations
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil)
+		mockTransactionRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+		mockPaymentGateway.EXPECT().CreateTopupTransaction(gomock.Any(), user.ID, amount.String(), "credit_card", gomock.Any(), gomock.Any()).
+			Return(&usecase.PaymentGatewayResponse{OrderID: "TXN-12345678", Status: "pending"}, nil)
+		mockTransactionRepo.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil)
+
+		// Execute
+		response, err := transactionUseCase.TopupBalance(context.Background(), user.ID, entities.TopupRequest{
+			Amount:        amount,
+			PaymentMethod: "credit_card",
+		})
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, entities.TransactionStatusProcessing, response.Status)
+	})
+}
@@ -0,0 +1,354 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-transaction-service/internal/domain/entities"
+	"go-transaction-service/internal/mocks"
+	"go-transaction-service/internal/usecase"
+	"go-transaction-service/pkg/errors"
+)
+
+func TestLimitUseCase(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Mock repositories
+	mockUserLimitRepo := mocks.NewMockUserLimitRepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockTransactionRepo := mocks.NewMockTransactionRepository(ctrl)
+
+	// Create use case
+	cfg := newTransactionTestConfig()
+	cfg.Payment.DailyCount = 3
+	cfg.Payment.MonthlyLimit = decimal.NewFromInt(10000)
+	cfg.Wallet.MaxBalance = decimal.NewFromInt(2000)
+	limitUseCase := usecase.NewLimitUseCase(mockUserLimitRepo, mockUserRepo, mockTransactionRepo, cfg)
+
+	noOverrides := customerrors.NewNotFoundError("User limits not found")
+
+	t.Run("daily count reached", func(t *testing.T) {
+		userID := uuid.New()
+
+		// Mock expectations
+		mockUserLimitRepo.EXPECT().GetByUserID(gomock.Any(), userID).Return(nil, noOverrides)
+		mockTransactionRepo.EXPECT().GetUsage(gomock.Any(), userID, entities.TransactionTypePayment, gomock.Any(), gomock.Any()).
+			Return(&entities.LimitUsage{DailyCount: 3, DailyAmount: decimal.NewFromInt(300), MonthlyCount: 3, MonthlyAmount: decimal.NewFromInt(300)}, nil)
+
+		// Execute
+		err := limitUseCase.CheckTransaction(context.Background(), &entities.User{ID: userID}, entities.TransactionTypePayment, decimal.NewFromInt(100))
+
+		// Assert
+		require.Error(t, err)
+		assert.True(t, customerrors.IsValidationError(err))
+		assert.Equal(t, entities.LimitReasonDailyCount, err.(*customerrors.CustomError).Reason)
+	})
+
+	t.Run("monthly amount exceeded", func(t *testing.T) {
+		userID := uuid.New()
+
+		// Mock expectations
+		mockUserLimitRepo.EXPECT().GetByUserID(gomock.Any(), userID).Return(nil, noOverrides)
+		mockTransactionRepo.EXPECT().GetUsage(gomock.Any(), userID, entities.TransactionTypePayment, gomock.Any(), gomock.Any()).
+			Return(&entities.LimitUsage{MonthlyCount: 40, MonthlyAmount: decimal.NewFromInt(9950)}, nil)
+
+		// Execute
+		err := limitUseCase.CheckTransaction(context.Background(), &entities.User{ID: userID}, entities.TransactionTypePayment, decimal.NewFromInt(100))
+
+		// Assert
+		require.Error(t, err)
+		assert.Equal(t, entities.LimitReasonMonthlyAmount, err.(*customerrors.CustomError).Reason)
+	})
+
+	t.Run("user override lifts the daily count", func(t *testing.T) {
+		userID := uuid.New()
+		dailyCount := 10
+		overrides := &entities.UserLimits{
+			UserID: userID,
+			Transactions: map[entities.TransactionType]entities.LimitOverride{
+				entities.TransactionTypePayment: {DailyCount: &dailyCount},
+			},
+		}
+
+		// Mock expectations
+		mockUserLimitRepo.EXPECT().GetByUserID(gomock.Any(), userID).Return(overrides, nil)
+		mockTransactionRepo.EXPECT().GetUsage(gomock.Any(), userID, entities.TransactionTypePayment, gomock.Any(), gomock.Any()).
+			Return(&entities.LimitUsage{DailyCount: 3, DailyAmount: decimal.NewFromInt(300), MonthlyCount: 3, MonthlyAmount: decimal.NewFromInt(300)}, nil)
+
+		// Execute
+		err := limitUseCase.CheckTransaction(context.Background(), &entities.User{ID: userID}, entities.TransactionTypePayment, decimal.NewFromInt(100))
+
+		// Assert
+		assert.NoError(t, err)
+	})
+
+	t.Run("types without caps skip the usage query", func(t *testing.T) {
+		userID := uuid.New()
+
+		// Mock expectations
+		mockUserLimitRepo.EXPECT().GetByUserID(gomock.Any(), userID).Return(nil, noOverrides)
+
+		// Execute
+		err := limitUseCase.CheckTransaction(context.Background(), &entities.User{ID: userID}, entities.TransactionTypeTopup, decimal.NewFromInt(100))
+
+		// Assert
+		assert.NoError(t, err)
+	})
+
+	t.Run("balance above the maximum", func(t *testing.T) {
+		user := &entities.User{ID: uuid.New(), Balance: decimal.NewFromInt(1950)}
+
+		// Mock expectations
+		mockUserLimitRepo.EXPECT().GetByUserID(gomock.Any(), user.ID).Return(nil, noOverrides)
+		mockTransactionRepo.EXPECT().SumPendingIncoming(gomock.Any(), user.ID).Return(decimal.Zero, nil)
+
+		// Execute
+		err := limitUseCase.CheckBalance(context.Background(), user, decimal.NewFromInt(100))
+
+		// Assert
+		require.Error(t, err)
+		assert.Equal(t, entities.LimitReasonMaxBalance, err.(*customerrors.CustomError).Reason)
+	})
+
+	t.Run("pending incoming funds count towards the maximum balance", func(t *testing.T) {
+		user := &entities.User{ID: uuid.New(), Balance: decimal.NewFromInt(1000)}
+
+		// Mock expectations
+		mockUserLimitRepo.EXPECT().GetByUserID(gomock.Any(), user.ID).Return(nil, noOverrides)
+		mockTransactionRepo.EXPECT().SumPendingIncoming(gomock.Any(), user.ID).Return(decimal.NewFromInt(950), nil)
+
+		// Execute
+		err := limitUseCase.CheckBalance(context.Background(), user, decimal.NewFromInt(100))
+
+		// Assert
+		require.Error(t, err)
+		assert.Equal(t, entities.LimitReasonMaxBalance, err.(*customerrors.CustomError).Reason)
+	})
+
+	t.Run("credit of counted funds ignores the pending ones", func(t *testing.T) {
+		user := &entities.User{ID: uuid.New(), Balance: decimal.NewFromInt(1000)}
+
+		// Mock expectations: SumPendingIncoming must not be called
+		mockUserLimitRepo.EXPECT().GetByUserID(gomock.Any(), user.ID).Return(nil, noOverrides)
+
+		// Execute
+		err := limitUseCase.CheckCredit(context.Background(), user, decimal.NewFromInt(100))
+
+		// Assert
+		assert.NoError(t, err)
+	})
+
+	t.Run("admin override of the maximum balance", func(t *testing.T) {
+		adminID := uuid.New()
+		user := &entities.User{ID: uuid.New(), Status: entities.UserStatusActive}
+		maxBalance := decimal.NewFromInt(50000)
+		maxAmount := decimal.NewFromInt(500)
+
+		// Mock expectations
+		mockUserRepo.EXPECT().GetByID(gomock.Any(), user.ID).Return(user, nil)
+		mockUserLimitRepo.EXPECT().Save(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, limits *entities.UserLimits) error {
+				assert.Equal(t, user.ID, limits.UserID)
+				assert.Equal(t, &adminID, limits.UpdatedBy)
+				return nil
+			})
+
+		// Execute
+		response, err := limitUseCase.UpdateUserLimits(context.Background(), adminID, user.ID, entities.UpdateUserLimitsRequest{
+			MaxBalance: &maxBalance,
+			Transactions: map[entities.TransactionType]entities.LimitOverride{
+				entities.TransactionTypeTransfer: {MaxAmount: &maxAmount},
+			},
+		})
+
+		// Assert
+		require.NoError(t, err)
+		assert.True(t, response.MaxBalance.Equal(maxBalance))
+		assert.True(t, response.Transactions[entities.TransactionTypeTransfer].MaxAmount.Equal(maxAmount))
+		assert.True(t, response.Transactions[entities.TransactionTypeTransfer].DailyAmount.Equal(cfg.Transfer.DailyLimit))
+		assert.Equal(t, 3, response.Transactions[entities.TransactionTypePayment].DailyCount)
+	})
+
+	t.Run("negative limits are refused", func(t *testing.T) {
+		user := &entities.User{ID: uuid.New(), Status: entities.UserStatusActive}
+		negative := -1
+
+		// Mock expectations
+		mockUserRepo.EXPECT().GetByID(gomock.Any(), user.ID).Return(user, nil)
+
+		// Execute
+		_, err := limitUseCase.UpdateUserLimits(context.Background(), uuid.New(), user.ID, entities.UpdateUserLimitsRequest{
+			Transactions: map[entities.TransactionType]entities.LimitOverride{
+				entities.TransactionTypePayment: {DailyCount: &negative},
+			},
+		})
+
+		// Assert
+		require.Error(t, err)
+		assert.True(t, customerrors.IsValidationError(err))
+	})
+}
+
+func TestTransactionUseCase_TopupMaxBalance(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Mock repositories
+	mockTransactionRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockLedgerRepo := mocks.NewMockLedgerRepository(ctrl)
+	mockTxManager := newPassThroughTxManager(ctrl)
+	mockPaymentGateway := mocks.NewMockPaymentGateway(ctrl)
+
+	// Create use case
+	cfg := newTransactionTestConfig()
+	cfg.Wallet.MaxBalance = decimal.NewFromInt(1000)
+	transactionUseCase := usecase.NewTransactionUseCase(mockTransactionRepo, mockUserRepo, mockLedgerRepo, mockTxManager, mockPaymentGateway, newDefaultFeeUseCase(ctrl, cfg), newDefaultLimitUseCase(ctrl, mockTransactionRepo, mockUserRepo, cfg), cfg)
+
+	t.Run("topup above the maximum balance is refused", func(t *testing.T) {
+		user := &entities.User{ID: uuid.New(), Status: entities.UserStatusActive, Balance: decimal.NewFromInt(950)}
+
+		// Mock expectations
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil)
+		mockTransactionRepo.EXPECT().SumPendingIncoming(gomock.Any(), user.ID).Return(decimal.Zero, nil)
+
+		// Execute
+		response, err := transactionUseCase.TopupBalance(context.Background(), user.ID, entities.TopupRequest{
+			Amount:        decimal.NewFromInt(100),
+			PaymentMethod: "credit_card",
+		})
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, response)
+		assert.Equal(t, entities.LimitReasonMaxBalance, err.(*customerrors.CustomError).Reason)
+	})
+
+	t.Run("unsettled topups count towards the maximum balance", func(t *testing.T) {
+		user := &entities.User{ID: uuid.New(), Status: entities.UserStatusActive, Balance: decimal.NewFromInt(500)}
+
+		// Mock expectations
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil)
+		mockTransactionRepo.EXPECT().SumPendingIncoming(gomock.Any(), user.ID).Return(decimal.NewFromInt(450), nil)
+
+		// Execute
+		response, err := transactionUseCase.TopupBalance(context.Background(), user.ID, entities.TopupRequest{
+			Amount:        decimal.NewFromInt(100),
+			PaymentMethod: "credit_card",
+		})
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, response)
+		assert.Equal(t, entities.LimitReasonMaxBalance, err.(*customerrors.CustomError).Reason)
+	})
+
+	newHeldTopup := func(userID uuid.UUID) *entities.Transaction {
+		transaction := entities.NewTransaction(userID, entities.TransactionTypeTopup, decimal.NewFromInt(100), "Balance top-up")
+		transaction.Metadata[entities.MetadataReviewReason] = entities.LimitReasonMaxBalance
+		transaction.MarkForReview()
+		return transaction
+	}
+
+	t.Run("paid topup above the maximum balance is held for review", func(t *testing.T) {
+		user := &entities.User{ID: uuid.New(), Status: entities.UserStatusActive, Balance: decimal.NewFromInt(950)}
+		transaction := entities.NewTransaction(user.ID, entities.TransactionTypeTopup, decimal.NewFromInt(100), "Balance top-up")
+		transaction.MarkAsProcessing()
+
+		// Mock expectations: nothing is credited
+		mockTransactionRepo.EXPECT().GetByReferenceForUpdate(gomock.Any(), transaction.Reference).Return(transaction, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil)
+		mockTransactionRepo.EXPECT().Update(gomock.Any(), transaction).Return(nil)
+
+		// Execute
+		err := transactionUseCase.ProcessCallback(context.Background(), transaction.Reference, entities.TransactionStatusCompleted)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, entities.TransactionStatusReview, transaction.Status)
+		assert.Equal(t, entities.LimitReasonMaxBalance, transaction.Metadata[entities.MetadataReviewReason])
+	})
+
+	t.Run("held topup cannot be approved while it still exceeds the maximum", func(t *testing.T) {
+		user := &entities.User{ID: uuid.New(), Status: entities.UserStatusActive, Balance: decimal.NewFromInt(950)}
+		transaction := newHeldTopup(user.ID)
+
+		// Mock expectations
+		mockTransactionRepo.EXPECT().GetByIDForUpdate(gomock.Any(), transaction.ID).Return(transaction, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil)
+
+		// Execute
+		response, err := transactionUseCase.ApproveTransaction(context.Background(), uuid.New(), transaction.ID, "")
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, response)
+		assert.Equal(t, entities.LimitReasonMaxBalance, err.(*customerrors.CustomError).Reason)
+		assert.Equal(t, entities.TransactionStatusReview, transaction.Status)
+	})
+
+	t.Run("approved topup is credited", func(t *testing.T) {
+		reviewerID := uuid.New()
+		user := &entities.User{ID: uuid.New(), Status: entities.UserStatusActive, Balance: decimal.NewFromInt(800)}
+		transaction := newHeldTopup(user.ID)
+
+		// Mock expectations
+		mockTransactionRepo.EXPECT().GetByIDForUpdate(gomock.Any(), transaction.ID).Return(transaction, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil)
+		mockLedgerRepo.EXPECT().CreateEntry(gomock.Any(), gomock.Any()).Return(nil)
+		mockUserRepo.EXPECT().AddBalance(gomock.Any(), user.ID, transaction.Amount).Return(nil)
+		mockTransactionRepo.EXPECT().Update(gomock.Any(), transaction).Return(nil)
+
+		// Execute
+		response, err := transactionUseCase.ApproveTransaction(context.Background(), reviewerID, transaction.ID, "Balance was spent")
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, entities.TransactionStatusCompleted, response.Status)
+		assert.Equal(t, reviewerID.String(), transaction.Metadata[entities.MetadataReviewedBy])
+	})
+
+	t.Run("rejected topup is refunded through the gateway", func(t *testing.T) {
+		transaction := newHeldTopup(uuid.New())
+
+		// Mock expectations
+		mockTransactionRepo.EXPECT().GetByIDForUpdate(gomock.Any(), transaction.ID).Return(transaction, nil)
+		mockTransactionRepo.EXPECT().Update(gomock.Any(), transaction).Return(nil)
+		mockPaymentGateway.EXPECT().Refund(gomock.Any(), transaction.Reference, transaction.Reference, "100", "Over the wallet maximum").
+			Return(&usecase.PaymentGatewayResponse{}, nil)
+
+		// Execute
+		response, err := transactionUseCase.RejectTransaction(context.Background(), uuid.New(), transaction.ID, "Over the wallet maximum")
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, entities.TransactionStatusCancelled, response.Status)
+	})
+
+	t.Run("rejected topup goes back under review when the refund fails", func(t *testing.T) {
+		transaction := newHeldTopup(uuid.New())
+
+		// Mock expectations
+		mockTransactionRepo.EXPECT().GetByIDForUpdate(gomock.Any(), transaction.ID).Return(transaction, nil).Times(2)
+		mockTransactionRepo.EXPECT().Update(gomock.Any(), transaction).Return(nil).Times(2)
+		mockPaymentGateway.EXPECT().Refund(gomock.Any(), transaction.Reference, transaction.Reference, "100", gomock.Any()).
+			Return(nil, errors.New("gateway unavailable"))
+
+		// Execute
+		response, err := transactionUseCase.RejectTransaction(context.Background(), uuid.New(), transaction.ID, "Over the wallet maximum")
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, response)
+		assert.True(t, customerrors.IsInternalError(err))
+		assert.Equal(t, entities.TransactionStatusReview, transaction.Status)
+		assert.Empty(t, transaction.Metadata[entities.MetadataCancelReason])
+	})
+}
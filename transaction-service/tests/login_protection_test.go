@@ -0,0 +1,323 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-transaction-service/internal/config"
+	"go-transaction-service/internal/domain/entities"
+	"go-transaction-service/internal/mocks"
+	"go-transaction-service/internal/usecase"
+	"go-transaction-service/pkg/errors"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func newLoginTestConfig() *config.Config {
+	return &config.Config{
+		JWT: config.JWTConfig{
+			ExpireDuration:        15 * time.Minute,
+			RefreshExpireDuration: 24 * time.Hour,
+		},
+		Login: config.LoginConfig{
+			MaxAttempts:   5,
+			LockDuration:  15 * time.Minute,
+			DelayAfter:    3,
+			BaseDelay:     time.Second,
+			MaxDelay:      30 * time.Second,
+			IPMaxAttempts: 20,
+			IPWindow:      15 * time.Minute,
+		},
+	}
+}
+
+// newLoginUser returns an active user with a password of "password"
+func newLoginUser(t *testing.T) *entities.User {
+	t.Helper()
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	return &entities.User{
+		ID:       uuid.New(),
+		Email:    "test@example.com",
+		Password: string(hashedPassword),
+		Status:   entities.UserStatusActive,
+		Role:     entities.RoleUser,
+	}
+}
+
+func TestUser_LoginRetryAt(t *testing.T) {
+	failedAt := time.Now()
+	user := &entities.User{LastFailedLoginAt: &failedAt}
+
+	// No delay before the threshold
+	user.FailedLoginAttempts = 2
+	assert.True(t, user.LoginRetryAt(3, time.Second, 30*time.Second).IsZero())
+
+	// The wait doubles with each further failure
+	user.FailedLoginAttempts = 3
+	assert.Equal(t, failedAt.Add(time.Second), user.LoginRetryAt(3, time.Second, 30*time.Second))
+	user.FailedLoginAttempts = 5
+	assert.Equal(t, failedAt.Add(4*time.Second), user.LoginRetryAt(3, time.Second, 30*time.Second))
+
+	// And is capped
+	user.FailedLoginAttempts = 20
+	assert.Equal(t, failedAt.Add(30*time.Second), user.LoginRetryAt(3, time.Second, 30*time.Second))
+}
+
+func TestAuthUseCase_LoginProtection(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Mock repositories
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockRefreshTokenRepo := mocks.NewMockRefreshTokenRepository(ctrl)
+	mockRevokedTokenRepo := mocks.NewMockRevokedTokenRepository(ctrl)
+	mockMFARepo := mocks.NewMockMFARepository(ctrl)
+	mockSecurityEventRepo := mocks.NewMockSecurityEventRepository(ctrl)
+	mockSessionRepo := mocks.NewMockSessionRepository(ctrl)
+	mockTxManager := newPassThroughTxManager(ctrl)
+
+	cfg := newLoginTestConfig()
+
+	// Create use case
+	authUseCase := usecase.NewAuthUseCase(mockUserRepo, mockRefreshTokenRepo, mockRevokedTokenRepo, mockMFARepo, mockSecurityEventRepo, mockSessionRepo, mockTxManager, newTestKeyring(t), cfg)
+	client := entities.ClientInfo{IPAddress: "203.0.113.7", UserAgent: "test-agent"}
+
+	t.Run("wrong password is counted and recorded", func(t *testing.T) {
+		user := newLoginUser(t)
+
+		// Mock expectations
+		var events []*entities.SecurityEvent
+		mockSecurityEventRepo.EXPECT().CountByIPSince(gomock.Any(), entities.SecurityEventLoginFailed, client.IPAddress, gomock.Any()).Return(0, nil)
+		mockUserRepo.EXPECT().GetByEmail(gomock.Any(), user.Email).Return(user, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil)
+		mockUserRepo.EXPECT().UpdateLoginFailures(gomock.Any(), user).Return(nil)
+		mockSecurityEventRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, event *entities.SecurityEvent) error {
+				events = append(events, event)
+				return nil
+			})
+
+		// Execute
+		response, _, err := authUseCase.Login(context.Background(), entities.LoginRequest{Email: user.Email, Password: "wrong"}, client)
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, response)
+		assert.True(t, customerrors.IsUnauthorizedError(err))
+		assert.Equal(t, 1, user.FailedLoginAttempts)
+		assert.NotNil(t, user.LastFailedLoginAt)
+		require.Len(t, events, 1)
+		assert.Equal(t, entities.SecurityEventLoginFailed, events[0].Type)
+		assert.Equal(t, user.ID, *events[0].UserID)
+		assert.Equal(t, client.IPAddress, events[0].IPAddress)
+		assert.Equal(t, client.UserAgent, events[0].UserAgent)
+	})
+
+	t.Run("last allowed failure locks the account", func(t *testing.T) {
+		user := newLoginUser(t)
+		user.FailedLoginAttempts = cfg.Login.MaxAttempts - 1
+		failedAt := time.Now().Add(-time.Hour)
+		user.LastFailedLoginAt = &failedAt
+
+		// Mock expectations
+		var types []entities.SecurityEventType
+		mockSecurityEventRepo.EXPECT().CountByIPSince(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(0, nil)
+		mockUserRepo.EXPECT().GetByEmail(gomock.Any(), user.Email).Return(user, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil)
+		mockUserRepo.EXPECT().UpdateLoginFailures(gomock.Any(), user).Return(nil)
+		mockSecurityEventRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, event *entities.SecurityEvent) error {
+				types = append(types, event.Type)
+				return nil
+			}).Times(2)
+
+		// Execute
+		response, _, err := authUseCase.Login(context.Background(), entities.LoginRequest{Email: user.Email, Password: "wrong"}, client)
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, response)
+		assert.True(t, customerrors.IsTooManyRequestsError(err))
+		assert.True(t, user.IsLoginLocked(time.Now()))
+		assert.WithinDuration(t, time.Now().Add(cfg.Login.LockDuration), *user.LockedUntil, time.Second)
+		assert.Equal(t, []entities.SecurityEventType{entities.SecurityEventLoginFailed, entities.SecurityEventAccountLocked}, types)
+	})
+
+	t.Run("locked account is refused even with the right password", func(t *testing.T) {
+		user := newLoginUser(t)
+		lockedUntil := time.Now().Add(10 * time.Minute)
+		user.LockedUntil = &lockedUntil
+
+		// Mock expectations
+		mockSecurityEventRepo.EXPECT().CountByIPSince(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(0, nil)
+		mockUserRepo.EXPECT().GetByEmail(gomock.Any(), user.Email).Return(user, nil)
+		mockSecurityEventRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, event *entities.SecurityEvent) error {
+				assert.Equal(t, entities.SecurityEventLoginThrottled, event.Type)
+				return nil
+			})
+
+		// Execute
+		response, _, err := authUseCase.Login(context.Background(), entities.LoginRequest{Email: user.Email, Password: "password"}, client)
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, response)
+		assert.Equal(t, http.StatusTooManyRequests, customerrors.GetErrorCode(err))
+	})
+
+	t.Run("attempt within the delay is refused", func(t *testing.T) {
+		user := newLoginUser(t)
+		user.FailedLoginAttempts = cfg.Login.DelayAfter
+		failedAt := time.Now()
+		user.LastFailedLoginAt = &failedAt
+
+		// Mock expectations
+		mockSecurityEventRepo.EXPECT().CountByIPSince(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(0, nil)
+		mockUserRepo.EXPECT().GetByEmail(gomock.Any(), user.Email).Return(user, nil)
+		mockSecurityEventRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+
+		// Execute
+		response, _, err := authUseCase.Login(context.Background(), entities.LoginRequest{Email: user.Email, Password: "password"}, client)
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, response)
+		assert.True(t, customerrors.IsTooManyRequestsError(err))
+		assert.Equal(t, cfg.Login.DelayAfter, user.FailedLoginAttempts)
+	})
+
+	t.Run("successful login after the lock clears the failures", func(t *testing.T) {
+		user := newLoginUser(t)
+		user.FailedLoginAttempts = 2
+		failedAt := time.Now().Add(-time.Hour)
+		lockedUntil := time.Now().Add(-time.Minute)
+		user.LastFailedLoginAt = &failedAt
+		user.LockedUntil = &lockedUntil
+
+		// Mock expectations
+		mockSecurityEventRepo.EXPECT().CountByIPSince(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(0, nil)
+		mockUserRepo.EXPECT().GetByEmail(gomock.Any(), user.Email).Return(user, nil)
+		mockUserRepo.EXPECT().UpdateLoginFailures(gomock.Any(), user).Return(nil)
+		mockSecurityEventRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, event *entities.SecurityEvent) error {
+				assert.Equal(t, entities.SecurityEventLoginSucceeded, event.Type)
+				return nil
+			})
+		mockSessionRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+		mockRefreshTokenRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+
+		// Execute
+		response, _, err := authUseCase.Login(context.Background(), entities.LoginRequest{Email: user.Email, Password: "password"}, client)
+
+		// Assert
+		require.NoError(t, err)
+		require.NotNil(t, response)
+		assert.Zero(t, user.FailedLoginAttempts)
+		assert.Nil(t, user.LastFailedLoginAt)
+		assert.Nil(t, user.LockedUntil)
+	})
+
+	t.Run("client with too many failures is refused", func(t *testing.T) {
+		// Mock expectations
+		mockSecurityEventRepo.EXPECT().CountByIPSince(gomock.Any(), entities.SecurityEventLoginFailed, client.IPAddress, gomock.Any()).DoAndReturn(
+			func(_ context.Context, _ entities.SecurityEventType, _ string, since time.Time) (int, error) {
+				assert.WithinDuration(t, time.Now().Add(-cfg.Login.IPWindow), since, time.Second)
+				return cfg.Login.IPMaxAttempts, nil
+			})
+		mockSecurityEventRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, event *entities.SecurityEvent) error {
+				assert.Equal(t, entities.SecurityEventLoginThrottled, event.Type)
+				assert.Nil(t, event.UserID)
+				return nil
+			})
+
+		// Execute
+		response, _, err := authUseCase.Login(context.Background(), entities.LoginRequest{Email: "anyone@example.com", Password: "password"}, client)
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, response)
+		assert.True(t, customerrors.IsTooManyRequestsError(err))
+	})
+}
+
+func TestAdminUseCase_UnlockUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Mock repositories
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockRefreshTokenRepo := mocks.NewMockRefreshTokenRepository(ctrl)
+	mockMFARepo := mocks.NewMockMFARepository(ctrl)
+	mockSecurityEventRepo := mocks.NewMockSecurityEventRepository(ctrl)
+	mockTxManager := newPassThroughTxManager(ctrl)
+
+	// Create use case
+	adminUseCase := usecase.NewAdminUseCase(mockUserRepo, mockRefreshTokenRepo, mockMFARepo, mockSecurityEventRepo, mockTxManager)
+	adminID := uuid.New()
+
+	t.Run("unlock clears the lockout", func(t *testing.T) {
+		user := newLoginUser(t)
+		failedAt := time.Now()
+		lockedUntil := time.Now().Add(10 * time.Minute)
+		user.LastFailedLoginAt = &failedAt
+		user.LockedUntil = &lockedUntil
+
+		// Mock expectations
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil)
+		mockUserRepo.EXPECT().UpdateLoginFailures(gomock.Any(), user).Return(nil)
+		mockSecurityEventRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, event *entities.SecurityEvent) error {
+				assert.Equal(t, entities.SecurityEventAccountUnlocked, event.Type)
+				assert.Equal(t, user.ID, *event.UserID)
+				assert.Contains(t, event.Details, adminID.String())
+				return nil
+			})
+
+		// Execute
+		profile, err := adminUseCase.UnlockUser(context.Background(), adminID, user.ID)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Nil(t, profile.LockedUntil)
+		assert.False(t, user.IsLoginLocked(time.Now()))
+	})
+
+	t.Run("user without failures is left alone", func(t *testing.T) {
+		user := newLoginUser(t)
+
+		// Mock expectations
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil)
+
+		// Execute
+		profile, err := adminUseCase.UnlockUser(context.Background(), adminID, user.ID)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, profile.ID)
+	})
+
+	t.Run("user not found", func(t *testing.T) {
+		userID := uuid.New()
+
+		// Mock expectations
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), userID).Return(nil, customerrors.NewNotFoundError("User not found"))
+
+		// Execute
+		profile, err := adminUseCase.UnlockUser(context.Background(), adminID, userID)
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, profile)
+		assert.True(t, customerrors.IsNotFoundError(err))
+	})
+}
@@ -0,0 +1,500 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-transaction-service/internal/config"
+	"go-transaction-service/internal/domain/entities"
+	"go-transaction-service/internal/mocks"
+	"go-transaction-service/internal/usecase"
+	"go-transaction-service/pkg/errors"
+	"go-transaction-service/pkg/totp"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func newMFATestConfig() *config.Config {
+	return &config.Config{
+		JWT: config.JWTConfig{
+			ExpireDuration:        15 * time.Minute,
+			RefreshExpireDuration: 24 * time.Hour,
+		},
+		MFA: config.MFAConfig{
+			Issuer:            "Pintro",
+			ChallengeTTL:      5 * time.Minute,
+			MaxAttempts:       3,
+			RecoveryCodeCount: 4,
+		},
+		Login: config.LoginConfig{
+			MaxAttempts:   5,
+			LockDuration:  15 * time.Minute,
+			DelayAfter:    3,
+			BaseDelay:     time.Second,
+			MaxDelay:      30 * time.Second,
+			IPMaxAttempts: 20,
+			IPWindow:      15 * time.Minute,
+		},
+	}
+}
+
+// newMFAUser returns an active user with a password of "password" and MFA enabled
+func newMFAUser(t *testing.T) *entities.User {
+	t.Helper()
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	require.NoError(t, err)
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+
+	return &entities.User{
+		ID:         uuid.New(),
+		Email:      "test@example.com",
+		Password:   string(hashedPassword),
+		Status:     entities.UserStatusActive,
+		Role:       entities.RoleUser,
+		MFAEnabled: true,
+		MFASecret:  secret,
+	}
+}
+
+func currentCode(t *testing.T, secret string) string {
+	t.Helper()
+
+	code, err := totp.Code(secret, totp.Step(time.Now()))
+	require.NoError(t, err)
+	return code
+}
+
+func TestAuthUseCase_MFALogin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Mock repositories
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockRefreshTokenRepo := mocks.NewMockRefreshTokenRepository(ctrl)
+	mockRevokedTokenRepo := mocks.NewMockRevokedTokenRepository(ctrl)
+	mockMFARepo := mocks.NewMockMFARepository(ctrl)
+	mockSecurityEventRepo := mocks.NewMockSecurityEventRepository(ctrl)
+	mockSessionRepo := mocks.NewMockSessionRepository(ctrl)
+	mockTxManager := newPassThroughTxManager(ctrl)
+
+	cfg := newMFATestConfig()
+
+	// Create use case
+	authUseCase := usecase.NewAuthUseCase(mockUserRepo, mockRefreshTokenRepo, mockRevokedTokenRepo, mockMFARepo, mockSecurityEventRepo, mockSessionRepo, mockTxManager, newTestKeyring(t), cfg)
+
+	t.Run("login returns a challenge instead of tokens", func(t *testing.T) {
+		user := newMFAUser(t)
+
+		// Mock expectations
+		var stored *entities.MFAChallenge
+		mockUserRepo.EXPECT().GetByEmail(gomock.Any(), user.Email).Return(user, nil)
+		mockMFARepo.EXPECT().CreateChallenge(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, challenge *entities.MFAChallenge) error {
+				stored = challenge
+				return nil
+			})
+
+		// Execute
+		response, challenge, err := authUseCase.Login(context.Background(), entities.LoginRequest{Email: user.Email, Password: "password"}, entities.ClientInfo{})
+
+		// Assert
+		require.NoError(t, err)
+		assert.Nil(t, response)
+		require.NotNil(t, challenge)
+		assert.True(t, challenge.MFARequired)
+		assert.NotEmpty(t, challenge.ChallengeToken)
+
+		// Only the hash of the challenge token is stored
+		require.NotNil(t, stored)
+		assert.Equal(t, user.ID, stored.UserID)
+		assert.Equal(t, entities.HashMFAChallengeToken(challenge.ChallengeToken), stored.TokenHash)
+		assert.WithinDuration(t, time.Now().Add(cfg.MFA.ChallengeTTL), challenge.ExpiresAt, time.Second)
+	})
+
+	t.Run("valid TOTP code completes the login", func(t *testing.T) {
+		user := newMFAUser(t)
+		challenge, plain, err := entities.NewMFAChallenge(user.ID, time.Minute)
+		require.NoError(t, err)
+
+		// Mock expectations
+		mockMFARepo.EXPECT().GetChallengeByHashForUpdate(gomock.Any(), challenge.TokenHash).Return(challenge, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil)
+		mockUserRepo.EXPECT().UpdateMFA(gomock.Any(), user).Return(nil)
+		mockMFARepo.EXPECT().UpdateChallenge(gomock.Any(), challenge).Return(nil)
+		mockSecurityEventRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+		mockSessionRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+		mockRefreshTokenRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+
+		// Execute
+		response, err := authUseCase.VerifyMFA(context.Background(), entities.MFAVerifyRequest{
+			ChallengeToken: plain,
+			Code:           currentCode(t, user.MFASecret),
+		}, entities.ClientInfo{})
+
+		// Assert
+		require.NoError(t, err)
+		assert.NotEmpty(t, response.Token)
+		assert.NotEmpty(t, response.RefreshToken)
+		assert.NotNil(t, challenge.UsedAt)
+		assert.Equal(t, totp.Step(time.Now()), user.MFALastUsedStep)
+	})
+
+	t.Run("recovery code completes the login once", func(t *testing.T) {
+		user := newMFAUser(t)
+		challenge, plain, err := entities.NewMFAChallenge(user.ID, time.Minute)
+		require.NoError(t, err)
+		codes, plainCodes, err := entities.NewRecoveryCodes(user.ID, 2)
+		require.NoError(t, err)
+
+		// Mock expectations
+		mockMFARepo.EXPECT().GetChallengeByHashForUpdate(gomock.Any(), challenge.TokenHash).Return(challenge, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil)
+		mockMFARepo.EXPECT().GetUnusedRecoveryCodes(gomock.Any(), user.ID).Return(codes, nil)
+		mockMFARepo.EXPECT().MarkRecoveryCodeUsed(gomock.Any(), codes[1].ID).Return(nil)
+		mockMFARepo.EXPECT().UpdateChallenge(gomock.Any(), challenge).Return(nil)
+		mockSecurityEventRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+		mockSessionRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+		mockRefreshTokenRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+
+		// Execute, codes are accepted regardless of case
+		response, err := authUseCase.VerifyMFA(context.Background(), entities.MFAVerifyRequest{
+			ChallengeToken: plain,
+			Code:           " " + plainCodes[1] + " ",
+		}, entities.ClientInfo{})
+
+		// Assert
+		require.NoError(t, err)
+		assert.NotEmpty(t, response.Token)
+	})
+
+	t.Run("failed logins are only cleared once the second factor is verified", func(t *testing.T) {
+		user := newMFAUser(t)
+		failedAt := time.Now().Add(-time.Minute)
+		user.FailedLoginAttempts = 2
+		user.LastFailedLoginAt = &failedAt
+
+		// The password alone leaves the counter untouched
+		mockUserRepo.EXPECT().GetByEmail(gomock.Any(), user.Email).Return(user, nil)
+		var challenge *entities.MFAChallenge
+		mockMFARepo.EXPECT().CreateChallenge(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, stored *entities.MFAChallenge) error {
+				challenge = stored
+				return nil
+			})
+
+		_, mfaChallenge, err := authUseCase.Login(context.Background(), entities.LoginRequest{Email: user.Email, Password: "password"}, entities.ClientInfo{})
+		require.NoError(t, err)
+		require.NotNil(t, mfaChallenge)
+		assert.Equal(t, 2, user.FailedLoginAttempts)
+
+		// A valid code completes the login and clears it
+		mockMFARepo.EXPECT().GetChallengeByHashForUpdate(gomock.Any(), challenge.TokenHash).Return(challenge, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil)
+		mockUserRepo.EXPECT().UpdateMFA(gomock.Any(), user).Return(nil)
+		mockMFARepo.EXPECT().UpdateChallenge(gomock.Any(), challenge).Return(nil)
+		mockUserRepo.EXPECT().UpdateLoginFailures(gomock.Any(), user).Return(nil)
+		mockSecurityEventRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, event *entities.SecurityEvent) error {
+				assert.Equal(t, entities.SecurityEventLoginSucceeded, event.Type)
+				return nil
+			})
+		mockSessionRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+		mockRefreshTokenRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+
+		_, err = authUseCase.VerifyMFA(context.Background(), entities.MFAVerifyRequest{
+			ChallengeToken: mfaChallenge.ChallengeToken,
+			Code:           currentCode(t, user.MFASecret),
+		}, entities.ClientInfo{})
+		require.NoError(t, err)
+		assert.Equal(t, 0, user.FailedLoginAttempts)
+	})
+
+	t.Run("wrong code is counted and rejected", func(t *testing.T) {
+		user := newMFAUser(t)
+		challenge, plain, err := entities.NewMFAChallenge(user.ID, time.Minute)
+		require.NoError(t, err)
+
+		// Mock expectations
+		mockMFARepo.EXPECT().GetChallengeByHashForUpdate(gomock.Any(), challenge.TokenHash).Return(challenge, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil)
+		mockMFARepo.EXPECT().UpdateChallenge(gomock.Any(), challenge).Return(nil)
+		mockUserRepo.EXPECT().UpdateLoginFailures(gomock.Any(), user).Return(nil)
+		mockSecurityEventRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, event *entities.SecurityEvent) error {
+				assert.Equal(t, entities.SecurityEventLoginFailed, event.Type)
+				assert.Equal(t, "wrong verification code", event.Details)
+				return nil
+			})
+
+		// Execute
+		response, err := authUseCase.VerifyMFA(context.Background(), entities.MFAVerifyRequest{ChallengeToken: plain, Code: "000000"}, entities.ClientInfo{})
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, response)
+		assert.True(t, customerrors.IsUnauthorizedError(err))
+		assert.Equal(t, 1, challenge.Attempts)
+		assert.Nil(t, challenge.UsedAt)
+		assert.Equal(t, 1, user.FailedLoginAttempts)
+	})
+
+	t.Run("wrong codes lock the account like wrong passwords", func(t *testing.T) {
+		user := newMFAUser(t)
+		user.FailedLoginAttempts = cfg.Login.MaxAttempts - 1
+		challenge, plain, err := entities.NewMFAChallenge(user.ID, time.Minute)
+		require.NoError(t, err)
+
+		// Mock expectations
+		var events []entities.SecurityEventType
+		mockMFARepo.EXPECT().GetChallengeByHashForUpdate(gomock.Any(), challenge.TokenHash).Return(challenge, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil)
+		mockMFARepo.EXPECT().UpdateChallenge(gomock.Any(), challenge).Return(nil)
+		mockUserRepo.EXPECT().UpdateLoginFailures(gomock.Any(), user).Return(nil)
+		mockSecurityEventRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, event *entities.SecurityEvent) error {
+				events = append(events, event.Type)
+				return nil
+			}).Times(2)
+
+		// Execute
+		response, err := authUseCase.VerifyMFA(context.Background(), entities.MFAVerifyRequest{ChallengeToken: plain, Code: "000000"}, entities.ClientInfo{})
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, response)
+		assert.True(t, customerrors.IsTooManyRequestsError(err))
+		assert.True(t, user.IsLoginLocked(time.Now()))
+		assert.Equal(t, []entities.SecurityEventType{entities.SecurityEventLoginFailed, entities.SecurityEventAccountLocked}, events)
+	})
+
+	t.Run("locked account cannot verify a code", func(t *testing.T) {
+		user := newMFAUser(t)
+		lockedUntil := time.Now().Add(10 * time.Minute)
+		user.LockedUntil = &lockedUntil
+		challenge, plain, err := entities.NewMFAChallenge(user.ID, time.Minute)
+		require.NoError(t, err)
+
+		// Mock expectations
+		mockMFARepo.EXPECT().GetChallengeByHashForUpdate(gomock.Any(), challenge.TokenHash).Return(challenge, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil)
+		mockSecurityEventRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, event *entities.SecurityEvent) error {
+				assert.Equal(t, entities.SecurityEventLoginThrottled, event.Type)
+				return nil
+			})
+
+		// Execute, even a valid code is refused
+		response, err := authUseCase.VerifyMFA(context.Background(), entities.MFAVerifyRequest{
+			ChallengeToken: plain,
+			Code:           currentCode(t, user.MFASecret),
+		}, entities.ClientInfo{})
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, response)
+		assert.True(t, customerrors.IsTooManyRequestsError(err))
+		assert.Nil(t, challenge.UsedAt)
+	})
+
+	t.Run("client with too many failures cannot verify a code", func(t *testing.T) {
+		user := newMFAUser(t)
+		challenge, plain, err := entities.NewMFAChallenge(user.ID, time.Minute)
+		require.NoError(t, err)
+		client := entities.ClientInfo{IPAddress: "203.0.113.7"}
+
+		// Mock expectations
+		mockMFARepo.EXPECT().GetChallengeByHashForUpdate(gomock.Any(), challenge.TokenHash).Return(challenge, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil)
+		mockSecurityEventRepo.EXPECT().CountByIPSince(gomock.Any(), entities.SecurityEventLoginFailed, client.IPAddress, gomock.Any()).Return(cfg.Login.IPMaxAttempts, nil)
+		mockSecurityEventRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+
+		// Execute
+		response, err := authUseCase.VerifyMFA(context.Background(), entities.MFAVerifyRequest{
+			ChallengeToken: plain,
+			Code:           currentCode(t, user.MFASecret),
+		}, client)
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, response)
+		assert.True(t, customerrors.IsTooManyRequestsError(err))
+	})
+
+	t.Run("challenge out of attempts is rejected", func(t *testing.T) {
+		user := newMFAUser(t)
+		challenge, plain, err := entities.NewMFAChallenge(user.ID, time.Minute)
+		require.NoError(t, err)
+		challenge.Attempts = cfg.MFA.MaxAttempts
+
+		// Mock expectations
+		mockMFARepo.EXPECT().GetChallengeByHashForUpdate(gomock.Any(), challenge.TokenHash).Return(challenge, nil)
+
+		// Execute
+		response, err := authUseCase.VerifyMFA(context.Background(), entities.MFAVerifyRequest{
+			ChallengeToken: plain,
+			Code:           currentCode(t, user.MFASecret),
+		}, entities.ClientInfo{})
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, response)
+		assert.True(t, customerrors.IsUnauthorizedError(err))
+	})
+
+	t.Run("expired challenge is rejected", func(t *testing.T) {
+		user := newMFAUser(t)
+		challenge, plain, err := entities.NewMFAChallenge(user.ID, -time.Second)
+		require.NoError(t, err)
+
+		// Mock expectations
+		mockMFARepo.EXPECT().GetChallengeByHashForUpdate(gomock.Any(), challenge.TokenHash).Return(challenge, nil)
+
+		// Execute
+		response, err := authUseCase.VerifyMFA(context.Background(), entities.MFAVerifyRequest{
+			ChallengeToken: plain,
+			Code:           currentCode(t, user.MFASecret),
+		}, entities.ClientInfo{})
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, response)
+		assert.True(t, customerrors.IsUnauthorizedError(err))
+	})
+}
+
+func TestMFAUseCase(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Mock repositories
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockMFARepo := mocks.NewMockMFARepository(ctrl)
+	mockTxManager := newPassThroughTxManager(ctrl)
+
+	cfg := newMFATestConfig()
+
+	// Create use case
+	mfaUseCase := usecase.NewMFAUseCase(mockUserRepo, mockMFARepo, mockTxManager, cfg)
+
+	t.Run("enrollment is enabled once a code is confirmed", func(t *testing.T) {
+		user := newMFAUser(t)
+		user.MFAEnabled = false
+		user.MFASecret = ""
+
+		// Begin: a pending secret is stored
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil)
+		mockUserRepo.EXPECT().UpdateMFA(gomock.Any(), user).Return(nil)
+
+		enrollment, err := mfaUseCase.BeginEnrollment(context.Background(), user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, enrollment.Secret, user.MFASecret)
+		assert.False(t, user.MFAEnabled)
+		assert.Contains(t, enrollment.OTPAuthURI, "secret="+enrollment.Secret)
+
+		// Confirm with a wrong code
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil)
+
+		_, err = mfaUseCase.ConfirmEnrollment(context.Background(), user.ID, entities.MFACodeRequest{Code: "000000"})
+		require.Error(t, err)
+		assert.True(t, customerrors.IsValidationError(err))
+		assert.False(t, user.MFAEnabled)
+
+		// Confirm with the current code
+		var storedCodes []*entities.RecoveryCode
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil)
+		mockUserRepo.EXPECT().UpdateMFA(gomock.Any(), user).Return(nil)
+		mockMFARepo.EXPECT().ReplaceRecoveryCodes(gomock.Any(), user.ID, gomock.Any()).DoAndReturn(
+			func(_ context.Context, _ uuid.UUID, codes []*entities.RecoveryCode) error {
+				storedCodes = codes
+				return nil
+			})
+
+		response, err := mfaUseCase.ConfirmEnrollment(context.Background(), user.ID, entities.MFACodeRequest{Code: currentCode(t, user.MFASecret)})
+		require.NoError(t, err)
+		assert.True(t, user.MFAEnabled)
+		require.Len(t, response.RecoveryCodes, cfg.MFA.RecoveryCodeCount)
+		require.Len(t, storedCodes, cfg.MFA.RecoveryCodeCount)
+
+		// Only hashes are stored
+		for i, code := range response.RecoveryCodes {
+			assert.NotEqual(t, code, storedCodes[i].CodeHash)
+			assert.True(t, storedCodes[i].Matches(code))
+		}
+	})
+
+	t.Run("enrollment when already enabled", func(t *testing.T) {
+		user := newMFAUser(t)
+
+		// Mock expectations
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil)
+
+		// Execute
+		response, err := mfaUseCase.BeginEnrollment(context.Background(), user.ID)
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, response)
+		assert.True(t, customerrors.IsConflictError(err))
+	})
+
+	t.Run("recovery codes are not accepted to regenerate codes", func(t *testing.T) {
+		user := newMFAUser(t)
+		_, plainCodes, err := entities.NewRecoveryCodes(user.ID, 1)
+		require.NoError(t, err)
+
+		// Mock expectations
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil)
+
+		// Execute
+		response, err := mfaUseCase.RegenerateRecoveryCodes(context.Background(), user.ID, entities.MFACodeRequest{Code: plainCodes[0]})
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, response)
+		assert.True(t, customerrors.IsValidationError(err))
+	})
+
+	t.Run("disable requires the password", func(t *testing.T) {
+		user := newMFAUser(t)
+
+		// Mock expectations
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil)
+
+		// Execute
+		err := mfaUseCase.Disable(context.Background(), user.ID, entities.MFADisableRequest{
+			Password: "wrongpassword",
+			Code:     currentCode(t, user.MFASecret),
+		})
+
+		// Assert
+		require.Error(t, err)
+		assert.True(t, customerrors.IsValidationError(err))
+		assert.True(t, user.MFAEnabled)
+	})
+
+	t.Run("disable clears the secret and recovery codes", func(t *testing.T) {
+		user := newMFAUser(t)
+
+		// Mock expectations
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil)
+		mockUserRepo.EXPECT().UpdateMFA(gomock.Any(), user).Return(nil).Times(2)
+		mockMFARepo.EXPECT().DeleteRecoveryCodes(gomock.Any(), user.ID).Return(nil)
+
+		// Execute
+		err := mfaUseCase.Disable(context.Background(), user.ID, entities.MFADisableRequest{
+			Password: "password",
+			Code:     currentCode(t, user.MFASecret),
+		})
+
+		// Assert
+		require.NoError(t, err)
+		assert.False(t, user.MFAEnabled)
+		assert.Empty(t, user.MFASecret)
+	})
+}
@@ -0,0 +1,209 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-transaction-service/internal/config"
+	"go-transaction-service/internal/infrastructure/external"
+	"go-transaction-service/internal/usecase"
+	"go.uber.org/zap"
+)
+
+// newMidtransStandIn starts a local HTTP server answering the Core API status endpoint
+func newMidtransStandIn(t *testing.T, body string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if !ok || username != testServerKey || password != "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		if r.Method != http.MethodGet || r.URL.Path != "/v2/TXN-12345678/status" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestMidtransConfig(apiURL string) *config.Config {
+	return &config.Config{
+		Midtrans: config.MidtransConfig{
+			ServerKey:   testServerKey,
+			Environment: "sandbox",
+			APIURL:      apiURL,
+		},
+	}
+}
+
+func TestMidtransPaymentGateway_GetTransactionStatus(t *testing.T) {
+	t.Run("settled transaction", func(t *testing.T) {
+		server := newMidtransStandIn(t, `{
+			"status_code": "200",
+			"status_message": "Success, transaction is found",
+			"transaction_id": "9aed5972-5b6a-401e-894b-a32c91ed1a3a",
+			"order_id": "TXN-12345678",
+			"gross_amount": "100000.00",
+			"transaction_status": "settlement",
+			"payment_type": "credit_card",
+			"fraud_status": "accept"
+		}`)
+		gateway := external.NewMidtransPaymentGateway(newTestMidtransConfig(server.URL), zap.NewNop())
+
+		// Execute
+		resp, err := gateway.GetTransactionStatus(context.Background(), "TXN-12345678")
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, "TXN-12345678", resp.OrderID)
+		assert.Equal(t, "settlement", resp.Status)
+		assert.Equal(t, "9aed5972-5b6a-401e-894b-a32c91ed1a3a", resp.GatewayID)
+		assert.Equal(t, "100000.00", resp.GrossAmount)
+		assert.Equal(t, "credit_card", resp.PaymentType)
+		assert.Equal(t, "accept", resp.FraudStatus)
+	})
+
+	t.Run("unknown order", func(t *testing.T) {
+		server := newMidtransStandIn(t, `{
+			"status_code": "404",
+			"status_message": "Transaction doesn't exist."
+		}`)
+		gateway := external.NewMidtransPaymentGateway(newTestMidtransConfig(server.URL), zap.NewNop())
+
+		// Execute
+		resp, err := gateway.GetTransactionStatus(context.Background(), "TXN-12345678")
+
+		// Assert
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, usecase.ErrPaymentNotFound))
+		assert.Nil(t, resp)
+	})
+
+	t.Run("gateway unavailable", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+		gateway := external.NewMidtransPaymentGateway(newTestMidtransConfig(server.URL), zap.NewNop())
+
+		// Execute
+		resp, err := gateway.GetTransactionStatus(context.Background(), "TXN-12345678")
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, resp)
+	})
+}
+
+func TestMidtransPaymentGateway_Refund(t *testing.T) {
+	t.Run("partial refund", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			username, _, ok := r.BasicAuth()
+			if !ok || username != testServerKey || r.Method != http.MethodPost || r.URL.Path != "/v2/TXN-12345678/refund" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+
+			var body map[string]interface{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Equal(t, "TXN-87654321", body["refund_key"])
+			assert.Equal(t, float64(25000), body["amount"])
+			assert.Equal(t, "Settled twice", body["reason"])
+
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{
+				"status_code": "200",
+				"status_message": "Success, refund request is approved",
+				"transaction_id": "9aed5972-5b6a-401e-894b-a32c91ed1a3a",
+				"order_id": "TXN-12345678",
+				"gross_amount": "100000.00",
+				"transaction_status": "partial_refund",
+				"refund_amount": "25000.00",
+				"refund_key": "TXN-87654321"
+			}`))
+		}))
+		defer server.Close()
+		gateway := external.NewMidtransPaymentGateway(newTestMidtransConfig(server.URL), zap.NewNop())
+
+		// Execute
+		resp, err := gateway.Refund(context.Background(), "TXN-12345678", "TXN-87654321", "25000", "Settled twice")
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, "TXN-12345678", resp.OrderID)
+		assert.Equal(t, "partial_refund", resp.Status)
+		assert.Equal(t, "9aed5972-5b6a-401e-894b-a32c91ed1a3a", resp.GatewayID)
+	})
+
+	t.Run("refund rejected by the gateway", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{
+				"status_code": "412",
+				"status_message": "Merchant cannot modify the status of the transaction"
+			}`))
+		}))
+		defer server.Close()
+		gateway := external.NewMidtransPaymentGateway(newTestMidtransConfig(server.URL), zap.NewNop())
+
+		// Execute
+		resp, err := gateway.Refund(context.Background(), "TXN-12345678", "TXN-87654321", "25000", "Settled twice")
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, resp)
+	})
+}
+
+func TestMockPaymentGateway_GetTransactionStatus(t *testing.T) {
+	gateway := external.NewMockPaymentGateway(zap.NewNop())
+
+	t.Run("topup is pending until it expires", func(t *testing.T) {
+		expiresAt := time.Now().Add(time.Hour)
+		_, err := gateway.CreateTopupTransaction(context.Background(), uuid.New(), "100000", "credit_card", "TXN-OPEN", &expiresAt)
+		require.NoError(t, err)
+
+		// Execute
+		resp, err := gateway.GetTransactionStatus(context.Background(), "TXN-OPEN")
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, "pending", resp.Status)
+	})
+
+	t.Run("overdue topup is expired", func(t *testing.T) {
+		expiresAt := time.Now().Add(-time.Minute)
+		_, err := gateway.CreateTopupTransaction(context.Background(), uuid.New(), "100000", "credit_card", "TXN-OVERDUE", &expiresAt)
+		require.NoError(t, err)
+
+		// Execute
+		resp, err := gateway.GetTransactionStatus(context.Background(), "TXN-OVERDUE")
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, "expire", resp.Status)
+	})
+
+	t.Run("unknown order", func(t *testing.T) {
+		// Execute
+		resp, err := gateway.GetTransactionStatus(context.Background(), "TXN-NEVER-CREATED")
+
+		// Assert
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, usecase.ErrPaymentNotFound))
+		assert.Nil(t, resp)
+	})
+}
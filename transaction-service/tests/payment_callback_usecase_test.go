@@ -0,0 +1,233 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-transaction-service/internal/domain/entities"
+	"go-transaction-service/internal/mocks"
+	"go-transaction-service/internal/usecase"
+	"go-transaction-service/pkg/errors"
+)
+
+//go:generate mockgen -source=../internal/domain/repositories/payment_callback_repository.go -destination=../internal/mocks/payment_callback_repository_mock.go
+//go:generate mockgen -source=../internal/usecase/transaction_usecase.go -destination=../internal/mocks/transaction_usecase_mock.go
+
+const testServerKey = "SB-Mid-server-test"
+
+func signedCallbackPayload(t *testing.T, orderID, status, grossAmount, serverKey string) []byte {
+	payload, err := json.Marshal(map[string]string{
+		"order_id":           orderID,
+		"transaction_id":     "gateway-" + orderID,
+		"transaction_status": status,
+		"status_code":        "200",
+		"payment_type":       "credit_card",
+		"gross_amount":       grossAmount,
+		"signature_key":      entities.MidtransSignature(orderID, "200", grossAmount, serverKey),
+	})
+	require.NoError(t, err)
+	return payload
+}
+
+// withFraudStatus adds the fraud detection result of a card capture to a signed payload; it is
+// not part of the signature
+func withFraudStatus(t *testing.T, payload []byte, fraudStatus string) []byte {
+	var fields map[string]string
+	require.NoError(t, json.Unmarshal(payload, &fields))
+	fields["fraud_status"] = fraudStatus
+	payload, err := json.Marshal(fields)
+	require.NoError(t, err)
+	return payload
+}
+
+func TestPaymentCallbackUseCase_HandleCallback(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Mock dependencies
+	mockCallbackRepo := mocks.NewMockPaymentCallbackRepository(ctrl)
+	mockTransactionRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockTransactionUseCase := mocks.NewMockTransactionUseCase(ctrl)
+
+	// Create use case
+	callbackUseCase := usecase.NewPaymentCallbackUseCase(mockCallbackRepo, mockTransactionRepo, mockTransactionUseCase, testServerKey)
+
+	newPendingTopup := func(reference string) *entities.Transaction {
+		transaction := entities.NewTransaction(uuid.New(), entities.TransactionTypeTopup, decimal.NewFromInt(100000), "Topup")
+		transaction.Reference = reference
+		return transaction
+	}
+
+	t.Run("valid settlement is applied and audited", func(t *testing.T) {
+		transaction := newPendingTopup("TXN-VALID")
+		payload := signedCallbackPayload(t, "TXN-VALID", "settlement", "100000.00", testServerKey)
+
+		// Mock expectations
+		mockTransactionRepo.EXPECT().GetByReference(gomock.Any(), "TXN-VALID").Return(transaction, nil)
+		mockTransactionUseCase.EXPECT().ProcessCallback(gomock.Any(), "TXN-VALID", entities.TransactionStatusCompleted).Return(nil)
+		mockCallbackRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(ctx context.Context, callback *entities.PaymentCallback) error {
+				assert.True(t, callback.Verified)
+				assert.Equal(t, transaction.ID, *callback.TransactionID)
+				assert.NotNil(t, callback.ProcessedAt)
+				assert.Empty(t, callback.RejectionReason)
+				assert.JSONEq(t, string(payload), string(callback.RawPayload))
+				return nil
+			})
+
+		// Execute
+		callback, err := callbackUseCase.HandleCallback(context.Background(), payload)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, "settlement", callback.GatewayStatus)
+	})
+
+	t.Run("invalid signature is rejected and audited", func(t *testing.T) {
+		payload := signedCallbackPayload(t, "TXN-FORGED", "settlement", "100000.00", "wrong-key")
+
+		// Mock expectations
+		mockCallbackRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(ctx context.Context, callback *entities.PaymentCallback) error {
+				assert.False(t, callback.Verified)
+				assert.Nil(t, callback.ProcessedAt)
+				assert.Equal(t, "Invalid callback signature", callback.RejectionReason)
+				return nil
+			})
+
+		// Execute
+		_, err := callbackUseCase.HandleCallback(context.Background(), payload)
+
+		// Assert
+		require.Error(t, err)
+		assert.Equal(t, http.StatusForbidden, customerrors.GetErrorCode(err))
+	})
+
+	t.Run("gross amount mismatch", func(t *testing.T) {
+		transaction := newPendingTopup("TXN-AMOUNT")
+		payload := signedCallbackPayload(t, "TXN-AMOUNT", "settlement", "1000000.00", testServerKey)
+
+		// Mock expectations
+		mockTransactionRepo.EXPECT().GetByReference(gomock.Any(), "TXN-AMOUNT").Return(transaction, nil)
+		mockCallbackRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(ctx context.Context, callback *entities.PaymentCallback) error {
+				assert.True(t, callback.Verified)
+				assert.Equal(t, "Gross amount does not match transaction amount", callback.RejectionReason)
+				return nil
+			})
+
+		// Execute
+		_, err := callbackUseCase.HandleCallback(context.Background(), payload)
+
+		// Assert
+		require.Error(t, err)
+		assert.True(t, customerrors.IsValidationError(err))
+	})
+
+	t.Run("payment with another method is rejected", func(t *testing.T) {
+		transaction := newPendingTopup("TXN-METHOD")
+		transaction.Metadata = map[string]string{entities.MetadataPaymentMethod: "va_bca"}
+		payload := signedCallbackPayload(t, "TXN-METHOD", "settlement", "100000.00", testServerKey)
+
+		// Mock expectations
+		mockTransactionRepo.EXPECT().GetByReference(gomock.Any(), "TXN-METHOD").Return(transaction, nil)
+		mockCallbackRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(ctx context.Context, callback *entities.PaymentCallback) error {
+				assert.Equal(t, "Payment type does not match the payment method of the transaction", callback.RejectionReason)
+				return nil
+			})
+
+		// Execute
+		_, err := callbackUseCase.HandleCallback(context.Background(), payload)
+
+		// Assert
+		require.Error(t, err)
+		assert.True(t, customerrors.IsValidationError(err))
+	})
+
+	captures := []struct {
+		fraudStatus string
+		status      entities.TransactionStatus // Applied status, empty when nothing is applied yet
+	}{
+		{fraudStatus: "accept", status: entities.TransactionStatusCompleted},
+		{fraudStatus: "challenge"},
+		{fraudStatus: "deny", status: entities.TransactionStatusFailed},
+		{fraudStatus: ""},
+	}
+	for _, capture := range captures {
+		t.Run("card capture with fraud status "+capture.fraudStatus, func(t *testing.T) {
+			transaction := newPendingTopup("TXN-CAPTURE")
+			payload := withFraudStatus(t, signedCallbackPayload(t, "TXN-CAPTURE", "capture", "100000.00", testServerKey), capture.fraudStatus)
+
+			// Mock expectations: a capture fraud detection did not accept is never credited
+			mockTransactionRepo.EXPECT().GetByReference(gomock.Any(), "TXN-CAPTURE").Return(transaction, nil)
+			if capture.status != "" {
+				mockTransactionUseCase.EXPECT().ProcessCallback(gomock.Any(), "TXN-CAPTURE", capture.status).Return(nil)
+			}
+			mockCallbackRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+
+			// Execute
+			callback, err := callbackUseCase.HandleCallback(context.Background(), payload)
+
+			// Assert
+			require.NoError(t, err)
+			assert.NotNil(t, callback.ProcessedAt)
+		})
+	}
+
+	t.Run("duplicate notification is acknowledged", func(t *testing.T) {
+		transaction := newPendingTopup("TXN-DUPLICATE")
+		transaction.MarkAsCompleted()
+		payload := signedCallbackPayload(t, "TXN-DUPLICATE", "settlement", "100000.00", testServerKey)
+
+		// Mock expectations: ProcessCallback must not be called again
+		mockTransactionRepo.EXPECT().GetByReference(gomock.Any(), "TXN-DUPLICATE").Return(transaction, nil)
+		mockCallbackRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+
+		// Execute
+		_, err := callbackUseCase.HandleCallback(context.Background(), payload)
+
+		// Assert
+		assert.NoError(t, err)
+	})
+
+	t.Run("settlement of a topup held for review is acknowledged", func(t *testing.T) {
+		transaction := newPendingTopup("TXN-HELD")
+		transaction.MarkForReview()
+		payload := signedCallbackPayload(t, "TXN-HELD", "settlement", "100000.00", testServerKey)
+
+		// Mock expectations: ProcessCallback must not be called again
+		mockTransactionRepo.EXPECT().GetByReference(gomock.Any(), "TXN-HELD").Return(transaction, nil)
+		mockCallbackRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+
+		// Execute
+		_, err := callbackUseCase.HandleCallback(context.Background(), payload)
+
+		// Assert
+		assert.NoError(t, err)
+	})
+
+	t.Run("malformed payload is kept for audit", func(t *testing.T) {
+		// Mock expectations
+		mockCallbackRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(ctx context.Context, callback *entities.PaymentCallback) error {
+				assert.True(t, json.Valid(callback.RawPayload))
+				assert.Equal(t, "Invalid payload format", callback.RejectionReason)
+				return nil
+			})
+
+		// Execute
+		_, err := callbackUseCase.HandleCallback(context.Background(), []byte("not json"))
+
+		// Assert
+		require.Error(t, err)
+		assert.True(t, customerrors.IsValidationError(err))
+	})
+}
@@ -0,0 +1,241 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-transaction-service/internal/domain/entities"
+	"go-transaction-service/internal/mocks"
+	"go-transaction-service/internal/usecase"
+	"go-transaction-service/pkg/errors"
+)
+
+func TestTransactionUseCase_TransactionPIN(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Mock repositories
+	mockTransactionRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockLedgerRepo := mocks.NewMockLedgerRepository(ctrl)
+	mockTxManager := newPassThroughTxManager(ctrl)
+	mockPaymentGateway := mocks.NewMockPaymentGateway(ctrl)
+
+	// Create use case
+	cfg := newTransactionTestConfig()
+	transactionUseCase := usecase.NewTransactionUseCase(mockTransactionRepo, mockUserRepo, mockLedgerRepo, mockTxManager, mockPaymentGateway, newDefaultFeeUseCase(ctrl, cfg), newDefaultLimitUseCase(ctrl, mockTransactionRepo, mockUserRepo, cfg), cfg)
+
+	newUsers := func() (*entities.User, *entities.User) {
+		sender := &entities.User{ID: uuid.New(), Balance: decimal.NewFromInt(500), Status: entities.UserStatusActive, PINHash: testPINHash}
+		recipient := &entities.User{ID: uuid.New(), Status: entities.UserStatusActive}
+		return sender, recipient
+	}
+
+	t.Run("wrong PIN is counted and no money moves", func(t *testing.T) {
+		sender, recipient := newUsers()
+		req := entities.PaymentRequest{Amount: decimal.NewFromInt(100), ToUserID: recipient.ID, PIN: "000000", Description: "Test payment"}
+
+		// Mock expectations: the failure is stored, nothing else is written
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), sender.ID).Return(sender, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), recipient.ID).Return(recipient, nil)
+		mockUserRepo.EXPECT().UpdatePIN(gomock.Any(), sender).Return(nil)
+
+		// Execute
+		response, err := transactionUseCase.ProcessPayment(context.Background(), sender.ID, req)
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, response)
+		assert.True(t, customerrors.IsValidationError(err))
+		assert.Contains(t, err.Error(), "2 attempts left")
+		assert.Equal(t, 1, sender.PINFailedAttempts)
+	})
+
+	t.Run("PIN locks after the last attempt", func(t *testing.T) {
+		sender, recipient := newUsers()
+		sender.PINFailedAttempts = 2
+		req := entities.PaymentRequest{Amount: decimal.NewFromInt(100), ToUserID: recipient.ID, PIN: "000000", Description: "Test payment"}
+
+		// Mock expectations
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), sender.ID).Return(sender, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), recipient.ID).Return(recipient, nil)
+		mockUserRepo.EXPECT().UpdatePIN(gomock.Any(), sender).Return(nil)
+
+		// Execute
+		response, err := transactionUseCase.ProcessPayment(context.Background(), sender.ID, req)
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, response)
+		assert.Equal(t, http.StatusForbidden, customerrors.GetErrorCode(err))
+		assert.True(t, sender.IsPINLocked(time.Now()))
+	})
+
+	t.Run("locked PIN refuses the correct PIN", func(t *testing.T) {
+		sender, recipient := newUsers()
+		lockedUntil := time.Now().Add(10 * time.Minute)
+		sender.PINLockedUntil = &lockedUntil
+		req := entities.TransferRequest{Amount: decimal.NewFromInt(100), ToEmail: "recipient@example.com", PIN: testPIN, Description: "Transfer to friend"}
+
+		// Mock expectations
+		mockUserRepo.EXPECT().GetByEmail(gomock.Any(), req.ToEmail).Return(recipient, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), sender.ID).Return(sender, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), recipient.ID).Return(recipient, nil)
+
+		// Execute
+		receipt, err := transactionUseCase.TransferFunds(context.Background(), sender.ID, req)
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, receipt)
+		assert.Equal(t, http.StatusForbidden, customerrors.GetErrorCode(err))
+	})
+
+	t.Run("correct PIN clears earlier failures", func(t *testing.T) {
+		sender, recipient := newUsers()
+		sender.PINFailedAttempts = 2
+		amount := decimal.NewFromInt(100)
+		req := entities.PaymentRequest{Amount: amount, ToUserID: recipient.ID, PIN: testPIN, Description: "Test payment"}
+
+		// Mock expectations
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), sender.ID).Return(sender, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), recipient.ID).Return(recipient, nil)
+		mockUserRepo.EXPECT().UpdatePIN(gomock.Any(), sender).Return(nil)
+		mockTransactionRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+		mockUserRepo.EXPECT().SubtractBalance(gomock.Any(), sender.ID, amount).Return(nil)
+		mockUserRepo.EXPECT().AddBalance(gomock.Any(), recipient.ID, amount).Return(nil)
+		mockLedgerRepo.EXPECT().CreateEntry(gomock.Any(), gomock.Any()).Return(nil)
+		mockTransactionRepo.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil)
+
+		// Execute
+		response, err := transactionUseCase.ProcessPayment(context.Background(), sender.ID, req)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, entities.TransactionStatusCompleted, response.Status)
+		assert.Equal(t, 0, sender.PINFailedAttempts)
+	})
+
+	t.Run("no PIN set", func(t *testing.T) {
+		sender, recipient := newUsers()
+		sender.PINHash = ""
+		req := entities.PaymentRequest{Amount: decimal.NewFromInt(100), ToUserID: recipient.ID, PIN: testPIN, Description: "Test payment"}
+
+		// Mock expectations
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), sender.ID).Return(sender, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), recipient.ID).Return(recipient, nil)
+
+		// Execute
+		response, err := transactionUseCase.ProcessPayment(context.Background(), sender.ID, req)
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, response)
+		assert.True(t, customerrors.IsValidationError(err))
+	})
+}
+
+func TestPINUseCase(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Mock repositories
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockMFARepo := mocks.NewMockMFARepository(ctrl)
+	mockTxManager := newPassThroughTxManager(ctrl)
+
+	cfg := newTransactionTestConfig()
+
+	// Create use case
+	pinUseCase := usecase.NewPINUseCase(mockUserRepo, mockMFARepo, mockTxManager, cfg)
+
+	t.Run("set PIN", func(t *testing.T) {
+		user := newMFAUser(t)
+
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil)
+		mockUserRepo.EXPECT().UpdatePIN(gomock.Any(), user).Return(nil)
+
+		err := pinUseCase.SetPIN(context.Background(), user.ID, entities.SetPINRequest{PIN: testPIN, Password: "password"})
+
+		require.NoError(t, err)
+		assert.True(t, user.CheckPIN(testPIN))
+	})
+
+	t.Run("set PIN twice", func(t *testing.T) {
+		user := newMFAUser(t)
+		user.PINHash = testPINHash
+
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil)
+
+		err := pinUseCase.SetPIN(context.Background(), user.ID, entities.SetPINRequest{PIN: "654321", Password: "password"})
+
+		require.Error(t, err)
+		assert.True(t, customerrors.IsConflictError(err))
+	})
+
+	t.Run("change PIN with wrong current PIN", func(t *testing.T) {
+		user := newMFAUser(t)
+		user.PINHash = testPINHash
+
+		// The failed attempt is stored although an error is returned
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil)
+		mockUserRepo.EXPECT().UpdatePIN(gomock.Any(), user).Return(nil)
+
+		err := pinUseCase.ChangePIN(context.Background(), user.ID, entities.ChangePINRequest{CurrentPIN: "000000", NewPIN: "654321"})
+
+		require.Error(t, err)
+		assert.True(t, customerrors.IsValidationError(err))
+		assert.Equal(t, 1, user.PINFailedAttempts)
+		assert.True(t, user.CheckPIN(testPIN))
+	})
+
+	t.Run("change PIN", func(t *testing.T) {
+		user := newMFAUser(t)
+		user.PINHash = testPINHash
+
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil)
+		mockUserRepo.EXPECT().UpdatePIN(gomock.Any(), user).Return(nil)
+
+		err := pinUseCase.ChangePIN(context.Background(), user.ID, entities.ChangePINRequest{CurrentPIN: testPIN, NewPIN: "654321"})
+
+		require.NoError(t, err)
+		assert.True(t, user.CheckPIN("654321"))
+	})
+
+	t.Run("reset PIN requires the second factor", func(t *testing.T) {
+		user := newMFAUser(t)
+		user.PINHash = testPINHash
+
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil)
+
+		err := pinUseCase.ResetPIN(context.Background(), user.ID, entities.ResetPINRequest{Password: "password", NewPIN: "654321"})
+
+		require.Error(t, err)
+		assert.True(t, customerrors.IsValidationError(err))
+		assert.True(t, user.CheckPIN(testPIN))
+	})
+
+	t.Run("reset PIN lifts the lockout", func(t *testing.T) {
+		user := newMFAUser(t)
+		user.PINHash = testPINHash
+		lockedUntil := time.Now().Add(10 * time.Minute)
+		user.PINLockedUntil = &lockedUntil
+
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil)
+		mockUserRepo.EXPECT().UpdateMFA(gomock.Any(), user).Return(nil)
+		mockUserRepo.EXPECT().UpdatePIN(gomock.Any(), user).Return(nil)
+
+		err := pinUseCase.ResetPIN(context.Background(), user.ID, entities.ResetPINRequest{Password: "password", Code: currentCode(t, user.MFASecret), NewPIN: "654321"})
+
+		require.NoError(t, err)
+		assert.True(t, user.CheckPIN("654321"))
+		assert.False(t, user.IsPINLocked(time.Now()))
+	})
+}
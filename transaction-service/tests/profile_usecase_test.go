@@ -0,0 +1,266 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-transaction-service/internal/domain/entities"
+	"go-transaction-service/internal/mocks"
+	"go-transaction-service/internal/usecase"
+	"go-transaction-service/pkg/errors"
+	"go.uber.org/zap"
+)
+
+func TestProfileUseCase(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Mock repositories
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockTransactionRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockRefreshTokenRepo := mocks.NewMockRefreshTokenRepository(ctrl)
+	mockTxManager := newPassThroughTxManager(ctrl)
+
+	// Create use case
+	profileUseCase := usecase.NewProfileUseCase(mockUserRepo, mockTransactionRepo, mockRefreshTokenRepo, mockTxManager)
+
+	t.Run("update changes only the given fields", func(t *testing.T) {
+		user := newLoginUser(t)
+		user.FirstName = "John"
+		user.LastName = "Doe"
+		user.Phone = "+1234567890"
+		firstName := "Johnny"
+
+		// Mock expectations
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil)
+		mockUserRepo.EXPECT().Update(gomock.Any(), user).Return(nil)
+
+		// Execute
+		profile, err := profileUseCase.UpdateProfile(context.Background(), user.ID, entities.UpdateProfileRequest{FirstName: &firstName})
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, "Johnny", profile.FirstName)
+		assert.Equal(t, "Doe", profile.LastName)
+		assert.Equal(t, "+1234567890", profile.Phone)
+	})
+
+	t.Run("password change ends the other sessions", func(t *testing.T) {
+		user := newLoginUser(t)
+		claims := &entities.JWTClaims{UserID: user.ID, SessionID: uuid.New()}
+
+		// Mock expectations
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil)
+		mockUserRepo.EXPECT().Update(gomock.Any(), user).Return(nil)
+		mockRefreshTokenRepo.EXPECT().RevokeAllForUserExcept(gomock.Any(), user.ID, claims.SessionID).Return(nil)
+
+		// Execute
+		err := profileUseCase.ChangePassword(context.Background(), claims, entities.ChangePasswordRequest{
+			CurrentPassword: "password",
+			NewPassword:     "newpassword123",
+		})
+
+		// Assert
+		require.NoError(t, err)
+		assert.True(t, user.CheckPassword("newpassword123"))
+		assert.False(t, user.CheckPassword("password"))
+	})
+
+	t.Run("password change with wrong current password", func(t *testing.T) {
+		user := newLoginUser(t)
+		claims := &entities.JWTClaims{UserID: user.ID, SessionID: uuid.New()}
+
+		// Mock expectations
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil)
+
+		// Execute
+		err := profileUseCase.ChangePassword(context.Background(), claims, entities.ChangePasswordRequest{
+			CurrentPassword: "wrong",
+			NewPassword:     "newpassword123",
+		})
+
+		// Assert
+		require.Error(t, err)
+		assert.True(t, customerrors.IsValidationError(err))
+		assert.True(t, user.CheckPassword("password"))
+	})
+
+	t.Run("closing with a balance is refused", func(t *testing.T) {
+		user := newLoginUser(t)
+		user.Balance = decimal.NewFromInt(10)
+
+		// Mock expectations
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil)
+
+		// Execute
+		err := profileUseCase.CloseAccount(context.Background(), user.ID, entities.CloseAccountRequest{Password: "password"})
+
+		// Assert
+		require.Error(t, err)
+		assert.True(t, customerrors.IsConflictError(err))
+		assert.Equal(t, entities.UserStatusActive, user.Status)
+	})
+
+	t.Run("closing with a transaction in progress is refused", func(t *testing.T) {
+		user := newLoginUser(t)
+		user.Balance = decimal.Zero
+
+		// Mock expectations
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil)
+		mockTransactionRepo.EXPECT().CountByUserID(gomock.Any(), user.ID, entities.TransactionFilter{Status: entities.TransactionStatusPending}).Return(1, nil)
+
+		// Execute
+		err := profileUseCase.CloseAccount(context.Background(), user.ID, entities.CloseAccountRequest{Password: "password"})
+
+		// Assert
+		require.Error(t, err)
+		assert.True(t, customerrors.IsConflictError(err))
+	})
+
+	t.Run("closing with a zero balance ends all sessions", func(t *testing.T) {
+		user := newLoginUser(t)
+		user.Balance = decimal.Zero
+
+		// Mock expectations
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil)
+		mockTransactionRepo.EXPECT().CountByUserID(gomock.Any(), user.ID, gomock.Any()).Return(0, nil).Times(4)
+		mockUserRepo.EXPECT().Update(gomock.Any(), user).Return(nil)
+		mockRefreshTokenRepo.EXPECT().RevokeAllForUser(gomock.Any(), user.ID).Return(nil)
+
+		// Execute
+		err := profileUseCase.CloseAccount(context.Background(), user.ID, entities.CloseAccountRequest{Password: "password"})
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, entities.UserStatusClosed, user.Status)
+		assert.False(t, user.IsActive())
+	})
+
+	t.Run("closed account cannot be changed", func(t *testing.T) {
+		user := newLoginUser(t)
+		user.Status = entities.UserStatusClosed
+		phone := "+1987654321"
+
+		// Mock expectations
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil)
+
+		// Execute
+		profile, err := profileUseCase.UpdateProfile(context.Background(), user.ID, entities.UpdateProfileRequest{Phone: &phone})
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, profile)
+		assert.Equal(t, http.StatusForbidden, customerrors.GetErrorCode(err))
+	})
+}
+
+func TestAccountUseCase_ChangeEmail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Mock repositories
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockAccountTokenRepo := mocks.NewMockAccountTokenRepository(ctrl)
+	mockRefreshTokenRepo := mocks.NewMockRefreshTokenRepository(ctrl)
+	mockTxManager := newPassThroughTxManager(ctrl)
+	mockMailer := mocks.NewMockMailer(ctrl)
+
+	// Create use case
+	accountUseCase := usecase.NewAccountUseCase(mockUserRepo, mockAccountTokenRepo, mockRefreshTokenRepo, mockTxManager, mockMailer, newAccountTestConfig(), zap.NewNop())
+
+	t.Run("new address is unverified and both addresses are emailed", func(t *testing.T) {
+		user := newLoginUser(t)
+		verifiedAt := time.Now().Add(-time.Hour)
+		user.EmailVerifiedAt = &verifiedAt
+
+		// Mock expectations
+		var messages []usecase.MailMessage
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil)
+		mockUserRepo.EXPECT().CheckEmailExists(gomock.Any(), "new@example.com").Return(false, nil)
+		mockUserRepo.EXPECT().Update(gomock.Any(), user).Return(nil)
+		mockUserRepo.EXPECT().GetByID(gomock.Any(), user.ID).Return(user, nil)
+		mockAccountTokenRepo.EXPECT().InvalidateForUser(gomock.Any(), user.ID, entities.AccountTokenEmailVerification).Return(nil)
+		mockAccountTokenRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, token *entities.AccountToken) error {
+				assert.Equal(t, "new@example.com", token.Email)
+				return nil
+			})
+		mockMailer.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, m usecase.MailMessage) error {
+				messages = append(messages, m)
+				return nil
+			}).Times(2)
+
+		// Execute
+		profile, err := accountUseCase.ChangeEmail(context.Background(), user.ID, entities.ChangeEmailRequest{Email: "new@example.com", Password: "password"})
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, "new@example.com", profile.Email)
+		assert.False(t, profile.EmailVerified)
+		require.Len(t, messages, 2)
+		assert.Equal(t, "new@example.com", messages[0].To)
+		assert.Contains(t, messages[0].Body, "https://pintro.example/verify-email?token=")
+		assert.Equal(t, "test@example.com", messages[1].To)
+	})
+
+	t.Run("change is kept when the emails cannot be sent", func(t *testing.T) {
+		user := newLoginUser(t)
+
+		// Mock expectations
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil)
+		mockUserRepo.EXPECT().CheckEmailExists(gomock.Any(), "new@example.com").Return(false, nil)
+		mockUserRepo.EXPECT().Update(gomock.Any(), user).Return(nil)
+		mockUserRepo.EXPECT().GetByID(gomock.Any(), user.ID).Return(user, nil)
+		mockAccountTokenRepo.EXPECT().InvalidateForUser(gomock.Any(), user.ID, entities.AccountTokenEmailVerification).Return(nil)
+		mockAccountTokenRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+		mockMailer.EXPECT().Send(gomock.Any(), gomock.Any()).Return(errors.New("smtp unavailable")).Times(2)
+
+		// Execute
+		profile, err := accountUseCase.ChangeEmail(context.Background(), user.ID, entities.ChangeEmailRequest{Email: "new@example.com", Password: "password"})
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, "new@example.com", profile.Email)
+	})
+
+	t.Run("address taken by another account", func(t *testing.T) {
+		user := newLoginUser(t)
+
+		// Mock expectations
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil)
+		mockUserRepo.EXPECT().CheckEmailExists(gomock.Any(), "taken@example.com").Return(true, nil)
+
+		// Execute
+		profile, err := accountUseCase.ChangeEmail(context.Background(), user.ID, entities.ChangeEmailRequest{Email: "taken@example.com", Password: "password"})
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, profile)
+		assert.True(t, customerrors.IsConflictError(err))
+		assert.Equal(t, "test@example.com", user.Email)
+	})
+
+	t.Run("wrong password", func(t *testing.T) {
+		user := newLoginUser(t)
+
+		// Mock expectations
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil)
+
+		// Execute
+		profile, err := accountUseCase.ChangeEmail(context.Background(), user.ID, entities.ChangeEmailRequest{Email: "new@example.com", Password: "wrong"})
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, profile)
+		assert.True(t, customerrors.IsValidationError(err))
+	})
+}
@@ -0,0 +1,95 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-transaction-service/internal/domain/entities"
+	"go-transaction-service/internal/mocks"
+	"go-transaction-service/internal/usecase"
+	"go-transaction-service/pkg/errors"
+)
+
+//go:generate mockgen -source=../internal/domain/repositories/session_repository.go -destination=../internal/mocks/session_repository_mock.go
+
+func TestSessionUseCase(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Mock repositories
+	mockSessionRepo := mocks.NewMockSessionRepository(ctrl)
+	mockRefreshTokenRepo := mocks.NewMockRefreshTokenRepository(ctrl)
+	mockTxManager := newPassThroughTxManager(ctrl)
+
+	// Create use case
+	sessionUseCase := usecase.NewSessionUseCase(mockSessionRepo, mockRefreshTokenRepo, mockTxManager)
+
+	userID := uuid.New()
+
+	t.Run("list marks the current session", func(t *testing.T) {
+		current := entities.NewSession(userID, entities.ClientInfo{IPAddress: "203.0.113.7", UserAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) Safari/604.1"})
+		other := entities.NewSession(userID, entities.ClientInfo{IPAddress: "198.51.100.1"})
+		other.LastSeenAt = time.Now().Add(-time.Hour)
+		claims := &entities.JWTClaims{UserID: userID, SessionID: current.ID}
+
+		// Mock expectations
+		mockSessionRepo.EXPECT().ListActiveByUser(gomock.Any(), userID).Return([]*entities.Session{current, other}, nil)
+
+		// Execute
+		response, err := sessionUseCase.ListSessions(context.Background(), claims)
+
+		// Assert
+		require.NoError(t, err)
+		require.Len(t, response.Sessions, 2)
+		assert.True(t, response.Sessions[0].Current)
+		assert.False(t, response.Sessions[1].Current)
+		assert.Equal(t, "Safari on iPhone", response.Sessions[0].Device)
+	})
+
+	t.Run("revoking a session ends its token family", func(t *testing.T) {
+		session := entities.NewSession(userID, entities.ClientInfo{})
+
+		// Mock expectations
+		mockSessionRepo.EXPECT().GetActive(gomock.Any(), session.ID).Return(session, nil)
+		mockRefreshTokenRepo.EXPECT().RevokeFamily(gomock.Any(), session.ID).Return(nil)
+
+		// Execute
+		err := sessionUseCase.RevokeSession(context.Background(), userID, session.ID)
+
+		// Assert
+		require.NoError(t, err)
+	})
+
+	t.Run("session of another user is not found", func(t *testing.T) {
+		session := entities.NewSession(uuid.New(), entities.ClientInfo{})
+
+		// Mock expectations
+		mockSessionRepo.EXPECT().GetActive(gomock.Any(), session.ID).Return(session, nil)
+
+		// Execute
+		err := sessionUseCase.RevokeSession(context.Background(), userID, session.ID)
+
+		// Assert
+		require.Error(t, err)
+		assert.True(t, customerrors.IsNotFoundError(err))
+	})
+
+	t.Run("ended session is not found", func(t *testing.T) {
+		sessionID := uuid.New()
+
+		// Mock expectations
+		mockSessionRepo.EXPECT().GetActive(gomock.Any(), sessionID).Return(nil, customerrors.NewNotFoundError("Session not found"))
+
+		// Execute
+		err := sessionUseCase.RevokeSession(context.Background(), userID, sessionID)
+
+		// Assert
+		require.Error(t, err)
+		assert.True(t, customerrors.IsNotFoundError(err))
+	})
+}
@@ -0,0 +1,81 @@
+package tests
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-transaction-service/pkg/totp"
+)
+
+// rfcSecret is the SHA-1 key of the RFC 6238 test vectors ("12345678901234567890"), base32 encoded
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTP(t *testing.T) {
+	t.Run("RFC 6238 test vectors", func(t *testing.T) {
+		vectors := map[int64]string{
+			59:         "287082",
+			1111111109: "081804",
+			1234567890: "005924",
+			2000000000: "279037",
+		}
+
+		for unix, want := range vectors {
+			code, err := totp.Code(rfcSecret, totp.Step(time.Unix(unix, 0)))
+			require.NoError(t, err)
+			assert.Equal(t, want, code, "time %d", unix)
+		}
+	})
+
+	t.Run("validate accepts clock drift of one step", func(t *testing.T) {
+		now := time.Unix(1234567890, 0)
+		previous, err := totp.Code(rfcSecret, totp.Step(now)-1)
+		require.NoError(t, err)
+
+		step, ok := totp.Validate(rfcSecret, previous, now, 0)
+		assert.True(t, ok)
+		assert.Equal(t, totp.Step(now)-1, step)
+
+		tooOld, err := totp.Code(rfcSecret, totp.Step(now)-2)
+		require.NoError(t, err)
+		_, ok = totp.Validate(rfcSecret, tooOld, now, 0)
+		assert.False(t, ok)
+	})
+
+	t.Run("validate refuses a replayed code", func(t *testing.T) {
+		now := time.Unix(1234567890, 0)
+		code, err := totp.Code(rfcSecret, totp.Step(now))
+		require.NoError(t, err)
+
+		step, ok := totp.Validate(rfcSecret, code, now, 0)
+		require.True(t, ok)
+
+		_, ok = totp.Validate(rfcSecret, code, now, step)
+		assert.False(t, ok)
+	})
+
+	t.Run("validate refuses malformed codes", func(t *testing.T) {
+		now := time.Unix(1234567890, 0)
+		for _, code := range []string{"", "12345", "1234567", "abcdef"} {
+			_, ok := totp.Validate(rfcSecret, code, now, 0)
+			assert.False(t, ok, code)
+		}
+	})
+
+	t.Run("generated secret and URI", func(t *testing.T) {
+		secret, err := totp.GenerateSecret()
+		require.NoError(t, err)
+		assert.Len(t, secret, 32)
+
+		uri, err := url.Parse(totp.URI("Pintro", "john@example.com", secret))
+		require.NoError(t, err)
+		assert.Equal(t, "otpauth", uri.Scheme)
+		assert.Equal(t, "totp", uri.Host)
+		assert.True(t, strings.HasPrefix(uri.Path, "/Pintro:john@example.com"))
+		assert.Equal(t, secret, uri.Query().Get("secret"))
+		assert.Equal(t, "Pintro", uri.Query().Get("issuer"))
+	})
+}
@@ -2,20 +2,119 @@ package tests
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go-transaction-service/internal/config"
 	"go-transaction-service/internal/domain/entities"
 	"go-transaction-service/internal/mocks"
 	"go-transaction-service/internal/usecase"
 	"go-transaction-service/pkg/errors"
+	"golang.org/x/crypto/bcrypt"
 )
 
 //go:generate mockgen -source=../internal/domain/repositories/transaction_repository.go -destination=../internal/mocks/transaction_repository_mock.go
+//go:generate mockgen -source=../internal/domain/repositories/ledger_repository.go -destination=../internal/mocks/ledger_repository_mock.go
+//go:generate mockgen -source=../internal/domain/repositories/tx_manager.go -destination=../internal/mocks/tx_manager_mock.go
+
+// newPassThroughTxManager returns a TxManager mock that simply runs the unit of work
+func newPassThroughTxManager(ctrl *gomock.Controller) *mocks.MockTxManager {
+	txManager := mocks.NewMockTxManager(ctrl)
+	txManager.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, fn func(ctx context.Context) error) error {
+			return fn(ctx)
+		}).AnyTimes()
+	return txManager
+}
+
+// newDefaultFeeUseCase returns the fee use case without any fee rule, so the fees of the
+// payment and transfer policies of the config apply
+func newDefaultFeeUseCase(ctrl *gomock.Controller, cfg *config.Config) usecase.FeeUseCase {
+	feeRuleRepo := mocks.NewMockFeeRuleRepository(ctrl)
+	feeRuleRepo.EXPECT().FindApplicable(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil, customerrors.NewNotFoundError("No fee rule applies")).AnyTimes()
+	return usecase.NewFeeUseCase(feeRuleRepo, cfg)
+}
+
+// newDefaultLimitUseCase returns the limit use case of users without limit overrides, so only
+// the global limits of cfg apply
+func newDefaultLimitUseCase(ctrl *gomock.Controller, transactionRepo *mocks.MockTransactionRepository, userRepo *mocks.MockUserRepository, cfg *config.Config) usecase.LimitUseCase {
+	userLimitRepo := mocks.NewMockUserLimitRepository(ctrl)
+	userLimitRepo.EXPECT().GetByUserID(gomock.Any(), gomock.Any()).
+		Return(nil, customerrors.NewNotFoundError("User limits not found")).AnyTimes()
+	return usecase.NewLimitUseCase(userLimitRepo, userRepo, transactionRepo, cfg)
+}
+
+// testPIN is the transaction PIN of every sender fixture
+const testPIN = "123456"
+
+// testPINHash is testPIN hashed at the lowest bcrypt cost to keep the tests fast
+var testPINHash = func() string {
+	hash, err := bcrypt.GenerateFromPassword([]byte(testPIN), bcrypt.MinCost)
+	if err != nil {
+		panic(err)
+	}
+	return string(hash)
+}()
+
+// newTransactionTestConfig returns the configuration used by the transaction use case tests
+func newTransactionTestConfig() *config.Config {
+	return &config.Config{
+		Expiry: config.ExpiryConfig{
+			Topup:            time.Hour,
+			Authorization:    24 * time.Hour,
+			MaxAuthorization: 7 * 24 * time.Hour,
+		},
+		PIN: config.PINConfig{
+			MaxAttempts:  3,
+			LockDuration: 30 * time.Minute,
+		},
+		// Every tier unlocks every feature so that tests not about KYC need no tier
+		KYC: config.KYCConfig{
+			MaxDocumentSize: 1 << 20,
+			Unverified:      config.KYCTierPolicy{Features: allKYCFeatures},
+			Basic:           config.KYCTierPolicy{Features: allKYCFeatures},
+			Full:            config.KYCTierPolicy{Features: allKYCFeatures},
+		},
+	}
+}
+
+var allKYCFeatures = []string{entities.KYCFeatureTopup, entities.KYCFeaturePayment, entities.KYCFeatureTransfer, entities.KYCFeatureAuthorization}
+
+// decimalEq matches a decimal.Decimal by value regardless of its scale
+type decimalEq struct{ want decimal.Decimal }
+
+func (m decimalEq) Matches(x interface{}) bool {
+	d, ok := x.(decimal.Decimal)
+	return ok && d.Equal(m.want)
+}
+
+func (m decimalEq) String() string { return "equals " + m.want.String() }
+
+// walletDeltas returns the net balance change per user wallet produced by a journal entry;
+// credits increase and debits decrease the user balance
+func walletDeltas(entry *entities.JournalEntry) map[uuid.UUID]decimal.Decimal {
+	deltas := make(map[uuid.UUID]decimal.Decimal)
+	for _, p := range entry.Postings {
+		if p.Account != entities.LedgerAccountUserWallet || p.UserID == nil {
+			continue
+		}
+
+		delta := p.Amount
+		if p.Direction == entities.PostingDirectionDebit {
+			delta = delta.Neg()
+		}
+		deltas[*p.UserID] = deltas[*p.UserID].Add(delta)
+	}
+	return deltas
+}
 
 func TestTransactionUseCase_ProcessPayment(t *testing.T) {
 	ctrl := gomock.NewController(t)
@@ -24,10 +123,13 @@ func TestTransactionUseCase_ProcessPayment(t *testing.T) {
 	// Mock repositories
 	mockTransactionRepo := mocks.NewMockTransactionRepository(ctrl)
 	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockLedgerRepo := mocks.NewMockLedgerRepository(ctrl)
+	mockTxManager := newPassThroughTxManager(ctrl)
 	mockPaymentGateway := mocks.NewMockPaymentGateway(ctrl)
 
 	// Create use case
-	transactionUseCase := usecase.NewTransactionUseCase(mockTransactionRepo, mockUserRepo, mockPaymentGateway)
+	cfg := newTransactionTestConfig()
+	transactionUseCase := usecase.NewTransactionUseCase(mockTransactionRepo, mockUserRepo, mockLedgerRepo, mockTxManager, mockPaymentGateway, newDefaultFeeUseCase(ctrl, cfg), newDefaultLimitUseCase(ctrl, mockTransactionRepo, mockUserRepo, cfg), cfg)
 
 	t.Run("successful payment", func(t *testing.T) {
 		// Test data
@@ -40,7 +142,9 @@ func TestTransactionUseCase_ProcessPayment(t *testing.T) {
 			Email:     "sender@example.com",
 			FirstName: "John",
 			LastName:  "Sender",
+			Balance:   decimal.NewFromFloat(500.00),
 			Status:    entities.UserStatusActive,
+			PINHash:   testPINHash,
 		}
 
 		recipient := &entities.User{
@@ -55,15 +159,23 @@ func TestTransactionUseCase_ProcessPayment(t *testing.T) {
 			Amount:      amount,
 			Description: "Test payment",
 			ToUserID:    recipientID,
+			PIN:         testPIN,
 		}
 
 		// Mock expectations
-		mockUserRepo.EXPECT().GetByID(gomock.Any(), senderID).Return(sender, nil)
-		mockUserRepo.EXPECT().GetByID(gomock.Any(), recipientID).Return(recipient, nil)
-		mockUserRepo.EXPECT().GetBalance(gomock.Any(), senderID).Return(decimal.NewFromFloat(500.00), nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), senderID).Return(sender, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), recipientID).Return(recipient, nil)
 		mockTransactionRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
 		mockUserRepo.EXPECT().SubtractBalance(gomock.Any(), senderID, amount).Return(nil)
 		mockUserRepo.EXPECT().AddBalance(gomock.Any(), recipientID, amount).Return(nil)
+		mockLedgerRepo.EXPECT().CreateEntry(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, entry *entities.JournalEntry) error {
+				assert.NoError(t, entry.Validate())
+				deltas := walletDeltas(entry)
+				assert.True(t, amount.Neg().Equal(deltas[senderID]))
+				assert.True(t, amount.Equal(deltas[recipientID]))
+				return nil
+			})
 		mockTransactionRepo.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil)
 
 		// Execute
@@ -88,7 +200,9 @@ func TestTransactionUseCase_ProcessPayment(t *testing.T) {
 			Email:     "sender@example.com",
 			FirstName: "John",
 			LastName:  "Sender",
+			Balance:   decimal.NewFromFloat(100.00),
 			Status:    entities.UserStatusActive,
+			PINHash:   testPINHash,
 		}
 
 		recipient := &entities.User{
@@ -103,12 +217,12 @@ func TestTransactionUseCase_ProcessPayment(t *testing.T) {
 			Amount:      amount,
 			Description: "Test payment",
 			ToUserID:    recipientID,
+			PIN:         testPIN,
 		}
 
 		// Mock expectations
-		mockUserRepo.EXPECT().GetByID(gomock.Any(), senderID).Return(sender, nil)
-		mockUserRepo.EXPECT().GetByID(gomock.Any(), recipientID).Return(recipient, nil)
-		mockUserRepo.EXPECT().GetBalance(gomock.Any(), senderID).Return(decimal.NewFromFloat(100.00), nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), senderID).Return(sender, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), recipientID).Return(recipient, nil)
 
 		// Execute
 		response, err := transactionUseCase.ProcessPayment(context.Background(), senderID, req)
@@ -129,10 +243,12 @@ func TestTransactionUseCase_ProcessPayment(t *testing.T) {
 			Amount:      amount,
 			Description: "Test payment",
 			ToUserID:    recipientID,
+			PIN:         testPIN,
 		}
 
 		// Mock expectations
-		mockUserRepo.EXPECT().GetByID(gomock.Any(), senderID).Return(nil, customerrors.NewNotFoundError("User not found"))
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), senderID).Return(nil, customerrors.NewNotFoundError("User not found"))
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), recipientID).Return(nil, customerrors.NewNotFoundError("User not found"))
 
 		// Execute
 		response, err := transactionUseCase.ProcessPayment(context.Background(), senderID, req)
@@ -155,17 +271,19 @@ func TestTransactionUseCase_ProcessPayment(t *testing.T) {
 			FirstName: "John",
 			LastName:  "Sender",
 			Status:    entities.UserStatusActive,
+			PINHash:   testPINHash,
 		}
 
 		req := entities.PaymentRequest{
 			Amount:      amount,
 			Description: "Test payment",
 			ToUserID:    recipientID,
+			PIN:         testPIN,
 		}
 
 		// Mock expectations
-		mockUserRepo.EXPECT().GetByID(gomock.Any(), senderID).Return(sender, nil)
-		mockUserRepo.EXPECT().GetByID(gomock.Any(), recipientID).Return(nil, customerrors.NewNotFoundError("User not found"))
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), senderID).Return(sender, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), recipientID).Return(nil, customerrors.NewNotFoundError("User not found"))
 
 		// Execute
 		response, err := transactionUseCase.ProcessPayment(context.Background(), senderID, req)
@@ -194,10 +312,12 @@ func TestTransactionUseCase_ProcessPayment(t *testing.T) {
 			Amount:      amount,
 			Description: "Test payment",
 			ToUserID:    recipientID,
+			PIN:         testPIN,
 		}
 
 		// Mock expectations
-		mockUserRepo.EXPECT().GetByID(gomock.Any(), senderID).Return(sender, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), senderID).Return(sender, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), recipientID).Return(nil, customerrors.NewNotFoundError("User not found"))
 
 		// Execute
 		response, err := transactionUseCase.ProcessPayment(context.Background(), senderID, req)
@@ -207,6 +327,192 @@ func TestTransactionUseCase_ProcessPayment(t *testing.T) {
 		assert.Nil(t, response)
 		assert.True(t, customerrors.IsValidationError(err))
 	})
+
+	t.Run("payment to self", func(t *testing.T) {
+		// Test data
+		userID := uuid.New()
+
+		req := entities.PaymentRequest{
+			Amount:      decimal.NewFromFloat(100.00),
+			Description: "Test payment",
+			ToUserID:    userID,
+			PIN:         testPIN,
+		}
+
+		// Execute
+		response, err := transactionUseCase.ProcessPayment(context.Background(), userID, req)
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, response)
+		assert.True(t, customerrors.IsValidationError(err))
+	})
+}
+
+func TestTransactionUseCase_TransferFunds(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Mock repositories
+	mockTransactionRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockLedgerRepo := mocks.NewMockLedgerRepository(ctrl)
+	mockTxManager := newPassThroughTxManager(ctrl)
+	mockPaymentGateway := mocks.NewMockPaymentGateway(ctrl)
+
+	// Transfers have their own limits and fee
+	cfg := newTransactionTestConfig()
+	cfg.Transfer = config.TransactionPolicy{
+		MaxAmount:  decimal.NewFromInt(1000),
+		DailyLimit: decimal.NewFromInt(1500),
+		FeeFlat:    decimal.NewFromInt(5),
+	}
+
+	// Create use case
+	transactionUseCase := usecase.NewTransactionUseCase(mockTransactionRepo, mockUserRepo, mockLedgerRepo, mockTxManager, mockPaymentGateway, newDefaultFeeUseCase(ctrl, cfg), newDefaultLimitUseCase(ctrl, mockTransactionRepo, mockUserRepo, cfg), cfg)
+
+	newUsers := func() (*entities.User, *entities.User) {
+		sender := &entities.User{ID: uuid.New(), Email: "sender@example.com", FirstName: "John", LastName: "Sender", Balance: decimal.NewFromInt(500), Status: entities.UserStatusActive, PINHash: testPINHash}
+		recipient := &entities.User{ID: uuid.New(), Email: "recipient@example.com", Phone: "+6281234567890", FirstName: "Jane", LastName: "Recipient", Status: entities.UserStatusActive}
+		return sender, recipient
+	}
+
+	t.Run("successful transfer by email", func(t *testing.T) {
+		sender, recipient := newUsers()
+		amount := decimal.NewFromInt(100)
+		fee := decimal.NewFromInt(5)
+		req := entities.TransferRequest{Amount: amount, ToEmail: recipient.Email, PIN: testPIN, Description: "Transfer to friend"}
+
+		// Mock expectations
+		mockUserRepo.EXPECT().GetByEmail(gomock.Any(), recipient.Email).Return(recipient, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), sender.ID).Return(sender, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), recipient.ID).Return(recipient, nil)
+		mockTransactionRepo.EXPECT().GetUsage(gomock.Any(), sender.ID, entities.TransactionTypeTransfer, gomock.Any(), gomock.Any()).
+			Return(&entities.LimitUsage{DailyAmount: decimal.NewFromInt(200), MonthlyAmount: decimal.NewFromInt(200)}, nil)
+		mockTransactionRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, transaction *entities.Transaction) error {
+				assert.Equal(t, entities.TransactionTypeTransfer, transaction.Type)
+				assert.Equal(t, &recipient.ID, transaction.CounterpartyUserID)
+				return nil
+			})
+		mockUserRepo.EXPECT().SubtractBalance(gomock.Any(), sender.ID, decimalEq{amount.Add(fee)}).Return(nil)
+		mockUserRepo.EXPECT().AddBalance(gomock.Any(), recipient.ID, amount).Return(nil)
+		mockLedgerRepo.EXPECT().CreateEntry(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, entry *entities.JournalEntry) error {
+				assert.NoError(t, entry.Validate())
+				deltas := walletDeltas(entry)
+				assert.True(t, amount.Add(fee).Neg().Equal(deltas[sender.ID]))
+				assert.True(t, amount.Equal(deltas[recipient.ID]))
+
+				var feeIncome decimal.Decimal
+				for _, posting := range entry.Postings {
+					if posting.Account == entities.LedgerAccountFeeIncome {
+						feeIncome = feeIncome.Add(posting.Amount)
+					}
+				}
+				assert.True(t, fee.Equal(feeIncome))
+				return nil
+			})
+		mockTransactionRepo.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil)
+
+		// Execute
+		receipt, err := transactionUseCase.TransferFunds(context.Background(), sender.ID, req)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, entities.TransactionStatusCompleted, receipt.Status)
+		assert.True(t, amount.Equal(receipt.Amount))
+		assert.True(t, fee.Equal(receipt.Fee))
+		assert.True(t, amount.Add(fee).Equal(receipt.TotalDebited))
+		assert.Equal(t, sender.ID, receipt.Sender.ID)
+		assert.Equal(t, recipient.ID, receipt.Recipient.ID)
+		assert.NotEmpty(t, receipt.Reference)
+	})
+
+	t.Run("recipient phone not found", func(t *testing.T) {
+		sender, _ := newUsers()
+		req := entities.TransferRequest{Amount: decimal.NewFromInt(100), ToPhone: "+6289999999999", PIN: testPIN, Description: "Transfer to friend"}
+
+		// Mock expectations
+		mockUserRepo.EXPECT().GetByPhone(gomock.Any(), req.ToPhone).Return(nil, customerrors.NewNotFoundError("User not found"))
+
+		// Execute
+		receipt, err := transactionUseCase.TransferFunds(context.Background(), sender.ID, req)
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, receipt)
+		assert.True(t, customerrors.IsNotFoundError(err))
+	})
+
+	t.Run("daily limit exceeded", func(t *testing.T) {
+		sender, recipient := newUsers()
+		req := entities.TransferRequest{Amount: decimal.NewFromInt(100), ToPhone: recipient.Phone, PIN: testPIN, Description: "Transfer to friend"}
+
+		// Mock expectations
+		mockUserRepo.EXPECT().GetByPhone(gomock.Any(), recipient.Phone).Return(recipient, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), sender.ID).Return(sender, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), recipient.ID).Return(recipient, nil)
+		mockTransactionRepo.EXPECT().GetUsage(gomock.Any(), sender.ID, entities.TransactionTypeTransfer, gomock.Any(), gomock.Any()).
+			Return(&entities.LimitUsage{DailyAmount: decimal.NewFromInt(1450), MonthlyAmount: decimal.NewFromInt(1450)}, nil)
+
+		// Execute
+		receipt, err := transactionUseCase.TransferFunds(context.Background(), sender.ID, req)
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, receipt)
+		assert.True(t, customerrors.IsValidationError(err))
+		assert.Equal(t, entities.LimitReasonDailyAmount, err.(*customerrors.CustomError).Reason)
+	})
+
+	t.Run("amount above transfer maximum", func(t *testing.T) {
+		sender, recipient := newUsers()
+		req := entities.TransferRequest{Amount: decimal.NewFromInt(5000), ToEmail: recipient.Email, PIN: testPIN, Description: "Transfer to friend"}
+
+		// Mock expectations
+		mockUserRepo.EXPECT().GetByEmail(gomock.Any(), recipient.Email).Return(recipient, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), sender.ID).Return(sender, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), recipient.ID).Return(recipient, nil)
+
+		// Execute
+		receipt, err := transactionUseCase.TransferFunds(context.Background(), sender.ID, req)
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, receipt)
+		assert.True(t, customerrors.IsValidationError(err))
+		assert.Equal(t, entities.LimitReasonMaxAmount, err.(*customerrors.CustomError).Reason)
+	})
+
+	t.Run("both email and phone", func(t *testing.T) {
+		sender, recipient := newUsers()
+		req := entities.TransferRequest{Amount: decimal.NewFromInt(100), ToEmail: recipient.Email, ToPhone: recipient.Phone, PIN: testPIN, Description: "Transfer to friend"}
+
+		// Execute
+		receipt, err := transactionUseCase.TransferFunds(context.Background(), sender.ID, req)
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, receipt)
+		assert.True(t, customerrors.IsValidationError(err))
+	})
+
+	t.Run("transfer to yourself", func(t *testing.T) {
+		sender, _ := newUsers()
+		req := entities.TransferRequest{Amount: decimal.NewFromInt(100), ToEmail: sender.Email, PIN: testPIN, Description: "Transfer to myself"}
+
+		// Mock expectations
+		mockUserRepo.EXPECT().GetByEmail(gomock.Any(), sender.Email).Return(sender, nil)
+
+		// Execute
+		receipt, err := transactionUseCase.TransferFunds(context.Background(), sender.ID, req)
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, receipt)
+		assert.True(t, customerrors.IsValidationError(err))
+	})
 }
 
 func TestTransactionUseCase_TopupBalance(t *testing.T) {
@@ -216,10 +522,13 @@ func TestTransactionUseCase_TopupBalance(t *testing.T) {
 	// Mock repositories
 	mockTransactionRepo := mocks.NewMockTransactionRepository(ctrl)
 	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockLedgerRepo := mocks.NewMockLedgerRepository(ctrl)
+	mockTxManager := newPassThroughTxManager(ctrl)
 	mockPaymentGateway := mocks.NewMockPaymentGateway(ctrl)
 
 	// Create use case
-	transactionUseCase := usecase.NewTransactionUseCase(mockTransactionRepo, mockUserRepo, mockPaymentGateway)
+	cfg := newTransactionTestConfig()
+	transactionUseCase := usecase.NewTransactionUseCase(mockTransactionRepo, mockUserRepo, mockLedgerRepo, mockTxManager, mockPaymentGateway, newDefaultFeeUseCase(ctrl, cfg), newDefaultLimitUseCase(ctrl, mockTransactionRepo, mockUserRepo, cfg), cfg)
 
 	t.Run("successful topup", func(t *testing.T) {
 		// Test data
@@ -247,9 +556,9 @@ func TestTransactionUseCase_TopupBalance(t *testing.T) {
 		}
 
 		// Mock expectations
-		mockUserRepo.EXPECT().GetByID(gomock.Any(), userID).Return(user, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), userID).Return(user, nil)
 		mockTransactionRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
-		mockPaymentGateway.EXPECT().CreateTopupTransaction(gomock.Any(), userID, amount.String(), gomock.Any()).Return(paymentResp, nil)
+		mockPaymentGateway.EXPECT().CreateTopupTransaction(gomock.Any(), userID, amount.String(), "credit_card", gomock.Any(), gomock.Any()).Return(paymentResp, nil)
 		mockTransactionRepo.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil)
 
 		// Execute
@@ -262,6 +571,8 @@ func TestTransactionUseCase_TopupBalance(t *testing.T) {
 		assert.Equal(t, entities.TransactionStatusProcessing, response.Status)
 		assert.Equal(t, paymentResp.PaymentURL, response.PaymentURL)
 		assert.NotEmpty(t, response.Reference)
+		require.NotNil(t, response.ExpiresAt)
+		assert.Equal(t, time.Hour, response.ExpiresAt.Sub(response.CreatedAt))
 	})
 
 	t.Run("user not found", func(t *testing.T) {
@@ -275,7 +586,7 @@ func TestTransactionUseCase_TopupBalance(t *testing.T) {
 		}
 
 		// Mock expectations
-		mockUserRepo.EXPECT().GetByID(gomock.Any(), userID).Return(nil, customerrors.NewNotFoundError("User not found"))
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), userID).Return(nil, customerrors.NewNotFoundError("User not found"))
 
 		// Execute
 		response, err := transactionUseCase.TopupBalance(context.Background(), userID, req)
@@ -305,7 +616,7 @@ func TestTransactionUseCase_TopupBalance(t *testing.T) {
 		}
 
 		// Mock expectations
-		mockUserRepo.EXPECT().GetByID(gomock.Any(), userID).Return(user, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), userID).Return(user, nil)
 
 		// Execute
 		response, err := transactionUseCase.TopupBalance(context.Background(), userID, req)
@@ -335,9 +646,9 @@ func TestTransactionUseCase_TopupBalance(t *testing.T) {
 		}
 
 		// Mock expectations
-		mockUserRepo.EXPECT().GetByID(gomock.Any(), userID).Return(user, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), userID).Return(user, nil)
 		mockTransactionRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
-		mockPaymentGateway.EXPECT().CreateTopupTransaction(gomock.Any(), userID, amount.String(), gomock.Any()).Return(nil, assert.AnError)
+		mockPaymentGateway.EXPECT().CreateTopupTransaction(gomock.Any(), userID, amount.String(), "credit_card", gomock.Any(), gomock.Any()).Return(nil, assert.AnError)
 		mockTransactionRepo.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil)
 
 		// Execute
@@ -357,10 +668,13 @@ func TestTransactionUseCase_ProcessCallback(t *testing.T) {
 	// Mock repositories
 	mockTransactionRepo := mocks.NewMockTransactionRepository(ctrl)
 	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockLedgerRepo := mocks.NewMockLedgerRepository(ctrl)
+	mockTxManager := newPassThroughTxManager(ctrl)
 	mockPaymentGateway := mocks.NewMockPaymentGateway(ctrl)
 
 	// Create use case
-	transactionUseCase := usecase.NewTransactionUseCase(mockTransactionRepo, mockUserRepo, mockPaymentGateway)
+	cfg := newTransactionTestConfig()
+	transactionUseCase := usecase.NewTransactionUseCase(mockTransactionRepo, mockUserRepo, mockLedgerRepo, mockTxManager, mockPaymentGateway, newDefaultFeeUseCase(ctrl, cfg), newDefaultLimitUseCase(ctrl, mockTransactionRepo, mockUserRepo, cfg), cfg)
 
 	t.Run("successful callback processing for topup", func(t *testing.T) {
 		// Test data
@@ -379,7 +693,14 @@ func TestTransactionUseCase_ProcessCallback(t *testing.T) {
 		}
 
 		// Mock expectations
-		mockTransactionRepo.EXPECT().GetByReference(gomock.Any(), reference).Return(transaction, nil)
+		mockTransactionRepo.EXPECT().GetByReferenceForUpdate(gomock.Any(), reference).Return(transaction, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), userID).Return(&entities.User{ID: userID}, nil)
+		mockLedgerRepo.EXPECT().CreateEntry(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, entry *entities.JournalEntry) error {
+				assert.NoError(t, entry.Validate())
+				assert.True(t, amount.Equal(walletDeltas(entry)[userID]))
+				return nil
+			})
 		mockUserRepo.EXPECT().AddBalance(gomock.Any(), userID, amount).Return(nil)
 		mockTransactionRepo.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil)
 
@@ -397,7 +718,7 @@ func TestTransactionUseCase_ProcessCallback(t *testing.T) {
 		reference := "TXN-nonexistent"
 
 		// Mock expectations
-		mockTransactionRepo.EXPECT().GetByReference(gomock.Any(), reference).Return(nil, customerrors.NewNotFoundError("Transaction not found"))
+		mockTransactionRepo.EXPECT().GetByReferenceForUpdate(gomock.Any(), reference).Return(nil, customerrors.NewNotFoundError("Transaction not found"))
 
 		// Execute
 		err := transactionUseCase.ProcessCallback(context.Background(), reference, entities.TransactionStatusCompleted)
@@ -424,7 +745,7 @@ func TestTransactionUseCase_ProcessCallback(t *testing.T) {
 		}
 
 		// Mock expectations
-		mockTransactionRepo.EXPECT().GetByReference(gomock.Any(), reference).Return(transaction, nil)
+		mockTransactionRepo.EXPECT().GetByReferenceForUpdate(gomock.Any(), reference).Return(transaction, nil)
 
 		// Execute
 		err := transactionUseCase.ProcessCallback(context.Background(), reference, entities.TransactionStatusCompleted)
@@ -451,7 +772,7 @@ func TestTransactionUseCase_ProcessCallback(t *testing.T) {
 		}
 
 		// Mock expectations
-		mockTransactionRepo.EXPECT().GetByReference(gomock.Any(), reference).Return(transaction, nil)
+		mockTransactionRepo.EXPECT().GetByReferenceForUpdate(gomock.Any(), reference).Return(transaction, nil)
 		mockTransactionRepo.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil)
 
 		// Execute
@@ -462,3 +783,1173 @@ func TestTransactionUseCase_ProcessCallback(t *testing.T) {
 		assert.Equal(t, entities.TransactionStatusFailed, transaction.Status)
 	})
 }
+
+func TestTransactionUseCase_ReconcilePendingTransactions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Mock repositories
+	mockTransactionRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockLedgerRepo := mocks.NewMockLedgerRepository(ctrl)
+	mockTxManager := newPassThroughTxManager(ctrl)
+	mockPaymentGateway := mocks.NewMockPaymentGateway(ctrl)
+
+	// Create use case
+	cfg := newTransactionTestConfig()
+	transactionUseCase := usecase.NewTransactionUseCase(mockTransactionRepo, mockUserRepo, mockLedgerRepo, mockTxManager, mockPaymentGateway, newDefaultFeeUseCase(ctrl, cfg), newDefaultLimitUseCase(ctrl, mockTransactionRepo, mockUserRepo, cfg), cfg)
+
+	newProcessingTopup := func(reference string) *entities.Transaction {
+		return &entities.Transaction{
+			ID:          uuid.New(),
+			UserID:      uuid.New(),
+			Type:        entities.TransactionTypeTopup,
+			Amount:      decimal.NewFromInt(100000),
+			Status:      entities.TransactionStatusProcessing,
+			Reference:   reference,
+			Description: "Balance top-up",
+		}
+	}
+
+	t.Run("settled, pending and unreachable transactions", func(t *testing.T) {
+		olderThan := time.Now().Add(-15 * time.Minute)
+		settled := newProcessingTopup("TXN-SETTLED")
+		waiting := newProcessingTopup("TXN-WAITING")
+		unreachable := newProcessingTopup("TXN-UNREACHABLE")
+
+		// Mock expectations
+		mockTransactionRepo.EXPECT().GetPendingTransactions(gomock.Any(), entities.TransactionTypeTopup, olderThan, 50).
+			Return([]*entities.Transaction{settled, waiting, unreachable}, nil)
+
+		mockPaymentGateway.EXPECT().GetTransactionStatus(gomock.Any(), "TXN-SETTLED").
+			Return(&usecase.PaymentGatewayResponse{OrderID: "TXN-SETTLED", Status: "settlement", GrossAmount: "100000.00"}, nil)
+		mockTransactionRepo.EXPECT().GetByReferenceForUpdate(gomock.Any(), "TXN-SETTLED").Return(settled, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), settled.UserID).Return(&entities.User{ID: settled.UserID}, nil)
+		mockLedgerRepo.EXPECT().CreateEntry(gomock.Any(), gomock.Any()).Return(nil)
+		mockUserRepo.EXPECT().AddBalance(gomock.Any(), settled.UserID, settled.Amount).Return(nil)
+		mockTransactionRepo.EXPECT().Update(gomock.Any(), settled).Return(nil)
+
+		mockPaymentGateway.EXPECT().GetTransactionStatus(gomock.Any(), "TXN-WAITING").
+			Return(&usecase.PaymentGatewayResponse{OrderID: "TXN-WAITING", Status: "pending"}, nil)
+
+		mockPaymentGateway.EXPECT().GetTransactionStatus(gomock.Any(), "TXN-UNREACHABLE").
+			Return(nil, errors.New("connection refused"))
+
+		// Execute
+		results, err := transactionUseCase.ReconcilePendingTransactions(context.Background(), olderThan, 50)
+
+		// Assert
+		require.NoError(t, err)
+		require.Len(t, results, 3)
+
+		assert.True(t, results[0].Updated)
+		assert.NoError(t, results[0].Err)
+		assert.Equal(t, entities.TransactionStatusCompleted, results[0].Status)
+		assert.Equal(t, entities.TransactionStatusCompleted, settled.Status)
+
+		assert.False(t, results[1].Updated)
+		assert.NoError(t, results[1].Err)
+		assert.Equal(t, entities.TransactionStatusProcessing, results[1].Status)
+
+		assert.False(t, results[2].Updated)
+		assert.Error(t, results[2].Err)
+	})
+
+	t.Run("gross amount mismatch is not applied", func(t *testing.T) {
+		olderThan := time.Now()
+		transaction := newProcessingTopup("TXN-MISMATCH")
+
+		// Mock expectations: ProcessCallback must not be reached
+		mockTransactionRepo.EXPECT().GetPendingTransactions(gomock.Any(), entities.TransactionTypeTopup, olderThan, 10).
+			Return([]*entities.Transaction{transaction}, nil)
+		mockPaymentGateway.EXPECT().GetTransactionStatus(gomock.Any(), "TXN-MISMATCH").
+			Return(&usecase.PaymentGatewayResponse{OrderID: "TXN-MISMATCH", Status: "settlement", GrossAmount: "1.00"}, nil)
+
+		// Execute
+		results, err := transactionUseCase.ReconcilePendingTransactions(context.Background(), olderThan, 10)
+
+		// Assert
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.False(t, results[0].Updated)
+		assert.True(t, customerrors.IsValidationError(results[0].Err))
+		assert.Equal(t, entities.TransactionStatusProcessing, transaction.Status)
+	})
+
+	t.Run("challenged card capture is left pending", func(t *testing.T) {
+		olderThan := time.Now()
+		transaction := newProcessingTopup("TXN-CHALLENGE")
+
+		// Mock expectations: ProcessCallback must not be reached
+		mockTransactionRepo.EXPECT().GetPendingTransactions(gomock.Any(), entities.TransactionTypeTopup, olderThan, 10).
+			Return([]*entities.Transaction{transaction}, nil)
+		mockPaymentGateway.EXPECT().GetTransactionStatus(gomock.Any(), "TXN-CHALLENGE").
+			Return(&usecase.PaymentGatewayResponse{OrderID: "TXN-CHALLENGE", Status: "capture", FraudStatus: "challenge", PaymentType: "credit_card", GrossAmount: "100000.00"}, nil)
+
+		// Execute
+		results, err := transactionUseCase.ReconcilePendingTransactions(context.Background(), olderThan, 10)
+
+		// Assert
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.False(t, results[0].Updated)
+		assert.NoError(t, results[0].Err)
+		assert.Equal(t, entities.TransactionStatusProcessing, transaction.Status)
+	})
+
+	t.Run("payment with another method is not applied", func(t *testing.T) {
+		olderThan := time.Now()
+		transaction := newProcessingTopup("TXN-METHOD")
+		transaction.Metadata = map[string]string{entities.MetadataPaymentMethod: "va_bca"}
+
+		// Mock expectations: ProcessCallback must not be reached
+		mockTransactionRepo.EXPECT().GetPendingTransactions(gomock.Any(), entities.TransactionTypeTopup, olderThan, 10).
+			Return([]*entities.Transaction{transaction}, nil)
+		mockPaymentGateway.EXPECT().GetTransactionStatus(gomock.Any(), "TXN-METHOD").
+			Return(&usecase.PaymentGatewayResponse{OrderID: "TXN-METHOD", Status: "settlement", PaymentType: "gopay", GrossAmount: "100000.00"}, nil)
+
+		// Execute
+		results, err := transactionUseCase.ReconcilePendingTransactions(context.Background(), olderThan, 10)
+
+		// Assert
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.False(t, results[0].Updated)
+		assert.True(t, customerrors.IsValidationError(results[0].Err))
+		assert.Equal(t, entities.TransactionStatusProcessing, transaction.Status)
+	})
+}
+
+func TestTransactionUseCase_ExpireTransactions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Mock repositories
+	mockTransactionRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockLedgerRepo := mocks.NewMockLedgerRepository(ctrl)
+	mockTxManager := newPassThroughTxManager(ctrl)
+	mockPaymentGateway := mocks.NewMockPaymentGateway(ctrl)
+
+	// Create use case
+	cfg := newTransactionTestConfig()
+	transactionUseCase := usecase.NewTransactionUseCase(mockTransactionRepo, mockUserRepo, mockLedgerRepo, mockTxManager, mockPaymentGateway, newDefaultFeeUseCase(ctrl, cfg), newDefaultLimitUseCase(ctrl, mockTransactionRepo, mockUserRepo, cfg), cfg)
+
+	newOverdueTopup := func(reference, gatewayID string) *entities.Transaction {
+		transaction := &entities.Transaction{
+			ID:               uuid.New(),
+			UserID:           uuid.New(),
+			Type:             entities.TransactionTypeTopup,
+			Amount:           decimal.NewFromInt(100000),
+			Status:           entities.TransactionStatusProcessing,
+			Reference:        reference,
+			PaymentGatewayID: gatewayID,
+			Description:      "Balance top-up",
+			Metadata:         map[string]string{},
+			CreatedAt:        time.Now().Add(-2 * time.Hour),
+		}
+		transaction.SetExpiry(time.Hour)
+		return transaction
+	}
+
+	t.Run("abandoned topup is cancelled", func(t *testing.T) {
+		now := time.Now()
+		transaction := newOverdueTopup("TXN-ABANDONED", "snap-token")
+
+		// Mock expectations
+		mockTransactionRepo.EXPECT().GetExpiredTransactions(gomock.Any(), now, 100).Return([]*entities.Transaction{transaction}, nil)
+		mockPaymentGateway.EXPECT().GetTransactionStatus(gomock.Any(), "TXN-ABANDONED").
+			Return(nil, fmt.Errorf("%w: TXN-ABANDONED", usecase.ErrPaymentNotFound))
+		mockTransactionRepo.EXPECT().GetByReferenceForUpdate(gomock.Any(), "TXN-ABANDONED").Return(transaction, nil)
+		mockTransactionRepo.EXPECT().Update(gomock.Any(), transaction).Return(nil)
+
+		// Execute
+		results, err := transactionUseCase.ExpireTransactions(context.Background(), now, 100)
+
+		// Assert
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.True(t, results[0].Updated)
+		assert.NoError(t, results[0].Err)
+		assert.Equal(t, entities.TransactionStatusCancelled, transaction.Status)
+		assert.Equal(t, entities.CancelReasonExpired, transaction.Metadata[entities.MetadataCancelReason])
+	})
+
+	t.Run("topup paid before the deadline is completed", func(t *testing.T) {
+		now := time.Now()
+		transaction := newOverdueTopup("TXN-LATE", "snap-token")
+
+		// Mock expectations
+		mockTransactionRepo.EXPECT().GetExpiredTransactions(gomock.Any(), now, 100).Return([]*entities.Transaction{transaction}, nil)
+		mockPaymentGateway.EXPECT().GetTransactionStatus(gomock.Any(), "TXN-LATE").
+			Return(&usecase.PaymentGatewayResponse{OrderID: "TXN-LATE", Status: "settlement", GrossAmount: "100000.00"}, nil)
+		mockTransactionRepo.EXPECT().GetByReferenceForUpdate(gomock.Any(), "TXN-LATE").Return(transaction, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), transaction.UserID).Return(&entities.User{ID: transaction.UserID}, nil)
+		mockLedgerRepo.EXPECT().CreateEntry(gomock.Any(), gomock.Any()).Return(nil)
+		mockUserRepo.EXPECT().AddBalance(gomock.Any(), transaction.UserID, transaction.Amount).Return(nil)
+		mockTransactionRepo.EXPECT().Update(gomock.Any(), transaction).Return(nil)
+
+		// Execute
+		results, err := transactionUseCase.ExpireTransactions(context.Background(), now, 100)
+
+		// Assert
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.True(t, results[0].Updated)
+		assert.Equal(t, entities.TransactionStatusCompleted, transaction.Status)
+		assert.Empty(t, transaction.Metadata[entities.MetadataCancelReason])
+	})
+
+	t.Run("topup still pending at the gateway is left open", func(t *testing.T) {
+		now := time.Now()
+		transaction := newOverdueTopup("TXN-WAITING", "snap-token")
+
+		// Mock expectations: the transaction must not be cancelled while it can still be paid
+		mockTransactionRepo.EXPECT().GetExpiredTransactions(gomock.Any(), now, 100).Return([]*entities.Transaction{transaction}, nil)
+		mockPaymentGateway.EXPECT().GetTransactionStatus(gomock.Any(), "TXN-WAITING").
+			Return(&usecase.PaymentGatewayResponse{OrderID: "TXN-WAITING", Status: "pending"}, nil)
+
+		// Execute
+		results, err := transactionUseCase.ExpireTransactions(context.Background(), now, 100)
+
+		// Assert
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.False(t, results[0].Updated)
+		assert.NoError(t, results[0].Err)
+		assert.Equal(t, entities.TransactionStatusProcessing, transaction.Status)
+	})
+
+	t.Run("topup expired at the gateway is cancelled", func(t *testing.T) {
+		now := time.Now()
+		transaction := newOverdueTopup("TXN-EXPIRED", "snap-token")
+
+		// Mock expectations
+		mockTransactionRepo.EXPECT().GetExpiredTransactions(gomock.Any(), now, 100).Return([]*entities.Transaction{transaction}, nil)
+		mockPaymentGateway.EXPECT().GetTransactionStatus(gomock.Any(), "TXN-EXPIRED").
+			Return(&usecase.PaymentGatewayResponse{OrderID: "TXN-EXPIRED", Status: "expire", GrossAmount: "100000.00"}, nil)
+		mockTransactionRepo.EXPECT().GetByReferenceForUpdate(gomock.Any(), "TXN-EXPIRED").Return(transaction, nil)
+		mockTransactionRepo.EXPECT().Update(gomock.Any(), transaction).Return(nil)
+
+		// Execute
+		results, err := transactionUseCase.ExpireTransactions(context.Background(), now, 100)
+
+		// Assert
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.True(t, results[0].Updated)
+		assert.Equal(t, entities.TransactionStatusCancelled, transaction.Status)
+	})
+
+	t.Run("topup expired locally, then settled is held for review", func(t *testing.T) {
+		transaction := newOverdueTopup("TXN-PAID-LATE", "snap-token")
+		transaction.Metadata[entities.MetadataCancelReason] = entities.CancelReasonExpired
+		transaction.MarkAsCancelled()
+
+		// Mock expectations: nothing is credited until a reviewer approves
+		mockTransactionRepo.EXPECT().GetByReferenceForUpdate(gomock.Any(), "TXN-PAID-LATE").Return(transaction, nil)
+		mockTransactionRepo.EXPECT().Update(gomock.Any(), transaction).Return(nil)
+
+		// Execute
+		err := transactionUseCase.ProcessCallback(context.Background(), "TXN-PAID-LATE", entities.TransactionStatusCompleted)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, entities.TransactionStatusReview, transaction.Status)
+		assert.Equal(t, entities.ReviewReasonPaidAfterExpiry, transaction.Metadata[entities.MetadataReviewReason])
+		assert.Empty(t, transaction.Metadata[entities.MetadataCancelReason])
+	})
+
+	t.Run("gateway unreachable leaves transaction untouched", func(t *testing.T) {
+		now := time.Now()
+		transaction := newOverdueTopup("TXN-UNKNOWN", "snap-token")
+
+		// Mock expectations
+		mockTransactionRepo.EXPECT().GetExpiredTransactions(gomock.Any(), now, 100).Return([]*entities.Transaction{transaction}, nil)
+		mockPaymentGateway.EXPECT().GetTransactionStatus(gomock.Any(), "TXN-UNKNOWN").Return(nil, errors.New("connection refused"))
+
+		// Execute
+		results, err := transactionUseCase.ExpireTransactions(context.Background(), now, 100)
+
+		// Assert
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.False(t, results[0].Updated)
+		assert.Error(t, results[0].Err)
+		assert.Equal(t, entities.TransactionStatusProcessing, transaction.Status)
+	})
+}
+
+func TestTransactionUseCase_GetTransactionDetail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Mock repositories
+	mockTransactionRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockLedgerRepo := mocks.NewMockLedgerRepository(ctrl)
+	mockTxManager := newPassThroughTxManager(ctrl)
+	mockPaymentGateway := mocks.NewMockPaymentGateway(ctrl)
+
+	// Create use case
+	cfg := newTransactionTestConfig()
+	transactionUseCase := usecase.NewTransactionUseCase(mockTransactionRepo, mockUserRepo, mockLedgerRepo, mockTxManager, mockPaymentGateway, newDefaultFeeUseCase(ctrl, cfg), newDefaultLimitUseCase(ctrl, mockTransactionRepo, mockUserRepo, cfg), cfg)
+
+	sender := &entities.User{ID: uuid.New(), FirstName: "John", LastName: "Sender", Status: entities.UserStatusActive}
+	recipient := &entities.User{ID: uuid.New(), FirstName: "Jane", LastName: "Recipient", Status: entities.UserStatusActive}
+
+	payment := &entities.Transaction{
+		ID:                 uuid.New(),
+		UserID:             sender.ID,
+		CounterpartyUserID: &recipient.ID,
+		Type:               entities.TransactionTypePayment,
+		Direction:          entities.TransactionDirectionOutgoing,
+		Amount:             decimal.NewFromFloat(100.00),
+		Status:             entities.TransactionStatusCompleted,
+		Reference:          "TXN-12345678",
+		Description:        "Payment for services",
+		Metadata:           map[string]string{},
+	}
+
+	timeline := []entities.TransactionStatusChange{
+		{ToStatus: entities.TransactionStatusPending, ChangedAt: time.Now().Add(-time.Second)},
+		{FromStatus: entities.TransactionStatusPending, ToStatus: entities.TransactionStatusCompleted, ChangedAt: time.Now()},
+	}
+
+	t.Run("sender sees recipient as counterparty", func(t *testing.T) {
+		// Mock expectations
+		mockTransactionRepo.EXPECT().GetByID(gomock.Any(), payment.ID).Return(payment, nil)
+		mockUserRepo.EXPECT().GetByID(gomock.Any(), recipient.ID).Return(recipient, nil)
+		mockTransactionRepo.EXPECT().GetStatusHistory(gomock.Any(), payment.ID).Return(timeline, nil)
+
+		// Execute
+		detail, err := transactionUseCase.GetTransactionDetail(context.Background(), sender.ID, payment.ID)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, payment.Reference, detail.Reference)
+		require.NotNil(t, detail.Counterparty)
+		assert.Equal(t, recipient.ID, detail.Counterparty.ID)
+		assert.Equal(t, "Jane", detail.Counterparty.FirstName)
+		assert.Equal(t, entities.TransactionDirectionOutgoing, detail.Direction)
+		assert.True(t, decimal.NewFromFloat(-100.00).Equal(detail.SignedAmount))
+		assert.Equal(t, timeline, detail.Timeline)
+	})
+
+	t.Run("recipient sees sender as counterparty", func(t *testing.T) {
+		// Mock expectations
+		mockTransactionRepo.EXPECT().GetByReference(gomock.Any(), payment.Reference).Return(payment, nil)
+		mockUserRepo.EXPECT().GetByID(gomock.Any(), sender.ID).Return(sender, nil)
+		mockTransactionRepo.EXPECT().GetStatusHistory(gomock.Any(), payment.ID).Return(timeline, nil)
+
+		// Execute
+		detail, err := transactionUseCase.GetTransactionDetailByReference(context.Background(), recipient.ID, payment.Reference)
+
+		// Assert
+		require.NoError(t, err)
+		require.NotNil(t, detail.Counterparty)
+		assert.Equal(t, sender.ID, detail.Counterparty.ID)
+		assert.Equal(t, entities.TransactionDirectionIncoming, detail.Direction)
+		assert.True(t, decimal.NewFromFloat(100.00).Equal(detail.SignedAmount))
+	})
+
+	t.Run("other user cannot read the transaction", func(t *testing.T) {
+		// Mock expectations
+		mockTransactionRepo.EXPECT().GetByID(gomock.Any(), payment.ID).Return(payment, nil)
+
+		// Execute
+		detail, err := transactionUseCase.GetTransactionDetail(context.Background(), uuid.New(), payment.ID)
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, detail)
+		assert.True(t, customerrors.IsNotFoundError(err))
+	})
+
+	t.Run("topup has no counterparty", func(t *testing.T) {
+		topup := &entities.Transaction{
+			ID:        uuid.New(),
+			UserID:    sender.ID,
+			Type:      entities.TransactionTypeTopup,
+			Amount:    decimal.NewFromFloat(50.00),
+			Status:    entities.TransactionStatusProcessing,
+			Reference: "TXN-87654321",
+			Metadata:  map[string]string{},
+		}
+
+		// Mock expectations
+		mockTransactionRepo.EXPECT().GetByID(gomock.Any(), topup.ID).Return(topup, nil)
+		mockTransactionRepo.EXPECT().GetStatusHistory(gomock.Any(), topup.ID).Return(nil, nil)
+
+		// Execute
+		detail, err := transactionUseCase.GetTransactionDetail(context.Background(), sender.ID, topup.ID)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Nil(t, detail.Counterparty)
+		assert.NotNil(t, detail.Timeline)
+	})
+
+	t.Run("transaction not found", func(t *testing.T) {
+		transactionID := uuid.New()
+
+		// Mock expectations
+		mockTransactionRepo.EXPECT().GetByID(gomock.Any(), transactionID).Return(nil, customerrors.NewNotFoundError("Transaction not found"))
+
+		// Execute
+		_, err := transactionUseCase.GetTransactionDetail(context.Background(), sender.ID, transactionID)
+
+		// Assert
+		require.Error(t, err)
+		assert.True(t, customerrors.IsNotFoundError(err))
+	})
+}
+
+func TestTransactionUseCase_GetTransactionHistory(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Mock repositories
+	mockTransactionRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockLedgerRepo := mocks.NewMockLedgerRepository(ctrl)
+	mockTxManager := newPassThroughTxManager(ctrl)
+	mockPaymentGateway := mocks.NewMockPaymentGateway(ctrl)
+
+	// Create use case
+	cfg := newTransactionTestConfig()
+	transactionUseCase := usecase.NewTransactionUseCase(mockTransactionRepo, mockUserRepo, mockLedgerRepo, mockTxManager, mockPaymentGateway, newDefaultFeeUseCase(ctrl, cfg), newDefaultLimitUseCase(ctrl, mockTransactionRepo, mockUserRepo, cfg), cfg)
+
+	userID := uuid.New()
+	newTopup := func(createdAt time.Time) *entities.Transaction {
+		return &entities.Transaction{
+			ID:        uuid.New(),
+			UserID:    userID,
+			Type:      entities.TransactionTypeTopup,
+			Amount:    decimal.NewFromFloat(100.00),
+			Status:    entities.TransactionStatusCompleted,
+			Reference: "TXN-" + uuid.NewString()[:8],
+			CreatedAt: createdAt,
+		}
+	}
+
+	t.Run("first page with more results", func(t *testing.T) {
+		now := time.Now()
+		filter := entities.TransactionFilter{Type: entities.TransactionTypeTopup, Status: entities.TransactionStatusCompleted}
+		rows := []*entities.Transaction{newTopup(now), newTopup(now.Add(-time.Minute)), newTopup(now.Add(-2 * time.Minute))}
+
+		// Mock expectations: one extra row is requested to detect the next page
+		mockTransactionRepo.EXPECT().GetByUserID(gomock.Any(), userID, filter, entities.TransactionPageRequest{Limit: 3}).Return(rows, nil)
+		mockTransactionRepo.EXPECT().CountByUserID(gomock.Any(), userID, filter).Return(7, nil)
+
+		// Execute
+		response, err := transactionUseCase.GetTransactionHistory(context.Background(), userID, filter, entities.TransactionPageRequest{Limit: 2})
+
+		// Assert
+		require.NoError(t, err)
+		assert.Len(t, response.Transactions, 2)
+		assert.Equal(t, 2, response.Pagination.Count)
+		assert.Equal(t, 7, response.Pagination.Total)
+		assert.True(t, response.Pagination.HasMore)
+
+		cursor, err := entities.DecodeTransactionCursor(response.Pagination.NextCursor)
+		require.NoError(t, err)
+		assert.Equal(t, rows[1].ID, cursor.ID)
+		assert.True(t, rows[1].CreatedAt.Equal(cursor.CreatedAt))
+	})
+
+	t.Run("last page", func(t *testing.T) {
+		cursor := &entities.TransactionCursor{CreatedAt: time.Now(), ID: uuid.New()}
+		rows := []*entities.Transaction{newTopup(time.Now().Add(-time.Hour))}
+
+		// Mock expectations
+		mockTransactionRepo.EXPECT().GetByUserID(gomock.Any(), userID, entities.TransactionFilter{}, entities.TransactionPageRequest{Limit: 11, Cursor: cursor}).Return(rows, nil)
+		mockTransactionRepo.EXPECT().CountByUserID(gomock.Any(), userID, entities.TransactionFilter{}).Return(11, nil)
+
+		// Execute
+		response, err := transactionUseCase.GetTransactionHistory(context.Background(), userID, entities.TransactionFilter{}, entities.TransactionPageRequest{Cursor: cursor})
+
+		// Assert
+		require.NoError(t, err)
+		assert.Len(t, response.Transactions, 1)
+		assert.Equal(t, 10, response.Pagination.Limit)
+		assert.False(t, response.Pagination.HasMore)
+		assert.Empty(t, response.Pagination.NextCursor)
+	})
+
+	t.Run("incoming payment in recipient history", func(t *testing.T) {
+		senderID := uuid.New()
+		payment := &entities.Transaction{
+			ID:                 uuid.New(),
+			UserID:             senderID,
+			CounterpartyUserID: &userID,
+			Type:               entities.TransactionTypePayment,
+			Direction:          entities.TransactionDirectionOutgoing,
+			Amount:             decimal.NewFromFloat(25.00),
+			Status:             entities.TransactionStatusCompleted,
+			Reference:          "TXN-11112222",
+			CreatedAt:          time.Now(),
+		}
+		filter := entities.TransactionFilter{Direction: entities.TransactionDirectionIncoming}
+
+		// Mock expectations
+		mockTransactionRepo.EXPECT().GetByUserID(gomock.Any(), userID, filter, entities.TransactionPageRequest{Limit: 11}).Return([]*entities.Transaction{payment}, nil)
+		mockTransactionRepo.EXPECT().CountByUserID(gomock.Any(), userID, filter).Return(1, nil)
+
+		// Execute
+		response, err := transactionUseCase.GetTransactionHistory(context.Background(), userID, filter, entities.TransactionPageRequest{})
+
+		// Assert
+		require.NoError(t, err)
+		require.Len(t, response.Transactions, 1)
+		item := response.Transactions[0]
+		assert.Equal(t, entities.TransactionDirectionIncoming, item.Direction)
+		assert.True(t, decimal.NewFromFloat(25.00).Equal(item.SignedAmount))
+		require.NotNil(t, item.CounterpartyUserID)
+		assert.Equal(t, senderID, *item.CounterpartyUserID)
+	})
+
+	t.Run("invalid filter", func(t *testing.T) {
+		minAmount := decimal.NewFromInt(100)
+		maxAmount := decimal.NewFromInt(10)
+		filter := entities.TransactionFilter{MinAmount: &minAmount, MaxAmount: &maxAmount}
+
+		// Execute
+		response, err := transactionUseCase.GetTransactionHistory(context.Background(), userID, filter, entities.TransactionPageRequest{})
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, response)
+		assert.True(t, customerrors.IsValidationError(err))
+	})
+}
+
+func TestTransactionUseCase_ManualReview(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Mock repositories
+	mockTransactionRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockLedgerRepo := mocks.NewMockLedgerRepository(ctrl)
+	mockTxManager := newPassThroughTxManager(ctrl)
+	mockPaymentGateway := mocks.NewMockPaymentGateway(ctrl)
+
+	// Payments of 1000 or more wait for review
+	cfg := newTransactionTestConfig()
+	cfg.Payment = config.TransactionPolicy{ReviewThreshold: decimal.NewFromInt(1000)}
+
+	// Create use case
+	transactionUseCase := usecase.NewTransactionUseCase(mockTransactionRepo, mockUserRepo, mockLedgerRepo, mockTxManager, mockPaymentGateway, newDefaultFeeUseCase(ctrl, cfg), newDefaultLimitUseCase(ctrl, mockTransactionRepo, mockUserRepo, cfg), cfg)
+
+	reviewerID := uuid.New()
+	newUsers := func(balance int64) (*entities.User, *entities.User) {
+		sender := &entities.User{ID: uuid.New(), Balance: decimal.NewFromInt(balance), Status: entities.UserStatusActive, PINHash: testPINHash}
+		recipient := &entities.User{ID: uuid.New(), Status: entities.UserStatusActive}
+		return sender, recipient
+	}
+	newReviewTransaction := func(sender, recipient *entities.User, amount int64) *entities.Transaction {
+		transaction := entities.NewTransaction(sender.ID, entities.TransactionTypePayment, decimal.NewFromInt(amount), "Large payment")
+		transaction.CounterpartyUserID = &recipient.ID
+		transaction.MarkForReview()
+		return transaction
+	}
+
+	t.Run("payment at threshold is held for review", func(t *testing.T) {
+		sender, recipient := newUsers(5000)
+		req := entities.PaymentRequest{Amount: decimal.NewFromInt(1000), PIN: testPIN, Description: "Large payment", ToUserID: recipient.ID}
+
+		// Mock expectations: no balance change, no ledger entry
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), sender.ID).Return(sender, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), recipient.ID).Return(recipient, nil)
+		mockTransactionRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, transaction *entities.Transaction) error {
+				assert.Equal(t, entities.TransactionStatusReview, transaction.Status)
+				return nil
+			})
+
+		// Execute
+		response, err := transactionUseCase.ProcessPayment(context.Background(), sender.ID, req)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, entities.TransactionStatusReview, response.Status)
+	})
+
+	t.Run("approve settles the transaction", func(t *testing.T) {
+		sender, recipient := newUsers(5000)
+		transaction := newReviewTransaction(sender, recipient, 2000)
+
+		// Mock expectations
+		mockTransactionRepo.EXPECT().GetByIDForUpdate(gomock.Any(), transaction.ID).Return(transaction, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), sender.ID).Return(sender, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), recipient.ID).Return(recipient, nil)
+		mockUserRepo.EXPECT().SubtractBalance(gomock.Any(), sender.ID, decimalEq{decimal.NewFromInt(2000)}).Return(nil)
+		mockUserRepo.EXPECT().AddBalance(gomock.Any(), recipient.ID, decimalEq{decimal.NewFromInt(2000)}).Return(nil)
+		mockLedgerRepo.EXPECT().CreateEntry(gomock.Any(), gomock.Any()).Return(nil)
+		mockTransactionRepo.EXPECT().Update(gomock.Any(), transaction).Return(nil)
+
+		// Execute
+		response, err := transactionUseCase.ApproveTransaction(context.Background(), reviewerID, transaction.ID, "Checked with customer")
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, entities.TransactionStatusCompleted, response.Status)
+		assert.Equal(t, reviewerID.String(), transaction.Metadata[entities.MetadataReviewedBy])
+		assert.Equal(t, "Checked with customer", transaction.Metadata[entities.MetadataReviewNote])
+	})
+
+	t.Run("approve fails when balance dropped", func(t *testing.T) {
+		sender, recipient := newUsers(500)
+		transaction := newReviewTransaction(sender, recipient, 2000)
+
+		// Mock expectations
+		mockTransactionRepo.EXPECT().GetByIDForUpdate(gomock.Any(), transaction.ID).Return(transaction, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), sender.ID).Return(sender, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), recipient.ID).Return(recipient, nil)
+
+		// Execute
+		response, err := transactionUseCase.ApproveTransaction(context.Background(), reviewerID, transaction.ID, "")
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, response)
+		assert.True(t, customerrors.IsValidationError(err))
+	})
+
+	t.Run("reject cancels the transaction", func(t *testing.T) {
+		sender, recipient := newUsers(5000)
+		transaction := newReviewTransaction(sender, recipient, 2000)
+
+		// Mock expectations: no balance change, no ledger entry
+		mockTransactionRepo.EXPECT().GetByIDForUpdate(gomock.Any(), transaction.ID).Return(transaction, nil)
+		mockTransactionRepo.EXPECT().Update(gomock.Any(), transaction).Return(nil)
+
+		// Execute
+		response, err := transactionUseCase.RejectTransaction(context.Background(), reviewerID, transaction.ID, "Suspected fraud")
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, entities.TransactionStatusCancelled, response.Status)
+		assert.Equal(t, entities.CancelReasonRejected, transaction.Metadata[entities.MetadataCancelReason])
+		assert.Equal(t, "Suspected fraud", transaction.Metadata[entities.MetadataReviewNote])
+	})
+
+	t.Run("transaction not under review", func(t *testing.T) {
+		sender, recipient := newUsers(5000)
+		transaction := newReviewTransaction(sender, recipient, 2000)
+		transaction.MarkAsCompleted()
+
+		// Mock expectations
+		mockTransactionRepo.EXPECT().GetByIDForUpdate(gomock.Any(), transaction.ID).Return(transaction, nil)
+
+		// Execute
+		response, err := transactionUseCase.RejectTransaction(context.Background(), reviewerID, transaction.ID, "Too late")
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, response)
+		assert.True(t, customerrors.IsConflictError(err))
+	})
+}
+
+func TestTransactionUseCase_RefundTransaction(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Mock repositories
+	mockTransactionRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockLedgerRepo := mocks.NewMockLedgerRepository(ctrl)
+	mockTxManager := newPassThroughTxManager(ctrl)
+	mockPaymentGateway := mocks.NewMockPaymentGateway(ctrl)
+
+	// Create use case
+	cfg := newTransactionTestConfig()
+	transactionUseCase := usecase.NewTransactionUseCase(mockTransactionRepo, mockUserRepo, mockLedgerRepo, mockTxManager, mockPaymentGateway, newDefaultFeeUseCase(ctrl, cfg), newDefaultLimitUseCase(ctrl, mockTransactionRepo, mockUserRepo, cfg), cfg)
+
+	adminID := uuid.New()
+	newCompletedPayment := func(payer, merchant *entities.User, amount int64) *entities.Transaction {
+		transaction := entities.NewTransaction(payer.ID, entities.TransactionTypePayment, decimal.NewFromInt(amount), "Payment for order")
+		transaction.CounterpartyUserID = &merchant.ID
+		transaction.MarkAsCompleted()
+		return transaction
+	}
+	newCompletedTopup := func(user *entities.User, amount int64) *entities.Transaction {
+		transaction := entities.NewTransaction(user.ID, entities.TransactionTypeTopup, decimal.NewFromInt(amount), "Balance top-up")
+		transaction.MarkAsCompleted()
+		return transaction
+	}
+
+	t.Run("partial payment refund moves funds back from the recipient", func(t *testing.T) {
+		payer := &entities.User{ID: uuid.New(), Status: entities.UserStatusActive}
+		merchant := &entities.User{ID: uuid.New(), Balance: decimal.NewFromInt(5000), Status: entities.UserStatusActive}
+		payment := newCompletedPayment(payer, merchant, 1000)
+
+		// Mock expectations
+		var refund *entities.Transaction
+		mockTransactionRepo.EXPECT().GetByIDForUpdate(gomock.Any(), payment.ID).Return(payment, nil)
+		mockTransactionRepo.EXPECT().SumRefundedAmount(gomock.Any(), payment.ID).Return(decimal.NewFromInt(300), nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), payer.ID).Return(payer, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), merchant.ID).Return(merchant, nil)
+		mockTransactionRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, transaction *entities.Transaction) error {
+				refund = transaction
+				return nil
+			})
+		mockUserRepo.EXPECT().SubtractBalance(gomock.Any(), merchant.ID, decimalEq{decimal.NewFromInt(400)}).Return(nil)
+		mockUserRepo.EXPECT().AddBalance(gomock.Any(), payer.ID, decimalEq{decimal.NewFromInt(400)}).Return(nil)
+		mockLedgerRepo.EXPECT().CreateEntry(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, entry *entities.JournalEntry) error {
+				require.NoError(t, entry.Validate())
+				deltas := walletDeltas(entry)
+				assert.True(t, deltas[merchant.ID].Equal(decimal.NewFromInt(-400)))
+				assert.True(t, deltas[payer.ID].Equal(decimal.NewFromInt(400)))
+				return nil
+			})
+		mockTransactionRepo.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil)
+
+		// Execute
+		response, err := transactionUseCase.RefundTransaction(context.Background(), adminID, payment.ID, entities.RefundTransactionRequest{
+			Amount: decimal.NewFromInt(400),
+			Reason: "Item out of stock",
+		})
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, entities.TransactionStatusCompleted, response.Status)
+		require.NotNil(t, refund)
+		assert.Equal(t, entities.TransactionTypeRefund, refund.Type)
+		assert.Equal(t, payment.ID, *refund.ParentTransactionID)
+		assert.Equal(t, entities.TransactionDirectionIncoming, refund.DirectionFor(payer.ID))
+		assert.Equal(t, entities.TransactionDirectionOutgoing, refund.DirectionFor(merchant.ID))
+		assert.Equal(t, adminID.String(), refund.Metadata[entities.MetadataRefundedBy])
+		assert.Equal(t, "Item out of stock", refund.Metadata[entities.MetadataRefundReason])
+	})
+
+	t.Run("refund above the remaining amount", func(t *testing.T) {
+		payer := &entities.User{ID: uuid.New(), Status: entities.UserStatusActive}
+		merchant := &entities.User{ID: uuid.New(), Balance: decimal.NewFromInt(5000), Status: entities.UserStatusActive}
+		payment := newCompletedPayment(payer, merchant, 1000)
+
+		// Mock expectations: nothing is created
+		mockTransactionRepo.EXPECT().GetByIDForUpdate(gomock.Any(), payment.ID).Return(payment, nil)
+		mockTransactionRepo.EXPECT().SumRefundedAmount(gomock.Any(), payment.ID).Return(decimal.NewFromInt(700), nil)
+
+		// Execute
+		response, err := transactionUseCase.RefundTransaction(context.Background(), adminID, payment.ID, entities.RefundTransactionRequest{
+			Amount: decimal.NewFromInt(301),
+			Reason: "Duplicate charge",
+		})
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, response)
+		assert.True(t, customerrors.IsValidationError(err))
+	})
+
+	t.Run("fully refunded transaction", func(t *testing.T) {
+		payer := &entities.User{ID: uuid.New(), Status: entities.UserStatusActive}
+		merchant := &entities.User{ID: uuid.New(), Status: entities.UserStatusActive}
+		payment := newCompletedPayment(payer, merchant, 1000)
+
+		// Mock expectations
+		mockTransactionRepo.EXPECT().GetByIDForUpdate(gomock.Any(), payment.ID).Return(payment, nil)
+		mockTransactionRepo.EXPECT().SumRefundedAmount(gomock.Any(), payment.ID).Return(decimal.NewFromInt(1000), nil)
+
+		// Execute
+		response, err := transactionUseCase.RefundTransaction(context.Background(), adminID, payment.ID, entities.RefundTransactionRequest{Reason: "Again"})
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, response)
+		assert.True(t, customerrors.IsConflictError(err))
+	})
+
+	t.Run("transaction that is not completed", func(t *testing.T) {
+		user := &entities.User{ID: uuid.New(), Status: entities.UserStatusActive}
+		topup := entities.NewTransaction(user.ID, entities.TransactionTypeTopup, decimal.NewFromInt(1000), "Balance top-up")
+		topup.MarkAsProcessing()
+
+		// Mock expectations
+		mockTransactionRepo.EXPECT().GetByIDForUpdate(gomock.Any(), topup.ID).Return(topup, nil)
+
+		// Execute
+		response, err := transactionUseCase.RefundTransaction(context.Background(), adminID, topup.ID, entities.RefundTransactionRequest{Reason: "Not paid yet"})
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, response)
+		assert.True(t, customerrors.IsConflictError(err))
+	})
+
+	t.Run("full topup reversal is refunded through the gateway", func(t *testing.T) {
+		user := &entities.User{ID: uuid.New(), Balance: decimal.NewFromInt(2000), Status: entities.UserStatusActive}
+		topup := newCompletedTopup(user, 1500)
+
+		// Mock expectations
+		var refund *entities.Transaction
+		mockTransactionRepo.EXPECT().GetByIDForUpdate(gomock.Any(), topup.ID).Return(topup, nil)
+		mockTransactionRepo.EXPECT().SumRefundedAmount(gomock.Any(), topup.ID).Return(decimal.Zero, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil)
+		mockTransactionRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, transaction *entities.Transaction) error {
+				refund = transaction
+				return nil
+			})
+		mockUserRepo.EXPECT().SubtractBalance(gomock.Any(), user.ID, decimalEq{decimal.NewFromInt(1500)}).Return(nil)
+		mockLedgerRepo.EXPECT().CreateEntry(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, entry *entities.JournalEntry) error {
+				require.NoError(t, entry.Validate())
+				assert.True(t, walletDeltas(entry)[user.ID].Equal(decimal.NewFromInt(-1500)))
+				return nil
+			})
+		mockTransactionRepo.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+		mockPaymentGateway.EXPECT().Refund(gomock.Any(), topup.Reference, gomock.Any(), "1500", "Settled twice").DoAndReturn(
+			func(_ context.Context, orderID, refundKey, amount, reason string) (*usecase.PaymentGatewayResponse, error) {
+				assert.Equal(t, refund.Reference, refundKey)
+				assert.Equal(t, entities.TransactionStatusProcessing, refund.Status)
+				return &usecase.PaymentGatewayResponse{OrderID: orderID, Status: "refund"}, nil
+			})
+
+		// Execute
+		response, err := transactionUseCase.RefundTransaction(context.Background(), adminID, topup.ID, entities.RefundTransactionRequest{Reason: "Settled twice"})
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, entities.TransactionStatusCompleted, response.Status)
+		assert.True(t, response.Amount.Equal(decimal.NewFromInt(1500)))
+		assert.Equal(t, entities.TransactionDirectionOutgoing, refund.Direction)
+	})
+
+	t.Run("gateway refusal returns the amount to the wallet", func(t *testing.T) {
+		user := &entities.User{ID: uuid.New(), Balance: decimal.NewFromInt(2000), Status: entities.UserStatusActive}
+		topup := newCompletedTopup(user, 1500)
+
+		// Mock expectations
+		var refund *entities.Transaction
+		mockTransactionRepo.EXPECT().GetByIDForUpdate(gomock.Any(), topup.ID).Return(topup, nil)
+		mockTransactionRepo.EXPECT().SumRefundedAmount(gomock.Any(), topup.ID).Return(decimal.Zero, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil)
+		mockTransactionRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, transaction *entities.Transaction) error {
+				refund = transaction
+				return nil
+			})
+		mockUserRepo.EXPECT().SubtractBalance(gomock.Any(), user.ID, decimalEq{decimal.NewFromInt(500)}).Return(nil)
+		mockLedgerRepo.EXPECT().CreateEntry(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+		mockTransactionRepo.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+		mockPaymentGateway.EXPECT().Refund(gomock.Any(), topup.Reference, gomock.Any(), "500", "Partial reversal").Return(nil, errors.New("refund window closed"))
+		mockTransactionRepo.EXPECT().GetByIDForUpdate(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, id uuid.UUID) (*entities.Transaction, error) {
+				assert.Equal(t, refund.ID, id)
+				return refund, nil
+			})
+		mockUserRepo.EXPECT().AddBalance(gomock.Any(), user.ID, decimalEq{decimal.NewFromInt(500)}).Return(nil)
+
+		// Execute
+		response, err := transactionUseCase.RefundTransaction(context.Background(), adminID, topup.ID, entities.RefundTransactionRequest{
+			Amount: decimal.NewFromInt(500),
+			Reason: "Partial reversal",
+		})
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, response)
+		assert.True(t, customerrors.IsInternalError(err))
+		assert.Equal(t, entities.TransactionStatusFailed, refund.Status)
+	})
+
+	t.Run("topup reversal with insufficient balance", func(t *testing.T) {
+		user := &entities.User{ID: uuid.New(), Balance: decimal.NewFromInt(100), Status: entities.UserStatusActive}
+		topup := newCompletedTopup(user, 1500)
+
+		// Mock expectations: nothing is created
+		mockTransactionRepo.EXPECT().GetByIDForUpdate(gomock.Any(), topup.ID).Return(topup, nil)
+		mockTransactionRepo.EXPECT().SumRefundedAmount(gomock.Any(), topup.ID).Return(decimal.Zero, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil)
+
+		// Execute
+		response, err := transactionUseCase.RefundTransaction(context.Background(), adminID, topup.ID, entities.RefundTransactionRequest{Reason: "Settled twice"})
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, response)
+		assert.True(t, customerrors.IsValidationError(err))
+	})
+}
+
+func TestTransactionUseCase_AuthorizeCapture(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Mock repositories
+	mockTransactionRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockLedgerRepo := mocks.NewMockLedgerRepository(ctrl)
+	mockTxManager := newPassThroughTxManager(ctrl)
+	mockPaymentGateway := mocks.NewMockPaymentGateway(ctrl)
+
+	// Create use case
+	cfg := newTransactionTestConfig()
+	transactionUseCase := usecase.NewTransactionUseCase(mockTransactionRepo, mockUserRepo, mockLedgerRepo, mockTxManager, mockPaymentGateway, newDefaultFeeUseCase(ctrl, cfg), newDefaultLimitUseCase(ctrl, mockTransactionRepo, mockUserRepo, cfg), cfg)
+
+	newAuthorization := func(payer, merchant *entities.User, amount int64) *entities.Transaction {
+		transaction := entities.NewTransaction(payer.ID, entities.TransactionTypePayment, decimal.NewFromInt(amount), "Hotel deposit")
+		transaction.CounterpartyUserID = &merchant.ID
+		transaction.MarkAsAuthorized(time.Hour)
+		return transaction
+	}
+
+	t.Run("authorization holds the amount without moving funds", func(t *testing.T) {
+		payer := &entities.User{ID: uuid.New(), Balance: decimal.NewFromInt(1000), Status: entities.UserStatusActive, PINHash: testPINHash}
+		merchant := &entities.User{ID: uuid.New(), Status: entities.UserStatusActive}
+
+		// Mock expectations
+		var created *entities.Transaction
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), payer.ID).Return(payer, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), merchant.ID).Return(merchant, nil)
+		mockTransactionRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, transaction *entities.Transaction) error {
+				created = transaction
+				return nil
+			})
+		mockUserRepo.EXPECT().HoldBalance(gomock.Any(), payer.ID, decimalEq{decimal.NewFromInt(400)}).Return(nil)
+
+		// Execute
+		response, err := transactionUseCase.AuthorizePayment(context.Background(), payer.ID, entities.AuthorizePaymentRequest{
+			Amount:           decimal.NewFromInt(400),
+			ToUserID:         merchant.ID,
+			PIN:              testPIN,
+			ExpiresInMinutes: 60,
+		})
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, entities.TransactionStatusAuthorized, response.Status)
+		require.NotNil(t, created.ExpiresAt)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), *created.ExpiresAt, time.Minute)
+	})
+
+	t.Run("held funds cannot be authorized again", func(t *testing.T) {
+		payer := &entities.User{ID: uuid.New(), Balance: decimal.NewFromInt(1000), HeldBalance: decimal.NewFromInt(700), Status: entities.UserStatusActive, PINHash: testPINHash}
+		merchant := &entities.User{ID: uuid.New(), Status: entities.UserStatusActive}
+
+		// Mock expectations
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), payer.ID).Return(payer, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), merchant.ID).Return(merchant, nil)
+
+		// Execute
+		_, err := transactionUseCase.AuthorizePayment(context.Background(), payer.ID, entities.AuthorizePaymentRequest{
+			Amount:   decimal.NewFromInt(400),
+			ToUserID: merchant.ID,
+			PIN:      testPIN,
+		})
+
+		// Assert
+		require.Error(t, err)
+		assert.True(t, customerrors.IsValidationError(err))
+	})
+
+	t.Run("authorization longer than the maximum is refused", func(t *testing.T) {
+		// Execute
+		_, err := transactionUseCase.AuthorizePayment(context.Background(), uuid.New(), entities.AuthorizePaymentRequest{
+			Amount:           decimal.NewFromInt(400),
+			ToUserID:         uuid.New(),
+			PIN:              testPIN,
+			ExpiresInMinutes: 8 * 24 * 60,
+		})
+
+		// Assert
+		require.Error(t, err)
+		assert.True(t, customerrors.IsValidationError(err))
+	})
+
+	t.Run("partial capture releases the hold and charges the captured amount", func(t *testing.T) {
+		payer := &entities.User{ID: uuid.New(), Balance: decimal.NewFromInt(1000), HeldBalance: decimal.NewFromInt(400), Status: entities.UserStatusActive}
+		merchant := &entities.User{ID: uuid.New(), Status: entities.UserStatusActive}
+		authorization := newAuthorization(payer, merchant, 400)
+
+		// Mock expectations
+		mockTransactionRepo.EXPECT().GetByIDForUpdate(gomock.Any(), authorization.ID).Return(authorization, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), payer.ID).Return(payer, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), merchant.ID).Return(merchant, nil)
+		mockUserRepo.EXPECT().ReleaseHold(gomock.Any(), payer.ID, decimalEq{decimal.NewFromInt(400)}).Return(nil)
+		mockUserRepo.EXPECT().SubtractBalance(gomock.Any(), payer.ID, decimalEq{decimal.NewFromInt(250)}).Return(nil)
+		mockUserRepo.EXPECT().AddBalance(gomock.Any(), merchant.ID, decimalEq{decimal.NewFromInt(250)}).Return(nil)
+		mockLedgerRepo.EXPECT().CreateEntry(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, entry *entities.JournalEntry) error {
+				require.NoError(t, entry.Validate())
+				deltas := walletDeltas(entry)
+				assert.True(t, deltas[payer.ID].Equal(decimal.NewFromInt(-250)))
+				assert.True(t, deltas[merchant.ID].Equal(decimal.NewFromInt(250)))
+				return nil
+			})
+		mockTransactionRepo.EXPECT().Update(gomock.Any(), authorization).Return(nil)
+
+		// Execute
+		response, err := transactionUseCase.CapturePayment(context.Background(), merchant.ID, authorization.ID, entities.CapturePaymentRequest{Amount: decimal.NewFromInt(250)})
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, entities.TransactionStatusCompleted, response.Status)
+		assert.True(t, response.Amount.Equal(decimal.NewFromInt(250)))
+		assert.Equal(t, "400", authorization.Metadata[entities.MetadataAuthorizedAmount])
+	})
+
+	t.Run("partial capture pro-rates the fee held at authorization", func(t *testing.T) {
+		payer := &entities.User{ID: uuid.New(), Balance: decimal.NewFromInt(1000), HeldBalance: decimal.NewFromInt(410), Status: entities.UserStatusActive}
+		merchant := &entities.User{ID: uuid.New(), Status: entities.UserStatusActive}
+		authorization := newAuthorization(payer, merchant, 400)
+		authorization.Fee = decimal.NewFromInt(10)
+
+		// The payment fee was raised after the authorization
+		feeRuleRepo := mocks.NewMockFeeRuleRepository(ctrl)
+		feeRuleRepo.EXPECT().FindApplicable(gomock.Any(), entities.TransactionTypePayment, gomock.Any()).
+			Return(&entities.FeeRule{ID: uuid.New(), TransactionType: entities.TransactionTypePayment, FlatFee: decimal.NewFromInt(500)}, nil).AnyTimes()
+		raisedFeeUseCase := usecase.NewTransactionUseCase(mockTransactionRepo, mockUserRepo, mockLedgerRepo, mockTxManager, mockPaymentGateway, usecase.NewFeeUseCase(feeRuleRepo, cfg), newDefaultLimitUseCase(ctrl, mockTransactionRepo, mockUserRepo, cfg), cfg)
+
+		// Mock expectations
+		mockTransactionRepo.EXPECT().GetByIDForUpdate(gomock.Any(), authorization.ID).Return(authorization, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), payer.ID).Return(payer, nil)
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), merchant.ID).Return(merchant, nil)
+		mockUserRepo.EXPECT().ReleaseHold(gomock.Any(), payer.ID, decimalEq{decimal.NewFromInt(410)}).Return(nil)
+		mockUserRepo.EXPECT().SubtractBalance(gomock.Any(), payer.ID, decimalEq{decimal.RequireFromString("256.25")}).Return(nil)
+		mockUserRepo.EXPECT().AddBalance(gomock.Any(), merchant.ID, decimalEq{decimal.NewFromInt(250)}).Return(nil)
+		mockLedgerRepo.EXPECT().CreateEntry(gomock.Any(), gomock.Any()).Return(nil)
+		mockTransactionRepo.EXPECT().Update(gomock.Any(), authorization).Return(nil)
+
+		// Execute
+		response, err := raisedFeeUseCase.CapturePayment(context.Background(), merchant.ID, authorization.ID, entities.CapturePaymentRequest{Amount: decimal.NewFromInt(250)})
+
+		// Assert
+		require.NoError(t, err)
+		assert.True(t, response.Fee.Equal(decimal.RequireFromString("6.25")))
+		assert.Equal(t, "10", authorization.Metadata[entities.MetadataAuthorizedFee])
+	})
+
+	t.Run("capture above the authorized amount is refused", func(t *testing.T) {
+		payer := &entities.User{ID: uuid.New()}
+		merchant := &entities.User{ID: uuid.New()}
+		authorization := newAuthorization(payer, merchant, 400)
+
+		// Mock expectations
+		mockTransactionRepo.EXPECT().GetByIDForUpdate(gomock.Any(), authorization.ID).Return(authorization, nil)
+
+		// Execute
+		_, err := transactionUseCase.CapturePayment(context.Background(), merchant.ID, authorization.ID, entities.CapturePaymentRequest{Amount: decimal.NewFromInt(401)})
+
+		// Assert
+		require.Error(t, err)
+		assert.True(t, customerrors.IsValidationError(err))
+		assert.Equal(t, entities.TransactionStatusAuthorized, authorization.Status)
+	})
+
+	t.Run("only the recipient can capture", func(t *testing.T) {
+		payer := &entities.User{ID: uuid.New()}
+		merchant := &entities.User{ID: uuid.New()}
+		authorization := newAuthorization(payer, merchant, 400)
+
+		// Mock expectations
+		mockTransactionRepo.EXPECT().GetByIDForUpdate(gomock.Any(), authorization.ID).Return(authorization, nil)
+
+		// Execute
+		_, err := transactionUseCase.CapturePayment(context.Background(), payer.ID, authorization.ID, entities.CapturePaymentRequest{})
+
+		// Assert
+		require.Error(t, err)
+		assert.True(t, customerrors.IsNotFoundError(err))
+	})
+
+	t.Run("void releases the hold", func(t *testing.T) {
+		payer := &entities.User{ID: uuid.New()}
+		merchant := &entities.User{ID: uuid.New()}
+		authorization := newAuthorization(payer, merchant, 400)
+
+		// Mock expectations
+		mockTransactionRepo.EXPECT().GetByIDForUpdate(gomock.Any(), authorization.ID).Return(authorization, nil)
+		mockUserRepo.EXPECT().ReleaseHold(gomock.Any(), payer.ID, decimalEq{decimal.NewFromInt(400)}).Return(nil)
+		mockTransactionRepo.EXPECT().Update(gomock.Any(), authorization).Return(nil)
+
+		// Execute
+		response, err := transactionUseCase.VoidPayment(context.Background(), merchant.ID, authorization.ID)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, entities.TransactionStatusCancelled, response.Status)
+		assert.Equal(t, entities.CancelReasonVoided, authorization.Metadata[entities.MetadataCancelReason])
+	})
+
+	t.Run("expired authorization releases the hold", func(t *testing.T) {
+		now := time.Now()
+		payer := &entities.User{ID: uuid.New()}
+		merchant := &entities.User{ID: uuid.New()}
+		authorization := newAuthorization(payer, merchant, 400)
+
+		// Mock expectations
+		mockTransactionRepo.EXPECT().GetExpiredTransactions(gomock.Any(), now, 100).Return([]*entities.Transaction{authorization}, nil)
+		mockTransactionRepo.EXPECT().GetByReferenceForUpdate(gomock.Any(), authorization.Reference).Return(authorization, nil)
+		mockUserRepo.EXPECT().ReleaseHold(gomock.Any(), payer.ID, decimalEq{decimal.NewFromInt(400)}).Return(nil)
+		mockTransactionRepo.EXPECT().Update(gomock.Any(), authorization).Return(nil)
+
+		// Execute
+		results, err := transactionUseCase.ExpireTransactions(context.Background(), now, 100)
+
+		// Assert
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.True(t, results[0].Updated)
+		assert.Equal(t, entities.TransactionStatusCancelled, authorization.Status)
+		assert.Equal(t, entities.CancelReasonExpired, authorization.Metadata[entities.MetadataCancelReason])
+	})
+}
+
+func TestTransactionUseCase_RebuildWalletBalance(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Mock repositories
+	mockTransactionRepo := mocks.NewMockTransactionRepository(ctrl)
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockLedgerRepo := mocks.NewMockLedgerRepository(ctrl)
+	mockTxManager := newPassThroughTxManager(ctrl)
+	mockPaymentGateway := mocks.NewMockPaymentGateway(ctrl)
+
+	// Create use case
+	cfg := newTransactionTestConfig()
+	transactionUseCase := usecase.NewTransactionUseCase(mockTransactionRepo, mockUserRepo, mockLedgerRepo, mockTxManager, mockPaymentGateway, newDefaultFeeUseCase(ctrl, cfg), newDefaultLimitUseCase(ctrl, mockTransactionRepo, mockUserRepo, cfg), cfg)
+
+	t.Run("balance is rebuilt under the user lock", func(t *testing.T) {
+		user := &entities.User{ID: uuid.New(), Balance: decimal.NewFromInt(900), HeldBalance: decimal.NewFromInt(200)}
+
+		// Mock expectations: the user row is locked before the ledger is summed
+		gomock.InOrder(
+			mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), user.ID).Return(user, nil),
+			mockLedgerRepo.EXPECT().RebuildWalletBalance(gomock.Any(), user.ID).Return(decimal.NewFromInt(1000), nil),
+		)
+
+		// Execute
+		response, err := transactionUseCase.RebuildWalletBalance(context.Background(), user.ID)
+
+		// Assert
+		require.NoError(t, err)
+		assert.True(t, response.Balance.Equal(decimal.NewFromInt(1000)))
+		assert.True(t, response.AvailableBalance.Equal(decimal.NewFromInt(800)))
+		assert.True(t, response.HeldBalance.Equal(decimal.NewFromInt(200)))
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		userID := uuid.New()
+
+		// Mock expectations
+		mockUserRepo.EXPECT().GetByIDForUpdate(gomock.Any(), userID).Return(nil, customerrors.NewNotFoundError("User not found"))
+
+		// Execute
+		response, err := transactionUseCase.RebuildWalletBalance(context.Background(), userID)
+
+		// Assert
+		require.Error(t, err)
+		assert.Nil(t, response)
+		assert.True(t, customerrors.IsNotFoundError(err))
+	})
+}
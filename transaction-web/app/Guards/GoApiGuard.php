@@ -2,6 +2,7 @@
 
 namespace App\Guards;
 
+use App\Services\GoTokenVerifier;
 use App\Services\GoTransactionService;
 use Illuminate\Auth\GuardHelpers;
 use Illuminate\Contracts\Auth\Guard;
@@ -16,13 +17,15 @@ class GoApiGuard implements Guard
 
     protected $request;
     protected $goService;
+    protected $tokenVerifier;
     protected $user;
 
-    public function __construct(UserProvider $provider, Request $request, GoTransactionService $goService)
+    public function __construct(UserProvider $provider, Request $request, GoTransactionService $goService, GoTokenVerifier $tokenVerifier)
     {
         $this->provider = $provider;
         $this->request = $request;
         $this->goService = $goService;
+        $this->tokenVerifier = $tokenVerifier;
     }
 
     /**
@@ -40,8 +43,13 @@ public function user()
             return null;
         }
 
+        // Check the signature against the published key set before trusting the token
+        if ($this->tokenVerifier->verify($token) === null) {
+            return null;
+        }
+
         try {
-            // Verify token with Go API
+            // Load the user with Go API, which also refuses ended sessions
             $userData = $this->goService->verifyToken($token);
             
             if ($userData) {
@@ -4,6 +4,7 @@
 
 use App\Guards\GoApiGuard;
 use App\Models\Admin;
+use App\Services\GoTokenVerifier;
 use App\Services\GoTransactionService;
 use Illuminate\Foundation\Support\Providers\AuthServiceProvider as ServiceProvider;
 use Illuminate\Support\Facades\Auth;
@@ -34,8 +35,9 @@ public function boot()
             $provider = Auth::createUserProvider($config['provider'] ?? null);
             $request = $app->make(Request::class);
             $goService = $app->make(GoTransactionService::class);
+            $tokenVerifier = $app->make(GoTokenVerifier::class);
 
-            return new GoApiGuard($provider, $request, $goService);
+            return new GoApiGuard($provider, $request, $goService, $tokenVerifier);
         });
 
         // Register custom user provider for admin
@@ -0,0 +1,188 @@
+<?php
+
+namespace App\Services;
+
+use Illuminate\Support\Facades\Cache;
+use Illuminate\Support\Facades\Http;
+use Illuminate\Support\Facades\Log;
+
+/**
+ * Verifies Go service access tokens with the public keys it publishes as a JWKS.
+ *
+ * The key set is cached; a token naming an unknown kid refetches it, at most once per
+ * cooldown, so a key rotation is picked up without trusting a single static key.
+ */
+class GoTokenVerifier
+{
+    private const CACHE_KEY = 'go_transaction.jwks';
+    private const REFRESH_LOCK_KEY = 'go_transaction.jwks.refresh';
+    private const CACHE_TTL = 3600;
+    private const REFRESH_COOLDOWN = 10;
+
+    private string $jwksUrl;
+
+    public function __construct()
+    {
+        $baseUrl = rtrim(env('GO_TRANSACTION_SERVICE_URL', 'http://localhost:8080'), '/');
+        $this->jwksUrl = $baseUrl . '/.well-known/jwks.json';
+    }
+
+    /**
+     * Verify the signature and lifetime of a token and return its claims.
+     */
+    public function verify(string $token): ?array
+    {
+        $parts = explode('.', $token);
+        if (count($parts) !== 3) {
+            return null;
+        }
+
+        [$encodedHeader, $encodedPayload, $encodedSignature] = $parts;
+        $header = json_decode($this->base64UrlDecode($encodedHeader), true);
+        $claims = json_decode($this->base64UrlDecode($encodedPayload), true);
+        $signature = $this->base64UrlDecode($encodedSignature);
+
+        if (!is_array($header) || !is_array($claims) || empty($header['kid']) || empty($header['alg'])) {
+            return null;
+        }
+
+        $key = $this->findKey($header['kid']);
+        if ($key === null || ($key['alg'] ?? null) !== $header['alg']) {
+            Log::warning('Go service token signed with an unknown key', ['kid' => $header['kid']]);
+            return null;
+        }
+
+        if (!$this->verifySignature($key, $encodedHeader . '.' . $encodedPayload, $signature)) {
+            return null;
+        }
+
+        $now = time();
+        if (!isset($claims['exp']) || $claims['exp'] <= $now) {
+            return null;
+        }
+        if (isset($claims['nbf']) && $claims['nbf'] > $now) {
+            return null;
+        }
+
+        return $claims;
+    }
+
+    /**
+     * Find a key by kid, refetching the key set when the kid is not cached.
+     */
+    private function findKey(string $kid): ?array
+    {
+        $keys = Cache::get(self::CACHE_KEY);
+        if (is_array($keys) && isset($keys[$kid])) {
+            return $keys[$kid];
+        }
+
+        // The service may have rotated; only one refetch per cooldown across all requests
+        if (!is_array($keys) || Cache::add(self::REFRESH_LOCK_KEY, true, self::REFRESH_COOLDOWN)) {
+            $keys = $this->fetchKeys();
+            if ($keys !== null) {
+                Cache::put(self::CACHE_KEY, $keys, self::CACHE_TTL);
+            }
+        }
+
+        return $keys[$kid] ?? null;
+    }
+
+    /**
+     * Fetch the published keys, indexed by kid.
+     */
+    private function fetchKeys(): ?array
+    {
+        try {
+            $response = Http::acceptJson()->timeout(10)->get($this->jwksUrl);
+
+            if (!$response->successful()) {
+                Log::error('Failed to fetch Go service JWKS', [
+                    'status' => $response->status(),
+                ]);
+                return null;
+            }
+
+            $keys = [];
+            foreach ($response->json('keys', []) as $key) {
+                if (!empty($key['kid'])) {
+                    $keys[$key['kid']] = $key;
+                }
+            }
+
+            return $keys;
+        } catch (\Exception $e) {
+            Log::error('Error fetching Go service JWKS', [
+                'error' => $e->getMessage(),
+            ]);
+            return null;
+        }
+    }
+
+    private function verifySignature(array $key, string $data, string $signature): bool
+    {
+        switch ($key['alg']) {
+            case 'RS256':
+                if (empty($key['n']) || empty($key['e'])) {
+                    return false;
+                }
+                $pem = $this->rsaPublicKeyPem($this->base64UrlDecode($key['n']), $this->base64UrlDecode($key['e']));
+                return openssl_verify($data, $signature, $pem, OPENSSL_ALGO_SHA256) === 1;
+
+            case 'EdDSA':
+                $publicKey = $this->base64UrlDecode($key['x'] ?? '');
+                if (strlen($publicKey) !== SODIUM_CRYPTO_SIGN_PUBLICKEYBYTES || strlen($signature) !== SODIUM_CRYPTO_SIGN_BYTES) {
+                    return false;
+                }
+                return sodium_crypto_sign_verify_detached($signature, $data, $publicKey);
+
+            default:
+                return false;
+        }
+    }
+
+    /**
+     * Encode an RSA modulus and exponent as a PEM SubjectPublicKeyInfo.
+     */
+    private function rsaPublicKeyPem(string $modulus, string $exponent): string
+    {
+        $rsaPublicKey = $this->derSequence($this->derInteger($modulus) . $this->derInteger($exponent));
+        // rsaEncryption OID 1.2.840.113549.1.1.1 with NULL parameters
+        $algorithm = $this->derSequence("\x06\x09\x2a\x86\x48\x86\xf7\x0d\x01\x01\x01\x05\x00");
+        $bitString = "\x03" . $this->derLength(strlen($rsaPublicKey) + 1) . "\x00" . $rsaPublicKey;
+
+        return "-----BEGIN PUBLIC KEY-----\n"
+            . chunk_split(base64_encode($this->derSequence($algorithm . $bitString)), 64, "\n")
+            . "-----END PUBLIC KEY-----\n";
+    }
+
+    private function derSequence(string $content): string
+    {
+        return "\x30" . $this->derLength(strlen($content)) . $content;
+    }
+
+    private function derInteger(string $bytes): string
+    {
+        $bytes = ltrim($bytes, "\x00");
+        if ($bytes === '' || ord($bytes[0]) > 0x7f) {
+            $bytes = "\x00" . $bytes;
+        }
+
+        return "\x02" . $this->derLength(strlen($bytes)) . $bytes;
+    }
+
+    private function derLength(int $length): string
+    {
+        if ($length < 0x80) {
+            return chr($length);
+        }
+
+        $bytes = ltrim(pack('N', $length), "\x00");
+        return chr(0x80 | strlen($bytes)) . $bytes;
+    }
+
+    private function base64UrlDecode(string $value): string
+    {
+        return (string) base64_decode(strtr($value, '-_', '+/') . str_repeat('=', (4 - strlen($value) % 4) % 4));
+    }
+}
@@ -155,7 +155,7 @@ public function approveTransaction(string $transactionId, int $approvedBy): bool
         try {
             $response = Http::withHeaders($this->headers)
                 ->timeout(30)
-                ->post($this->baseUrl . "/api/v1/transactions/{$transactionId}/approve", [
+                ->post($this->baseUrl . "/api/v1/admin/transactions/{$transactionId}/approve", [
                     'approved_by' => $approvedBy,
                     'approved_at' => now()->toISOString(),
                 ]);
@@ -194,7 +194,7 @@ public function rejectTransaction(string $transactionId, int $rejectedBy, string
         try {
             $response = Http::withHeaders($this->headers)
                 ->timeout(30)
-                ->post($this->baseUrl . "/api/v1/transactions/{$transactionId}/reject", [
+                ->post($this->baseUrl . "/api/v1/admin/transactions/{$transactionId}/reject", [
                     'rejected_by' => $rejectedBy,
                     'rejected_at' => now()->toISOString(),
                     'reason' => $reason,